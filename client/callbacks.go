@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/url"
+)
+
+// CreateCallbackAction makes a webhook call back after every request it
+// receives.
+func (c *Client) CreateCallbackAction(ctx context.Context, webhookID string, in CallbackActionRequest) (*CallbackAction, error) {
+	var out CallbackAction
+	if err := c.do(ctx, createAction, []string{webhookID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListCallbackActions returns the callback actions of a webhook, oldest first.
+func (c *Client) ListCallbackActions(ctx context.Context, webhookID string) ([]CallbackAction, error) {
+	var out []CallbackAction
+	if err := c.do(ctx, listActions, []string{webhookID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetCallbackAction returns one callback action.
+func (c *Client) GetCallbackAction(ctx context.Context, webhookID, actionID string) (*CallbackAction, error) {
+	var out CallbackAction
+	if err := c.do(ctx, getAction, []string{webhookID, actionID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateCallbackAction replaces a callback action. Callbacks already
+// scheduled are sent as the action was.
+func (c *Client) UpdateCallbackAction(ctx context.Context, webhookID, actionID string, in CallbackActionRequest) (*CallbackAction, error) {
+	var out CallbackAction
+	if err := c.do(ctx, updateAction, []string{webhookID, actionID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteCallbackAction deletes a callback action and cancels its scheduled
+// callbacks.
+func (c *Client) DeleteCallbackAction(ctx context.Context, webhookID, actionID string) error {
+	return c.do(ctx, deleteAction, []string{webhookID, actionID}, nil, nil, nil)
+}
+
+// ListCallbacks returns the latest callbacks of a webhook, newest first, or
+// all callbacks of one received request when requestID is set.
+func (c *Client) ListCallbacks(ctx context.Context, webhookID, requestID string) ([]Callback, error) {
+	var q url.Values
+	if requestID != "" {
+		q = url.Values{"request_id": {requestID}}
+	}
+	var out []Callback
+	if err := c.do(ctx, listCallbacks, []string{webhookID}, q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetCallback returns one callback.
+func (c *Client) GetCallback(ctx context.Context, webhookID, callbackID string) (*Callback, error) {
+	return c.callbackCall(ctx, getCallback, webhookID, callbackID)
+}
+
+// CancelCallback stops a scheduled callback from being sent.
+func (c *Client) CancelCallback(ctx context.Context, webhookID, callbackID string) (*Callback, error) {
+	return c.callbackCall(ctx, cancelCallback, webhookID, callbackID)
+}
+
+func (c *Client) callbackCall(ctx context.Context, ep endpoint, webhookID, callbackID string) (*Callback, error) {
+	var out Callback
+	if err := c.do(ctx, ep, []string{webhookID, callbackID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
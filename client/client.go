@@ -0,0 +1,234 @@
+// Package client is a typed Go client for the webhook-tester REST API.
+//
+//	c := client.New("https://webhook-tester.example.com", os.Getenv("WEBHOOK_TESTER_API_KEY"))
+//	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders"})
+//
+// Every endpoint the client calls is listed in endpoints, which the package
+// tests check against docs/swagger.json so the client cannot drift from the
+// server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// endpoint is an API route relative to the /api base path.
+type endpoint struct {
+	method string
+	path   string
+}
+
+var (
+	listWebhooks   = endpoint{http.MethodGet, "/webhooks"}
+	createWebhook  = endpoint{http.MethodPost, "/webhooks"}
+	getWebhook     = endpoint{http.MethodGet, "/webhooks/{id}"}
+	updateWebhook  = endpoint{http.MethodPut, "/webhooks/{id}"}
+	patchWebhook   = endpoint{http.MethodPatch, "/webhooks/{id}"}
+	deleteWebhook  = endpoint{http.MethodDelete, "/webhooks/{id}"}
+	listRequests   = endpoint{http.MethodGet, "/webhooks/{id}/requests"}
+	deleteRequests = endpoint{http.MethodDelete, "/webhooks/{id}/requests"}
+	getRequest     = endpoint{http.MethodGet, "/webhooks/{id}/requests/{requestID}"}
+	deleteRequest  = endpoint{http.MethodDelete, "/webhooks/{id}/requests/{requestID}"}
+	getSnippets    = endpoint{http.MethodGet, "/webhooks/{id}/requests/{requestID}/snippets"}
+	replayRequest  = endpoint{http.MethodPost, "/webhooks/{id}/requests/{requestID}/replays"}
+	listReplays    = endpoint{http.MethodGet, "/webhooks/{id}/requests/{requestID}/replays"}
+	getReplay      = endpoint{http.MethodGet, "/webhooks/{id}/requests/{requestID}/replays/{attemptID}"}
+	startJob       = endpoint{http.MethodPost, "/webhooks/{id}/replay-jobs"}
+	listJobs       = endpoint{http.MethodGet, "/webhooks/{id}/replay-jobs"}
+	getJob         = endpoint{http.MethodGet, "/webhooks/{id}/replay-jobs/{jobID}"}
+	listJobItems   = endpoint{http.MethodGet, "/webhooks/{id}/replay-jobs/{jobID}/items"}
+	pauseJob       = endpoint{http.MethodPost, "/webhooks/{id}/replay-jobs/{jobID}/pause"}
+	resumeJob      = endpoint{http.MethodPost, "/webhooks/{id}/replay-jobs/{jobID}/resume"}
+	cancelJob      = endpoint{http.MethodPost, "/webhooks/{id}/replay-jobs/{jobID}/cancel"}
+	compareRequest = endpoint{http.MethodPost, "/webhooks/{id}/requests/{requestID}/comparisons"}
+	listCompares   = endpoint{http.MethodGet, "/webhooks/{id}/requests/{requestID}/comparisons"}
+	getCompare     = endpoint{http.MethodGet, "/webhooks/{id}/requests/{requestID}/comparisons/{comparisonID}"}
+	startBatch     = endpoint{http.MethodPost, "/webhooks/{id}/comparison-batches"}
+	listBatches    = endpoint{http.MethodGet, "/webhooks/{id}/comparison-batches"}
+	getBatch       = endpoint{http.MethodGet, "/webhooks/{id}/comparison-batches/{batchID}"}
+	batchResults   = endpoint{http.MethodGet, "/webhooks/{id}/comparison-batches/{batchID}/results"}
+	sendEvent      = endpoint{http.MethodPost, "/webhooks/{id}/send"}
+	listTemplates  = endpoint{http.MethodGet, "/event-templates"}
+	startDelivery  = endpoint{http.MethodPost, "/webhooks/{id}/deliveries"}
+	listDeliveries = endpoint{http.MethodGet, "/webhooks/{id}/deliveries"}
+	getDelivery    = endpoint{http.MethodGet, "/webhooks/{id}/deliveries/{deliveryID}"}
+	listAttempts   = endpoint{http.MethodGet, "/webhooks/{id}/deliveries/{deliveryID}/attempts"}
+	cancelDelivery = endpoint{http.MethodPost, "/webhooks/{id}/deliveries/{deliveryID}/cancel"}
+	startLoadRun   = endpoint{http.MethodPost, "/webhooks/{id}/load-runs"}
+	listLoadRuns   = endpoint{http.MethodGet, "/webhooks/{id}/load-runs"}
+	getLoadRun     = endpoint{http.MethodGet, "/webhooks/{id}/load-runs/{runID}"}
+	cancelLoadRun  = endpoint{http.MethodPost, "/webhooks/{id}/load-runs/{runID}/cancel"}
+	createAction   = endpoint{http.MethodPost, "/webhooks/{id}/callback-actions"}
+	listActions    = endpoint{http.MethodGet, "/webhooks/{id}/callback-actions"}
+	getAction      = endpoint{http.MethodGet, "/webhooks/{id}/callback-actions/{actionID}"}
+	updateAction   = endpoint{http.MethodPut, "/webhooks/{id}/callback-actions/{actionID}"}
+	deleteAction   = endpoint{http.MethodDelete, "/webhooks/{id}/callback-actions/{actionID}"}
+	listCallbacks  = endpoint{http.MethodGet, "/webhooks/{id}/callbacks"}
+	getCallback    = endpoint{http.MethodGet, "/webhooks/{id}/callbacks/{callbackID}"}
+	cancelCallback = endpoint{http.MethodPost, "/webhooks/{id}/callbacks/{callbackID}/cancel"}
+	getScriptState = endpoint{http.MethodGet, "/webhooks/{id}/script-state"}
+	resetScript    = endpoint{http.MethodDelete, "/webhooks/{id}/script-state"}
+	streamRequests = endpoint{http.MethodGet, "/webhooks/{id}/stream"}
+	exportRequests = endpoint{http.MethodGet, "/webhooks/{id}/requests/export"}
+	importRequests = endpoint{http.MethodPost, "/webhooks/{id}/requests/import"}
+	exportSpec     = endpoint{http.MethodGet, "/spec"}
+	applySpec      = endpoint{http.MethodPost, "/spec/apply"}
+)
+
+// endpoints lists every route the client implements.
+var endpoints = []endpoint{
+	listWebhooks, createWebhook, getWebhook, updateWebhook, patchWebhook, deleteWebhook,
+	listRequests, deleteRequests, getRequest, deleteRequest, getSnippets, streamRequests,
+	replayRequest, listReplays, getReplay, startJob, listJobs, getJob, listJobItems, pauseJob, resumeJob, cancelJob,
+	compareRequest, listCompares, getCompare, startBatch, listBatches, getBatch, batchResults,
+	sendEvent, listTemplates, startDelivery, listDeliveries, getDelivery, listAttempts, cancelDelivery,
+	startLoadRun, listLoadRuns, getLoadRun, cancelLoadRun,
+	createAction, listActions, getAction, updateAction, deleteAction, listCallbacks, getCallback, cancelCallback,
+	getScriptState, resetScript,
+	exportRequests, importRequests, exportSpec, applySpec,
+}
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryWait  = 250 * time.Millisecond
+)
+
+// Client calls the webhook-tester API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	maxRetries int
+	retryWait  time.Duration
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetries sets how many times GET, PUT and DELETE requests are retried after a
+// 5xx response or a network error. Zero disables retries.
+func WithRetries(n int) Option {
+	return func(c *Client) { c.maxRetries = n }
+}
+
+// WithRetryWait sets the wait before the first retry; it doubles after each attempt.
+func WithRetryWait(d time.Duration) Option {
+	return func(c *Client) { c.retryWait = d }
+}
+
+// New creates a client for the server at baseURL, authenticating with apiKey.
+func New(baseURL, apiKey string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/") + "/api",
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: defaultMaxRetries,
+		retryWait:  defaultRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// do calls ep, filling its path parameters from params in order. A non-nil
+// in is sent as JSON and a non-nil out is decoded from the response.
+func (c *Client) do(ctx context.Context, ep endpoint, params []string, query url.Values, in, out any) error {
+	u := c.baseURL + expand(ep.path, params)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+	}
+
+	// POST and PATCH aren't idempotent, so only retry the other methods
+	retries := 0
+	if ep.method != http.MethodPost && ep.method != http.MethodPatch {
+		retries = c.maxRetries
+	}
+	wait := c.retryWait
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, ep.method, u, body)
+		if err == nil && resp.StatusCode < 500 {
+			defer resp.Body.Close()
+			return decode(resp, out)
+		}
+		if err == nil {
+			err = newAPIError(resp)
+			resp.Body.Close()
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, u string, body []byte) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.httpClient.Do(req)
+}
+
+func decode(resp *http.Response, out any) error {
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// expand replaces each {param} in path with the next escaped value.
+func expand(path string, params []string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, "{") && len(params) > 0 {
+			parts[i] = url.PathEscape(params[0])
+			params = params[1:]
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,1692 @@
+package client_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"webhook-tester/client"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/outbound"
+	"webhook-tester/internal/routers"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/store"
+	"webhook-tester/spec"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+type noopRecorder struct{}
+
+func (noopRecorder) IncWebhooksCreated()        {}
+func (noopRecorder) IncWebhookRequest(_ string) {}
+func (noopRecorder) IncSignUp()                 {}
+func (noopRecorder) IncLogin()                  {}
+
+// newServer serves the real API router over an in-memory store.
+func newServer(t *testing.T) (*httptest.Server, *store.MemoryDB) {
+	mem := store.NewMemoryDB()
+	users := store.NewMemoryUserRepo(mem)
+	require.NoError(t, users.Create(&models.User{Email: "ada@example.com", APIKey: "key"}))
+	require.NoError(t, users.Create(&models.User{Email: "bob@example.com", APIKey: "other"}))
+
+	webhookSvc := service.NewWebhookService(store.NewMemoryWebhookRepo(mem))
+	reqSvc := service.NewWebhookRequestService(store.NewMemoryWebhookRequestRepo(mem))
+	authSvc := service.NewAuthService(users, sessions.NewCookieStore([]byte("secret")))
+	retentionSvc := service.NewRetentionService(store.NewMemoryWebhookRepo(mem), store.NewMemoryWebhookRequestRepo(mem), users, 0)
+	// replay targets are httptest servers on loopback, which is blocked by default
+	loopback := outbound.Policy{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}}
+	replaySvc := service.NewReplayService(store.NewMemoryReplayAttemptRepo(mem), store.NewMemoryWebhookRepo(mem), outbound.New(loopback))
+	logger := log.New(io.Discard, "", 0)
+	jobSvc := service.NewReplayJobService(store.NewMemoryReplayJobRepo(mem), store.NewMemoryWebhookRequestRepo(mem), replaySvc, logger)
+	comparisonSvc := service.NewComparisonService(store.NewMemoryComparisonRepo(mem), store.NewMemoryWebhookRequestRepo(mem), replaySvc, logger)
+	senderSvc := service.NewSenderService(store.NewMemoryWebhookRequestRepo(mem), replaySvc, retentionSvc, logger)
+	deliverySvc := service.NewDeliveryService(store.NewMemoryDeliveryRepo(mem), senderSvc, replaySvc, logger)
+	loadSvc := service.NewLoadService(store.NewMemoryLoadRunRepo(mem), store.NewMemoryWebhookRequestRepo(mem), replaySvc, logger)
+	callbackSvc := service.NewCallbackService(store.NewMemoryCallbackRepo(mem), replaySvc, logger)
+	scriptSvc := service.NewScriptService(store.NewMemoryScriptStateRepo(mem))
+
+	r := chi.NewRouter()
+	r.Mount("/api", routers.NewApiRouter(webhookSvc, reqSvc, authSvc, retentionSvc, replaySvc, jobSvc, comparisonSvc, senderSvc, deliverySvc, loadSvc, callbackSvc, scriptSvc, logger, noopRecorder{}))
+	r.Mount("/webhooks", routers.NewWebhookRouter(webhookSvc, authSvc, retentionSvc, callbackSvc, scriptSvc, logger, noopRecorder{}))
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, mem
+}
+
+func TestWebhookLifecycle(t *testing.T) {
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	created, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders", ContentType: "text/plain"})
+	require.NoError(t, err)
+	assert.Equal(t, "orders", created.Title)
+	assert.Equal(t, http.StatusOK, created.ResponseCode)
+
+	got, err := c.GetWebhook(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+
+	updated, err := c.UpdateWebhook(ctx, created.ID, client.UpdateWebhookRequest{Title: "payments", ResponseCode: 202})
+	require.NoError(t, err)
+	assert.Equal(t, "payments", updated.Title)
+	assert.Equal(t, 202, updated.ResponseCode)
+
+	list, err := c.ListWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	_, err = client.New(srv.URL, "other").GetWebhook(ctx, created.ID)
+	assert.True(t, errors.Is(err, client.ErrNotFound), "other users can't see the webhook: %v", err)
+
+	require.NoError(t, c.DeleteWebhook(ctx, created.ID))
+	_, err = c.GetWebhook(ctx, created.ID)
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+}
+
+func TestRequestPagination(t *testing.T) {
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders"})
+	require.NoError(t, err)
+	requests := store.NewMemoryWebhookRequestRepo(mem)
+	base := time.Now().UTC()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, requests.Insert(&models.WebhookRequest{
+			ID:         fmt.Sprintf("r%d", i),
+			WebhookID:  hook.ID,
+			Method:     http.MethodPost,
+			ReceivedAt: base.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	page, err := c.ListRequests(ctx, hook.ID, client.ListOptions{Page: 1, PerPage: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), page.Total)
+	assert.True(t, page.HasNext())
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, "r4", page.Data[0].ID)
+
+	var ids []string
+	for wr, err := range c.Requests(ctx, hook.ID, 2) {
+		require.NoError(t, err)
+		ids = append(ids, wr.ID)
+	}
+	assert.Equal(t, []string{"r4", "r3", "r2", "r1", "r0"}, ids)
+
+	wr, err := c.GetRequest(ctx, hook.ID, "r2")
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, wr.Method)
+
+	require.NoError(t, c.DeleteRequest(ctx, hook.ID, "r2"))
+	_, err = c.GetRequest(ctx, hook.ID, "r2")
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+
+	require.NoError(t, c.DeleteRequests(ctx, hook.ID))
+	page, err = c.ListRequests(ctx, hook.ID, client.ListOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, page.Total)
+}
+
+func TestTypedErrors(t *testing.T) {
+	srv, _ := newServer(t)
+	ctx := context.Background()
+
+	_, err := client.New(srv.URL, "wrong").ListWebhooks(ctx)
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, "unauthorized", apiErr.Code)
+	assert.Equal(t, "invalid API key", apiErr.Message)
+	assert.True(t, errors.Is(err, client.ErrUnauthorized))
+
+	_, err = client.New(srv.URL, "key").GetWebhook(ctx, "missing")
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "not_found", apiErr.Code)
+	assert.Equal(t, "webhook not found", apiErr.Message)
+}
+
+func TestRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, `{"error":"try again"}`, http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer srv.Close()
+
+	c := client.New(srv.URL, "key", client.WithRetryWait(time.Millisecond))
+	_, err := c.ListWebhooks(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+
+	calls.Store(-10)
+	_, err = c.ListWebhooks(context.Background())
+	assert.True(t, errors.Is(err, client.ErrServer))
+	assert.Equal(t, int32(-6), calls.Load(), "gives up after the configured retries")
+
+	calls.Store(0)
+	_, err = c.CreateWebhook(context.Background(), client.CreateWebhookRequest{})
+	assert.True(t, errors.Is(err, client.ErrServer))
+	assert.Equal(t, int32(1), calls.Load(), "creates are not retried")
+}
+
+func TestContextCancellation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := client.New(srv.URL, "key", client.WithRetries(100), client.WithRetryWait(20*time.Millisecond))
+	_, err := c.ListWebhooks(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestTail(t *testing.T) {
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders"})
+	require.NoError(t, err)
+
+	for _, err = range client.New(srv.URL, "other").Tail(ctx, hook.ID) {
+		break
+	}
+	assert.True(t, errors.Is(err, client.ErrNotFound), "tailing another user's webhook: %v", err)
+
+	// send once the stream is subscribed; retry until an event arrives
+	go func() {
+		for ctx.Err() == nil {
+			resp, err := http.Post(srv.URL+"/webhooks/"+hook.ID+"?n=1", "application/json", strings.NewReader(`{"a":1}`))
+			if err == nil {
+				resp.Body.Close()
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+	for wr, err := range c.Tail(ctx, hook.ID) {
+		require.NoError(t, err)
+		assert.Equal(t, http.MethodPost, wr.Method)
+		assert.Equal(t, `{"a":1}`, wr.Body)
+		assert.Equal(t, "1", wr.Query["n"])
+		break
+	}
+}
+
+func TestPatchAndPutSemantics(t *testing.T) {
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{
+		Title:           "orders",
+		ResponseCode:    201,
+		ResponseDelay:   100,
+		Payload:         `{"ok":true}`,
+		ResponseHeaders: map[string]string{"X-Test": "1"},
+		RetentionCount:  10,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-Test": "1"}, hook.ResponseHeaders)
+	assert.Equal(t, uint(10), hook.RetentionCount)
+
+	// omitted fields are kept, null resets to the default
+	hook, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{
+		ResponseDelay: client.Set[uint](0),
+		Payload:       client.Null[string](),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "orders", hook.Title)
+	assert.Equal(t, 201, hook.ResponseCode)
+	assert.Zero(t, hook.ResponseDelay)
+	assert.Empty(t, hook.Payload)
+	assert.Equal(t, map[string]string{"X-Test": "1"}, hook.ResponseHeaders)
+
+	hook, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{ResponseCode: client.Null[int]()})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, hook.ResponseCode)
+
+	// PUT replaces everything
+	hook, err = c.UpdateWebhook(ctx, hook.ID, client.UpdateWebhookRequest{Title: "payments"})
+	require.NoError(t, err)
+	assert.Equal(t, "payments", hook.Title)
+	assert.Equal(t, http.StatusOK, hook.ResponseCode)
+	assert.Empty(t, hook.ResponseHeaders)
+	assert.Zero(t, hook.RetentionCount)
+}
+
+func TestValidationErrors(t *testing.T) {
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	_, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{
+		ResponseCode:    42,
+		ResponseDelay:   60_000,
+		ContentType:     "not a type",
+		ResponseHeaders: map[string]string{"Bad Header": "x", "Content-Length": "1", "X-Ok": "a\r\nb"},
+	})
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Equal(t, "validation_failed", apiErr.Code)
+	assert.True(t, errors.Is(err, client.ErrBadRequest))
+	assert.ElementsMatch(t, []string{
+		"response_code", "response_delay", "content_type",
+		"response_headers.Bad Header", "response_headers.Content-Length", "response_headers.X-Ok",
+	}, keys(apiErr.Fields))
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "ok"})
+	require.NoError(t, err)
+	_, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{ResponseCode: client.Set(1000)})
+	require.True(t, errors.As(err, &apiErr))
+	assert.Contains(t, apiErr.Fields, "response_code")
+}
+
+func keys(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func TestApplySpec(t *testing.T) {
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	stale, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "stale"})
+	require.NoError(t, err)
+
+	f := &spec.File{Version: spec.Version, Webhooks: []spec.Webhook{
+		{ID: "orders", Title: "Orders", ResponseCode: 202, ResponseHeaders: map[string]string{"X-Env": "test"}},
+		{Title: "Payments", RetentionCount: 10},
+	}}
+
+	plan, err := c.ApplySpec(ctx, f, client.ApplyOptions{Prune: true, DryRun: true})
+	require.NoError(t, err)
+	assert.False(t, plan.Applied)
+	assert.Equal(t, 2, plan.Count(spec.Create))
+	assert.Equal(t, 1, plan.Count(spec.Delete))
+	list, err := c.ListWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 1, "dry runs change nothing")
+
+	plan, err = c.ApplySpec(ctx, f, client.ApplyOptions{Prune: true})
+	require.NoError(t, err)
+	assert.True(t, plan.Applied)
+	_, err = c.GetWebhook(ctx, stale.ID)
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+	hook, err := c.GetWebhook(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, 202, hook.ResponseCode)
+
+	plan, err = c.ApplySpec(ctx, f, client.ApplyOptions{Prune: true})
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges(), "applying twice is a no-op: %+v", plan.Changes)
+
+	exported, err := c.ExportSpec(ctx)
+	require.NoError(t, err)
+	require.Len(t, exported.Webhooks, 2)
+	plan, err = c.ApplySpec(ctx, exported, client.ApplyOptions{Prune: true, DryRun: true})
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges(), "an export applies cleanly")
+
+	f.Webhooks[1].ResponseCode = 1000
+	_, err = c.ApplySpec(ctx, f, client.ApplyOptions{})
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Fields, "webhooks[1].response_code")
+
+	_, err = client.New(srv.URL, "other").ApplySpec(ctx, &spec.File{Version: spec.Version, Webhooks: []spec.Webhook{{ID: "orders"}}}, client.ApplyOptions{})
+	assert.True(t, errors.Is(err, client.ErrConflict), "ids are global: %v", err)
+
+	huge := &spec.File{Version: spec.Version, Webhooks: []spec.Webhook{{Title: "huge", Payload: strings.Repeat("x", 1<<20)}}}
+	_, err = c.ApplySpec(ctx, huge, client.ApplyOptions{})
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, apiErr.StatusCode)
+}
+
+// TestSpecRoundTrip recreates a fully configured webhook on another server from
+// its export.
+func TestSpecRoundTrip(t *testing.T) {
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{
+		Title:         "orders",
+		SigningScheme: "github",
+		SigningSecret: "s3cret",
+		Faults:        client.FaultConfig{ErrorPercent: 12.5, ErrorStatus: 503},
+		Script:        "def handle(request):\n    return {\"status\": 201}\n",
+	})
+	require.NoError(t, err)
+	disabled := false
+	_, err = c.CreateCallbackAction(ctx, hook.ID, client.CallbackActionRequest{Name: "status", URL: "http://localhost:1/status", Sign: true, Headers: map[string]string{"X-Order": `{{field "body.id"}}`}})
+	require.NoError(t, err)
+	_, err = c.CreateCallbackAction(ctx, hook.ID, client.CallbackActionRequest{Name: "audit", Enabled: &disabled, Method: "put", URLFrom: "body.callback_url"})
+	require.NoError(t, err)
+
+	exported, err := c.ExportSpec(ctx)
+	require.NoError(t, err)
+	require.Len(t, exported.Webhooks, 1)
+	sw := exported.Webhooks[0]
+	assert.Equal(t, "github", sw.SigningScheme)
+	assert.Empty(t, sw.SigningSecret, "secrets are never exported")
+	assert.Equal(t, spec.Faults{ErrorPercent: 12.5, ErrorStatus: 503}, sw.Faults)
+	assert.Contains(t, sw.Script, "def handle")
+	require.Len(t, sw.Callbacks, 2)
+	assert.Equal(t, "PUT", sw.Callbacks[1].Method)
+
+	plan, err := c.ApplySpec(ctx, exported, client.ApplyOptions{Prune: true})
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges(), "an export applies cleanly: %+v", plan.Changes)
+	stored, err := store.NewMemoryWebhookRepo(mem).Get(hook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", stored.SigningSecret, "applying without a secret keeps it")
+
+	other, otherMem := newServer(t)
+	oc := client.New(other.URL, "key")
+	_, err = oc.ApplySpec(ctx, exported, client.ApplyOptions{})
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr), "a new webhook needs its secret: %v", err)
+	assert.Contains(t, apiErr.Fields, "webhooks[0].signing_secret")
+
+	exported.Webhooks[0].SigningSecret = "${ORDERS_SECRET}"
+	_, err = oc.ApplySpec(ctx, exported, client.ApplyOptions{})
+	require.True(t, errors.As(err, &apiErr), "the server refuses unresolved references: %v", err)
+	assert.Contains(t, apiErr.Fields["webhooks[0].signing_secret"], "ORDERS_SECRET")
+
+	require.NoError(t, exported.ResolveSecrets(func(string) (string, bool) { return "s3cret", true }))
+	plan, err = oc.ApplySpec(ctx, exported, client.ApplyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.Count(spec.Create))
+	recreated, err := store.NewMemoryWebhookRepo(otherMem).Get(hook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", recreated.SigningSecret)
+
+	again, err := oc.ExportSpec(ctx)
+	require.NoError(t, err)
+	exported.Webhooks[0].SigningSecret = ""
+	assert.Equal(t, exported, again)
+
+	// callbacks are synced by name
+	exported.Webhooks[0].Callbacks = []spec.Callback{{Name: "audit", URL: "http://localhost:1/audit"}}
+	plan, err = oc.ApplySpec(ctx, exported, client.ApplyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"callbacks"}, plan.Changes[0].Fields)
+	actions, err := oc.ListCallbackActions(ctx, hook.ID)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "http://localhost:1/audit", actions[0].URL)
+	assert.True(t, actions[0].Enabled)
+}
+
+func TestExportRequests(t *testing.T) {
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders"})
+	require.NoError(t, err)
+	requests := store.NewMemoryWebhookRequestRepo(mem)
+	base := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	for i, method := range []string{"POST", "GET", "POST"} {
+		require.NoError(t, requests.Insert(&models.WebhookRequest{
+			ID: fmt.Sprintf("r%d", i), WebhookID: hook.ID, Method: method, ReceivedAt: base.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+
+	body, err := c.ExportRequests(ctx, hook.ID, client.ExportOptions{
+		Format: client.FormatJSONL, Methods: []string{"post"}, Until: base.Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	raw, err := io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], `"id":"r0"`)
+
+	body, err = c.ExportRequests(ctx, hook.ID, client.ExportOptions{})
+	require.NoError(t, err)
+	raw, _ = io.ReadAll(body)
+	body.Close()
+	assert.Contains(t, string(raw), `"version":"1.2"`, "HAR is the default")
+
+	body, err = c.ExportRequests(ctx, hook.ID, client.ExportOptions{Format: client.FormatPostman, IDs: []string{"r2", "r0"}, Name: "Paid orders"})
+	require.NoError(t, err)
+	var collection struct {
+		Info struct{ Name string }
+		Item []struct{ Name string }
+	}
+	require.NoError(t, json.NewDecoder(body).Decode(&collection))
+	body.Close()
+	assert.Equal(t, "Paid orders", collection.Info.Name)
+	require.Len(t, collection.Item, 2)
+	assert.Contains(t, collection.Item[0].Name, "(r0)")
+	assert.Contains(t, collection.Item[1].Name, "(r2)")
+
+	_, err = c.ExportRequests(ctx, hook.ID, client.ExportOptions{Format: "xml"})
+	assert.True(t, errors.Is(err, client.ErrBadRequest))
+	_, err = client.New(srv.URL, "other").ExportRequests(ctx, hook.ID, client.ExportOptions{})
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+}
+
+func TestImportRequests(t *testing.T) {
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	src, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "prod"})
+	require.NoError(t, err)
+	dst, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "fixtures"})
+	require.NoError(t, err)
+	base := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.NewMemoryWebhookRequestRepo(mem).Insert(&models.WebhookRequest{
+		ID: "r0", WebhookID: src.ID, Method: "POST", Body: `{"event":"paid"}`, Pinned: true, ReceivedAt: base,
+	}))
+
+	for _, format := range []string{client.FormatHAR, client.FormatJSONL} {
+		body, err := c.ExportRequests(ctx, src.ID, client.ExportOptions{Format: format})
+		require.NoError(t, err)
+		res, err := c.ImportRequests(ctx, dst.ID, body, "")
+		body.Close()
+		require.NoError(t, err, format)
+		assert.Equal(t, 1, res.Imported)
+	}
+
+	page, err := c.ListRequests(ctx, dst.ID, client.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 2)
+	for _, wr := range page.Data {
+		assert.Equal(t, "import", wr.Source)
+		assert.Equal(t, `{"event":"paid"}`, wr.Body)
+		assert.NotEqual(t, "r0", wr.ID, "imports get new IDs")
+		assert.True(t, base.Equal(wr.ReceivedAt))
+	}
+
+	body, err := c.ExportRequests(ctx, dst.ID, client.ExportOptions{Format: client.FormatJSONL, Source: "live"})
+	require.NoError(t, err)
+	raw, _ := io.ReadAll(body)
+	body.Close()
+	assert.Empty(t, raw)
+
+	_, err = c.ImportRequests(ctx, dst.ID, strings.NewReader(`{"method":"GET"}`+"\n"+`{"body":"no method"}`), client.FormatJSONL)
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "validation_failed", apiErr.Code)
+	assert.Contains(t, apiErr.Message, "entry 2")
+	page, err = c.ListRequests(ctx, dst.ID, client.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, page.Data, 2, "a failed import stores nothing")
+
+	_, err = c.ImportRequests(ctx, dst.ID, strings.NewReader("id,method\n"), "")
+	assert.True(t, errors.Is(err, client.ErrBadRequest))
+}
+
+func TestGetSnippets(t *testing.T) {
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders"})
+	require.NoError(t, err)
+	require.NoError(t, store.NewMemoryWebhookRequestRepo(mem).Insert(&models.WebhookRequest{
+		ID: "r1", WebhookID: hook.ID, Method: "POST", Body: `{"id":1}`,
+		Headers: datatypes.JSONMap{"Content-Type": "application/json"}, ReceivedAt: time.Now(),
+	}))
+
+	all, err := c.GetSnippets(ctx, hook.ID, "r1", client.SnippetOptions{})
+	require.NoError(t, err)
+	var langs []string
+	for _, s := range all {
+		langs = append(langs, s.Language)
+	}
+	assert.Equal(t, []string{"curl", "httpie", "go", "python", "node", "powershell"}, langs)
+
+	curl, err := c.GetSnippets(ctx, hook.ID, "r1", client.SnippetOptions{Languages: []string{"curl"}, Target: "http://localhost:8080/in"})
+	require.NoError(t, err)
+	require.Len(t, curl, 1)
+	assert.Equal(t, "curl -X POST 'http://localhost:8080/in' \\\n  -H 'Content-Type: application/json' \\\n  --data-raw '{\"id\":1}'", curl[0].Code)
+
+	_, err = c.GetSnippets(ctx, hook.ID, "r1", client.SnippetOptions{Languages: []string{"cobol"}})
+	assert.True(t, errors.Is(err, client.ErrBadRequest))
+	_, err = c.GetSnippets(ctx, hook.ID, "r1", client.SnippetOptions{Target: "not a url"})
+	assert.True(t, errors.Is(err, client.ErrBadRequest))
+	_, err = client.New(srv.URL, "other").GetSnippets(ctx, hook.ID, "r1", client.SnippetOptions{})
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+}
+
+func TestReplay(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(b)
+		w.Header().Set("X-Consumer", "v2")
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "queued")
+	}))
+	defer target.Close()
+
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders"})
+	require.NoError(t, err)
+	require.NoError(t, store.NewMemoryWebhookRequestRepo(mem).Insert(&models.WebhookRequest{
+		ID: "r1", WebhookID: hook.ID, Method: "POST", Body: `{"id":1}`,
+		Headers:    datatypes.JSONMap{"Content-Type": "application/json", "X-Signature": "stale", "Host": "tester"},
+		Query:      datatypes.JSONMap{"a": "1", "b": "2"},
+		ReceivedAt: time.Now(),
+	}))
+
+	body := `{"id":2}`
+	attempt, err := c.Replay(ctx, hook.ID, "r1", client.ReplayRequest{
+		Target:        target.URL + "/hooks?env=dev",
+		Method:        "put",
+		Headers:       map[string]string{"X-Replay": "yes"},
+		RemoveHeaders: []string{"x-signature"},
+		Query:         map[string]string{"a": "9"},
+		RemoveQuery:   []string{"b"},
+		Body:          &body,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, got.Method)
+	assert.Equal(t, "/hooks", got.URL.Path)
+	assert.Equal(t, "a=9&env=dev", got.URL.RawQuery)
+	assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	assert.Equal(t, "yes", got.Header.Get("X-Replay"))
+	assert.Empty(t, got.Header.Get("X-Signature"))
+	assert.Equal(t, `{"id":2}`, gotBody)
+
+	assert.Equal(t, "r1", attempt.RequestID)
+	assert.Equal(t, http.MethodPut, attempt.Method)
+	assert.Equal(t, target.URL+"/hooks?a=9&env=dev", attempt.URL)
+	assert.Equal(t, `{"id":2}`, attempt.RequestBody)
+	assert.Equal(t, http.StatusAccepted, attempt.StatusCode)
+	assert.Equal(t, "v2", attempt.ResponseHeaders["X-Consumer"])
+	assert.Equal(t, "queued", attempt.ResponseBody)
+	assert.Empty(t, attempt.Error)
+
+	// an unreachable target is recorded, not returned as an error
+	target.Close()
+	failed, err := c.Replay(ctx, hook.ID, "r1", client.ReplayRequest{Target: target.URL})
+	require.NoError(t, err)
+	assert.Zero(t, failed.StatusCode)
+	assert.NotEmpty(t, failed.Error)
+
+	list, err := c.ListReplays(ctx, hook.ID, "r1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	one, err := c.GetReplay(ctx, hook.ID, "r1", attempt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "queued", one.ResponseBody)
+
+	_, err = c.Replay(ctx, hook.ID, "r1", client.ReplayRequest{Target: "ftp://example.com", Headers: map[string]string{"Bad Name": "x"}})
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Fields, "target")
+	assert.Contains(t, apiErr.Fields, "headers.Bad Name")
+	_, err = c.GetReplay(ctx, hook.ID, "r1", "missing")
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+	_, err = client.New(srv.URL, "other").ListReplays(ctx, hook.ID, "r1")
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+}
+
+func TestReplayJob(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Query().Get("n"))
+		mu.Unlock()
+		if r.URL.Query().Get("n") == "3" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer target.Close()
+
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders"})
+	require.NoError(t, err)
+	start := time.Now().Add(-time.Hour)
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, store.NewMemoryWebhookRequestRepo(mem).Insert(&models.WebhookRequest{
+			ID: fmt.Sprintf("r%d", i), WebhookID: hook.ID, Method: "POST",
+			Query:      datatypes.JSONMap{"n": fmt.Sprint(i)},
+			ReceivedAt: start.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	job, err := c.StartReplayJob(ctx, hook.ID, client.ReplayJobRequest{
+		Target: target.URL, Mode: "rate", StopOnFailure: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, job.Total)
+	assert.Equal(t, 1, job.Concurrency)
+	require.Eventually(t, func() bool {
+		job, err = c.GetReplayJob(ctx, hook.ID, job.ID)
+		return err == nil && job.Done()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// sent oldest first, one at a time, stopping at the failure
+	assert.Equal(t, "stopped", job.Status)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 1, job.Failed)
+	assert.Contains(t, job.Error, "r3")
+	assert.Equal(t, []string{"1", "2", "3"}, paths)
+
+	items, err := c.ReplayJobItems(ctx, hook.ID, job.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 5)
+	assert.Equal(t, "r1", items[0].RequestID)
+	assert.Equal(t, "failed", items[2].Status)
+	assert.Equal(t, http.StatusInternalServerError, items[2].StatusCode)
+	assert.Equal(t, "pending", items[4].Status)
+	replays, err := c.ListReplays(ctx, hook.ID, "r1")
+	require.NoError(t, err)
+	require.Len(t, replays, 1)
+	assert.Equal(t, items[0].AttemptID, replays[0].ID)
+
+	list, err := c.ListReplayJobs(ctx, hook.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	_, err = c.CancelReplayJob(ctx, hook.ID, job.ID)
+	assert.True(t, errors.Is(err, client.ErrConflict))
+
+	// a slow timing replay can be paused and cancelled
+	slow, err := c.StartReplayJob(ctx, hook.ID, client.ReplayJobRequest{Target: target.URL, Speed: 0.01})
+	require.NoError(t, err)
+	paused, err := c.PauseReplayJob(ctx, hook.ID, slow.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "paused", paused.Status)
+	resumed, err := c.ResumeReplayJob(ctx, hook.ID, slow.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "running", resumed.Status)
+	cancelled, err := c.CancelReplayJob(ctx, hook.ID, slow.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cancelled.Status)
+
+	_, err = c.StartReplayJob(ctx, hook.ID, client.ReplayJobRequest{IDs: []string{"missing"}})
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	_, err = c.GetReplayJob(ctx, hook.ID, "missing")
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+}
+
+func TestReplayResign(t *testing.T) {
+	const secret = "whsec_test"
+	got := make(chan *http.Request, 4)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(b)))
+		got <- r
+	}))
+	defer target.Close()
+
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "payments"})
+	require.NoError(t, err)
+	require.NoError(t, store.NewMemoryWebhookRequestRepo(mem).Insert(&models.WebhookRequest{
+		ID: "r1", WebhookID: hook.ID, Method: "POST", Body: `{"id":"evt_1"}`,
+		Headers:    datatypes.JSONMap{"Stripe-Signature": "t=1,v1=stale"},
+		ReceivedAt: time.Now(),
+	}))
+
+	_, err = c.Replay(ctx, hook.ID, "r1", client.ReplayRequest{Target: target.URL, Resign: true})
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Contains(t, apiErr.Fields, "resign", "the webhook has no secret yet")
+
+	hook, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{
+		SigningScheme: client.Set("stripe"),
+		SigningSecret: client.Set(secret),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "stripe", hook.SigningScheme)
+	raw, err := json.Marshal(hook)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), secret, "the secret is write-only")
+
+	// the signature covers the edited body and a fresh timestamp
+	body := `{"id":"evt_2"}`
+	attempt, err := c.Replay(ctx, hook.ID, "r1", client.ReplayRequest{Target: target.URL, Body: &body, Resign: true})
+	require.NoError(t, err)
+	req := <-got
+	verifyStripe(t, req.Header.Get("Stripe-Signature"), secret, body)
+	assert.Equal(t, req.Header.Get("Stripe-Signature"), attempt.RequestHeaders["Stripe-Signature"])
+
+	job, err := c.StartReplayJob(ctx, hook.ID, client.ReplayJobRequest{Target: target.URL, Resign: true})
+	require.NoError(t, err)
+	assert.True(t, job.Resign)
+	verifyStripe(t, (<-got).Header.Get("Stripe-Signature"), secret, `{"id":"evt_1"}`)
+
+	// without resign the captured header is sent as is
+	_, err = c.Replay(ctx, hook.ID, "r1", client.ReplayRequest{Target: target.URL})
+	require.NoError(t, err)
+	assert.Equal(t, "t=1,v1=stale", (<-got).Header.Get("Stripe-Signature"))
+
+	_, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{SigningScheme: client.Set("svix")})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Contains(t, apiErr.Fields, "signing_scheme")
+	hook, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{SigningScheme: client.Null[string]()})
+	require.NoError(t, err, "removing the scheme forgets the secret")
+	assert.Empty(t, hook.SigningScheme)
+}
+
+// verifyStripe checks a Stripe-Signature header the way Stripe's libraries do.
+func verifyStripe(t *testing.T, header, secret, body string) {
+	t.Helper()
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), time.Unix(sec, 0), time.Minute)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "." + body))
+	assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), sig)
+}
+
+func TestCompare(t *testing.T) {
+	respond := func(version string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			n := r.URL.Query().Get("n")
+			sku := "x"
+			if version == "2" && n == "2" {
+				sku = "y"
+			}
+			if version == "2" {
+				w.Header().Set("X-Version", "2")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"%s-%s","total":10,"items":[{"sku":%q}]}`, version, n, sku)
+		}
+	}
+	baseline := httptest.NewServer(respond("1"))
+	defer baseline.Close()
+	candidate := httptest.NewServer(respond("2"))
+	defer candidate.Close()
+
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "orders"})
+	require.NoError(t, err)
+	start := time.Now().Add(-time.Hour)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, store.NewMemoryWebhookRequestRepo(mem).Insert(&models.WebhookRequest{
+			ID: fmt.Sprintf("r%d", i), WebhookID: hook.ID, Method: "POST",
+			Query:      datatypes.JSONMap{"n": fmt.Sprint(i)},
+			ReceivedAt: start.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	cmp, err := c.Compare(ctx, hook.ID, "r2", client.CompareRequest{Baseline: baseline.URL, Candidate: candidate.URL, Ignore: []string{"body.id"}})
+	require.NoError(t, err)
+	assert.False(t, cmp.Equal)
+	assert.Empty(t, cmp.Error)
+	require.Len(t, cmp.Differences, 2)
+	assert.Equal(t, client.Difference{Path: "headers.X-Version", Change: "added", Candidate: "2"}, cmp.Differences[0])
+	assert.Equal(t, client.Difference{Path: "body.items[0].sku", Change: "changed", Baseline: "x", Candidate: "y"}, cmp.Differences[1])
+	replays, err := c.ListReplays(ctx, hook.ID, "r2")
+	require.NoError(t, err)
+	assert.Len(t, replays, 2, "both exchanges are kept as replay attempts")
+
+	equal, err := c.Compare(ctx, hook.ID, "r1", client.CompareRequest{Baseline: baseline.URL, Candidate: candidate.URL,
+		Ignore: []string{"body.id", "headers.x-version"}})
+	require.NoError(t, err)
+	assert.True(t, equal.Equal)
+	assert.Empty(t, equal.Differences)
+
+	list, err := c.ListComparisons(ctx, hook.ID, "r2")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	got, err := c.GetComparison(ctx, hook.ID, "r2", cmp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, cmp.Differences, got.Differences)
+	_, err = c.GetComparison(ctx, hook.ID, "r1", cmp.ID)
+	assert.True(t, errors.Is(err, client.ErrNotFound), "comparisons are looked up under their request")
+
+	// an unreachable target is recorded, not returned as an error
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+	failed, err := c.Compare(ctx, hook.ID, "r1", client.CompareRequest{Baseline: baseline.URL, Candidate: gone.URL})
+	require.NoError(t, err)
+	assert.Contains(t, failed.Error, "candidate: ")
+	assert.False(t, failed.Equal)
+
+	_, err = c.Compare(ctx, hook.ID, "r1", client.CompareRequest{Baseline: "ftp://old", Candidate: candidate.URL, Ignore: []string{"payload.id"}})
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Fields, "baseline")
+	assert.Contains(t, apiErr.Fields, "ignore")
+
+	batch, err := c.StartComparisonBatch(ctx, hook.ID, client.ComparisonBatchRequest{Baseline: baseline.URL, Candidate: candidate.URL,
+		Ignore: []string{"body.id"}, Concurrency: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, batch.Total)
+	require.Eventually(t, func() bool {
+		batch, err = c.GetComparisonBatch(ctx, hook.ID, batch.ID)
+		return err == nil && batch.Done()
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "completed", batch.Status)
+	assert.Equal(t, 3, batch.Different)
+
+	results, err := c.ComparisonBatchResults(ctx, hook.ID, batch.ID)
+	require.NoError(t, err)
+	assert.Len(t, results.Comparisons, 3)
+	assert.Equal(t, []client.PathCount{{Path: "headers.X-Version", Count: 3}, {Path: "body.items[*].sku", Count: 1}}, results.Paths)
+	batches, err := c.ListComparisonBatches(ctx, hook.ID)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+
+	_, err = c.StartComparisonBatch(ctx, hook.ID, client.ComparisonBatchRequest{Baseline: baseline.URL, Candidate: candidate.URL, IDs: []string{"missing"}})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	_, err = c.GetComparisonBatch(ctx, hook.ID, "missing")
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+}
+
+func TestSendEvent(t *testing.T) {
+	const secret = "It's a Secret to Everybody"
+	got := make(chan *http.Request, 4)
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(b)))
+		got <- r
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	defer target.Close()
+
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	templates, err := c.ListEventTemplates(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, tmpl := range templates {
+		names = append(names, tmpl.Name)
+	}
+	assert.Subset(t, names, []string{"github.push", "github.pull_request", "stripe.checkout.session.completed",
+		"shopify.orders.create", "slack.event_callback", "cloudevents"})
+	assert.Equal(t, "github", templates[0].Scheme)
+	assert.NotContains(t, templates[0].Body, "{{", "templates come rendered")
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "ci", SigningScheme: "github", SigningSecret: secret})
+	require.NoError(t, err)
+
+	sent, err := c.SendEvent(ctx, hook.ID, client.SendEventRequest{
+		Target: target.URL, Template: "github.push", Sign: true,
+		Headers: map[string]string{"x-github-event": "ping"},
+	})
+	require.NoError(t, err)
+	req := <-got
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "ping", req.Header.Get("X-GitHub-Event"), "edits replace template headers in any case")
+	assert.NotEmpty(t, req.Header.Get("X-GitHub-Delivery"))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Hub-Signature-256"))
+
+	assert.Equal(t, http.StatusAccepted, sent.Delivery.StatusCode)
+	assert.Equal(t, `{"ok":true}`, sent.Delivery.ResponseBody)
+	assert.Equal(t, sent.Request.ID, sent.Delivery.RequestID)
+	assert.Equal(t, "sent", sent.Request.Source)
+	assert.Equal(t, string(body), sent.Request.Body)
+	assert.Equal(t, req.Header.Get("X-Hub-Signature-256"), sent.Request.Headers["X-Hub-Signature-256"], "the stored request is what was sent")
+
+	stored, err := c.GetRequest(ctx, hook.ID, sent.Request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "sent", stored.Source)
+	replays, err := c.ListReplays(ctx, hook.ID, sent.Request.ID)
+	require.NoError(t, err)
+	require.Len(t, replays, 1)
+
+	// without a template the request is built from the fields alone
+	raw := "a=1"
+	sent, err = c.SendEvent(ctx, hook.ID, client.SendEventRequest{Target: target.URL, Method: "put", Body: &raw,
+		Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"}})
+	require.NoError(t, err)
+	req = <-got
+	assert.Equal(t, http.MethodPut, req.Method)
+	assert.Empty(t, req.Header.Get("X-Hub-Signature-256"), "events are only signed when asked")
+	assert.Equal(t, "PUT", sent.Request.Method)
+
+	_, err = c.SendEvent(ctx, hook.ID, client.SendEventRequest{Target: "ftp://x", Template: "github.nope"})
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Fields, "target")
+	assert.Contains(t, apiErr.Fields, "template")
+
+	plain, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "plain"})
+	require.NoError(t, err)
+	_, err = c.SendEvent(ctx, plain.ID, client.SendEventRequest{Target: target.URL, Template: "cloudevents", Sign: true})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Contains(t, apiErr.Fields, "sign", "signing needs a secret")
+}
+
+func TestDelivery(t *testing.T) {
+	const secret = "It's a Secret to Everybody"
+	var mu sync.Mutex
+	var got []*http.Request
+	var bodies []string
+	codes := []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		got, bodies = append(got, r), append(bodies, string(b))
+		code := http.StatusInternalServerError
+		if len(got) <= len(codes) {
+			code = codes[len(got)-1]
+		}
+		w.WriteHeader(code)
+	}))
+	defer target.Close()
+
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "ci", SigningScheme: "github", SigningSecret: secret})
+	require.NoError(t, err)
+
+	wait := func(d *client.Delivery) *client.Delivery {
+		t.Helper()
+		for i := 0; !d.Done(); i++ {
+			require.Less(t, i, 200, "the delivery didn't finish")
+			time.Sleep(10 * time.Millisecond)
+			d, err = c.GetDelivery(ctx, hook.ID, d.ID)
+			require.NoError(t, err)
+		}
+		return d
+	}
+
+	// a custom schedule retries until the target answers 2xx
+	d, err := c.StartDelivery(ctx, hook.ID, client.DeliveryRequest{
+		Target: target.URL, Template: "github.push", Sign: true,
+		Policy: "custom", ScheduleMs: []int{20, 40, 60}, DeliveryHeader: "X-Delivery-ID",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "delivering", d.Status)
+	assert.Equal(t, 4, d.MaxAttempts)
+	d = wait(d)
+	assert.Equal(t, "succeeded", d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Equal(t, http.StatusOK, d.StatusCode)
+	assert.Nil(t, d.NextAttemptAt)
+
+	mu.Lock()
+	require.Len(t, got, 3)
+	for i, req := range got {
+		assert.Equal(t, got[0].Header.Get("X-GitHub-Delivery"), req.Header.Get("X-GitHub-Delivery"), "attempt %d keeps the event's ID", i+1)
+		assert.Equal(t, d.ID, req.Header.Get("X-Delivery-ID"))
+		assert.Equal(t, bodies[0], bodies[i])
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(bodies[i]))
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Hub-Signature-256"))
+	}
+	mu.Unlock()
+
+	attempts, err := c.DeliveryAttempts(ctx, hook.ID, d.ID)
+	require.NoError(t, err)
+	require.Len(t, attempts, 3)
+	for i, a := range attempts {
+		assert.Equal(t, i+1, a.Seq)
+		assert.Equal(t, codes[i], a.StatusCode)
+		assert.Equal(t, i == 2, a.Succeeded)
+		assert.NotEmpty(t, a.AttemptID)
+	}
+	assert.GreaterOrEqual(t, attempts[2].SentAt.Sub(attempts[0].SentAt), 60*time.Millisecond, "retries wait their turn")
+	replays, err := c.ListReplays(ctx, hook.ID, d.RequestID)
+	require.NoError(t, err)
+	assert.Len(t, replays, 3, "each attempt is a replay of the event")
+
+	// exponential backoff doubles the wait and gives up after max_attempts
+	d, err = c.StartDelivery(ctx, hook.ID, client.DeliveryRequest{Target: target.URL, Template: "cloudevents",
+		Policy: "exponential", MaxAttempts: 3, InitialDelayMs: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 20}, d.ScheduleMs)
+	d = wait(d)
+	assert.Equal(t, "failed", d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, d.StatusCode)
+	assert.Equal(t, "all 3 attempts failed", d.Error)
+
+	// without retries one failure is final
+	d, err = c.StartDelivery(ctx, hook.ID, client.DeliveryRequest{Target: target.URL, Template: "cloudevents"})
+	require.NoError(t, err)
+	assert.Equal(t, "none", d.Policy)
+	d = wait(d)
+	assert.Equal(t, "failed", d.Status)
+	assert.Equal(t, 1, d.Attempts)
+
+	// a retry that wouldn't fit the time budget isn't made
+	d, err = c.StartDelivery(ctx, hook.ID, client.DeliveryRequest{Target: target.URL, Template: "cloudevents",
+		Policy: "custom", ScheduleMs: []int{60000}, BudgetMs: 1000})
+	require.NoError(t, err)
+	d = wait(d)
+	assert.Equal(t, "failed", d.Status)
+	assert.Equal(t, "the time budget ran out before attempt 2", d.Error)
+
+	// a delivery waiting to retry can be cancelled, once
+	d, err = c.StartDelivery(ctx, hook.ID, client.DeliveryRequest{Target: target.URL, Template: "cloudevents",
+		Policy: "custom", ScheduleMs: []int{60000}})
+	require.NoError(t, err)
+	for d.NextAttemptAt == nil {
+		time.Sleep(10 * time.Millisecond)
+		d, err = c.GetDelivery(ctx, hook.ID, d.ID)
+		require.NoError(t, err)
+	}
+	d, err = c.CancelDelivery(ctx, hook.ID, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", d.Status)
+	assert.Nil(t, d.NextAttemptAt)
+	d = wait(d)
+	assert.Equal(t, 1, d.Attempts)
+	_, err = c.CancelDelivery(ctx, hook.ID, d.ID)
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+
+	list, err := c.ListDeliveries(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.Len(t, list, 5)
+	assert.Equal(t, d.ID, list[0].ID, "newest first")
+
+	_, err = c.StartDelivery(ctx, hook.ID, client.DeliveryRequest{Target: target.URL, Policy: "weekly", MaxAttempts: 3})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Fields, "policy")
+	assert.Contains(t, apiErr.Fields, "max_attempts")
+	_, err = c.StartDelivery(ctx, hook.ID, client.DeliveryRequest{Target: target.URL, Policy: "custom", BudgetMs: -1})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Contains(t, apiErr.Fields, "schedule_ms")
+	assert.Contains(t, apiErr.Fields, "budget_ms")
+	_, err = c.GetDelivery(ctx, hook.ID, "missing")
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestLoadRun(t *testing.T) {
+	const secret = "It's a Secret to Everybody"
+	var mu sync.Mutex
+	deliveries := map[string]bool{}
+	var signed int
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if id := r.Header.Get("X-GitHub-Delivery"); id != "" {
+			deliveries[id] = true
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(b)
+			if r.Header.Get("X-Hub-Signature-256") == "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+				signed++
+			}
+		}
+		if strings.Contains(string(b), "fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer target.Close()
+
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "ci", SigningScheme: "github", SigningSecret: secret})
+	require.NoError(t, err)
+	other, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "other"})
+	require.NoError(t, err)
+	requests := store.NewMemoryWebhookRequestRepo(mem)
+	require.NoError(t, requests.Insert(&models.WebhookRequest{
+		ID: "r1", WebhookID: hook.ID, Method: "POST", Body: `{"status":"fail"}`, ReceivedAt: time.Now(),
+	}))
+	require.NoError(t, requests.Insert(&models.WebhookRequest{
+		ID: "r2", WebhookID: other.ID, Method: "POST", Body: `{}`, ReceivedAt: time.Now(),
+	}))
+
+	wait := func(run *client.LoadRun) *client.LoadRun {
+		t.Helper()
+		for i := 0; !run.Done(); i++ {
+			require.Less(t, i, 500, "the load run didn't finish")
+			time.Sleep(10 * time.Millisecond)
+			run, err = c.GetLoadRun(ctx, hook.ID, run.ID)
+			require.NoError(t, err)
+		}
+		return run
+	}
+
+	// a template is rendered afresh, and signed, for every request
+	run, err := c.StartLoadRun(ctx, hook.ID, client.LoadRunRequest{
+		Target: target.URL, Template: "github.push", Sign: true, Concurrency: 5, Count: 50,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "running", run.Status)
+	run = wait(run)
+	assert.Equal(t, "completed", run.Status)
+	assert.Equal(t, 50, run.Requests)
+	assert.Equal(t, 50, run.Succeeded)
+	assert.Equal(t, []client.StatusCount{{Code: http.StatusOK, Count: 50}}, run.StatusCodes)
+	assert.LessOrEqual(t, run.MinMs, run.P50Ms)
+	assert.LessOrEqual(t, run.P50Ms, run.P99Ms)
+	assert.LessOrEqual(t, run.P99Ms, run.MaxMs)
+	assert.Positive(t, run.Throughput)
+	assert.NotNil(t, run.FinishedAt)
+	mu.Lock()
+	assert.Len(t, deliveries, 50, "every request has its own delivery ID")
+	assert.Equal(t, 50, signed)
+	mu.Unlock()
+
+	// a captured request is sent as captured, and its status codes counted
+	run, err = c.StartLoadRun(ctx, hook.ID, client.LoadRunRequest{Target: target.URL, RequestID: "r1", Count: 10, Rate: 500})
+	require.NoError(t, err)
+	run = wait(run)
+	assert.Equal(t, 10, run.Failed)
+	assert.Equal(t, []client.StatusCount{{Code: http.StatusInternalServerError, Count: 10}}, run.StatusCodes)
+	assert.GreaterOrEqual(t, run.ElapsedMs, int64(18), "the rate spaces requests out")
+
+	// requests that time out are errors
+	run, err = c.StartLoadRun(ctx, hook.ID, client.LoadRunRequest{Target: target.URL + "/slow", Template: "cloudevents", Count: 2, TimeoutMs: 20})
+	require.NoError(t, err)
+	run = wait(run)
+	assert.Equal(t, 2, run.Errors)
+	require.Len(t, run.ErrorCounts, 1)
+	assert.Equal(t, 2, run.ErrorCounts[0].Count)
+	assert.Empty(t, run.StatusCodes)
+
+	// a webhook runs one load run at a time, which can be cancelled once
+	run, err = c.StartLoadRun(ctx, hook.ID, client.LoadRunRequest{Target: target.URL + "/slow", Template: "cloudevents", DurationMs: 60000})
+	require.NoError(t, err)
+	var apiErr *client.APIError
+	_, err = c.StartLoadRun(ctx, hook.ID, client.LoadRunRequest{Target: target.URL, Template: "cloudevents", Count: 1})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	run, err = c.CancelLoadRun(ctx, hook.ID, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", run.Status)
+	assert.Zero(t, run.Requests, "requests in flight are left out")
+	_, err = c.CancelLoadRun(ctx, hook.ID, run.ID)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+
+	list, err := c.ListLoadRuns(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.Len(t, list, 4)
+	assert.Equal(t, run.ID, list[0].ID, "newest first")
+
+	_, err = c.StartLoadRun(ctx, hook.ID, client.LoadRunRequest{Target: target.URL, Concurrency: 1000})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Fields, "request_id")
+	assert.Contains(t, apiErr.Fields, "concurrency")
+	assert.Contains(t, apiErr.Fields, "count")
+	_, err = c.StartLoadRun(ctx, hook.ID, client.LoadRunRequest{Target: target.URL, RequestID: "r2", Count: 1})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Contains(t, apiErr.Fields, "request_id")
+	_, err = c.StartLoadRun(ctx, other.ID, client.LoadRunRequest{Target: target.URL, Template: "cloudevents", Sign: true, Count: 1})
+	require.ErrorAs(t, err, &apiErr)
+	assert.Contains(t, apiErr.Fields, "sign")
+	_, err = c.GetLoadRun(ctx, other.ID, run.ID)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestCallbacks(t *testing.T) {
+	var mu sync.Mutex
+	var got []*http.Request
+	var bodies []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		got, bodies = append(got, r), append(bodies, string(b))
+	}))
+	defer target.Close()
+
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "payments"})
+	require.NoError(t, err)
+
+	receive := func(body string) string {
+		t.Helper()
+		resp, err := http.Post(srv.URL+"/webhooks/"+hook.ID, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		page, err := c.ListRequests(ctx, hook.ID, client.ListOptions{})
+		require.NoError(t, err)
+		// a callback to the webhook itself may already be stored above it
+		for _, wr := range page.Data {
+			if wr.Body == body {
+				return wr.ID
+			}
+		}
+		require.FailNow(t, "the request wasn't stored")
+		return ""
+	}
+	wait := func(requestID string) client.Callback {
+		t.Helper()
+		for i := 0; ; i++ {
+			require.Less(t, i, 200, "the callback wasn't sent")
+			list, err := c.ListCallbacks(ctx, hook.ID, requestID)
+			require.NoError(t, err)
+			if len(list) == 1 && list[0].Done() {
+				return list[0]
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// the callback goes to the URL in the body, with values of the request
+	action, err := c.CreateCallbackAction(ctx, hook.ID, client.CallbackActionRequest{
+		Name:    "payment status",
+		DelayMs: 20,
+		URLFrom: "body.callback_url",
+		Headers: map[string]string{"X-Order": `{{field "body.order.id"}}`},
+		Body:    `{"order":{{json "body.order.id"}},"status":"paid","request":"{{.ID}}"}`,
+	})
+	require.NoError(t, err)
+	assert.True(t, action.Enabled)
+	assert.Equal(t, http.MethodPost, action.Method)
+
+	requestID := receive(`{"order":{"id":42},"callback_url":"` + target.URL + `/status"}`)
+	cb := wait(requestID)
+	assert.Equal(t, "succeeded", cb.Status)
+	assert.Equal(t, action.ID, cb.ActionID)
+	assert.Equal(t, "payment status", cb.ActionName)
+	assert.Equal(t, target.URL+"/status", cb.URL)
+	assert.Equal(t, http.StatusOK, cb.StatusCode)
+	assert.False(t, cb.SentAt.Before(cb.DueAt))
+
+	mu.Lock()
+	require.Len(t, got, 1)
+	assert.Equal(t, "/status", got[0].URL.Path)
+	assert.Equal(t, "42", got[0].Header.Get("X-Order"))
+	assert.Equal(t, "application/json", got[0].Header.Get("Content-Type"))
+	assert.Equal(t, cb.ID, got[0].Header.Get("X-Webhook-Tester-Callback"))
+	assert.JSONEq(t, `{"order":42,"status":"paid","request":"`+requestID+`"}`, bodies[0])
+	mu.Unlock()
+
+	// the exchange is recorded as a replay of the received request
+	attempt, err := c.GetReplay(ctx, hook.ID, requestID, cb.AttemptID)
+	require.NoError(t, err)
+	assert.Equal(t, target.URL+"/status", attempt.URL)
+	assert.Equal(t, http.StatusOK, attempt.StatusCode)
+
+	// a request without the URL fails its callback
+	cb = wait(receive(`{"order":{"id":43}}`))
+	assert.Equal(t, "failed", cb.Status)
+	assert.Contains(t, cb.Error, "body.callback_url")
+
+	// a scheduled callback can be cancelled once
+	action, err = c.UpdateCallbackAction(ctx, hook.ID, action.ID, client.CallbackActionRequest{
+		Name: "later", DelayMs: 3_600_000, URL: target.URL,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "later", action.Name)
+	requestID = receive(`{}`)
+	list, err := c.ListCallbacks(ctx, hook.ID, requestID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "scheduled", list[0].Status)
+	cb2, err := c.CancelCallback(ctx, hook.ID, list[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", cb2.Status)
+	_, err = c.CancelCallback(ctx, hook.ID, list[0].ID)
+	assert.True(t, errors.Is(err, client.ErrConflict))
+
+	// deleting an action cancels its scheduled callbacks
+	requestID = receive(`{}`)
+	require.NoError(t, c.DeleteCallbackAction(ctx, hook.ID, action.ID))
+	list, err = c.ListCallbacks(ctx, hook.ID, requestID)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "cancelled", list[0].Status)
+
+	// a callback to the webhook itself doesn't trigger another one
+	_, err = c.CreateCallbackAction(ctx, hook.ID, client.CallbackActionRequest{
+		Name: "echo", URL: srv.URL + "/webhooks/" + hook.ID, Body: `{"echo":true}`,
+	})
+	require.NoError(t, err)
+	cb = wait(receive(`{}`))
+	assert.Equal(t, "succeeded", cb.Status)
+	page, err := c.ListRequests(ctx, hook.ID, client.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"echo":true}`, page.Data[0].Body)
+	list, err = c.ListCallbacks(ctx, hook.ID, page.Data[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	_, err = c.CreateCallbackAction(ctx, hook.ID, client.CallbackActionRequest{
+		DelayMs: -1,
+		URL:     "ftp://example.com",
+		URLFrom: "url",
+		Headers: map[string]string{"Bad Header": "x"},
+		Body:    "{{nope",
+		Sign:    true,
+	})
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.ElementsMatch(t, []string{"name", "delay_ms", "url", "url_from", "headers.Bad Header", "body", "sign"}, keys(apiErr.Fields))
+
+	other, err := client.New(srv.URL, "other").CreateWebhook(ctx, client.CreateWebhookRequest{Title: "theirs"})
+	require.NoError(t, err)
+	_, err = c.GetCallback(ctx, other.ID, cb.ID)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestFaults(t *testing.T) {
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	_, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "bad", Faults: client.FaultConfig{
+		ErrorPercent: 120, ErrorStatus: 42, FailEvery: 1, DelayMinMs: 500, DelayMaxMs: 100, DelayDistribution: "pareto",
+	}})
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.ElementsMatch(t, []string{
+		"faults.error_percent", "faults.error_status", "faults.fail_every", "faults.delay_max_ms", "faults.delay_distribution",
+	}, keys(apiErr.Fields))
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "flaky", Payload: `{"ok":true}`,
+		Faults: client.FaultConfig{FailEvery: 3, ErrorStatus: 503}})
+	require.NoError(t, err)
+	assert.Equal(t, client.FaultConfig{FailEvery: 3, ErrorStatus: 503}, hook.Faults)
+	url := srv.URL + "/webhooks/" + hook.ID
+
+	send := func() (*http.Response, string, error) {
+		resp, err := http.Post(url, "application/json", strings.NewReader(`{}`))
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp, string(body), err
+	}
+	var statuses []int
+	for range 3 {
+		resp, _, err := send()
+		require.NoError(t, err)
+		statuses = append(statuses, resp.StatusCode)
+	}
+	assert.Equal(t, []int{200, 200, 503}, statuses)
+
+	page, err := c.ListRequests(ctx, hook.ID, client.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 3)
+	assert.Equal(t, "every_nth", page.Data[0].Fault, "newest first")
+	assert.Empty(t, page.Data[1].Fault)
+
+	// past the rate limit
+	hook, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{Faults: client.Set(client.FaultConfig{RateLimit: 1, RetryAfter: 7})})
+	require.NoError(t, err)
+	resp, _, err := send()
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	resp, _, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "7", resp.Header.Get("Retry-After"))
+
+	_, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{Faults: client.Set(client.FaultConfig{TruncatePercent: 100})})
+	require.NoError(t, err)
+	_, body, err := send()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, `{"ok"`, body)
+
+	_, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{Faults: client.Set(client.FaultConfig{DripPercent: 100, DripIntervalMs: 1})})
+	require.NoError(t, err)
+	_, body, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, body)
+
+	_, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{Faults: client.Set(client.FaultConfig{DisconnectPercent: 100, DelayMinMs: 10, DelayMaxMs: 20})})
+	require.NoError(t, err)
+	_, _, err = send()
+	assert.Error(t, err)
+
+	page, err = c.ListRequests(ctx, hook.ID, client.ListOptions{})
+	require.NoError(t, err)
+	var faults []string
+	for _, wr := range page.Data {
+		faults = append(faults, wr.Fault)
+	}
+	assert.Equal(t, []string{"disconnect", "drip", "truncate", "rate_limit", ""}, faults[:5])
+	assert.GreaterOrEqual(t, page.Data[0].FaultDelay, int64(10))
+
+	// null turns faults off
+	hook, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{Faults: client.Null[client.FaultConfig]()})
+	require.NoError(t, err)
+	assert.Zero(t, hook.Faults)
+	resp, _, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestScripts(t *testing.T) {
+	srv, _ := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+
+	_, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "bad", Script: "def answer(request):\n    return None\n"})
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, []string{"script"}, keys(apiErr.Fields))
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "counter", Payload: "static", Script: `
+def handle(request):
+    n = state.get("count", 0) + 1
+    state["count"] = n
+    print("call", n, request.query.get("mode", ""))
+    if request.json and request.json.get("skip"):
+        return None
+    return {
+        "status": 201,
+        "headers": {"X-Count": str(n), "Content-Length": "1"},
+        "body": {"count": n, "method": request.method},
+        "delay_ms": 10,
+    }
+`})
+	require.NoError(t, err)
+	assert.Contains(t, hook.Script, "def handle")
+	url := srv.URL + "/webhooks/" + hook.ID
+
+	send := func(body string) (*http.Response, string) {
+		resp, err := http.Post(url+"?mode=test", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(b)
+	}
+	start := time.Now()
+	resp, body := send(`{}`)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	assert.Equal(t, 201, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("X-Count"))
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"count": 1, "method": "POST"}`, body)
+	resp, body = send(`{}`)
+	assert.Equal(t, "2", resp.Header.Get("X-Count"))
+	assert.JSONEq(t, `{"count": 2, "method": "POST"}`, body)
+	resp, body = send(`{"skip": true}`)
+	assert.Equal(t, 200, resp.StatusCode, "None keeps the webhook's response")
+	assert.Equal(t, "static", body)
+
+	state, err := c.GetScriptState(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"count": float64(3)}, state)
+
+	page, err := c.ListRequests(ctx, hook.ID, client.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 3)
+	assert.Equal(t, "call 3 test\n", page.Data[0].ScriptLog)
+	assert.Contains(t, page.Data[1].ScriptLog, `dropped the header "Content-Length"`)
+
+	require.NoError(t, c.ResetScriptState(ctx, hook.ID))
+	state, err = c.GetScriptState(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.Empty(t, state)
+	resp, _ = send(`{}`)
+	assert.Equal(t, "1", resp.Header.Get("X-Count"))
+
+	// a failing script answers 500 and its error is logged
+	hook, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{Script: client.Set("def handle(request):\n    print('before')\n    fail('boom')\n")})
+	require.NoError(t, err)
+	resp, body = send(`{}`)
+	assert.Equal(t, 500, resp.StatusCode)
+	assert.Contains(t, body, "boom")
+	page, err = c.ListRequests(ctx, hook.ID, client.ListOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, page.Data[0].ScriptLog, "before\nerror: ")
+
+	// null removes the script
+	hook, err = c.PatchWebhook(ctx, hook.ID, client.PatchWebhookRequest{Script: client.Null[string]()})
+	require.NoError(t, err)
+	assert.Empty(t, hook.Script)
+	resp, body = send(`{}`)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "static", body)
+}
+
+func TestRetentionAndQuota(t *testing.T) {
+	srv, mem := newServer(t)
+	c := client.New(srv.URL, "key")
+	ctx := context.Background()
+	users := store.NewMemoryUserRepo(mem)
+	requests := store.NewMemoryWebhookRequestRepo(mem)
+	retention := service.NewRetentionService(store.NewMemoryWebhookRepo(mem), requests, users, 0)
+
+	hook, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "kept", RetentionCount: 2})
+	require.NoError(t, err)
+	send := func(id, body string) *http.Response {
+		resp, err := http.Post(srv.URL+"/webhooks/"+id, "text/plain", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// the newest two survive each request, and pinned requests on top of them
+	send(hook.ID, "first")
+	page, err := c.ListRequests(ctx, hook.ID, client.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, page.Data, 1)
+	pinned := page.Data[0].ID
+	require.NoError(t, requests.SetPinned(pinned, true))
+	for _, body := range []string{"second", "third", "fourth"} {
+		assert.Equal(t, 200, send(hook.ID, body).StatusCode)
+	}
+	page, err = c.ListRequests(ctx, hook.ID, client.ListOptions{})
+	require.NoError(t, err)
+	var bodies []string
+	for _, wr := range page.Data {
+		bodies = append(bodies, wr.Body)
+	}
+	assert.Equal(t, []string{"fourth", "third", "first"}, bodies)
+
+	// EnforceAll applies retention_days to requests nobody has sent to lately
+	aged, err := c.CreateWebhook(ctx, client.CreateWebhookRequest{Title: "aged", RetentionDays: 1})
+	require.NoError(t, err)
+	old := time.Now().UTC().AddDate(0, 0, -2)
+	for _, id := range []string{"old", "old-pinned"} {
+		wr := &models.WebhookRequest{ID: id, WebhookID: aged.ID, Method: "POST", ReceivedAt: old}
+		wr.Size = wr.ComputeSize()
+		require.NoError(t, requests.Insert(wr))
+	}
+	require.NoError(t, requests.SetPinned("old-pinned", true))
+	assert.Equal(t, 200, send(aged.ID, "recent").StatusCode)
+	removed, err := retention.EnforceAll()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+	left, err := requests.ListByWebhook(aged.ID)
+	require.NoError(t, err)
+	assert.Len(t, left, 2)
+	_, err = requests.GetByID("old")
+	assert.Error(t, err)
+
+	// past the owner's quota requests are refused with 507 and nothing is stored
+	user, err := users.GetByAPIKey("key")
+	require.NoError(t, err)
+	usage, err := retention.Usage(user.ID)
+	require.NoError(t, err)
+	assert.Positive(t, usage.Used)
+	user.StorageQuota = usage.Used + 10
+	require.NoError(t, users.Update(user))
+
+	resp := send(hook.ID, strings.Repeat("x", 100))
+	assert.Equal(t, http.StatusInsufficientStorage, resp.StatusCode)
+	assert.Equal(t, strconv.FormatInt(usage.Used+10, 10), resp.Header.Get("X-Storage-Quota-Limit"))
+	assert.Equal(t, strconv.FormatInt(usage.Used, 10), resp.Header.Get("X-Storage-Quota-Used"))
+	after, err := retention.Usage(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, usage.Used, after.Used)
+
+	_, err = retention.CheckQuota(&models.Webhook{UserID: int(user.ID)}, 10)
+	assert.NoError(t, err, "a request that fits exactly is allowed")
+	_, err = retention.CheckQuota(&models.Webhook{UserID: int(user.ID)}, 11)
+	assert.True(t, errors.Is(err, service.ErrQuotaExceeded))
+	_, err = retention.CheckQuota(&models.Webhook{}, 1<<30)
+	assert.NoError(t, err, "guest webhooks have no quota")
+}
@@ -0,0 +1,70 @@
+package client
+
+import "context"
+
+// Compare sends a stored request to in.Baseline and in.Candidate and returns
+// how the responses differ. Not reaching a target isn't an error: the
+// comparison's Error says what went wrong.
+func (c *Client) Compare(ctx context.Context, webhookID, requestID string, in CompareRequest) (*Comparison, error) {
+	var out Comparison
+	if err := c.do(ctx, compareRequest, []string{webhookID, requestID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListComparisons returns the comparisons of a request, newest first.
+func (c *Client) ListComparisons(ctx context.Context, webhookID, requestID string) ([]Comparison, error) {
+	var out []Comparison
+	if err := c.do(ctx, listCompares, []string{webhookID, requestID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetComparison returns one comparison of a request.
+func (c *Client) GetComparison(ctx context.Context, webhookID, requestID, comparisonID string) (*Comparison, error) {
+	var out Comparison
+	if err := c.do(ctx, getCompare, []string{webhookID, requestID, comparisonID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartComparisonBatch starts comparing the webhook's requests selected by
+// in, in the background. Poll GetComparisonBatch for progress.
+func (c *Client) StartComparisonBatch(ctx context.Context, webhookID string, in ComparisonBatchRequest) (*ComparisonBatch, error) {
+	var out ComparisonBatch
+	if err := c.do(ctx, startBatch, []string{webhookID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListComparisonBatches returns the batch comparisons of a webhook, newest first.
+func (c *Client) ListComparisonBatches(ctx context.Context, webhookID string) ([]ComparisonBatch, error) {
+	var out []ComparisonBatch
+	if err := c.do(ctx, listBatches, []string{webhookID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetComparisonBatch returns a batch comparison with its progress.
+func (c *Client) GetComparisonBatch(ctx context.Context, webhookID, batchID string) (*ComparisonBatch, error) {
+	var out ComparisonBatch
+	if err := c.do(ctx, getBatch, []string{webhookID, batchID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ComparisonBatchResults returns the comparisons a batch has made so far and
+// the paths where they differ, most frequent first.
+func (c *Client) ComparisonBatchResults(ctx context.Context, webhookID, batchID string) (*ComparisonBatchResults, error) {
+	var out ComparisonBatchResults
+	if err := c.do(ctx, batchResults, []string{webhookID, batchID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
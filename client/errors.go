@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Sentinel errors for the API's error classes. Match them with errors.Is.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrServer       = errors.New("server error")
+)
+
+// APIError is returned for every non-2xx response.
+type APIError struct {
+	StatusCode int
+	// Code is the problem's stable error code, such as "not_found" or
+	// "validation_failed". It is empty when the response wasn't a problem document.
+	Code string
+	// Message is the problem's detail, or the raw body.
+	Message string
+	// Fields maps each invalid input field to its problem, for 422 responses.
+	Fields map[string]string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Fields) > 0 {
+		keys := make([]string, 0, len(e.Fields))
+		for k := range e.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		msgs := make([]string, len(keys))
+		for i, k := range keys {
+			msgs[i] = k + " " + e.Fields[k]
+		}
+		return fmt.Sprintf("webhook-tester: %d %s: %s", e.StatusCode, e.Message, strings.Join(msgs, "; "))
+	}
+	if e.Message == "" {
+		return fmt.Sprintf("webhook-tester: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("webhook-tester: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is maps the status code onto the sentinel errors.
+func (e *APIError) Is(target error) bool {
+	switch {
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return target == ErrBadRequest
+	case e.StatusCode == http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return target == ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return target == ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return target == ErrConflict
+	case e.StatusCode >= 500:
+		return target == ErrServer
+	}
+	return false
+}
+
+// problem mirrors dtos.Problem, the API's RFC 7807 error document.
+type problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail"`
+	Instance string            `json:"instance"`
+	Code     string            `json:"code"`
+	Errors   map[string]string `json:"errors"`
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	e := &APIError{StatusCode: resp.StatusCode}
+
+	var p problem
+	var s string
+	switch {
+	case json.Unmarshal(raw, &p) == nil && p.Code != "":
+		e.Code = p.Code
+		e.Message = p.Detail
+		e.Fields = p.Errors
+	case json.Unmarshal(raw, &s) == nil:
+		e.Message = s
+	default:
+		e.Message = strings.TrimSpace(string(raw))
+	}
+	return e
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Export formats.
+const (
+	FormatHAR      = "har"
+	FormatJSONL    = "jsonl"
+	FormatCSV      = "csv"
+	FormatPostman  = "postman"  // Postman collection v2.1
+	FormatInsomnia = "insomnia" // Insomnia export v4
+)
+
+// ExportOptions select what ExportRequests downloads.
+type ExportOptions struct {
+	// Format is FormatHAR (the default), FormatJSONL, FormatCSV,
+	// FormatPostman or FormatInsomnia.
+	Format string
+	// Since and Until limit the export to requests received in [Since, Until).
+	Since, Until time.Time
+	// Methods limits the export to requests with these HTTP methods.
+	Methods []string
+	// Source limits the export to live ("live"), imported ("import") or sent ("sent") requests.
+	Source string
+	// IDs limits the export to these requests.
+	IDs []string
+	// Name names a Postman or Insomnia collection; it defaults to the webhook's title.
+	Name string
+}
+
+// ExportRequests downloads a webhook's requests, oldest first. The caller must
+// close the returned body. Large exports are streamed, so the client's
+// timeout doesn't apply; cancel ctx to stop one.
+func (c *Client) ExportRequests(ctx context.Context, webhookID string, opts ExportOptions) (io.ReadCloser, error) {
+	q := url.Values{}
+	if opts.Format != "" {
+		q.Set("format", opts.Format)
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.UTC().Format(time.RFC3339))
+	}
+	if !opts.Until.IsZero() {
+		q.Set("until", opts.Until.UTC().Format(time.RFC3339))
+	}
+	for _, m := range opts.Methods {
+		q.Add("method", m)
+	}
+	if opts.Source != "" {
+		q.Set("source", opts.Source)
+	}
+	for _, id := range opts.IDs {
+		q.Add("id", id)
+	}
+	if opts.Name != "" {
+		q.Set("name", opts.Name)
+	}
+
+	u := c.baseURL + expand(exportRequests.path, []string{webhookID}) + "?" + q.Encode()
+	req, err := http.NewRequestWithContext(ctx, exportRequests.method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	hc := *c.httpClient
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ImportRequests uploads a HAR or JSONL document, such as one downloaded with
+// ExportRequests, and stores its requests under a webhook. format is
+// FormatHAR, FormatJSONL or empty to let the server detect it. Imports are
+// all or nothing and are never retried.
+func (c *Client) ImportRequests(ctx context.Context, webhookID string, r io.Reader, format string) (*ImportResult, error) {
+	u := c.baseURL + expand(importRequests.path, []string{webhookID})
+	if format != "" {
+		u += "?" + url.Values{"format": {format}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, importRequests.method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if format == FormatJSONL {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	hc := *c.httpClient
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, newAPIError(resp)
+	}
+	var out ImportResult
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return &out, nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import "context"
+
+// StartLoadRun starts firing a captured or templated request at in.Target in
+// the background. Poll GetLoadRun for the results.
+func (c *Client) StartLoadRun(ctx context.Context, webhookID string, in LoadRunRequest) (*LoadRun, error) {
+	var out LoadRun
+	if err := c.do(ctx, startLoadRun, []string{webhookID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListLoadRuns returns the load runs of a webhook, newest first.
+func (c *Client) ListLoadRuns(ctx context.Context, webhookID string) ([]LoadRun, error) {
+	var out []LoadRun
+	if err := c.do(ctx, listLoadRuns, []string{webhookID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetLoadRun returns a load run with its results so far.
+func (c *Client) GetLoadRun(ctx context.Context, webhookID, runID string) (*LoadRun, error) {
+	return c.loadRunCall(ctx, getLoadRun, webhookID, runID)
+}
+
+// CancelLoadRun stops a load run and returns its final results.
+func (c *Client) CancelLoadRun(ctx context.Context, webhookID, runID string) (*LoadRun, error) {
+	return c.loadRunCall(ctx, cancelLoadRun, webhookID, runID)
+}
+
+func (c *Client) loadRunCall(ctx context.Context, ep endpoint, webhookID, runID string) (*LoadRun, error) {
+	var out LoadRun
+	if err := c.do(ctx, ep, []string{webhookID, runID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
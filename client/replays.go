@@ -0,0 +1,89 @@
+package client
+
+import "context"
+
+// Replay sends a stored request, changed by in, to in.Target and returns the
+// recorded attempt. Not reaching the target isn't an error: the attempt's
+// Error says what went wrong. Replays are never retried.
+func (c *Client) Replay(ctx context.Context, webhookID, requestID string, in ReplayRequest) (*ReplayAttempt, error) {
+	var out ReplayAttempt
+	if err := c.do(ctx, replayRequest, []string{webhookID, requestID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListReplays returns the replay attempts of a request, newest first.
+func (c *Client) ListReplays(ctx context.Context, webhookID, requestID string) ([]ReplayAttempt, error) {
+	var out []ReplayAttempt
+	if err := c.do(ctx, listReplays, []string{webhookID, requestID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetReplay returns one replay attempt of a request.
+func (c *Client) GetReplay(ctx context.Context, webhookID, requestID, attemptID string) (*ReplayAttempt, error) {
+	var out ReplayAttempt
+	if err := c.do(ctx, getReplay, []string{webhookID, requestID, attemptID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartReplayJob starts replaying the webhook's requests selected by in, in
+// the background. Poll GetReplayJob for progress.
+func (c *Client) StartReplayJob(ctx context.Context, webhookID string, in ReplayJobRequest) (*ReplayJob, error) {
+	var out ReplayJob
+	if err := c.do(ctx, startJob, []string{webhookID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListReplayJobs returns the batch replays of a webhook, newest first.
+func (c *Client) ListReplayJobs(ctx context.Context, webhookID string) ([]ReplayJob, error) {
+	var out []ReplayJob
+	if err := c.do(ctx, listJobs, []string{webhookID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetReplayJob returns a batch replay with its progress.
+func (c *Client) GetReplayJob(ctx context.Context, webhookID, jobID string) (*ReplayJob, error) {
+	return c.jobCall(ctx, getJob, webhookID, jobID)
+}
+
+// ReplayJobItems returns the requests of a batch replay in order, with the
+// results of those already sent.
+func (c *Client) ReplayJobItems(ctx context.Context, webhookID, jobID string) ([]ReplayJobItem, error) {
+	var out []ReplayJobItem
+	if err := c.do(ctx, listJobItems, []string{webhookID, jobID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PauseReplayJob stops a batch replay from sending more requests until it is resumed.
+func (c *Client) PauseReplayJob(ctx context.Context, webhookID, jobID string) (*ReplayJob, error) {
+	return c.jobCall(ctx, pauseJob, webhookID, jobID)
+}
+
+// ResumeReplayJob lets a paused batch replay continue.
+func (c *Client) ResumeReplayJob(ctx context.Context, webhookID, jobID string) (*ReplayJob, error) {
+	return c.jobCall(ctx, resumeJob, webhookID, jobID)
+}
+
+// CancelReplayJob ends a running or paused batch replay.
+func (c *Client) CancelReplayJob(ctx context.Context, webhookID, jobID string) (*ReplayJob, error) {
+	return c.jobCall(ctx, cancelJob, webhookID, jobID)
+}
+
+func (c *Client) jobCall(ctx context.Context, ep endpoint, webhookID, jobID string) (*ReplayJob, error) {
+	var out ReplayJob
+	if err := c.do(ctx, ep, []string{webhookID, jobID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
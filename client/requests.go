@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+)
+
+// ListRequests returns one page of a webhook's requests, newest first.
+func (c *Client) ListRequests(ctx context.Context, webhookID string, opts ListOptions) (*RequestPage, error) {
+	q := url.Values{}
+	if opts.Page > 0 {
+		q.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(opts.PerPage))
+	}
+	var out RequestPage
+	if err := c.do(ctx, listRequests, []string{webhookID}, q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Requests iterates over every request of a webhook, newest first, fetching
+// pages of perPage as needed. Iteration stops at the first error.
+//
+//	for wr, err := range c.Requests(ctx, id, 100) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) Requests(ctx context.Context, webhookID string, perPage int) iter.Seq2[WebhookRequest, error] {
+	return func(yield func(WebhookRequest, error) bool) {
+		for page := 1; ; page++ {
+			p, err := c.ListRequests(ctx, webhookID, ListOptions{Page: page, PerPage: perPage})
+			if err != nil {
+				yield(WebhookRequest{}, err)
+				return
+			}
+			for _, wr := range p.Data {
+				if !yield(wr, nil) {
+					return
+				}
+			}
+			if !p.HasNext() || len(p.Data) == 0 {
+				return
+			}
+		}
+	}
+}
+
+// GetRequest returns one request received by a webhook.
+func (c *Client) GetRequest(ctx context.Context, webhookID, requestID string) (*WebhookRequest, error) {
+	var out WebhookRequest
+	if err := c.do(ctx, getRequest, []string{webhookID, requestID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SnippetOptions select what GetSnippets renders.
+type SnippetOptions struct {
+	// Languages limits the snippets to these languages: curl, httpie, go,
+	// python, node or powershell. Empty means all of them.
+	Languages []string
+	// Target is the URL the snippets send the request to. Empty means the
+	// webhook that captured it.
+	Target string
+}
+
+// GetSnippets renders one request as code that sends it again.
+func (c *Client) GetSnippets(ctx context.Context, webhookID, requestID string, opts SnippetOptions) ([]Snippet, error) {
+	q := url.Values{}
+	for _, l := range opts.Languages {
+		q.Add("lang", l)
+	}
+	if opts.Target != "" {
+		q.Set("target", opts.Target)
+	}
+	var out []Snippet
+	if err := c.do(ctx, getSnippets, []string{webhookID, requestID}, q, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteRequest deletes one request received by a webhook.
+func (c *Client) DeleteRequest(ctx context.Context, webhookID, requestID string) error {
+	return c.do(ctx, deleteRequest, []string{webhookID, requestID}, nil, nil, nil)
+}
+
+// DeleteRequests deletes every request received by a webhook.
+func (c *Client) DeleteRequests(ctx context.Context, webhookID string) error {
+	return c.do(ctx, deleteRequests, []string{webhookID}, nil, nil, nil)
+}
@@ -0,0 +1,17 @@
+package client
+
+import "context"
+
+// GetScriptState returns the state a webhook's script keeps between requests.
+func (c *Client) GetScriptState(ctx context.Context, webhookID string) (map[string]any, error) {
+	var out ScriptState
+	if err := c.do(ctx, getScriptState, []string{webhookID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Data, nil
+}
+
+// ResetScriptState empties the state of a webhook's script.
+func (c *Client) ResetScriptState(ctx context.Context, webhookID string) error {
+	return c.do(ctx, resetScript, []string{webhookID}, nil, nil, nil)
+}
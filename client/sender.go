@@ -0,0 +1,70 @@
+package client
+
+import "context"
+
+// ListEventTemplates returns the provider events the server can send, each
+// rendered with fresh IDs and timestamps.
+func (c *Client) ListEventTemplates(ctx context.Context) ([]EventTemplate, error) {
+	var out []EventTemplate
+	if err := c.do(ctx, listTemplates, nil, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SendEvent sends an event from a webhook to in.Target. The server stores it
+// as a request of the webhook with Source "sent". Not reaching the target
+// isn't an error: the delivery's Error says what went wrong.
+func (c *Client) SendEvent(ctx context.Context, webhookID string, in SendEventRequest) (*SentEvent, error) {
+	var out SentEvent
+	if err := c.do(ctx, sendEvent, []string{webhookID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartDelivery stores an event like SendEvent and delivers it in the
+// background, retrying as in.Policy says until the target answers 2xx.
+func (c *Client) StartDelivery(ctx context.Context, webhookID string, in DeliveryRequest) (*Delivery, error) {
+	var out Delivery
+	if err := c.do(ctx, startDelivery, []string{webhookID}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListDeliveries returns the deliveries of a webhook, newest first.
+func (c *Client) ListDeliveries(ctx context.Context, webhookID string) ([]Delivery, error) {
+	var out []Delivery
+	if err := c.do(ctx, listDeliveries, []string{webhookID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetDelivery returns a delivery with its progress.
+func (c *Client) GetDelivery(ctx context.Context, webhookID, deliveryID string) (*Delivery, error) {
+	return c.deliveryCall(ctx, getDelivery, webhookID, deliveryID)
+}
+
+// DeliveryAttempts returns the attempts of a delivery in order.
+func (c *Client) DeliveryAttempts(ctx context.Context, webhookID, deliveryID string) ([]DeliveryAttempt, error) {
+	var out []DeliveryAttempt
+	if err := c.do(ctx, listAttempts, []string{webhookID, deliveryID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CancelDelivery stops a delivery from making more attempts.
+func (c *Client) CancelDelivery(ctx context.Context, webhookID, deliveryID string) (*Delivery, error) {
+	return c.deliveryCall(ctx, cancelDelivery, webhookID, deliveryID)
+}
+
+func (c *Client) deliveryCall(ctx context.Context, ep endpoint, webhookID, deliveryID string) (*Delivery, error) {
+	var out Delivery
+	if err := c.do(ctx, ep, []string{webhookID, deliveryID}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
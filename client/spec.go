@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"webhook-tester/spec"
+)
+
+// ApplyOptions control ApplySpec.
+type ApplyOptions struct {
+	// Prune deletes webhooks that the file doesn't mention.
+	Prune bool
+	// DryRun only plans the changes.
+	DryRun bool
+}
+
+// ExportSpec describes the user's webhooks as a spec file.
+func (c *Client) ExportSpec(ctx context.Context) (*spec.File, error) {
+	var out spec.File
+	if err := c.do(ctx, exportSpec, nil, url.Values{"format": {spec.FormatJSON}}, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ApplySpec syncs the user's webhooks to f and returns the plan that was
+// carried out, or only planned when opts.DryRun is set.
+func (c *Client) ApplySpec(ctx context.Context, f *spec.File, opts ApplyOptions) (*spec.Plan, error) {
+	q := url.Values{
+		"prune":   {strconv.FormatBool(opts.Prune)},
+		"dry_run": {strconv.FormatBool(opts.DryRun)},
+	}
+	var out spec.Plan
+	if err := c.do(ctx, applySpec, nil, q, f, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"webhook-tester/docs"
+	"webhook-tester/spec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// swaggerSpec is the subset of docs/swagger.json the drift check needs.
+type swaggerSpec struct {
+	Paths       map[string]map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	} `json:"definitions"`
+}
+
+func loadSpec(t *testing.T) swaggerSpec {
+	raw, err := os.ReadFile("../docs/swagger.json")
+	require.NoError(t, err)
+	var doc swaggerSpec
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	return doc
+}
+
+// TestServedSpecIsJSON renders the spec the server serves at
+// /docs/swagger.json. Template syntax in an annotation breaks the rendering,
+// which then serves the raw template instead.
+func TestServedSpecIsJSON(t *testing.T) {
+	var served swaggerSpec
+	require.NoError(t, json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &served))
+	assert.Equal(t, loadSpec(t), served)
+}
+
+// TestEndpointsMatchSpec fails when a route is added to or removed from the
+// API without updating the client, or the other way round.
+func TestEndpointsMatchSpec(t *testing.T) {
+	doc := loadSpec(t)
+
+	var documented []string
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	var implemented []string
+	for _, ep := range endpoints {
+		implemented = append(implemented, ep.method+" "+ep.path)
+	}
+	sort.Strings(documented)
+	sort.Strings(implemented)
+	assert.Equal(t, documented, implemented, "client endpoints differ from docs/swagger.json")
+}
+
+// TestTypesMatchSpec fails when a model's JSON fields drift from the spec.
+func TestTypesMatchSpec(t *testing.T) {
+	doc := loadSpec(t)
+	types := map[string]any{
+		"Webhook":                Webhook{},
+		"WebhookRequest":         WebhookRequest{},
+		"CreateWebhookRequest":   CreateWebhookRequest{},
+		"UpdateWebhookRequest":   UpdateWebhookRequest{},
+		"PatchWebhookRequest":    PatchWebhookRequest{},
+		"FaultConfig":            FaultConfig{},
+		"ScriptState":            ScriptState{},
+		"RequestPage":            RequestPage{},
+		"ImportResult":           ImportResult{},
+		"Snippet":                Snippet{},
+		"ReplayRequest":          ReplayRequest{},
+		"ReplayAttempt":          ReplayAttempt{},
+		"ReplayJobRequest":       ReplayJobRequest{},
+		"ReplayJob":              ReplayJob{},
+		"ReplayJobItem":          ReplayJobItem{},
+		"CompareRequest":         CompareRequest{},
+		"Difference":             Difference{},
+		"Comparison":             Comparison{},
+		"ComparisonBatchRequest": ComparisonBatchRequest{},
+		"ComparisonBatch":        ComparisonBatch{},
+		"PathCount":              PathCount{},
+		"ComparisonBatchResults": ComparisonBatchResults{},
+		"EventTemplate":          EventTemplate{},
+		"SendEventRequest":       SendEventRequest{},
+		"SentEvent":              SentEvent{},
+		"DeliveryRequest":        DeliveryRequest{},
+		"Delivery":               Delivery{},
+		"DeliveryAttempt":        DeliveryAttempt{},
+		"LoadRunRequest":         LoadRunRequest{},
+		"LoadRun":                LoadRun{},
+		"CallbackActionRequest":  CallbackActionRequest{},
+		"CallbackAction":         CallbackAction{},
+		"Callback":               Callback{},
+		"StatusCount":            StatusCount{},
+		"ErrorCount":             ErrorCount{},
+		"Problem":                problem{},
+		"SpecFile":               spec.File{},
+		"SpecWebhook":            spec.Webhook{},
+		"SpecPlan":               spec.Plan{},
+		"SpecChange":             spec.Change{},
+	}
+	for name, v := range types {
+		def, ok := doc.Definitions[name]
+		if !assert.True(t, ok, "definition %s missing from docs/swagger.json", name) {
+			continue
+		}
+		var want []string
+		for prop := range def.Properties {
+			want = append(want, prop)
+		}
+		sort.Strings(want)
+		assert.Equal(t, want, jsonFields(reflect.TypeOf(v)), "fields of %s", name)
+	}
+}
+
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func TestExpand(t *testing.T) {
+	assert.Equal(t, "/webhooks/a%2Fb/requests/r1", expand(getRequest.path, []string{"a/b", "r1"}))
+	assert.Equal(t, http.MethodGet, getRequest.method)
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+)
+
+// Tail streams requests as the webhook receives them, until ctx is cancelled
+// or the connection drops. Iteration stops at the first error; a cancelled
+// ctx ends it without one.
+//
+//	for wr, err := range c.Tail(ctx, id) {
+//		...
+//	}
+func (c *Client) Tail(ctx context.Context, webhookID string) iter.Seq2[WebhookRequest, error] {
+	return func(yield func(WebhookRequest, error) bool) {
+		req, err := http.NewRequestWithContext(ctx, streamRequests.method, c.baseURL+expand(streamRequests.path, []string{webhookID}), nil)
+		if err != nil {
+			yield(WebhookRequest{}, err)
+			return
+		}
+		req.Header.Set("X-API-Key", c.apiKey)
+		req.Header.Set("Accept", "text/event-stream")
+
+		// the stream is long-lived, so the client's overall timeout must not apply
+		hc := *c.httpClient
+		hc.Timeout = 0
+		resp, err := hc.Do(req)
+		if err != nil {
+			if ctx.Err() == nil {
+				yield(WebhookRequest{}, err)
+			}
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			yield(WebhookRequest{}, newAPIError(resp))
+			return
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64<<10), 16<<20)
+		var data strings.Builder
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "data:"):
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			case line == "" && data.Len() > 0:
+				var wr WebhookRequest
+				if err := json.Unmarshal([]byte(data.String()), &wr); err != nil {
+					yield(WebhookRequest{}, fmt.Errorf("decoding event: %w", err))
+					return
+				}
+				data.Reset()
+				if !yield(wr, nil) {
+					return
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			yield(WebhookRequest{}, err)
+		}
+	}
+}
@@ -0,0 +1,562 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook mirrors the Webhook definition in docs/swagger.json.
+type Webhook struct {
+	ID              string            `json:"id"`
+	Title           string            `json:"title"`
+	ResponseCode    int               `json:"response_code"`
+	ResponseDelay   uint              `json:"response_delay"` // milliseconds
+	ContentType     string            `json:"content_type"`
+	Payload         string            `json:"payload"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	NotifyOnEvent   bool              `json:"notify_on_event"`
+	RetentionCount  uint              `json:"retention_count"`
+	RetentionDays   uint              `json:"retention_days"`
+	SigningScheme   string            `json:"signing_scheme"` // the secret is never returned
+	Faults          FaultConfig       `json:"faults"`
+	Script          string            `json:"script"`
+	UserID          int               `json:"user_id"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	Requests        []WebhookRequest  `json:"requests"`
+}
+
+// WebhookRequest mirrors the WebhookRequest definition in docs/swagger.json.
+type WebhookRequest struct {
+	ID         string            `json:"id"`
+	WebhookID  string            `json:"webhook_id"`
+	Method     string            `json:"method"`
+	Headers    map[string]string `json:"headers"`
+	Query      map[string]string `json:"query"`
+	Body       string            `json:"body"`
+	Pinned     bool              `json:"pinned"`
+	Size       int64             `json:"size"`
+	Source     string            `json:"source"`                   // "live", "import" or "sent"
+	Fault      string            `json:"fault,omitempty"`          // fault injected into the response, see FaultConfig
+	FaultDelay int64             `json:"fault_delay_ms,omitempty"` // random delay injected, milliseconds
+	ScriptLog  string            `json:"script_log,omitempty"`     // what the webhook's script printed, and its error
+	ReceivedAt time.Time         `json:"received_at"`
+}
+
+// CreateWebhookRequest mirrors the CreateWebhookRequest definition in docs/swagger.json.
+type CreateWebhookRequest struct {
+	Title           string            `json:"title"`
+	ResponseCode    int               `json:"response_code"`  // 0 uses 200
+	ResponseDelay   uint              `json:"response_delay"` // milliseconds
+	ContentType     string            `json:"content_type"`
+	Payload         string            `json:"payload"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	NotifyOnEvent   bool              `json:"notify_on_event"`
+	RetentionCount  uint              `json:"retention_count"` // keep the last N requests, 0 keeps all
+	RetentionDays   uint              `json:"retention_days"`  // keep requests for D days, 0 keeps all
+	SigningScheme   string            `json:"signing_scheme"`  // "github", "stripe", "shopify" or "slack"; replays and sent events are signed with it
+	SigningSecret   string            `json:"signing_secret"`  // write-only
+	Faults          FaultConfig       `json:"faults"`          // the zero FaultConfig injects no faults
+	Script          string            `json:"script"`          // Starlark script defining handle(request)
+}
+
+// UpdateWebhookRequest mirrors the UpdateWebhookRequest definition in docs/swagger.json.
+// It replaces every field; omitted fields are reset to their defaults.
+type UpdateWebhookRequest struct {
+	Title           string            `json:"title"`
+	ResponseCode    int               `json:"response_code"`  // 0 uses 200
+	ResponseDelay   uint              `json:"response_delay"` // milliseconds
+	ContentType     string            `json:"content_type"`
+	Payload         string            `json:"payload"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	NotifyOnEvent   bool              `json:"notify_on_event"`
+	RetentionCount  uint              `json:"retention_count"` // keep the last N requests, 0 keeps all
+	RetentionDays   uint              `json:"retention_days"`  // keep requests for D days, 0 keeps all
+	SigningScheme   string            `json:"signing_scheme"`  // "github", "stripe", "shopify" or "slack"; replays and sent events are signed with it
+	SigningSecret   string            `json:"signing_secret"`  // write-only
+	Faults          FaultConfig       `json:"faults"`          // the zero FaultConfig injects no faults
+	Script          string            `json:"script"`          // Starlark script defining handle(request)
+}
+
+// PatchWebhookRequest mirrors the PatchWebhookRequest definition in
+// docs/swagger.json. Only fields built with Set or Null are sent.
+//
+//	c.PatchWebhook(ctx, id, client.PatchWebhookRequest{
+//		ResponseCode: client.Set(503),
+//		Payload:      client.Null[string](),
+//	})
+type PatchWebhookRequest struct {
+	Title           Field[string]            `json:"title,omitzero"`
+	ResponseCode    Field[int]               `json:"response_code,omitzero"`
+	ResponseDelay   Field[uint]              `json:"response_delay,omitzero"`
+	ContentType     Field[string]            `json:"content_type,omitzero"`
+	Payload         Field[string]            `json:"payload,omitzero"`
+	ResponseHeaders Field[map[string]string] `json:"response_headers,omitzero"`
+	NotifyOnEvent   Field[bool]              `json:"notify_on_event,omitzero"`
+	RetentionCount  Field[uint]              `json:"retention_count,omitzero"`
+	RetentionDays   Field[uint]              `json:"retention_days,omitzero"`
+	SigningScheme   Field[string]            `json:"signing_scheme,omitzero"`
+	SigningSecret   Field[string]            `json:"signing_secret,omitzero"`
+	Faults          Field[FaultConfig]       `json:"faults,omitzero"` // replaces the whole fault config
+	Script          Field[string]            `json:"script,omitzero"`
+}
+
+// FaultConfig mirrors the FaultConfig definition in docs/swagger.json. It
+// makes a webhook's responses fail, to test how senders retry. Percentages
+// are between 0 and 100.
+type FaultConfig struct {
+	ErrorPercent      float64 `json:"error_percent,omitempty"`
+	ErrorStatus       int     `json:"error_status,omitempty"` // default 500
+	FailEvery         int     `json:"fail_every,omitempty"`   // every N-th request gets the error status
+	DelayMinMs        int     `json:"delay_min_ms,omitempty"`
+	DelayMaxMs        int     `json:"delay_max_ms,omitempty"`
+	DelayDistribution string  `json:"delay_distribution,omitempty"`   // "uniform" (default), "normal" or "exponential"
+	RateLimit         int     `json:"rate_limit,omitempty"`           // requests answered per window; later ones get 429
+	RateLimitWindowMs int     `json:"rate_limit_window_ms,omitempty"` // default 60000
+	RetryAfter        int     `json:"retry_after,omitempty"`          // seconds; default until the window ends
+	TruncatePercent   float64 `json:"truncate_percent,omitempty"`
+	DripPercent       float64 `json:"drip_percent,omitempty"`
+	DripIntervalMs    int     `json:"drip_interval_ms,omitempty"` // between bytes, default 100
+	DisconnectPercent float64 `json:"disconnect_percent,omitempty"`
+}
+
+// Field is a PATCH field that is either omitted (the zero Field), null or a value.
+type Field[T any] struct {
+	set   bool
+	null  bool
+	value T
+}
+
+// Set returns a field that changes the value to v.
+func Set[T any](v T) Field[T] { return Field[T]{set: true, value: v} }
+
+// Null returns a field that resets the value to its server default.
+func Null[T any]() Field[T] { return Field[T]{set: true, null: true} }
+
+// IsZero reports whether the field is omitted.
+func (f Field[T]) IsZero() bool { return !f.set }
+
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	if f.null || !f.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(f.value)
+}
+
+// RequestPage mirrors the RequestPage definition in docs/swagger.json.
+type RequestPage struct {
+	Data    []WebhookRequest `json:"data"`
+	Page    int              `json:"page"`
+	PerPage int              `json:"per_page"`
+	Total   int64            `json:"total"`
+}
+
+// HasNext reports whether more pages follow this one.
+func (p *RequestPage) HasNext() bool {
+	return int64(p.Page*p.PerPage) < p.Total
+}
+
+// Snippet mirrors the Snippet definition in docs/swagger.json.
+type Snippet struct {
+	Language string `json:"language"`
+	Label    string `json:"label"`
+	Code     string `json:"code"`
+}
+
+// ImportResult mirrors the ImportResult definition in docs/swagger.json.
+type ImportResult struct {
+	Imported int `json:"imported"`
+}
+
+// ReplayRequest mirrors the ReplayRequest definition in docs/swagger.json.
+// Omitted fields keep what was captured.
+type ReplayRequest struct {
+	Target        string            `json:"target,omitempty"` // default the webhook URL
+	Method        string            `json:"method,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	RemoveHeaders []string          `json:"remove_headers,omitempty"`
+	Query         map[string]string `json:"query,omitempty"`
+	RemoveQuery   []string          `json:"remove_query,omitempty"`
+	Body          *string           `json:"body,omitempty"`
+	Resign        bool              `json:"resign,omitempty"` // recompute signature headers with the webhook's signing secret
+	TimeoutMs     int               `json:"timeout_ms,omitempty"`
+}
+
+// ReplayAttempt mirrors the ReplayAttempt definition in docs/swagger.json.
+type ReplayAttempt struct {
+	ID                string            `json:"id"`
+	RequestID         string            `json:"request_id"`
+	Method            string            `json:"method"`
+	URL               string            `json:"url"`
+	RequestHeaders    map[string]string `json:"request_headers"`
+	RequestBody       string            `json:"request_body"`
+	StatusCode        int               `json:"status_code"` // 0 when no response arrived
+	ResponseHeaders   map[string]string `json:"response_headers"`
+	ResponseBody      string            `json:"response_body"`
+	ResponseTruncated bool              `json:"response_truncated"`
+	DurationMs        int64             `json:"duration_ms"`
+	Error             string            `json:"error,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+}
+
+// ReplayJobRequest mirrors the ReplayJobRequest definition in
+// docs/swagger.json. Omitted selection fields match every request.
+type ReplayJobRequest struct {
+	Target        string     `json:"target,omitempty"` // default the webhook URL
+	Since         *time.Time `json:"since,omitempty"`
+	Until         *time.Time `json:"until,omitempty"`
+	IDs           []string   `json:"ids,omitempty"`
+	Mode          string     `json:"mode,omitempty"`  // "timing" (default) or "rate"
+	Speed         float64    `json:"speed,omitempty"` // timing mode
+	Rate          float64    `json:"rate,omitempty"`  // rate mode, requests per second
+	Concurrency   int        `json:"concurrency,omitempty"`
+	StopOnFailure bool       `json:"stop_on_failure,omitempty"`
+	Resign        bool       `json:"resign,omitempty"`
+	TimeoutMs     int        `json:"timeout_ms,omitempty"`
+}
+
+// ReplayJob mirrors the ReplayJob definition in docs/swagger.json.
+type ReplayJob struct {
+	ID            string     `json:"id"`
+	WebhookID     string     `json:"webhook_id"`
+	Target        string     `json:"target"`
+	Mode          string     `json:"mode"`
+	Speed         float64    `json:"speed,omitempty"`
+	Rate          float64    `json:"rate,omitempty"`
+	Concurrency   int        `json:"concurrency"`
+	StopOnFailure bool       `json:"stop_on_failure"`
+	Resign        bool       `json:"resign"`
+	TimeoutMs     int64      `json:"timeout_ms"`
+	Status        string     `json:"status"`
+	Total         int        `json:"total"`
+	Succeeded     int        `json:"succeeded"`
+	Failed        int        `json:"failed"`
+	Skipped       int        `json:"skipped"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the job has ended.
+func (j *ReplayJob) Done() bool {
+	return j.Status != "running" && j.Status != "paused"
+}
+
+// ReplayJobItem mirrors the ReplayJobItem definition in docs/swagger.json.
+type ReplayJobItem struct {
+	Seq        int        `json:"seq"`
+	RequestID  string     `json:"request_id"`
+	OffsetMs   int64      `json:"offset_ms"`
+	Status     string     `json:"status"` // pending, succeeded, failed or skipped
+	AttemptID  string     `json:"attempt_id,omitempty"`
+	StatusCode int        `json:"status_code,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+}
+
+// CompareRequest is the body of Client.Compare.
+type CompareRequest struct {
+	Baseline  string   `json:"baseline"`
+	Candidate string   `json:"candidate"`
+	Ignore    []string `json:"ignore,omitempty"` // like body.id, body.items[*].id or body.**.created_at
+	Resign    bool     `json:"resign,omitempty"`
+	TimeoutMs int      `json:"timeout_ms,omitempty"`
+}
+
+// Difference mirrors the Difference definition in docs/swagger.json.
+// Baseline and Candidate hold JSON values, nil on the side that lacks one.
+type Difference struct {
+	Path      string `json:"path"`
+	Change    string `json:"change"` // changed, added or removed
+	Baseline  any    `json:"baseline,omitempty"`
+	Candidate any    `json:"candidate,omitempty"`
+}
+
+// Comparison mirrors the Comparison definition in docs/swagger.json.
+type Comparison struct {
+	ID                 string       `json:"id"`
+	RequestID          string       `json:"request_id"`
+	BatchID            string       `json:"batch_id,omitempty"`
+	Baseline           string       `json:"baseline"`
+	Candidate          string       `json:"candidate"`
+	Ignore             []string     `json:"ignore"`
+	BaselineAttemptID  string       `json:"baseline_attempt_id"`
+	CandidateAttemptID string       `json:"candidate_attempt_id"`
+	BaselineStatus     int          `json:"baseline_status"`
+	CandidateStatus    int          `json:"candidate_status"`
+	Equal              bool         `json:"equal"`
+	Differences        []Difference `json:"differences"`
+	Error              string       `json:"error,omitempty"`
+	CreatedAt          time.Time    `json:"created_at"`
+}
+
+// ComparisonBatchRequest is the body of Client.StartComparisonBatch. Empty
+// selection fields match every request.
+type ComparisonBatchRequest struct {
+	Baseline    string     `json:"baseline"`
+	Candidate   string     `json:"candidate"`
+	Ignore      []string   `json:"ignore,omitempty"`
+	Since       *time.Time `json:"since,omitempty"`
+	Until       *time.Time `json:"until,omitempty"`
+	IDs         []string   `json:"ids,omitempty"`
+	Concurrency int        `json:"concurrency,omitempty"`
+	Resign      bool       `json:"resign,omitempty"`
+	TimeoutMs   int        `json:"timeout_ms,omitempty"`
+}
+
+// ComparisonBatch mirrors the ComparisonBatch definition in docs/swagger.json.
+type ComparisonBatch struct {
+	ID          string     `json:"id"`
+	WebhookID   string     `json:"webhook_id"`
+	Baseline    string     `json:"baseline"`
+	Candidate   string     `json:"candidate"`
+	Ignore      []string   `json:"ignore"`
+	Resign      bool       `json:"resign"`
+	TimeoutMs   int64      `json:"timeout_ms"`
+	Concurrency int        `json:"concurrency"`
+	Status      string     `json:"status"`
+	Total       int        `json:"total"`
+	Equal       int        `json:"equal"`
+	Different   int        `json:"different"`
+	Failed      int        `json:"failed"`
+	Skipped     int        `json:"skipped"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the batch has ended.
+func (b *ComparisonBatch) Done() bool {
+	return b.Status != "running"
+}
+
+// PathCount mirrors the PathCount definition in docs/swagger.json.
+type PathCount struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+}
+
+// ComparisonBatchResults mirrors the ComparisonBatchResults definition in docs/swagger.json.
+type ComparisonBatchResults struct {
+	Paths       []PathCount  `json:"paths"`
+	Comparisons []Comparison `json:"comparisons"`
+}
+
+// EventTemplate mirrors the EventTemplate definition in docs/swagger.json.
+// Headers and Body are rendered with fresh IDs and timestamps.
+type EventTemplate struct {
+	Name     string            `json:"name"`
+	Title    string            `json:"title"`
+	Provider string            `json:"provider"`
+	Scheme   string            `json:"scheme,omitempty"` // the signing scheme the provider uses
+	Method   string            `json:"method"`
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body"`
+}
+
+// SendEventRequest is the body of Client.SendEvent. Omitted fields come from
+// the template; without one the method is POST.
+type SendEventRequest struct {
+	Target    string            `json:"target,omitempty"` // default the webhook URL
+	Template  string            `json:"template,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"` // set over the template's headers
+	Body      *string           `json:"body,omitempty"`
+	Sign      bool              `json:"sign,omitempty"` // sign with the webhook's signing secret
+	TimeoutMs int               `json:"timeout_ms,omitempty"`
+}
+
+// SentEvent mirrors the SentEvent definition in docs/swagger.json.
+type SentEvent struct {
+	Request  WebhookRequest `json:"request"`
+	Delivery ReplayAttempt  `json:"delivery"`
+}
+
+// DeliveryRequest is the body of Client.StartDelivery: a SendEventRequest
+// and how to retry it. Policy is "none" (the default), "exponential" or "custom".
+type DeliveryRequest struct {
+	Target         string            `json:"target,omitempty"` // default the webhook URL
+	Template       string            `json:"template,omitempty"`
+	Method         string            `json:"method,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"` // set over the template's headers
+	Body           *string           `json:"body,omitempty"`
+	Sign           bool              `json:"sign,omitempty"` // sign each attempt with the webhook's signing secret
+	TimeoutMs      int               `json:"timeout_ms,omitempty"`
+	Policy         string            `json:"policy,omitempty"`
+	MaxAttempts    int               `json:"max_attempts,omitempty"`     // exponential
+	InitialDelayMs int               `json:"initial_delay_ms,omitempty"` // exponential
+	ScheduleMs     []int             `json:"schedule_ms,omitempty"`      // custom
+	BudgetMs       int               `json:"budget_ms,omitempty"`
+	DeliveryHeader string            `json:"delivery_header,omitempty"`
+}
+
+// Delivery mirrors the Delivery definition in docs/swagger.json.
+type Delivery struct {
+	ID            string     `json:"id"`
+	WebhookID     string     `json:"webhook_id"`
+	RequestID     string     `json:"request_id"`
+	Target        string     `json:"target"`
+	Policy        string     `json:"policy"`
+	ScheduleMs    []int64    `json:"schedule_ms"`
+	MaxAttempts   int        `json:"max_attempts"`
+	Sign          bool       `json:"sign"`
+	TimeoutMs     int64      `json:"timeout_ms"`
+	BudgetMs      int64      `json:"budget_ms,omitempty"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	StatusCode    int        `json:"status_code,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the delivery has ended.
+func (d *Delivery) Done() bool {
+	return d.Status != "delivering"
+}
+
+// DeliveryAttempt mirrors the DeliveryAttempt definition in docs/swagger.json.
+type DeliveryAttempt struct {
+	Seq        int       `json:"seq"`
+	AttemptID  string    `json:"attempt_id,omitempty"`
+	Succeeded  bool      `json:"succeeded"`
+	StatusCode int       `json:"status_code,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// LoadRunRequest is the body of Client.StartLoadRun. Set RequestID or
+// Template, and Count, DurationMs or both.
+type LoadRunRequest struct {
+	Target      string  `json:"target"`
+	RequestID   string  `json:"request_id,omitempty"` // a captured request, sent as captured
+	Template    string  `json:"template,omitempty"`   // rendered afresh for every request
+	Sign        bool    `json:"sign,omitempty"`       // sign every request with the webhook's signing secret
+	Concurrency int     `json:"concurrency,omitempty"`
+	Rate        float64 `json:"rate,omitempty"` // requests started per second
+	DurationMs  int     `json:"duration_ms,omitempty"`
+	Count       int     `json:"count,omitempty"`
+	TimeoutMs   int     `json:"timeout_ms,omitempty"`
+}
+
+// LoadRun mirrors the LoadRun definition in docs/swagger.json.
+type LoadRun struct {
+	ID          string        `json:"id"`
+	WebhookID   string        `json:"webhook_id"`
+	RequestID   string        `json:"request_id,omitempty"`
+	Template    string        `json:"template,omitempty"`
+	Sign        bool          `json:"sign"`
+	Target      string        `json:"target"`
+	Concurrency int           `json:"concurrency"`
+	Rate        float64       `json:"rate,omitempty"`
+	DurationMs  int64         `json:"duration_ms,omitempty"`
+	Count       int           `json:"count,omitempty"`
+	TimeoutMs   int64         `json:"timeout_ms"`
+	Status      string        `json:"status"`
+	Requests    int           `json:"requests"`
+	Succeeded   int           `json:"succeeded"`
+	Failed      int           `json:"failed"`
+	Errors      int           `json:"errors"`
+	StatusCodes []StatusCount `json:"status_codes"`
+	ErrorCounts []ErrorCount  `json:"error_counts"`
+	MinMs       float64       `json:"min_ms"`
+	MeanMs      float64       `json:"mean_ms"`
+	P50Ms       float64       `json:"p50_ms"`
+	P90Ms       float64       `json:"p90_ms"`
+	P95Ms       float64       `json:"p95_ms"`
+	P99Ms       float64       `json:"p99_ms"`
+	MaxMs       float64       `json:"max_ms"`
+	ElapsedMs   int64         `json:"elapsed_ms"`
+	Throughput  float64       `json:"throughput"`
+	Error       string        `json:"error,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	FinishedAt  *time.Time    `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the run has ended.
+func (r *LoadRun) Done() bool {
+	return r.Status != "running"
+}
+
+// StatusCount mirrors the StatusCount definition in docs/swagger.json.
+type StatusCount struct {
+	Code  int `json:"code"`
+	Count int `json:"count"`
+}
+
+// ErrorCount mirrors the ErrorCount definition in docs/swagger.json.
+type ErrorCount struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// CallbackActionRequest is the body of Client.CreateCallbackAction and
+// Client.UpdateCallbackAction. Set URL, URLFrom or both; Headers and Body are
+// templates rendered with the received request.
+type CallbackActionRequest struct {
+	Name      string            `json:"name"`
+	Enabled   *bool             `json:"enabled,omitempty"` // default true
+	DelayMs   int64             `json:"delay_ms,omitempty"`
+	Method    string            `json:"method,omitempty"`   // default POST
+	URL       string            `json:"url,omitempty"`      // used when URLFrom is empty or not in the request
+	URLFrom   string            `json:"url_from,omitempty"` // like "body.callback_url"
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Sign      bool              `json:"sign,omitempty"` // sign with the webhook's signing secret
+	TimeoutMs int64             `json:"timeout_ms,omitempty"`
+}
+
+// CallbackAction mirrors the CallbackAction definition in docs/swagger.json.
+type CallbackAction struct {
+	ID        string            `json:"id"`
+	WebhookID string            `json:"webhook_id"`
+	Name      string            `json:"name"`
+	Enabled   bool              `json:"enabled"`
+	DelayMs   int64             `json:"delay_ms"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url,omitempty"`
+	URLFrom   string            `json:"url_from,omitempty"`
+	Headers   map[string]string `json:"headers"`
+	Body      string            `json:"body"`
+	Sign      bool              `json:"sign"`
+	TimeoutMs int64             `json:"timeout_ms"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Callback mirrors the Callback definition in docs/swagger.json.
+type Callback struct {
+	ID         string     `json:"id"`
+	WebhookID  string     `json:"webhook_id"`
+	ActionID   string     `json:"action_id"`
+	ActionName string     `json:"action_name"`
+	RequestID  string     `json:"request_id"`
+	Status     string     `json:"status"`
+	DueAt      time.Time  `json:"due_at"`
+	URL        string     `json:"url,omitempty"`
+	AttemptID  string     `json:"attempt_id,omitempty"` // the replay attempt recording the exchange
+	StatusCode int        `json:"status_code,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+}
+
+// Done reports whether the callback was sent, failed or was cancelled.
+func (c *Callback) Done() bool {
+	return c.Status != "scheduled"
+}
+
+// ScriptState mirrors the ScriptState definition in docs/swagger.json.
+type ScriptState struct {
+	Data map[string]any `json:"data"`
+}
+
+// ListOptions selects a page of results. Zero values use the server defaults.
+type ListOptions struct {
+	Page    int
+	PerPage int
+}
@@ -0,0 +1,53 @@
+package client
+
+import "context"
+
+// ListWebhooks returns the webhooks owned by the API key's user.
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var out []Webhook
+	if err := c.do(ctx, listWebhooks, nil, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreateWebhook creates a webhook. Create requests are never retried.
+func (c *Client) CreateWebhook(ctx context.Context, in CreateWebhookRequest) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, createWebhook, nil, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWebhook returns a webhook by ID.
+func (c *Client) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, getWebhook, []string{id}, nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateWebhook replaces every field of a webhook and returns the result.
+func (c *Client) UpdateWebhook(ctx context.Context, id string, in UpdateWebhookRequest) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, updateWebhook, []string{id}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PatchWebhook changes the fields set in in and returns the result.
+func (c *Client) PatchWebhook(ctx context.Context, id string, in PatchWebhookRequest) (*Webhook, error) {
+	var out Webhook
+	if err := c.do(ctx, patchWebhook, []string{id}, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWebhook deletes a webhook and all of its requests.
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.do(ctx, deleteWebhook, []string{id}, nil, nil, nil)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"github.com/robfig/cron"
 	"gorm.io/gorm"
 	"log"
@@ -11,6 +13,7 @@ import (
 	"time"
 	"webhook-tester/cmd/server"
 	"webhook-tester/internal/metrics"
+	"webhook-tester/internal/service"
 )
 
 func scheduleCleanup(db *gorm.DB, c *cron.Cron) {
@@ -23,6 +26,27 @@ func scheduleCleanup(db *gorm.DB, c *cron.Cron) {
 	}
 }
 
+func scheduleRetention(svc *service.RetentionService, l *log.Logger, c *cron.Cron) {
+	// apply per-webhook retention every hour
+	err := c.AddFunc("@hourly", func() {
+		removed, err := svc.EnforceAll()
+		if err != nil {
+			l.Printf("error applying retention: %s", err)
+			return
+		}
+		l.Printf("retention removed %d requests", removed)
+	})
+	if err != nil {
+		log.Fatalf("error scheduling retention: %s", err)
+	}
+}
+
+func setEnvFlag(key, value string) {
+	if value != "" {
+		os.Setenv(key, value)
+	}
+}
+
 // @title Webhook Tester API
 // @version 1.0
 // @description REST API to interact with webhooks and webhook requests
@@ -35,6 +59,34 @@ func scheduleCleanup(db *gorm.DB, c *cron.Cron) {
 // @in header
 // @name X-API-Key
 func main() {
+	cmd := "serve"
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+
+	switch cmd {
+	case "serve":
+		serve(os.Args[2:])
+	case "migrate":
+		runMigrate(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\nusage: webhook-tester [serve|migrate]\n", cmd)
+		os.Exit(2)
+	}
+}
+
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := fs.String("port", "", "port to listen on (env PORT, default 3000)")
+	driver := fs.String("db", "", "database driver: sqlite, postgres or memory (env DB_DRIVER)")
+	sqlitePath := fs.String("sqlite-path", "", "SQLite database file (env SQLITE_PATH, default webhook-tester.db)")
+	_ = fs.Parse(args)
+
+	// flags take precedence over the environment and .env
+	setEnvFlag("PORT", *port)
+	setEnvFlag("DB_DRIVER", *driver)
+	setEnvFlag("SQLITE_PATH", *sqlitePath)
+
 	s := server.NewServer()
 	s.MountHandlers()
 	metrics.Register()
@@ -46,11 +98,12 @@ func main() {
 		}
 	}()
 
-	s.Logger.Printf("server listening on port 3000")
+	s.Logger.Printf("server listening on port %s", os.Getenv("PORT"))
 
 	// cron setup
 	c := cron.New()
 	scheduleCleanup(s.DB, c)
+	scheduleRetention(s.Retention, s.Logger, c)
 	c.Start()
 	defer c.Stop()
 
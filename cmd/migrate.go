@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"webhook-tester/config"
+	"webhook-tester/internal/db"
+)
+
+const migrateUsage = `usage: webhook-tester migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down [N]      revert the last N migrations (default 1)
+  status        list migrations and whether they are applied
+  force VERSION mark VERSION as the last applied migration without running SQL`
+
+// runMigrate implements the `migrate` subcommand.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	config.LoadEnv()
+	logger := log.New(os.Stdout, "[migrate] ", log.LstdFlags)
+	m, err := db.NewMigrator(db.Connect(), logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		logger.Printf("applied %d migrations", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				logger.Fatalf("invalid step count %q", args[1])
+			}
+		}
+		reverted, err := m.Down(steps)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		logger.Printf("reverted %d migrations", reverted)
+	case "status":
+		list, err := m.Status()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, st := range list {
+			status, appliedAt := "pending", ""
+			if st.Applied {
+				status = "applied"
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05 UTC")
+			}
+			if st.Dirty {
+				status = "dirty"
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", st.Version, st.Name, status, appliedAt)
+		}
+		tw.Flush()
+	case "force":
+		if len(args) < 2 {
+			logger.Fatal("force requires a version")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			logger.Fatalf("invalid version %q", args[1])
+		}
+		if err := m.Force(version); err != nil {
+			logger.Fatal(err)
+		}
+		logger.Printf("schema forced to version %d", version)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
@@ -9,47 +9,165 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	metrics "github.com/slok/go-http-metrics/metrics/prometheus"
 	metricsMiddleware "github.com/slok/go-http-metrics/middleware"
+	"webhook-tester/internal/outbound"
 	"webhook-tester/internal/routers"
 	"webhook-tester/internal/service"
 	"webhook-tester/internal/store"
 
+	"github.com/gorilla/sessions"
 	"github.com/slok/go-http-metrics/middleware/std"
 	"github.com/wader/gormstore/v2"
+	"webhook-tester/internal/repository"
 
 	"gorm.io/gorm"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 	"webhook-tester/config"
-	_ "webhook-tester/docs"
+	"webhook-tester/docs"
 	"webhook-tester/internal/db"
 	appMetrics "webhook-tester/internal/metrics"
+	"webhook-tester/static"
 )
 
 type Server struct {
 	Router       *chi.Mux
 	DB           *gorm.DB
-	SessionStore *gormstore.Store
+	SessionStore sessions.Store
 	Logger       *log.Logger
 	Srv          *http.Server
+	Retention    *service.RetentionService
+}
+
+// defaultStorageQuota reads the per-user storage quota from USER_STORAGE_QUOTA_MB.
+func defaultStorageQuota(l *log.Logger) int64 {
+	const fallbackMB = 100
+	raw := os.Getenv("USER_STORAGE_QUOTA_MB")
+	if raw == "" {
+		return fallbackMB << 20
+	}
+	mb, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || mb < 0 {
+		l.Printf("invalid USER_STORAGE_QUOTA_MB %q, using %d MB", raw, fallbackMB)
+		return fallbackMB << 20
+	}
+	return mb << 20
+}
+
+// outboundClient builds the client for requests made on users' behalf from
+// OUTBOUND_ALLOW and OUTBOUND_DENY. The server's own DOMAIN is always
+// allowed, so requests can be replayed to their webhook.
+func outboundClient(l *log.Logger) *outbound.Client {
+	policy, err := outbound.PolicyFromEnv()
+	if err != nil {
+		l.Fatalf("invalid outbound policy: %v", err)
+	}
+	if u, err := url.Parse(os.Getenv("DOMAIN")); err == nil && u.Hostname() != "" {
+		port := u.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+		}
+		policy.AllowHosts = append(policy.AllowHosts, outbound.HostPort{Host: strings.ToLower(u.Hostname()), Port: port})
+	}
+	return outbound.New(policy)
+}
+
+// repositories holds one storage backend.
+type repositories struct {
+	webhooks   repository.WebhookRepository
+	requests   repository.WebhookRequestRepository
+	users      repository.UserRepository
+	replays    repository.ReplayAttemptRepository
+	jobs       repository.ReplayJobRepository
+	compares   repository.ComparisonRepository
+	deliveries repository.DeliveryRepository
+	loadRuns   repository.LoadRunRepository
+	callbacks  repository.CallbackRepository
+	scripts    repository.ScriptStateRepository
+}
+
+// repositories returns GORM repositories, or in-memory ones in ephemeral mode.
+func (srv *Server) repositories() repositories {
+	if srv.DB == nil {
+		mem := store.NewMemoryDB()
+		return repositories{
+			webhooks:   store.NewMemoryWebhookRepo(mem),
+			requests:   store.NewMemoryWebhookRequestRepo(mem),
+			users:      store.NewMemoryUserRepo(mem),
+			replays:    store.NewMemoryReplayAttemptRepo(mem),
+			jobs:       store.NewMemoryReplayJobRepo(mem),
+			compares:   store.NewMemoryComparisonRepo(mem),
+			deliveries: store.NewMemoryDeliveryRepo(mem),
+			loadRuns:   store.NewMemoryLoadRunRepo(mem),
+			callbacks:  store.NewMemoryCallbackRepo(mem),
+			scripts:    store.NewMemoryScriptStateRepo(mem),
+		}
+	}
+	return repositories{
+		webhooks:   store.NewGormWebookRepo(srv.DB, srv.Logger),
+		requests:   store.NewGormWebhookRequestRepo(srv.DB, srv.Logger),
+		users:      store.NewGormUserRepo(srv.DB, srv.Logger),
+		replays:    store.NewGormReplayAttemptRepo(srv.DB, srv.Logger),
+		jobs:       store.NewGormReplayJobRepo(srv.DB, srv.Logger),
+		compares:   store.NewGormComparisonRepo(srv.DB, srv.Logger),
+		deliveries: store.NewGormDeliveryRepo(srv.DB, srv.Logger),
+		loadRuns:   store.NewGormLoadRunRepo(srv.DB, srv.Logger),
+		callbacks:  store.NewGormCallbackRepo(srv.DB, srv.Logger),
+		scripts:    store.NewGormScriptStateRepo(srv.DB, srv.Logger),
+	}
 }
 
 func (srv *Server) MountHandlers() {
 	r := srv.Router
-	authSecret := os.Getenv("AUTH_SECRET")
-	repo := store.NewGormWebookRepo(srv.DB, srv.Logger)
-	userRepo := store.NewGormUserRepo(srv.DB, srv.Logger)
-	webhookReqRepo := store.NewGormWebhookRequestRepo(srv.DB, srv.Logger)
-	webhookSvc := service.NewWebhookService(repo)
-	webhookReqSvc := service.NewWebhookRequestService(webhookReqRepo)
-	authSvc := service.NewAuthService(userRepo, srv.DB, authSecret)
+	repos := srv.repositories()
+	webhookSvc := service.NewWebhookService(repos.webhooks)
+	webhookReqSvc := service.NewWebhookRequestService(repos.requests)
+	authSvc := service.NewAuthService(repos.users, srv.SessionStore)
+	retentionSvc := service.NewRetentionService(repos.webhooks, repos.requests, repos.users, defaultStorageQuota(srv.Logger))
+	replaySvc := service.NewReplayService(repos.replays, repos.webhooks, outboundClient(srv.Logger))
+	replayJobSvc := service.NewReplayJobService(repos.jobs, repos.requests, replaySvc, srv.Logger)
+	if n, err := replayJobSvc.FailInterrupted(); err != nil {
+		srv.Logger.Printf("failed to mark interrupted replay jobs: %v", err)
+	} else if n > 0 {
+		srv.Logger.Printf("marked %d replay jobs interrupted by the last shutdown as failed", n)
+	}
+	comparisonSvc := service.NewComparisonService(repos.compares, repos.requests, replaySvc, srv.Logger)
+	if n, err := comparisonSvc.FailInterrupted(); err != nil {
+		srv.Logger.Printf("failed to mark interrupted comparison batches: %v", err)
+	} else if n > 0 {
+		srv.Logger.Printf("marked %d comparison batches interrupted by the last shutdown as failed", n)
+	}
+	senderSvc := service.NewSenderService(repos.requests, replaySvc, retentionSvc, srv.Logger)
+	deliverySvc := service.NewDeliveryService(repos.deliveries, senderSvc, replaySvc, srv.Logger)
+	if n, err := deliverySvc.FailInterrupted(); err != nil {
+		srv.Logger.Printf("failed to mark interrupted deliveries: %v", err)
+	} else if n > 0 {
+		srv.Logger.Printf("marked %d deliveries interrupted by the last shutdown as failed", n)
+	}
+	loadSvc := service.NewLoadService(repos.loadRuns, repos.requests, replaySvc, srv.Logger)
+	if n, err := loadSvc.FailInterrupted(); err != nil {
+		srv.Logger.Printf("failed to mark interrupted load runs: %v", err)
+	} else if n > 0 {
+		srv.Logger.Printf("marked %d load runs interrupted by the last shutdown as failed", n)
+	}
+	callbackSvc := service.NewCallbackService(repos.callbacks, replaySvc, srv.Logger)
+	if n, err := callbackSvc.FailInterrupted(); err != nil {
+		srv.Logger.Printf("failed to mark interrupted callbacks: %v", err)
+	} else if n > 0 {
+		srv.Logger.Printf("marked %d callbacks interrupted by the last shutdown as failed", n)
+	}
+	scriptSvc := service.NewScriptService(repos.scripts)
+	srv.Retention = retentionSvc
 	metricsRec := appMetrics.PrometheusRecorder{}
 	// Basic CORS
 	// for more ideas, see: https://developer.github.com/v3/#cross-origin-resource-sharing
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
@@ -68,19 +186,23 @@ func (srv *Server) MountHandlers() {
 	// Instrument all routes
 	r.Use(std.HandlerProvider("", mdlw))
 
-	// Static file server for /static/*
-	fs := http.FileServer(http.Dir("static"))
+	// Static file server for /static/*, embedded in the binary
+	fs := http.FileServer(http.FS(static.FS))
 	r.Handle("/static/*", http.StripPrefix("/static/", fs))
 
-	r.Mount("/", routers.NewWebRouter(webhookReqSvc, webhookSvc, authSvc, &metricsRec, srv.Logger))
+	r.Mount("/", routers.NewWebRouter(webhookReqSvc, webhookSvc, authSvc, retentionSvc, replaySvc, replayJobSvc, comparisonSvc, senderSvc, deliverySvc, loadSvc, callbackSvc, scriptSvc, &metricsRec, srv.Logger))
 
-	r.Mount("/api", routers.NewApiRouter(webhookSvc, authSvc, srv.Logger, &metricsRec))
-	r.Mount("/webhooks", routers.NewWebhookRouter(webhookSvc, authSvc, srv.Logger, &metricsRec))
+	r.Mount("/api", routers.NewApiRouter(webhookSvc, webhookReqSvc, authSvc, retentionSvc, replaySvc, replayJobSvc, comparisonSvc, senderSvc, deliverySvc, loadSvc, callbackSvc, scriptSvc, srv.Logger, &metricsRec))
+	r.Mount("/webhooks", routers.NewWebhookRouter(webhookSvc, authSvc, retentionSvc, callbackSvc, scriptSvc, srv.Logger, &metricsRec))
 
 	// metrics
 	r.Handle("/metrics", promhttp.Handler())
 
 	// API documentation
+	r.Get("/docs/swagger.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, docs.SwaggerInfo.ReadDoc())
+	})
 	r.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
 		htmlContent, err := scalar.ApiReferenceHTML(&scalar.Options{
 			SpecURL: "./docs/swagger.json",
@@ -100,20 +222,37 @@ func (srv *Server) MountHandlers() {
 
 func NewServer() *Server {
 	config.LoadEnv()
-	conn := db.Connect()
-	db.AutoMigrate(conn)
+	config.ApplyDefaults()
+	logger := log.New(os.Stdout, "[server] ", log.LstdFlags)
+	authSecret := []byte(os.Getenv("AUTH_SECRET"))
+
+	var conn *gorm.DB
+	var sessionStore sessions.Store
+	if db.Driver() == db.DriverMemory {
+		logger.Printf("ephemeral mode: data is kept in memory and lost on exit")
+		sessionStore = sessions.NewCookieStore(authSecret)
+	} else {
+		conn = db.Connect()
+		logger.Printf("using %s database", db.Driver())
+		db.Migrate(conn, logger)
+
+		gs := gormstore.New(conn, authSecret)
+		go gs.PeriodicCleanup(48*time.Hour, nil)
+		sessionStore = gs
+	}
 
 	r := chi.NewRouter()
 	srv := http.Server{
-		Addr:        ":3000",
+		Addr:        ":" + os.Getenv("PORT"),
 		Handler:     r,
 		IdleTimeout: time.Minute,
 	}
 
 	return &Server{
-		Router: r,
-		DB:     conn,
-		Logger: log.New(os.Stdout, "[server] ", log.LstdFlags),
-		Srv:    &srv,
+		Router:       r,
+		DB:           conn,
+		SessionStore: sessionStore,
+		Logger:       logger,
+		Srv:          &srv,
 	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+)
+
+func callbacksCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("callbacks", flag.ExitOnError)
+	request := fs.String("request", "", "only the callbacks of this received request")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+	if *request == "" {
+		actions, err := a.api.ListCallbackActions(ctx, pos[0])
+		if err != nil {
+			return err
+		}
+		if len(actions) == 0 {
+			fmt.Fprintln(a.out, a.out.paint(dim, "no callback actions; add them in the web UI or with the API"))
+			return nil
+		}
+		tw := a.out.table()
+		fmt.Fprintln(tw, "ACTION\tID\tAFTER\tCALLS")
+		for _, ac := range actions {
+			name := ac.Name
+			if !ac.Enabled {
+				name += a.out.paint(dim, " (disabled)")
+			}
+			to := ac.URL
+			if ac.URLFrom != "" && ac.URL != "" {
+				to = ac.URLFrom + " or " + ac.URL
+			} else if ac.URLFrom != "" {
+				to = ac.URLFrom
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%dms\t%s %s\n", name, ac.ID, ac.DelayMs, ac.Method, to)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	callbacks, err := a.api.ListCallbacks(ctx, pos[0], *request)
+	if err != nil {
+		return err
+	}
+	if len(callbacks) == 0 {
+		return nil
+	}
+	fmt.Fprintln(a.out)
+	// oldest first, like requests
+	for i := len(callbacks) - 1; i >= 0; i-- {
+		a.out.callback(callbacks[i])
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+	"webhook-tester/client"
+)
+
+// errDifferent makes whctl exit non-zero when the responses differ, so a
+// script can gate on a comparison.
+var errDifferent = errors.New("the responses differ")
+
+// listFlag collects comma-separated or repeated values.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// compareFlags registers the flags shared by compare and compare-batch.
+func compareFlags(fs *flag.FlagSet) (baseline, candidate *string, ignore *listFlag, resign *bool, timeout *time.Duration) {
+	baseline = fs.String("baseline", "", "URL of the current service (required)")
+	candidate = fs.String("candidate", "", "URL of the service to check against it (required)")
+	ignore = &listFlag{}
+	fs.Var(ignore, "ignore", "paths that may differ, like body.id,body.items[*].id or body.**.created_at (repeatable)")
+	resign = fs.Bool("resign", false, "recompute the signature headers with the webhook's signing secret")
+	timeout = fs.Duration("timeout", 0, "how long to wait for each response (default 30s)")
+	return
+}
+
+func compareCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	baseline, candidate, ignore, resign, timeout := compareFlags(fs)
+	pos, err := parse(fs, args, "ID", "REQUEST_ID")
+	if err != nil {
+		return err
+	}
+
+	cmp, err := a.api.Compare(ctx, pos[0], pos[1], client.CompareRequest{
+		Baseline: *baseline, Candidate: *candidate, Ignore: *ignore, Resign: *resign, TimeoutMs: int(timeout.Milliseconds()),
+	})
+	if err != nil {
+		return err
+	}
+	a.out.comparison(*cmp)
+	if cmp.Error != "" {
+		return errors.New(cmp.Error)
+	}
+	if !cmp.Equal {
+		return errDifferent
+	}
+	return nil
+}
+
+func compareBatchCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("compare-batch", flag.ExitOnError)
+	baseline, candidate, ignore, resign, timeout := compareFlags(fs)
+	since := fs.String("since", "", "only requests received after this RFC 3339 time or duration ago, e.g. 2h")
+	until := fs.String("until", "", "only requests received before this RFC 3339 time or duration ago")
+	concurrency := fs.Int("concurrency", 0, "requests compared at once at most (default 4)")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+
+	in := client.ComparisonBatchRequest{
+		Baseline: *baseline, Candidate: *candidate, Ignore: *ignore, Resign: *resign,
+		TimeoutMs: int(timeout.Milliseconds()), Concurrency: *concurrency,
+	}
+	for _, t := range []struct {
+		name, value string
+		dst         **time.Time
+	}{{"since", *since, &in.Since}, {"until", *until, &in.Until}} {
+		v, err := parseTime(t.value)
+		if err != nil {
+			return fmt.Errorf("-%s: %w", t.name, err)
+		}
+		if !v.IsZero() {
+			*t.dst = &v
+		}
+	}
+
+	batch, err := a.api.StartComparisonBatch(ctx, pos[0], in)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "comparing %d requests (batch %s)\n", batch.Total, batch.ID)
+	for !batch.Done() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+		if batch, err = a.api.GetComparisonBatch(ctx, pos[0], batch.ID); err != nil {
+			return err
+		}
+	}
+
+	results, err := a.api.ComparisonBatchResults(ctx, pos[0], batch.ID)
+	if err != nil {
+		return err
+	}
+	if len(results.Paths) > 0 {
+		tw := a.out.table()
+		fmt.Fprintln(tw, "REQUESTS\tPATH")
+		for _, p := range results.Paths {
+			fmt.Fprintf(tw, "%d\t%s\n", p.Count, p.Path)
+		}
+		tw.Flush()
+	}
+	for _, c := range results.Comparisons {
+		if c.Error != "" {
+			fmt.Fprintf(a.out, "%s %s\n", c.RequestID, a.out.paint(bold+red, "failed: "+c.Error))
+		}
+	}
+
+	summary := fmt.Sprintf("%s: %d equal, %d different, %d failed", batch.Status, batch.Equal, batch.Different, batch.Failed)
+	if batch.Skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", batch.Skipped)
+	}
+	fmt.Fprintln(a.out, summary)
+	switch {
+	case batch.Error != "":
+		return errors.New(batch.Error)
+	case batch.Different > 0 || batch.Failed > 0:
+		return errDifferent
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+	"webhook-tester/client"
+)
+
+func loadCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	to := fs.String("to", "", "URL to fire the requests at")
+	tmpl := fs.String("template", "", `event template rendered afresh for every request; see "whctl templates"`)
+	request := fs.String("request", "", "stored request to send instead of a template")
+	sign := fs.Bool("sign", false, "sign every request with the webhook's signing secret")
+	concurrency := fs.Int("concurrency", 0, "requests in flight at most (default 10)")
+	rate := fs.Float64("rate", 0, "start at most this many requests per second (default no limit)")
+	n := fs.Int("n", 0, "stop after this many requests")
+	duration := fs.Duration("duration", 0, "stop after this long")
+	timeout := fs.Duration("timeout", 0, "how long to wait for each response (default 30s)")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+
+	run, err := a.api.StartLoadRun(ctx, pos[0], client.LoadRunRequest{
+		Target: *to, RequestID: *request, Template: *tmpl, Sign: *sign, Concurrency: *concurrency, Rate: *rate,
+		DurationMs: int(duration.Milliseconds()), Count: *n, TimeoutMs: int(timeout.Milliseconds()),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "firing at %s, %d at once (load run %s)\n", run.Target, run.Concurrency, run.ID)
+
+	// print progress every second; Ctrl-C cancels the run
+	for !run.Done() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+		run, err = a.api.GetLoadRun(ctx, pos[0], run.ID)
+		if errors.Is(err, context.Canceled) {
+			run, err = a.api.CancelLoadRun(context.Background(), pos[0], run.ID)
+			if errors.Is(err, client.ErrConflict) {
+				run, err = a.api.GetLoadRun(context.Background(), pos[0], run.ID)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if !run.Done() {
+			a.out.loadProgress(run)
+		}
+	}
+
+	a.out.loadRun(run)
+	if run.Error != "" {
+		return errors.New(run.Error)
+	}
+	return nil
+}
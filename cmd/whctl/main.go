@@ -0,0 +1,160 @@
+// Command whctl manages webhook-tester webhooks from the terminal.
+//
+// It talks to the REST API, authenticating with the API key from
+// WEBHOOK_TESTER_API_KEY (or -api-key) against the server in
+// WEBHOOK_TESTER_URL (or -server, default http://localhost:3000).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"webhook-tester/client"
+)
+
+const usage = `usage: whctl [-server URL] [-api-key KEY] [-no-color] <command> [arguments]
+
+commands:
+  list                          list webhooks
+  create [flags]                create a webhook
+  get ID                        show a webhook
+  update ID [flags]             change the given fields of a webhook
+  delete ID                     delete a webhook and its requests
+  requests ID [-n N]            show the latest requests
+  tail ID [-body=false]         print requests live as they arrive
+  replay ID REQUEST_ID -to URL  send a stored request to another URL: -X, -H and -d edit it,
+                                -record sends it from the server and stores the response
+  replays ID REQUEST_ID         list the recorded replays of a request
+  replay-batch ID [flags]       replay the stored requests in order from the server, keeping
+                                their timing (-speed) or at a fixed -rate, and follow progress
+  compare ID REQUEST_ID         send a stored request to -baseline and -candidate URLs and show how
+                                the responses differ; exits 1 when they do (-ignore skips paths)
+  compare-batch ID [flags]      compare every stored request (-since, -until) and count the
+                                paths that differ
+  templates [NAME]              list the event templates, or print the body of one
+  send ID -template NAME        send a provider event (GitHub, Stripe, Shopify, Slack, CloudEvents)
+                                to -to URL; -sign signs it with the webhook's secret, -retry
+                                retries it like a provider and follows the attempts
+  load ID -to URL [flags]       fire a -template or stored -request at a URL, -concurrency at once
+                                and at most -rate per second, for -n requests or -duration, and
+                                show status codes, errors and latency percentiles
+  callbacks ID [-request ID]    list the callback actions of a webhook and the callbacks they made
+  script-state ID [-reset]      print the state the webhook's script keeps, or empty it
+  snippet ID REQUEST_ID         print a request as code: -lang curl, httpie, go, python, node, powershell
+  export ID [-format F] [-o F]  write requests as json, jsonl, har, csv, postman or insomnia
+  import ID FILE [-format F]    store the requests of a har or json(l) file
+  open ID                       open the webhook in the browser
+  config [-format F] [-o F]     write all webhooks as a yaml or json spec
+  plan FILE [-prune]            show what applying a spec would change
+  apply FILE [-prune]           create, update (and prune) webhooks to match a spec
+
+Run "whctl <command> -h" for the flags of a command.
+`
+
+// app carries what every command needs.
+type app struct {
+	api       *client.Client
+	serverURL string
+	out       *printer
+	status    io.Writer // progress notes, kept out of the output
+}
+
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]command{
+	"list":          listCmd,
+	"create":        createCmd,
+	"get":           getCmd,
+	"update":        updateCmd,
+	"delete":        deleteCmd,
+	"requests":      requestsCmd,
+	"tail":          tailCmd,
+	"replay":        replayCmd,
+	"replays":       replaysCmd,
+	"replay-batch":  replayBatchCmd,
+	"compare":       compareCmd,
+	"compare-batch": compareBatchCmd,
+	"templates":     templatesCmd,
+	"send":          sendCmd,
+	"load":          loadCmd,
+	"callbacks":     callbacksCmd,
+	"script-state":  scriptStateCmd,
+	"snippet":       snippetCmd,
+	"export":        exportCmd,
+	"import":        importCmd,
+	"open":          openCmd,
+	"config":        configCmd,
+	"plan":          planCmd,
+	"apply":         applyCmd,
+}
+
+func main() {
+	fs := flag.NewFlagSet("whctl", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	server := fs.String("server", envOr("WEBHOOK_TESTER_URL", "http://localhost:3000"), "webhook-tester base URL (env WEBHOOK_TESTER_URL)")
+	apiKey := fs.String("api-key", os.Getenv("WEBHOOK_TESTER_API_KEY"), "API key (env WEBHOOK_TESTER_API_KEY)")
+	noColor := fs.Bool("no-color", false, "disable coloured output (env NO_COLOR)")
+	_ = fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	name, args := fs.Arg(0), fs.Args()[1:]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+
+	a := &app{
+		api:       client.New(*server, *apiKey),
+		serverURL: *server,
+		out:       newPrinter(os.Stdout, *noColor),
+		status:    os.Stderr,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd(ctx, a, args); err != nil && !errors.Is(err, context.Canceled) {
+		if errors.Is(err, client.ErrUnauthorized) && *apiKey == "" {
+			err = fmt.Errorf("%w (set WEBHOOK_TESTER_API_KEY or pass -api-key)", err)
+		}
+		fmt.Fprintln(os.Stderr, "whctl:", err)
+		os.Exit(1)
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// parse parses a command's flags, which may come before or after its
+// positional arguments, and checks it received one argument per argNames.
+func parse(fs *flag.FlagSet, args []string, argNames ...string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(pos) != len(argNames) {
+		return nil, fmt.Errorf("usage: whctl %s %s", fs.Name(), strings.TrimSpace(strings.Join(argNames, " ")+" [flags]"))
+	}
+	return pos, nil
+}
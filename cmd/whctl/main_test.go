@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"webhook-tester/client"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/outbound"
+	"webhook-tester/internal/routers"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/store"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type noopRecorder struct{}
+
+func (noopRecorder) IncWebhooksCreated()        {}
+func (noopRecorder) IncWebhookRequest(_ string) {}
+func (noopRecorder) IncSignUp()                 {}
+func (noopRecorder) IncLogin()                  {}
+
+// newServer serves the real API and webhook routers over an in-memory store,
+// for the user with API key "key".
+func newServer(t *testing.T) *httptest.Server {
+	mem := store.NewMemoryDB()
+	users := store.NewMemoryUserRepo(mem)
+	require.NoError(t, users.Create(&models.User{Email: "ada@example.com", APIKey: "key"}))
+
+	webhookSvc := service.NewWebhookService(store.NewMemoryWebhookRepo(mem))
+	reqSvc := service.NewWebhookRequestService(store.NewMemoryWebhookRequestRepo(mem))
+	authSvc := service.NewAuthService(users, sessions.NewCookieStore([]byte("secret")))
+	retentionSvc := service.NewRetentionService(store.NewMemoryWebhookRepo(mem), store.NewMemoryWebhookRequestRepo(mem), users, 0)
+	loopback := outbound.Policy{Allow: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}}
+	replaySvc := service.NewReplayService(store.NewMemoryReplayAttemptRepo(mem), store.NewMemoryWebhookRepo(mem), outbound.New(loopback))
+	logger := log.New(io.Discard, "", 0)
+	jobSvc := service.NewReplayJobService(store.NewMemoryReplayJobRepo(mem), store.NewMemoryWebhookRequestRepo(mem), replaySvc, logger)
+	comparisonSvc := service.NewComparisonService(store.NewMemoryComparisonRepo(mem), store.NewMemoryWebhookRequestRepo(mem), replaySvc, logger)
+	senderSvc := service.NewSenderService(store.NewMemoryWebhookRequestRepo(mem), replaySvc, retentionSvc, logger)
+	deliverySvc := service.NewDeliveryService(store.NewMemoryDeliveryRepo(mem), senderSvc, replaySvc, logger)
+	loadSvc := service.NewLoadService(store.NewMemoryLoadRunRepo(mem), store.NewMemoryWebhookRequestRepo(mem), replaySvc, logger)
+	callbackSvc := service.NewCallbackService(store.NewMemoryCallbackRepo(mem), replaySvc, logger)
+	scriptSvc := service.NewScriptService(store.NewMemoryScriptStateRepo(mem))
+
+	r := chi.NewRouter()
+	r.Mount("/api", routers.NewApiRouter(webhookSvc, reqSvc, authSvc, retentionSvc, replaySvc, jobSvc, comparisonSvc, senderSvc, deliverySvc, loadSvc, callbackSvc, scriptSvc, logger, noopRecorder{}))
+	r.Mount("/webhooks", routers.NewWebhookRouter(webhookSvc, authSvc, retentionSvc, callbackSvc, scriptSvc, logger, noopRecorder{}))
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testApp is an app whose output and progress notes are kept for assertions.
+type testApp struct {
+	*app
+	out, status *bytes.Buffer
+}
+
+func newApp(serverURL string) testApp {
+	out, status := &bytes.Buffer{}, &bytes.Buffer{}
+	return testApp{
+		app:    &app{api: client.New(serverURL, "key"), serverURL: serverURL, out: &printer{w: out}, status: status},
+		out:    out,
+		status: status,
+	}
+}
+
+// run runs the command name and returns what it printed.
+func (a testApp) run(t *testing.T, name string, args ...string) string {
+	t.Helper()
+	a.out.Reset()
+	require.NoError(t, commands[name](context.Background(), a.app, args), "whctl %s %s", name, strings.Join(args, " "))
+	return a.out.String()
+}
+
+func TestWebhookCommands(t *testing.T) {
+	srv := newServer(t)
+	a := newApp(srv.URL)
+
+	out := a.run(t, "create", "-title", "orders", "-status", "202", "-header", "X-Env: test", "-fault", "fail_every=3")
+	assert.Contains(t, out, "orders")
+	assert.Contains(t, out, "X-Env: test")
+	list, err := a.api.ListWebhooks(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	id := list[0].ID
+	assert.Equal(t, 202, list[0].ResponseCode)
+	assert.Equal(t, 3, list[0].Faults.FailEvery)
+
+	assert.Contains(t, a.run(t, "list"), id)
+	assert.Contains(t, a.run(t, "get", id), srv.URL+"/webhooks/"+id)
+
+	// flags may come after the positional arguments
+	a.run(t, "update", id, "-title", "payments")
+	got, err := a.api.GetWebhook(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "payments", got.Title)
+	assert.Equal(t, 202, got.ResponseCode, "update changes only the given fields")
+
+	resp, err := http.Post(srv.URL+"/webhooks/"+id+"?n=1", "application/json", strings.NewReader(`{"id":7}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	out = a.run(t, "requests", id)
+	assert.Contains(t, out, "POST")
+	assert.Contains(t, out, "?n=1")
+	assert.Contains(t, out, `"id": 7`, "JSON bodies are pretty-printed")
+	assert.Contains(t, a.run(t, "export", id, "-format", "jsonl"), `"body":"{\"id\":7}"`)
+
+	a.run(t, "delete", id)
+	_, err = a.api.GetWebhook(context.Background(), id)
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+
+	err = commands["get"](context.Background(), a.app, []string{id})
+	assert.True(t, errors.Is(err, client.ErrNotFound), "API errors are returned: %v", err)
+	err = commands["get"](context.Background(), a.app, nil)
+	assert.EqualError(t, err, "usage: whctl get ID [flags]")
+}
+
+func TestSpecCommands(t *testing.T) {
+	srv := newServer(t)
+	a := newApp(srv.URL)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "webhooks.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+version: 1
+webhooks:
+  - id: orders
+    title: Orders
+    signing_scheme: github
+    signing_secret: ${ORDERS_SECRET}
+`), 0o600))
+
+	err := commands["plan"](context.Background(), a.app, []string{file})
+	assert.EqualError(t, err, "spec: webhooks[0].signing_secret: ORDERS_SECRET is not set")
+
+	t.Setenv("ORDERS_SECRET", "s3cret")
+	assert.Contains(t, a.run(t, "plan", file), "Plan: 1 to create")
+	assert.Contains(t, a.run(t, "apply", file), "Applied: 1 created")
+	assert.Contains(t, a.run(t, "apply", file), "0 created, 0 updated, 0 deleted, 1 unchanged")
+
+	exported := filepath.Join(dir, "exported.yaml")
+	a.run(t, "config", "-o", exported)
+	assert.Equal(t, "wrote 1 webhooks to "+exported+"\n", a.status.String())
+	raw, err := os.ReadFile(exported)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "signing_scheme: github")
+	assert.NotContains(t, string(raw), "s3cret")
+	assert.Contains(t, a.run(t, "plan", exported), "Plan: 0 to create, 0 to update")
+}
+
+// sse writes each event to w as a server-sent event.
+func sse(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		fmt.Fprint(w, e)
+	}
+	w.(http.Flusher).Flush()
+}
+
+func TestTailParsesStream(t *testing.T) {
+	defer func(retry time.Duration) { tailRetry = retry }(tailRetry)
+	tailRetry = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		assert.Equal(t, "/api/webhooks/hook/stream", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("X-API-Key"))
+		sse(w,
+			": keep-alive comments are skipped\n\n",
+			`data: {"id": "r1", "method": "POST", "headers": {"Content-Type": "application/json"},`+"\n",
+			`data:  "body": "{\"total\":9.5}", "size": 12}`+"\n\n",
+			`data: {"id": "r2", "method": "DELETE", "query": {"force": "1"}}`+"\n\n",
+		)
+	}))
+	defer srv.Close()
+
+	a := newApp(srv.URL)
+	err := tailCmd(ctx, a.app, []string{"hook"})
+	assert.ErrorIs(t, err, context.Canceled)
+	out := a.out.String()
+	assert.Regexp(t, `POST +r1 \(12 bytes\)`, out)
+	assert.Contains(t, out, "Content-Type: application/json")
+	assert.Contains(t, out, `"total": 9.5`, "split data lines join into one event")
+	assert.Regexp(t, `DELETE +r2 \?force=1`, out)
+	assert.Less(t, strings.Index(out, "r1"), strings.Index(out, "r2"))
+}
+
+func TestTailReconnects(t *testing.T) {
+	defer func(retry, maxRetry time.Duration) { tailRetry, tailMaxRetry = retry, maxRetry }(tailRetry, tailMaxRetry)
+	tailRetry, tailMaxRetry = time.Millisecond, 4*time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			sse(w, `data: {"id": "first", "method": "POST"}`+"\n\n")
+		case 2:
+			http.Error(w, "restarting", http.StatusServiceUnavailable)
+		case 3:
+			sse(w, `data: {"id": "second", "method": "POST"}`+"\n\n")
+		default:
+			cancel()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	a := newApp(srv.URL)
+	err := tailCmd(ctx, a.app, []string{"hook", "-body=false"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Regexp(t, `POST +first`, a.out.String())
+	assert.Regexp(t, `POST +second`, a.out.String())
+	assert.Equal(t, int32(4), calls.Load())
+	status := a.status.String()
+	assert.Contains(t, status, "the stream ended; reconnecting in 1ms")
+	assert.Contains(t, status, "restarting; reconnecting in 2ms", "the wait doubles while failing")
+}
+
+func TestTailStopsOnRefusal(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 404, "code": "not_found", "detail": "webhook not found"}`)
+	}))
+	defer srv.Close()
+
+	a := newApp(srv.URL)
+	err := tailCmd(context.Background(), a.app, []string{"hook"})
+	assert.True(t, errors.Is(err, client.ErrNotFound), "%v", err)
+	assert.Equal(t, int32(1), calls.Load(), "client errors aren't retried")
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"webhook-tester/client"
+	"webhook-tester/spec"
+)
+
+const (
+	reset  = "\033[0m"
+	bold   = "\033[1m"
+	dim    = "\033[2m"
+	red    = "\033[31m"
+	green  = "\033[32m"
+	yellow = "\033[33m"
+	blue   = "\033[34m"
+	purple = "\033[35m"
+	cyan   = "\033[36m"
+)
+
+var methodColors = map[string]string{
+	http.MethodGet:    green,
+	http.MethodPost:   yellow,
+	http.MethodPut:    blue,
+	http.MethodPatch:  purple,
+	http.MethodDelete: red,
+}
+
+// printer writes human-readable output, coloured when w is a terminal.
+type printer struct {
+	w     io.Writer
+	color bool
+}
+
+func newPrinter(w *os.File, noColor bool) *printer {
+	color := !noColor && os.Getenv("NO_COLOR") == ""
+	if fi, err := w.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		color = false
+	}
+	return &printer{w: w, color: color}
+}
+
+func (p *printer) Write(b []byte) (int, error) { return p.w.Write(b) }
+
+func (p *printer) paint(code, s string) string {
+	if !p.color {
+		return s
+	}
+	return code + s + reset
+}
+
+func (p *printer) table() *tabwriter.Writer {
+	return tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+}
+
+func (p *printer) webhook(h *client.Webhook, url string) {
+	tw := p.table()
+	fmt.Fprintf(tw, "%s\t%s\n", p.paint(bold, "ID"), h.ID)
+	fmt.Fprintf(tw, "%s\t%s\n", p.paint(bold, "Title"), h.Title)
+	fmt.Fprintf(tw, "%s\t%s\n", p.paint(bold, "URL"), p.paint(cyan, url))
+	fmt.Fprintf(tw, "%s\t%d\n", p.paint(bold, "Status"), h.ResponseCode)
+	fmt.Fprintf(tw, "%s\t%dms\n", p.paint(bold, "Delay"), h.ResponseDelay)
+	fmt.Fprintf(tw, "%s\t%s\n", p.paint(bold, "Content-Type"), h.ContentType)
+	if h.Payload != "" {
+		fmt.Fprintf(tw, "%s\t%s\n", p.paint(bold, "Payload"), h.Payload)
+	}
+	names := make([]string, 0, len(h.ResponseHeaders))
+	for k := range h.ResponseHeaders {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Fprintf(tw, "%s\t%s: %s\n", p.paint(bold, "Header"), k, h.ResponseHeaders[k])
+	}
+	if h.RetentionCount > 0 || h.RetentionDays > 0 {
+		fmt.Fprintf(tw, "%s\tlast %d requests, %d days (0 = unlimited)\n", p.paint(bold, "Retention"), h.RetentionCount, h.RetentionDays)
+	}
+	if h.SigningScheme != "" {
+		fmt.Fprintf(tw, "%s\t%s\n", p.paint(bold, "Signing"), h.SigningScheme)
+	}
+	if f := faultSummary(h.Faults); f != "" {
+		fmt.Fprintf(tw, "%s\t%s\n", p.paint(bold, "Faults"), p.paint(red, f))
+	}
+	if h.Script != "" {
+		fmt.Fprintf(tw, "%s\t%d lines, answers requests\n", p.paint(bold, "Script"), strings.Count(strings.TrimRight(h.Script, "\n"), "\n")+1)
+	}
+	tw.Flush()
+}
+
+func (p *printer) method(m string) string {
+	code, ok := methodColors[m]
+	if !ok {
+		code = cyan
+	}
+	return p.paint(bold+code, fmt.Sprintf("%-7s", m))
+}
+
+// request prints one received request; full adds headers and body.
+func (p *printer) request(wr client.WebhookRequest, full bool) {
+	line := fmt.Sprintf("%s %s %s", p.paint(dim, wr.ReceivedAt.Local().Format("15:04:05")), p.method(wr.Method), wr.ID)
+	if len(wr.Query) > 0 {
+		line += " " + p.paint(dim, "?"+encodeQuery(wr.Query))
+	}
+	if wr.Fault != "" {
+		line += " " + p.paint(bold+red, "["+wr.Fault+"]")
+	}
+	if wr.FaultDelay > 0 {
+		line += " " + p.paint(dim, fmt.Sprintf("+%dms", wr.FaultDelay))
+	}
+	fmt.Fprintf(p.w, "%s %s\n", line, p.paint(dim, fmt.Sprintf("(%d bytes)", wr.Size)))
+	if !full {
+		return
+	}
+	h := http.Header{}
+	for k, v := range wr.Headers {
+		h.Set(k, v)
+	}
+	p.headers(h)
+	p.body(wr.Body, h.Get("Content-Type"))
+	if wr.ScriptLog != "" {
+		fmt.Fprintf(p.w, "\n  %s\n", p.paint(bold, "Script log"))
+		for _, line := range strings.Split(strings.TrimRight(wr.ScriptLog, "\n"), "\n") {
+			fmt.Fprintln(p.w, "  "+p.paint(dim, line))
+		}
+	}
+	fmt.Fprintln(p.w)
+}
+
+func (p *printer) status(method, url string, code int, took time.Duration) {
+	color := green
+	switch {
+	case code >= 500:
+		color = red
+	case code >= 400:
+		color = yellow
+	}
+	fmt.Fprintf(p.w, "%s %s -> %s %s\n", p.method(method), url,
+		p.paint(bold+color, fmt.Sprintf("%d %s", code, http.StatusText(code))),
+		p.paint(dim, took.Round(time.Millisecond).String()))
+}
+
+// attempt prints a replay attempt recorded by the server; full adds the
+// response headers and body.
+func (p *printer) attempt(a client.ReplayAttempt, full bool) {
+	took := time.Duration(a.DurationMs) * time.Millisecond
+	if a.Error != "" {
+		fmt.Fprintf(p.w, "%s %s -> %s %s\n", p.method(a.Method), a.URL, p.paint(bold+red, "failed: "+a.Error), p.paint(dim, took.String()))
+	} else {
+		p.status(a.Method, a.URL, a.StatusCode, took)
+	}
+	if !full {
+		return
+	}
+	h := http.Header{}
+	for k, v := range a.ResponseHeaders {
+		h.Set(k, v)
+	}
+	p.headers(h)
+	p.body(a.ResponseBody, h.Get("Content-Type"))
+	if a.ResponseTruncated {
+		fmt.Fprintln(p.w, p.paint(dim, "  (only the first MiB of the body was kept)"))
+	}
+}
+
+// jobItem prints the result of one request of a batch replay.
+func (p *printer) jobItem(item client.ReplayJobItem) {
+	took := p.paint(dim, (time.Duration(item.DurationMs) * time.Millisecond).String())
+	switch {
+	case item.Status == "skipped":
+		fmt.Fprintf(p.w, "%4d %s %s\n", item.Seq, item.RequestID, p.paint(dim, "skipped: "+item.Error))
+	case item.StatusCode == 0:
+		fmt.Fprintf(p.w, "%4d %s %s %s\n", item.Seq, item.RequestID, p.paint(bold+red, "failed: "+item.Error), took)
+	default:
+		color := green
+		if item.Status != "succeeded" {
+			color = red
+		}
+		fmt.Fprintf(p.w, "%4d %s %s %s\n", item.Seq, item.RequestID,
+			p.paint(bold+color, fmt.Sprintf("%d %s", item.StatusCode, http.StatusText(item.StatusCode))), took)
+	}
+}
+
+// deliveryAttempt prints one attempt of a delivery.
+func (p *printer) deliveryAttempt(a client.DeliveryAttempt) {
+	took := p.paint(dim, (time.Duration(a.DurationMs) * time.Millisecond).String())
+	at := a.SentAt.Local().Format("15:04:05.000")
+	if a.StatusCode == 0 {
+		fmt.Fprintf(p.w, "%4d %s %s %s\n", a.Seq, at, p.paint(bold+red, "failed: "+a.Error), took)
+		return
+	}
+	color := green
+	if !a.Succeeded {
+		color = red
+	}
+	fmt.Fprintf(p.w, "%4d %s %s %s\n", a.Seq, at, p.paint(bold+color, fmt.Sprintf("%d %s", a.StatusCode, http.StatusText(a.StatusCode))), took)
+}
+
+// callback prints one callback of a callback action.
+func (p *printer) callback(c client.Callback) {
+	prefix := fmt.Sprintf("%s %s %s", p.paint(dim, c.DueAt.Local().Format("2006-01-02 15:04:05")), c.RequestID, c.ActionName)
+	took := p.paint(dim, (time.Duration(c.DurationMs) * time.Millisecond).String())
+	switch {
+	case c.Status == "scheduled" || c.Status == "cancelled":
+		fmt.Fprintf(p.w, "%s %s %s\n", prefix, p.paint(dim, c.Status), p.paint(dim, c.ID))
+	case c.StatusCode == 0:
+		fmt.Fprintf(p.w, "%s %s %s\n", prefix, p.paint(bold+red, "failed: "+c.Error), took)
+	default:
+		color := green
+		if c.Status != "succeeded" {
+			color = red
+		}
+		fmt.Fprintf(p.w, "%s -> %s %s %s\n", prefix, c.URL,
+			p.paint(bold+color, fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode))), took)
+	}
+}
+
+// loadProgress prints one line about a load run that is still going.
+func (p *printer) loadProgress(r *client.LoadRun) {
+	fmt.Fprintf(p.w, "%s %d requests, %d 2xx, %d other, %d errors, %.2f/s, p50 %s\n",
+		p.paint(dim, (time.Duration(r.ElapsedMs)*time.Millisecond).Round(time.Second).String()),
+		r.Requests, r.Succeeded, r.Failed, r.Errors, r.Throughput, millis(r.P50Ms))
+}
+
+// loadRun prints the results of a load run.
+func (p *printer) loadRun(r *client.LoadRun) {
+	fmt.Fprintf(p.w, "%s: %d requests in %s, %.2f per second\n", r.Status, r.Requests,
+		(time.Duration(r.ElapsedMs) * time.Millisecond).String(), r.Throughput)
+	tw := p.table()
+	fmt.Fprintln(tw, "MIN\tMEAN\tP50\tP90\tP95\tP99\tMAX")
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", millis(r.MinMs), millis(r.MeanMs), millis(r.P50Ms),
+		millis(r.P90Ms), millis(r.P95Ms), millis(r.P99Ms), millis(r.MaxMs))
+	tw.Flush()
+	for _, c := range r.StatusCodes {
+		color := green
+		if c.Code < 200 || c.Code > 299 {
+			color = red
+		}
+		fmt.Fprintf(p.w, "%6d %s\n", c.Count, p.paint(bold+color, fmt.Sprintf("%d %s", c.Code, http.StatusText(c.Code))))
+	}
+	for _, e := range r.ErrorCounts {
+		fmt.Fprintf(p.w, "%6d %s\n", e.Count, p.paint(bold+red, e.Error))
+	}
+}
+
+// millis shows a latency in milliseconds as a duration.
+func millis(ms float64) string {
+	return time.Duration(ms * float64(time.Millisecond)).Round(10 * time.Microsecond).String()
+}
+
+// comparison prints the two responses of a comparison and their differences.
+func (p *printer) comparison(c client.Comparison) {
+	for _, side := range []struct {
+		name, url string
+		status    int
+	}{{"baseline ", c.Baseline, c.BaselineStatus}, {"candidate", c.Candidate, c.CandidateStatus}} {
+		status := p.paint(dim, "no response")
+		if side.status != 0 {
+			status = fmt.Sprintf("%d %s", side.status, http.StatusText(side.status))
+		}
+		fmt.Fprintf(p.w, "%s %s -> %s\n", p.paint(dim, side.name), side.url, status)
+	}
+	switch {
+	case c.Error != "":
+		fmt.Fprintln(p.w, p.paint(bold+red, "failed: "+c.Error))
+	case c.Equal:
+		fmt.Fprintln(p.w, p.paint(bold+green, "equal"))
+	default:
+		tw := p.table()
+		fmt.Fprintln(tw, "PATH\tCHANGE\tBASELINE\tCANDIDATE")
+		for _, d := range c.Differences {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.Path, d.Change, jsonValue(d.Baseline), jsonValue(d.Candidate))
+		}
+		tw.Flush()
+	}
+}
+
+// jsonValue shows a difference's value as JSON, or "-" when it's absent.
+func jsonValue(v any) string {
+	if v == nil {
+		return "-"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func (p *printer) headers(h http.Header) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(p.w, "  %s: %s\n", p.paint(cyan, k), strings.Join(h[k], ", "))
+	}
+}
+
+// body prints b, pretty-printing it when it is JSON.
+func (p *printer) body(b, contentType string) {
+	if b == "" {
+		return
+	}
+	var pretty bytes.Buffer
+	if (strings.Contains(contentType, "json") || json.Valid([]byte(b))) &&
+		json.Indent(&pretty, []byte(b), "  ", "  ") == nil {
+		b = pretty.String()
+	}
+	fmt.Fprintf(p.w, "\n  %s\n", b)
+}
+
+func encodeQuery(q map[string]string) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + q[k]
+	}
+	return strings.Join(parts, "&")
+}
+
+var planSymbols = map[spec.Action]struct{ sym, color string }{
+	spec.Create: {"+", green},
+	spec.Update: {"~", yellow},
+	spec.Delete: {"-", red},
+}
+
+// plan prints each change of a spec plan and a summary line.
+func (p *printer) plan(pl *spec.Plan) {
+	for _, c := range pl.Changes {
+		s, ok := planSymbols[c.Action]
+		if !ok {
+			continue
+		}
+		line := fmt.Sprintf("%s %-6s %s", s.sym, c.Action, c.Title)
+		if c.ID != "" {
+			line += " (" + c.ID + ")"
+		}
+		if len(c.Fields) > 0 {
+			line += ": " + strings.Join(c.Fields, ", ")
+		}
+		fmt.Fprintln(p.w, p.paint(s.color, line))
+	}
+
+	format := "%s: %d to create, %d to update, %d to delete, %d unchanged.\n"
+	verb := "Plan"
+	if pl.Applied {
+		format = "%s: %d created, %d updated, %d deleted, %d unchanged.\n"
+		verb = "Applied"
+	}
+	fmt.Fprintf(p.w, format, p.paint(bold, verb),
+		pl.Count(spec.Create), pl.Count(spec.Update), pl.Count(spec.Delete), pl.Count(spec.Unchanged))
+}
+
+// faultSummary lists the fault injection fields that are set, as name=value.
+func faultSummary(f client.FaultConfig) string {
+	var parts []string
+	add := func(name string, v float64) {
+		if v != 0 {
+			parts = append(parts, fmt.Sprintf("%s=%g", name, v))
+		}
+	}
+	add("error_percent", f.ErrorPercent)
+	add("error_status", float64(f.ErrorStatus))
+	add("fail_every", float64(f.FailEvery))
+	add("delay_min_ms", float64(f.DelayMinMs))
+	add("delay_max_ms", float64(f.DelayMaxMs))
+	if f.DelayDistribution != "" {
+		parts = append(parts, "delay_distribution="+f.DelayDistribution)
+	}
+	add("rate_limit", float64(f.RateLimit))
+	add("rate_limit_window_ms", float64(f.RateLimitWindowMs))
+	add("retry_after", float64(f.RetryAfter))
+	add("truncate_percent", f.TruncatePercent)
+	add("drip_percent", f.DripPercent)
+	add("drip_interval_ms", float64(f.DripIntervalMs))
+	add("disconnect_percent", f.DisconnectPercent)
+	return strings.Join(parts, " ")
+}
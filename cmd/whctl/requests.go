@@ -0,0 +1,488 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"webhook-tester/client"
+)
+
+func requestsCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("requests", flag.ExitOnError)
+	n := fs.Int("n", 10, "number of requests to show")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+	page, err := a.api.ListRequests(ctx, pos[0], client.ListOptions{PerPage: *n})
+	if err != nil {
+		return err
+	}
+	// print oldest first so the newest ends up next to the prompt
+	for i := len(page.Data) - 1; i >= 0; i-- {
+		a.out.request(page.Data[i], true)
+	}
+	if page.Total > int64(len(page.Data)) {
+		fmt.Fprintf(a.out, "%d of %d requests shown\n", len(page.Data), page.Total)
+	}
+	return nil
+}
+
+func tailCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	body := fs.Bool("body", true, "print headers and bodies, not only the request line")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.status, "tailing %s, press Ctrl+C to stop\n", a.webhookURL(pos[0]))
+	wait := tailRetry
+	for {
+		var err error
+		for wr, e := range a.api.Tail(ctx, pos[0]) {
+			if err = e; err != nil {
+				break
+			}
+			a.out.request(wr, *body)
+			wait = tailRetry
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// requests the server refuses won't succeed on a retry, unlike a
+		// dropped connection or a restarting server
+		var apiErr *client.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+			return err
+		}
+		reason := "the stream ended"
+		if err != nil {
+			reason = err.Error()
+		}
+		fmt.Fprintf(a.status, "%s; reconnecting in %s\n", reason, wait)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, tailMaxRetry)
+	}
+}
+
+// tail waits tailRetry before reconnecting a dropped stream, twice as long
+// each time it fails again, up to tailMaxRetry.
+var (
+	tailRetry    = time.Second
+	tailMaxRetry = 30 * time.Second
+)
+
+// headerFlags collects repeated -H "Name: value" flags.
+type headerFlags []string
+
+func (h *headerFlags) String() string     { return strings.Join(*h, ", ") }
+func (h *headerFlags) Set(v string) error { *h = append(*h, v); return nil }
+
+func replayCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	to := fs.String("to", "", "URL to send the request to (required unless -record, which defaults to the webhook)")
+	method := fs.String("X", "", "send with this method instead")
+	var headers headerFlags
+	fs.Var(&headers, "H", `set a header, "Name: value"; "Name:" removes it (repeatable)`)
+	data := fs.String("d", "", "send this body instead; @FILE reads it from a file")
+	record := fs.Bool("record", false, "send from the server, which stores the attempt and its response")
+	resign := fs.Bool("resign", false, "recompute the signature headers with the webhook's signing secret (needs -record)")
+	pos, err := parse(fs, args, "ID", "REQUEST_ID")
+	if err != nil {
+		return err
+	}
+	if *to == "" && !*record {
+		return fmt.Errorf("replay needs a target: -to URL")
+	}
+	if *resign && !*record {
+		return fmt.Errorf("-resign needs -record: the signing secret stays on the server")
+	}
+
+	in := client.ReplayRequest{Target: *to, Method: strings.ToUpper(*method), Headers: map[string]string{}, Resign: *resign}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf("-H %q: want \"Name: value\"", h)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if value == "" {
+			in.RemoveHeaders = append(in.RemoveHeaders, name)
+		} else {
+			in.Headers[name] = value
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "d" {
+			in.Body = data
+		}
+	})
+	if in.Body != nil && strings.HasPrefix(*in.Body, "@") {
+		b, err := os.ReadFile(strings.TrimPrefix(*in.Body, "@"))
+		if err != nil {
+			return err
+		}
+		body := string(b)
+		in.Body = &body
+	}
+
+	if *record {
+		attempt, err := a.api.Replay(ctx, pos[0], pos[1], in)
+		if err != nil {
+			return err
+		}
+		a.out.attempt(*attempt, true)
+		return nil
+	}
+
+	wr, err := a.api.GetRequest(ctx, pos[0], pos[1])
+	if err != nil {
+		return err
+	}
+	return replayLocally(ctx, a, wr, in)
+}
+
+// replayLocally sends wr, changed by in, from this machine.
+func replayLocally(ctx context.Context, a *app, wr *client.WebhookRequest, in client.ReplayRequest) error {
+	target, err := url.Parse(in.Target)
+	if err != nil {
+		return fmt.Errorf("invalid target: %w", err)
+	}
+	q := target.Query()
+	for k, v := range wr.Query {
+		q.Set(k, v)
+	}
+	target.RawQuery = q.Encode()
+
+	method, body := wr.Method, wr.Body
+	if in.Method != "" {
+		method = in.Method
+	}
+	if in.Body != nil {
+		body = *in.Body
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range wr.Headers {
+		// let the transport set hop-by-hop and length headers for the new connection
+		switch http.CanonicalHeaderKey(k) {
+		case "Host", "Content-Length", "Connection", "Accept-Encoding":
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	for _, k := range in.RemoveHeaders {
+		req.Header.Del(k)
+	}
+	for k, v := range in.Headers {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	a.out.status(req.Method, target.String(), resp.StatusCode, time.Since(start))
+	a.out.headers(resp.Header)
+	a.out.body(string(respBody), resp.Header.Get("Content-Type"))
+	return nil
+}
+
+func replaysCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("replays", flag.ExitOnError)
+	full := fs.Bool("body", false, "print response headers and bodies too")
+	pos, err := parse(fs, args, "ID", "REQUEST_ID")
+	if err != nil {
+		return err
+	}
+	attempts, err := a.api.ListReplays(ctx, pos[0], pos[1])
+	if err != nil {
+		return err
+	}
+	// oldest first, like requests
+	for i := len(attempts) - 1; i >= 0; i-- {
+		fmt.Fprint(a.out, a.out.paint(dim, attempts[i].CreatedAt.Local().Format("2006-01-02 15:04:05"))+" ")
+		a.out.attempt(attempts[i], *full)
+	}
+	return nil
+}
+
+func replayBatchCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("replay-batch", flag.ExitOnError)
+	to := fs.String("to", "", "URL to send the requests to (default the webhook)")
+	since := fs.String("since", "", "only requests received after this RFC 3339 time or duration ago, e.g. 2h")
+	until := fs.String("until", "", "only requests received before this RFC 3339 time or duration ago")
+	speed := fs.Float64("speed", 0, "keep the original gaps between requests, divided by this (default 1)")
+	rate := fs.Float64("rate", 0, "send this many requests per second instead of keeping the original timing")
+	concurrency := fs.Int("concurrency", 0, "requests in flight at most (default 10, or 1 with -rate)")
+	stop := fs.Bool("stop-on-failure", false, "stop at the first request that fails or gets a non-2xx")
+	resign := fs.Bool("resign", false, "recompute the signature headers with the webhook's signing secret")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+
+	in := client.ReplayJobRequest{Target: *to, Speed: *speed, Rate: *rate, Concurrency: *concurrency, StopOnFailure: *stop, Resign: *resign}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "rate" {
+			in.Mode = "rate"
+		}
+	})
+	for _, t := range []struct {
+		name, value string
+		dst         **time.Time
+	}{{"since", *since, &in.Since}, {"until", *until, &in.Until}} {
+		v, err := parseTime(t.value)
+		if err != nil {
+			return fmt.Errorf("-%s: %w", t.name, err)
+		}
+		if !v.IsZero() {
+			*t.dst = &v
+		}
+	}
+
+	job, err := a.api.StartReplayJob(ctx, pos[0], in)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "replaying %d requests to %s (job %s)\n", job.Total, job.Target, job.ID)
+
+	// print results in order as they come in; Ctrl-C cancels the job
+	printed := 0
+	for {
+		items, err := a.api.ReplayJobItems(ctx, pos[0], job.ID)
+		if err == nil {
+			job, err = a.api.GetReplayJob(ctx, pos[0], job.ID)
+		}
+		if errors.Is(err, context.Canceled) {
+			if _, err := a.api.CancelReplayJob(context.Background(), pos[0], job.ID); err != nil && !errors.Is(err, client.ErrConflict) {
+				return err
+			}
+			fmt.Fprintln(a.out, "cancelled")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for ; printed < len(items) && items[printed].Status != "pending"; printed++ {
+			a.out.jobItem(items[printed])
+		}
+		if job.Done() {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+
+	summary := fmt.Sprintf("%s: %d succeeded, %d failed", job.Status, job.Succeeded, job.Failed)
+	if job.Skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", job.Skipped)
+	}
+	fmt.Fprintln(a.out, summary)
+	if job.Error != "" {
+		return errors.New(job.Error)
+	}
+	return nil
+}
+
+func snippetCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("snippet", flag.ExitOnError)
+	lang := fs.String("lang", "curl", "language: curl, httpie, go, python, node or powershell")
+	to := fs.String("to", "", "URL the snippet sends the request to (default the webhook)")
+	pos, err := parse(fs, args, "ID", "REQUEST_ID")
+	if err != nil {
+		return err
+	}
+	snippets, err := a.api.GetSnippets(ctx, pos[0], pos[1], client.SnippetOptions{Languages: []string{*lang}, Target: *to})
+	if err != nil {
+		return err
+	}
+	for _, s := range snippets {
+		fmt.Fprintln(a.out, strings.TrimSuffix(s.Code, "\n"))
+	}
+	return nil
+}
+
+func exportCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "json", "output format: json, jsonl, har, csv, postman or insomnia")
+	outPath := fs.String("o", "", "output file (default stdout)")
+	since := fs.String("since", "", "only requests received after this RFC 3339 time or duration ago, e.g. 2h")
+	until := fs.String("until", "", "only requests received before this RFC 3339 time or duration ago")
+	method := fs.String("method", "", "only requests with these comma-separated methods")
+	source := fs.String("source", "", "only live or only imported requests: live or import")
+	ids := fs.String("id", "", "only these comma-separated request IDs")
+	name := fs.String("name", "", "collection name for postman and insomnia (default the webhook title)")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+
+	opts := client.ExportOptions{Format: *format, Source: *source, Name: *name}
+	switch *format {
+	case "json":
+		opts.Format = client.FormatJSONL
+	case client.FormatJSONL, client.FormatHAR, client.FormatCSV, client.FormatPostman, client.FormatInsomnia:
+	default:
+		return fmt.Errorf("unknown format %q, want json, jsonl, har, csv, postman or insomnia", *format)
+	}
+	if *ids != "" {
+		opts.IDs = strings.Split(*ids, ",")
+	}
+	if opts.Since, err = parseTime(*since); err != nil {
+		return fmt.Errorf("-since: %w", err)
+	}
+	if opts.Until, err = parseTime(*until); err != nil {
+		return fmt.Errorf("-until: %w", err)
+	}
+	if *method != "" {
+		opts.Methods = strings.Split(strings.ToUpper(*method), ",")
+	}
+
+	body, err := a.api.ExportRequests(ctx, pos[0], opts)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var w io.Writer = a.out
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		err = jsonlToArray(w, body)
+	} else {
+		_, err = io.Copy(w, body)
+	}
+	if err != nil {
+		return err
+	}
+	if *outPath != "" {
+		fmt.Fprintf(a.status, "exported requests to %s\n", *outPath)
+	}
+	return nil
+}
+
+func importCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "file format: har, jsonl or json (default from the file name or contents)")
+	pos, err := parse(fs, args, "ID", "FILE")
+	if err != nil {
+		return err
+	}
+
+	f := *format
+	if f == "" {
+		switch strings.ToLower(filepath.Ext(pos[1])) {
+		case ".har":
+			f = client.FormatHAR
+		case ".jsonl", ".ndjson", ".json":
+			f = client.FormatJSONL
+		}
+	}
+	switch f {
+	case "json":
+		// the server reads arrays as JSONL too
+		f = client.FormatJSONL
+	case "", client.FormatHAR, client.FormatJSONL:
+	default:
+		return fmt.Errorf("unknown format %q, want har, jsonl or json", f)
+	}
+
+	var r io.Reader = os.Stdin
+	if pos[1] != "-" {
+		file, err := os.Open(pos[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	res, err := a.api.ImportRequests(ctx, pos[0], r, f)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "imported %d requests into %s\n", res.Imported, pos[0])
+	return nil
+}
+
+// jsonlToArray rewrites newline-delimited JSON as one JSON array.
+func jsonlToArray(w io.Writer, r io.Reader) error {
+	bw := bufio.NewWriter(w)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20)
+	bw.WriteString("[")
+	for n := 0; scanner.Scan(); n++ {
+		if n > 0 {
+			bw.WriteString(",")
+		}
+		bw.Write(scanner.Bytes())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+// parseTime accepts an RFC 3339 time or a duration meaning that long ago.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func openCmd(ctx context.Context, a *app, args []string) error {
+	pos, err := parse(flag.NewFlagSet("open", flag.ExitOnError), args, "ID")
+	if err != nil {
+		return err
+	}
+	u := a.serverURL + "/?address=" + url.QueryEscape(pos[0])
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "open", u)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.CommandContext(ctx, "xdg-open", u)
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(a.out, u)
+		return fmt.Errorf("could not open a browser: %w", err)
+	}
+	return nil
+}
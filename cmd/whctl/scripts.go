@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+)
+
+func scriptStateCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("script-state", flag.ExitOnError)
+	reset := fs.Bool("reset", false, "empty the state so the next request starts afresh")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+	if *reset {
+		if err := a.api.ResetScriptState(ctx, pos[0]); err != nil {
+			return err
+		}
+		fmt.Fprintln(a.out, "script state emptied")
+		return nil
+	}
+	state, err := a.api.GetScriptState(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, string(b))
+	return nil
+}
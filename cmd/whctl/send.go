@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"webhook-tester/client"
+)
+
+func templatesCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("templates", flag.ExitOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	list, err := a.api.ListEventTemplates(ctx)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		tw := a.out.table()
+		fmt.Fprintln(tw, "NAME\tSIGNED\tTITLE")
+		for _, t := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", t.Name, t.Scheme, t.Title)
+		}
+		return tw.Flush()
+	}
+	// print one body, to edit and send with -d @FILE
+	for _, t := range list {
+		if t.Name == fs.Arg(0) {
+			fmt.Fprint(a.out, t.Body)
+			return nil
+		}
+	}
+	return fmt.Errorf("no template %q; run whctl templates for the list", fs.Arg(0))
+}
+
+func sendCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	to := fs.String("to", "", "URL to send the event to (default the webhook)")
+	tmpl := fs.String("template", "", `event template, like github.push; see "whctl templates"`)
+	method := fs.String("X", "", "send with this method (default the template's, or POST)")
+	var headers headerFlags
+	fs.Var(&headers, "H", `set a header over the template's, "Name: value" (repeatable)`)
+	data := fs.String("d", "", "send this body instead of the template's; @FILE reads it from a file")
+	sign := fs.Bool("sign", false, "add signature headers with the webhook's signing secret")
+	timeout := fs.Duration("timeout", 0, "how long to wait for the response (default 30s)")
+	retry := fs.String("retry", "", "retry like a provider until a 2xx, and follow the attempts: none, exponential or custom")
+	attempts := fs.Int("attempts", 0, "exponential: attempts in all (default 5)")
+	delay := fs.Duration("delay", 0, "exponential: the first wait, doubled for each retry (default 1s)")
+	var schedule listFlag
+	fs.Var(&schedule, "schedule", "custom: the wait before each retry, like 1s,5s,30s")
+	budget := fs.Duration("budget", 0, "give up retrying after this long in all")
+	deliveryHeader := fs.String("delivery-header", "", "send the delivery ID in this header on every attempt")
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+
+	in := client.SendEventRequest{Target: *to, Template: *tmpl, Method: strings.ToUpper(*method), Headers: map[string]string{},
+		Sign: *sign, TimeoutMs: int(timeout.Milliseconds())}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf("-H %q: want \"Name: value\"", h)
+		}
+		in.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "d" {
+			in.Body = data
+		}
+	})
+	if in.Body != nil && strings.HasPrefix(*in.Body, "@") {
+		b, err := os.ReadFile(strings.TrimPrefix(*in.Body, "@"))
+		if err != nil {
+			return err
+		}
+		body := string(b)
+		in.Body = &body
+	}
+
+	deliver := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "retry", "attempts", "delay", "schedule", "budget", "delivery-header":
+			deliver = true
+		}
+	})
+	if deliver {
+		dr := client.DeliveryRequest{Target: in.Target, Template: in.Template, Method: in.Method, Headers: in.Headers, Body: in.Body,
+			Sign: in.Sign, TimeoutMs: in.TimeoutMs, Policy: *retry, MaxAttempts: *attempts, InitialDelayMs: int(delay.Milliseconds()),
+			BudgetMs: int(budget.Milliseconds()), DeliveryHeader: *deliveryHeader}
+		for _, v := range schedule {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("-schedule: %w", err)
+			}
+			dr.ScheduleMs = append(dr.ScheduleMs, int(d.Milliseconds()))
+		}
+		return followDelivery(ctx, a, pos[0], dr)
+	}
+
+	sent, err := a.api.SendEvent(ctx, pos[0], in)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, a.out.paint(dim, "stored as request "+sent.Request.ID+" at "+sent.Request.ReceivedAt.Local().Format(time.TimeOnly)))
+	a.out.attempt(sent.Delivery, true)
+	return nil
+}
+
+// followDelivery starts a delivery and prints its attempts as they happen.
+func followDelivery(ctx context.Context, a *app, webhookID string, in client.DeliveryRequest) error {
+	d, err := a.api.StartDelivery(ctx, webhookID, in)
+	if err != nil {
+		return err
+	}
+	plan := "no retries"
+	if len(d.ScheduleMs) > 0 {
+		waits := make([]string, len(d.ScheduleMs))
+		for i, ms := range d.ScheduleMs {
+			waits[i] = (time.Duration(ms) * time.Millisecond).String()
+		}
+		plan = fmt.Sprintf("up to %d attempts, waiting %s", d.MaxAttempts, strings.Join(waits, ", "))
+	}
+	fmt.Fprintf(a.out, "delivering request %s to %s, %s (delivery %s)\n", d.RequestID, d.Target, plan, d.ID)
+
+	// print attempts as they come in; Ctrl-C cancels the delivery
+	printed := 0
+	var announced time.Time
+	for {
+		attempts, err := a.api.DeliveryAttempts(ctx, webhookID, d.ID)
+		if err == nil {
+			d, err = a.api.GetDelivery(ctx, webhookID, d.ID)
+		}
+		if errors.Is(err, context.Canceled) {
+			if _, err := a.api.CancelDelivery(context.Background(), webhookID, d.ID); err != nil && !errors.Is(err, client.ErrConflict) {
+				return err
+			}
+			fmt.Fprintln(a.out, "cancelled")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for ; printed < len(attempts); printed++ {
+			a.out.deliveryAttempt(attempts[printed])
+		}
+		if d.Done() {
+			break
+		}
+		if next := d.NextAttemptAt; next != nil && !next.Equal(announced) {
+			announced = *next
+			fmt.Fprintln(a.out, a.out.paint(dim, "     next attempt at "+next.Local().Format("15:04:05.000")))
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+
+	fmt.Fprintln(a.out, d.Status)
+	switch {
+	case d.Status == "succeeded":
+		return nil
+	case d.Error != "":
+		return errors.New(d.Error)
+	}
+	return errors.New("the delivery was " + d.Status)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"webhook-tester/client"
+	"webhook-tester/spec"
+)
+
+func configCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	format := fs.String("format", spec.FormatYAML, "output format: yaml or json")
+	outPath := fs.String("o", "", "output file (default stdout)")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	f, err := a.api.ExportSpec(ctx)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = a.out
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := spec.Encode(w, f, *format); err != nil {
+		return err
+	}
+	if *outPath != "" {
+		fmt.Fprintf(a.status, "wrote %d webhooks to %s\n", len(f.Webhooks), *outPath)
+	}
+	return nil
+}
+
+func planCmd(ctx context.Context, a *app, args []string) error {
+	return syncSpec(ctx, a, "plan", args, true)
+}
+
+func applyCmd(ctx context.Context, a *app, args []string) error {
+	return syncSpec(ctx, a, "apply", args, false)
+}
+
+func syncSpec(ctx context.Context, a *app, name string, args []string, dryRun bool) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	prune := fs.Bool("prune", false, "delete webhooks the file doesn't mention")
+	if !dryRun {
+		fs.BoolVar(&dryRun, "dry-run", false, "only show what would change")
+	}
+	pos, err := parse(fs, args, "FILE")
+	if err != nil {
+		return err
+	}
+
+	var raw []byte
+	if pos[0] == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(pos[0])
+	}
+	if err != nil {
+		return err
+	}
+	f, err := spec.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if err := f.ResolveSecrets(os.LookupEnv); err != nil {
+		return err
+	}
+
+	plan, err := a.api.ApplySpec(ctx, f, client.ApplyOptions{Prune: *prune, DryRun: dryRun})
+	if err != nil {
+		return err
+	}
+	a.out.plan(plan)
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"webhook-tester/client"
+)
+
+// headerFlag collects repeated -header "Name: value" flags.
+type headerFlag map[string]string
+
+func (h headerFlag) String() string { return "" }
+
+func (h headerFlag) Set(v string) error {
+	name, value, ok := strings.Cut(v, ":")
+	if !ok {
+		return fmt.Errorf("want Name: value, got %q", v)
+	}
+	h[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	return nil
+}
+
+// faultFlag sets one fault injection field per repeated -fault name=value
+// flag, named as in the API; -fault off clears them all.
+type faultFlag struct{ c *client.FaultConfig }
+
+func (f faultFlag) String() string { return "" }
+
+func (f faultFlag) Set(v string) error {
+	if v == "off" {
+		*f.c = client.FaultConfig{}
+		return nil
+	}
+	name, value, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("want name=value, got %q", v)
+	}
+	if name == "delay_distribution" {
+		f.c.DelayDistribution = value
+		return nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("%s: want a number, got %q", name, value)
+	}
+	switch name {
+	case "error_percent":
+		f.c.ErrorPercent = n
+	case "error_status":
+		f.c.ErrorStatus = int(n)
+	case "fail_every":
+		f.c.FailEvery = int(n)
+	case "delay_min_ms":
+		f.c.DelayMinMs = int(n)
+	case "delay_max_ms":
+		f.c.DelayMaxMs = int(n)
+	case "rate_limit":
+		f.c.RateLimit = int(n)
+	case "rate_limit_window_ms":
+		f.c.RateLimitWindowMs = int(n)
+	case "retry_after":
+		f.c.RetryAfter = int(n)
+	case "truncate_percent":
+		f.c.TruncatePercent = n
+	case "drip_percent":
+		f.c.DripPercent = n
+	case "drip_interval_ms":
+		f.c.DripIntervalMs = int(n)
+	case "disconnect_percent":
+		f.c.DisconnectPercent = n
+	default:
+		return fmt.Errorf("unknown fault %q", name)
+	}
+	return nil
+}
+
+// scriptFlag reads a -script FILE flag into the script; -script "" removes it.
+type scriptFlag struct{ s *string }
+
+func (f scriptFlag) String() string { return "" }
+
+func (f scriptFlag) Set(v string) error {
+	if v == "" {
+		*f.s = ""
+		return nil
+	}
+	b, err := os.ReadFile(v)
+	if err != nil {
+		return err
+	}
+	*f.s = string(b)
+	return nil
+}
+
+// webhookFlags registers the settable webhook fields on fs.
+func webhookFlags(fs *flag.FlagSet) *client.CreateWebhookRequest {
+	in := &client.CreateWebhookRequest{ResponseHeaders: map[string]string{}}
+	fs.StringVar(&in.Title, "title", "", "webhook title")
+	fs.IntVar(&in.ResponseCode, "status", 0, "response status code (default 200)")
+	fs.UintVar(&in.ResponseDelay, "delay", 0, "response delay in milliseconds")
+	fs.StringVar(&in.ContentType, "content-type", "", "response Content-Type")
+	fs.StringVar(&in.Payload, "payload", "", "response body")
+	fs.Var(headerFlag(in.ResponseHeaders), "header", `response header as "Name: value"; repeat for more`)
+	fs.BoolVar(&in.NotifyOnEvent, "notify", false, "email on every request")
+	fs.UintVar(&in.RetentionCount, "keep", 0, "keep only the last N requests (0 keeps all)")
+	fs.UintVar(&in.RetentionDays, "keep-days", 0, "keep requests for N days (0 keeps all)")
+	fs.StringVar(&in.SigningScheme, "signing-scheme", "", `scheme replays and sent events are signed with: "github", "stripe", "shopify" or "slack"; "" removes it`)
+	fs.StringVar(&in.SigningSecret, "signing-secret", "", "secret replays are re-signed with")
+	fs.Var(faultFlag{&in.Faults}, "fault", `fault injection as name=value, e.g. "error_percent=20" or "fail_every=3"; repeat for more, "off" clears them`)
+	fs.Var(scriptFlag{&in.Script}, "script", `file with a Starlark script that answers requests; "" removes it`)
+	return in
+}
+
+func listCmd(ctx context.Context, a *app, args []string) error {
+	if _, err := parse(flag.NewFlagSet("list", flag.ExitOnError), args); err != nil {
+		return err
+	}
+	hooks, err := a.api.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		fmt.Fprintln(a.out, "no webhooks yet; create one with: whctl create -title NAME")
+		return nil
+	}
+	tw := a.out.table()
+	fmt.Fprintln(tw, "ID\tTITLE\tSTATUS\tREQUESTS\tCREATED")
+	for _, h := range hooks {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", h.ID, h.Title, h.ResponseCode, len(h.Requests), h.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
+
+func createCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	in := webhookFlags(fs)
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	h, err := a.api.CreateWebhook(ctx, *in)
+	if err != nil {
+		return err
+	}
+	a.out.webhook(h, a.webhookURL(h.ID))
+	return nil
+}
+
+func getCmd(ctx context.Context, a *app, args []string) error {
+	pos, err := parse(flag.NewFlagSet("get", flag.ExitOnError), args, "ID")
+	if err != nil {
+		return err
+	}
+	h, err := a.api.GetWebhook(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	a.out.webhook(h, a.webhookURL(h.ID))
+	return nil
+}
+
+func updateCmd(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	in := webhookFlags(fs)
+	pos, err := parse(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+
+	// only send the flags that were given; -header and -fault replace all
+	// headers and faults
+	var patch client.PatchWebhookRequest
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			patch.Title = client.Set(in.Title)
+		case "status":
+			patch.ResponseCode = client.Set(in.ResponseCode)
+		case "delay":
+			patch.ResponseDelay = client.Set(in.ResponseDelay)
+		case "content-type":
+			patch.ContentType = client.Set(in.ContentType)
+		case "payload":
+			patch.Payload = client.Set(in.Payload)
+		case "header":
+			patch.ResponseHeaders = client.Set(in.ResponseHeaders)
+		case "notify":
+			patch.NotifyOnEvent = client.Set(in.NotifyOnEvent)
+		case "keep":
+			patch.RetentionCount = client.Set(in.RetentionCount)
+		case "keep-days":
+			patch.RetentionDays = client.Set(in.RetentionDays)
+		case "signing-scheme":
+			patch.SigningScheme = client.Set(in.SigningScheme)
+		case "signing-secret":
+			patch.SigningSecret = client.Set(in.SigningSecret)
+		case "fault":
+			patch.Faults = client.Set(in.Faults)
+		case "script":
+			patch.Script = client.Set(in.Script)
+		}
+	})
+
+	h, err := a.api.PatchWebhook(ctx, pos[0], patch)
+	if err != nil {
+		return err
+	}
+	a.out.webhook(h, a.webhookURL(h.ID))
+	return nil
+}
+
+func deleteCmd(ctx context.Context, a *app, args []string) error {
+	pos, err := parse(flag.NewFlagSet("delete", flag.ExitOnError), args, "ID")
+	if err != nil {
+		return err
+	}
+	if err := a.api.DeleteWebhook(ctx, pos[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "deleted %s\n", pos[0])
+	return nil
+}
+
+func (a *app) webhookURL(id string) string {
+	return a.serverURL + "/webhooks/" + id
+}
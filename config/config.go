@@ -1,8 +1,12 @@
 package config
 
 import (
-	"github.com/joho/godotenv"
+	"crypto/rand"
+	"encoding/base64"
 	"log"
+	"os"
+
+	"github.com/joho/godotenv"
 )
 
 func LoadEnv() {
@@ -10,3 +14,26 @@ func LoadEnv() {
 		log.Println("No .env found - using defaults")
 	}
 }
+
+// ApplyDefaults fills in settings that are not configured so the server can
+// run on a laptop with zero setup. Values are written back to the environment
+// so every reader of os.Getenv sees the same configuration.
+func ApplyDefaults() {
+	setDefault("PORT", "3000")
+	setDefault("DOMAIN", "http://localhost:"+os.Getenv("PORT"))
+
+	if os.Getenv("AUTH_SECRET") == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("failed to generate AUTH_SECRET: %v", err)
+		}
+		os.Setenv("AUTH_SECRET", base64.StdEncoding.EncodeToString(secret))
+		log.Println("AUTH_SECRET not set - generated a temporary one, sessions will not survive a restart")
+	}
+}
+
+func setDefault(key, value string) {
+	if os.Getenv(key) == "" {
+		os.Setenv(key, value)
+	}
+}
@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS holds the versioned SQL migrations, one directory per database dialect.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,50 @@
+-- Baseline schema. Uses IF NOT EXISTS so databases previously created by
+-- GORM auto-migration are adopted without changes.
+CREATE TABLE IF NOT EXISTS users
+(
+    id                 BIGSERIAL PRIMARY KEY,
+    created_at         TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ,
+    deleted_at         TIMESTAMPTZ,
+    full_name          TEXT,
+    email              VARCHAR(255) UNIQUE,
+    password           TEXT,
+    api_key            TEXT,
+    reset_token        TEXT,
+    reset_token_expiry TIMESTAMPTZ
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS storage_quota BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id               TEXT PRIMARY KEY,
+    title            TEXT,
+    response_code    BIGINT,
+    response_delay   BIGINT,
+    content_type     TEXT,
+    payload          TEXT,
+    response_headers JSONB,
+    notify_on_event  BOOLEAN,
+    user_id          BIGINT,
+    created_at       TIMESTAMPTZ,
+    updated_at       TIMESTAMPTZ
+);
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS retention_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS retention_days BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks (user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_requests
+(
+    id          TEXT PRIMARY KEY,
+    webhook_id  TEXT,
+    method      TEXT,
+    headers     JSONB,
+    query       JSONB,
+    body        TEXT,
+    received_at TIMESTAMPTZ,
+    CONSTRAINT fk_webhooks_requests FOREIGN KEY (webhook_id) REFERENCES webhooks (id)
+);
+ALTER TABLE webhook_requests ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE webhook_requests ADD COLUMN IF NOT EXISTS size BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_webhook_requests_webhook_received ON webhook_requests (webhook_id, received_at);
@@ -0,0 +1 @@
+ALTER TABLE webhook_requests DROP COLUMN IF EXISTS source;
@@ -0,0 +1 @@
+ALTER TABLE webhook_requests ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'live';
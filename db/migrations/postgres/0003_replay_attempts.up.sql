@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS replay_attempts
+(
+    id                 TEXT PRIMARY KEY,
+    request_id         TEXT NOT NULL REFERENCES webhook_requests (id) ON DELETE CASCADE,
+    method             TEXT,
+    url                TEXT,
+    request_headers    JSONB,
+    request_body       TEXT,
+    status_code        INTEGER NOT NULL DEFAULT 0,
+    response_headers   JSONB,
+    response_body      TEXT,
+    response_truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    duration_ms        BIGINT NOT NULL DEFAULT 0,
+    error              TEXT,
+    created_at         TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_replay_attempts_request_created ON replay_attempts (request_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS replay_job_items;
+DROP TABLE IF EXISTS replay_jobs;
@@ -0,0 +1,3 @@
+ALTER TABLE replay_jobs DROP COLUMN IF EXISTS resign;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS signing_secret;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS signing_scheme;
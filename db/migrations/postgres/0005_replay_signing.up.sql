@@ -0,0 +1,3 @@
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS signing_scheme TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS signing_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE replay_jobs ADD COLUMN IF NOT EXISTS resign BOOLEAN NOT NULL DEFAULT FALSE;
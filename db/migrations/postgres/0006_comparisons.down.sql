@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS comparisons;
+DROP TABLE IF EXISTS comparison_batches;
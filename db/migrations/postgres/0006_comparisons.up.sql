@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS comparison_batches
+(
+    id            TEXT PRIMARY KEY,
+    webhook_id    TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    baseline_url  TEXT,
+    candidate_url TEXT,
+    ignore        JSONB,
+    resign        BOOLEAN NOT NULL DEFAULT FALSE,
+    timeout_ms    BIGINT NOT NULL DEFAULT 0,
+    concurrency   INTEGER NOT NULL DEFAULT 0,
+    status        TEXT,
+    total         INTEGER NOT NULL DEFAULT 0,
+    equal         INTEGER NOT NULL DEFAULT 0,
+    different     INTEGER NOT NULL DEFAULT 0,
+    failed        INTEGER NOT NULL DEFAULT 0,
+    skipped       INTEGER NOT NULL DEFAULT 0,
+    error         TEXT,
+    created_at    TIMESTAMPTZ,
+    finished_at   TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_comparison_batches_webhook_created ON comparison_batches (webhook_id, created_at);
+
+CREATE TABLE IF NOT EXISTS comparisons
+(
+    id                   TEXT PRIMARY KEY,
+    webhook_id           TEXT,
+    request_id           TEXT NOT NULL REFERENCES webhook_requests (id) ON DELETE CASCADE,
+    batch_id             TEXT,
+    baseline_url         TEXT,
+    candidate_url        TEXT,
+    ignore               JSONB,
+    baseline_attempt_id  TEXT,
+    candidate_attempt_id TEXT,
+    baseline_status      INTEGER NOT NULL DEFAULT 0,
+    candidate_status     INTEGER NOT NULL DEFAULT 0,
+    equal                BOOLEAN NOT NULL DEFAULT FALSE,
+    differences          JSONB,
+    error                TEXT,
+    created_at           TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_comparisons_request_created ON comparisons (request_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_comparisons_batch ON comparisons (batch_id);
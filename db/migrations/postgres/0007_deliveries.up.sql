@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS deliveries
+(
+    id              TEXT PRIMARY KEY,
+    webhook_id      TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    request_id      TEXT NOT NULL REFERENCES webhook_requests (id) ON DELETE CASCADE,
+    target          TEXT,
+    policy          TEXT,
+    schedule        JSONB,
+    sign            BOOLEAN NOT NULL DEFAULT FALSE,
+    timeout_ms      BIGINT NOT NULL DEFAULT 0,
+    budget_ms       BIGINT NOT NULL DEFAULT 0,
+    status          TEXT,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    status_code     INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    error           TEXT,
+    created_at      TIMESTAMPTZ,
+    finished_at     TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_deliveries_webhook_created ON deliveries (webhook_id, created_at);
+
+CREATE TABLE IF NOT EXISTS delivery_attempts
+(
+    delivery_id TEXT NOT NULL REFERENCES deliveries (id) ON DELETE CASCADE,
+    seq         INTEGER NOT NULL,
+    attempt_id  TEXT,
+    succeeded   BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    error       TEXT,
+    sent_at     TIMESTAMPTZ,
+    PRIMARY KEY (delivery_id, seq)
+);
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS load_runs
+(
+    id           TEXT PRIMARY KEY,
+    webhook_id   TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    request_id   TEXT,
+    template     TEXT,
+    sign         BOOLEAN NOT NULL DEFAULT FALSE,
+    target       TEXT,
+    concurrency  INTEGER NOT NULL DEFAULT 0,
+    rate         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    duration_ms  BIGINT NOT NULL DEFAULT 0,
+    count        INTEGER NOT NULL DEFAULT 0,
+    timeout_ms   BIGINT NOT NULL DEFAULT 0,
+    status       TEXT,
+    requests     INTEGER NOT NULL DEFAULT 0,
+    succeeded    INTEGER NOT NULL DEFAULT 0,
+    failed       INTEGER NOT NULL DEFAULT 0,
+    errors       INTEGER NOT NULL DEFAULT 0,
+    status_codes JSONB,
+    error_counts JSONB,
+    min_ms       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    mean_ms      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p50_ms       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p90_ms       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p95_ms       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    p99_ms       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_ms       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    elapsed_ms   BIGINT NOT NULL DEFAULT 0,
+    throughput   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    error        TEXT,
+    created_at   TIMESTAMPTZ,
+    finished_at  TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_load_runs_webhook_created ON load_runs (webhook_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS callbacks;
+DROP TABLE IF EXISTS callback_actions;
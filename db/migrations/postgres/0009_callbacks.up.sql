@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS callback_actions
+(
+    id         TEXT PRIMARY KEY,
+    webhook_id TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    name       TEXT,
+    enabled    BOOLEAN NOT NULL DEFAULT FALSE,
+    delay_ms   BIGINT NOT NULL DEFAULT 0,
+    method     TEXT,
+    url        TEXT,
+    url_from   TEXT,
+    headers    JSONB,
+    body       TEXT,
+    sign       BOOLEAN NOT NULL DEFAULT FALSE,
+    timeout_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_callback_actions_webhook ON callback_actions (webhook_id);
+
+CREATE TABLE IF NOT EXISTS callbacks
+(
+    id          TEXT PRIMARY KEY,
+    webhook_id  TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    action_id   TEXT,
+    action_name TEXT,
+    request_id  TEXT NOT NULL REFERENCES webhook_requests (id) ON DELETE CASCADE,
+    status      TEXT,
+    due_at      TIMESTAMPTZ,
+    url         TEXT,
+    attempt_id  TEXT,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    error       TEXT,
+    created_at  TIMESTAMPTZ,
+    sent_at     TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_callbacks_webhook_created ON callbacks (webhook_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_callbacks_request ON callbacks (request_id);
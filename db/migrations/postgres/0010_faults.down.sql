@@ -0,0 +1,3 @@
+ALTER TABLE webhook_requests DROP COLUMN IF EXISTS fault_delay;
+ALTER TABLE webhook_requests DROP COLUMN IF EXISTS fault;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS faults;
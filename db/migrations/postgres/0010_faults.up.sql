@@ -0,0 +1,3 @@
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS faults JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE webhook_requests ADD COLUMN IF NOT EXISTS fault TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_requests ADD COLUMN IF NOT EXISTS fault_delay BIGINT NOT NULL DEFAULT 0;
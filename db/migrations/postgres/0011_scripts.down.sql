@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS script_states;
+ALTER TABLE webhook_requests DROP COLUMN IF EXISTS script_log;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS script;
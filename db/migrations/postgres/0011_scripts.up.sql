@@ -0,0 +1,9 @@
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS script TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_requests ADD COLUMN IF NOT EXISTS script_log TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS script_states
+(
+    webhook_id TEXT PRIMARY KEY REFERENCES webhooks (id) ON DELETE CASCADE,
+    data       JSONB,
+    updated_at TIMESTAMPTZ
+);
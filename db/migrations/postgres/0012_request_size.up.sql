@@ -0,0 +1,11 @@
+-- Requests stored before quotas were added have a size of 0. Count them the
+-- way models.WebhookRequest.ComputeSize does: the method, the body, and the
+-- keys plus string values of the headers and query.
+UPDATE webhook_requests
+SET size = octet_length(coalesce(method, ''))
+    + octet_length(coalesce(body, ''))
+    + coalesce((SELECT sum(octet_length(key) + CASE WHEN jsonb_typeof(value) = 'string' THEN octet_length(value #>> '{}') ELSE 0 END)
+                FROM jsonb_each(CASE WHEN jsonb_typeof(webhook_requests.headers) = 'object' THEN webhook_requests.headers ELSE '{}' END)), 0)
+    + coalesce((SELECT sum(octet_length(key) + CASE WHEN jsonb_typeof(value) = 'string' THEN octet_length(value #>> '{}') ELSE 0 END)
+                FROM jsonb_each(CASE WHEN jsonb_typeof(webhook_requests.query) = 'object' THEN webhook_requests.query ELSE '{}' END)), 0)
+WHERE size = 0;
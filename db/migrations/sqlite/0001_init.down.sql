@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_requests;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,47 @@
+CREATE TABLE users
+(
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at         DATETIME,
+    updated_at         DATETIME,
+    deleted_at         DATETIME,
+    full_name          TEXT,
+    email              VARCHAR(255) UNIQUE,
+    password           TEXT,
+    api_key            TEXT,
+    reset_token        TEXT,
+    reset_token_expiry DATETIME,
+    storage_quota      INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE webhooks
+(
+    id               TEXT PRIMARY KEY,
+    title            TEXT,
+    response_code    INTEGER,
+    response_delay   INTEGER,
+    content_type     TEXT,
+    payload          TEXT,
+    response_headers JSON,
+    notify_on_event  NUMERIC,
+    retention_count  INTEGER NOT NULL DEFAULT 0,
+    retention_days   INTEGER NOT NULL DEFAULT 0,
+    user_id          INTEGER,
+    created_at       DATETIME,
+    updated_at       DATETIME
+);
+CREATE INDEX idx_webhooks_user_id ON webhooks (user_id);
+
+CREATE TABLE webhook_requests
+(
+    id          TEXT PRIMARY KEY,
+    webhook_id  TEXT REFERENCES webhooks (id),
+    method      TEXT,
+    headers     JSON,
+    query       JSON,
+    body        TEXT,
+    pinned      NUMERIC NOT NULL DEFAULT 0,
+    size        INTEGER NOT NULL DEFAULT 0,
+    received_at DATETIME
+);
+CREATE INDEX idx_webhook_requests_webhook_received ON webhook_requests (webhook_id, received_at);
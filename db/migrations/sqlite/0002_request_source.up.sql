@@ -0,0 +1 @@
+ALTER TABLE webhook_requests ADD COLUMN source TEXT NOT NULL DEFAULT 'live';
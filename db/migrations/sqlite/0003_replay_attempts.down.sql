@@ -0,0 +1 @@
+DROP TABLE replay_attempts;
@@ -0,0 +1,17 @@
+CREATE TABLE replay_attempts
+(
+    id                 TEXT PRIMARY KEY,
+    request_id         TEXT NOT NULL REFERENCES webhook_requests (id) ON DELETE CASCADE,
+    method             TEXT,
+    url                TEXT,
+    request_headers    JSON,
+    request_body       TEXT,
+    status_code        INTEGER NOT NULL DEFAULT 0,
+    response_headers   JSON,
+    response_body      TEXT,
+    response_truncated NUMERIC NOT NULL DEFAULT 0,
+    duration_ms        INTEGER NOT NULL DEFAULT 0,
+    error              TEXT,
+    created_at         DATETIME
+);
+CREATE INDEX idx_replay_attempts_request_created ON replay_attempts (request_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE replay_job_items;
+DROP TABLE replay_jobs;
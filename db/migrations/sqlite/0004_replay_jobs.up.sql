@@ -0,0 +1,36 @@
+CREATE TABLE replay_jobs
+(
+    id              TEXT PRIMARY KEY,
+    webhook_id      TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    target          TEXT,
+    mode            TEXT,
+    speed           REAL NOT NULL DEFAULT 0,
+    rate            REAL NOT NULL DEFAULT 0,
+    concurrency     INTEGER NOT NULL DEFAULT 0,
+    stop_on_failure NUMERIC NOT NULL DEFAULT 0,
+    timeout_ms      INTEGER NOT NULL DEFAULT 0,
+    status          TEXT,
+    total           INTEGER NOT NULL DEFAULT 0,
+    succeeded       INTEGER NOT NULL DEFAULT 0,
+    failed          INTEGER NOT NULL DEFAULT 0,
+    skipped         INTEGER NOT NULL DEFAULT 0,
+    error           TEXT,
+    created_at      DATETIME,
+    finished_at     DATETIME
+);
+CREATE INDEX idx_replay_jobs_webhook_created ON replay_jobs (webhook_id, created_at);
+
+CREATE TABLE replay_job_items
+(
+    job_id      TEXT NOT NULL REFERENCES replay_jobs (id) ON DELETE CASCADE,
+    seq         INTEGER NOT NULL,
+    request_id  TEXT,
+    offset_ms   INTEGER NOT NULL DEFAULT 0,
+    status      TEXT,
+    attempt_id  TEXT,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    error       TEXT,
+    sent_at     DATETIME,
+    PRIMARY KEY (job_id, seq)
+);
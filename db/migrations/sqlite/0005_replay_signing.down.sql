@@ -0,0 +1,3 @@
+ALTER TABLE replay_jobs DROP COLUMN resign;
+ALTER TABLE webhooks DROP COLUMN signing_secret;
+ALTER TABLE webhooks DROP COLUMN signing_scheme;
@@ -0,0 +1,3 @@
+ALTER TABLE webhooks ADD COLUMN signing_scheme TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhooks ADD COLUMN signing_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE replay_jobs ADD COLUMN resign NUMERIC NOT NULL DEFAULT 0;
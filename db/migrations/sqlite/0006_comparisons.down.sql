@@ -0,0 +1,2 @@
+DROP TABLE comparisons;
+DROP TABLE comparison_batches;
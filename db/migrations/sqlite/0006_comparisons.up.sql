@@ -0,0 +1,42 @@
+CREATE TABLE comparison_batches
+(
+    id            TEXT PRIMARY KEY,
+    webhook_id    TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    baseline_url  TEXT,
+    candidate_url TEXT,
+    ignore        JSON,
+    resign        NUMERIC NOT NULL DEFAULT 0,
+    timeout_ms    INTEGER NOT NULL DEFAULT 0,
+    concurrency   INTEGER NOT NULL DEFAULT 0,
+    status        TEXT,
+    total         INTEGER NOT NULL DEFAULT 0,
+    equal         INTEGER NOT NULL DEFAULT 0,
+    different     INTEGER NOT NULL DEFAULT 0,
+    failed        INTEGER NOT NULL DEFAULT 0,
+    skipped       INTEGER NOT NULL DEFAULT 0,
+    error         TEXT,
+    created_at    DATETIME,
+    finished_at   DATETIME
+);
+CREATE INDEX idx_comparison_batches_webhook_created ON comparison_batches (webhook_id, created_at);
+
+CREATE TABLE comparisons
+(
+    id                   TEXT PRIMARY KEY,
+    webhook_id           TEXT,
+    request_id           TEXT NOT NULL REFERENCES webhook_requests (id) ON DELETE CASCADE,
+    batch_id             TEXT,
+    baseline_url         TEXT,
+    candidate_url        TEXT,
+    ignore               JSON,
+    baseline_attempt_id  TEXT,
+    candidate_attempt_id TEXT,
+    baseline_status      INTEGER NOT NULL DEFAULT 0,
+    candidate_status     INTEGER NOT NULL DEFAULT 0,
+    equal                NUMERIC NOT NULL DEFAULT 0,
+    differences          JSON,
+    error                TEXT,
+    created_at           DATETIME
+);
+CREATE INDEX idx_comparisons_request_created ON comparisons (request_id, created_at);
+CREATE INDEX idx_comparisons_batch ON comparisons (batch_id);
@@ -0,0 +1,33 @@
+CREATE TABLE deliveries
+(
+    id              TEXT PRIMARY KEY,
+    webhook_id      TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    request_id      TEXT NOT NULL REFERENCES webhook_requests (id) ON DELETE CASCADE,
+    target          TEXT,
+    policy          TEXT,
+    schedule        JSON,
+    sign            NUMERIC NOT NULL DEFAULT 0,
+    timeout_ms      INTEGER NOT NULL DEFAULT 0,
+    budget_ms       INTEGER NOT NULL DEFAULT 0,
+    status          TEXT,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    status_code     INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME,
+    error           TEXT,
+    created_at      DATETIME,
+    finished_at     DATETIME
+);
+CREATE INDEX idx_deliveries_webhook_created ON deliveries (webhook_id, created_at);
+
+CREATE TABLE delivery_attempts
+(
+    delivery_id TEXT NOT NULL REFERENCES deliveries (id) ON DELETE CASCADE,
+    seq         INTEGER NOT NULL,
+    attempt_id  TEXT,
+    succeeded   NUMERIC NOT NULL DEFAULT 0,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    error       TEXT,
+    sent_at     DATETIME,
+    PRIMARY KEY (delivery_id, seq)
+);
@@ -0,0 +1 @@
+DROP TABLE load_runs;
@@ -0,0 +1,34 @@
+CREATE TABLE load_runs
+(
+    id           TEXT PRIMARY KEY,
+    webhook_id   TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    request_id   TEXT,
+    template     TEXT,
+    sign         NUMERIC NOT NULL DEFAULT 0,
+    target       TEXT,
+    concurrency  INTEGER NOT NULL DEFAULT 0,
+    rate         REAL NOT NULL DEFAULT 0,
+    duration_ms  INTEGER NOT NULL DEFAULT 0,
+    count        INTEGER NOT NULL DEFAULT 0,
+    timeout_ms   INTEGER NOT NULL DEFAULT 0,
+    status       TEXT,
+    requests     INTEGER NOT NULL DEFAULT 0,
+    succeeded    INTEGER NOT NULL DEFAULT 0,
+    failed       INTEGER NOT NULL DEFAULT 0,
+    errors       INTEGER NOT NULL DEFAULT 0,
+    status_codes JSON,
+    error_counts JSON,
+    min_ms       REAL NOT NULL DEFAULT 0,
+    mean_ms      REAL NOT NULL DEFAULT 0,
+    p50_ms       REAL NOT NULL DEFAULT 0,
+    p90_ms       REAL NOT NULL DEFAULT 0,
+    p95_ms       REAL NOT NULL DEFAULT 0,
+    p99_ms       REAL NOT NULL DEFAULT 0,
+    max_ms       REAL NOT NULL DEFAULT 0,
+    elapsed_ms   INTEGER NOT NULL DEFAULT 0,
+    throughput   REAL NOT NULL DEFAULT 0,
+    error        TEXT,
+    created_at   DATETIME,
+    finished_at  DATETIME
+);
+CREATE INDEX idx_load_runs_webhook_created ON load_runs (webhook_id, created_at);
@@ -0,0 +1,38 @@
+CREATE TABLE callback_actions
+(
+    id         TEXT PRIMARY KEY,
+    webhook_id TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    name       TEXT,
+    enabled    NUMERIC NOT NULL DEFAULT 0,
+    delay_ms   INTEGER NOT NULL DEFAULT 0,
+    method     TEXT,
+    url        TEXT,
+    url_from   TEXT,
+    headers    JSON,
+    body       TEXT,
+    sign       NUMERIC NOT NULL DEFAULT 0,
+    timeout_ms INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+CREATE INDEX idx_callback_actions_webhook ON callback_actions (webhook_id);
+
+CREATE TABLE callbacks
+(
+    id          TEXT PRIMARY KEY,
+    webhook_id  TEXT NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    action_id   TEXT,
+    action_name TEXT,
+    request_id  TEXT NOT NULL REFERENCES webhook_requests (id) ON DELETE CASCADE,
+    status      TEXT,
+    due_at      DATETIME,
+    url         TEXT,
+    attempt_id  TEXT,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    error       TEXT,
+    created_at  DATETIME,
+    sent_at     DATETIME
+);
+CREATE INDEX idx_callbacks_webhook_created ON callbacks (webhook_id, created_at);
+CREATE INDEX idx_callbacks_request ON callbacks (request_id);
@@ -0,0 +1,3 @@
+ALTER TABLE webhook_requests DROP COLUMN fault_delay;
+ALTER TABLE webhook_requests DROP COLUMN fault;
+ALTER TABLE webhooks DROP COLUMN faults;
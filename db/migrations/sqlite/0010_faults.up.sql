@@ -0,0 +1,3 @@
+ALTER TABLE webhooks ADD COLUMN faults JSON NOT NULL DEFAULT '{}';
+ALTER TABLE webhook_requests ADD COLUMN fault TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_requests ADD COLUMN fault_delay INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP TABLE script_states;
+ALTER TABLE webhook_requests DROP COLUMN script_log;
+ALTER TABLE webhooks DROP COLUMN script;
@@ -0,0 +1,9 @@
+ALTER TABLE webhooks ADD COLUMN script TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_requests ADD COLUMN script_log TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE script_states
+(
+    webhook_id TEXT PRIMARY KEY REFERENCES webhooks (id) ON DELETE CASCADE,
+    data       JSON,
+    updated_at DATETIME
+);
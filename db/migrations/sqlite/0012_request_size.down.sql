@@ -0,0 +1 @@
+-- The backfilled sizes are correct under the old schema too, so nothing is undone.
@@ -0,0 +1,11 @@
+-- Requests stored before quotas were added have a size of 0. Count them the
+-- way models.WebhookRequest.ComputeSize does: the method, the body, and the
+-- keys plus string values of the headers and query.
+UPDATE webhook_requests
+SET size = length(CAST(coalesce(method, '') AS BLOB))
+    + length(CAST(coalesce(body, '') AS BLOB))
+    + coalesce((SELECT sum(length(CAST(key AS BLOB)) + CASE WHEN type = 'text' THEN length(CAST(value AS BLOB)) ELSE 0 END)
+                FROM json_each(CASE WHEN json_type(webhook_requests.headers) = 'object' THEN webhook_requests.headers ELSE '{}' END)), 0)
+    + coalesce((SELECT sum(length(CAST(key AS BLOB)) + CASE WHEN type = 'text' THEN length(CAST(value AS BLOB)) ELSE 0 END)
+                FROM json_each(CASE WHEN json_type(webhook_requests.query) = 'object' THEN webhook_requests.query ELSE '{}' END)), 0)
+WHERE size = 0;
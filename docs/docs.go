@@ -18,6 +18,172 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/event-templates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the provider events the sender can send, each rendered with fresh IDs and timestamps, ready to edit",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sender"
+                ],
+                "summary": "List event templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/EventTemplate"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/spec": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Describes every webhook of the user as a spec file that POST /spec/apply accepts",
+                "produces": [
+                    "application/yaml",
+                    "application/json"
+                ],
+                "tags": [
+                    "Spec"
+                ],
+                "summary": "Export webhooks as code",
+                "parameters": [
+                    {
+                        "enum": [
+                            "yaml",
+                            "json"
+                        ],
+                        "type": "string",
+                        "description": "yaml (default) or json",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SpecFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/spec/apply": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates and updates webhooks so they match a YAML or JSON spec file. With prune, webhooks missing from the file are deleted. With dry_run, only the plan is returned",
+                "consumes": [
+                    "application/yaml",
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Spec"
+                ],
+                "summary": "Apply webhooks as code",
+                "parameters": [
+                    {
+                        "description": "Spec file",
+                        "name": "spec",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SpecFile"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Delete webhooks the file doesn't mention",
+                        "name": "prune",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Plan without changing anything",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/SpecPlan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
         "/webhooks": {
             "get": {
                 "security": [
@@ -42,6 +208,12 @@ const docTemplate = `{
                                 "$ref": "#/definitions/Webhook"
                             }
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     }
                 }
             },
@@ -71,11 +243,29 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "$ref": "#/definitions/Webhook"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
                     }
                 }
             }
@@ -108,10 +298,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/Webhook"
-                            }
+                            "$ref": "#/definitions/Webhook"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
@@ -122,14 +321,14 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Updates a webhook",
+                "description": "Replaces every field of a webhook. Omitted fields are reset to their defaults; use PATCH to change single fields",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Webhooks"
                 ],
-                "summary": "Updates a webhook",
+                "summary": "Replaces a webhook",
                 "parameters": [
                     {
                         "type": "string",
@@ -152,10 +351,31 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/Webhook"
-                            }
+                            "$ref": "#/definitions/Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
@@ -190,71 +410,4517 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
+                            "$ref": "#/definitions/Problem"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "CreateWebhookRequest": {
-            "type": "object",
-            "properties": {
-                "content_type": {
-                    "type": "string"
-                },
-                "notify_on_event": {
-                    "type": "boolean"
-                },
-                "payload": {
-                    "type": "string"
-                },
-                "response_code": {
-                    "type": "integer"
-                },
-                "response_delay": {
-                    "description": "milliseconds",
-                    "type": "integer"
-                },
-                "title": {
-                    "description": "Title of the webhook\nrequired: true",
-                    "type": "string"
-                }
-            }
-        },
-        "ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "Webhook not found"
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes only the fields present in the body. A field set to null is reset to its default",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Updates a webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/PatchWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
                 }
             }
         },
-        "UpdateWebhookRequest": {
-            "type": "object",
-            "properties": {
-                "content_type": {
-                    "type": "string"
-                },
-                "notify_on_event": {
-                    "type": "boolean"
-                },
-                "payload": {
-                    "type": "string"
-                },
-                "response_code": {
-                    "type": "integer"
-                },
-                "response_delay": {
-                    "description": "milliseconds",
-                    "type": "integer"
-                },
-                "title": {
+        "/webhooks/{id}/callback-actions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the callback actions of a webhook, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Callbacks"
+                ],
+                "summary": "List callback actions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/CallbackAction"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Makes the webhook call back after it receives a request, the way asynchronous APIs acknowledge a call at once and report the outcome later. Every request the webhook receives schedules a callback from each enabled action, sent delay_ms later to the URL found at url_from in the request, or to url. Headers and body are Go templates: besides the placeholders of events, such as uuid and now, the field helper inserts a value of the received request by path (field \"body.order.id\") and the json helper inserts one as JSON (json \"body.order\"), each written inside double braces. Each callback is recorded as a replay of the received request. Callbacks carry the X-Webhook-Tester-Callback header and requests with it trigger no callbacks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Callbacks"
+                ],
+                "summary": "Create callback action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Callback action",
+                        "name": "action",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CallbackActionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/CallbackAction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/callback-actions/{actionID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets a callback action of a webhook",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Callbacks"
+                ],
+                "summary": "Get callback action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Callback action ID",
+                        "name": "actionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/CallbackAction"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces a callback action. Callbacks already scheduled are sent as the action was",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Callbacks"
+                ],
+                "summary": "Update callback action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Callback action ID",
+                        "name": "actionID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Callback action",
+                        "name": "action",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CallbackActionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/CallbackAction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a callback action and cancels its scheduled callbacks. Callbacks already sent are kept",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Callbacks"
+                ],
+                "summary": "Delete callback action",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Callback action ID",
+                        "name": "actionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/callbacks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the latest 100 callbacks of a webhook, or all callbacks of one received request, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Callbacks"
+                ],
+                "summary": "List callbacks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only callbacks of this received request",
+                        "name": "request_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Callback"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/callbacks/{callbackID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets a callback with its status. Once sent, attempt_id names the replay attempt holding the full exchange",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Callbacks"
+                ],
+                "summary": "Get callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Callback ID",
+                        "name": "callbackID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Callback"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/callbacks/{callbackID}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops a scheduled callback from being sent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Callbacks"
+                ],
+                "summary": "Cancel callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Callback ID",
+                        "name": "callbackID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Callback"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/comparison-batches": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the batch comparisons of a webhook, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comparisons"
+                ],
+                "summary": "List batch comparisons",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ComparisonBatch"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Compares the webhook's requests matching the selection, oldest first, against a baseline and a candidate target in the background, as a single comparison would. Poll the batch for progress and its results for the paths that differ most often. A webhook runs one batch at a time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comparisons"
+                ],
+                "summary": "Start a batch comparison",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Selection, targets and ignored paths",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ComparisonBatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ComparisonBatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/comparison-batches/{batchID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets a batch comparison with its progress",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comparisons"
+                ],
+                "summary": "Get batch comparison",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comparison batch ID",
+                        "name": "batchID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ComparisonBatch"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/comparison-batches/{batchID}/results": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets the comparisons a batch has made so far, oldest first, and how many of them differ at each path, most frequent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comparisons"
+                ],
+                "summary": "Get batch comparison results",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comparison batch ID",
+                        "name": "batchID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ComparisonBatchResults"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the deliveries of a webhook, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sender"
+                ],
+                "summary": "List deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Delivery"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stores an event like the send endpoint does, then delivers it in the background the way a provider would: attempts that get no 2xx are retried on the policy's schedule until one succeeds, the attempts run out or the time budget is spent. Every attempt replays the same stored request, so IDs in the event stay the same; signed deliveries are signed afresh for each attempt. The \"none\" policy makes one attempt, like GitHub; \"exponential\" doubles the wait after each attempt, like Stripe; \"custom\" waits schedule_ms before each retry. Poll the delivery for its timeline",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sender"
+                ],
+                "summary": "Deliver an event with retries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Event, target and retry policy",
+                        "name": "delivery",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/DeliveryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/Delivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets a delivery with its progress and, while retrying, when the next attempt is due",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sender"
+                ],
+                "summary": "Get delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Delivery"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryID}/attempts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the attempts of a delivery in order: its timeline. Each links to the replay attempt holding the full exchange",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sender"
+                ],
+                "summary": "List delivery attempts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/DeliveryAttempt"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryID}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops a delivery from making more attempts. An attempt in flight completes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sender"
+                ],
+                "summary": "Cancel delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Delivery"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/load-runs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the load runs of a webhook with their results, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Load"
+                ],
+                "summary": "List load runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/LoadRun"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fires a captured request of the webhook, or an event rendered afresh from a template for every request, at a target in the background, with at most concurrency requests in flight and, with rate, at most that many started per second. The run stops after count requests or duration_ms, whichever comes first. Requests aren't stored; the run keeps status codes, errors, latency percentiles and throughput, updated every second while it runs. A webhook runs one load run at a time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Load"
+                ],
+                "summary": "Start a load run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "What to send, where and how hard",
+                        "name": "run",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/LoadRunRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/LoadRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/load-runs/{runID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets a load run with its results, at most a second old while it runs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Load"
+                ],
+                "summary": "Get load run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Load run ID",
+                        "name": "runID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/LoadRun"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/load-runs/{runID}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops a load run and returns its final results. Requests in flight are abandoned and left out",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Load"
+                ],
+                "summary": "Cancel load run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Load run ID",
+                        "name": "runID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/LoadRun"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay-jobs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the batch replays of a webhook, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "List batch replays",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ReplayJob"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replays the webhook's requests matching the selection, oldest first, to one target in the background. In timing mode the gaps between the original requests are kept, divided by speed; in rate mode requests are sent at a fixed rate. Each request is recorded as a replay attempt. Poll the job for progress",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "Start a batch replay",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Selection, target and pace",
+                        "name": "job",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ReplayJobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/ReplayJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay-jobs/{jobID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets a batch replay with its progress",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "Get batch replay",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replay job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ReplayJob"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay-jobs/{jobID}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ends a running or paused batch replay. Requests in flight complete",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "Cancel batch replay",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replay job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ReplayJob"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay-jobs/{jobID}/items": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the requests of a batch replay in order, with the result of those already sent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "List batch replay results",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replay job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ReplayJobItem"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay-jobs/{jobID}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops a running batch replay from sending more requests until it is resumed. Requests in flight complete",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "Pause batch replay",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replay job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ReplayJob"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay-jobs/{jobID}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lets a paused batch replay continue. In timing mode the remaining requests keep their gaps",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "Resume batch replay",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replay job ID",
+                        "name": "jobID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ReplayJob"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists requests received by a webhook, newest first, one page at a time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Requests"
+                ],
+                "summary": "List webhook requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Requests per page (max 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/RequestPage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes every request received by a webhook",
+                "tags": [
+                    "Requests"
+                ],
+                "summary": "Delete all webhook requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Streams the requests received by a webhook, oldest first, as HAR 1.2, newline-delimited JSON, CSV, a Postman v2.1 collection or an Insomnia v4 export. Collections keep the target in a baseUrl variable that defaults to the webhook URL",
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Requests"
+                ],
+                "summary": "Export webhook requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "har",
+                            "jsonl",
+                            "csv",
+                            "postman",
+                            "insomnia"
+                        ],
+                        "type": "string",
+                        "description": "Export format (default har)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only requests received at or after this RFC 3339 time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only requests received before this RFC 3339 time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only requests with these methods",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "live",
+                            "import",
+                            "sent"
+                        ],
+                        "type": "string",
+                        "description": "Only live, imported or sent requests",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only these requests",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection name (default the webhook title)",
+                        "name": "name",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stores every request of a HAR 1.2 or JSONL file (such as an export) under a webhook, marked with source \"import\". The file is imported completely or not at all. Requests keep their original timestamps and follow the webhook's retention policy",
+                "consumes": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Requests"
+                ],
+                "summary": "Import webhook requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "har",
+                            "jsonl"
+                        ],
+                        "type": "string",
+                        "description": "File format, detected when omitted",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "HAR or JSONL document",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/ImportResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests/{requestID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a single request received by a webhook",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Requests"
+                ],
+                "summary": "Get webhook request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/WebhookRequest"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a single request received by a webhook",
+                "tags": [
+                    "Requests"
+                ],
+                "summary": "Delete webhook request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests/{requestID}/comparisons": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the comparisons of a request, newest first, including those made by batches",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comparisons"
+                ],
+                "summary": "List comparisons",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Comparison"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sends a captured request, unchanged, to a baseline and a candidate target at the same time and stores the differences between their responses: status, headers, and the body, key by key when both bodies are JSON. Paths in ignore are left out, as are the Date and Content-Length headers. Patterns look like headers.X-Request-Id, body.id, body.items[*].id or body.**.created_at. Both exchanges are also stored as replay attempts. When a target can't be reached the comparison is stored with error set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comparisons"
+                ],
+                "summary": "Compare two targets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Targets and ignored paths",
+                        "name": "comparison",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CompareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Comparison"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests/{requestID}/comparisons/{comparisonID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets one comparison of a request with its differences",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Comparisons"
+                ],
+                "summary": "Get comparison",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comparison ID",
+                        "name": "comparisonID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Comparison"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests/{requestID}/replays": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the replay attempts of a request, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "List replay attempts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ReplayAttempt"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sends a captured request to a URL, by default the webhook itself, after applying the given edits, and stores the attempt with the target's status, headers, body (up to 1 MiB) and timing. Redirects are not followed. When the target can't be reached, or is on a loopback, private or link-local address the server doesn't allow, the attempt is still stored, with error set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "Replay a request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target and edits",
+                        "name": "replay",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ReplayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/ReplayAttempt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests/{requestID}/replays/{attemptID}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets one replay attempt of a request with the target's response",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replays"
+                ],
+                "summary": "Get replay attempt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replay attempt ID",
+                        "name": "attemptID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ReplayAttempt"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/requests/{requestID}/snippets": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Renders a captured request as code that sends it again, in curl, HTTPie, Go net/http, Python requests, Node fetch and PowerShell. Binary bodies are embedded exactly, as base64 or byte escapes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Requests"
+                ],
+                "summary": "Get code snippets for a request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "requestID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "curl",
+                                "httpie",
+                                "go",
+                                "python",
+                                "node",
+                                "powershell"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Only these languages",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "URL to send the request to (default the webhook URL)",
+                        "name": "target",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Snippet"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/script-state": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gets the key/value state the webhook's script keeps between requests, as the script left it. A webhook whose script never changed it has an empty state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Scripts"
+                ],
+                "summary": "Get script state",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/ScriptState"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Empties the key/value state of the webhook's script, so that the next request starts afresh",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Scripts"
+                ],
+                "summary": "Reset script state",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/send": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sends an event, built from a template and edits, to a target URL, signed with the webhook's signing secret when asked. The event is stored as a request of the webhook with source \"sent\", and the delivery as a replay attempt of it. Failing to reach the target isn't an error: the delivery's error says why",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sender"
+                ],
+                "summary": "Send an event",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template, edits and target",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/SendEventRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/SentEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Streams each new request received by a webhook as a server-sent event whose data is a WebhookRequest",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Requests"
+                ],
+                "summary": "Stream webhook requests",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/WebhookRequest"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "Callback": {
+            "type": "object",
+            "properties": {
+                "action_id": {
+                    "type": "string"
+                },
+                "action_name": {
+                    "type": "string"
+                },
+                "attempt_id": {
+                    "description": "the replay attempt recording the exchange",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "the received request",
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "scheduled",
+                        "succeeded",
+                        "failed",
+                        "cancelled"
+                    ],
+                    "example": "scheduled"
+                },
+                "status_code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "url": {
+                    "description": "as sent",
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "CallbackAction": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delay_ms": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sign": {
+                    "type": "boolean"
+                },
+                "timeout_ms": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "url_from": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "CallbackActionRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "template; JSON unless headers set a Content-Type",
+                    "type": "string",
+                    "example": "{\"status\":\"done\"}"
+                },
+                "delay_ms": {
+                    "description": "after the request is received; at most 3600000",
+                    "type": "integer",
+                    "example": 2000
+                },
+                "enabled": {
+                    "description": "default true",
+                    "type": "boolean"
+                },
+                "headers": {
+                    "description": "templates",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "description": "default POST",
+                    "type": "string",
+                    "example": "POST"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "payment status"
+                },
+                "sign": {
+                    "description": "sign with the webhook's signing secret",
+                    "type": "boolean"
+                },
+                "timeout_ms": {
+                    "description": "default 30000, at most 120000",
+                    "type": "integer",
+                    "example": 30000
+                },
+                "url": {
+                    "description": "used when url_from is empty or not in the request",
+                    "type": "string",
+                    "example": "http://localhost:8080/status"
+                },
+                "url_from": {
+                    "description": "path of the URL in the received request",
+                    "type": "string",
+                    "example": "body.callback_url"
+                }
+            }
+        },
+        "CompareRequest": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "type": "string",
+                    "example": "http://old-consumer.internal/hooks"
+                },
+                "candidate": {
+                    "type": "string",
+                    "example": "http://new-consumer.internal/hooks"
+                },
+                "ignore": {
+                    "description": "paths left out of the diff, besides the Date and Content-Length headers",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "body.id",
+                        "body.**.created_at"
+                    ]
+                },
+                "resign": {
+                    "description": "recompute signature headers with the webhook's signing secret",
+                    "type": "boolean"
+                },
+                "timeout_ms": {
+                    "description": "per target; default 30000, at most 120000",
+                    "type": "integer",
+                    "example": 30000
+                }
+            }
+        },
+        "Comparison": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "type": "string"
+                },
+                "baseline_attempt_id": {
+                    "description": "the replay attempt holding the baseline's response",
+                    "type": "string"
+                },
+                "baseline_status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "batch_id": {
+                    "type": "string"
+                },
+                "candidate": {
+                    "type": "string"
+                },
+                "candidate_attempt_id": {
+                    "description": "the replay attempt holding the candidate's response",
+                    "type": "string"
+                },
+                "candidate_status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "differences": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Difference"
+                    }
+                },
+                "equal": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "a target didn't answer, so nothing was compared",
+                    "type": "string",
+                    "example": "candidate: connection refused"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ignore": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "ComparisonBatch": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "type": "string"
+                },
+                "candidate": {
+                    "type": "string"
+                },
+                "concurrency": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "different": {
+                    "type": "integer"
+                },
+                "equal": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failed": {
+                    "description": "a target didn't answer",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ignore": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "resign": {
+                    "type": "boolean"
+                },
+                "skipped": {
+                    "description": "deleted before their turn",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "completed",
+                        "failed"
+                    ],
+                    "example": "running"
+                },
+                "timeout_ms": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "ComparisonBatchRequest": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "type": "string",
+                    "example": "http://old-consumer.internal/hooks"
+                },
+                "candidate": {
+                    "type": "string",
+                    "example": "http://new-consumer.internal/hooks"
+                },
+                "concurrency": {
+                    "description": "requests compared at once; default 4, at most 20",
+                    "type": "integer",
+                    "example": 4
+                },
+                "ids": {
+                    "description": "only these requests",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ignore": {
+                    "description": "paths left out of the diff, besides the Date and Content-Length headers",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "body.id",
+                        "body.**.created_at"
+                    ]
+                },
+                "resign": {
+                    "description": "recompute signature headers with the webhook's signing secret",
+                    "type": "boolean"
+                },
+                "since": {
+                    "description": "received at or after",
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "description": "per target; default 30000, at most 120000",
+                    "type": "integer",
+                    "example": 30000
+                },
+                "until": {
+                    "description": "received before",
+                    "type": "string"
+                }
+            }
+        },
+        "ComparisonBatchResults": {
+            "type": "object",
+            "properties": {
+                "comparisons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Comparison"
+                    }
+                },
+                "paths": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/PathCount"
+                    }
+                }
+            }
+        },
+        "CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "application/json"
+                },
+                "faults": {
+                    "description": "omitted injects no faults",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/FaultConfig"
+                        }
+                    ]
+                },
+                "notify_on_event": {
+                    "type": "boolean"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "response_code": {
+                    "description": "0 uses 200",
+                    "type": "integer",
+                    "example": 200
+                },
+                "response_delay": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "response_headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "retention_count": {
+                    "description": "keep the last N requests, 0 keeps all",
+                    "type": "integer"
+                },
+                "retention_days": {
+                    "description": "keep requests for D days, 0 keeps all",
+                    "type": "integer"
+                },
+                "script": {
+                    "description": "Starlark script defining handle(request), which answers requests",
+                    "type": "string"
+                },
+                "signing_scheme": {
+                    "description": "github, stripe, shopify or slack; replays and sent events are signed with it",
+                    "type": "string",
+                    "example": "github"
+                },
+                "signing_secret": {
+                    "description": "write-only, never returned",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "Title of the webhook\nrequired: true",
+                    "type": "string"
+                }
+            }
+        },
+        "Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "made so far",
+                    "type": "integer"
+                },
+                "budget_ms": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "all 5 attempts failed"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "policy": {
+                    "type": "string",
+                    "example": "exponential"
+                },
+                "request_id": {
+                    "description": "the event, stored with source \"sent\"",
+                    "type": "string"
+                },
+                "schedule_ms": {
+                    "description": "the wait before each retry",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "sign": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "delivering",
+                        "succeeded",
+                        "failed",
+                        "cancelled"
+                    ],
+                    "example": "delivering"
+                },
+                "status_code": {
+                    "description": "of the latest attempt",
+                    "type": "integer",
+                    "example": 503
+                },
+                "target": {
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "type": "integer"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "DeliveryAttempt": {
+            "type": "object",
+            "properties": {
+                "attempt_id": {
+                    "description": "the replay attempt recording the exchange",
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "status_code": {
+                    "type": "integer",
+                    "example": 503
+                },
+                "succeeded": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "DeliveryRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "budget_ms": {
+                    "description": "for all attempts and waits; default no limit",
+                    "type": "integer",
+                    "example": 60000
+                },
+                "delivery_header": {
+                    "description": "header carrying the delivery ID on every attempt",
+                    "type": "string",
+                    "example": "X-Delivery-ID"
+                },
+                "headers": {
+                    "description": "set over the template's headers",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "initial_delay_ms": {
+                    "description": "exponential: the first wait, doubled for each retry; default 1000",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "max_attempts": {
+                    "description": "exponential: attempts in all; default 5, at most 20",
+                    "type": "integer",
+                    "example": 5
+                },
+                "method": {
+                    "description": "default POST",
+                    "type": "string",
+                    "example": "POST"
+                },
+                "policy": {
+                    "description": "default none",
+                    "type": "string",
+                    "enum": [
+                        "none",
+                        "exponential",
+                        "custom"
+                    ],
+                    "example": "exponential"
+                },
+                "schedule_ms": {
+                    "description": "custom: the wait before each retry",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1000,
+                        5000,
+                        30000
+                    ]
+                },
+                "sign": {
+                    "description": "sign each attempt afresh with the webhook's signing secret",
+                    "type": "boolean"
+                },
+                "target": {
+                    "description": "default the webhook URL",
+                    "type": "string",
+                    "example": "http://localhost:8080/hooks"
+                },
+                "template": {
+                    "type": "string",
+                    "example": "stripe.checkout.session.completed"
+                },
+                "timeout_ms": {
+                    "description": "per attempt; default 30000, at most 120000",
+                    "type": "integer",
+                    "example": 30000
+                }
+            }
+        },
+        "Difference": {
+            "type": "object",
+            "properties": {
+                "baseline": {},
+                "candidate": {},
+                "change": {
+                    "type": "string",
+                    "enum": [
+                        "changed",
+                        "added",
+                        "removed"
+                    ],
+                    "example": "changed"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "body.items[0].total"
+                }
+            }
+        },
+        "ErrorCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "error": {
+                    "type": "string",
+                    "example": "no response within 30s"
+                }
+            }
+        },
+        "EventTemplate": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "example": "POST"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "github.push"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "GitHub"
+                },
+                "scheme": {
+                    "description": "the signing scheme the provider uses",
+                    "type": "string",
+                    "example": "github"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "GitHub push"
+                }
+            }
+        },
+        "FaultConfig": {
+            "type": "object",
+            "properties": {
+                "delay_distribution": {
+                    "description": "default uniform",
+                    "type": "string",
+                    "enum": [
+                        "uniform",
+                        "normal",
+                        "exponential"
+                    ]
+                },
+                "delay_max_ms": {
+                    "description": "at most 30000",
+                    "type": "integer",
+                    "example": 2000
+                },
+                "delay_min_ms": {
+                    "description": "random delay on top of response_delay",
+                    "type": "integer"
+                },
+                "disconnect_percent": {
+                    "description": "connection closed without a response",
+                    "type": "number"
+                },
+                "drip_interval_ms": {
+                    "description": "between dripped bytes, default 100",
+                    "type": "integer"
+                },
+                "drip_percent": {
+                    "description": "body sent one byte at a time",
+                    "type": "number"
+                },
+                "error_percent": {
+                    "type": "number",
+                    "example": 10
+                },
+                "error_status": {
+                    "description": "status of failed requests, default 500",
+                    "type": "integer",
+                    "example": 503
+                },
+                "fail_every": {
+                    "description": "every N-th request gets the error status",
+                    "type": "integer",
+                    "example": 3
+                },
+                "rate_limit": {
+                    "description": "requests answered per window; later ones get 429",
+                    "type": "integer",
+                    "example": 10
+                },
+                "rate_limit_window_ms": {
+                    "description": "default 60000",
+                    "type": "integer",
+                    "example": 60000
+                },
+                "retry_after": {
+                    "description": "seconds in Retry-After, default until the window ends",
+                    "type": "integer"
+                },
+                "truncate_percent": {
+                    "description": "body cut off halfway",
+                    "type": "number"
+                }
+            }
+        },
+        "ImportResult": {
+            "type": "object",
+            "properties": {
+                "imported": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "LoadRun": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "type": "integer"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "elapsed_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "error_counts": {
+                    "description": "most frequent first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ErrorCount"
+                    }
+                },
+                "errors": {
+                    "description": "got no response",
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "answered with another status",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_ms": {
+                    "type": "number",
+                    "example": 48
+                },
+                "mean_ms": {
+                    "type": "number",
+                    "example": 4.2
+                },
+                "min_ms": {
+                    "type": "number",
+                    "example": 0.8
+                },
+                "p50_ms": {
+                    "type": "number",
+                    "example": 3.1
+                },
+                "p90_ms": {
+                    "type": "number",
+                    "example": 7.5
+                },
+                "p95_ms": {
+                    "type": "number",
+                    "example": 9.9
+                },
+                "p99_ms": {
+                    "type": "number",
+                    "example": 21.4
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "requests": {
+                    "description": "completed so far",
+                    "type": "integer"
+                },
+                "sign": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "completed",
+                        "cancelled",
+                        "failed"
+                    ],
+                    "example": "running"
+                },
+                "status_codes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/StatusCount"
+                    }
+                },
+                "succeeded": {
+                    "description": "answered 2xx",
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "throughput": {
+                    "description": "completed requests per second",
+                    "type": "number",
+                    "example": 248.5
+                },
+                "timeout_ms": {
+                    "type": "integer"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "LoadRunRequest": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "description": "requests in flight at most; default 10, at most 100",
+                    "type": "integer",
+                    "example": 10
+                },
+                "count": {
+                    "description": "at most 100000, which also caps runs by duration",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "duration_ms": {
+                    "description": "at most 600000",
+                    "type": "integer",
+                    "example": 30000
+                },
+                "rate": {
+                    "description": "requests started per second; default no limit, at most 1000",
+                    "type": "number",
+                    "example": 50
+                },
+                "request_id": {
+                    "description": "a captured request of the webhook",
+                    "type": "string"
+                },
+                "sign": {
+                    "description": "sign every request with the webhook's signing secret",
+                    "type": "boolean"
+                },
+                "target": {
+                    "type": "string",
+                    "example": "http://localhost:8080/hooks"
+                },
+                "template": {
+                    "description": "instead of request_id",
+                    "type": "string",
+                    "example": "github.push"
+                },
+                "timeout_ms": {
+                    "description": "per request; default 30000, at most 120000",
+                    "type": "integer",
+                    "example": 30000
+                }
+            }
+        },
+        "PatchWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "faults": {
+                    "description": "replaces the whole fault config",
+                    "type": "object"
+                },
+                "notify_on_event": {
+                    "type": "boolean"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "response_code": {
+                    "type": "integer"
+                },
+                "response_delay": {
+                    "type": "integer"
+                },
+                "response_headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "retention_count": {
+                    "type": "integer"
+                },
+                "retention_days": {
+                    "type": "integer"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "signing_scheme": {
+                    "type": "string"
+                },
+                "signing_secret": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "PathCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "path": {
+                    "type": "string",
+                    "example": "body.items[*].total"
+                }
+            }
+        },
+        "Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "webhook not found"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/webhooks/abc"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "urn:webhook-tester:problem:not_found"
+                }
+            }
+        },
+        "ReplayAttempt": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "error": {
+                    "type": "string",
+                    "example": "connection refused"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "request_body": {
+                    "type": "string"
+                },
+                "request_headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "response_body": {
+                    "type": "string"
+                },
+                "response_headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "response_truncated": {
+                    "description": "only the first MiB of the body was kept",
+                    "type": "boolean"
+                },
+                "status_code": {
+                    "description": "0 when no response arrived",
+                    "type": "integer",
+                    "example": 200
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "ReplayJob": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "request 4kPq got 500"
+                },
+                "failed": {
+                    "description": "other statuses and errors",
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "timing"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "resign": {
+                    "type": "boolean"
+                },
+                "skipped": {
+                    "description": "deleted before their turn",
+                    "type": "integer"
+                },
+                "speed": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "paused",
+                        "completed",
+                        "stopped",
+                        "cancelled",
+                        "failed"
+                    ],
+                    "example": "running"
+                },
+                "stop_on_failure": {
+                    "type": "boolean"
+                },
+                "succeeded": {
+                    "description": "answered 2xx",
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "timeout_ms": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "webhook_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "ReplayJobItem": {
+            "type": "object",
+            "properties": {
+                "attempt_id": {
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "offset_ms": {
+                    "description": "when the request arrived, after the job's first",
+                    "type": "integer"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "seq": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "succeeded",
+                        "failed",
+                        "skipped"
+                    ],
+                    "example": "succeeded"
+                },
+                "status_code": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "ReplayJobRequest": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "description": "requests in flight; default 10 in timing mode, 1 in rate mode",
+                    "type": "integer",
+                    "example": 1
+                },
+                "ids": {
+                    "description": "only these requests",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "mode": {
+                    "description": "default timing",
+                    "type": "string",
+                    "enum": [
+                        "timing",
+                        "rate"
+                    ],
+                    "example": "timing"
+                },
+                "rate": {
+                    "description": "rate mode: requests per second; default no limit",
+                    "type": "number",
+                    "example": 5
+                },
+                "resign": {
+                    "description": "recompute signature headers with the webhook's signing secret",
+                    "type": "boolean"
+                },
+                "since": {
+                    "description": "received at or after",
+                    "type": "string"
+                },
+                "speed": {
+                    "description": "timing mode: 2 halves the gaps; default 1",
+                    "type": "number",
+                    "example": 1
+                },
+                "stop_on_failure": {
+                    "description": "stop at the first non-2xx response or error",
+                    "type": "boolean"
+                },
+                "target": {
+                    "description": "default the webhook URL",
+                    "type": "string",
+                    "example": "http://localhost:8080/hooks"
+                },
+                "timeout_ms": {
+                    "description": "per request; default 30000, at most 120000",
+                    "type": "integer",
+                    "example": 30000
+                },
+                "until": {
+                    "description": "received before",
+                    "type": "string"
+                }
+            }
+        },
+        "ReplayRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "headers": {
+                    "description": "set, replacing captured headers",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "example": "POST"
+                },
+                "query": {
+                    "description": "set, replacing captured parameters",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "remove_headers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "X-Hub-Signature-256"
+                    ]
+                },
+                "remove_query": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "resign": {
+                    "description": "recompute signature headers with the webhook's signing secret",
+                    "type": "boolean"
+                },
+                "target": {
+                    "description": "default the webhook URL",
+                    "type": "string",
+                    "example": "http://localhost:8080/hooks"
+                },
+                "timeout_ms": {
+                    "description": "default 30000, at most 120000",
+                    "type": "integer",
+                    "example": 30000
+                }
+            }
+        },
+        "RequestPage": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/WebhookRequest"
+                    }
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "per_page": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "ScriptState": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object"
+                }
+            }
+        },
+        "SendEventRequest": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "headers": {
+                    "description": "set over the template's headers",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "description": "default POST",
+                    "type": "string",
+                    "example": "POST"
+                },
+                "sign": {
+                    "description": "add signature headers with the webhook's signing secret",
+                    "type": "boolean"
+                },
+                "target": {
+                    "description": "default the webhook URL",
+                    "type": "string",
+                    "example": "http://localhost:8080/hooks"
+                },
+                "template": {
+                    "type": "string",
+                    "example": "github.push"
+                },
+                "timeout_ms": {
+                    "description": "default 30000, at most 120000",
+                    "type": "integer",
+                    "example": 30000
+                }
+            }
+        },
+        "SentEvent": {
+            "type": "object",
+            "properties": {
+                "delivery": {
+                    "$ref": "#/definitions/ReplayAttempt"
+                },
+                "request": {
+                    "$ref": "#/definitions/WebhookRequest"
+                }
+            }
+        },
+        "Snippet": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string",
+                    "example": "curl"
+                },
+                "language": {
+                    "type": "string",
+                    "example": "curl"
+                }
+            }
+        },
+        "SpecCallback": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "delay_ms": {
+                    "type": "integer"
+                },
+                "enabled": {
+                    "description": "default true",
+                    "type": "boolean"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "description": "default POST",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sign": {
+                    "type": "boolean"
+                },
+                "timeout_ms": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "url_from": {
+                    "type": "string"
+                }
+            }
+        },
+        "SpecChange": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/spec.Action"
+                        }
+                    ],
+                    "example": "update"
+                },
+                "fields": {
+                    "description": "Fields lists the JSON names of the fields an update changes.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "SpecFaults": {
+            "type": "object",
+            "properties": {
+                "delay_distribution": {
+                    "type": "string"
+                },
+                "delay_max_ms": {
+                    "type": "integer"
+                },
+                "delay_min_ms": {
+                    "type": "integer"
+                },
+                "disconnect_percent": {
+                    "type": "number"
+                },
+                "drip_interval_ms": {
+                    "type": "integer"
+                },
+                "drip_percent": {
+                    "type": "number"
+                },
+                "error_percent": {
+                    "type": "number"
+                },
+                "error_status": {
+                    "type": "integer"
+                },
+                "fail_every": {
+                    "type": "integer"
+                },
+                "rate_limit": {
+                    "type": "integer"
+                },
+                "rate_limit_window_ms": {
+                    "type": "integer"
+                },
+                "retry_after": {
+                    "type": "integer"
+                },
+                "truncate_percent": {
+                    "type": "number"
+                }
+            }
+        },
+        "SpecFile": {
+            "type": "object",
+            "properties": {
+                "version": {
+                    "type": "integer"
+                },
+                "webhooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SpecWebhook"
+                    }
+                }
+            }
+        },
+        "SpecPlan": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "Applied is false for dry runs.",
+                    "type": "boolean"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SpecChange"
+                    }
+                }
+            }
+        },
+        "SpecWebhook": {
+            "type": "object",
+            "properties": {
+                "callbacks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SpecCallback"
+                    }
+                },
+                "content_type": {
+                    "type": "string"
+                },
+                "faults": {
+                    "$ref": "#/definitions/SpecFaults"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "notify_on_event": {
+                    "type": "boolean"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "response_code": {
+                    "type": "integer"
+                },
+                "response_delay": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "response_headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "retention_count": {
+                    "type": "integer"
+                },
+                "retention_days": {
+                    "type": "integer"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "signing_scheme": {
+                    "type": "string"
+                },
+                "signing_secret": {
+                    "description": "a ${NAME} reference; empty keeps the current secret",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "StatusCount": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "count": {
+                    "type": "integer",
+                    "example": 980
+                }
+            }
+        },
+        "UpdateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "application/json"
+                },
+                "faults": {
+                    "description": "omitted injects no faults",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/FaultConfig"
+                        }
+                    ]
+                },
+                "notify_on_event": {
+                    "type": "boolean"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "response_code": {
+                    "description": "0 uses 200",
+                    "type": "integer",
+                    "example": 200
+                },
+                "response_delay": {
+                    "description": "milliseconds",
+                    "type": "integer"
+                },
+                "response_headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "retention_count": {
+                    "description": "keep the last N requests, 0 keeps all",
+                    "type": "integer"
+                },
+                "retention_days": {
+                    "description": "keep requests for D days, 0 keeps all",
+                    "type": "integer"
+                },
+                "script": {
+                    "description": "Starlark script defining handle(request), which answers requests",
+                    "type": "string"
+                },
+                "signing_scheme": {
+                    "description": "github, stripe, shopify or slack; replays and sent events are signed with it",
+                    "type": "string",
+                    "example": "github"
+                },
+                "signing_secret": {
+                    "description": "write-only, never returned",
+                    "type": "string"
+                },
+                "title": {
                     "description": "Title of the webhook\nrequired: true",
                     "type": "string"
                 }
@@ -269,6 +4935,9 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "faults": {
+                    "$ref": "#/definitions/FaultConfig"
+                },
                 "id": {
                     "type": "string"
                 },
@@ -291,6 +4960,25 @@ const docTemplate = `{
                     "description": "milliseconds",
                     "type": "integer"
                 },
+                "response_headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "retention_count": {
+                    "type": "integer"
+                },
+                "retention_days": {
+                    "type": "integer"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "signing_scheme": {
+                    "description": "the secret is never returned",
+                    "type": "string"
+                },
                 "title": {
                     "type": "string"
                 },
@@ -308,6 +4996,22 @@ const docTemplate = `{
                 "body": {
                     "type": "string"
                 },
+                "fault": {
+                    "description": "injected into the response",
+                    "type": "string",
+                    "enum": [
+                        "rate_limit",
+                        "disconnect",
+                        "every_nth",
+                        "error",
+                        "truncate",
+                        "drip"
+                    ]
+                },
+                "fault_delay_ms": {
+                    "description": "random delay injected, milliseconds",
+                    "type": "integer"
+                },
                 "headers": {
                     "$ref": "#/definitions/datatypes.JSONMap"
                 },
@@ -317,12 +5021,31 @@ const docTemplate = `{
                 "method": {
                     "type": "string"
                 },
+                "pinned": {
+                    "type": "boolean"
+                },
                 "query": {
                     "$ref": "#/definitions/datatypes.JSONMap"
                 },
                 "received_at": {
                     "type": "string"
                 },
+                "script_log": {
+                    "description": "what the webhook's script printed, and its error",
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "source": {
+                    "description": "live traffic, imported from a file or sent by the event sender",
+                    "type": "string",
+                    "enum": [
+                        "live",
+                        "import",
+                        "sent"
+                    ]
+                },
                 "webhook_id": {
                     "type": "string"
                 }
@@ -331,6 +5054,21 @@ const docTemplate = `{
         "datatypes.JSONMap": {
             "type": "object",
             "additionalProperties": true
+        },
+        "spec.Action": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete",
+                "unchanged"
+            ],
+            "x-enum-varnames": [
+                "Create",
+                "Update",
+                "Delete",
+                "Unchanged"
+            ]
         }
     },
     "securityDefinitions": {
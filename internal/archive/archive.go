@@ -0,0 +1,250 @@
+// Package archive writes captured webhook requests in formats other tools
+// read: HTTP Archive (HAR 1.2), newline-delimited JSON, CSV, and Postman and
+// Insomnia collections. Writers stream, so an export never holds more than
+// one request in memory. HAR and JSONL documents can be read back into
+// requests.
+package archive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"webhook-tester/internal/models"
+)
+
+// Format is an export format.
+type Format string
+
+const (
+	HAR      Format = "har"
+	JSONL    Format = "jsonl"
+	CSV      Format = "csv"
+	Postman  Format = "postman"  // Postman collection v2.1
+	Insomnia Format = "insomnia" // Insomnia export v4
+)
+
+// Formats lists every supported format.
+var Formats = []Format{HAR, JSONL, CSV, Postman, Insomnia}
+
+// ParseFormat returns the Format named s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %q, want har, jsonl, csv, postman or insomnia", s)
+}
+
+// ContentType is the media type of a document in format f.
+func (f Format) ContentType() string {
+	switch f {
+	case HAR, Postman, Insomnia:
+		return "application/json"
+	case JSONL:
+		return "application/x-ndjson"
+	case CSV:
+		return "text/csv; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// Ext is the file name extension of a document in format f.
+func (f Format) Ext() string {
+	switch f {
+	case Postman:
+		return "postman_collection.json"
+	case Insomnia:
+		return "insomnia.json"
+	}
+	return string(f)
+}
+
+// Source describes where the exported requests were captured.
+type Source struct {
+	Webhook *models.Webhook
+	// BaseURL is the server the requests were sent to, e.g. https://hooks.example.com.
+	BaseURL string
+	// Name names a collection; it defaults to the webhook's title.
+	Name string
+}
+
+// WebhookURL is the URL of the webhook the requests were sent to.
+func (s Source) WebhookURL() string {
+	return strings.TrimSuffix(s.BaseURL, "/") + "/webhooks/" + url.PathEscape(s.Webhook.ID)
+}
+
+// URL is the full URL a request was sent to.
+func (s Source) URL(wr *models.WebhookRequest) string {
+	u := s.WebhookURL()
+	if q := encodeQuery(wr.Query); q != "" {
+		u += "?" + q
+	}
+	return u
+}
+
+// CollectionName is the name of a Postman or Insomnia collection.
+func (s Source) CollectionName() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Webhook.Title != "":
+		return s.Webhook.Title
+	}
+	return "Webhook " + s.Webhook.ID
+}
+
+// Writer writes requests one at a time. Close completes the document; it
+// doesn't close the underlying io.Writer.
+type Writer interface {
+	Write(wr *models.WebhookRequest) error
+	Close() error
+}
+
+// NewWriter returns a Writer producing format f on w.
+func NewWriter(f Format, w io.Writer, src Source) (Writer, error) {
+	switch f {
+	case HAR:
+		return newHARWriter(w, src), nil
+	case JSONL:
+		return newJSONLWriter(w), nil
+	case CSV:
+		return newCSVWriter(w), nil
+	case Postman:
+		return newPostmanWriter(w, src), nil
+	case Insomnia:
+		return newInsomniaWriter(w, src), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", f)
+}
+
+// Reader reads requests one at a time. Read returns io.EOF after the last
+// one. Requests come back without an ID or webhook; ReceivedAt is zero when
+// the document doesn't record it.
+type Reader interface {
+	Read() (*models.WebhookRequest, error)
+}
+
+// NewReader returns a Reader for a document in format f. CSV can't be read
+// back: it doesn't keep repeated query parameters apart.
+func NewReader(f Format, r io.Reader) (Reader, error) {
+	switch f {
+	case HAR:
+		return newHARReader(r), nil
+	case JSONL:
+		return newJSONLReader(r), nil
+	}
+	return nil, fmt.Errorf("%s files can't be imported, use har or jsonl", f)
+}
+
+// Detect guesses the format of a document from its first bytes: a JSON
+// object with a "log" key is HAR, other JSON objects and arrays are JSONL.
+func Detect(head []byte) (Format, bool) {
+	head = bytes.TrimLeft(head, " \t\r\n\ufeff")
+	if len(head) == 0 {
+		return "", false
+	}
+	if head[0] == '[' {
+		return JSONL, true
+	}
+	if head[0] != '{' {
+		return "", false
+	}
+	dec := json.NewDecoder(bytes.NewReader(head))
+	dec.Token() // {
+	if key, err := dec.Token(); err == nil && key == "log" {
+		return HAR, true
+	}
+	return JSONL, true
+}
+
+// ReadError reports a malformed entry of an imported document.
+type ReadError struct {
+	Entry int // 1-based
+	Err   error
+}
+
+func (e *ReadError) Error() string { return fmt.Sprintf("entry %d: %v", e.Entry, e.Err) }
+
+func (e *ReadError) Unwrap() error { return e.Err }
+
+// checkRequest validates a request read from a document.
+func checkRequest(wr *models.WebhookRequest) error {
+	if wr.Method == "" {
+		return fmt.Errorf("missing method")
+	}
+	for _, c := range wr.Method {
+		if c < 'A' || c > 'Z' {
+			return fmt.Errorf("invalid method %q", wr.Method)
+		}
+	}
+	return nil
+}
+
+// joinValues adds value to the comma-joined list under key, the way live
+// requests store repeated headers and query parameters.
+func joinValues(m map[string]any, key, value string) {
+	if prev, ok := m[key]; ok {
+		value = fmt.Sprint(prev) + "," + value
+	}
+	m[key] = value
+}
+
+func encodeQuery(q map[string]any) string {
+	v := url.Values{}
+	for k, val := range q {
+		v.Set(k, fmt.Sprint(val))
+	}
+	return v.Encode()
+}
+
+// sortedKeys returns the keys of m in order, so output is deterministic.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// connectionHeaders are set by the HTTP client for each connection, so
+// collections leave them out.
+var connectionHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+}
+
+// requestHeaders returns the headers of wr worth replaying, sorted by name.
+func requestHeaders(wr *models.WebhookRequest) []harNameValue {
+	var out []harNameValue
+	for _, nv := range nameValues(wr.Headers) {
+		if !connectionHeaders[http.CanonicalHeaderKey(nv.Name)] {
+			out = append(out, nv)
+		}
+	}
+	return out
+}
+
+// itemName names a request in a collection.
+func itemName(wr *models.WebhookRequest) string {
+	return wr.Method + " " + wr.ReceivedAt.UTC().Format("2006-01-02 15:04:05") + " (" + wr.ID + ")"
+}
+
+// header returns the value of the named header, ignoring case.
+func header(h map[string]any, name string) string {
+	for k, v := range h {
+		if strings.EqualFold(k, name) {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,301 @@
+package archive_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"webhook-tester/internal/archive"
+	"webhook-tester/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+var (
+	contentType = "application/json"
+	payload     = `{"ok":true}`
+	hook        = &models.Webhook{ID: "w1", ResponseCode: 202, ContentType: &contentType, Payload: &payload}
+	requests    = []models.WebhookRequest{
+		{
+			ID: "r1", WebhookID: "w1", Method: "POST",
+			Headers:    datatypes.JSONMap{"Content-Type": "application/json", "X-Id": "1"},
+			Query:      datatypes.JSONMap{"a": "1 2"},
+			Body:       "{\"event\":\"paid\",\n\"n\":1}",
+			ReceivedAt: time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{ID: "r2", WebhookID: "w1", Method: "GET", ReceivedAt: time.Date(2025, 4, 1, 12, 1, 0, 0, time.UTC)},
+	}
+)
+
+func export(t *testing.T, f archive.Format, list []models.WebhookRequest) []byte {
+	var buf bytes.Buffer
+	w, err := archive.NewWriter(f, &buf, archive.Source{Webhook: hook, BaseURL: "https://hooks.example.com/"})
+	require.NoError(t, err)
+	for i := range list {
+		require.NoError(t, w.Write(&list[i]))
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestHAR(t *testing.T) {
+	var doc struct {
+		Log struct {
+			Version string `json:"version"`
+			Entries []struct {
+				StartedDateTime string `json:"startedDateTime"`
+				Request         struct {
+					Method      string `json:"method"`
+					URL         string `json:"url"`
+					Headers     []map[string]string
+					QueryString []map[string]string `json:"queryString"`
+					PostData    *struct {
+						MimeType string `json:"mimeType"`
+						Text     string `json:"text"`
+					} `json:"postData"`
+				} `json:"request"`
+				Response struct {
+					Status int `json:"status"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	require.NoError(t, json.Unmarshal(export(t, archive.HAR, requests), &doc))
+	assert.Equal(t, "1.2", doc.Log.Version)
+	require.Len(t, doc.Log.Entries, 2)
+
+	e := doc.Log.Entries[0]
+	assert.Equal(t, "2025-04-01T12:00:00Z", e.StartedDateTime)
+	assert.Equal(t, "POST", e.Request.Method)
+	assert.Equal(t, "https://hooks.example.com/webhooks/w1?a=1+2", e.Request.URL)
+	assert.Equal(t, []map[string]string{{"name": "Content-Type", "value": "application/json"}, {"name": "X-Id", "value": "1"}}, e.Request.Headers)
+	assert.Equal(t, []map[string]string{{"name": "a", "value": "1 2"}}, e.Request.QueryString)
+	require.NotNil(t, e.Request.PostData)
+	assert.Equal(t, "application/json", e.Request.PostData.MimeType)
+	assert.Equal(t, requests[0].Body, e.Request.PostData.Text)
+	assert.Equal(t, 202, e.Response.Status)
+	assert.Nil(t, doc.Log.Entries[1].Request.PostData)
+
+	require.NoError(t, json.Unmarshal(export(t, archive.HAR, nil), &doc))
+	assert.Empty(t, doc.Log.Entries)
+}
+
+func TestJSONL(t *testing.T) {
+	scanner := bufio.NewScanner(bytes.NewReader(export(t, archive.JSONL, requests)))
+	var ids []string
+	for scanner.Scan() {
+		var wr struct {
+			ID   string `json:"id"`
+			Body string `json:"body"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &wr))
+		ids = append(ids, wr.ID)
+	}
+	assert.Equal(t, []string{"r1", "r2"}, ids)
+	assert.Empty(t, export(t, archive.JSONL, nil))
+}
+
+func TestCSV(t *testing.T) {
+	rows, err := csv.NewReader(bytes.NewReader(export(t, archive.CSV, requests))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"id", "received_at", "method", "query", "headers", "body", "size", "pinned"}, rows[0])
+	assert.Equal(t, "r1", rows[1][0])
+	assert.Equal(t, "a=1+2", rows[1][3])
+	assert.JSONEq(t, `{"Content-Type":"application/json","X-Id":"1"}`, rows[1][4])
+	assert.Equal(t, requests[0].Body, rows[1][5], "multi-line bodies survive quoting")
+
+	rows, err = csv.NewReader(bytes.NewReader(export(t, archive.CSV, nil))).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, rows, 1, "an empty export still has a header")
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := archive.ParseFormat("HAR")
+	require.NoError(t, err)
+	assert.Equal(t, archive.HAR, f)
+	_, err = archive.ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func readAll(t *testing.T, f archive.Format, doc []byte) []models.WebhookRequest {
+	r, err := archive.NewReader(f, bytes.NewReader(doc))
+	require.NoError(t, err)
+	var out []models.WebhookRequest
+	for {
+		wr, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return out
+		}
+		require.NoError(t, err)
+		out = append(out, *wr)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, f := range []archive.Format{archive.HAR, archive.JSONL} {
+		t.Run(string(f), func(t *testing.T) {
+			doc := export(t, f, requests)
+			detected, ok := archive.Detect(doc)
+			assert.True(t, ok)
+			assert.Equal(t, f, detected)
+
+			got := readAll(t, f, doc)
+			require.Len(t, got, 2)
+			for i, wr := range got {
+				assert.Equal(t, requests[i].Method, wr.Method)
+				assert.Equal(t, requests[i].Body, wr.Body)
+				assert.True(t, requests[i].ReceivedAt.Equal(wr.ReceivedAt))
+			}
+			assert.Equal(t, "application/json", got[0].Headers["Content-Type"])
+			assert.Equal(t, "1 2", got[0].Query["a"])
+
+			assert.Empty(t, readAll(t, f, export(t, f, nil)))
+		})
+	}
+}
+
+func TestReadHAR(t *testing.T) {
+	doc := `{"log":{"version":"1.2","pages":[{"id":"p"}],"entries":[{
+		"startedDateTime":"2025-04-01T12:00:00.5+02:00",
+		"request":{"method":"post","url":"https://example.com/hook?x=1&x=2",
+			"headers":[{"name":":authority","value":"example.com"},{"name":"x-sig","value":"a"},{"name":"X-Sig","value":"b"}],
+			"postData":{"mimeType":"application/x-www-form-urlencoded","params":[{"name":"k","value":"v w"}]}}}]}}`
+	got := readAll(t, archive.HAR, []byte(doc))
+	require.Len(t, got, 1)
+	assert.Equal(t, "POST", got[0].Method)
+	assert.Equal(t, datatypes.JSONMap{"X-Sig": "a,b"}, got[0].Headers, "pseudo-headers are dropped, repeats joined")
+	assert.Equal(t, datatypes.JSONMap{"x": "1,2"}, got[0].Query, "query falls back to the URL")
+	assert.Equal(t, "k=v+w", got[0].Body)
+	assert.Equal(t, time.Date(2025, 4, 1, 10, 0, 0, 5e8, time.UTC), got[0].ReceivedAt)
+
+	r, err := archive.NewReader(archive.HAR, strings.NewReader(`{"entries":[]}`))
+	require.NoError(t, err)
+	_, err = r.Read()
+	assert.ErrorContains(t, err, "not a HAR file")
+}
+
+func TestReadJSONL(t *testing.T) {
+	array := `[{"method":"PUT","headers":{"X-N":1},"pinned":true,"received_at":"2025-04-01T12:00:00Z"},{"method":"GET"}]`
+	got := readAll(t, archive.JSONL, []byte(array))
+	require.Len(t, got, 2)
+	assert.Equal(t, "1", got[0].Headers["X-N"], "values are stored as strings")
+	assert.True(t, got[0].Pinned)
+	assert.True(t, got[1].ReceivedAt.IsZero())
+
+	r, err := archive.NewReader(archive.JSONL, strings.NewReader("{\"method\":\"GET\"}\n{\"body\":\"x\"}\n"))
+	require.NoError(t, err)
+	_, err = r.Read()
+	require.NoError(t, err)
+	_, err = r.Read()
+	var re *archive.ReadError
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, 2, re.Entry)
+
+	_, err = archive.NewReader(archive.CSV, strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestPostman(t *testing.T) {
+	var doc struct {
+		Info struct {
+			Name   string `json:"name"`
+			Schema string `json:"schema"`
+		} `json:"info"`
+		Variable []map[string]string `json:"variable"`
+		Item     []struct {
+			Name    string `json:"name"`
+			Request struct {
+				Method string              `json:"method"`
+				Header []map[string]string `json:"header"`
+				URL    struct {
+					Raw   string              `json:"raw"`
+					Query []map[string]string `json:"query"`
+				} `json:"url"`
+				Body *struct {
+					Mode    string `json:"mode"`
+					Raw     string `json:"raw"`
+					Options struct {
+						Raw struct {
+							Language string `json:"language"`
+						} `json:"raw"`
+					} `json:"options"`
+				} `json:"body"`
+			} `json:"request"`
+		} `json:"item"`
+	}
+	require.NoError(t, json.Unmarshal(export(t, archive.Postman, requests), &doc))
+	assert.Equal(t, "https://schema.getpostman.com/json/collection/v2.1.0/collection.json", doc.Info.Schema)
+	assert.Equal(t, "Webhook w1", doc.Info.Name)
+	assert.Equal(t, []map[string]string{{"key": "baseUrl", "value": "https://hooks.example.com/webhooks/w1", "type": "string"}}, doc.Variable)
+	require.Len(t, doc.Item, 2)
+
+	req := doc.Item[0].Request
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "{{baseUrl}}?a=1+2", req.URL.Raw)
+	assert.Equal(t, []map[string]string{{"key": "a", "value": "1 2"}}, req.URL.Query)
+	assert.Equal(t, []map[string]string{{"key": "Content-Type", "value": "application/json"}, {"key": "X-Id", "value": "1"}}, req.Header)
+	require.NotNil(t, req.Body)
+	assert.Equal(t, requests[0].Body, req.Body.Raw)
+	assert.Equal(t, "json", req.Body.Options.Raw.Language)
+	assert.Nil(t, doc.Item[1].Request.Body)
+
+	require.NoError(t, json.Unmarshal(export(t, archive.Postman, nil), &doc))
+	assert.Empty(t, doc.Item)
+}
+
+func TestInsomnia(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := archive.NewWriter(archive.Insomnia, &buf, archive.Source{Webhook: hook, BaseURL: "https://hooks.example.com", Name: "Checkout fixtures"})
+	require.NoError(t, err)
+	binary := models.WebhookRequest{ID: "r3", Method: "PUT", Body: "\x00\xff", Headers: datatypes.JSONMap{"Host": "x"}}
+	for _, wr := range append(slices.Clone(requests), binary) {
+		require.NoError(t, w.Write(&wr))
+	}
+	require.NoError(t, w.Close())
+
+	var doc struct {
+		Format    int `json:"__export_format"`
+		Resources []struct {
+			ID          string            `json:"_id"`
+			Type        string            `json:"_type"`
+			ParentID    string            `json:"parentId"`
+			Name        string            `json:"name"`
+			Description string            `json:"description"`
+			Data        map[string]string `json:"data"`
+			URL         string            `json:"url"`
+			Parameters  []map[string]string
+			Headers     []map[string]string
+			Body        *struct {
+				MimeType string `json:"mimeType"`
+				Text     string `json:"text"`
+			} `json:"body"`
+		} `json:"resources"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, 4, doc.Format)
+	require.Len(t, doc.Resources, 5)
+	assert.Equal(t, "workspace", doc.Resources[0].Type)
+	assert.Equal(t, "Checkout fixtures", doc.Resources[0].Name)
+	assert.Equal(t, map[string]string{"baseUrl": "https://hooks.example.com/webhooks/w1"}, doc.Resources[1].Data)
+
+	req := doc.Resources[2]
+	assert.Equal(t, "req_r1", req.ID)
+	assert.Equal(t, "wrk_w1", req.ParentID)
+	assert.Equal(t, "{{ _.baseUrl }}", req.URL)
+	assert.Equal(t, []map[string]string{{"name": "a", "value": "1 2"}}, req.Parameters)
+	assert.Equal(t, "application/json", req.Body.MimeType)
+
+	bin := doc.Resources[4]
+	assert.Empty(t, bin.Headers, "connection headers are left out")
+	assert.Equal(t, "AP8=", bin.Body.Text)
+	assert.Contains(t, bin.Description, "base64")
+}
@@ -0,0 +1,55 @@
+package archive
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+	"webhook-tester/internal/models"
+)
+
+var csvHeader = []string{"id", "received_at", "method", "query", "headers", "body", "size", "pinned"}
+
+// csvWriter writes one row per request. Query strings are URL-encoded and
+// headers are a JSON object, so each request stays on a single record.
+type csvWriter struct {
+	w       *csv.Writer
+	started bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(wr *models.WebhookRequest) error {
+	if !c.started {
+		c.started = true
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	headers, err := json.Marshal(wr.Headers)
+	if err != nil {
+		return err
+	}
+	return c.w.Write([]string{
+		wr.ID,
+		wr.ReceivedAt.UTC().Format(time.RFC3339Nano),
+		wr.Method,
+		encodeQuery(wr.Query),
+		string(headers),
+		wr.Body,
+		strconv.FormatInt(wr.Size, 10),
+		strconv.FormatBool(wr.Pinned),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	if !c.started {
+		c.started = true
+		c.w.Write(csvHeader)
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
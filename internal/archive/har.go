@@ -0,0 +1,309 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"webhook-tester/internal/models"
+
+	"gorm.io/datatypes"
+)
+
+// HAR 1.2 document types, see http://www.softwareishard.com/blog/har-12-spec/.
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    int `json:"send"`
+	Wait    int `json:"wait"`
+	Receive int `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            int         `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+// harWriter streams the entries array of a HAR log, so the document is
+// assembled by hand around entries encoded one at a time.
+type harWriter struct {
+	w        *bufio.Writer
+	src      Source
+	response harResponse
+	started  bool
+	entries  int
+}
+
+func newHARWriter(w io.Writer, src Source) *harWriter {
+	return &harWriter{w: bufio.NewWriter(w), src: src, response: harWebhookResponse(src.Webhook)}
+}
+
+func (h *harWriter) Write(wr *models.WebhookRequest) error {
+	if !h.started {
+		h.start()
+	}
+	entry, err := json.Marshal(h.entry(wr))
+	if err != nil {
+		return err
+	}
+	if h.entries > 0 {
+		h.w.WriteByte(',')
+	}
+	h.entries++
+	_, err = h.w.Write(entry)
+	return err
+}
+
+func (h *harWriter) Close() error {
+	if !h.started {
+		h.start()
+	}
+	h.w.WriteString("]}}\n")
+	return h.w.Flush()
+}
+
+func (h *harWriter) start() {
+	h.started = true
+	fmt.Fprintf(h.w, `{"log":{"version":"1.2","creator":{"name":"webhook-tester","version":"1.0"},"comment":%q,"entries":[`,
+		"Requests captured by webhook "+h.src.Webhook.ID)
+}
+
+func (h *harWriter) entry(wr *models.WebhookRequest) harEntry {
+	req := harRequest{
+		Method:      wr.Method,
+		URL:         h.src.URL(wr),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     nameValues(wr.Headers),
+		QueryString: nameValues(wr.Query),
+		HeadersSize: -1,
+		BodySize:    len(wr.Body),
+	}
+	if wr.Body != "" {
+		req.PostData = &harPostData{MimeType: header(wr.Headers, "Content-Type"), Text: wr.Body}
+	}
+	return harEntry{
+		StartedDateTime: wr.ReceivedAt.UTC().Format(time.RFC3339Nano),
+		Request:         req,
+		Response:        h.response,
+	}
+}
+
+// harWebhookResponse describes the response the webhook is configured to
+// send. Responses aren't stored per request, so every entry shares it.
+func harWebhookResponse(w *models.Webhook) harResponse {
+	resp := harResponse{
+		Status:      w.ResponseCode,
+		StatusText:  http.StatusText(w.ResponseCode),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     nameValues(w.ResponseHeaders),
+		HeadersSize: -1,
+		BodySize:    -1,
+		Comment:     "response configured on the webhook at export time",
+	}
+	if w.ContentType != nil {
+		resp.Content.MimeType = *w.ContentType
+	}
+	if w.Payload != nil {
+		resp.Content.Text = *w.Payload
+		resp.Content.Size = len(*w.Payload)
+		resp.BodySize = len(*w.Payload)
+	}
+	return resp
+}
+
+func nameValues(m map[string]any) []harNameValue {
+	out := make([]harNameValue, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		out = append(out, harNameValue{Name: k, Value: fmt.Sprint(m[k])})
+	}
+	return out
+}
+
+// harInEntry is the part of a HAR entry an import needs.
+type harInEntry struct {
+	StartedDateTime string `json:"startedDateTime"`
+	Request         struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *struct {
+			Text   string         `json:"text"`
+			Params []harNameValue `json:"params"`
+		} `json:"postData"`
+	} `json:"request"`
+}
+
+// harReader decodes log.entries one entry at a time, skipping the rest of
+// the document.
+type harReader struct {
+	dec     *json.Decoder
+	started bool
+	entries int
+}
+
+func newHARReader(r io.Reader) *harReader {
+	return &harReader{dec: json.NewDecoder(r)}
+}
+
+func (h *harReader) Read() (*models.WebhookRequest, error) {
+	if !h.started {
+		h.started = true
+		if err := h.seekEntries(); err != nil {
+			return nil, fmt.Errorf("not a HAR file: %w", err)
+		}
+	}
+	if !h.dec.More() {
+		return nil, io.EOF
+	}
+	h.entries++
+	var e harInEntry
+	if err := h.dec.Decode(&e); err != nil {
+		return nil, &ReadError{Entry: h.entries, Err: err}
+	}
+	wr, err := e.request()
+	if err != nil {
+		return nil, &ReadError{Entry: h.entries, Err: err}
+	}
+	return wr, nil
+}
+
+// seekEntries moves the decoder to just inside the log.entries array.
+func (h *harReader) seekEntries() error {
+	if err := h.expect('{'); err != nil {
+		return err
+	}
+	if err := h.seekKey("log"); err != nil {
+		return err
+	}
+	if err := h.expect('{'); err != nil {
+		return err
+	}
+	if err := h.seekKey("entries"); err != nil {
+		return err
+	}
+	return h.expect('[')
+}
+
+// seekKey skips members of the current object until key.
+func (h *harReader) seekKey(key string) error {
+	for h.dec.More() {
+		tok, err := h.dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == key {
+			return nil
+		}
+		var skip json.RawMessage
+		if err := h.dec.Decode(&skip); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("missing %q", key)
+}
+
+func (h *harReader) expect(d json.Delim) error {
+	tok, err := h.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != d {
+		return fmt.Errorf("expected %q, got %v", d, tok)
+	}
+	return nil
+}
+
+func (e *harInEntry) request() (*models.WebhookRequest, error) {
+	wr := &models.WebhookRequest{
+		Method:  strings.ToUpper(strings.TrimSpace(e.Request.Method)),
+		Headers: datatypes.JSONMap{},
+		Query:   datatypes.JSONMap{},
+	}
+	if err := checkRequest(wr); err != nil {
+		return nil, err
+	}
+	for _, h := range e.Request.Headers {
+		// HTTP/2 pseudo-headers such as :authority aren't real headers
+		if h.Name == "" || strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		joinValues(wr.Headers, http.CanonicalHeaderKey(h.Name), h.Value)
+	}
+	if len(e.Request.QueryString) > 0 {
+		for _, q := range e.Request.QueryString {
+			joinValues(wr.Query, q.Name, q.Value)
+		}
+	} else if u, err := url.Parse(e.Request.URL); err == nil {
+		for k, vs := range u.Query() {
+			wr.Query[k] = strings.Join(vs, ",")
+		}
+	}
+	if pd := e.Request.PostData; pd != nil {
+		wr.Body = pd.Text
+		if wr.Body == "" && len(pd.Params) > 0 {
+			form := url.Values{}
+			for _, p := range pd.Params {
+				form.Add(p.Name, p.Value)
+			}
+			wr.Body = form.Encode()
+		}
+	}
+	if e.StartedDateTime != "" {
+		t, err := time.Parse(time.RFC3339Nano, e.StartedDateTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid startedDateTime %q", e.StartedDateTime)
+		}
+		wr.ReceivedAt = t.UTC()
+	}
+	return wr, nil
+}
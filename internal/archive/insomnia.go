@@ -0,0 +1,122 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+	"webhook-tester/internal/models"
+)
+
+type insomniaResource struct {
+	ID          string         `json:"_id"`
+	Type        string         `json:"_type"`
+	ParentID    string         `json:"parentId,omitempty"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Scope       string         `json:"scope,omitempty"`
+	Data        map[string]any `json:"data,omitempty"`
+	Method      string         `json:"method,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Parameters  []harNameValue `json:"parameters,omitempty"`
+	Headers     []harNameValue `json:"headers,omitempty"`
+	Body        *insomniaBody  `json:"body,omitempty"`
+}
+
+type insomniaBody struct {
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// insomniaWriter streams the resources of an Insomnia v4 export: a
+// workspace, its base environment holding the base URL, then one request
+// per captured request.
+type insomniaWriter struct {
+	w         *bufio.Writer
+	src       Source
+	started   bool
+	resources int
+}
+
+func newInsomniaWriter(w io.Writer, src Source) *insomniaWriter {
+	return &insomniaWriter{w: bufio.NewWriter(w), src: src}
+}
+
+func (in *insomniaWriter) workspaceID() string { return "wrk_" + in.src.Webhook.ID }
+
+func (in *insomniaWriter) Write(wr *models.WebhookRequest) error {
+	if err := in.start(); err != nil {
+		return err
+	}
+	res := insomniaResource{
+		ID:         "req_" + wr.ID,
+		Type:       "request",
+		ParentID:   in.workspaceID(),
+		Name:       itemName(wr),
+		Method:     wr.Method,
+		URL:        "{{ _." + baseURLVariable + " }}",
+		Parameters: nameValues(wr.Query),
+		Headers:    requestHeaders(wr),
+	}
+	if wr.Body != "" {
+		text, note := collectionBody(wr.Body)
+		res.Body = &insomniaBody{MimeType: header(wr.Headers, "Content-Type"), Text: text}
+		res.Description = note
+	}
+	return in.resource(res)
+}
+
+func (in *insomniaWriter) Close() error {
+	if err := in.start(); err != nil {
+		return err
+	}
+	in.w.WriteString("]}\n")
+	return in.w.Flush()
+}
+
+func (in *insomniaWriter) start() error {
+	if in.started {
+		return nil
+	}
+	in.started = true
+	head, err := json.Marshal(map[string]any{
+		"_type":           "export",
+		"__export_format": 4,
+		"__export_date":   time.Now().UTC().Format(time.RFC3339),
+		"__export_source": "webhook-tester",
+	})
+	if err != nil {
+		return err
+	}
+	in.w.Write(head[:len(head)-1])
+	in.w.WriteString(`,"resources":[`)
+	if err := in.resource(insomniaResource{
+		ID:          in.workspaceID(),
+		Type:        "workspace",
+		Name:        in.src.CollectionName(),
+		Description: "Requests captured by webhook " + in.src.Webhook.ID,
+		Scope:       "collection",
+	}); err != nil {
+		return err
+	}
+	return in.resource(insomniaResource{
+		ID:       "env_" + in.src.Webhook.ID,
+		Type:     "environment",
+		ParentID: in.workspaceID(),
+		Name:     "Base Environment",
+		Data:     map[string]any{baseURLVariable: in.src.WebhookURL()},
+	})
+}
+
+func (in *insomniaWriter) resource(r insomniaResource) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if in.resources > 0 {
+		in.w.WriteByte(',')
+	}
+	in.resources++
+	_, err = in.w.Write(b)
+	return err
+}
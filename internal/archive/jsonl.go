@@ -0,0 +1,122 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/models"
+
+	"gorm.io/datatypes"
+)
+
+// jsonlWriter writes one API WebhookRequest object per line.
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (j *jsonlWriter) Write(wr *models.WebhookRequest) error {
+	return j.enc.Encode(dtos.NewWebhookRequestDTO(*wr))
+}
+
+func (j *jsonlWriter) Close() error {
+	return j.w.Flush()
+}
+
+// jsonlEntry is the part of an API WebhookRequest an import keeps.
+type jsonlEntry struct {
+	Method     string         `json:"method"`
+	Headers    map[string]any `json:"headers"`
+	Query      map[string]any `json:"query"`
+	Body       string         `json:"body"`
+	Pinned     bool           `json:"pinned"`
+	ReceivedAt time.Time      `json:"received_at"`
+}
+
+// jsonlReader reads WebhookRequest objects, one per line or, as whctl
+// export -format json writes them, in a single array.
+type jsonlReader struct {
+	r       *bufio.Reader
+	dec     *json.Decoder
+	array   bool
+	entries int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	return &jsonlReader{r: bufio.NewReader(r)}
+}
+
+func (j *jsonlReader) Read() (*models.WebhookRequest, error) {
+	if j.dec == nil {
+		if err := j.start(); err != nil {
+			return nil, err
+		}
+	}
+	if j.array && !j.dec.More() {
+		return nil, io.EOF
+	}
+	j.entries++
+	var e jsonlEntry
+	if err := j.dec.Decode(&e); err != nil {
+		if err == io.EOF && !j.array {
+			return nil, io.EOF
+		}
+		return nil, &ReadError{Entry: j.entries, Err: err}
+	}
+	wr := &models.WebhookRequest{
+		Method:     strings.ToUpper(strings.TrimSpace(e.Method)),
+		Headers:    stringValues(e.Headers),
+		Query:      stringValues(e.Query),
+		Body:       e.Body,
+		Pinned:     e.Pinned,
+		ReceivedAt: e.ReceivedAt.UTC(),
+	}
+	if err := checkRequest(wr); err != nil {
+		return nil, &ReadError{Entry: j.entries, Err: err}
+	}
+	return wr, nil
+}
+
+// start skips leading space and a byte order mark and checks for an array.
+func (j *jsonlReader) start() error {
+	if bom, _ := j.r.Peek(3); string(bom) == "\ufeff" {
+		j.r.Discard(3)
+	}
+	j.dec = json.NewDecoder(j.r)
+	for {
+		b, err := j.r.Peek(1)
+		if err != nil || !unicode.IsSpace(rune(b[0])) {
+			j.array = err == nil && b[0] == '['
+			break
+		}
+		j.r.Discard(1)
+	}
+	if j.array {
+		_, err := j.dec.Token()
+		return err
+	}
+	return nil
+}
+
+// stringValues stores every value as a string, as live requests do.
+func stringValues(m map[string]any) datatypes.JSONMap {
+	out := datatypes.JSONMap{}
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		} else {
+			out[k] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
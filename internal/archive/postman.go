@@ -0,0 +1,174 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"strings"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/snippet"
+)
+
+// postmanSchema identifies Postman collection format v2.1.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// baseURLVariable holds where a collection sends its requests, so users can
+// point it at a local service instead of the webhook.
+const baseURLVariable = "baseUrl"
+
+type postmanKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+type postmanURL struct {
+	Raw   string            `json:"raw"`
+	Host  []string          `json:"host"`
+	Query []postmanKeyValue `json:"query,omitempty"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Schema      string `json:"schema"`
+}
+
+type postmanBody struct {
+	Mode    string          `json:"mode"`
+	Raw     string          `json:"raw"`
+	Options *postmanOptions `json:"options,omitempty"`
+}
+
+type postmanOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Header      []postmanKeyValue `json:"header"`
+	URL         postmanURL        `json:"url"`
+	Body        *postmanBody      `json:"body,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+// postmanWriter streams the item array of a collection.
+type postmanWriter struct {
+	w       *bufio.Writer
+	src     Source
+	started bool
+	items   int
+}
+
+func newPostmanWriter(w io.Writer, src Source) *postmanWriter {
+	return &postmanWriter{w: bufio.NewWriter(w), src: src}
+}
+
+func (p *postmanWriter) Write(wr *models.WebhookRequest) error {
+	if err := p.start(); err != nil {
+		return err
+	}
+	item, err := json.Marshal(postmanItemFor(wr))
+	if err != nil {
+		return err
+	}
+	if p.items > 0 {
+		p.w.WriteByte(',')
+	}
+	p.items++
+	_, err = p.w.Write(item)
+	return err
+}
+
+func (p *postmanWriter) Close() error {
+	if err := p.start(); err != nil {
+		return err
+	}
+	p.w.WriteString("]}\n")
+	return p.w.Flush()
+}
+
+func (p *postmanWriter) start() error {
+	if p.started {
+		return nil
+	}
+	p.started = true
+	head, err := json.Marshal(struct {
+		Info     postmanInfo       `json:"info"`
+		Variable []postmanKeyValue `json:"variable"`
+	}{
+		Info:     postmanInfo{Name: p.src.CollectionName(), Description: "Requests captured by webhook " + p.src.Webhook.ID, Schema: postmanSchema},
+		Variable: []postmanKeyValue{{Key: baseURLVariable, Value: p.src.WebhookURL(), Type: "string"}},
+	})
+	if err != nil {
+		return err
+	}
+	// reopen the object to append the item array
+	p.w.Write(head[:len(head)-1])
+	p.w.WriteString(`,"item":[`)
+	return nil
+}
+
+func postmanItemFor(wr *models.WebhookRequest) postmanItem {
+	req := postmanRequest{
+		Method: wr.Method,
+		Header: []postmanKeyValue{},
+		URL:    postmanURL{Raw: "{{" + baseURLVariable + "}}", Host: []string{"{{" + baseURLVariable + "}}"}},
+	}
+	for _, h := range requestHeaders(wr) {
+		req.Header = append(req.Header, postmanKeyValue{Key: h.Name, Value: h.Value})
+	}
+	if q := encodeQuery(wr.Query); q != "" {
+		req.URL.Raw += "?" + q
+		for _, nv := range nameValues(wr.Query) {
+			req.URL.Query = append(req.URL.Query, postmanKeyValue{Key: nv.Name, Value: nv.Value})
+		}
+	}
+	if wr.Body != "" {
+		body, note := collectionBody(wr.Body)
+		req.Body = &postmanBody{Mode: "raw", Raw: body}
+		if lang := rawLanguage(header(wr.Headers, "Content-Type")); lang != "" {
+			req.Body.Options = &postmanOptions{}
+			req.Body.Options.Raw.Language = lang
+		}
+		req.Description = note
+	}
+	return postmanItem{Name: itemName(wr), Request: req}
+}
+
+// collectionBody returns the body as collections store it. They only hold
+// text, so a binary body is base64-encoded and described by note.
+func collectionBody(body string) (text, note string) {
+	if snippet.Binary(body) {
+		return base64.StdEncoding.EncodeToString([]byte(body)),
+			"The captured body is binary and shown base64-encoded; decode it before sending."
+	}
+	return body, ""
+}
+
+// rawLanguage is the Postman editor language for a content type.
+func rawLanguage(contentType string) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		return "json"
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		return "xml"
+	case mt == "text/html":
+		return "html"
+	case mt == "application/javascript":
+		return "javascript"
+	case mt != "":
+		return "text"
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+// Package callback renders the requests a webhook sends back some time
+// after it receives one, the way asynchronous APIs acknowledge a call at once
+// and report the outcome later, often to a URL given in the original payload.
+//
+// Values of the received request are named by paths in the syntax of
+// package compare: "body" followed by object keys and array indexes, like
+// "body.data.callback_url" or "body.items[0].url", "headers.<Name>" or
+// "query.<name>".
+//
+// Bodies and header values are text/template templates. Besides the
+// placeholders of package events, like {{uuid}} and {{now}}, they can use
+// the received request:
+//
+//	{{.ID}} {{.Method}} {{.Body}}  the request, see Request
+//	{{field "body.order.id"}}      a value by path; objects and arrays as JSON
+//	{{json "body.order"}}          a value by path as JSON, null when absent
+package callback
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"webhook-tester/internal/events"
+)
+
+// Request is the received request a callback answers.
+type Request struct {
+	ID         string
+	Method     string
+	Headers    map[string]string
+	Query      map[string]string
+	Body       string
+	ReceivedAt time.Time
+}
+
+// segment is one step of a path: an object key or an array index.
+type segment struct {
+	key   string
+	index int // -1 for keys
+}
+
+// parsePath splits a path into its root ("body", "headers" or "query") and
+// the segments below it.
+func parsePath(path string) (string, []segment, error) {
+	root := path
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		root = path[:i]
+	}
+	rest := path[len(root):]
+	switch root {
+	case "body":
+	case "headers", "query":
+		name := strings.TrimPrefix(rest, ".")
+		if !strings.HasPrefix(rest, ".") || name == "" {
+			return "", nil, fmt.Errorf("%s must be followed by a name, like %s.name", root, root)
+		}
+		return root, []segment{{key: name, index: -1}}, nil
+	default:
+		return "", nil, errors.New(`must start with "body", "headers." or "query."`)
+	}
+
+	var segs []segment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := len(rest)
+			if i := strings.IndexAny(rest[1:], ".["); i >= 0 {
+				end = i + 1
+			}
+			if end == 1 {
+				return "", nil, errors.New("has an empty key")
+			}
+			segs, rest = append(segs, segment{key: rest[1:end], index: -1}), rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", nil, errors.New("has an unclosed [")
+			}
+			n, err := strconv.Atoi(rest[1:end])
+			if err != nil || n < 0 {
+				return "", nil, fmt.Errorf("%s is not an array index", rest[:end+1])
+			}
+			segs, rest = append(segs, segment{index: n}), rest[end+1:]
+		}
+	}
+	return root, segs, nil
+}
+
+// CheckPath reports whether path names a value of a request.
+func CheckPath(path string) error {
+	_, _, err := parsePath(path)
+	return err
+}
+
+// Lookup returns the value at path in r. Header names match in any case.
+// Body values are decoded from JSON, with numbers as json.Number.
+func Lookup(r Request, path string) (any, bool) {
+	root, segs, err := parsePath(path)
+	if err != nil {
+		return nil, false
+	}
+	switch root {
+	case "headers":
+		for name, v := range r.Headers {
+			if http.CanonicalHeaderKey(name) == http.CanonicalHeaderKey(segs[0].key) {
+				return v, true
+			}
+		}
+		return nil, false
+	case "query":
+		v, ok := r.Query[segs[0].key]
+		return v, ok
+	}
+
+	dec := json.NewDecoder(strings.NewReader(r.Body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	for _, s := range segs {
+		switch node := v.(type) {
+		case map[string]any:
+			if s.index >= 0 {
+				return nil, false
+			}
+			var ok bool
+			if v, ok = node[s.key]; !ok {
+				return nil, false
+			}
+		case []any:
+			if s.index < 0 || s.index >= len(node) {
+				return nil, false
+			}
+			v = node[s.index]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// Text shows a value found by Lookup as text: strings as they are, other
+// values as JSON.
+func Text(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Parse checks the syntax of a template.
+func Parse(text string) error {
+	_, err := parse(text, time.Now(), Request{})
+	return err
+}
+
+// Render fills in a template for r at now.
+func Render(text string, r Request, now time.Time) (string, error) {
+	tmpl, err := parse(text, now, r)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		return "", fmt.Errorf("callback: %w", err)
+	}
+	return b.String(), nil
+}
+
+func parse(text string, now time.Time, r Request) (*template.Template, error) {
+	funcs := events.Funcs(now)
+	funcs["field"] = func(path string) (string, error) {
+		if err := CheckPath(path); err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		v, ok := Lookup(r, path)
+		if !ok {
+			return "", nil
+		}
+		return Text(v), nil
+	}
+	funcs["json"] = func(path string) (string, error) {
+		if err := CheckPath(path); err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		v, _ := Lookup(r, path)
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+	tmpl, err := template.New("callback").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("callback: %w", err)
+	}
+	return tmpl, nil
+}
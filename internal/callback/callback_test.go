@@ -0,0 +1,71 @@
+package callback_test
+
+import (
+	"testing"
+	"time"
+	"webhook-tester/internal/callback"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var received = callback.Request{
+	ID:      "r1",
+	Method:  "POST",
+	Headers: map[string]string{"X-Callback-Url": "http://localhost:8080/status"},
+	Query:   map[string]string{"notify": "http://localhost:8080/q"},
+	Body:    `{"order":{"id":42,"callback_url":"http://localhost:8080/orders/42"},"items":[{"sku":"a"},{"sku":"b"}],"note":"paid"}`,
+}
+
+func TestLookup(t *testing.T) {
+	for path, want := range map[string]any{
+		"body.order.callback_url": "http://localhost:8080/orders/42",
+		"body.items[1].sku":       "b",
+		"body.note":               "paid",
+		"headers.x-callback-url":  "http://localhost:8080/status",
+		"query.notify":            "http://localhost:8080/q",
+	} {
+		v, ok := callback.Lookup(received, path)
+		assert.True(t, ok, path)
+		assert.Equal(t, want, v, path)
+	}
+	v, ok := callback.Lookup(received, "body.order.id")
+	require.True(t, ok)
+	assert.Equal(t, "42", callback.Text(v))
+	v, ok = callback.Lookup(received, "body.items[0]")
+	require.True(t, ok)
+	assert.Equal(t, `{"sku":"a"}`, callback.Text(v))
+
+	for _, path := range []string{"body.missing", "body.items[2]", "body.order[0]", "body.note.x", "headers.X-Missing", "query.other"} {
+		_, ok := callback.Lookup(received, path)
+		assert.False(t, ok, path)
+	}
+	_, ok = callback.Lookup(callback.Request{Body: "not json"}, "body.id")
+	assert.False(t, ok)
+}
+
+func TestCheckPath(t *testing.T) {
+	for _, path := range []string{"body", "body.a", "body.a[0].b", "body[1]", "headers.X-Id", "query.a.b"} {
+		assert.NoError(t, callback.CheckPath(path), path)
+	}
+	for _, path := range []string{"", "url", "headers", "query.", "body..a", "body.a[x]", "body.a[0", "body.a[-1]"} {
+		assert.Error(t, callback.CheckPath(path), path)
+	}
+}
+
+func TestRender(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	out, err := callback.Render(`{"request":"{{.ID}}","order":{{json "body.order.id"}},"sku":"{{field "body.items[0].sku"}}","missing":{{json "body.nope"}},"at":{{unix}}}`, received, now)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"request":"r1","order":42,"sku":"a","missing":null,"at":1743508800}`, out)
+
+	out, err = callback.Render(`{{field "body.missing"}}|{{.Method}}`, received, now)
+	require.NoError(t, err)
+	assert.Equal(t, "|POST", out)
+
+	_, err = callback.Render(`{{field "url"}}`, received, now)
+	assert.Error(t, err)
+	assert.Error(t, callback.Parse(`{{field "body"`))
+	assert.Error(t, callback.Parse(`{{nope}}`))
+	assert.NoError(t, callback.Parse(`{{uuid}} {{json "body.id"}}`))
+}
@@ -0,0 +1,276 @@
+// Package chaos injects faults into the responses of a webhook, to test how
+// senders cope with a failing receiver: error statuses at a rate or on every
+// N-th request, random delays, 429s past a rate limit, truncated or slowly
+// dripped bodies and connections closed without a response.
+//
+// An Injector decides the faults of each request from the webhook's Config,
+// and the Decision then writes the response. A request gets at most one
+// fault, besides a delay: the rate limit is checked first, then disconnect,
+// every N-th, the error rate, truncate and drip.
+package chaos
+
+import (
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Faults, as recorded on the requests they were applied to.
+const (
+	FaultRateLimit  = "rate_limit" // answered 429 with Retry-After
+	FaultDisconnect = "disconnect" // connection closed without a response
+	FaultEveryNth   = "every_nth"  // answered with the error status as the N-th request
+	FaultError      = "error"      // answered with the error status at the error rate
+	FaultTruncate   = "truncate"   // body cut off halfway
+	FaultDrip       = "drip"       // body sent one byte at a time
+)
+
+// Delay distributions.
+const (
+	DelayUniform     = "uniform"     // any delay in the range is as likely
+	DelayNormal      = "normal"      // around the middle of the range
+	DelayExponential = "exponential" // mostly short, now and then long
+)
+
+const (
+	// MaxDelay caps the random delay of a request.
+	MaxDelay = 30 * time.Second
+	// MaxDrip is how long a body drips at most; the rest is sent at once.
+	MaxDrip = time.Minute
+	// DefaultErrorStatus is the status of failed requests when Config has none.
+	DefaultErrorStatus = http.StatusInternalServerError
+	// DefaultRateLimitWindow is the rate limit window when Config has none.
+	DefaultRateLimitWindow = time.Minute
+	// DefaultDripInterval is the wait between dripped bytes when Config has none.
+	DefaultDripInterval = 100 * time.Millisecond
+)
+
+// Config is the fault injection of a webhook. The zero Config injects no
+// faults. Percentages are between 0 and 100.
+type Config struct {
+	ErrorPercent      float64 `json:"error_percent,omitempty"`
+	ErrorStatus       int     `json:"error_status,omitempty"` // default 500
+	FailEvery         int     `json:"fail_every,omitempty"`   // every N-th request gets the error status
+	DelayMinMs        int     `json:"delay_min_ms,omitempty"`
+	DelayMaxMs        int     `json:"delay_max_ms,omitempty"`
+	DelayDistribution string  `json:"delay_distribution,omitempty"` // uniform (default), normal or exponential
+	RateLimit         int     `json:"rate_limit,omitempty"`           // requests answered per window; later ones get 429
+	RateLimitWindowMs int     `json:"rate_limit_window_ms,omitempty"` // default 60000
+	RetryAfter        int     `json:"retry_after,omitempty"`          // seconds; default until the window ends
+	TruncatePercent   float64 `json:"truncate_percent,omitempty"`
+	DripPercent       float64 `json:"drip_percent,omitempty"`
+	DripIntervalMs    int     `json:"drip_interval_ms,omitempty"` // between bytes, default 100
+	DisconnectPercent float64 `json:"disconnect_percent,omitempty"`
+}
+
+// Enabled reports whether c injects any fault.
+func (c Config) Enabled() bool {
+	return c != Config{}
+}
+
+func (c Config) errorStatus() int {
+	if c.ErrorStatus == 0 {
+		return DefaultErrorStatus
+	}
+	return c.ErrorStatus
+}
+
+func (c Config) window() time.Duration {
+	if c.RateLimitWindowMs == 0 {
+		return DefaultRateLimitWindow
+	}
+	return time.Duration(c.RateLimitWindowMs) * time.Millisecond
+}
+
+func (c Config) dripInterval() time.Duration {
+	if c.DripIntervalMs == 0 {
+		return DefaultDripInterval
+	}
+	return time.Duration(c.DripIntervalMs) * time.Millisecond
+}
+
+// Decision is what happens to the response to one request.
+type Decision struct {
+	Fault      string        // one of the Fault constants, empty for none
+	Delay      time.Duration // before responding, on top of the webhook's own
+	Status     int           // replaces the webhook's status when set
+	RetryAfter int           // seconds, sent in Retry-After when set
+	Disconnect bool
+	Truncate   bool
+	Drip       time.Duration // between body bytes when set
+}
+
+// Injector decides the faults of requests. It counts the requests of each
+// webhook for FailEvery and RateLimit; changing a webhook's Config starts
+// counting afresh. Counts live in memory and restart with the process.
+type Injector struct {
+	mu     sync.Mutex
+	rand   *rand.Rand
+	counts map[string]*count // by webhook ID
+}
+
+type count struct {
+	config      Config
+	seen        int
+	windowStart time.Time
+	inWindow    int
+}
+
+// NewInjector returns an Injector drawing from r, or from a random source
+// when r is nil.
+func NewInjector(r *rand.Rand) *Injector {
+	if r == nil {
+		r = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return &Injector{rand: r, counts: map[string]*count{}}
+}
+
+// Decide decides the faults of a request webhookID received at now.
+func (in *Injector) Decide(webhookID string, c Config, now time.Time) Decision {
+	if !c.Enabled() {
+		return Decision{}
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	n := in.counts[webhookID]
+	if n == nil || n.config != c {
+		n = &count{config: c}
+		in.counts[webhookID] = n
+	}
+	n.seen++
+
+	d := Decision{Delay: in.delay(c)}
+	if c.RateLimit > 0 {
+		window := c.window()
+		if n.windowStart.IsZero() || now.Sub(n.windowStart) >= window {
+			n.windowStart, n.inWindow = now, 0
+		}
+		if n.inWindow >= c.RateLimit {
+			d.Fault, d.Status, d.RetryAfter = FaultRateLimit, http.StatusTooManyRequests, c.RetryAfter
+			if d.RetryAfter == 0 {
+				d.RetryAfter = max(int(math.Ceil(n.windowStart.Add(window).Sub(now).Seconds())), 1)
+			}
+			return d
+		}
+		n.inWindow++
+	}
+	switch {
+	case in.chance(c.DisconnectPercent):
+		d.Fault, d.Disconnect = FaultDisconnect, true
+	case c.FailEvery > 0 && n.seen%c.FailEvery == 0:
+		d.Fault, d.Status = FaultEveryNth, c.errorStatus()
+	case in.chance(c.ErrorPercent):
+		d.Fault, d.Status = FaultError, c.errorStatus()
+	case in.chance(c.TruncatePercent):
+		d.Fault, d.Truncate = FaultTruncate, true
+	case in.chance(c.DripPercent):
+		d.Fault, d.Drip = FaultDrip, c.dripInterval()
+	}
+	return d
+}
+
+// chance reports true percent times out of 100.
+func (in *Injector) chance(percent float64) bool {
+	return percent > 0 && in.rand.Float64()*100 < percent
+}
+
+// delay draws a delay between DelayMinMs and DelayMaxMs.
+func (in *Injector) delay(c Config) time.Duration {
+	if c.DelayMaxMs <= 0 {
+		return 0
+	}
+	lo, hi := float64(c.DelayMinMs), float64(c.DelayMaxMs)
+	var ms float64
+	switch c.DelayDistribution {
+	case DelayNormal:
+		// nearly all draws fall within three standard deviations
+		ms = (lo+hi)/2 + in.rand.NormFloat64()*(hi-lo)/6
+	case DelayExponential:
+		ms = lo + in.rand.ExpFloat64()*(hi-lo)/4
+	default:
+		ms = lo + in.rand.Float64()*(hi-lo)
+	}
+	ms = min(max(ms, lo), hi)
+	return time.Duration(ms * float64(time.Millisecond)).Round(time.Millisecond)
+}
+
+// Respond writes the response to r as d says: status and body as given, the
+// status replaced, the body cut off or dripped, or no response at all. The
+// headers in w are sent as they are, besides Retry-After and Content-Length.
+func (d Decision) Respond(w http.ResponseWriter, r *http.Request, status int, body []byte) error {
+	if d.Disconnect {
+		return disconnect(w)
+	}
+	if d.Status != 0 {
+		status = d.Status
+	}
+	if d.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(d.RetryAfter))
+	}
+	switch {
+	case d.Truncate:
+		// declaring more than is sent makes the server close the connection
+		// at the end of the handler, so the client sees the body end early
+		w.Header().Set("Content-Length", strconv.Itoa(max(len(body), 1)))
+		w.WriteHeader(status)
+		_, err := w.Write(body[:len(body)/2])
+		return err
+	case d.Drip > 0:
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(status)
+		return drip(w, r, body, d.Drip)
+	default:
+		w.WriteHeader(status)
+		_, err := w.Write(body)
+		return err
+	}
+}
+
+// drip writes body one byte every interval, and the rest at once after MaxDrip.
+func drip(w http.ResponseWriter, r *http.Request, body []byte, interval time.Duration) error {
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(MaxDrip)
+	for i := range body {
+		if i > 0 {
+			t := time.NewTimer(interval)
+			select {
+			case <-r.Context().Done():
+				t.Stop()
+				return r.Context().Err()
+			case <-t.C:
+			}
+		}
+		if time.Now().After(deadline) {
+			_, err := w.Write(body[i:])
+			return err
+		}
+		if _, err := w.Write(body[i : i+1]); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disconnect closes the connection of w without a response.
+func disconnect(w http.ResponseWriter) error {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		// HTTP/2 streams can't be hijacked; aborting the handler resets the stream
+		panic(http.ErrAbortHandler)
+	}
+	if tc, ok := conn.(*net.TCPConn); ok {
+		// reset rather than close, like a receiver that crashed
+		_ = tc.SetLinger(0)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,149 @@
+package chaos_test
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"webhook-tester/internal/chaos"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInjector() *chaos.Injector {
+	return chaos.NewInjector(rand.New(rand.NewPCG(1, 2)))
+}
+
+func TestNoFaults(t *testing.T) {
+	in := newInjector()
+	for range 10 {
+		assert.Equal(t, chaos.Decision{}, in.Decide("w", chaos.Config{}, time.Now()))
+	}
+}
+
+func TestFailEvery(t *testing.T) {
+	in := newInjector()
+	c := chaos.Config{FailEvery: 3, ErrorStatus: 503}
+	var faults []string
+	for range 7 {
+		d := in.Decide("w", c, time.Now())
+		faults = append(faults, d.Fault)
+		if d.Fault != "" {
+			assert.Equal(t, 503, d.Status)
+		}
+	}
+	assert.Equal(t, []string{"", "", "every_nth", "", "", "every_nth", ""}, faults)
+
+	// another webhook counts on its own, and a new config counts afresh
+	assert.Empty(t, in.Decide("other", c, time.Now()).Fault)
+	c.FailEvery = 2
+	assert.Empty(t, in.Decide("w", c, time.Now()).Fault)
+	assert.Equal(t, chaos.FaultEveryNth, in.Decide("w", c, time.Now()).Fault)
+}
+
+func TestRateLimit(t *testing.T) {
+	in := newInjector()
+	c := chaos.Config{RateLimit: 2, RateLimitWindowMs: 10_000}
+	start := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	assert.Empty(t, in.Decide("w", c, start).Fault)
+	assert.Empty(t, in.Decide("w", c, start.Add(time.Second)).Fault)
+	d := in.Decide("w", c, start.Add(2500*time.Millisecond))
+	assert.Equal(t, chaos.FaultRateLimit, d.Fault)
+	assert.Equal(t, http.StatusTooManyRequests, d.Status)
+	assert.Equal(t, 8, d.RetryAfter, "until the window ends")
+	assert.Empty(t, in.Decide("w", c, start.Add(10*time.Second)).Fault, "a new window")
+
+	c.RetryAfter = 30
+	in.Decide("w", c, start)
+	in.Decide("w", c, start)
+	assert.Equal(t, 30, in.Decide("w", c, start).RetryAfter)
+}
+
+func TestPercentages(t *testing.T) {
+	in := newInjector()
+	for _, tc := range []struct {
+		config chaos.Config
+		fault  string
+	}{
+		{chaos.Config{ErrorPercent: 100}, chaos.FaultError},
+		{chaos.Config{TruncatePercent: 100}, chaos.FaultTruncate},
+		{chaos.Config{DripPercent: 100}, chaos.FaultDrip},
+		{chaos.Config{DisconnectPercent: 100}, chaos.FaultDisconnect},
+	} {
+		d := in.Decide("w", tc.config, time.Now())
+		assert.Equal(t, tc.fault, d.Fault)
+	}
+	assert.Equal(t, chaos.DefaultErrorStatus, in.Decide("w", chaos.Config{ErrorPercent: 100}, time.Now()).Status)
+	assert.Equal(t, chaos.DefaultDripInterval, in.Decide("w", chaos.Config{DripPercent: 100}, time.Now()).Drip)
+
+	failed := 0
+	for range 1000 {
+		if in.Decide("w", chaos.Config{ErrorPercent: 25}, time.Now()).Fault != "" {
+			failed++
+		}
+	}
+	assert.InDelta(t, 250, failed, 50)
+}
+
+func TestDelay(t *testing.T) {
+	in := newInjector()
+	for _, dist := range []string{"", chaos.DelayUniform, chaos.DelayNormal, chaos.DelayExponential} {
+		c := chaos.Config{DelayMinMs: 100, DelayMaxMs: 300, DelayDistribution: dist}
+		var sum time.Duration
+		for range 500 {
+			d := in.Decide("w", c, time.Now())
+			assert.Empty(t, d.Fault)
+			require.GreaterOrEqual(t, d.Delay, 100*time.Millisecond, dist)
+			require.LessOrEqual(t, d.Delay, 300*time.Millisecond, dist)
+			sum += d.Delay
+		}
+		mean := sum / 500
+		if dist == chaos.DelayExponential {
+			assert.Less(t, mean, 200*time.Millisecond, "mostly short")
+		} else {
+			assert.InDelta(t, 200, mean.Milliseconds(), 20, dist)
+		}
+	}
+}
+
+// serve answers every request with d.
+func serve(t *testing.T, d chaos.Decision) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = d.Respond(w, r, http.StatusOK, []byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRespond(t *testing.T) {
+	resp, err := http.Get(serve(t, chaos.Decision{Status: 429, RetryAfter: 5}).URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, "5", resp.Header.Get("Retry-After"))
+	assert.Equal(t, `{"ok":true}`, string(body))
+
+	resp, err = http.Get(serve(t, chaos.Decision{Truncate: true}).URL)
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, `{"ok"`, string(body), "the first half")
+
+	start := time.Now()
+	resp, err = http.Get(serve(t, chaos.Decision{Drip: 5 * time.Millisecond}).URL)
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok":true}`, string(body))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	_, err = http.Get(serve(t, chaos.Decision{Disconnect: true}).URL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,332 @@
+// Package compare diffs two HTTP responses to the same request: status,
+// headers and body, structurally when both bodies are JSON.
+//
+// Every difference has a path: "status", "headers.<Name>", "body" for bodies
+// that aren't both JSON, or "body" followed by object keys and array indexes,
+// like "body.items[0].id". Ignore patterns use the same syntax, where "*"
+// matches one key or index and "**" any number of them:
+//
+//	headers.X-Request-Id
+//	body.created_at
+//	body.items[*].id
+//	body.**.updated_at
+//
+// A pattern that matches a path also ignores everything below it.
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Changes a Difference can describe.
+const (
+	Changed = "changed"
+	Added   = "added"   // only the candidate has it
+	Removed = "removed" // only the baseline has it
+)
+
+// DefaultIgnore holds the paths that differ between any two responses.
+var DefaultIgnore = []string{"headers.Date", "headers.Content-Length"}
+
+// maxValueLength caps the length of non-JSON bodies kept in a Difference.
+const maxValueLength = 1024
+
+// Response is one side of a comparison.
+type Response struct {
+	StatusCode int
+	Header     map[string]string
+	Body       string
+}
+
+// Difference is one place where the responses disagree. Baseline and
+// Candidate are absent on the side that lacks the value.
+type Difference struct {
+	Path      string `json:"path"`
+	Change    string `json:"change"`
+	Baseline  any    `json:"baseline,omitempty"`
+	Candidate any    `json:"candidate,omitempty"`
+}
+
+// Diff compares candidate with baseline and returns their differences in
+// path order, leaving out the paths matched by ignore.
+func Diff(baseline, candidate Response, ignore []string) []Difference {
+	d := differ{ignore: compile(ignore)}
+	if baseline.StatusCode != candidate.StatusCode && !d.ignored([]string{"status"}) {
+		d.add("status", baseline.StatusCode, candidate.StatusCode)
+	}
+	d.headers(canonical(baseline.Header), canonical(candidate.Header))
+
+	var a, b any
+	if json.Unmarshal([]byte(baseline.Body), &a) == nil && json.Unmarshal([]byte(candidate.Body), &b) == nil {
+		d.value([]string{"body"}, a, b)
+	} else if baseline.Body != candidate.Body && !d.ignored([]string{"body"}) {
+		d.add("body", truncate(baseline.Body), truncate(candidate.Body))
+	}
+	return d.diffs
+}
+
+// PathCount is how many comparisons differ at a path.
+type PathCount struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+}
+
+// Summarize counts the comparisons that differ at each path, most frequent
+// first. Array indexes become "[*]", so every path is also an ignore pattern.
+func Summarize(results [][]Difference) []PathCount {
+	counts := map[string]int{}
+	for _, diffs := range results {
+		seen := map[string]bool{}
+		for _, d := range diffs {
+			segs := split(d.Path)
+			for i, s := range segs {
+				if strings.HasPrefix(s, "[") {
+					segs[i] = "[*]"
+				}
+			}
+			if p := join(segs); !seen[p] {
+				seen[p] = true
+				counts[p]++
+			}
+		}
+	}
+	list := make([]PathCount, 0, len(counts))
+	for p, n := range counts {
+		list = append(list, PathCount{Path: p, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Path < list[j].Path
+	})
+	return list
+}
+
+// ValidPattern reports why an ignore pattern can't match anything, or nil.
+func ValidPattern(pattern string) error {
+	segs := split(pattern)
+	if len(segs) == 0 {
+		return fmt.Errorf("empty pattern")
+	}
+	for _, s := range segs {
+		if s == "" {
+			return fmt.Errorf("%q has an empty key", pattern)
+		}
+	}
+	switch segs[0] {
+	case "status", "headers", "body", "*", "**":
+		return nil
+	}
+	return fmt.Errorf(`%q must start with "status", "headers" or "body"`, pattern)
+}
+
+type differ struct {
+	ignore [][]string
+	diffs  []Difference
+}
+
+func (d *differ) add(path string, baseline, candidate any) {
+	diff := Difference{Path: path, Change: Changed, Baseline: baseline, Candidate: candidate}
+	switch {
+	case baseline == nil:
+		diff.Change = Added
+	case candidate == nil:
+		diff.Change = Removed
+	}
+	d.diffs = append(d.diffs, diff)
+}
+
+func (d *differ) ignored(path []string) bool {
+	for _, p := range d.ignore {
+		if match(p, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *differ) headers(a, b map[string]string) {
+	for _, name := range keys(a, b) {
+		if d.ignored([]string{"headers", name}) {
+			continue
+		}
+		va, inA := a[name]
+		vb, inB := b[name]
+		switch {
+		case !inA:
+			d.add("headers."+name, nil, vb)
+		case !inB:
+			d.add("headers."+name, va, nil)
+		case va != vb:
+			d.add("headers."+name, va, vb)
+		}
+	}
+}
+
+// value compares two decoded JSON values at path.
+func (d *differ) value(path []string, a, b any) {
+	if d.ignored(path) {
+		return
+	}
+	switch a := a.(type) {
+	case map[string]any:
+		if b, ok := b.(map[string]any); ok {
+			for _, k := range keys(a, b) {
+				va, inA := a[k]
+				vb, inB := b[k]
+				p := append(path[:len(path):len(path)], k)
+				switch {
+				case !inA:
+					if !d.ignored(p) {
+						d.add(join(p), nil, orNull(vb))
+					}
+				case !inB:
+					if !d.ignored(p) {
+						d.add(join(p), orNull(va), nil)
+					}
+				default:
+					d.value(p, va, vb)
+				}
+			}
+			return
+		}
+	case []any:
+		if b, ok := b.([]any); ok {
+			for i := 0; i < max(len(a), len(b)); i++ {
+				p := append(path[:len(path):len(path)], "["+strconv.Itoa(i)+"]")
+				switch {
+				case i >= len(a):
+					if !d.ignored(p) {
+						d.add(join(p), nil, orNull(b[i]))
+					}
+				case i >= len(b):
+					if !d.ignored(p) {
+						d.add(join(p), orNull(a[i]), nil)
+					}
+				default:
+					d.value(p, a[i], b[i])
+				}
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		d.add(join(path), orNull(a), orNull(b))
+	}
+}
+
+// orNull keeps a JSON null distinguishable from a missing value.
+func orNull(v any) any {
+	if v == nil {
+		return json.RawMessage("null")
+	}
+	return v
+}
+
+func keys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var list []string
+	for _, m := range []map[string]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				list = append(list, k)
+			}
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+func canonical(h map[string]string) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[http.CanonicalHeaderKey(k)] = v
+	}
+	return out
+}
+
+func truncate(s string) string {
+	if len(s) <= maxValueLength {
+		return s
+	}
+	cut := maxValueLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
+
+// split turns a path or pattern into segments; indexes keep their brackets.
+func split(path string) []string {
+	var segs []string
+	for _, part := range strings.Split(path, ".") {
+		i := strings.IndexByte(part, '[')
+		if i < 0 {
+			segs = append(segs, part)
+			continue
+		}
+		if i > 0 {
+			segs = append(segs, part[:i])
+		}
+		for _, idx := range strings.SplitAfter(part[i:], "]") {
+			if idx != "" {
+				segs = append(segs, idx)
+			}
+		}
+	}
+	return segs
+}
+
+func join(segs []string) string {
+	var b strings.Builder
+	for i, s := range segs {
+		if i > 0 && !strings.HasPrefix(s, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// compile splits the patterns, matching header names in any case.
+func compile(patterns []string) [][]string {
+	out := make([][]string, 0, len(patterns))
+	for _, p := range patterns {
+		segs := split(p)
+		if len(segs) > 1 && segs[0] == "headers" && segs[1] != "*" && segs[1] != "**" {
+			segs[1] = http.CanonicalHeaderKey(segs[1])
+		}
+		out = append(out, segs)
+	}
+	return out
+}
+
+// match reports whether pattern matches path or one of its ancestors.
+func match(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if match(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	seg := pattern[0]
+	ok := seg == path[0] || seg == "*" || (seg == "[*]" && strings.HasPrefix(path[0], "["))
+	return ok && match(pattern[1:], path[1:])
+}
@@ -0,0 +1,90 @@
+package compare_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"webhook-tester/internal/compare"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func paths(diffs []compare.Difference) []string {
+	out := make([]string, len(diffs))
+	for i, d := range diffs {
+		out[i] = d.Path + " " + d.Change
+	}
+	return out
+}
+
+func TestDiffJSON(t *testing.T) {
+	a := compare.Response{StatusCode: 200, Body: `{"id":"a1","total":10,"items":[{"id":1,"sku":"x"},{"id":2}],"meta":{"at":"t1"},"gone":null}`}
+	b := compare.Response{StatusCode: 201, Body: `{"id":"b2","total":10.0,"items":[{"id":1,"sku":"y"}],"meta":{"at":"t2"},"new":true}`}
+
+	diffs := compare.Diff(a, b, nil)
+	assert.Equal(t, []string{
+		"status changed",
+		"body.gone removed",
+		"body.id changed",
+		"body.items[0].sku changed",
+		"body.items[1] removed",
+		"body.meta.at changed",
+		"body.new added",
+	}, paths(diffs), "numbers compare by value")
+	assert.Equal(t, 200, diffs[0].Baseline)
+	assert.Equal(t, "x", diffs[3].Baseline)
+	assert.Nil(t, diffs[6].Baseline)
+
+	raw, _ := json.Marshal(diffs[1])
+	assert.JSONEq(t, `{"path":"body.gone","change":"removed","baseline":null}`, string(raw), "a null value is kept")
+
+	diffs = compare.Diff(a, b, []string{"status", "body.id", "body.items[*].sku", "body.**.at", "body.items[1]"})
+	assert.Equal(t, []string{"body.gone removed", "body.new added"}, paths(diffs))
+	assert.Empty(t, compare.Diff(a, b, []string{"status", "body"}), "ignoring a path ignores what's below it")
+}
+
+func TestDiffHeaders(t *testing.T) {
+	a := compare.Response{StatusCode: 200, Header: map[string]string{"date": "Mon", "X-Version": "1", "X-Old": "y"}}
+	b := compare.Response{StatusCode: 200, Header: map[string]string{"Date": "Tue", "x-version": "2", "X-Request-Id": "r"}}
+
+	assert.Equal(t, []string{"headers.X-Old removed", "headers.X-Request-Id added", "headers.X-Version changed"},
+		paths(compare.Diff(a, b, compare.DefaultIgnore)))
+	assert.Equal(t, []string{"headers.X-Version changed"},
+		paths(compare.Diff(a, b, append(compare.DefaultIgnore, "headers.x-old", "headers.x-request-id"))), "header names match in any case")
+}
+
+func TestDiffText(t *testing.T) {
+	long := strings.Repeat("é", 1000)
+	diffs := compare.Diff(compare.Response{Body: "ok"}, compare.Response{Body: long}, nil)
+	assert.Equal(t, []string{"body changed"}, paths(diffs))
+	assert.True(t, strings.HasSuffix(diffs[0].Candidate.(string), "…"), "long bodies are cut")
+	assert.LessOrEqual(t, len(diffs[0].Candidate.(string)), 1024+len("…"))
+
+	assert.Empty(t, compare.Diff(compare.Response{Body: "same"}, compare.Response{Body: "same"}, nil))
+	assert.Equal(t, []string{"body changed"}, paths(compare.Diff(compare.Response{Body: `{}`}, compare.Response{Body: "{"}, nil)),
+		"bodies that aren't both JSON compare as text")
+}
+
+func TestSummarize(t *testing.T) {
+	results := [][]compare.Difference{
+		{{Path: "body.items[0].sku"}, {Path: "body.items[3].sku"}, {Path: "status"}},
+		{{Path: "body.items[1].sku"}},
+		nil,
+		{{Path: "headers.X-Version"}},
+	}
+	assert.Equal(t, []compare.PathCount{
+		{Path: "body.items[*].sku", Count: 2},
+		{Path: "headers.X-Version", Count: 1},
+		{Path: "status", Count: 1},
+	}, compare.Summarize(results), "a path counts once per comparison")
+	assert.Empty(t, compare.Summarize(nil))
+}
+
+func TestValidPattern(t *testing.T) {
+	for _, p := range []string{"status", "headers.Date", "body.items[*].id", "body.**.at", "**.id"} {
+		assert.NoError(t, compare.ValidPattern(p), p)
+	}
+	for _, p := range []string{"", "body..id", "payload.id"} {
+		assert.Error(t, compare.ValidPattern(p), p)
+	}
+}
@@ -4,13 +4,55 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"webhook-tester/internal/models"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	// DriverMemory keeps everything in process memory; nothing survives a restart.
+	DriverMemory = "memory"
+)
+
+// Driver returns the configured database driver. DB_DRIVER wins; otherwise
+// Postgres is used when its connection variables are set and SQLite when not.
+func Driver() string {
+	if d := os.Getenv("DB_DRIVER"); d != "" {
+		return d
+	}
+	if os.Getenv("POSTGRES_DB") != "" && os.Getenv("DB_HOST") != "" {
+		return DriverPostgres
+	}
+	return DriverSQLite
+}
+
+// Open connects to the database selected by Driver.
+func Open() (*gorm.DB, error) {
+	switch driver := Driver(); driver {
+	case DriverPostgres:
+		return openPostgres()
+	case DriverSQLite:
+		return OpenSQLite(os.Getenv("SQLITE_PATH"))
+	case DriverMemory:
+		return nil, fmt.Errorf("the %s driver has no database to open", driver)
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q", driver)
+	}
+}
+
+// Connect is Open for callers that cannot continue without a database.
 func Connect() *gorm.DB {
+	db, err := Open()
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	return db
+}
+
+func openPostgres() (*gorm.DB, error) {
 	user := os.Getenv("POSTGRES_USER")
 	pass := os.Getenv("POSTGRES_PASSWORD")
 	name := os.Getenv("POSTGRES_DB")
@@ -22,21 +64,48 @@ func Connect() *gorm.DB {
 	}
 
 	if user == "" || name == "" || host == "" {
-		log.Fatal("Database credentials are not fully set in environment variables")
+		return nil, fmt.Errorf("POSTGRES_USER, POSTGRES_DB and DB_HOST must be set for the postgres driver")
 	}
 
 	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", user, pass, host, port, name)
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+// OpenSQLite opens (creating if needed) the database file at path using the
+// pure-Go driver, so the binary keeps building with CGO_ENABLED=0.
+func OpenSQLite(path string) (*gorm.DB, error) {
+	if path == "" {
+		path = "webhook-tester.db"
 	}
-	return db
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	return gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 }
 
-func AutoMigrate(db *gorm.DB) {
-	err := db.AutoMigrate(&models.Webhook{}, &models.WebhookRequest{}, &models.User{})
+// Migrate brings the schema up to date on boot. With AUTO_MIGRATE=false it
+// only verifies the schema and refuses to start if migrations are pending.
+// A dirty schema always refuses to start.
+func Migrate(db *gorm.DB, l *log.Logger) {
+	m, err := NewMigrator(db, l)
 	if err != nil {
-		log.Fatalf("failed to auto-migrate: %v", err)
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+
+	if os.Getenv("AUTO_MIGRATE") == "false" {
+		pending, err := m.Pending()
+		if err != nil {
+			log.Fatalf("refusing to start: %v", err)
+		}
+		if pending > 0 {
+			log.Fatalf("refusing to start: %d pending migrations, run `webhook-tester migrate up`", pending)
+		}
+		return
+	}
+
+	applied, err := m.Up()
+	if err != nil {
+		log.Fatalf("refusing to start: %v", err)
+	}
+	if applied > 0 {
+		l.Printf("applied %d migrations", applied)
 	}
 }
@@ -0,0 +1,456 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"webhook-tester/db/migrations"
+
+	"gorm.io/gorm"
+)
+
+// ErrDirtySchema is returned when a previous migration failed part-way.
+var ErrDirtySchema = errors.New("database schema is dirty")
+
+// migrationLockID is the Postgres advisory lock key held while migrating.
+const migrationLockID = 727_001_337
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus describes whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	Dirty     bool
+}
+
+// schemaMigration is a row of the schema_migrations bookkeeping table.
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies the embedded SQL migrations for the connection's dialect.
+type Migrator struct {
+	db         *gorm.DB
+	logger     *log.Logger
+	migrations []Migration
+}
+
+// NewMigrator loads the migrations matching the dialect of db.
+func NewMigrator(db *gorm.DB, l *log.Logger) (*Migrator, error) {
+	list, err := loadMigrations(migrations.FS, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, logger: l, migrations: list}, nil
+}
+
+func loadMigrations(fsys fs.FS, dialect string) ([]Migration, error) {
+	files, err := fs.Glob(fsys, dialect+"/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, file := range files {
+		base := path.Base(file)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql suffix", file)
+		}
+		stem := strings.TrimSuffix(base, "."+direction+".sql")
+		rawVersion, name, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", file)
+		}
+		version, err := strconv.ParseInt(rawVersion, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", file, err)
+		}
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: both up and down files are required", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Up applies all pending migrations and returns how many were applied.
+func (m *Migrator) Up() (int, error) {
+	applied := 0
+	err := m.withLock(func(tx *gorm.DB) error {
+		rows, err := m.verify(tx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := rows[mig.Version]; ok {
+				continue
+			}
+			m.logger.Printf("applying migration %d_%s", mig.Version, mig.Name)
+			if err := m.apply(tx, mig); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the most recent steps migrations.
+func (m *Migrator) Down(steps int) (int, error) {
+	reverted := 0
+	err := m.withLock(func(tx *gorm.DB) error {
+		rows, err := m.verify(tx)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := rows[mig.Version]; !ok {
+				continue
+			}
+			m.logger.Printf("reverting migration %d_%s", mig.Version, mig.Name)
+			if err := m.revert(tx, mig); err != nil {
+				return err
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force marks version as the last cleanly applied migration without running
+// any SQL. It is the escape hatch after fixing a dirty schema by hand.
+func (m *Migrator) Force(version int64) error {
+	return m.withLock(func(tx *gorm.DB) error {
+		if _, err := m.appliedRows(tx); err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				if err := tx.Delete(&schemaMigration{}, "version = ?", mig.Version).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			row := schemaMigration{
+				Version:   mig.Version,
+				Name:      mig.Name,
+				Checksum:  mig.Checksum,
+				AppliedAt: time.Now().UTC(),
+			}
+			if err := tx.Save(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var list []MigrationStatus
+	err := m.withLock(func(tx *gorm.DB) error {
+		rows, err := m.appliedRows(tx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			st := MigrationStatus{Migration: mig}
+			if row, ok := rows[mig.Version]; ok {
+				appliedAt := row.AppliedAt
+				st.Applied = true
+				st.AppliedAt = &appliedAt
+				st.Dirty = row.Dirty
+			}
+			list = append(list, st)
+		}
+		return nil
+	})
+	return list, err
+}
+
+// Pending returns the number of migrations that have not been applied yet.
+// It fails with ErrDirtySchema or a checksum error if the schema cannot be trusted.
+func (m *Migrator) Pending() (int, error) {
+	pending := 0
+	err := m.withLock(func(tx *gorm.DB) error {
+		rows, err := m.verify(tx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := rows[mig.Version]; !ok {
+				pending++
+			}
+		}
+		return nil
+	})
+	return pending, err
+}
+
+// verify checks that the schema is clean and that applied migrations still
+// match their embedded checksums.
+func (m *Migrator) verify(tx *gorm.DB) (map[int64]schemaMigration, error) {
+	rows, err := m.appliedRows(tx)
+	if err != nil {
+		return nil, err
+	}
+	known := map[int64]Migration{}
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	for version, row := range rows {
+		if row.Dirty {
+			return nil, fmt.Errorf("%w: migration %d_%s did not complete; fix the schema and run `migrate force`", ErrDirtySchema, row.Version, row.Name)
+		}
+		mig, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("database has migration %d_%s that this binary does not know about", row.Version, row.Name)
+		}
+		if mig.Checksum != row.Checksum {
+			return nil, fmt.Errorf("checksum mismatch for migration %d_%s: it was edited after being applied", mig.Version, mig.Name)
+		}
+	}
+	return rows, nil
+}
+
+func (m *Migrator) appliedRows(tx *gorm.DB) (map[int64]schemaMigration, error) {
+	if !tx.Migrator().HasTable(&schemaMigration{}) {
+		if err := tx.Migrator().CreateTable(&schemaMigration{}); err != nil {
+			return nil, fmt.Errorf("creating schema_migrations: %w", err)
+		}
+	}
+	var list []schemaMigration
+	if err := tx.Order("version").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	rows := make(map[int64]schemaMigration, len(list))
+	for _, row := range list {
+		rows[row.Version] = row
+	}
+	return rows, nil
+}
+
+// apply marks the migration dirty, then runs it and clears the flag in one
+// transaction. A failure therefore leaves a dirty row behind.
+func (m *Migrator) apply(tx *gorm.DB, mig Migration) error {
+	row := schemaMigration{
+		Version:   mig.Version,
+		Name:      mig.Name,
+		Checksum:  mig.Checksum,
+		Dirty:     true,
+		AppliedAt: time.Now().UTC(),
+	}
+	if err := tx.Create(&row).Error; err != nil {
+		return err
+	}
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := execScript(tx, mig.Up); err != nil {
+			return err
+		}
+		return tx.Model(&schemaMigration{}).Where("version = ?", mig.Version).Update("dirty", false).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) revert(tx *gorm.DB, mig Migration) error {
+	if err := tx.Model(&schemaMigration{}).Where("version = ?", mig.Version).Update("dirty", true).Error; err != nil {
+		return err
+	}
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := execScript(tx, mig.Down); err != nil {
+			return err
+		}
+		return tx.Delete(&schemaMigration{}, "version = ?", mig.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("reverting migration %d_%s failed: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+// withLock runs fn on a single connection while holding the migration lock,
+// so replicas starting at the same time do not race each other.
+func (m *Migrator) withLock(fn func(tx *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		// a fresh session keeps chained statements from leaking into each other
+		tx := conn.Session(&gorm.Session{})
+		if tx.Dialector.Name() != "postgres" {
+			// SQLite serialises writers itself and is never shared between replicas.
+			return fn(tx)
+		}
+		if err := tx.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("acquiring migration lock: %w", err)
+		}
+		defer func() {
+			if err := tx.Exec("SELECT pg_advisory_unlock(?)", migrationLockID).Error; err != nil {
+				m.logger.Printf("releasing migration lock: %v", err)
+			}
+		}()
+		return fn(tx)
+	})
+}
+
+// execScript runs each statement of a migration file in turn.
+func execScript(tx *gorm.DB, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dollarTag matches the opening of a Postgres dollar-quoted string, $$ or $tag$.
+var dollarTag = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// splitStatements splits a migration file into statements at semicolons,
+// except those inside quotes, comments, Postgres dollar-quoted bodies and
+// the BEGIN ... END body of a trigger. Statements holding only comments are
+// dropped.
+func splitStatements(script string) []string {
+	var (
+		stmts   []string
+		start   int
+		code    bool // the statement has more than whitespace and comments
+		words   int  // keywords seen so far in the statement
+		create  bool // the statement starts with CREATE
+		trigger bool // the statement is CREATE [TEMP] TRIGGER
+		depth   int  // BEGIN and CASE blocks open in a trigger
+	)
+	for i := 0; i < len(script); {
+		c := script[i]
+		rest := script[i:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || c == '"' || c == '`':
+			// a doubled quote ends one string and starts the next, which
+			// reads the same
+			end := strings.IndexByte(rest[1:], c)
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 2
+			}
+			code = true
+		case c == '$' && dollarTag.MatchString(rest):
+			tag := dollarTag.FindString(rest)
+			end := strings.Index(rest[len(tag):], tag)
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += len(tag) + end + len(tag)
+			}
+			code = true
+		case c == ';' && depth == 0:
+			if code {
+				stmts = append(stmts, strings.TrimSpace(script[start:i+1]))
+			}
+			i++
+			start, code, words, create, trigger = i, false, 0, false, false
+		case c == '_' || unicode.IsLetter(rune(c)):
+			end := i + 1
+			for end < len(script) && (script[end] == '_' || unicode.IsLetter(rune(script[end])) || unicode.IsDigit(rune(script[end]))) {
+				end++
+			}
+			word := strings.ToUpper(script[i:end])
+			words++
+			code = true
+			switch {
+			case words == 1:
+				create = word == "CREATE"
+			case create && words <= 4 && word == "TRIGGER":
+				trigger = true
+			case trigger && (word == "BEGIN" || word == "CASE"):
+				depth++
+			case trigger && word == "END" && depth > 0:
+				depth--
+			}
+			i = end
+		default:
+			if !unicode.IsSpace(rune(c)) {
+				code = true
+			}
+			i++
+		}
+	}
+	if code {
+		stmts = append(stmts, strings.TrimSpace(script[start:]))
+	}
+	return stmts
+}
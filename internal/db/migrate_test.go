@@ -0,0 +1,288 @@
+package db
+
+import (
+	"errors"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"webhook-tester/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var discard = log.New(io.Discard, "", 0)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := OpenSQLite(filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	conn.Logger = logger.Discard
+	return conn
+}
+
+// testMigrations are two migrations: a table, then a trigger whose body and
+// a string literal hold semicolons.
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"sqlite/0001_notes.up.sql": {Data: []byte(`-- notes and their log
+CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL DEFAULT 'a;b');
+CREATE TABLE note_log (note_id INTEGER, what TEXT);
+`)},
+		"sqlite/0001_notes.down.sql": {Data: []byte("DROP TABLE note_log;\nDROP TABLE notes;\n")},
+		"sqlite/0002_log.up.sql": {Data: []byte(`CREATE TRIGGER notes_log AFTER INSERT ON notes
+BEGIN
+  INSERT INTO note_log (note_id, what) VALUES (NEW.id, CASE WHEN NEW.body = '' THEN 'empty;' ELSE 'note' END);
+  UPDATE notes SET body = body || ';' WHERE id = NEW.id;
+END;
+`)},
+		"sqlite/0002_log.down.sql": {Data: []byte("DROP TRIGGER notes_log;\n")},
+	}
+}
+
+func newTestMigrator(t *testing.T, conn *gorm.DB, fsys fstest.MapFS) *Migrator {
+	t.Helper()
+	list, err := loadMigrations(fsys, "sqlite")
+	require.NoError(t, err)
+	return &Migrator{db: conn, logger: discard, migrations: list}
+}
+
+func TestSplitStatements(t *testing.T) {
+	for name, tc := range map[string]struct {
+		script string
+		want   []string
+	}{
+		"lines":              {"CREATE TABLE a (x INT);\n\nCREATE TABLE b (y INT);\n", []string{"CREATE TABLE a (x INT);", "CREATE TABLE b (y INT);"}},
+		"one line":           {"DROP TABLE a; DROP TABLE b;", []string{"DROP TABLE a;", "DROP TABLE b;"}},
+		"no final semicolon": {"DROP TABLE a;\nDROP TABLE b", []string{"DROP TABLE a;", "DROP TABLE b"}},
+		"comments": {
+			"-- drop; both\nDROP TABLE a; /* not; here */\n-- trailing;\n",
+			[]string{"-- drop; both\nDROP TABLE a;"},
+		},
+		"strings": {
+			`INSERT INTO t VALUES ('a;b', 'it''s;', "c;d");` + "\nSELECT 1;",
+			[]string{`INSERT INTO t VALUES ('a;b', 'it''s;', "c;d");`, "SELECT 1;"},
+		},
+		"dollar quotes": {
+			"CREATE FUNCTION f() RETURNS trigger AS $fn$\nBEGIN\n  NEW.x := 1;\n  RETURN NEW;\nEND;\n$fn$ LANGUAGE plpgsql;\nSELECT $$a;b$$;",
+			[]string{"CREATE FUNCTION f() RETURNS trigger AS $fn$\nBEGIN\n  NEW.x := 1;\n  RETURN NEW;\nEND;\n$fn$ LANGUAGE plpgsql;", "SELECT $$a;b$$;"},
+		},
+		"trigger": {
+			"CREATE TEMP TRIGGER t AFTER INSERT ON a BEGIN\n  SELECT CASE WHEN 1 THEN 2 END;\n  DELETE FROM b;\nEND;\nBEGIN;\nCOMMIT;",
+			[]string{"CREATE TEMP TRIGGER t AFTER INSERT ON a BEGIN\n  SELECT CASE WHEN 1 THEN 2 END;\n  DELETE FROM b;\nEND;", "BEGIN;", "COMMIT;"},
+		},
+		"empty": {"\n-- nothing\n", nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, splitStatements(tc.script))
+		})
+	}
+}
+
+func TestLoadMigrationsErrors(t *testing.T) {
+	_, err := loadMigrations(fstest.MapFS{"sqlite/0001_a.up.sql": {Data: []byte("SELECT 1;")}}, "sqlite")
+	assert.ErrorContains(t, err, "both up and down files are required")
+	_, err = loadMigrations(fstest.MapFS{"sqlite/x_a.up.sql": {}, "sqlite/x_a.down.sql": {}}, "sqlite")
+	assert.ErrorContains(t, err, "invalid version")
+	_, err = loadMigrations(fstest.MapFS{"sqlite/0001.up.sql": {}}, "sqlite")
+	assert.ErrorContains(t, err, "expected <version>_<name>")
+	_, err = loadMigrations(testMigrations(), "postgres")
+	assert.ErrorContains(t, err, "no migrations")
+}
+
+func TestUpAndDown(t *testing.T) {
+	conn := openTestDB(t)
+	m := newTestMigrator(t, conn, testMigrations())
+
+	pending, err := m.Pending()
+	require.NoError(t, err)
+	assert.Equal(t, 2, pending)
+
+	n, err := m.Up()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = m.Up()
+	require.NoError(t, err)
+	assert.Zero(t, n, "nothing left to apply")
+
+	// the trigger runs both of its statements
+	require.NoError(t, conn.Exec("INSERT INTO notes (id, body) VALUES (1, '')").Error)
+	var what, body string
+	require.NoError(t, conn.Raw("SELECT what FROM note_log WHERE note_id = 1").Scan(&what).Error)
+	require.NoError(t, conn.Raw("SELECT body FROM notes WHERE id = 1").Scan(&body).Error)
+	assert.Equal(t, "empty;", what)
+	assert.Equal(t, ";", body)
+
+	status, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, status, 2)
+	for _, st := range status {
+		assert.True(t, st.Applied)
+		assert.False(t, st.Dirty)
+		assert.NotNil(t, st.AppliedAt)
+	}
+
+	n, err = m.Down(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.False(t, hasTrigger(t, conn, "notes_log"))
+	assert.True(t, conn.Migrator().HasTable("notes"))
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+
+	n, err = m.Down(5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only applied migrations are reverted")
+	assert.False(t, conn.Migrator().HasTable("notes"))
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	conn := openTestDB(t)
+	_, err := newTestMigrator(t, conn, testMigrations()).Up()
+	require.NoError(t, err)
+
+	edited := testMigrations()
+	edited["sqlite/0001_notes.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY);\n")}
+	m := newTestMigrator(t, conn, edited)
+	_, err = m.Up()
+	assert.ErrorContains(t, err, "checksum mismatch for migration 1_notes")
+	_, err = m.Pending()
+	assert.ErrorContains(t, err, "checksum mismatch")
+	_, err = m.Down(1)
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestUnknownMigration(t *testing.T) {
+	conn := openTestDB(t)
+	_, err := newTestMigrator(t, conn, testMigrations()).Up()
+	require.NoError(t, err)
+
+	older := testMigrations()
+	delete(older, "sqlite/0002_log.up.sql")
+	delete(older, "sqlite/0002_log.down.sql")
+	_, err = newTestMigrator(t, conn, older).Up()
+	assert.ErrorContains(t, err, "does not know about")
+}
+
+func TestFailedMigrationLeavesDirtySchema(t *testing.T) {
+	conn := openTestDB(t)
+	broken := testMigrations()
+	broken["sqlite/0002_log.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE extra (x INT);\nCREATE TABLE oops (;\n")}
+	m := newTestMigrator(t, conn, broken)
+
+	n, err := m.Up()
+	assert.ErrorContains(t, err, "migration 2_log failed")
+	assert.Equal(t, 1, n)
+	assert.False(t, conn.Migrator().HasTable("extra"), "the failed migration is rolled back")
+
+	status, err := m.Status()
+	require.NoError(t, err)
+	assert.True(t, status[1].Applied)
+	assert.True(t, status[1].Dirty)
+
+	// nothing runs while the schema is dirty
+	_, err = m.Up()
+	assert.True(t, errors.Is(err, ErrDirtySchema), "got %v", err)
+	_, err = m.Down(1)
+	assert.True(t, errors.Is(err, ErrDirtySchema), "got %v", err)
+	_, err = m.Pending()
+	assert.True(t, errors.Is(err, ErrDirtySchema), "got %v", err)
+
+	// force back to the last good version, then apply the fixed migration
+	require.NoError(t, m.Force(1))
+	status, err = m.Status()
+	require.NoError(t, err)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+	assert.False(t, status[1].Dirty)
+
+	n, err = newTestMigrator(t, conn, testMigrations()).Up()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, hasTrigger(t, conn, "notes_log"))
+}
+
+func TestForceMarksApplied(t *testing.T) {
+	conn := openTestDB(t)
+	m := newTestMigrator(t, conn, testMigrations())
+	require.NoError(t, m.Force(2))
+	pending, err := m.Pending()
+	require.NoError(t, err)
+	assert.Zero(t, pending, "forced migrations count as applied")
+	assert.False(t, conn.Migrator().HasTable("notes"), "force runs no SQL")
+}
+
+// TestEmbeddedMigrations applies, reverts and reapplies every real SQLite
+// migration, so that each down file undoes its up file.
+func TestEmbeddedMigrations(t *testing.T) {
+	conn := openTestDB(t)
+	m, err := NewMigrator(conn, discard)
+	require.NoError(t, err)
+	n, err := m.Up()
+	require.NoError(t, err)
+	total := len(m.migrations)
+	assert.Equal(t, total, n)
+
+	n, err = m.Down(total)
+	require.NoError(t, err)
+	assert.Equal(t, total, n)
+	assert.False(t, conn.Migrator().HasTable("webhooks"))
+
+	n, err = m.Up()
+	require.NoError(t, err)
+	assert.Equal(t, total, n)
+}
+
+// TestRequestSizeBackfill checks that requests stored before sizes were
+// recorded are counted the way ComputeSize counts new ones.
+func TestRequestSizeBackfill(t *testing.T) {
+	conn := openTestDB(t)
+	m, err := NewMigrator(conn, discard)
+	require.NoError(t, err)
+	all := m.migrations
+	for i, mig := range all {
+		if mig.Name == "request_size" {
+			m.migrations = all[:i]
+		}
+	}
+	require.Less(t, len(m.migrations), len(all))
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	require.NoError(t, conn.Create(&models.Webhook{ID: "wh"}).Error)
+	old := []models.WebhookRequest{
+		{ID: "a", WebhookID: "wh", Method: "POST", Body: "héllo;",
+			Headers: map[string]any{"Content-Type": "text/plain", "X-Count": 3.0},
+			Query:   map[string]any{"q": "1"}},
+		{ID: "b", WebhookID: "wh", Method: "GET"},
+	}
+	require.NoError(t, conn.Create(&old).Error)
+	kept := models.WebhookRequest{ID: "c", WebhookID: "wh", Method: "GET", Size: 42}
+	require.NoError(t, conn.Create(&kept).Error)
+
+	m.migrations = all
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	var got []models.WebhookRequest
+	require.NoError(t, conn.Order("id").Find(&got).Error)
+	require.Len(t, got, 3)
+	for i := range old {
+		assert.Equal(t, old[i].ComputeSize(), got[i].Size, old[i].ID)
+		assert.NotZero(t, got[i].Size)
+	}
+	assert.Equal(t, int64(42), got[2].Size, "recorded sizes are left alone")
+}
+
+func hasTrigger(t *testing.T, conn *gorm.DB, name string) bool {
+	t.Helper()
+	var count int64
+	require.NoError(t, conn.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?", name).Scan(&count).Error)
+	return count > 0
+}
@@ -1,7 +1,12 @@
 package dtos
 
 import (
+	"fmt"
+	"net/http"
 	"time"
+	"webhook-tester/internal/chaos"
+	"webhook-tester/internal/compare"
+	"webhook-tester/internal/events"
 	"webhook-tester/internal/models"
 
 	"gorm.io/datatypes"
@@ -12,22 +17,147 @@ import (
 type CreateWebhookRequest struct {
 	// Title of the webhook
 	// required: true
-	Title         string `json:"title"`
-	ResponseCode  int    `json:"response_code"`
-	ResponseDelay uint   `json:"response_delay"` // milliseconds
-	ContentType   string `json:"content_type"`
-	Payload       string `json:"payload"`
-	NotifyOnEvent bool   `json:"notify_on_event"`
+	Title           string            `json:"title"`
+	ResponseCode    int               `json:"response_code" example:"200"` // 0 uses 200
+	ResponseDelay   uint              `json:"response_delay"`              // milliseconds
+	ContentType     string            `json:"content_type" example:"application/json"`
+	Payload         string            `json:"payload"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	NotifyOnEvent   bool              `json:"notify_on_event"`
+	RetentionCount  uint              `json:"retention_count"`                 // keep the last N requests, 0 keeps all
+	RetentionDays   uint              `json:"retention_days"`                  // keep requests for D days, 0 keeps all
+	SigningScheme   string            `json:"signing_scheme" example:"github"` // github, stripe, shopify or slack; replays and sent events are signed with it
+	SigningSecret   string            `json:"signing_secret"`                  // write-only, never returned
+	Faults          FaultConfig       `json:"faults"`                          // omitted injects no faults
+	Script          string            `json:"script"`                          // Starlark script defining handle(request), which answers requests
 } // @name CreateWebhookRequest
 
+// FaultConfig injects faults into the responses of a webhook, to test how
+// senders retry. Percentages are between 0 and 100. A request gets at most
+// one fault besides a delay: the rate limit is checked first, then
+// disconnect, every N-th, the error rate, truncate and drip
+type FaultConfig struct {
+	ErrorPercent      float64 `json:"error_percent,omitempty" example:"10"`
+	ErrorStatus       int     `json:"error_status,omitempty" example:"503"`                            // status of failed requests, default 500
+	FailEvery         int     `json:"fail_every,omitempty" example:"3"`                                // every N-th request gets the error status
+	DelayMinMs        int     `json:"delay_min_ms,omitempty"`                                          // random delay on top of response_delay
+	DelayMaxMs        int     `json:"delay_max_ms,omitempty" example:"2000"`                           // at most 30000
+	DelayDistribution string  `json:"delay_distribution,omitempty" enums:"uniform,normal,exponential"` // default uniform
+	RateLimit         int     `json:"rate_limit,omitempty" example:"10"`                               // requests answered per window; later ones get 429
+	RateLimitWindowMs int     `json:"rate_limit_window_ms,omitempty" example:"60000"`                  // default 60000
+	RetryAfter        int     `json:"retry_after,omitempty"`                                           // seconds in Retry-After, default until the window ends
+	TruncatePercent   float64 `json:"truncate_percent,omitempty"`                                      // body cut off halfway
+	DripPercent       float64 `json:"drip_percent,omitempty"`                                          // body sent one byte at a time
+	DripIntervalMs    int     `json:"drip_interval_ms,omitempty"`                                      // between dripped bytes, default 100
+	DisconnectPercent float64 `json:"disconnect_percent,omitempty"`                                    // connection closed without a response
+} // @name FaultConfig
+
+// UpdateWebhookRequest replaces every field of a webhook; omitted fields are
+// reset to their defaults
 type UpdateWebhookRequest struct {
 	CreateWebhookRequest
 } // @name UpdateWebhookRequest
 
-// ErrorResponse represents an error payload
-type ErrorResponse struct {
-	Error string `json:"error" example:"Webhook not found"`
-} // @name ErrorResponse
+// PatchWebhookRequest changes only the fields present in the body. A null
+// field is reset to its default
+type PatchWebhookRequest struct {
+	Title           Optional[string]            `json:"title" swaggertype:"string"`
+	ResponseCode    Optional[int]               `json:"response_code" swaggertype:"integer"`
+	ResponseDelay   Optional[uint]              `json:"response_delay" swaggertype:"integer"`
+	ContentType     Optional[string]            `json:"content_type" swaggertype:"string"`
+	Payload         Optional[string]            `json:"payload" swaggertype:"string"`
+	ResponseHeaders Optional[map[string]string] `json:"response_headers" swaggertype:"object,string"`
+	NotifyOnEvent   Optional[bool]              `json:"notify_on_event" swaggertype:"boolean"`
+	RetentionCount  Optional[uint]              `json:"retention_count" swaggertype:"integer"`
+	RetentionDays   Optional[uint]              `json:"retention_days" swaggertype:"integer"`
+	SigningScheme   Optional[string]            `json:"signing_scheme" swaggertype:"string"`
+	SigningSecret   Optional[string]            `json:"signing_secret" swaggertype:"string"`
+	Faults          Optional[FaultConfig]       `json:"faults" swaggertype:"object"` // replaces the whole fault config
+	Script          Optional[string]            `json:"script" swaggertype:"string"`
+} // @name PatchWebhookRequest
+
+// ApplyTo writes the request onto a new webhook, filling in defaults
+func (in CreateWebhookRequest) ApplyTo(w *models.Webhook) {
+	w.Title = in.Title
+	w.ResponseCode = in.ResponseCode
+	if w.ResponseCode == 0 {
+		w.ResponseCode = http.StatusOK
+	}
+	w.ResponseDelay = in.ResponseDelay
+	w.ContentType = optionalString(in.ContentType)
+	w.Payload = optionalString(in.Payload)
+	w.ResponseHeaders = headersMap(in.ResponseHeaders)
+	w.NotifyOnEvent = in.NotifyOnEvent
+	w.RetentionCount = in.RetentionCount
+	w.RetentionDays = in.RetentionDays
+	w.SigningScheme = in.SigningScheme
+	w.SigningSecret = in.SigningSecret
+	w.Faults = datatypes.NewJSONType(chaos.Config(in.Faults))
+	w.Script = in.Script
+}
+
+// ApplyTo writes the fields present in the request onto w
+func (in PatchWebhookRequest) ApplyTo(w *models.Webhook) {
+	in.Title.Apply(&w.Title, "")
+	in.ResponseCode.Apply(&w.ResponseCode, http.StatusOK)
+	in.ResponseDelay.Apply(&w.ResponseDelay, 0)
+	in.NotifyOnEvent.Apply(&w.NotifyOnEvent, false)
+	in.RetentionCount.Apply(&w.RetentionCount, 0)
+	in.RetentionDays.Apply(&w.RetentionDays, 0)
+	in.SigningScheme.Apply(&w.SigningScheme, "")
+	in.SigningSecret.Apply(&w.SigningSecret, "")
+	in.Script.Apply(&w.Script, "")
+	if w.SigningScheme == "" && !in.SigningSecret.Set {
+		w.SigningSecret = "" // removing the scheme forgets the secret
+	}
+	if in.ContentType.Set {
+		w.ContentType = optionalString(in.ContentType.Value)
+	}
+	if in.Payload.Set {
+		w.Payload = optionalString(in.Payload.Value)
+	}
+	if in.ResponseHeaders.Set {
+		w.ResponseHeaders = headersMap(in.ResponseHeaders.Value)
+	}
+	if in.Faults.Set {
+		w.Faults = datatypes.NewJSONType(chaos.Config(in.Faults.Value))
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func headersMap(h map[string]string) datatypes.JSONMap {
+	if len(h) == 0 {
+		return nil
+	}
+	m := make(datatypes.JSONMap, len(h))
+	for k, v := range h {
+		m[k] = v
+	}
+	return m
+}
+
+// ScriptState is the key/value state a webhook's script keeps between requests
+type ScriptState struct {
+	Data map[string]any `json:"data" swaggertype:"object"`
+} // @name ScriptState
+
+// Problem is an RFC 7807 error document. Code is a stable, machine-readable
+// identifier; Errors names each invalid field when Code is validation_failed.
+type Problem struct {
+	Type     string            `json:"type" example:"urn:webhook-tester:problem:not_found"`
+	Title    string            `json:"title" example:"Not Found"`
+	Status   int               `json:"status" example:"404"`
+	Detail   string            `json:"detail,omitempty" example:"webhook not found"`
+	Instance string            `json:"instance,omitempty" example:"/api/webhooks/abc"`
+	Code     string            `json:"code" example:"not_found"`
+	Errors   map[string]string `json:"errors,omitempty"`
+} // @name Problem
 
 type WebhookRequest struct {
 	ID         string            `gorm:"primaryKey" json:"id"`
@@ -36,38 +166,731 @@ type WebhookRequest struct {
 	Headers    datatypes.JSONMap `json:"headers"`
 	Query      datatypes.JSONMap `json:"query"`
 	Body       string            `json:"body"`
+	Pinned     bool              `json:"pinned"`
+	Size       int64             `json:"size"`
+	Source     string            `json:"source" enums:"live,import,sent"`                                             // live traffic, imported from a file or sent by the event sender
+	Fault      string            `json:"fault,omitempty" enums:"rate_limit,disconnect,every_nth,error,truncate,drip"` // injected into the response
+	FaultDelay int64             `json:"fault_delay_ms,omitempty"`                                                    // random delay injected, milliseconds
+	ScriptLog  string            `json:"script_log,omitempty"`                                                        // what the webhook's script printed, and its error
 	ReceivedAt time.Time         `json:"received_at"`
 } // @name WebhookRequest
 
+// RequestPage is one page of webhook requests, newest first
+type RequestPage struct {
+	Data    []WebhookRequest `json:"data"`
+	Page    int              `json:"page" example:"1"`
+	PerPage int              `json:"per_page" example:"50"`
+	Total   int64            `json:"total" example:"120"`
+} // @name RequestPage
+
+// Snippet is code that sends a captured request again
+type Snippet struct {
+	Language string `json:"language" example:"curl"`
+	Label    string `json:"label" example:"curl"`
+	Code     string `json:"code"`
+} // @name Snippet
+
+// ImportResult reports how many requests an import stored
+type ImportResult struct {
+	Imported int `json:"imported" example:"42"`
+} // @name ImportResult
+
+// ReplayRequest says where to replay a request and what to change. Omitted
+// fields keep what was captured
+type ReplayRequest struct {
+	Target        string            `json:"target,omitempty" example:"http://localhost:8080/hooks"` // default the webhook URL
+	Method        string            `json:"method,omitempty" example:"POST"`
+	Headers       map[string]string `json:"headers,omitempty"` // set, replacing captured headers
+	RemoveHeaders []string          `json:"remove_headers,omitempty" example:"X-Hub-Signature-256"`
+	Query         map[string]string `json:"query,omitempty"` // set, replacing captured parameters
+	RemoveQuery   []string          `json:"remove_query,omitempty"`
+	Body          *string           `json:"body,omitempty"`
+	Resign        bool              `json:"resign,omitempty"`                     // recompute signature headers with the webhook's signing secret
+	TimeoutMs     int               `json:"timeout_ms,omitempty" example:"30000"` // default 30000, at most 120000
+} // @name ReplayRequest
+
+// ReplayAttempt is one replay of a request and the target's response
+type ReplayAttempt struct {
+	ID                string            `json:"id"`
+	RequestID         string            `json:"request_id"`
+	Method            string            `json:"method"`
+	URL               string            `json:"url"`
+	RequestHeaders    map[string]string `json:"request_headers"`
+	RequestBody       string            `json:"request_body"`
+	StatusCode        int               `json:"status_code" example:"200"` // 0 when no response arrived
+	ResponseHeaders   map[string]string `json:"response_headers"`
+	ResponseBody      string            `json:"response_body"`
+	ResponseTruncated bool              `json:"response_truncated"` // only the first MiB of the body was kept
+	DurationMs        int64             `json:"duration_ms" example:"42"`
+	Error             string            `json:"error,omitempty" example:"connection refused"`
+	CreatedAt         time.Time         `json:"created_at"`
+} // @name ReplayAttempt
+
+// NewReplayAttemptDTO creates a ReplayAttempt DTO from models.ReplayAttempt
+func NewReplayAttemptDTO(a models.ReplayAttempt) ReplayAttempt {
+	return ReplayAttempt{
+		ID:                a.ID,
+		RequestID:         a.RequestID,
+		Method:            a.Method,
+		URL:               a.URL,
+		RequestHeaders:    stringMap(a.RequestHeaders),
+		RequestBody:       a.RequestBody,
+		StatusCode:        a.StatusCode,
+		ResponseHeaders:   stringMap(a.ResponseHeaders),
+		ResponseBody:      a.ResponseBody,
+		ResponseTruncated: a.ResponseTruncated,
+		DurationMs:        a.DurationMs,
+		Error:             a.Error,
+		CreatedAt:         a.CreatedAt,
+	}
+}
+
+func stringMap(m datatypes.JSONMap) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+// ReplayJobRequest starts a batch replay of a webhook's requests, oldest
+// first. Omitted selection fields match every request
+type ReplayJobRequest struct {
+	Target        string     `json:"target,omitempty" example:"http://localhost:8080/hooks"` // default the webhook URL
+	Since         *time.Time `json:"since,omitempty"`                                        // received at or after
+	Until         *time.Time `json:"until,omitempty"`                                        // received before
+	IDs           []string   `json:"ids,omitempty"`                                          // only these requests
+	Mode          string     `json:"mode,omitempty" example:"timing" enums:"timing,rate"`    // default timing
+	Speed         float64    `json:"speed,omitempty" example:"1"`                            // timing mode: 2 halves the gaps; default 1
+	Rate          float64    `json:"rate,omitempty" example:"5"`                             // rate mode: requests per second; default no limit
+	Concurrency   int        `json:"concurrency,omitempty" example:"1"`                      // requests in flight; default 10 in timing mode, 1 in rate mode
+	StopOnFailure bool       `json:"stop_on_failure,omitempty"`                              // stop at the first non-2xx response or error
+	Resign        bool       `json:"resign,omitempty"`                                       // recompute signature headers with the webhook's signing secret
+	TimeoutMs     int        `json:"timeout_ms,omitempty" example:"30000"`                   // per request; default 30000, at most 120000
+} // @name ReplayJobRequest
+
+// ReplayJob is a batch replay and its progress
+type ReplayJob struct {
+	ID            string     `json:"id"`
+	WebhookID     string     `json:"webhook_id"`
+	Target        string     `json:"target"`
+	Mode          string     `json:"mode" example:"timing"`
+	Speed         float64    `json:"speed,omitempty"`
+	Rate          float64    `json:"rate,omitempty"`
+	Concurrency   int        `json:"concurrency"`
+	StopOnFailure bool       `json:"stop_on_failure"`
+	Resign        bool       `json:"resign"`
+	TimeoutMs     int64      `json:"timeout_ms"`
+	Status        string     `json:"status" example:"running" enums:"running,paused,completed,stopped,cancelled,failed"`
+	Total         int        `json:"total"`
+	Succeeded     int        `json:"succeeded"` // answered 2xx
+	Failed        int        `json:"failed"`    // other statuses and errors
+	Skipped       int        `json:"skipped"`   // deleted before their turn
+	Error         string     `json:"error,omitempty" example:"request 4kPq got 500"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+} // @name ReplayJob
+
+// ReplayJobItem is one request of a batch replay and its result
+type ReplayJobItem struct {
+	Seq        int        `json:"seq"`
+	RequestID  string     `json:"request_id"`
+	OffsetMs   int64      `json:"offset_ms"` // when the request arrived, after the job's first
+	Status     string     `json:"status" example:"succeeded" enums:"pending,succeeded,failed,skipped"`
+	AttemptID  string     `json:"attempt_id,omitempty"`
+	StatusCode int        `json:"status_code,omitempty" example:"200"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+} // @name ReplayJobItem
+
+// NewReplayJobDTO creates a ReplayJob DTO from models.ReplayJob
+func NewReplayJobDTO(j models.ReplayJob) ReplayJob {
+	return ReplayJob{
+		ID:            j.ID,
+		WebhookID:     j.WebhookID,
+		Target:        j.Target,
+		Mode:          j.Mode,
+		Speed:         j.Speed,
+		Rate:          j.Rate,
+		Concurrency:   j.Concurrency,
+		StopOnFailure: j.StopOnFailure,
+		Resign:        j.Resign,
+		TimeoutMs:     j.TimeoutMs,
+		Status:        j.Status,
+		Total:         j.Total,
+		Succeeded:     j.Succeeded,
+		Failed:        j.Failed,
+		Skipped:       j.Skipped,
+		Error:         j.Error,
+		CreatedAt:     j.CreatedAt,
+		FinishedAt:    j.FinishedAt,
+	}
+}
+
+// NewReplayJobItemDTO creates a ReplayJobItem DTO from models.ReplayJobItem
+func NewReplayJobItemDTO(item models.ReplayJobItem) ReplayJobItem {
+	return ReplayJobItem{
+		Seq:        item.Seq,
+		RequestID:  item.RequestID,
+		OffsetMs:   item.OffsetMs,
+		Status:     item.Status,
+		AttemptID:  item.AttemptID,
+		StatusCode: item.StatusCode,
+		DurationMs: item.DurationMs,
+		Error:      item.Error,
+		SentAt:     item.SentAt,
+	}
+}
+
+// CompareRequest sends a request to a baseline and a candidate target and
+// diffs their responses
+type CompareRequest struct {
+	Baseline  string   `json:"baseline" example:"http://old-consumer.internal/hooks"`
+	Candidate string   `json:"candidate" example:"http://new-consumer.internal/hooks"`
+	Ignore    []string `json:"ignore,omitempty" example:"body.id,body.**.created_at"` // paths left out of the diff, besides the Date and Content-Length headers
+	Resign    bool     `json:"resign,omitempty"`                                      // recompute signature headers with the webhook's signing secret
+	TimeoutMs int      `json:"timeout_ms,omitempty" example:"30000"`                  // per target; default 30000, at most 120000
+} // @name CompareRequest
+
+// Difference is one place where the candidate's response differs from the
+// baseline's. The side that lacks the value omits it
+type Difference struct {
+	Path      string `json:"path" example:"body.items[0].total"`
+	Change    string `json:"change" example:"changed" enums:"changed,added,removed"`
+	Baseline  any    `json:"baseline,omitempty"`
+	Candidate any    `json:"candidate,omitempty"`
+} // @name Difference
+
+// Comparison is a request sent to two targets and how their responses differ
+type Comparison struct {
+	ID                 string       `json:"id"`
+	RequestID          string       `json:"request_id"`
+	BatchID            string       `json:"batch_id,omitempty"`
+	Baseline           string       `json:"baseline"`
+	Candidate          string       `json:"candidate"`
+	Ignore             []string     `json:"ignore"`
+	BaselineAttemptID  string       `json:"baseline_attempt_id"`  // the replay attempt holding the baseline's response
+	CandidateAttemptID string       `json:"candidate_attempt_id"` // the replay attempt holding the candidate's response
+	BaselineStatus     int          `json:"baseline_status" example:"200"`
+	CandidateStatus    int          `json:"candidate_status" example:"200"`
+	Equal              bool         `json:"equal"`
+	Differences        []Difference `json:"differences"`
+	Error              string       `json:"error,omitempty" example:"candidate: connection refused"` // a target didn't answer, so nothing was compared
+	CreatedAt          time.Time    `json:"created_at"`
+} // @name Comparison
+
+// ComparisonBatchRequest compares a webhook's requests, oldest first.
+// Omitted selection fields match every request
+type ComparisonBatchRequest struct {
+	Baseline    string     `json:"baseline" example:"http://old-consumer.internal/hooks"`
+	Candidate   string     `json:"candidate" example:"http://new-consumer.internal/hooks"`
+	Ignore      []string   `json:"ignore,omitempty" example:"body.id,body.**.created_at"` // paths left out of the diff, besides the Date and Content-Length headers
+	Since       *time.Time `json:"since,omitempty"`                                       // received at or after
+	Until       *time.Time `json:"until,omitempty"`                                       // received before
+	IDs         []string   `json:"ids,omitempty"`                                         // only these requests
+	Concurrency int        `json:"concurrency,omitempty" example:"4"`                     // requests compared at once; default 4, at most 20
+	Resign      bool       `json:"resign,omitempty"`                                      // recompute signature headers with the webhook's signing secret
+	TimeoutMs   int        `json:"timeout_ms,omitempty" example:"30000"`                  // per target; default 30000, at most 120000
+} // @name ComparisonBatchRequest
+
+// ComparisonBatch is a batch comparison and its progress
+type ComparisonBatch struct {
+	ID          string     `json:"id"`
+	WebhookID   string     `json:"webhook_id"`
+	Baseline    string     `json:"baseline"`
+	Candidate   string     `json:"candidate"`
+	Ignore      []string   `json:"ignore"`
+	Resign      bool       `json:"resign"`
+	TimeoutMs   int64      `json:"timeout_ms"`
+	Concurrency int        `json:"concurrency"`
+	Status      string     `json:"status" example:"running" enums:"running,completed,failed"`
+	Total       int        `json:"total"`
+	Equal       int        `json:"equal"`
+	Different   int        `json:"different"`
+	Failed      int        `json:"failed"`  // a target didn't answer
+	Skipped     int        `json:"skipped"` // deleted before their turn
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+} // @name ComparisonBatch
+
+// PathCount is how many comparisons of a batch differ at a path. Array
+// indexes read [*], so the path can be used as an ignore pattern
+type PathCount struct {
+	Path  string `json:"path" example:"body.items[*].total"`
+	Count int    `json:"count" example:"12"`
+} // @name PathCount
+
+// ComparisonBatchResults are the comparisons of a batch so far and where
+// they differ, most frequent first
+type ComparisonBatchResults struct {
+	Paths       []PathCount  `json:"paths"`
+	Comparisons []Comparison `json:"comparisons"`
+} // @name ComparisonBatchResults
+
+// NewComparisonDTO creates a Comparison DTO from models.Comparison
+func NewComparisonDTO(c models.Comparison) Comparison {
+	diffs := make([]Difference, len(c.Differences))
+	for i, d := range c.Differences {
+		diffs[i] = Difference{Path: d.Path, Change: d.Change, Baseline: d.Baseline, Candidate: d.Candidate}
+	}
+	return Comparison{
+		ID:                 c.ID,
+		RequestID:          c.RequestID,
+		BatchID:            c.BatchID,
+		Baseline:           c.BaselineURL,
+		Candidate:          c.CandidateURL,
+		Ignore:             append([]string{}, c.Ignore...),
+		BaselineAttemptID:  c.BaselineAttemptID,
+		CandidateAttemptID: c.CandidateAttemptID,
+		BaselineStatus:     c.BaselineStatus,
+		CandidateStatus:    c.CandidateStatus,
+		Equal:              c.Equal,
+		Differences:        diffs,
+		Error:              c.Error,
+		CreatedAt:          c.CreatedAt,
+	}
+}
+
+// NewComparisonBatchDTO creates a ComparisonBatch DTO from models.ComparisonBatch
+func NewComparisonBatchDTO(b models.ComparisonBatch) ComparisonBatch {
+	return ComparisonBatch{
+		ID:          b.ID,
+		WebhookID:   b.WebhookID,
+		Baseline:    b.BaselineURL,
+		Candidate:   b.CandidateURL,
+		Ignore:      append([]string{}, b.Ignore...),
+		Resign:      b.Resign,
+		TimeoutMs:   b.TimeoutMs,
+		Concurrency: b.Concurrency,
+		Status:      b.Status,
+		Total:       b.Total,
+		Equal:       b.Equal,
+		Different:   b.Different,
+		Failed:      b.Failed,
+		Skipped:     b.Skipped,
+		Error:       b.Error,
+		CreatedAt:   b.CreatedAt,
+		FinishedAt:  b.FinishedAt,
+	}
+}
+
+// NewComparisonBatchResultsDTO creates ComparisonBatchResults from the
+// comparisons of a batch and their differing paths
+func NewComparisonBatchResultsDTO(list []models.Comparison, paths []compare.PathCount) ComparisonBatchResults {
+	out := ComparisonBatchResults{Paths: make([]PathCount, len(paths)), Comparisons: make([]Comparison, len(list))}
+	for i, p := range paths {
+		out.Paths[i] = PathCount{Path: p.Path, Count: p.Count}
+	}
+	for i, c := range list {
+		out.Comparisons[i] = NewComparisonDTO(c)
+	}
+	return out
+}
+
+// EventTemplate is a provider event the sender can send, rendered with fresh
+// IDs and timestamps
+type EventTemplate struct {
+	Name     string            `json:"name" example:"github.push"`
+	Title    string            `json:"title" example:"GitHub push"`
+	Provider string            `json:"provider" example:"GitHub"`
+	Scheme   string            `json:"scheme,omitempty" example:"github"` // the signing scheme the provider uses
+	Method   string            `json:"method" example:"POST"`
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body"`
+} // @name EventTemplate
+
+// NewEventTemplateDTO creates an EventTemplate from a template and its rendering
+func NewEventTemplateDTO(t events.Template, ev events.Event) EventTemplate {
+	return EventTemplate{
+		Name:     t.Name,
+		Title:    t.Title,
+		Provider: t.Provider,
+		Scheme:   t.Scheme,
+		Method:   ev.Method,
+		Headers:  ev.Headers,
+		Body:     ev.Body,
+	}
+}
+
+// SendEventRequest describes an event to send from a webhook. Omitted fields
+// come from the template
+type SendEventRequest struct {
+	Target    string            `json:"target,omitempty" example:"http://localhost:8080/hooks"` // default the webhook URL
+	Template  string            `json:"template,omitempty" example:"github.push"`
+	Method    string            `json:"method,omitempty" example:"POST"` // default POST
+	Headers   map[string]string `json:"headers,omitempty"`               // set over the template's headers
+	Body      *string           `json:"body,omitempty"`
+	Sign      bool              `json:"sign,omitempty"`                       // add signature headers with the webhook's signing secret
+	TimeoutMs int               `json:"timeout_ms,omitempty" example:"30000"` // default 30000, at most 120000
+} // @name SendEventRequest
+
+// SentEvent is an event sent from a webhook: the request as stored, with
+// source "sent", and its delivery
+type SentEvent struct {
+	Request  WebhookRequest `json:"request"`
+	Delivery ReplayAttempt  `json:"delivery"`
+} // @name SentEvent
+
+// DeliveryRequest delivers an event from a webhook the way a provider would,
+// retrying until the target answers 2xx. Omitted event fields come from the
+// template
+type DeliveryRequest struct {
+	Target         string            `json:"target,omitempty" example:"http://localhost:8080/hooks"` // default the webhook URL
+	Template       string            `json:"template,omitempty" example:"stripe.checkout.session.completed"`
+	Method         string            `json:"method,omitempty" example:"POST"` // default POST
+	Headers        map[string]string `json:"headers,omitempty"`               // set over the template's headers
+	Body           *string           `json:"body,omitempty"`
+	Sign           bool              `json:"sign,omitempty"`                                                         // sign each attempt afresh with the webhook's signing secret
+	TimeoutMs      int               `json:"timeout_ms,omitempty" example:"30000"`                                   // per attempt; default 30000, at most 120000
+	Policy         string            `json:"policy,omitempty" example:"exponential" enums:"none,exponential,custom"` // default none
+	MaxAttempts    int               `json:"max_attempts,omitempty" example:"5"`                                     // exponential: attempts in all; default 5, at most 20
+	InitialDelayMs int               `json:"initial_delay_ms,omitempty" example:"1000"`                              // exponential: the first wait, doubled for each retry; default 1000
+	ScheduleMs     []int             `json:"schedule_ms,omitempty" example:"1000,5000,30000"`                        // custom: the wait before each retry
+	BudgetMs       int               `json:"budget_ms,omitempty" example:"60000"`                                    // for all attempts and waits; default no limit
+	DeliveryHeader string            `json:"delivery_header,omitempty" example:"X-Delivery-ID"`                      // header carrying the delivery ID on every attempt
+} // @name DeliveryRequest
+
+// Delivery is an event delivered with retries and its progress
+type Delivery struct {
+	ID            string     `json:"id"`
+	WebhookID     string     `json:"webhook_id"`
+	RequestID     string     `json:"request_id"` // the event, stored with source "sent"
+	Target        string     `json:"target"`
+	Policy        string     `json:"policy" example:"exponential"`
+	ScheduleMs    []int64    `json:"schedule_ms"` // the wait before each retry
+	MaxAttempts   int        `json:"max_attempts"`
+	Sign          bool       `json:"sign"`
+	TimeoutMs     int64      `json:"timeout_ms"`
+	BudgetMs      int64      `json:"budget_ms,omitempty"`
+	Status        string     `json:"status" example:"delivering" enums:"delivering,succeeded,failed,cancelled"`
+	Attempts      int        `json:"attempts"`                            // made so far
+	StatusCode    int        `json:"status_code,omitempty" example:"503"` // of the latest attempt
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	Error         string     `json:"error,omitempty" example:"all 5 attempts failed"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+} // @name Delivery
+
+// DeliveryAttempt is one attempt of a delivery
+type DeliveryAttempt struct {
+	Seq        int       `json:"seq" example:"1"`
+	AttemptID  string    `json:"attempt_id,omitempty"` // the replay attempt recording the exchange
+	Succeeded  bool      `json:"succeeded"`
+	StatusCode int       `json:"status_code,omitempty" example:"503"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	SentAt     time.Time `json:"sent_at"`
+} // @name DeliveryAttempt
+
+// NewDeliveryDTO creates a Delivery DTO from models.Delivery
+func NewDeliveryDTO(d models.Delivery) Delivery {
+	return Delivery{
+		ID:            d.ID,
+		WebhookID:     d.WebhookID,
+		RequestID:     d.RequestID,
+		Target:        d.Target,
+		Policy:        d.Policy,
+		ScheduleMs:    append([]int64{}, d.Schedule...),
+		MaxAttempts:   d.MaxAttempts(),
+		Sign:          d.Sign,
+		TimeoutMs:     d.TimeoutMs,
+		BudgetMs:      d.BudgetMs,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		StatusCode:    d.StatusCode,
+		NextAttemptAt: d.NextAttemptAt,
+		Error:         d.Error,
+		CreatedAt:     d.CreatedAt,
+		FinishedAt:    d.FinishedAt,
+	}
+}
+
+// NewDeliveryAttemptDTO creates a DeliveryAttempt DTO from models.DeliveryAttempt
+func NewDeliveryAttemptDTO(a models.DeliveryAttempt) DeliveryAttempt {
+	return DeliveryAttempt{
+		Seq:        a.Seq,
+		AttemptID:  a.AttemptID,
+		Succeeded:  a.Succeeded,
+		StatusCode: a.StatusCode,
+		DurationMs: a.DurationMs,
+		Error:      a.Error,
+		SentAt:     a.SentAt,
+	}
+}
+
+// LoadRunRequest fires a captured request, or an event rendered afresh from
+// a template for every request, at a target. The run stops after count
+// requests or duration_ms, whichever comes first
+type LoadRunRequest struct {
+	Target      string  `json:"target" example:"http://localhost:8080/hooks"`
+	RequestID   string  `json:"request_id,omitempty"`                     // a captured request of the webhook
+	Template    string  `json:"template,omitempty" example:"github.push"` // instead of request_id
+	Sign        bool    `json:"sign,omitempty"`                           // sign every request with the webhook's signing secret
+	Concurrency int     `json:"concurrency,omitempty" example:"10"`       // requests in flight at most; default 10, at most 100
+	Rate        float64 `json:"rate,omitempty" example:"50"`              // requests started per second; default no limit, at most 1000
+	DurationMs  int     `json:"duration_ms,omitempty" example:"30000"`    // at most 600000
+	Count       int     `json:"count,omitempty" example:"1000"`           // at most 100000, which also caps runs by duration
+	TimeoutMs   int     `json:"timeout_ms,omitempty" example:"30000"`     // per request; default 30000, at most 120000
+} // @name LoadRunRequest
+
+// LoadRun is a load run with its results so far. Latencies are those of
+// requests that got a response
+type LoadRun struct {
+	ID          string        `json:"id"`
+	WebhookID   string        `json:"webhook_id"`
+	RequestID   string        `json:"request_id,omitempty"`
+	Template    string        `json:"template,omitempty"`
+	Sign        bool          `json:"sign"`
+	Target      string        `json:"target"`
+	Concurrency int           `json:"concurrency"`
+	Rate        float64       `json:"rate,omitempty"`
+	DurationMs  int64         `json:"duration_ms,omitempty"`
+	Count       int           `json:"count,omitempty"`
+	TimeoutMs   int64         `json:"timeout_ms"`
+	Status      string        `json:"status" example:"running" enums:"running,completed,cancelled,failed"`
+	Requests    int           `json:"requests"`  // completed so far
+	Succeeded   int           `json:"succeeded"` // answered 2xx
+	Failed      int           `json:"failed"`    // answered with another status
+	Errors      int           `json:"errors"`    // got no response
+	StatusCodes []StatusCount `json:"status_codes"`
+	ErrorCounts []ErrorCount  `json:"error_counts"` // most frequent first
+	MinMs       float64       `json:"min_ms" example:"0.8"`
+	MeanMs      float64       `json:"mean_ms" example:"4.2"`
+	P50Ms       float64       `json:"p50_ms" example:"3.1"`
+	P90Ms       float64       `json:"p90_ms" example:"7.5"`
+	P95Ms       float64       `json:"p95_ms" example:"9.9"`
+	P99Ms       float64       `json:"p99_ms" example:"21.4"`
+	MaxMs       float64       `json:"max_ms" example:"48"`
+	ElapsedMs   int64         `json:"elapsed_ms"`
+	Throughput  float64       `json:"throughput" example:"248.5"` // completed requests per second
+	Error       string        `json:"error,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	FinishedAt  *time.Time    `json:"finished_at,omitempty"`
+} // @name LoadRun
+
+// StatusCount is how many responses of a load run had a status code
+type StatusCount struct {
+	Code  int `json:"code" example:"200"`
+	Count int `json:"count" example:"980"`
+} // @name StatusCount
+
+// ErrorCount is how many requests of a load run got no response for a reason
+type ErrorCount struct {
+	Error string `json:"error" example:"no response within 30s"`
+	Count int    `json:"count" example:"20"`
+} // @name ErrorCount
+
+// NewLoadRunDTO creates a LoadRun DTO from models.LoadRun
+func NewLoadRunDTO(r models.LoadRun) LoadRun {
+	out := LoadRun{
+		ID:          r.ID,
+		WebhookID:   r.WebhookID,
+		RequestID:   r.RequestID,
+		Template:    r.Template,
+		Sign:        r.Sign,
+		Target:      r.Target,
+		Concurrency: r.Concurrency,
+		Rate:        r.Rate,
+		DurationMs:  r.DurationMs,
+		Count:       r.Count,
+		TimeoutMs:   r.TimeoutMs,
+		Status:      r.Status,
+		Requests:    r.Requests,
+		Succeeded:   r.Succeeded,
+		Failed:      r.Failed,
+		Errors:      r.Errors,
+		StatusCodes: make([]StatusCount, len(r.StatusCodes)),
+		ErrorCounts: make([]ErrorCount, len(r.ErrorCounts)),
+		MinMs:       r.MinMs,
+		MeanMs:      r.MeanMs,
+		P50Ms:       r.P50Ms,
+		P90Ms:       r.P90Ms,
+		P95Ms:       r.P95Ms,
+		P99Ms:       r.P99Ms,
+		MaxMs:       r.MaxMs,
+		ElapsedMs:   r.ElapsedMs,
+		Throughput:  r.Throughput,
+		Error:       r.Error,
+		CreatedAt:   r.CreatedAt,
+		FinishedAt:  r.FinishedAt,
+	}
+	for i, c := range r.StatusCodes {
+		out.StatusCodes[i] = StatusCount{Code: c.Code, Count: c.Count}
+	}
+	for i, c := range r.ErrorCounts {
+		out.ErrorCounts[i] = ErrorCount{Error: c.Error, Count: c.Count}
+	}
+	return out
+}
+
+// CallbackActionRequest makes a webhook call back after it receives a
+// request. Headers and body are templates rendered with the received request
+type CallbackActionRequest struct {
+	Name      string            `json:"name" example:"payment status"`
+	Enabled   *bool             `json:"enabled,omitempty"`                                    // default true
+	DelayMs   int64             `json:"delay_ms,omitempty" example:"2000"`                    // after the request is received; at most 3600000
+	Method    string            `json:"method,omitempty" example:"POST"`                      // default POST
+	URL       string            `json:"url,omitempty" example:"http://localhost:8080/status"` // used when url_from is empty or not in the request
+	URLFrom   string            `json:"url_from,omitempty" example:"body.callback_url"`       // path of the URL in the received request
+	Headers   map[string]string `json:"headers,omitempty"`                                    // templates
+	Body      string            `json:"body,omitempty" example:"{\"status\":\"done\"}"`       // template; JSON unless headers set a Content-Type
+	Sign      bool              `json:"sign,omitempty"`                                       // sign with the webhook's signing secret
+	TimeoutMs int64             `json:"timeout_ms,omitempty" example:"30000"`                 // default 30000, at most 120000
+} // @name CallbackActionRequest
+
+// CallbackAction makes a webhook call back after it receives a request
+type CallbackAction struct {
+	ID        string            `json:"id"`
+	WebhookID string            `json:"webhook_id"`
+	Name      string            `json:"name"`
+	Enabled   bool              `json:"enabled"`
+	DelayMs   int64             `json:"delay_ms"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url,omitempty"`
+	URLFrom   string            `json:"url_from,omitempty"`
+	Headers   map[string]string `json:"headers"`
+	Body      string            `json:"body"`
+	Sign      bool              `json:"sign"`
+	TimeoutMs int64             `json:"timeout_ms"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+} // @name CallbackAction
+
+// Callback is one call made, or to be made, by a callback action after a
+// request was received
+type Callback struct {
+	ID         string     `json:"id"`
+	WebhookID  string     `json:"webhook_id"`
+	ActionID   string     `json:"action_id"`
+	ActionName string     `json:"action_name"`
+	RequestID  string     `json:"request_id"` // the received request
+	Status     string     `json:"status" example:"scheduled" enums:"scheduled,succeeded,failed,cancelled"`
+	DueAt      time.Time  `json:"due_at"`
+	URL        string     `json:"url,omitempty"`        // as sent
+	AttemptID  string     `json:"attempt_id,omitempty"` // the replay attempt recording the exchange
+	StatusCode int        `json:"status_code,omitempty" example:"200"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	SentAt     *time.Time `json:"sent_at,omitempty"`
+} // @name Callback
+
+// NewCallbackActionDTO creates a CallbackAction DTO from models.CallbackAction
+func NewCallbackActionDTO(a models.CallbackAction) CallbackAction {
+	out := CallbackAction{
+		ID:        a.ID,
+		WebhookID: a.WebhookID,
+		Name:      a.Name,
+		Enabled:   a.Enabled,
+		DelayMs:   a.DelayMs,
+		Method:    a.Method,
+		URL:       a.URL,
+		URLFrom:   a.URLFrom,
+		Headers:   map[string]string{},
+		Body:      a.Body,
+		Sign:      a.Sign,
+		TimeoutMs: a.TimeoutMs,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+	for k, v := range a.Headers {
+		out.Headers[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+// NewCallbackDTO creates a Callback DTO from models.Callback
+func NewCallbackDTO(c models.Callback) Callback {
+	return Callback{
+		ID:         c.ID,
+		WebhookID:  c.WebhookID,
+		ActionID:   c.ActionID,
+		ActionName: c.ActionName,
+		RequestID:  c.RequestID,
+		Status:     c.Status,
+		DueAt:      c.DueAt,
+		URL:        c.URL,
+		AttemptID:  c.AttemptID,
+		StatusCode: c.StatusCode,
+		DurationMs: c.DurationMs,
+		Error:      c.Error,
+		CreatedAt:  c.CreatedAt,
+		SentAt:     c.SentAt,
+	}
+}
+
+// NewWebhookRequestDTO creates a WebhookRequest DTO from models.WebhookRequest
+func NewWebhookRequestDTO(wr models.WebhookRequest) WebhookRequest {
+	return WebhookRequest{
+		ID:         wr.ID,
+		WebhookID:  wr.WebhookID,
+		Method:     wr.Method,
+		Headers:    wr.Headers,
+		Query:      wr.Query,
+		Body:       wr.Body,
+		Pinned:     wr.Pinned,
+		Size:       wr.Size,
+		Source:     wr.Source,
+		Fault:      wr.Fault,
+		FaultDelay: wr.FaultDelay,
+		ScriptLog:  wr.ScriptLog,
+		ReceivedAt: wr.ReceivedAt,
+	}
+}
+
 // swagger:model
 type Webhook struct {
-	ID            string    `gorm:"primaryKey" json:"id"`
-	Title         string    `json:"title"`
-	ResponseCode  int       `json:"response_code"`
-	ResponseDelay uint      `json:"response_delay"` // milliseconds
-	ContentType   string    `json:"content_type"`
-	Payload       string    `json:"payload"`
-	NotifyOnEvent bool      `json:"notify_on_event"`
-	UserID        int       `json:"user_id"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	Requests      []models.WebhookRequest
+	ID              string            `gorm:"primaryKey" json:"id"`
+	Title           string            `json:"title"`
+	ResponseCode    int               `json:"response_code"`
+	ResponseDelay   uint              `json:"response_delay"` // milliseconds
+	ContentType     string            `json:"content_type"`
+	Payload         string            `json:"payload"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+	NotifyOnEvent   bool              `json:"notify_on_event"`
+	RetentionCount  uint              `json:"retention_count"`
+	RetentionDays   uint              `json:"retention_days"`
+	SigningScheme   string            `json:"signing_scheme"` // the secret is never returned
+	Faults          FaultConfig       `json:"faults"`
+	Script          string            `json:"script"`
+	UserID          int               `json:"user_id"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	Requests        []WebhookRequest  `json:"requests"`
 } // @name Webhook
 
 // Creates a new instance of Webhook DTO from models.Webhook
 func NewWebhookDTO(w models.Webhook) Webhook {
 	dto := Webhook{
-		ID:            w.ID,
-		Title:         w.Title,
-		ResponseCode:  w.ResponseCode,
-		ResponseDelay: w.ResponseDelay,
-		ContentType:   *w.ContentType,
-		Payload:       *w.Payload,
-		UserID:        w.UserID,
-		CreatedAt:     w.CreatedAt,
-		UpdatedAt:     w.UpdatedAt,
-		NotifyOnEvent: w.NotifyOnEvent,
-		Requests:      w.Requests,
+		ID:              w.ID,
+		Title:           w.Title,
+		ResponseCode:    w.ResponseCode,
+		ResponseDelay:   w.ResponseDelay,
+		UserID:          w.UserID,
+		CreatedAt:       w.CreatedAt,
+		UpdatedAt:       w.UpdatedAt,
+		NotifyOnEvent:   w.NotifyOnEvent,
+		RetentionCount:  w.RetentionCount,
+		RetentionDays:   w.RetentionDays,
+		SigningScheme:   w.SigningScheme,
+		Faults:          FaultConfig(w.Faults.Data()),
+		Script:          w.Script,
+		ResponseHeaders: map[string]string{},
+		Requests:        make([]WebhookRequest, 0, len(w.Requests)),
+	}
+	for k, v := range w.ResponseHeaders {
+		dto.ResponseHeaders[k] = fmt.Sprint(v)
+	}
+	if w.ContentType != nil {
+		dto.ContentType = *w.ContentType
+	}
+	if w.Payload != nil {
+		dto.Payload = *w.Payload
+	}
+	for _, wr := range w.Requests {
+		dto.Requests = append(dto.Requests, NewWebhookRequestDTO(wr))
 	}
 
 	return dto
@@ -0,0 +1,31 @@
+package dtos
+
+import "encoding/json"
+
+// Optional is a JSON field that tells apart "omitted", "null" and a value,
+// for PATCH bodies where omitted means "keep" and null means "reset".
+type Optional[T any] struct {
+	Set   bool // the field was present in the body
+	Null  bool // the field was present and null
+	Value T
+}
+
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	if string(b) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(b, &o.Value)
+}
+
+// Apply updates dst: omitted leaves it alone, null sets reset and a value sets the value.
+func (o Optional[T]) Apply(dst *T, reset T) {
+	switch {
+	case !o.Set:
+	case o.Null:
+		*dst = reset
+	default:
+		*dst = o.Value
+	}
+}
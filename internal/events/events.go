@@ -0,0 +1,134 @@
+// Package events is a library of provider webhook events to send from the
+// tester: a GitHub push, a Stripe checkout, a Shopify order and so on, each
+// with the headers the provider sends.
+//
+// Headers and bodies are text/template templates rendered with fresh IDs and
+// timestamps, so every send looks like a new delivery:
+//
+//	{{uuid}}     a random UUID
+//	{{id 24}}    24 random letters and digits
+//	{{digits 9}} 9 random digits
+//	{{hex 40}}   40 random hex digits
+//	{{unix}}     the current Unix time
+//	{{now}}      the current time in RFC 3339
+package events
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Template is an event as a provider sends it.
+type Template struct {
+	Name     string // like "github.push"
+	Title    string
+	Provider string
+	Scheme   string // the signing.Scheme the provider signs with, or ""
+	Method   string
+	Headers  map[string]string
+	Body     string
+}
+
+// Event is a rendered template, ready to edit and send.
+type Event struct {
+	Method  string
+	Headers map[string]string
+	Body    string
+}
+
+// Templates returns the library in display order.
+func Templates() []Template {
+	return append([]Template(nil), library...)
+}
+
+// Lookup returns the template called name.
+func Lookup(name string) (Template, bool) {
+	for _, t := range library {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// Render fills in the placeholders of t's headers and body for a delivery
+// at now.
+func (t Template) Render(now time.Time) (Event, error) {
+	ev := Event{Method: t.Method, Headers: make(map[string]string, len(t.Headers))}
+	funcs := Funcs(now)
+	for name, value := range t.Headers {
+		v, err := execute(t.Name+" "+name, value, funcs)
+		if err != nil {
+			return Event{}, err
+		}
+		ev.Headers[name] = v
+	}
+	body, err := execute(t.Name, t.Body, funcs)
+	if err != nil {
+		return Event{}, err
+	}
+	ev.Body = body
+	return ev, nil
+}
+
+// HeaderNames returns the names of the event's headers, sorted.
+func (e Event) HeaderNames() []string {
+	names := make([]string, 0, len(e.Headers))
+	for name := range e.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func execute(name, text string, funcs template.FuncMap) (string, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("events: %w", err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, nil); err != nil {
+		return "", fmt.Errorf("events: %w", err)
+	}
+	return b.String(), nil
+}
+
+const (
+	alnum = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	hex   = "0123456789abcdef"
+)
+
+// Funcs returns the placeholders of a template rendered at now.
+func Funcs(now time.Time) template.FuncMap {
+	now = now.UTC()
+	return template.FuncMap{
+		"uuid": func() string {
+			h := random(hex, 32)
+			return h[:8] + "-" + h[8:12] + "-4" + h[13:16] + "-" + string("89ab"[strings.IndexByte(hex, h[16])%4]) + h[17:20] + "-" + h[20:]
+		},
+		"id":     func(n int) string { return random(alnum, n) },
+		"digits": func(n int) string { return "1" + random("0123456789", n-1) },
+		"hex":    func(n int) string { return random(hex, n) },
+		"unix":   func() int64 { return now.Unix() },
+		"now":    func() string { return now.Format(time.RFC3339) },
+	}
+}
+
+func random(alphabet string, n int) string {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range b {
+		k, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = alphabet[k.Int64()]
+	}
+	return string(b)
+}
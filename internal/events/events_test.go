@@ -0,0 +1,56 @@
+package events_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+	"webhook-tester/internal/events"
+	"webhook-tester/internal/signing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderLibrary(t *testing.T) {
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	for _, tmpl := range events.Templates() {
+		ev, err := tmpl.Render(now)
+		require.NoError(t, err, tmpl.Name)
+		assert.True(t, json.Valid([]byte(ev.Body)), "%s renders JSON", tmpl.Name)
+		assert.NotContains(t, ev.Body, "{{", tmpl.Name)
+		assert.NotEmpty(t, ev.Headers["Content-Type"], tmpl.Name)
+		if tmpl.Scheme != "" {
+			assert.True(t, signing.Valid(tmpl.Scheme), tmpl.Name)
+		}
+	}
+}
+
+func TestRenderFreshValues(t *testing.T) {
+	tmpl, ok := events.Lookup("github.push")
+	require.True(t, ok)
+	now := time.Now()
+	a, err := tmpl.Render(now)
+	require.NoError(t, err)
+	b, err := tmpl.Render(now)
+	require.NoError(t, err)
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	assert.Regexp(t, uuid, a.Headers["X-GitHub-Delivery"])
+	assert.NotEqual(t, a.Headers["X-GitHub-Delivery"], b.Headers["X-GitHub-Delivery"], "every render is a new delivery")
+	assert.Equal(t, "push", a.Headers["X-GitHub-Event"])
+	assert.Equal(t, "POST", a.Method)
+
+	var push struct {
+		After   string `json:"after"`
+		Commits []struct {
+			Timestamp string `json:"timestamp"`
+		} `json:"commits"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(a.Body), &push))
+	assert.Regexp(t, `^[0-9a-f]{40}$`, push.After)
+	assert.Equal(t, now.UTC().Format(time.RFC3339), push.Commits[0].Timestamp)
+
+	_, ok = events.Lookup("github.nope")
+	assert.False(t, ok)
+}
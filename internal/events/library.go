@@ -0,0 +1,238 @@
+package events
+
+import "webhook-tester/internal/signing"
+
+var library = []Template{
+	{
+		Name:     "github.push",
+		Title:    "GitHub push",
+		Provider: "GitHub",
+		Scheme:   signing.GitHub,
+		Method:   "POST",
+		Headers: map[string]string{
+			"Content-Type":                           "application/json",
+			"User-Agent":                             "GitHub-Hookshot/{{hex 7}}",
+			"X-GitHub-Event":                         "push",
+			"X-GitHub-Delivery":                      "{{uuid}}",
+			"X-GitHub-Hook-ID":                       "{{digits 9}}",
+			"X-GitHub-Hook-Installation-Target-ID":   "{{digits 9}}",
+			"X-GitHub-Hook-Installation-Target-Type": "repository",
+		},
+		Body: `{
+  "ref": "refs/heads/main",
+  "before": "{{hex 40}}",
+  "after": "{{hex 40}}",
+  "created": false,
+  "deleted": false,
+  "forced": false,
+  "compare": "https://github.com/octo-org/octo-repo/compare/{{hex 12}}...{{hex 12}}",
+  "commits": [
+    {
+      "id": "{{hex 40}}",
+      "tree_id": "{{hex 40}}",
+      "distinct": true,
+      "message": "Fix checkout rounding",
+      "timestamp": "{{now}}",
+      "url": "https://github.com/octo-org/octo-repo/commit/{{hex 40}}",
+      "author": {"name": "Mona Lisa Octocat", "email": "mona@github.com", "username": "octocat"},
+      "committer": {"name": "Mona Lisa Octocat", "email": "mona@github.com", "username": "octocat"},
+      "added": [],
+      "removed": [],
+      "modified": ["checkout/total.go"]
+    }
+  ],
+  "repository": {
+    "id": {{digits 9}},
+    "name": "octo-repo",
+    "full_name": "octo-org/octo-repo",
+    "private": true,
+    "owner": {"login": "octo-org", "id": {{digits 8}}, "type": "Organization"},
+    "html_url": "https://github.com/octo-org/octo-repo",
+    "default_branch": "main"
+  },
+  "pusher": {"name": "octocat", "email": "mona@github.com"},
+  "sender": {"login": "octocat", "id": 583231, "type": "User"}
+}
+`,
+	},
+	{
+		Name:     "github.pull_request",
+		Title:    "GitHub pull request opened",
+		Provider: "GitHub",
+		Scheme:   signing.GitHub,
+		Method:   "POST",
+		Headers: map[string]string{
+			"Content-Type":                           "application/json",
+			"User-Agent":                             "GitHub-Hookshot/{{hex 7}}",
+			"X-GitHub-Event":                         "pull_request",
+			"X-GitHub-Delivery":                      "{{uuid}}",
+			"X-GitHub-Hook-ID":                       "{{digits 9}}",
+			"X-GitHub-Hook-Installation-Target-ID":   "{{digits 9}}",
+			"X-GitHub-Hook-Installation-Target-Type": "repository",
+		},
+		Body: `{
+  "action": "opened",
+  "number": 42,
+  "pull_request": {
+    "id": {{digits 10}},
+    "number": 42,
+    "state": "open",
+    "title": "Fix checkout rounding",
+    "body": "Totals are rounded once, after tax.",
+    "draft": false,
+    "html_url": "https://github.com/octo-org/octo-repo/pull/42",
+    "user": {"login": "octocat", "id": 583231, "type": "User"},
+    "created_at": "{{now}}",
+    "updated_at": "{{now}}",
+    "head": {"ref": "fix-rounding", "sha": "{{hex 40}}"},
+    "base": {"ref": "main", "sha": "{{hex 40}}"},
+    "merged": false,
+    "commits": 1,
+    "additions": 12,
+    "deletions": 3,
+    "changed_files": 2
+  },
+  "repository": {
+    "id": {{digits 9}},
+    "name": "octo-repo",
+    "full_name": "octo-org/octo-repo",
+    "private": true,
+    "owner": {"login": "octo-org", "id": {{digits 8}}, "type": "Organization"},
+    "html_url": "https://github.com/octo-org/octo-repo",
+    "default_branch": "main"
+  },
+  "sender": {"login": "octocat", "id": 583231, "type": "User"}
+}
+`,
+	},
+	{
+		Name:     "stripe.checkout.session.completed",
+		Title:    "Stripe checkout.session.completed",
+		Provider: "Stripe",
+		Scheme:   signing.Stripe,
+		Method:   "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/json; charset=utf-8",
+			"User-Agent":   "Stripe/1.0 (+https://stripe.com/docs/webhooks)",
+		},
+		Body: `{
+  "id": "evt_{{id 24}}",
+  "object": "event",
+  "api_version": "2024-06-20",
+  "created": {{unix}},
+  "type": "checkout.session.completed",
+  "livemode": false,
+  "pending_webhooks": 1,
+  "request": {"id": null, "idempotency_key": null},
+  "data": {
+    "object": {
+      "id": "cs_test_{{id 58}}",
+      "object": "checkout.session",
+      "amount_subtotal": 2000,
+      "amount_total": 2000,
+      "currency": "usd",
+      "customer": "cus_{{id 14}}",
+      "customer_details": {"email": "jenny.rosen@example.com", "name": "Jenny Rosen"},
+      "mode": "payment",
+      "payment_intent": "pi_{{id 24}}",
+      "payment_status": "paid",
+      "status": "complete",
+      "created": {{unix}},
+      "metadata": {"order_id": "{{digits 6}}"}
+    }
+  }
+}
+`,
+	},
+	{
+		Name:     "shopify.orders.create",
+		Title:    "Shopify orders/create",
+		Provider: "Shopify",
+		Scheme:   signing.Shopify,
+		Method:   "POST",
+		Headers: map[string]string{
+			"Content-Type":           "application/json",
+			"User-Agent":             "Shopify-Captain-Hook",
+			"X-Shopify-Topic":        "orders/create",
+			"X-Shopify-Shop-Domain":  "example.myshopify.com",
+			"X-Shopify-API-Version":  "2024-07",
+			"X-Shopify-Webhook-Id":   "{{uuid}}",
+			"X-Shopify-Event-Id":     "{{uuid}}",
+			"X-Shopify-Triggered-At": "{{now}}",
+		},
+		Body: `{
+  "id": {{digits 13}},
+  "admin_graphql_api_id": "gid://shopify/Order/{{digits 13}}",
+  "name": "#1001",
+  "order_number": 1001,
+  "email": "jenny.rosen@example.com",
+  "created_at": "{{now}}",
+  "currency": "USD",
+  "financial_status": "paid",
+  "fulfillment_status": null,
+  "subtotal_price": "18.00",
+  "total_tax": "2.00",
+  "total_price": "20.00",
+  "customer": {"id": {{digits 13}}, "email": "jenny.rosen@example.com", "first_name": "Jenny", "last_name": "Rosen"},
+  "line_items": [
+    {"id": {{digits 13}}, "product_id": {{digits 13}}, "title": "Coffee beans", "sku": "BEANS-1KG", "quantity": 1, "price": "18.00"}
+  ],
+  "shipping_address": {"address1": "1 Main St", "city": "Springfield", "zip": "12345", "country_code": "US"}
+}
+`,
+	},
+	{
+		Name:     "slack.event_callback",
+		Title:    "Slack message event",
+		Provider: "Slack",
+		Scheme:   signing.Slack,
+		Method:   "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+			"User-Agent":   "Slackbot 1.0 (+https://api.slack.com/robots)",
+		},
+		Body: `{
+  "token": "{{id 24}}",
+  "team_id": "T061EG9R6",
+  "api_app_id": "A0MDYCDME",
+  "type": "event_callback",
+  "event_id": "Ev{{id 10}}",
+  "event_time": {{unix}},
+  "event": {
+    "type": "message",
+    "channel": "C{{id 10}}",
+    "channel_type": "channel",
+    "user": "U061F7AUR",
+    "text": "Deploy finished :rocket:",
+    "ts": "{{unix}}.000100",
+    "event_ts": "{{unix}}.000100"
+  },
+  "authorizations": [{"team_id": "T061EG9R6", "user_id": "U0JD3BPNC", "is_bot": true}]
+}
+`,
+	},
+	{
+		Name:     "cloudevents",
+		Title:    "CloudEvents envelope",
+		Provider: "CloudEvents",
+		Method:   "POST",
+		Headers: map[string]string{
+			"Content-Type": "application/cloudevents+json; charset=utf-8",
+		},
+		Body: `{
+  "specversion": "1.0",
+  "id": "{{uuid}}",
+  "source": "/orders",
+  "type": "com.example.order.created",
+  "subject": "order/{{digits 6}}",
+  "time": "{{now}}",
+  "datacontenttype": "application/json",
+  "data": {
+    "order_id": "{{digits 6}}",
+    "total": 20.00,
+    "currency": "USD"
+  }
+}
+`,
+	},
+}
@@ -1,12 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"html/template"
 	"log"
 	"net/http"
 	"os"
-	"strings"
 	"webhook-tester/internal/metrics"
+	"webhook-tester/internal/problem"
 	"webhook-tester/internal/service"
 	"webhook-tester/internal/utils"
 
@@ -67,7 +68,7 @@ func (h *AuthHandler) renderRegisterForm(w http.ResponseWriter, r *http.Request,
 
 func (h *AuthHandler) RegisterPost(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
-		http.Error(w, "failed to parse form", http.StatusInternalServerError)
+		renderError(w, r, h.logger, problem.BadRequest(err.Error()))
 		return
 	}
 
@@ -95,7 +96,7 @@ func (h *AuthHandler) RegisterPost(w http.ResponseWriter, r *http.Request) {
 	_, err := h.auth.Register(email, password, fullName)
 	if err != nil {
 		// Duplicate email?
-		if strings.Contains(err.Error(), "email already taken") {
+		if errors.Is(err, service.ErrConflict) {
 			h.renderRegisterForm(w, r, &RegisterPageData{
 				Error:     "That email is already registered",
 				FullName:  fullName,
@@ -105,8 +106,7 @@ func (h *AuthHandler) RegisterPost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// Otherwise: unexpected
-		h.logger.Printf("error registering user: %v", err)
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
 		return
 	}
 
@@ -124,7 +124,7 @@ func (h *AuthHandler) LoginGet(w http.ResponseWriter, r *http.Request) {
 
 func (h *AuthHandler) LoginPost(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
-		http.Error(w, "unable to parse form", http.StatusInternalServerError)
+		renderError(w, r, h.logger, problem.BadRequest(err.Error()))
 		return
 	}
 	email := r.FormValue("email")
@@ -142,8 +142,7 @@ func (h *AuthHandler) LoginPost(w http.ResponseWriter, r *http.Request) {
 
 	err = h.auth.CreateSession(w, r, user)
 	if err != nil {
-		h.logger.Printf("error creating session: %v", err)
-		http.Error(w, "unable to save session", http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
 	}
 
 	if c, err := r.Cookie(sessionIdName); err == nil {
@@ -177,7 +176,7 @@ func (h *AuthHandler) ForgotPasswordGet(w http.ResponseWriter, r *http.Request)
 // ForgotPasswordPost handles the form submission.
 func (h *AuthHandler) ForgotPasswordPost(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
-		http.Error(w, "unable to parse form", http.StatusInternalServerError)
+		renderError(w, r, h.logger, problem.BadRequest(err.Error()))
 		return
 	}
 
@@ -230,7 +229,7 @@ func (h *AuthHandler) ResetPasswordGet(w http.ResponseWriter, r *http.Request) {
 }
 func (h *AuthHandler) ResetPasswordPost(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
-		http.Error(w, "unable to parse form", http.StatusInternalServerError)
+		renderError(w, r, h.logger, problem.BadRequest(err.Error()))
 		return
 	}
 
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"gorm.io/datatypes"
+)
+
+// CreateCallbackActionApi adds a callback action to a webhook
+// @Summary     Create callback action
+// @Description Makes the webhook call back after it receives a request, the way asynchronous APIs acknowledge a call at once and report the outcome later. Every request the webhook receives schedules a callback from each enabled action, sent delay_ms later to the URL found at url_from in the request, or to url. Headers and body are Go templates: besides the placeholders of events, such as uuid and now, the field helper inserts a value of the received request by path (field "body.order.id") and the json helper inserts one as JSON (json "body.order"), each written inside double braces. Each callback is recorded as a replay of the received request. Callbacks carry the X-Webhook-Tester-Callback header and requests with it trigger no callbacks
+// @Tags        Callbacks
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id      path  string                      true  "Webhook ID"
+// @Param       action  body  dtos.CallbackActionRequest  true  "Callback action"
+// @Success     201  {object}  dtos.CallbackAction
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /webhooks/{id}/callback-actions [post]
+func (h *WebhookRequestApiHandler) CreateCallbackActionApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.CallbackActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	a := callbackAction(in)
+	if err := h.Callbacks.CreateAction(webhook, &a); err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusCreated, dtos.NewCallbackActionDTO(a))
+}
+
+// ListCallbackActionsApi lists the callback actions of a webhook
+// @Summary     List callback actions
+// @Description Lists the callback actions of a webhook, oldest first
+// @Tags        Callbacks
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     200  {array}   dtos.CallbackAction
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/callback-actions [get]
+func (h *WebhookRequestApiHandler) ListCallbackActionsApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	actions, err := h.Callbacks.ListActions(webhook.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.CallbackAction, 0, len(actions))
+	for _, a := range actions {
+		out = append(out, dtos.NewCallbackActionDTO(a))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetCallbackActionApi gets a callback action
+// @Summary     Get callback action
+// @Description Gets a callback action of a webhook
+// @Tags        Callbacks
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id        path  string  true  "Webhook ID"
+// @Param       actionID  path  string  true  "Callback action ID"
+// @Success     200  {object}  dtos.CallbackAction
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/callback-actions/{actionID} [get]
+func (h *WebhookRequestApiHandler) GetCallbackActionApi(w http.ResponseWriter, r *http.Request) {
+	_, a, ok := h.ownedCallbackAction(w, r)
+	if !ok {
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewCallbackActionDTO(*a))
+}
+
+// UpdateCallbackActionApi replaces a callback action
+// @Summary     Update callback action
+// @Description Replaces a callback action. Callbacks already scheduled are sent as the action was
+// @Tags        Callbacks
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id        path  string                      true  "Webhook ID"
+// @Param       actionID  path  string                      true  "Callback action ID"
+// @Param       action    body  dtos.CallbackActionRequest  true  "Callback action"
+// @Success     200  {object}  dtos.CallbackAction
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /webhooks/{id}/callback-actions/{actionID} [put]
+func (h *WebhookRequestApiHandler) UpdateCallbackActionApi(w http.ResponseWriter, r *http.Request) {
+	webhook, existing, ok := h.ownedCallbackAction(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.CallbackActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	a := callbackAction(in)
+	a.ID = existing.ID
+	if err := h.Callbacks.UpdateAction(webhook, &a); err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewCallbackActionDTO(a))
+}
+
+// DeleteCallbackActionApi deletes a callback action
+// @Summary     Delete callback action
+// @Description Deletes a callback action and cancels its scheduled callbacks. Callbacks already sent are kept
+// @Tags        Callbacks
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id        path  string  true  "Webhook ID"
+// @Param       actionID  path  string  true  "Callback action ID"
+// @Success     204  {string}  string  "No Content"
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/callback-actions/{actionID} [delete]
+func (h *WebhookRequestApiHandler) DeleteCallbackActionApi(w http.ResponseWriter, r *http.Request) {
+	_, a, ok := h.ownedCallbackAction(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Callbacks.DeleteAction(a.ID); err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListCallbacksApi lists the callbacks of a webhook
+// @Summary     List callbacks
+// @Description Lists the latest 100 callbacks of a webhook, or all callbacks of one received request, newest first
+// @Tags        Callbacks
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path   string  true   "Webhook ID"
+// @Param       request_id  query  string  false  "Only callbacks of this received request"
+// @Success     200  {array}   dtos.Callback
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/callbacks [get]
+func (h *WebhookRequestApiHandler) ListCallbacksApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	var list []models.Callback
+	var err error
+	if requestID := r.URL.Query().Get("request_id"); requestID != "" {
+		list, err = h.Callbacks.ListByRequest(requestID)
+	} else {
+		list, err = h.Callbacks.List(webhook.ID)
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.Callback, 0, len(list))
+	for _, c := range list {
+		if c.WebhookID == webhook.ID {
+			out = append(out, dtos.NewCallbackDTO(c))
+		}
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetCallbackApi gets a callback
+// @Summary     Get callback
+// @Description Gets a callback with its status. Once sent, attempt_id names the replay attempt holding the full exchange
+// @Tags        Callbacks
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       callbackID  path  string  true  "Callback ID"
+// @Success     200  {object}  dtos.Callback
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/callbacks/{callbackID} [get]
+func (h *WebhookRequestApiHandler) GetCallbackApi(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.ownedCallback(w, r)
+	if !ok {
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewCallbackDTO(*c))
+}
+
+// CancelCallbackApi cancels a scheduled callback
+// @Summary     Cancel callback
+// @Description Stops a scheduled callback from being sent
+// @Tags        Callbacks
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       callbackID  path  string  true  "Callback ID"
+// @Success     200  {object}  dtos.Callback
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Router      /webhooks/{id}/callbacks/{callbackID}/cancel [post]
+func (h *WebhookRequestApiHandler) CancelCallbackApi(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.ownedCallback(w, r)
+	if !ok {
+		return
+	}
+	c, err := h.Callbacks.Cancel(c.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewCallbackDTO(*c))
+}
+
+// ownedCallbackAction loads the {actionID} callback action, which must belong to the {id} webhook.
+func (h *WebhookRequestApiHandler) ownedCallbackAction(w http.ResponseWriter, r *http.Request) (*models.Webhook, *models.CallbackAction, bool) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	a, err := h.Callbacks.GetAction(chi.URLParam(r, "actionID"))
+	if err == nil && a.WebhookID != webhook.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "callback action not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, nil, false
+	}
+	return webhook, a, true
+}
+
+// ownedCallback loads the {callbackID} callback, which must belong to the {id} webhook.
+func (h *WebhookRequestApiHandler) ownedCallback(w http.ResponseWriter, r *http.Request) (*models.Callback, bool) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return nil, false
+	}
+	c, err := h.Callbacks.Get(chi.URLParam(r, "callbackID"))
+	if err == nil && c.WebhookID != webhook.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "callback not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, false
+	}
+	return c, true
+}
+
+// callbackAction builds a callback action from an API request.
+func callbackAction(in dtos.CallbackActionRequest) models.CallbackAction {
+	a := models.CallbackAction{
+		Name:      in.Name,
+		Enabled:   in.Enabled == nil || *in.Enabled,
+		DelayMs:   in.DelayMs,
+		Method:    in.Method,
+		URL:       in.URL,
+		URLFrom:   in.URLFrom,
+		Headers:   datatypes.JSONMap{},
+		Body:      in.Body,
+		Sign:      in.Sign,
+		TimeoutMs: in.TimeoutMs,
+	}
+	for k, v := range in.Headers {
+		a.Headers[k] = v
+	}
+	return a
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+	"gorm.io/datatypes"
+)
+
+// callbackForm is the callback action form. ID is empty for a new action;
+// Headers holds one "Name: value" pair per line.
+type callbackForm struct {
+	ID      string
+	Name    string
+	Enabled bool
+	Delay   string
+	Method  string
+	URL     string
+	URLFrom string
+	Headers string
+	Body    string
+	Sign    bool
+	Timeout string
+	Error   string
+	Errors  map[string]string
+}
+
+// newCallbackForm fills the form with a, or with the defaults of a new
+// action when a is nil.
+func newCallbackForm(a *models.CallbackAction) callbackForm {
+	if a == nil {
+		return callbackForm{Enabled: true, Method: http.MethodPost, URLFrom: "body.callback_url"}
+	}
+	names := make([]string, 0, len(a.Headers))
+	for name := range a.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %v", name, a.Headers[name]))
+	}
+	f := callbackForm{
+		ID:      a.ID,
+		Name:    a.Name,
+		Enabled: a.Enabled,
+		Delay:   strconv.FormatInt(a.DelayMs, 10),
+		Method:  a.Method,
+		URL:     a.URL,
+		URLFrom: a.URLFrom,
+		Headers: strings.Join(lines, "\n"),
+		Body:    a.Body,
+		Sign:    a.Sign,
+	}
+	if a.TimeoutMs > 0 {
+		f.Timeout = strconv.FormatInt(a.TimeoutMs, 10)
+	}
+	return f
+}
+
+// parseCallbackForm reads the callback action form into an action.
+func parseCallbackForm(r *http.Request) (callbackForm, models.CallbackAction, error) {
+	f := callbackForm{
+		ID:      r.PostFormValue("action_id"),
+		Name:    r.PostFormValue("name"),
+		Enabled: r.PostFormValue("enabled") != "",
+		Delay:   strings.TrimSpace(r.PostFormValue("delay_ms")),
+		Method:  strings.TrimSpace(r.PostFormValue("method")),
+		URL:     strings.TrimSpace(r.PostFormValue("url")),
+		URLFrom: strings.TrimSpace(r.PostFormValue("url_from")),
+		Headers: r.PostFormValue("headers"),
+		Body:    r.PostFormValue("body"),
+		Sign:    r.PostFormValue("sign") != "",
+		Timeout: strings.TrimSpace(r.PostFormValue("timeout_ms")),
+	}
+	a := models.CallbackAction{
+		ID:      f.ID,
+		Name:    f.Name,
+		Enabled: f.Enabled,
+		Method:  f.Method,
+		URL:     f.URL,
+		URLFrom: f.URLFrom,
+		Headers: datatypes.JSONMap{},
+		// browsers send textarea line breaks as CRLF
+		Body: strings.ReplaceAll(f.Body, "\r\n", "\n"),
+		Sign: f.Sign,
+	}
+	for _, line := range formLines(f.Headers) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return f, a, problem.BadRequest(fmt.Sprintf("headers: %q must look like Name: value", line))
+		}
+		a.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	verr := &service.ValidationError{Fields: map[string]string{}}
+	number := func(name, value string) int64 {
+		if value == "" {
+			return 0
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			verr.Fields[name] = "must be a whole number"
+		}
+		return n
+	}
+	a.DelayMs = number("delay_ms", f.Delay)
+	a.TimeoutMs = number("timeout_ms", f.Timeout)
+	if len(verr.Fields) > 0 {
+		return f, a, verr
+	}
+	return f, a, nil
+}
+
+// Callbacks shows the callback actions and recent callbacks of the {id}
+// webhook, with the form set to edit the action given by the edit query
+// parameter or to add one.
+func (h *WebhookRequestHandler) Callbacks(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	form := newCallbackForm(nil)
+	if id := r.URL.Query().Get("edit"); id != "" {
+		a, err := h.callbackSvc.GetAction(id)
+		if err == nil && a.WebhookID != wh.ID {
+			err = problem.New(http.StatusNotFound, problem.CodeNotFound, "callback action not found")
+		}
+		if err != nil {
+			renderError(w, r, h.logger, err)
+			return
+		}
+		form = newCallbackForm(a)
+	}
+	h.renderCallbacks(w, r, http.StatusOK, wh, form)
+}
+
+// SaveCallbackAction adds the action of the form to the {id} webhook, or
+// updates it when the form edits one.
+func (h *WebhookRequestHandler) SaveCallbackAction(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckAccess(wh, userID)
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	form, a, err := parseCallbackForm(r)
+	if err == nil {
+		if a.ID == "" {
+			err = h.callbackSvc.CreateAction(wh, &a)
+		} else if existing, gerr := h.callbackSvc.GetAction(a.ID); gerr != nil || existing.WebhookID != wh.ID {
+			err = problem.New(http.StatusNotFound, problem.CodeNotFound, "callback action not found")
+		} else {
+			err = h.callbackSvc.UpdateAction(wh, &a)
+		}
+	}
+	if err != nil {
+		status, ok := formError(err, &form.Error, &form.Errors)
+		if !ok {
+			renderError(w, r, h.logger, err)
+			return
+		}
+		h.renderCallbacks(w, r, status, wh, form)
+		return
+	}
+	http.Redirect(w, r, "/callbacks/"+wh.ID, http.StatusSeeOther)
+}
+
+// ControlCallbackAction enables, disables or deletes a callback action of
+// the {id} webhook, as the {action} path parameter says.
+func (h *WebhookRequestHandler) ControlCallbackAction(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckAccess(wh, userID)
+	}
+	var a *models.CallbackAction
+	if err == nil {
+		a, err = h.callbackSvc.GetAction(chi.URLParam(r, "actionID"))
+	}
+	if err == nil && a.WebhookID != wh.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "callback action not found")
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	switch chi.URLParam(r, "action") {
+	case "enable", "disable":
+		a.Enabled = chi.URLParam(r, "action") == "enable"
+		err = h.callbackSvc.UpdateAction(wh, a)
+	case "delete":
+		err = h.callbackSvc.DeleteAction(a.ID)
+	default:
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "unknown action")
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	http.Redirect(w, r, "/callbacks/"+wh.ID, http.StatusSeeOther)
+}
+
+// CancelCallback stops a scheduled callback of the {id} webhook.
+func (h *WebhookRequestHandler) CancelCallback(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckAccess(wh, userID)
+	}
+	var c *models.Callback
+	if err == nil {
+		c, err = h.callbackSvc.Get(chi.URLParam(r, "callbackID"))
+	}
+	if err == nil && c.WebhookID != wh.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "callback not found")
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	// a callback sent in the meantime shows its outcome
+	if _, err := h.callbackSvc.Cancel(c.ID); err != nil && problem.From(err).Status == http.StatusInternalServerError {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	http.Redirect(w, r, "/callbacks/"+wh.ID, http.StatusSeeOther)
+}
+
+// renderCallbacks renders the callbacks page with the given form.
+func (h *WebhookRequestHandler) renderCallbacks(w http.ResponseWriter, r *http.Request, status int, wh *models.Webhook, form callbackForm) {
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	actions, err := h.callbackSvc.ListActions(wh.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	callbacks, err := h.callbackSvc.List(wh.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year       int
+		User       models.User
+		Webhooks   []models.Webhook
+		Webhook    *models.Webhook
+		Form       callbackForm
+		Actions    []models.CallbackAction
+		Callbacks  []models.Callback
+		MaxActions int
+		CSRFField  template.HTML
+	}{
+		Year:       time.Now().Year(),
+		User:       *user,
+		Webhooks:   list,
+		Webhook:    wh,
+		Form:       form,
+		Actions:    actions,
+		Callbacks:  callbacks,
+		MaxActions: service.MaxCallbackActions,
+		CSRFField:  csrf.TemplateField(r),
+	}
+	w.WriteHeader(status)
+	utils.RenderHtml(w, r, "callbacks", data)
+}
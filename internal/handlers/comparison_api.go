@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CompareRequestApi sends a request to two targets and diffs the responses
+// @Summary     Compare two targets
+// @Description Sends a captured request, unchanged, to a baseline and a candidate target at the same time and stores the differences between their responses: status, headers, and the body, key by key when both bodies are JSON. Paths in ignore are left out, as are the Date and Content-Length headers. Patterns look like headers.X-Request-Id, body.id, body.items[*].id or body.**.created_at. Both exchanges are also stored as replay attempts. When a target can't be reached the comparison is stored with error set
+// @Tags        Comparisons
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string               true  "Webhook ID"
+// @Param       requestID   path  string               true  "Request ID"
+// @Param       comparison  body  dtos.CompareRequest  true  "Targets and ignored paths"
+// @Success     201  {object}  dtos.Comparison
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID}/comparisons [post]
+func (h *WebhookRequestApiHandler) CompareRequestApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.CompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	c, err := h.Comparisons.Compare(r.Context(), wr, compareOptions(in))
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusCreated, dtos.NewComparisonDTO(*c))
+}
+
+// ListComparisonsApi lists the comparisons of a request
+// @Summary     List comparisons
+// @Description Lists the comparisons of a request, newest first, including those made by batches
+// @Tags        Comparisons
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       requestID   path  string  true  "Request ID"
+// @Success     200  {array}   dtos.Comparison
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID}/comparisons [get]
+func (h *WebhookRequestApiHandler) ListComparisonsApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.Comparisons.List(wr.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.Comparison, 0, len(list))
+	for _, c := range list {
+		out = append(out, dtos.NewComparisonDTO(c))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetComparisonApi gets a single comparison
+// @Summary     Get comparison
+// @Description Gets one comparison of a request with its differences
+// @Tags        Comparisons
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id            path  string  true  "Webhook ID"
+// @Param       requestID     path  string  true  "Request ID"
+// @Param       comparisonID  path  string  true  "Comparison ID"
+// @Success     200  {object}  dtos.Comparison
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID}/comparisons/{comparisonID} [get]
+func (h *WebhookRequestApiHandler) GetComparisonApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	c, err := h.Comparisons.Get(chi.URLParam(r, "comparisonID"))
+	if err == nil && c.RequestID != wr.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "comparison not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewComparisonDTO(*c))
+}
+
+// StartComparisonBatchApi starts a batch comparison
+// @Summary     Start a batch comparison
+// @Description Compares the webhook's requests matching the selection, oldest first, against a baseline and a candidate target in the background, as a single comparison would. Poll the batch for progress and its results for the paths that differ most often. A webhook runs one batch at a time
+// @Tags        Comparisons
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string                       true  "Webhook ID"
+// @Param       batch  body  dtos.ComparisonBatchRequest  true  "Selection, targets and ignored paths"
+// @Success     202  {object}  dtos.ComparisonBatch
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /webhooks/{id}/comparison-batches [post]
+func (h *WebhookRequestApiHandler) StartComparisonBatchApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.ComparisonBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	opts := service.ComparisonBatchOptions{
+		CompareOptions: compareOptions(dtos.CompareRequest{Baseline: in.Baseline, Candidate: in.Candidate, Ignore: in.Ignore, Resign: in.Resign, TimeoutMs: in.TimeoutMs}),
+		Filter:         repository.RequestFilter{IDs: in.IDs},
+		Concurrency:    in.Concurrency,
+	}
+	if in.Since != nil {
+		opts.Filter.Since = in.Since.UTC()
+	}
+	if in.Until != nil {
+		opts.Filter.Until = in.Until.UTC()
+	}
+	batch, err := h.Comparisons.StartBatch(webhook.ID, opts)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusAccepted, dtos.NewComparisonBatchDTO(*batch))
+}
+
+// ListComparisonBatchesApi lists the batch comparisons of a webhook
+// @Summary     List batch comparisons
+// @Description Lists the batch comparisons of a webhook, newest first
+// @Tags        Comparisons
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     200  {array}   dtos.ComparisonBatch
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/comparison-batches [get]
+func (h *WebhookRequestApiHandler) ListComparisonBatchesApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	batches, err := h.Comparisons.Batches(webhook.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.ComparisonBatch, 0, len(batches))
+	for _, b := range batches {
+		out = append(out, dtos.NewComparisonBatchDTO(b))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetComparisonBatchApi gets a batch comparison
+// @Summary     Get batch comparison
+// @Description Gets a batch comparison with its progress
+// @Tags        Comparisons
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id       path  string  true  "Webhook ID"
+// @Param       batchID  path  string  true  "Comparison batch ID"
+// @Success     200  {object}  dtos.ComparisonBatch
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/comparison-batches/{batchID} [get]
+func (h *WebhookRequestApiHandler) GetComparisonBatchApi(w http.ResponseWriter, r *http.Request) {
+	batch, ok := h.ownedBatch(w, r)
+	if !ok {
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewComparisonBatchDTO(*batch))
+}
+
+// GetComparisonBatchResultsApi gets the results of a batch comparison
+// @Summary     Get batch comparison results
+// @Description Gets the comparisons a batch has made so far, oldest first, and how many of them differ at each path, most frequent first
+// @Tags        Comparisons
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id       path  string  true  "Webhook ID"
+// @Param       batchID  path  string  true  "Comparison batch ID"
+// @Success     200  {object}  dtos.ComparisonBatchResults
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/comparison-batches/{batchID}/results [get]
+func (h *WebhookRequestApiHandler) GetComparisonBatchResultsApi(w http.ResponseWriter, r *http.Request) {
+	batch, ok := h.ownedBatch(w, r)
+	if !ok {
+		return
+	}
+	list, paths, err := h.Comparisons.BatchComparisons(batch.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewComparisonBatchResultsDTO(list, paths))
+}
+
+// ownedBatch loads the {batchID} comparison batch, which must belong to the {id} webhook.
+func (h *WebhookRequestApiHandler) ownedBatch(w http.ResponseWriter, r *http.Request) (*models.ComparisonBatch, bool) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return nil, false
+	}
+	batch, err := h.Comparisons.Batch(chi.URLParam(r, "batchID"))
+	if err == nil && batch.WebhookID != webhook.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "comparison batch not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, false
+	}
+	return batch, true
+}
+
+// compareOptions turns an API comparison body into service options.
+func compareOptions(in dtos.CompareRequest) service.CompareOptions {
+	return service.CompareOptions{
+		Baseline:  in.Baseline,
+		Candidate: in.Candidate,
+		Ignore:    in.Ignore,
+		Resign:    in.Resign,
+		Timeout:   time.Duration(in.TimeoutMs) * time.Millisecond,
+	}
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webhook-tester/internal/compare"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+)
+
+// compareForm is the comparison form of the request page and, with the
+// selection fields, of the batch comparison page. Ignore holds one pattern
+// per line.
+type compareForm struct {
+	Baseline    string
+	Candidate   string
+	Ignore      string
+	Resign      bool
+	Since       string
+	Until       string
+	IDs         []string
+	Concurrency string
+	Error       string
+	Errors      map[string]string
+}
+
+// parseCompareForm reads the targets and ignore patterns of a comparison form.
+func parseCompareForm(r *http.Request) (compareForm, service.CompareOptions) {
+	f := compareForm{
+		Baseline:  strings.TrimSpace(r.PostFormValue("baseline")),
+		Candidate: strings.TrimSpace(r.PostFormValue("candidate")),
+		Ignore:    r.PostFormValue("ignore"),
+		Resign:    r.PostFormValue("resign") != "",
+	}
+	opts := service.CompareOptions{Baseline: f.Baseline, Candidate: f.Candidate, Ignore: formLines(f.Ignore), Resign: f.Resign}
+	return f, opts
+}
+
+// parseComparisonBatchForm reads the batch comparison form into service options.
+func parseComparisonBatchForm(r *http.Request) (compareForm, service.ComparisonBatchOptions, error) {
+	_ = r.ParseForm()
+	f, compareOpts := parseCompareForm(r)
+	f.Since, f.Until = r.PostFormValue("since"), r.PostFormValue("until")
+	f.IDs = listParam(r.PostForm, "id")
+	f.Concurrency = strings.TrimSpace(r.PostFormValue("concurrency"))
+	opts := service.ComparisonBatchOptions{CompareOptions: compareOpts}
+	opts.Filter.IDs = f.IDs
+
+	verr := &service.ValidationError{Fields: map[string]string{}}
+	for _, p := range []struct {
+		name, value string
+		dst         *time.Time
+	}{{"since", f.Since, &opts.Filter.Since}, {"until", f.Until, &opts.Filter.Until}} {
+		if p.value == "" {
+			continue
+		}
+		t, err := time.Parse(formTime, p.value)
+		if err != nil {
+			verr.Fields[p.name] = "must be a date and time"
+		}
+		*p.dst = t
+	}
+	if f.Concurrency != "" {
+		n, err := strconv.Atoi(f.Concurrency)
+		if err != nil {
+			verr.Fields["concurrency"] = "must be a whole number"
+		}
+		opts.Concurrency = n
+	}
+	if len(verr.Fields) > 0 {
+		return f, opts, verr
+	}
+	return f, opts, nil
+}
+
+// formError shows a service error on a form, or reports that it can't.
+func formError(err error, msg *string, fields *map[string]string) (int, bool) {
+	p := problem.From(err)
+	if p.Status == http.StatusInternalServerError {
+		return p.Status, false
+	}
+	*msg, *fields = p.Detail, p.Errors
+	if len(p.Errors) > 0 {
+		*msg = ""
+	}
+	return p.Status, true
+}
+
+// differenceView is a compare.Difference with its values as JSON.
+type differenceView struct {
+	Path      string
+	Change    string
+	Baseline  string // empty when the baseline lacks the value
+	Candidate string // empty when the candidate lacks the value
+}
+
+// comparisonView is a comparison as the templates show it.
+type comparisonView struct {
+	models.Comparison
+	Diffs []differenceView
+}
+
+func newComparisonViews(list []models.Comparison) []comparisonView {
+	out := make([]comparisonView, len(list))
+	for i, c := range list {
+		out[i] = comparisonView{Comparison: c, Diffs: make([]differenceView, len(c.Differences))}
+		for j, d := range c.Differences {
+			out[i].Diffs[j] = differenceView{Path: d.Path, Change: d.Change, Baseline: jsonValue(d.Baseline), Candidate: jsonValue(d.Candidate)}
+		}
+	}
+	return out
+}
+
+func jsonValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// CompareRequest sends a stored request to the two targets of the comparison
+// form and records how the responses differ.
+func (h *WebhookRequestHandler) CompareRequest(w http.ResponseWriter, r *http.Request) {
+	reqEvent, wh, err := h.accessibleRequest(r)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	form, opts := parseCompareForm(r)
+	if _, err = h.comparisonSvc.Compare(r.Context(), reqEvent, opts); err != nil {
+		status, ok := formError(err, &form.Error, &form.Errors)
+		if !ok {
+			renderError(w, r, h.logger, err)
+			return
+		}
+		h.renderRequest(w, r, status, wh, reqEvent, newReplayForm(r, reqEvent, wh), form)
+		return
+	}
+
+	redirectURL := fmt.Sprintf("/requests/%s?address=%s#comparisons", reqEvent.ID, reqEvent.WebhookID)
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+// Comparisons shows the batch comparisons of the {id} webhook and the form
+// that starts one, preselecting the requests given by the id query parameters.
+func (h *WebhookRequestHandler) Comparisons(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	form := compareForm{IDs: listParam(r.URL.Query(), "id"), Resign: wh.SigningScheme != ""}
+	h.renderComparisons(w, r, http.StatusOK, wh, form)
+}
+
+// StartComparisonBatch starts a batch comparison of the {id} webhook's requests.
+func (h *WebhookRequestHandler) StartComparisonBatch(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckAccess(wh, userID)
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	form, opts, err := parseComparisonBatchForm(r)
+	var batch *models.ComparisonBatch
+	if err == nil {
+		batch, err = h.comparisonSvc.StartBatch(wh.ID, opts)
+	}
+	if err != nil {
+		status, ok := formError(err, &form.Error, &form.Errors)
+		if !ok {
+			renderError(w, r, h.logger, err)
+			return
+		}
+		h.renderComparisons(w, r, status, wh, form)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/comparisons/%s/%s", wh.ID, batch.ID), http.StatusSeeOther)
+}
+
+// renderComparisons renders the batch comparison page with the given form.
+func (h *WebhookRequestHandler) renderComparisons(w http.ResponseWriter, r *http.Request, status int, wh *models.Webhook, form compareForm) {
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	batches, err := h.comparisonSvc.Batches(wh.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year          int
+		User          models.User
+		Webhooks      []models.Webhook
+		Webhook       *models.Webhook
+		Form          compareForm
+		DefaultIgnore []string
+		Batches       []models.ComparisonBatch
+		CSRFField     template.HTML
+	}{
+		Year:          time.Now().Year(),
+		User:          *user,
+		Webhooks:      list,
+		Webhook:       wh,
+		Form:          form,
+		DefaultIgnore: compare.DefaultIgnore,
+		Batches:       batches,
+		CSRFField:     csrf.TemplateField(r),
+	}
+	w.WriteHeader(status)
+	utils.RenderHtml(w, r, "comparisons", data)
+}
+
+// ComparisonBatch shows the progress of a batch comparison, the paths that
+// differ most often and the result of each request.
+func (h *WebhookRequestHandler) ComparisonBatch(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	batch, err := h.comparisonSvc.Batch(chi.URLParam(r, "batchID"))
+	if err == nil && batch.WebhookID != wh.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "comparison batch not found")
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	comparisons, paths, err := h.comparisonSvc.BatchComparisons(batch.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year        int
+		User        models.User
+		Webhooks    []models.Webhook
+		Webhook     *models.Webhook
+		Batch       *models.ComparisonBatch
+		Paths       []compare.PathCount
+		Comparisons []comparisonView
+	}{
+		Year:        time.Now().Year(),
+		User:        *user,
+		Webhooks:    list,
+		Webhook:     wh,
+		Batch:       batch,
+		Paths:       paths,
+		Comparisons: newComparisonViews(comparisons),
+	}
+	utils.RenderHtml(w, r, "comparison", data)
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// StartDeliveryApi delivers an event with retries
+// @Summary     Deliver an event with retries
+// @Description Stores an event like the send endpoint does, then delivers it in the background the way a provider would: attempts that get no 2xx are retried on the policy's schedule until one succeeds, the attempts run out or the time budget is spent. Every attempt replays the same stored request, so IDs in the event stay the same; signed deliveries are signed afresh for each attempt. The "none" policy makes one attempt, like GitHub; "exponential" doubles the wait after each attempt, like Stripe; "custom" waits schedule_ms before each retry. Poll the delivery for its timeline
+// @Tags        Sender
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id        path  string                true  "Webhook ID"
+// @Param       delivery  body  dtos.DeliveryRequest  true  "Event, target and retry policy"
+// @Success     202  {object}  dtos.Delivery
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Failure     507  {object}  dtos.Problem
+// @Router      /webhooks/{id}/deliveries [post]
+func (h *WebhookRequestApiHandler) StartDeliveryApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.DeliveryRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	opts := service.DeliveryOptions{
+		SendOptions: service.SendOptions{
+			Target:   in.Target,
+			Template: in.Template,
+			Method:   in.Method,
+			Headers:  in.Headers,
+			Body:     in.Body,
+			Sign:     in.Sign,
+			Timeout:  time.Duration(in.TimeoutMs) * time.Millisecond,
+		},
+		Policy:         in.Policy,
+		MaxAttempts:    in.MaxAttempts,
+		InitialDelay:   time.Duration(in.InitialDelayMs) * time.Millisecond,
+		Budget:         time.Duration(in.BudgetMs) * time.Millisecond,
+		DeliveryHeader: in.DeliveryHeader,
+	}
+	for _, ms := range in.ScheduleMs {
+		opts.Schedule = append(opts.Schedule, time.Duration(ms)*time.Millisecond)
+	}
+	if opts.Target == "" {
+		opts.Target = webhookURL(r, webhook.ID)
+	}
+	d, err := h.Deliveries.Start(webhook, opts)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusAccepted, dtos.NewDeliveryDTO(*d))
+}
+
+// ListDeliveriesApi lists the deliveries of a webhook
+// @Summary     List deliveries
+// @Description Lists the deliveries of a webhook, newest first
+// @Tags        Sender
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     200  {array}   dtos.Delivery
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/deliveries [get]
+func (h *WebhookRequestApiHandler) ListDeliveriesApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.Deliveries.List(webhook.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.Delivery, 0, len(list))
+	for _, d := range list {
+		out = append(out, dtos.NewDeliveryDTO(d))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetDeliveryApi gets a delivery
+// @Summary     Get delivery
+// @Description Gets a delivery with its progress and, while retrying, when the next attempt is due
+// @Tags        Sender
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       deliveryID  path  string  true  "Delivery ID"
+// @Success     200  {object}  dtos.Delivery
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/deliveries/{deliveryID} [get]
+func (h *WebhookRequestApiHandler) GetDeliveryApi(w http.ResponseWriter, r *http.Request) {
+	d, ok := h.ownedDelivery(w, r)
+	if !ok {
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewDeliveryDTO(*d))
+}
+
+// ListDeliveryAttemptsApi lists the attempts of a delivery
+// @Summary     List delivery attempts
+// @Description Lists the attempts of a delivery in order: its timeline. Each links to the replay attempt holding the full exchange
+// @Tags        Sender
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       deliveryID  path  string  true  "Delivery ID"
+// @Success     200  {array}   dtos.DeliveryAttempt
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/deliveries/{deliveryID}/attempts [get]
+func (h *WebhookRequestApiHandler) ListDeliveryAttemptsApi(w http.ResponseWriter, r *http.Request) {
+	d, ok := h.ownedDelivery(w, r)
+	if !ok {
+		return
+	}
+	attempts, err := h.Deliveries.Attempts(d.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.DeliveryAttempt, 0, len(attempts))
+	for _, a := range attempts {
+		out = append(out, dtos.NewDeliveryAttemptDTO(a))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// CancelDeliveryApi cancels a delivery
+// @Summary     Cancel delivery
+// @Description Stops a delivery from making more attempts. An attempt in flight completes
+// @Tags        Sender
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       deliveryID  path  string  true  "Delivery ID"
+// @Success     200  {object}  dtos.Delivery
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Router      /webhooks/{id}/deliveries/{deliveryID}/cancel [post]
+func (h *WebhookRequestApiHandler) CancelDeliveryApi(w http.ResponseWriter, r *http.Request) {
+	d, ok := h.ownedDelivery(w, r)
+	if !ok {
+		return
+	}
+	d, err := h.Deliveries.Cancel(d.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewDeliveryDTO(*d))
+}
+
+// ownedDelivery loads the {deliveryID} delivery, which must belong to the {id} webhook.
+func (h *WebhookRequestApiHandler) ownedDelivery(w http.ResponseWriter, r *http.Request) (*models.Delivery, bool) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return nil, false
+	}
+	d, err := h.Deliveries.Get(chi.URLParam(r, "deliveryID"))
+	if err == nil && d.WebhookID != webhook.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "delivery not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, false
+	}
+	return d, true
+}
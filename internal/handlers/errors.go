@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"webhook-tester/internal/problem"
+)
+
+// renderError writes err as a problem response, logging errors that are not
+// domain errors since their details are hidden from the client.
+func renderError(w http.ResponseWriter, r *http.Request, l *log.Logger, err error) {
+	if problem.From(err).Status == http.StatusInternalServerError {
+		l.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+	}
+	problem.Write(w, r, err)
+}
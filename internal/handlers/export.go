@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"webhook-tester/internal/archive"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/service"
+)
+
+// exportRequests streams the requests of webhook as a download, in the format
+// and with the filters given by the query parameters. name names a Postman or
+// Insomnia collection.
+func exportRequests(w http.ResponseWriter, r *http.Request, l *log.Logger, svc *service.WebhookRequestService, webhook *models.Webhook) {
+	q := r.URL.Query()
+	format := archive.HAR
+	if f := q.Get("format"); f != "" {
+		var err error
+		if format, err = archive.ParseFormat(f); err != nil {
+			renderError(w, r, l, problem.BadRequest(err.Error()))
+			return
+		}
+	}
+	filter, err := requestFilter(q)
+	if err != nil {
+		renderError(w, r, l, err)
+		return
+	}
+
+	out := &writeTracker{w: w}
+	aw, err := archive.NewWriter(format, out, archive.Source{Webhook: webhook, BaseURL: os.Getenv("DOMAIN"), Name: q.Get("name")})
+	if err != nil {
+		renderError(w, r, l, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-requests.%s"`, webhook.ID, format.Ext()))
+
+	_, err = svc.Export(aw, webhook.ID, filter)
+	if err == nil {
+		err = aw.Close()
+	}
+	if err == nil {
+		return
+	}
+	if !out.written {
+		w.Header().Del("Content-Disposition")
+		renderError(w, r, l, err)
+		return
+	}
+	// the status line is gone; abort so the client sees a broken download
+	// instead of a complete-looking but truncated one
+	l.Printf("export of %s failed: %v", webhook.ID, err)
+	panic(http.ErrAbortHandler)
+}
+
+// requestFilter parses the since, until (RFC 3339), method, source and id
+// query parameters. method and id may be repeated or comma-separated.
+func requestFilter(q url.Values) (repository.RequestFilter, error) {
+	var f repository.RequestFilter
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, problem.BadRequest(fmt.Sprintf("%s: must be an RFC 3339 time such as 2025-04-01T12:00:00Z", p.name))
+		}
+		*p.dst = t.UTC()
+	}
+	for _, m := range listParam(q, "method") {
+		f.Methods = append(f.Methods, strings.ToUpper(m))
+	}
+	f.IDs = listParam(q, "id")
+	switch src := q.Get("source"); src {
+	case "", models.SourceLive, models.SourceImport, models.SourceSent:
+		f.Source = src
+	default:
+		return f, problem.BadRequest(`source: must be "live", "import" or "sent"`)
+	}
+	return f, nil
+}
+
+// listParam returns the values of a repeatable, comma-separated parameter.
+func listParam(q url.Values, name string) []string {
+	var out []string
+	for _, v := range q[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// writeTracker records whether anything reached the client.
+type writeTracker struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *writeTracker) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
@@ -16,23 +16,26 @@ import (
 )
 
 type HomeHandler struct {
-	webhookSvc *service.WebhookService
-	authSvc    *service.AuthService
-	Logger     *log.Logger
-	Metrics    metrics.Recorder
+	webhookSvc   *service.WebhookService
+	authSvc      *service.AuthService
+	retentionSvc *service.RetentionService
+	Logger       *log.Logger
+	Metrics      metrics.Recorder
 }
 
 func NewHomeHandler(
 	webhookSvc *service.WebhookService,
 	authSvc *service.AuthService,
+	retentionSvc *service.RetentionService,
 	l *log.Logger,
 	mr metrics.Recorder,
 ) *HomeHandler {
 	return &HomeHandler{
-		webhookSvc: webhookSvc,
-		authSvc:    authSvc,
-		Logger:     l,
-		Metrics:    mr,
+		webhookSvc:   webhookSvc,
+		authSvc:      authSvc,
+		retentionSvc: retentionSvc,
+		Logger:       l,
+		Metrics:      mr,
 	}
 }
 
@@ -43,6 +46,7 @@ type HomePageData struct {
 	Webhook         models.Webhook
 	ResponseHeaders string
 	RequestsCount   uint
+	Usage           service.Usage
 	Domain          string
 	Year            int
 }
@@ -86,8 +90,7 @@ func (h *HomeHandler) Home(w http.ResponseWriter, r *http.Request) {
 	if err != nil && userID == 0 {
 		defaultWhID, err := createDefaultWebhook(h.webhookSvc, h.Logger)
 		if err != nil {
-			h.Logger.Printf("Error creating default webhook: %v", err)
-			http.Error(w, "failed to create webhook", http.StatusInternalServerError)
+			renderError(w, r, h.Logger, err)
 			return
 		}
 		cookie = createDefaultWebhookCookie(defaultWhID, w)
@@ -140,7 +143,17 @@ func (h *HomeHandler) Home(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	user, _ := h.authSvc.GetCurrentUser(r)
+	user, err := h.authSvc.GetCurrentUser(r)
+	if err != nil {
+		user = &models.User{} // guest
+	}
+
+	var usage service.Usage
+	if userID != 0 {
+		if usage, err = h.retentionSvc.Usage(userID); err != nil {
+			h.Logger.Printf("error loading storage usage: %v", err)
+		}
+	}
 
 	// RenderHtml the home page
 	data := HomePageData{
@@ -150,6 +163,7 @@ func (h *HomeHandler) Home(w http.ResponseWriter, r *http.Request) {
 		Webhook:         activeWebhook,
 		ResponseHeaders: headersJSON,
 		RequestsCount:   uint(len(activeWebhook.Requests)),
+		Usage:           usage,
 		Domain:          os.Getenv("DOMAIN"),
 		Year:            time.Now().Year(),
 	}
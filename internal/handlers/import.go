@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"webhook-tester/internal/archive"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+)
+
+// maxImportSize bounds an imported HAR or JSONL file.
+const maxImportSize = 32 << 20
+
+// importRequests stores the requests of the HAR or JSONL document in body
+// under webhook. An empty format is detected from the document.
+func importRequests(
+	w http.ResponseWriter,
+	l *log.Logger,
+	body io.Reader,
+	format string,
+	svc *service.WebhookRequestService,
+	retentionSvc *service.RetentionService,
+	webhook *models.Webhook,
+) (int, error) {
+	br := bufio.NewReader(body)
+	var f archive.Format
+	if format != "" {
+		var err error
+		if f, err = archive.ParseFormat(format); err != nil {
+			return 0, problem.BadRequest(err.Error())
+		}
+	} else {
+		head, _ := br.Peek(512)
+		var ok bool
+		if f, ok = archive.Detect(head); !ok {
+			return 0, problem.BadRequest("can't tell the file format, expected a HAR or JSONL document")
+		}
+	}
+	ar, err := archive.NewReader(f, br)
+	if err != nil {
+		return 0, problem.BadRequest(err.Error())
+	}
+
+	n, err := svc.Import(ar, webhook.ID, func(size int64) error {
+		usage, err := retentionSvc.CheckQuota(webhook, size)
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			w.Header().Set("X-Storage-Quota-Limit", strconv.FormatInt(usage.Limit, 10))
+			w.Header().Set("X-Storage-Quota-Used", strconv.FormatInt(usage.Used, 10))
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if webhook.RetentionCount > 0 {
+		if _, err := retentionSvc.Enforce(webhook); err != nil {
+			l.Printf("error applying retention: %s", err)
+		}
+	}
+	return n, nil
+}
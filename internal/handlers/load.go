@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webhook-tester/internal/events"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+)
+
+// loadForm is the load run form. Source says whether RequestID or Template
+// is sent.
+type loadForm struct {
+	Source      string
+	RequestID   string
+	Template    string
+	Target      string
+	Sign        bool
+	Concurrency string
+	Rate        string
+	Duration    string
+	Count       string
+	Timeout     string
+	Error       string
+	Errors      map[string]string
+}
+
+// parseLoadForm reads the load run form into service options.
+func parseLoadForm(r *http.Request) (loadForm, service.LoadOptions, error) {
+	f := loadForm{
+		Source:      r.PostFormValue("source"),
+		RequestID:   strings.TrimSpace(r.PostFormValue("request_id")),
+		Template:    r.PostFormValue("template"),
+		Target:      strings.TrimSpace(r.PostFormValue("target")),
+		Sign:        r.PostFormValue("sign") != "",
+		Concurrency: strings.TrimSpace(r.PostFormValue("concurrency")),
+		Rate:        strings.TrimSpace(r.PostFormValue("rate")),
+		Duration:    strings.TrimSpace(r.PostFormValue("duration_ms")),
+		Count:       strings.TrimSpace(r.PostFormValue("count")),
+		Timeout:     strings.TrimSpace(r.PostFormValue("timeout_ms")),
+	}
+	opts := service.LoadOptions{Target: f.Target, Sign: f.Sign}
+	// the field of the other source is left over when the source changes
+	if f.Source == "template" {
+		opts.Template = f.Template
+	} else {
+		opts.RequestID = f.RequestID
+	}
+
+	verr := &service.ValidationError{Fields: map[string]string{}}
+	number := func(name, value string) int {
+		if value == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			verr.Fields[name] = "must be a whole number"
+		}
+		return n
+	}
+	opts.Concurrency = number("concurrency", f.Concurrency)
+	opts.Duration = time.Duration(number("duration_ms", f.Duration)) * time.Millisecond
+	opts.Count = number("count", f.Count)
+	opts.Timeout = time.Duration(number("timeout_ms", f.Timeout)) * time.Millisecond
+	if f.Rate != "" {
+		rate, err := strconv.ParseFloat(f.Rate, 64)
+		if err != nil {
+			verr.Fields["rate"] = "must be a number"
+		}
+		opts.Rate = rate
+	}
+	if len(verr.Fields) > 0 {
+		return f, opts, verr
+	}
+	return f, opts, nil
+}
+
+// LoadRuns shows the load runs of the {id} webhook and the form that starts
+// one, set to send the request given by the request query parameter.
+func (h *WebhookRequestHandler) LoadRuns(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	form := loadForm{Source: "template", Sign: wh.SigningScheme != ""}
+	if id := r.URL.Query().Get("request"); id != "" {
+		form.Source, form.RequestID = "request", id
+	}
+	h.renderLoadRuns(w, r, http.StatusOK, wh, form)
+}
+
+// StartLoadRun starts the load run of the form from the {id} webhook and
+// shows it.
+func (h *WebhookRequestHandler) StartLoadRun(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckSendAccess(wh, userID)
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	form, opts, err := parseLoadForm(r)
+	var run *models.LoadRun
+	if err == nil {
+		run, err = h.loadSvc.Start(wh, opts)
+	}
+	if err != nil {
+		status, ok := formError(err, &form.Error, &form.Errors)
+		if !ok {
+			renderError(w, r, h.logger, err)
+			return
+		}
+		h.renderLoadRuns(w, r, status, wh, form)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/load/%s/%s", wh.ID, run.ID), http.StatusSeeOther)
+}
+
+// renderLoadRuns renders the load page with the given form.
+func (h *WebhookRequestHandler) renderLoadRuns(w http.ResponseWriter, r *http.Request, status int, wh *models.Webhook, form loadForm) {
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	runs, err := h.loadSvc.List(wh.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year      int
+		User      models.User
+		Webhooks  []models.Webhook
+		Webhook   *models.Webhook
+		Form      loadForm
+		Templates []events.Template
+		Runs      []models.LoadRun
+		CSRFField template.HTML
+	}{
+		Year:      time.Now().Year(),
+		User:      *user,
+		Webhooks:  list,
+		Webhook:   wh,
+		Form:      form,
+		Templates: events.Templates(),
+		Runs:      runs,
+		CSRFField: csrf.TemplateField(r),
+	}
+	w.WriteHeader(status)
+	utils.RenderHtml(w, r, "load-runs", data)
+}
+
+// LoadRun shows the results of a load run of the {id} webhook.
+func (h *WebhookRequestHandler) LoadRun(w http.ResponseWriter, r *http.Request) {
+	run, wh, err := h.accessibleLoadRun(r)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year      int
+		User      models.User
+		Webhooks  []models.Webhook
+		Webhook   *models.Webhook
+		Run       *models.LoadRun
+		CSRFField template.HTML
+	}{
+		Year:      time.Now().Year(),
+		User:      *user,
+		Webhooks:  list,
+		Webhook:   wh,
+		Run:       run,
+		CSRFField: csrf.TemplateField(r),
+	}
+	utils.RenderHtml(w, r, "load-run", data)
+}
+
+// CancelLoadRun stops a load run of the {id} webhook.
+func (h *WebhookRequestHandler) CancelLoadRun(w http.ResponseWriter, r *http.Request) {
+	run, _, err := h.accessibleLoadRun(r)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	// a run that ended in the meantime shows its final results
+	if _, err := h.loadSvc.Cancel(run.ID); err != nil && problem.From(err).Status == http.StatusInternalServerError {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/load/%s/%s", run.WebhookID, run.ID), http.StatusSeeOther)
+}
+
+// accessibleLoadRun loads the {runID} load run of the {id} webhook, which
+// the user must have access to.
+func (h *WebhookRequestHandler) accessibleLoadRun(r *http.Request) (*models.LoadRun, *models.Webhook, error) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, nil, err
+	}
+	userID, _ := h.authSvc.Authorize(r)
+	if err := h.webhookService.CheckAccess(wh, userID); err != nil {
+		return nil, nil, err
+	}
+	run, err := h.loadSvc.Get(chi.URLParam(r, "runID"))
+	if err == nil && run.WebhookID != wh.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "load run not found")
+	}
+	return run, wh, err
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// StartLoadRunApi starts a load run
+// @Summary     Start a load run
+// @Description Fires a captured request of the webhook, or an event rendered afresh from a template for every request, at a target in the background, with at most concurrency requests in flight and, with rate, at most that many started per second. The run stops after count requests or duration_ms, whichever comes first. Requests aren't stored; the run keeps status codes, errors, latency percentiles and throughput, updated every second while it runs. A webhook runs one load run at a time
+// @Tags        Load
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id    path  string               true  "Webhook ID"
+// @Param       run   body  dtos.LoadRunRequest  true  "What to send, where and how hard"
+// @Success     202  {object}  dtos.LoadRun
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /webhooks/{id}/load-runs [post]
+func (h *WebhookRequestApiHandler) StartLoadRunApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.LoadRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	run, err := h.Loads.Start(webhook, service.LoadOptions{
+		Target:      in.Target,
+		RequestID:   in.RequestID,
+		Template:    in.Template,
+		Sign:        in.Sign,
+		Concurrency: in.Concurrency,
+		Rate:        in.Rate,
+		Duration:    time.Duration(in.DurationMs) * time.Millisecond,
+		Count:       in.Count,
+		Timeout:     time.Duration(in.TimeoutMs) * time.Millisecond,
+	})
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusAccepted, dtos.NewLoadRunDTO(*run))
+}
+
+// ListLoadRunsApi lists the load runs of a webhook
+// @Summary     List load runs
+// @Description Lists the load runs of a webhook with their results, newest first
+// @Tags        Load
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     200  {array}   dtos.LoadRun
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/load-runs [get]
+func (h *WebhookRequestApiHandler) ListLoadRunsApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.Loads.List(webhook.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.LoadRun, 0, len(list))
+	for _, run := range list {
+		out = append(out, dtos.NewLoadRunDTO(run))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetLoadRunApi gets a load run
+// @Summary     Get load run
+// @Description Gets a load run with its results, at most a second old while it runs
+// @Tags        Load
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string  true  "Webhook ID"
+// @Param       runID  path  string  true  "Load run ID"
+// @Success     200  {object}  dtos.LoadRun
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/load-runs/{runID} [get]
+func (h *WebhookRequestApiHandler) GetLoadRunApi(w http.ResponseWriter, r *http.Request) {
+	run, ok := h.ownedLoadRun(w, r)
+	if !ok {
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewLoadRunDTO(*run))
+}
+
+// CancelLoadRunApi cancels a load run
+// @Summary     Cancel load run
+// @Description Stops a load run and returns its final results. Requests in flight are abandoned and left out
+// @Tags        Load
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string  true  "Webhook ID"
+// @Param       runID  path  string  true  "Load run ID"
+// @Success     200  {object}  dtos.LoadRun
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Router      /webhooks/{id}/load-runs/{runID}/cancel [post]
+func (h *WebhookRequestApiHandler) CancelLoadRunApi(w http.ResponseWriter, r *http.Request) {
+	run, ok := h.ownedLoadRun(w, r)
+	if !ok {
+		return
+	}
+	run, err := h.Loads.Cancel(run.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewLoadRunDTO(*run))
+}
+
+// ownedLoadRun loads the {runID} load run, which must belong to the {id} webhook.
+func (h *WebhookRequestApiHandler) ownedLoadRun(w http.ResponseWriter, r *http.Request) (*models.LoadRun, bool) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return nil, false
+	}
+	run, err := h.Loads.Get(chi.URLParam(r, "runID"))
+	if err == nil && run.WebhookID != webhook.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "load run not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, false
+	}
+	return run, true
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/snippet"
+)
+
+// replayOptions turns an API replay body into service options, aiming at the
+// webhook that captured wr when no target is given.
+func replayOptions(r *http.Request, wr *models.WebhookRequest, in dtos.ReplayRequest) service.ReplayOptions {
+	opts := service.ReplayOptions{
+		Target:        in.Target,
+		Method:        in.Method,
+		Headers:       in.Headers,
+		RemoveHeaders: in.RemoveHeaders,
+		Query:         in.Query,
+		RemoveQuery:   in.RemoveQuery,
+		Body:          in.Body,
+		Resign:        in.Resign,
+		Timeout:       time.Duration(in.TimeoutMs) * time.Millisecond,
+	}
+	if opts.Target == "" {
+		opts.Target = webhookURL(r, wr.WebhookID)
+	}
+	return opts
+}
+
+// replayJobOptions turns an API batch replay body into service options,
+// aiming at the webhook when no target is given.
+func replayJobOptions(r *http.Request, webhookID string, in dtos.ReplayJobRequest) service.ReplayJobOptions {
+	opts := service.ReplayJobOptions{
+		Target:        in.Target,
+		Filter:        repository.RequestFilter{IDs: in.IDs},
+		Mode:          in.Mode,
+		Speed:         in.Speed,
+		Rate:          in.Rate,
+		Concurrency:   in.Concurrency,
+		StopOnFailure: in.StopOnFailure,
+		Resign:        in.Resign,
+		Timeout:       time.Duration(in.TimeoutMs) * time.Millisecond,
+	}
+	if in.Since != nil {
+		opts.Filter.Since = in.Since.UTC()
+	}
+	if in.Until != nil {
+		opts.Filter.Until = in.Until.UTC()
+	}
+	if opts.Target == "" {
+		opts.Target = webhookURL(r, webhookID)
+	}
+	return opts
+}
+
+// webhookURL is the public URL of a webhook.
+func webhookURL(r *http.Request, webhookID string) string {
+	return baseURL(r) + "/webhooks/" + url.PathEscape(webhookID)
+}
+
+// replayForm is the editable replay form of the request page. Headers and
+// Query hold one "Name: value" or "name=value" pair per line.
+type replayForm struct {
+	Target     string
+	Method     string
+	Headers    string
+	Query      string
+	Body       string
+	BinaryBody bool // the body can't be edited as text and is sent as captured
+	Resign     bool // recompute signature headers with the webhook's secret
+	Error      string
+	Errors     map[string]string
+}
+
+// newReplayForm fills the form with wr as captured, aimed at its webhook, and
+// re-signed when wh has a signing secret.
+func newReplayForm(r *http.Request, wr *models.WebhookRequest, wh *models.Webhook) replayForm {
+	f := replayForm{Target: webhookURL(r, wr.WebhookID), Method: wr.Method, Body: wr.Body, BinaryBody: snippet.Binary(wr.Body),
+		Resign: wh.SigningScheme != ""}
+	if sr, err := snippet.New(wr, f.Target); err == nil {
+		lines := make([]string, len(sr.Headers))
+		for i, h := range sr.Headers {
+			lines[i] = h.Name + ": " + h.Value
+		}
+		f.Headers = strings.Join(lines, "\n")
+	}
+	lines := make([]string, 0, len(wr.Query))
+	for k, v := range wr.Query {
+		lines = append(lines, k+"="+fmt.Sprint(v))
+	}
+	sort.Strings(lines)
+	f.Query = strings.Join(lines, "\n")
+	if f.BinaryBody {
+		f.Body = ""
+	}
+	return f
+}
+
+// parseReplayForm reads the replay form. The form lists every header and
+// query parameter to send, so whatever was captured is replaced. A request
+// without the edit field, like the Replay button of the home page, replays wr
+// unchanged to its webhook.
+func parseReplayForm(r *http.Request, wr *models.WebhookRequest, wh *models.Webhook) (replayForm, service.ReplayOptions, error) {
+	if r.PostFormValue("edit") == "" {
+		return newReplayForm(r, wr, wh), service.ReplayOptions{Target: webhookURL(r, wr.WebhookID)}, nil
+	}
+
+	f := replayForm{
+		Target:     strings.TrimSpace(r.PostFormValue("target")),
+		Method:     strings.TrimSpace(r.PostFormValue("method")),
+		Headers:    r.PostFormValue("headers"),
+		Query:      r.PostFormValue("query"),
+		Body:       r.PostFormValue("body"),
+		BinaryBody: snippet.Binary(wr.Body),
+		Resign:     r.PostFormValue("resign") != "",
+	}
+	opts := service.ReplayOptions{Target: f.Target, Method: f.Method, Headers: map[string]string{}, Query: map[string]string{}, Resign: f.Resign}
+	if opts.Target == "" {
+		opts.Target = webhookURL(r, wr.WebhookID)
+	}
+	if !f.BinaryBody {
+		// browsers send textarea line breaks as CRLF; keep the captured body's
+		body := strings.ReplaceAll(f.Body, "\r\n", "\n")
+		if strings.Contains(wr.Body, "\r\n") {
+			body = strings.ReplaceAll(body, "\n", "\r\n")
+		}
+		opts.Body = &body
+	}
+	for name := range wr.Headers {
+		opts.RemoveHeaders = append(opts.RemoveHeaders, name)
+	}
+	for name := range wr.Query {
+		opts.RemoveQuery = append(opts.RemoveQuery, name)
+	}
+
+	for _, line := range formLines(f.Headers) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return f, opts, problem.BadRequest(fmt.Sprintf("headers: %q must look like Name: value", line))
+		}
+		opts.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	for _, line := range formLines(f.Query) {
+		name, value, _ := strings.Cut(line, "=")
+		opts.Query[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return f, opts, nil
+}
+
+// formLines splits a textarea into its non-blank lines.
+func formLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// StartReplayJobApi starts a batch replay
+// @Summary     Start a batch replay
+// @Description Replays the webhook's requests matching the selection, oldest first, to one target in the background. In timing mode the gaps between the original requests are kept, divided by speed; in rate mode requests are sent at a fixed rate. Each request is recorded as a replay attempt. Poll the job for progress
+// @Tags        Replays
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id   path  string                 true  "Webhook ID"
+// @Param       job  body  dtos.ReplayJobRequest  true  "Selection, target and pace"
+// @Success     202  {object}  dtos.ReplayJob
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /webhooks/{id}/replay-jobs [post]
+func (h *WebhookRequestApiHandler) StartReplayJobApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.ReplayJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	job, err := h.Jobs.Start(webhook.ID, replayJobOptions(r, webhook.ID, in))
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusAccepted, dtos.NewReplayJobDTO(*job))
+}
+
+// ListReplayJobsApi lists the batch replays of a webhook
+// @Summary     List batch replays
+// @Description Lists the batch replays of a webhook, newest first
+// @Tags        Replays
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     200  {array}   dtos.ReplayJob
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/replay-jobs [get]
+func (h *WebhookRequestApiHandler) ListReplayJobsApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	jobs, err := h.Jobs.List(webhook.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.ReplayJob, 0, len(jobs))
+	for _, j := range jobs {
+		out = append(out, dtos.NewReplayJobDTO(j))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetReplayJobApi gets a batch replay
+// @Summary     Get batch replay
+// @Description Gets a batch replay with its progress
+// @Tags        Replays
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string  true  "Webhook ID"
+// @Param       jobID  path  string  true  "Replay job ID"
+// @Success     200  {object}  dtos.ReplayJob
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/replay-jobs/{jobID} [get]
+func (h *WebhookRequestApiHandler) GetReplayJobApi(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.ownedJob(w, r)
+	if !ok {
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewReplayJobDTO(*job))
+}
+
+// ListReplayJobItemsApi lists the results of a batch replay
+// @Summary     List batch replay results
+// @Description Lists the requests of a batch replay in order, with the result of those already sent
+// @Tags        Replays
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string  true  "Webhook ID"
+// @Param       jobID  path  string  true  "Replay job ID"
+// @Success     200  {array}   dtos.ReplayJobItem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/replay-jobs/{jobID}/items [get]
+func (h *WebhookRequestApiHandler) ListReplayJobItemsApi(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.ownedJob(w, r)
+	if !ok {
+		return
+	}
+	items, err := h.Jobs.Items(job.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.ReplayJobItem, 0, len(items))
+	for _, item := range items {
+		out = append(out, dtos.NewReplayJobItemDTO(item))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// PauseReplayJobApi pauses a batch replay
+// @Summary     Pause batch replay
+// @Description Stops a running batch replay from sending more requests until it is resumed. Requests in flight complete
+// @Tags        Replays
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string  true  "Webhook ID"
+// @Param       jobID  path  string  true  "Replay job ID"
+// @Success     200  {object}  dtos.ReplayJob
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Router      /webhooks/{id}/replay-jobs/{jobID}/pause [post]
+func (h *WebhookRequestApiHandler) PauseReplayJobApi(w http.ResponseWriter, r *http.Request) {
+	h.controlJob(w, r, h.Jobs.Pause)
+}
+
+// ResumeReplayJobApi resumes a batch replay
+// @Summary     Resume batch replay
+// @Description Lets a paused batch replay continue. In timing mode the remaining requests keep their gaps
+// @Tags        Replays
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string  true  "Webhook ID"
+// @Param       jobID  path  string  true  "Replay job ID"
+// @Success     200  {object}  dtos.ReplayJob
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Router      /webhooks/{id}/replay-jobs/{jobID}/resume [post]
+func (h *WebhookRequestApiHandler) ResumeReplayJobApi(w http.ResponseWriter, r *http.Request) {
+	h.controlJob(w, r, h.Jobs.Resume)
+}
+
+// CancelReplayJobApi cancels a batch replay
+// @Summary     Cancel batch replay
+// @Description Ends a running or paused batch replay. Requests in flight complete
+// @Tags        Replays
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string  true  "Webhook ID"
+// @Param       jobID  path  string  true  "Replay job ID"
+// @Success     200  {object}  dtos.ReplayJob
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Router      /webhooks/{id}/replay-jobs/{jobID}/cancel [post]
+func (h *WebhookRequestApiHandler) CancelReplayJobApi(w http.ResponseWriter, r *http.Request) {
+	h.controlJob(w, r, h.Jobs.Cancel)
+}
+
+func (h *WebhookRequestApiHandler) controlJob(w http.ResponseWriter, r *http.Request, fn func(id string) (*models.ReplayJob, error)) {
+	job, ok := h.ownedJob(w, r)
+	if !ok {
+		return
+	}
+	job, err := fn(job.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewReplayJobDTO(*job))
+}
+
+// ownedJob loads the {jobID} replay job, which must belong to the {id} webhook.
+func (h *WebhookRequestApiHandler) ownedJob(w http.ResponseWriter, r *http.Request) (*models.ReplayJob, bool) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return nil, false
+	}
+	job, err := h.Jobs.Get(chi.URLParam(r, "jobID"))
+	if err == nil && job.WebhookID != webhook.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "replay job not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, false
+	}
+	return job, true
+}
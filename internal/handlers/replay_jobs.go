@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+)
+
+// formTime is the layout of datetime-local inputs, read as UTC.
+const formTime = "2006-01-02T15:04"
+
+// replayJobForm is the batch replay form. IDs are the requests ticked on the
+// home page; without them the time range selects the requests.
+type replayJobForm struct {
+	Target        string
+	Since         string
+	Until         string
+	IDs           []string
+	Mode          string
+	Speed         string
+	Rate          string
+	Concurrency   string
+	StopOnFailure bool
+	Resign        bool
+	Error         string
+	Errors        map[string]string
+}
+
+// parseReplayJobForm reads the batch replay form into service options.
+func parseReplayJobForm(r *http.Request, webhookID string) (replayJobForm, service.ReplayJobOptions, error) {
+	_ = r.ParseForm()
+	f := replayJobForm{
+		Target:        strings.TrimSpace(r.PostFormValue("target")),
+		Since:         r.PostFormValue("since"),
+		Until:         r.PostFormValue("until"),
+		IDs:           listParam(r.PostForm, "id"),
+		Mode:          r.PostFormValue("mode"),
+		Speed:         strings.TrimSpace(r.PostFormValue("speed")),
+		Rate:          strings.TrimSpace(r.PostFormValue("rate")),
+		Concurrency:   strings.TrimSpace(r.PostFormValue("concurrency")),
+		StopOnFailure: r.PostFormValue("stop_on_failure") != "",
+		Resign:        r.PostFormValue("resign") != "",
+	}
+	opts := service.ReplayJobOptions{Target: f.Target, Mode: f.Mode, StopOnFailure: f.StopOnFailure, Resign: f.Resign}
+	opts.Filter.IDs = f.IDs
+	if opts.Target == "" {
+		opts.Target = webhookURL(r, webhookID)
+	}
+
+	verr := &service.ValidationError{Fields: map[string]string{}}
+	for _, p := range []struct {
+		name, value string
+		dst         *time.Time
+	}{{"since", f.Since, &opts.Filter.Since}, {"until", f.Until, &opts.Filter.Until}} {
+		if p.value == "" {
+			continue
+		}
+		t, err := time.Parse(formTime, p.value)
+		if err != nil {
+			verr.Fields[p.name] = "must be a date and time"
+		}
+		*p.dst = t
+	}
+	for _, p := range []struct {
+		name, value string
+		dst         *float64
+	}{{"speed", f.Speed, &opts.Speed}, {"rate", f.Rate, &opts.Rate}} {
+		if p.value == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(p.value, 64)
+		if err != nil {
+			verr.Fields[p.name] = "must be a number"
+		}
+		*p.dst = n
+	}
+	if f.Concurrency != "" {
+		n, err := strconv.Atoi(f.Concurrency)
+		if err != nil {
+			verr.Fields["concurrency"] = "must be a whole number"
+		}
+		opts.Concurrency = n
+	}
+	if len(verr.Fields) > 0 {
+		return f, opts, verr
+	}
+	return f, opts, nil
+}
+
+// ReplayJobs shows the batch replays of the {id} webhook and the form that
+// starts one, preselecting the requests given by the id query parameters.
+func (h *WebhookRequestHandler) ReplayJobs(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	form := replayJobForm{Target: webhookURL(r, wh.ID), Mode: models.ReplayModeTiming, IDs: listParam(r.URL.Query(), "id"),
+		Resign: wh.SigningScheme != ""}
+	h.renderReplayJobs(w, r, http.StatusOK, wh, form)
+}
+
+// StartReplayJob starts a batch replay of the {id} webhook's requests.
+func (h *WebhookRequestHandler) StartReplayJob(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckSendAccess(wh, userID)
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	form, opts, err := parseReplayJobForm(r, wh.ID)
+	var job *models.ReplayJob
+	if err == nil {
+		job, err = h.replayJobSvc.Start(wh.ID, opts)
+	}
+	if err != nil {
+		p := problem.From(err)
+		if p.Status == http.StatusInternalServerError {
+			renderError(w, r, h.logger, err)
+			return
+		}
+		form.Error, form.Errors = p.Detail, p.Errors
+		if len(p.Errors) > 0 {
+			form.Error = ""
+		}
+		h.renderReplayJobs(w, r, p.Status, wh, form)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/replay-jobs/%s/%s", wh.ID, job.ID), http.StatusSeeOther)
+}
+
+// renderReplayJobs renders the batch replay page with the given form.
+func (h *WebhookRequestHandler) renderReplayJobs(w http.ResponseWriter, r *http.Request, status int, wh *models.Webhook, form replayJobForm) {
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	jobs, err := h.replayJobSvc.List(wh.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year      int
+		User      models.User
+		Webhooks  []models.Webhook
+		Webhook   *models.Webhook
+		Form      replayJobForm
+		Jobs      []models.ReplayJob
+		CSRFField template.HTML
+	}{
+		Year:      time.Now().Year(),
+		User:      *user,
+		Webhooks:  list,
+		Webhook:   wh,
+		Form:      form,
+		Jobs:      jobs,
+		CSRFField: csrf.TemplateField(r),
+	}
+	w.WriteHeader(status)
+	utils.RenderHtml(w, r, "replay-jobs", data)
+}
+
+// ReplayJob shows the progress and results of a batch replay.
+func (h *WebhookRequestHandler) ReplayJob(w http.ResponseWriter, r *http.Request) {
+	job, wh, err := h.accessibleJob(r)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	items, err := h.replayJobSvc.Items(job.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year      int
+		User      models.User
+		Webhooks  []models.Webhook
+		Webhook   *models.Webhook
+		Job       *models.ReplayJob
+		Items     []models.ReplayJobItem
+		CSRFField template.HTML
+	}{
+		Year:      time.Now().Year(),
+		User:      *user,
+		Webhooks:  list,
+		Webhook:   wh,
+		Job:       job,
+		Items:     items,
+		CSRFField: csrf.TemplateField(r),
+	}
+	utils.RenderHtml(w, r, "replay-job", data)
+}
+
+// ControlReplayJob pauses, resumes or cancels a batch replay, as the
+// {action} path parameter says.
+func (h *WebhookRequestHandler) ControlReplayJob(w http.ResponseWriter, r *http.Request) {
+	job, wh, err := h.accessibleJob(r)
+	if err == nil && chi.URLParam(r, "action") == "resume" {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckSendAccess(wh, userID)
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	actions := map[string]func(string) (*models.ReplayJob, error){
+		"pause":  h.replayJobSvc.Pause,
+		"resume": h.replayJobSvc.Resume,
+		"cancel": h.replayJobSvc.Cancel,
+	}
+	action, ok := actions[chi.URLParam(r, "action")]
+	if !ok {
+		renderError(w, r, h.logger, problem.New(http.StatusNotFound, problem.CodeNotFound, "unknown action"))
+		return
+	}
+	// a job that ended in the meantime shows its final state
+	if _, err := action(job.ID); err != nil && problem.From(err).Status == http.StatusInternalServerError {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/replay-jobs/%s/%s", job.WebhookID, job.ID), http.StatusSeeOther)
+}
+
+// accessibleJob loads the {jobID} job of the {id} webhook if the visitor may
+// manage the webhook.
+func (h *WebhookRequestHandler) accessibleJob(r *http.Request) (*models.ReplayJob, *models.Webhook, error) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, nil, err
+	}
+	userID, _ := h.authSvc.Authorize(r)
+	if err := h.webhookService.CheckAccess(wh, userID); err != nil {
+		return nil, nil, err
+	}
+	job, err := h.replayJobSvc.Get(chi.URLParam(r, "jobID"))
+	if err == nil && job.WebhookID != wh.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "replay job not found")
+	}
+	return job, wh, err
+}
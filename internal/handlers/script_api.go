@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/utils"
+)
+
+// GetScriptStateApi gets the script state of a webhook
+// @Summary     Get script state
+// @Description Gets the key/value state the webhook's script keeps between requests, as the script left it. A webhook whose script never changed it has an empty state
+// @Tags        Scripts
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     200  {object}  dtos.ScriptState
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/script-state [get]
+func (h *WebhookRequestApiHandler) GetScriptStateApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	state, err := h.Scripts.State(webhook.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.ScriptState{Data: state})
+}
+
+// ResetScriptStateApi clears the script state of a webhook
+// @Summary     Reset script state
+// @Description Empties the key/value state of the webhook's script, so that the next request starts afresh
+// @Tags        Scripts
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     204  {string}  string  "No Content"
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/script-state [delete]
+func (h *WebhookRequestApiHandler) ResetScriptStateApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Scripts.ResetState(webhook.ID); err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"webhook-tester/internal/events"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/csrf"
+)
+
+// sendForm is the event sender form. Headers holds one "Name: value" pair
+// per line; Template only says which template the fields came from. Schedule
+// lists the custom policy's waits, in milliseconds, separated by commas.
+type sendForm struct {
+	Template       string
+	Target         string
+	Method         string
+	Headers        string
+	Body           string
+	Sign           bool
+	Policy         string
+	MaxAttempts    string
+	InitialDelay   string
+	Schedule       string
+	Budget         string
+	DeliveryHeader string
+	Error          string
+	Errors         map[string]string
+}
+
+// newSendForm fills the form with the template called name, rendered now.
+func newSendForm(r *http.Request, wh *models.Webhook, name string) (sendForm, error) {
+	f := sendForm{Template: name, Target: webhookURL(r, wh.ID), Method: http.MethodPost, Sign: wh.SigningScheme != "", Policy: models.RetryNone}
+	if name == "" {
+		return f, nil
+	}
+	t, ok := events.Lookup(name)
+	if !ok {
+		return f, problem.New(http.StatusNotFound, problem.CodeNotFound, "event template not found")
+	}
+	ev, err := t.Render(time.Now())
+	if err != nil {
+		return f, err
+	}
+	lines := make([]string, 0, len(ev.Headers))
+	for _, name := range ev.HeaderNames() {
+		lines = append(lines, name+": "+ev.Headers[name])
+	}
+	f.Method, f.Headers, f.Body = ev.Method, strings.Join(lines, "\n"), ev.Body
+	return f, nil
+}
+
+// parseSendForm reads the sender form into service options. The form holds
+// the whole event, so the template isn't rendered again.
+func parseSendForm(r *http.Request, webhookID string) (sendForm, service.DeliveryOptions, error) {
+	f := sendForm{
+		Template:       r.PostFormValue("template"),
+		Target:         strings.TrimSpace(r.PostFormValue("target")),
+		Method:         strings.TrimSpace(r.PostFormValue("method")),
+		Headers:        r.PostFormValue("headers"),
+		Body:           r.PostFormValue("body"),
+		Sign:           r.PostFormValue("sign") != "",
+		Policy:         r.PostFormValue("policy"),
+		MaxAttempts:    strings.TrimSpace(r.PostFormValue("max_attempts")),
+		InitialDelay:   strings.TrimSpace(r.PostFormValue("initial_delay_ms")),
+		Schedule:       strings.TrimSpace(r.PostFormValue("schedule_ms")),
+		Budget:         strings.TrimSpace(r.PostFormValue("budget_ms")),
+		DeliveryHeader: strings.TrimSpace(r.PostFormValue("delivery_header")),
+	}
+	// browsers send textarea line breaks as CRLF
+	body := strings.ReplaceAll(f.Body, "\r\n", "\n")
+	opts := service.DeliveryOptions{
+		SendOptions:    service.SendOptions{Target: f.Target, Method: f.Method, Headers: map[string]string{}, Body: &body, Sign: f.Sign},
+		Policy:         f.Policy,
+		DeliveryHeader: f.DeliveryHeader,
+	}
+	if opts.Target == "" {
+		opts.Target = webhookURL(r, webhookID)
+	}
+	for _, line := range formLines(f.Headers) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return f, opts, problem.BadRequest(fmt.Sprintf("headers: %q must look like Name: value", line))
+		}
+		opts.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	// the fields of other policies are left over when the policy changes
+	verr := &service.ValidationError{Fields: map[string]string{}}
+	number := func(name, value string) int {
+		if value == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			verr.Fields[name] = "must be a whole number"
+		}
+		return n
+	}
+	switch f.Policy {
+	case models.RetryExponential:
+		opts.MaxAttempts = number("max_attempts", f.MaxAttempts)
+		opts.InitialDelay = time.Duration(number("initial_delay_ms", f.InitialDelay)) * time.Millisecond
+	case models.RetryCustom:
+		for _, v := range strings.Split(f.Schedule, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				opts.Schedule = append(opts.Schedule, time.Duration(number("schedule_ms", v))*time.Millisecond)
+			}
+		}
+	}
+	opts.Budget = time.Duration(number("budget_ms", f.Budget)) * time.Millisecond
+	if len(verr.Fields) > 0 {
+		return f, opts, verr
+	}
+	return f, opts, nil
+}
+
+// Sender shows the event sender of the {id} webhook, filled with the
+// template given by the template query parameter.
+func (h *WebhookRequestHandler) Sender(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	form, err := newSendForm(r, wh, r.URL.Query().Get("template"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	h.renderSender(w, r, http.StatusOK, wh, form)
+}
+
+// SendEvent delivers the event of the sender form from the {id} webhook and
+// shows the delivery.
+func (h *WebhookRequestHandler) SendEvent(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckSendAccess(wh, userID)
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	form, opts, err := parseSendForm(r, wh.ID)
+	var d *models.Delivery
+	if err == nil {
+		d, err = h.deliverySvc.Start(wh, opts)
+	}
+	if err != nil {
+		status, ok := formError(err, &form.Error, &form.Errors)
+		if !ok {
+			renderError(w, r, h.logger, err)
+			return
+		}
+		h.renderSender(w, r, status, wh, form)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/deliveries/%s/%s", wh.ID, d.ID), http.StatusSeeOther)
+}
+
+// renderSender renders the event sender page with the given form.
+func (h *WebhookRequestHandler) renderSender(w http.ResponseWriter, r *http.Request, status int, wh *models.Webhook, form sendForm) {
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	deliveries, err := h.deliverySvc.List(wh.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year       int
+		User       models.User
+		Webhooks   []models.Webhook
+		Webhook    *models.Webhook
+		Form       sendForm
+		Templates  []events.Template
+		Deliveries []models.Delivery
+		CSRFField  template.HTML
+	}{
+		Year:       time.Now().Year(),
+		User:       *user,
+		Webhooks:   list,
+		Webhook:    wh,
+		Form:       form,
+		Templates:  events.Templates(),
+		Deliveries: deliveries,
+		CSRFField:  csrf.TemplateField(r),
+	}
+	w.WriteHeader(status)
+	utils.RenderHtml(w, r, "send", data)
+}
+
+// Delivery shows the timeline of a delivery of the {id} webhook.
+func (h *WebhookRequestHandler) Delivery(w http.ResponseWriter, r *http.Request) {
+	d, wh, err := h.accessibleDelivery(r)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	attempts, err := h.deliverySvc.Attempts(d.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	data := struct {
+		Year      int
+		User      models.User
+		Webhooks  []models.Webhook
+		Webhook   *models.Webhook
+		Delivery  *models.Delivery
+		Attempts  []models.DeliveryAttempt
+		CSRFField template.HTML
+	}{
+		Year:      time.Now().Year(),
+		User:      *user,
+		Webhooks:  list,
+		Webhook:   wh,
+		Delivery:  d,
+		Attempts:  attempts,
+		CSRFField: csrf.TemplateField(r),
+	}
+	utils.RenderHtml(w, r, "delivery", data)
+}
+
+// CancelDelivery stops a delivery of the {id} webhook from retrying.
+func (h *WebhookRequestHandler) CancelDelivery(w http.ResponseWriter, r *http.Request) {
+	d, _, err := h.accessibleDelivery(r)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	// a delivery that ended in the meantime shows its final state
+	if _, err := h.deliverySvc.Cancel(d.ID); err != nil && problem.From(err).Status == http.StatusInternalServerError {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/deliveries/%s/%s", d.WebhookID, d.ID), http.StatusSeeOther)
+}
+
+// accessibleDelivery loads the {deliveryID} delivery of the {id} webhook,
+// which the user must have access to.
+func (h *WebhookRequestHandler) accessibleDelivery(r *http.Request) (*models.Delivery, *models.Webhook, error) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, nil, err
+	}
+	userID, _ := h.authSvc.Authorize(r)
+	if err := h.webhookService.CheckAccess(wh, userID); err != nil {
+		return nil, nil, err
+	}
+	d, err := h.deliverySvc.Get(chi.URLParam(r, "deliveryID"))
+	if err == nil && d.WebhookID != wh.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "delivery not found")
+	}
+	return d, wh, err
+}
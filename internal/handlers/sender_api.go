@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/events"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+)
+
+// ListEventTemplatesApi lists the event templates
+// @Summary     List event templates
+// @Description Lists the provider events the sender can send, each rendered with fresh IDs and timestamps, ready to edit
+// @Tags        Sender
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Success     200  {array}   dtos.EventTemplate
+// @Failure     401  {object}  dtos.Problem
+// @Router      /event-templates [get]
+func (h *WebhookRequestApiHandler) ListEventTemplatesApi(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	list := events.Templates()
+	out := make([]dtos.EventTemplate, len(list))
+	for i, t := range list {
+		ev, err := t.Render(now)
+		if err != nil {
+			renderError(w, r, h.Logger, err)
+			return
+		}
+		out[i] = dtos.NewEventTemplateDTO(t, ev)
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// SendEventApi sends an event from a webhook
+// @Summary     Send an event
+// @Description Sends an event, built from a template and edits, to a target URL, signed with the webhook's signing secret when asked. The event is stored as a request of the webhook with source "sent", and the delivery as a replay attempt of it. Failing to reach the target isn't an error: the delivery's error says why
+// @Tags        Sender
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id     path  string                 true  "Webhook ID"
+// @Param       event  body  dtos.SendEventRequest  true  "Template, edits and target"
+// @Success     201  {object}  dtos.SentEvent
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Failure     507  {object}  dtos.Problem
+// @Router      /webhooks/{id}/send [post]
+func (h *WebhookRequestApiHandler) SendEventApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.SendEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	opts := service.SendOptions{
+		Target:   in.Target,
+		Template: in.Template,
+		Method:   in.Method,
+		Headers:  in.Headers,
+		Body:     in.Body,
+		Sign:     in.Sign,
+		Timeout:  time.Duration(in.TimeoutMs) * time.Millisecond,
+	}
+	if opts.Target == "" {
+		opts.Target = webhookURL(r, webhook.ID)
+	}
+	wr, attempt, err := h.Sender.Send(r.Context(), webhook, opts)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusCreated, dtos.SentEvent{
+		Request:  dtos.NewWebhookRequestDTO(*wr),
+		Delivery: dtos.NewReplayAttemptDTO(*attempt),
+	})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/snippet"
+)
+
+// requestSnippets renders wr in the languages named by the lang query
+// parameters, or all of them, sending it to the target query parameter or
+// by default back to the webhook that captured it.
+func requestSnippets(r *http.Request, q url.Values, wr *models.WebhookRequest) ([]dtos.Snippet, error) {
+	langs := snippet.Languages
+	if len(q["lang"]) > 0 {
+		langs = nil
+		for _, name := range q["lang"] {
+			l, err := snippet.ParseLanguage(name)
+			if err != nil {
+				return nil, problem.BadRequest(err.Error())
+			}
+			langs = append(langs, l)
+		}
+	}
+	target := q.Get("target")
+	if target == "" {
+		target = webhookURL(r, wr.WebhookID)
+	}
+	req, err := snippet.New(wr, target)
+	if err != nil {
+		return nil, problem.BadRequest("target: " + err.Error())
+	}
+
+	out := make([]dtos.Snippet, 0, len(langs))
+	for _, l := range langs {
+		code, err := snippet.Generate(l, req)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, dtos.Snippet{Language: string(l), Label: l.Label(), Code: code})
+	}
+	return out, nil
+}
+
+// baseURL is the public URL of the server: DOMAIN, or the host r was sent to.
+func baseURL(r *http.Request) string {
+	if d := os.Getenv("DOMAIN"); d != "" {
+		return strings.TrimSuffix(d, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
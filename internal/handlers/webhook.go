@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,8 +11,11 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"webhook-tester/internal/chaos"
 	"webhook-tester/internal/metrics"
 	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/script"
 	"webhook-tester/internal/service"
 	"webhook-tester/internal/utils"
 
@@ -22,36 +24,46 @@ import (
 )
 
 type WebhookHandler struct {
-	webhookSvc *service.WebhookService
-	authSvc    *service.AuthService
-	logger     *log.Logger
-	metrics    metrics.Recorder
+	webhookSvc   *service.WebhookService
+	authSvc      *service.AuthService
+	retentionSvc *service.RetentionService
+	callbackSvc  *service.CallbackService // nil when received requests aren't called back
+	scriptSvc    *service.ScriptService   // nil when webhook scripts don't run
+	faults       *chaos.Injector
+	logger       *log.Logger
+	metrics      metrics.Recorder
 }
 
 func NewWebhookHandler(
 	webhookSvc *service.WebhookService,
 	authSvc *service.AuthService,
+	retentionSvc *service.RetentionService,
+	callbackSvc *service.CallbackService,
+	scriptSvc *service.ScriptService,
 	logger *log.Logger,
 	metrics metrics.Recorder) *WebhookHandler {
 	return &WebhookHandler{
-		webhookSvc: webhookSvc,
-		authSvc:    authSvc,
-		logger:     logger,
-		metrics:    metrics,
+		webhookSvc:   webhookSvc,
+		authSvc:      authSvc,
+		retentionSvc: retentionSvc,
+		callbackSvc:  callbackSvc,
+		scriptSvc:    scriptSvc,
+		faults:       chaos.NewInjector(nil),
+		logger:       logger,
+		metrics:      metrics,
 	}
 }
 
 func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 	userID, err := h.authSvc.Authorize(r)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		renderError(w, r, h.logger, err)
 		return
 	}
 
 	err = r.ParseForm()
 	if err != nil {
-		h.logger.Printf("error parsing form: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		renderError(w, r, h.logger, problem.BadRequest(err.Error()))
 		return
 	}
 
@@ -64,14 +76,13 @@ func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 	responseDelay, _ := strconv.Atoi(r.FormValue("response_delay")) // defaults to 0
 	payload := r.FormValue("payload")
 	notify := r.FormValue("notify_on_event") == "true"
+	retentionCount, _ := strconv.Atoi(r.FormValue("retention_count")) // defaults to 0, keep all
+	retentionDays, _ := strconv.Atoi(r.FormValue("retention_days"))
 
-	headersStr := r.FormValue("response_headers")
-	var headers datatypes.JSONMap
-	if headersStr != "" {
-		err := json.Unmarshal([]byte(headersStr), &headers)
-		if err != nil {
-			log.Printf("error parsing json %s", err)
-		}
+	headers, err := parseHeadersField(r.FormValue("response_headers"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
 	}
 
 	webhookID := utils.GenerateID()
@@ -79,18 +90,19 @@ func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 		ID:              webhookID,
 		UserID:          int(userID),
 		Title:           title,
-		ContentType:     &contentType,
+		ContentType:     optionalString(contentType),
 		ResponseCode:    responseCode,
-		ResponseDelay:   uint(responseDelay),
-		Payload:         &payload,
+		ResponseDelay:   uint(max(responseDelay, 0)),
+		Payload:         optionalString(payload),
 		ResponseHeaders: headers,
 		NotifyOnEvent:   notify,
+		RetentionCount:  uint(max(retentionCount, 0)),
+		RetentionDays:   uint(max(retentionDays, 0)),
 	}
 
 	err = h.webhookSvc.CreateWebhook(&wh)
 	if err != nil {
-		h.logger.Printf("Error creating webhook: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
 		return
 	}
 
@@ -101,22 +113,22 @@ func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 func (h *WebhookHandler) DeleteRequests(w http.ResponseWriter, r *http.Request) {
 	userID, err := h.authSvc.Authorize(r)
-
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		renderError(w, r, h.logger, err)
+		return
 	}
 
 	webhookID := chi.URLParam(r, "id")
 
 	if webhookID == "" {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		renderError(w, r, h.logger, problem.BadRequest("missing webhook id"))
+		return
 	}
 
 	err = h.webhookSvc.DeleteWebhook(webhookID, userID)
-
 	if err != nil {
-		h.logger.Printf("Error deleting webhook: %v", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
+		return
 	}
 
 	http.Redirect(w, r, fmt.Sprintf("/?address=%s", webhookID), http.StatusSeeOther)
@@ -124,22 +136,22 @@ func (h *WebhookHandler) DeleteRequests(w http.ResponseWriter, r *http.Request)
 
 func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
 	userID, err := h.authSvc.Authorize(r)
-
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		renderError(w, r, h.logger, err)
+		return
 	}
 
 	webhookID := chi.URLParam(r, "id")
 
 	if webhookID == "" {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		renderError(w, r, h.logger, problem.BadRequest("missing webhook id"))
+		return
 	}
 
 	err = h.webhookSvc.DeleteWebhook(webhookID, userID)
-
 	if err != nil {
-		h.logger.Printf("Error deleting webhook: %v", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
+		return
 	}
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -148,20 +160,19 @@ func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
 func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
 	userID, err := h.authSvc.Authorize(r)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		renderError(w, r, h.logger, err)
 		return
 	}
 
 	webhookID := chi.URLParam(r, "id")
 	if webhookID == "" {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		renderError(w, r, h.logger, problem.BadRequest("missing webhook id"))
 		return
 	}
 
 	err = r.ParseForm()
 	if err != nil {
-		h.logger.Printf("error parsing form: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		renderError(w, r, h.logger, problem.BadRequest(err.Error()))
 		return
 	}
 
@@ -174,52 +185,148 @@ func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
 	responseDelay, _ := strconv.Atoi(r.FormValue("response_delay")) // defaults to 0
 	payload := r.FormValue("payload")
 	notify := r.FormValue("notify_on_event") == "true"
+	retentionCount, _ := strconv.Atoi(r.FormValue("retention_count"))
+	retentionDays, _ := strconv.Atoi(r.FormValue("retention_days"))
 
-	headersStr := r.FormValue("response_headers")
-	var headers datatypes.JSONMap
-	if headersStr != "" {
-		err := json.Unmarshal([]byte(headersStr), &headers)
-		if err != nil {
-			log.Printf("error parsing json %s", err)
-		}
+	headers, err := parseHeadersField(r.FormValue("response_headers"))
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	faults, err := parseFaultsForm(r)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
 	}
 	wh, err := h.webhookSvc.GetUserWebhook(webhookID, userID)
 	if err != nil {
-		h.logger.Printf("Error getting webhook: %v", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
+		return
 	}
 
 	wh.Title = title
-	wh.ContentType = &contentType
+	wh.ContentType = optionalString(contentType)
 	wh.ResponseCode = responseCode
-	wh.ResponseDelay = uint(responseDelay)
+	wh.ResponseDelay = uint(max(responseDelay, 0))
 	wh.NotifyOnEvent = notify
-	wh.Payload = &payload
+	wh.Payload = optionalString(payload)
 	wh.ResponseHeaders = headers
+	wh.RetentionCount = uint(max(retentionCount, 0))
+	wh.RetentionDays = uint(max(retentionDays, 0))
+	// a blank secret keeps the stored one; no scheme forgets it
+	wh.SigningScheme = r.FormValue("signing_scheme")
+	if secret := r.FormValue("signing_secret"); secret != "" {
+		wh.SigningSecret = secret
+	}
+	if wh.SigningScheme == "" {
+		wh.SigningSecret = ""
+	}
+	wh.Faults = datatypes.NewJSONType(faults)
+	// textareas submit CRLF line endings
+	wh.Script = strings.ReplaceAll(r.FormValue("script"), "\r\n", "\n")
 
 	err = h.webhookSvc.UpdateWebhook(wh)
 	if err != nil {
-		h.logger.Printf("Error updating webhook: %v", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	if _, err := h.retentionSvc.Enforce(wh); err != nil {
+		h.logger.Printf("Error applying retention: %v", err)
 	}
 	http.Redirect(w, r, fmt.Sprintf("/?address=%s", webhookID), http.StatusSeeOther)
 }
 
+// parseHeadersField decodes the JSON object sent in the response_headers form field.
+func parseHeadersField(raw string) (datatypes.JSONMap, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var headers datatypes.JSONMap
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		return nil, problem.BadRequest("response_headers: must be a JSON object of header names to values")
+	}
+	return headers, nil
+}
+
+// parseFaultsForm reads the fault injection fields of the settings form;
+// blank fields inject nothing.
+func parseFaultsForm(r *http.Request) (chaos.Config, error) {
+	var c chaos.Config
+	var bad []string
+	number := func(name string) float64 {
+		raw := strings.TrimSpace(r.FormValue(name))
+		if raw == "" {
+			return 0
+		}
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			bad = append(bad, name)
+		}
+		return n
+	}
+	c.ErrorPercent = number("fault_error_percent")
+	c.ErrorStatus = int(number("fault_error_status"))
+	c.FailEvery = int(number("fault_fail_every"))
+	c.DelayMinMs = int(number("fault_delay_min_ms"))
+	c.DelayMaxMs = int(number("fault_delay_max_ms"))
+	c.DelayDistribution = r.FormValue("fault_delay_distribution")
+	c.RateLimit = int(number("fault_rate_limit"))
+	c.RateLimitWindowMs = int(number("fault_rate_limit_window_ms"))
+	c.RetryAfter = int(number("fault_retry_after"))
+	c.TruncatePercent = number("fault_truncate_percent")
+	c.DripPercent = number("fault_drip_percent")
+	c.DripIntervalMs = int(number("fault_drip_interval_ms"))
+	c.DisconnectPercent = number("fault_disconnect_percent")
+	if len(bad) > 0 {
+		return c, problem.BadRequest(fmt.Sprintf("%s: must be a number", strings.Join(bad, ", ")))
+	}
+	return c, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 var webhookStreams = make(map[string][]chan string)
 var mu sync.Mutex
 
+// subscribe registers a channel that receives each new request of a webhook
+// as JSON. Slow subscribers miss events rather than block ingestion.
+func subscribe(webhookID string) chan string {
+	ch := make(chan string, 16)
+	mu.Lock()
+	webhookStreams[webhookID] = append(webhookStreams[webhookID], ch)
+	mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes a channel registered with subscribe.
+func unsubscribe(webhookID string, ch chan string) {
+	mu.Lock()
+	defer mu.Unlock()
+	subs := webhookStreams[webhookID]
+	for i, sub := range subs {
+		if sub == ch {
+			webhookStreams[webhookID] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(webhookStreams[webhookID]) == 0 {
+		delete(webhookStreams, webhookID)
+	}
+}
+
 func (h *WebhookHandler) HandleWebhookRequest(w http.ResponseWriter, r *http.Request) {
 	webhookID := strings.TrimPrefix(r.URL.Path, "/webhooks/")
 	h.logger.Printf("Handling webhook request for %s", webhookID)
 	webhook, err := h.webhookSvc.GetWebhook(webhookID)
 
 	if err != nil {
-		switch {
-		case errors.Is(err, sql.ErrNoRows):
-			w.WriteHeader(http.StatusNotFound)
-		default:
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+		renderError(w, r, h.logger, err)
 		return
 	}
 
@@ -250,20 +357,57 @@ func (h *WebhookHandler) HandleWebhookRequest(w http.ResponseWriter, r *http.Req
 		Headers:    headers,
 		Query:      query,
 		Body:       string(body),
+		Source:     models.SourceLive,
 		ReceivedAt: time.Now().UTC(),
 	}
+	wr.Size = wr.ComputeSize()
+
+	usage, err := h.retentionSvc.CheckQuota(webhook, wr.Size)
+	if err != nil {
+		if errors.Is(err, service.ErrQuotaExceeded) {
+			w.Header().Set("X-Storage-Quota-Limit", strconv.FormatInt(usage.Limit, 10))
+			w.Header().Set("X-Storage-Quota-Used", strconv.FormatInt(usage.Used, 10))
+		}
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	// the fault is decided up front so that the stored request records it
+	fault := h.faults.Decide(webhook.ID, webhook.Faults.Data(), wr.ReceivedAt)
+	wr.Fault, wr.FaultDelay = fault.Fault, fault.Delay.Milliseconds()
+
+	// the script runs before the request is stored so that its log is kept
+	var scripted *script.Response
+	var scriptErr error
+	if webhook.Script != "" && h.scriptSvc != nil {
+		if scripted, scriptErr = h.scriptSvc.Run(r.Context(), webhook, &wr); scriptErr != nil {
+			h.logger.Printf("script of webhook %s failed: %s", webhookID, scriptErr)
+		}
+	}
 
 	err = h.webhookSvc.CreateRequest(&wr)
 	if err != nil {
-		h.logger.Printf("error creating webhook request: %s", err)
-		utils.RenderJSON(w, http.StatusInternalServerError, nil)
+		renderError(w, r, h.logger, err)
 		return
 	}
 	h.metrics.IncWebhookRequest(webhookID)
+	if h.callbackSvc != nil {
+		h.callbackSvc.Schedule(webhook, &wr)
+	}
+
+	if webhook.RetentionCount > 0 {
+		if _, err := h.retentionSvc.Enforce(webhook); err != nil {
+			h.logger.Printf("error applying retention: %s", err)
+		}
+	}
 
 	// Delay response
-	if webhook.ResponseDelay > 0 {
-		time.Sleep(time.Duration(webhook.ResponseDelay) * time.Millisecond)
+	delay := time.Duration(webhook.ResponseDelay)*time.Millisecond + fault.Delay
+	if scripted != nil {
+		delay += scripted.Delay
+	}
+	if delay > 0 {
+		time.Sleep(delay)
 	}
 
 	jsonData, _ := json.Marshal(wr)
@@ -285,19 +429,37 @@ func (h *WebhookHandler) HandleWebhookRequest(w http.ResponseWriter, r *http.Req
 	}
 
 	// Return custom response
-	if webhook.ContentType != nil {
+	if webhook.ContentType != nil && *webhook.ContentType != "" {
 		w.Header().Set("Content-Type", *webhook.ContentType)
 	} else {
 		// Default to application json if content type is not specified
 		w.Header().Set("Content-Type", "application/json")
 	}
 
-	w.WriteHeader(webhook.ResponseCode)
+	status := webhook.ResponseCode
+	var payload []byte
 	if webhook.Payload != nil {
-		if _, err := w.Write([]byte(*webhook.Payload)); err != nil {
-			h.logger.Printf("error writing payload: %s", err)
+		payload = []byte(*webhook.Payload)
+	}
+	switch {
+	case scriptErr != nil:
+		status = http.StatusInternalServerError
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		payload = []byte("script failed: " + scriptErr.Error())
+	case scripted != nil && scripted.Set:
+		if scripted.Status != 0 {
+			status = scripted.Status
+		}
+		for k, v := range scripted.Headers {
+			w.Header().Set(k, v)
+		}
+		if scripted.Body != nil {
+			payload = []byte(*scripted.Body)
 		}
 	}
+	if err := fault.Respond(w, r, status, payload); err != nil {
+		h.logger.Printf("error writing payload: %s", err)
+	}
 }
 
 func (h *WebhookHandler) StreamWebhookEvents(w http.ResponseWriter, r *http.Request) {
@@ -309,10 +471,8 @@ func (h *WebhookHandler) StreamWebhookEvents(w http.ResponseWriter, r *http.Requ
 	w.Header().Set("Connection", "keep-alive")
 
 	// Create a channel for this client
-	eventChan := make(chan string)
-	mu.Lock()
-	webhookStreams[webhookID] = append(webhookStreams[webhookID], eventChan)
-	mu.Unlock()
+	eventChan := subscribe(webhookID)
+	defer unsubscribe(webhookID, eventChan)
 
 	// Stream new events
 	flusher, _ := w.(http.Flusher)
@@ -326,15 +486,6 @@ func (h *WebhookHandler) StreamWebhookEvents(w http.ResponseWriter, r *http.Requ
 			}
 			flusher.Flush()
 		case <-r.Context().Done():
-			mu.Lock()
-			subs := webhookStreams[webhookID]
-			for i, sub := range subs {
-				if sub == eventChan {
-					webhookStreams[webhookID] = append(subs[:i], subs[i+1:]...)
-					break
-				}
-			}
-			mu.Unlock()
 			return
 		}
 	}
@@ -2,7 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -10,22 +11,23 @@ import (
 	"webhook-tester/internal/metrics"
 	"webhook-tester/internal/middlewares"
 	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
 	"webhook-tester/internal/service"
 	"webhook-tester/internal/utils"
-
-	"gorm.io/gorm"
+	"webhook-tester/spec"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type WebhookAiHandler struct {
 	Service *service.WebhookService
+	Spec    *service.SpecService
 	Metrics metrics.Recorder
 	Logger  *log.Logger
 }
 
-func NewWebhookApiHandler(svc *service.WebhookService, m metrics.Recorder, l *log.Logger) *WebhookAiHandler {
-	return &WebhookAiHandler{Service: svc, Metrics: m, Logger: l}
+func NewWebhookApiHandler(svc *service.WebhookService, specSvc *service.SpecService, m metrics.Recorder, l *log.Logger) *WebhookAiHandler {
+	return &WebhookAiHandler{Service: svc, Spec: specSvc, Metrics: m, Logger: l}
 }
 
 // CreateWebhookApi Creates a webhook
@@ -35,44 +37,33 @@ func NewWebhookApiHandler(svc *service.WebhookService, m metrics.Recorder, l *lo
 // @Produce     json
 // @Security     ApiKeyAuth
 // @Param        webhook body dtos.CreateWebhookRequest true "Webhook body"
-// @Success     200  {object}  dtos.Webhook
+// @Success     201  {object}  dtos.Webhook
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
 // @Router      /webhooks [post]
 func (h *WebhookAiHandler) CreateWebhookApi(w http.ResponseWriter, r *http.Request) {
 	user := middlewares.GetAPIAuthenticatedUser(r)
 	input := dtos.CreateWebhookRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		utils.RenderJSON(w, http.StatusBadRequest, err.Error())
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
 		return
 	}
 
-	code := input.ResponseCode
-	if code == 0 {
-		code = 200
-	}
-
 	webhook := models.Webhook{
-		ID:            utils.GenerateID(),
-		Title:         input.Title,
-		ResponseCode:  code,
-		ResponseDelay: input.ResponseDelay,
-		ContentType:   &input.ContentType,
-		Payload:       &input.Payload,
-		UserID:        int(user.ID),
-		CreatedAt:     time.Now().UTC(),
-		UpdatedAt:     time.Now().UTC(),
-		NotifyOnEvent: input.NotifyOnEvent,
+		ID:        utils.GenerateID(),
+		UserID:    int(user.ID),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
 	}
+	input.ApplyTo(&webhook)
 
 	if err := h.Service.CreateWebhook(&webhook); err != nil {
-		utils.RenderJSON(w, http.StatusInternalServerError, map[string]interface{}{
-			"error": err.Error(),
-		})
+		renderError(w, r, h.Logger, err)
 		return
 	}
 	h.Metrics.IncWebhooksCreated()
-	dto := dtos.NewWebhookDTO(webhook)
-	dto.Requests = make([]models.WebhookRequest, 0)
-	utils.RenderJSON(w, http.StatusCreated, dto)
+	utils.RenderJSON(w, http.StatusCreated, dtos.NewWebhookDTO(webhook))
 }
 
 // ListWebhooksApi Creates a webhook
@@ -81,19 +72,22 @@ func (h *WebhookAiHandler) CreateWebhookApi(w http.ResponseWriter, r *http.Reque
 // @Tags        Webhooks
 // @Produce     json
 // @Security     ApiKeyAuth
-// @Success     200  {object} []dtos.Webhook
+// @Success     200  {array}  dtos.Webhook
+// @Failure     401  {object}  dtos.Problem
 // @Router      /webhooks [get]
 func (h *WebhookAiHandler) ListWebhooksApi(w http.ResponseWriter, r *http.Request) {
 	user := middlewares.GetAPIAuthenticatedUser(r)
 	webhooks, err := h.Service.ListWebhooks(user.ID)
 	if err != nil {
-		utils.RenderJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		renderError(w, r, h.Logger, err)
 		return
 	}
 
-	utils.RenderJSON(w, http.StatusOK, webhooks)
+	list := make([]dtos.Webhook, 0, len(webhooks))
+	for _, wh := range webhooks {
+		list = append(list, dtos.NewWebhookDTO(wh))
+	}
+	utils.RenderJSON(w, http.StatusOK, list)
 }
 
 // GetWebhookApi Gets a webhook by webhook ID
@@ -103,97 +97,94 @@ func (h *WebhookAiHandler) ListWebhooksApi(w http.ResponseWriter, r *http.Reques
 // @Produce     json
 // @Security     ApiKeyAuth
 // @Param        id   path      string  true  "Webhook ID"
-// @Success     200  {object} []dtos.Webhook
+// @Success     200  {object} dtos.Webhook
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
 // @Router      /webhooks/{id} [get]
 func (h *WebhookAiHandler) GetWebhookApi(w http.ResponseWriter, r *http.Request) {
-	webhookID := chi.URLParam(r, "id")
-	user := middlewares.GetAPIAuthenticatedUser(r)
-	webhook, err := h.Service.GetUserWebhook(webhookID, user.ID)
-
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.RenderJSON(w, http.StatusNotFound, map[string]string{
-				"error": "webhook not found",
-			})
-			return
-		}
-
-		h.Logger.Printf("error getting webhook: %v", err)
-
-		utils.RenderJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+	webhook, ok := h.userWebhook(w, r)
+	if !ok {
 		return
 	}
-	dto := dtos.NewWebhookDTO(*webhook)
-	utils.RenderJSON(w, http.StatusOK, dto)
+	utils.RenderJSON(w, http.StatusOK, dtos.NewWebhookDTO(*webhook))
 }
 
-// UpdateWebhookApi Updates a webhook
-// @Summary  Updates a webhook
-// @Description Updates a webhook
+// UpdateWebhookApi Replaces a webhook
+// @Summary  Replaces a webhook
+// @Description Replaces every field of a webhook. Omitted fields are reset to their defaults; use PATCH to change single fields
 // @Tags        Webhooks
 // @Produce     json
 // @Security     ApiKeyAuth
 // @Param        id   path      string  true  "Webhook ID"
 // @Param        webhook body dtos.UpdateWebhookRequest true "Updated webhook"
-// @Success     200  {object} []dtos.Webhook
+// @Success     200  {object} dtos.Webhook
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
 // @Router      /webhooks/{id} [put]
 func (h *WebhookAiHandler) UpdateWebhookApi(w http.ResponseWriter, r *http.Request) {
-	webhookID := chi.URLParam(r, "id")
-	user := middlewares.GetAPIAuthenticatedUser(r)
-	webhook, err := h.Service.GetUserWebhook(webhookID, user.ID)
-	if err != nil {
-		utils.RenderJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+	webhook, ok := h.userWebhook(w, r)
+	if !ok {
 		return
 	}
 
 	input := dtos.UpdateWebhookRequest{}
-
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		utils.RenderJSON(w, http.StatusBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
 		return
 	}
+	input.ApplyTo(webhook)
+	h.save(w, r, webhook)
+}
 
-	if webhook.Title != input.Title && input.Title != "" {
-		webhook.Title = input.Title
-	}
-
-	if webhook.ResponseCode != input.ResponseCode && input.ResponseCode != 0 {
-		webhook.ResponseCode = input.ResponseCode
-	}
-
-	if webhook.ResponseDelay != input.ResponseDelay && input.ResponseDelay != 0 {
-		webhook.ResponseDelay = input.ResponseDelay
-	}
-
-	if webhook.ContentType != &input.ContentType && input.ContentType != "" {
-		webhook.ContentType = &input.ContentType
+// PatchWebhookApi Updates some fields of a webhook
+// @Summary  Updates a webhook
+// @Description Changes only the fields present in the body. A field set to null is reset to its default
+// @Tags        Webhooks
+// @Produce     json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Webhook ID"
+// @Param        webhook body dtos.PatchWebhookRequest true "Fields to change"
+// @Success     200  {object} dtos.Webhook
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /webhooks/{id} [patch]
+func (h *WebhookAiHandler) PatchWebhookApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.userWebhook(w, r)
+	if !ok {
+		return
 	}
 
-	if webhook.Payload != &input.Payload {
-		webhook.Payload = &input.Payload
+	input := dtos.PatchWebhookRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
 	}
+	input.ApplyTo(webhook)
+	h.save(w, r, webhook)
+}
 
-	if webhook.NotifyOnEvent != input.NotifyOnEvent {
-		webhook.NotifyOnEvent = input.NotifyOnEvent
+// userWebhook loads the {id} webhook of the API user, writing an error response on failure.
+func (h *WebhookAiHandler) userWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	user := middlewares.GetAPIAuthenticatedUser(r)
+	webhook, err := h.Service.GetUserWebhook(chi.URLParam(r, "id"), user.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, false
 	}
+	return webhook, true
+}
 
+func (h *WebhookAiHandler) save(w http.ResponseWriter, r *http.Request, webhook *models.Webhook) {
 	webhook.UpdatedAt = time.Now().UTC()
-
 	if err := h.Service.UpdateWebhook(webhook); err != nil {
-		utils.RenderJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		renderError(w, r, h.Logger, err)
 		return
 	}
-
-	dto := dtos.NewWebhookDTO(*webhook)
-	utils.RenderJSON(w, http.StatusOK, dto)
+	utils.RenderJSON(w, http.StatusOK, dtos.NewWebhookDTO(*webhook))
 }
 
 // DeleteWebhookApi deletes a webhook
@@ -204,17 +195,104 @@ func (h *WebhookAiHandler) UpdateWebhookApi(w http.ResponseWriter, r *http.Reque
 // @Security     ApiKeyAuth
 // @Param        id   path      string  true  "Webhook ID"
 // @Success      204  {string}  string  "No Content"
-// @Failure      500  {object}  ErrorResponse
+// @Failure      401  {object}  dtos.Problem
+// @Failure      404  {object}  dtos.Problem
+// @Failure      500  {object}  dtos.Problem
 // @Router       /webhooks/{id} [delete]
 func (h *WebhookAiHandler) DeleteWebhookApi(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	user := middlewares.GetAPIAuthenticatedUser(r)
 	if err := h.Service.DeleteWebhook(id, user.ID); err != nil {
-		utils.RenderJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		renderError(w, r, h.Logger, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// maxSpecSize bounds the body of an apply request.
+const maxSpecSize = 1 << 20
+
+// ExportSpecApi exports webhooks as a spec file
+// @Summary     Export webhooks as code
+// @Description Describes every webhook of the user as a spec file that POST /spec/apply accepts
+// @Tags        Spec
+// @Produce     application/yaml,json
+// @Security    ApiKeyAuth
+// @Param       format  query  string  false  "yaml (default) or json"  Enums(yaml, json)
+// @Success     200  {object}  spec.File
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Router      /spec [get]
+func (h *WebhookAiHandler) ExportSpecApi(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetAPIAuthenticatedUser(r)
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = spec.FormatYAML
+	}
+	if format != spec.FormatYAML && format != spec.FormatJSON {
+		renderError(w, r, h.Logger, problem.BadRequest(fmt.Sprintf("unknown format %q", format)))
+		return
+	}
+
+	f, err := h.Spec.ExportSpec(user.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	if format == spec.FormatJSON {
+		utils.RenderJSON(w, http.StatusOK, f)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	if err := spec.Encode(w, f, format); err != nil {
+		h.Logger.Printf("error encoding spec: %v", err)
+	}
+}
+
+// ApplySpecApi syncs webhooks to a spec file
+// @Summary     Apply webhooks as code
+// @Description Creates and updates webhooks so they match a YAML or JSON spec file. With prune, webhooks missing from the file are deleted. With dry_run, only the plan is returned
+// @Tags        Spec
+// @Accept      application/yaml,json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       spec     body   spec.File  true   "Spec file"
+// @Param       prune    query  bool       false  "Delete webhooks the file doesn't mention"
+// @Param       dry_run  query  bool       false  "Plan without changing anything"
+// @Success     200  {object}  spec.Plan
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     409  {object}  dtos.Problem
+// @Failure     413  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /spec/apply [post]
+func (h *WebhookAiHandler) ApplySpecApi(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetAPIAuthenticatedUser(r)
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSpecSize))
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	f, err := spec.Parse(raw)
+	if err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+
+	opts := service.ApplyOptions{
+		Prune:  r.URL.Query().Get("prune") == "true",
+		DryRun: r.URL.Query().Get("dry_run") == "true",
+	}
+	plan, err := h.Spec.ApplySpec(user.ID, f, opts)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	if plan.Applied {
+		for range plan.Count(spec.Create) {
+			h.Metrics.IncWebhooksCreated()
+		}
+	}
+	utils.RenderJSON(w, http.StatusOK, plan)
+}
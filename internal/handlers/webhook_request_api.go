@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/middlewares"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 100
+)
+
+type WebhookRequestApiHandler struct {
+	Webhooks    *service.WebhookService
+	Requests    *service.WebhookRequestService
+	Retention   *service.RetentionService
+	Replays     *service.ReplayService
+	Jobs        *service.ReplayJobService
+	Comparisons *service.ComparisonService
+	Sender      *service.SenderService
+	Deliveries  *service.DeliveryService
+	Loads       *service.LoadService
+	Callbacks   *service.CallbackService
+	Scripts     *service.ScriptService
+	Logger      *log.Logger
+}
+
+func NewWebhookRequestApiHandler(ws *service.WebhookService, rs *service.WebhookRequestService, ret *service.RetentionService, rp *service.ReplayService, jobs *service.ReplayJobService, cs *service.ComparisonService, sender *service.SenderService, ds *service.DeliveryService, ls *service.LoadService, cb *service.CallbackService, sc *service.ScriptService, l *log.Logger) *WebhookRequestApiHandler {
+	return &WebhookRequestApiHandler{Webhooks: ws, Requests: rs, Retention: ret, Replays: rp, Jobs: jobs, Comparisons: cs, Sender: sender, Deliveries: ds, Loads: ls, Callbacks: cb, Scripts: sc, Logger: l}
+}
+
+// ListRequestsApi lists the requests received by a webhook
+// @Summary     List webhook requests
+// @Description Lists requests received by a webhook, newest first, one page at a time
+// @Tags        Requests
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id        path   string  true   "Webhook ID"
+// @Param       page      query  int     false  "Page number, starting at 1"
+// @Param       per_page  query  int     false  "Requests per page (max 100)"
+// @Success     200  {object}  dtos.RequestPage
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests [get]
+func (h *WebhookRequestApiHandler) ListRequestsApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	page := queryInt(r, "page", 1)
+	perPage := queryInt(r, "per_page", defaultPerPage)
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+
+	requests, total, err := h.Requests.ListPage(webhook.ID, page, perPage)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+
+	out := dtos.RequestPage{Data: make([]dtos.WebhookRequest, 0, len(requests)), Page: page, PerPage: perPage, Total: total}
+	for _, wr := range requests {
+		out.Data = append(out.Data, dtos.NewWebhookRequestDTO(wr))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetRequestApi gets a single request
+// @Summary     Get webhook request
+// @Description Get a single request received by a webhook
+// @Tags        Requests
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       requestID   path  string  true  "Request ID"
+// @Success     200  {object}  dtos.WebhookRequest
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID} [get]
+func (h *WebhookRequestApiHandler) GetRequestApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewWebhookRequestDTO(*wr))
+}
+
+// GetRequestSnippetsApi renders a request as code
+// @Summary     Get code snippets for a request
+// @Description Renders a captured request as code that sends it again, in curl, HTTPie, Go net/http, Python requests, Node fetch and PowerShell. Binary bodies are embedded exactly, as base64 or byte escapes
+// @Tags        Requests
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path   string    true   "Webhook ID"
+// @Param       requestID   path   string    true   "Request ID"
+// @Param       lang        query  []string  false  "Only these languages"  collectionFormat(multi)  Enums(curl, httpie, go, python, node, powershell)
+// @Param       target      query  string    false  "URL to send the request to (default the webhook URL)"
+// @Success     200  {array}   dtos.Snippet
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID}/snippets [get]
+func (h *WebhookRequestApiHandler) GetRequestSnippetsApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	snippets, err := requestSnippets(r, r.URL.Query(), wr)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, snippets)
+}
+
+// ReplayRequestApi replays a request
+// @Summary     Replay a request
+// @Description Sends a captured request to a URL, by default the webhook itself, after applying the given edits, and stores the attempt with the target's status, headers, body (up to 1 MiB) and timing. Redirects are not followed. When the target can't be reached, or is on a loopback, private or link-local address the server doesn't allow, the attempt is still stored, with error set
+// @Tags        Replays
+// @Accept      json
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string              true  "Webhook ID"
+// @Param       requestID   path  string              true  "Request ID"
+// @Param       replay      body  dtos.ReplayRequest  true  "Target and edits"
+// @Success     201  {object}  dtos.ReplayAttempt
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID}/replays [post]
+func (h *WebhookRequestApiHandler) ReplayRequestApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	var in dtos.ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		renderError(w, r, h.Logger, problem.BadRequest(err.Error()))
+		return
+	}
+	attempt, err := h.Replays.Replay(r.Context(), wr, replayOptions(r, wr, in))
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusCreated, dtos.NewReplayAttemptDTO(*attempt))
+}
+
+// ListReplaysApi lists the replays of a request
+// @Summary     List replay attempts
+// @Description Lists the replay attempts of a request, newest first
+// @Tags        Replays
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       requestID   path  string  true  "Request ID"
+// @Success     200  {array}   dtos.ReplayAttempt
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID}/replays [get]
+func (h *WebhookRequestApiHandler) ListReplaysApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	attempts, err := h.Replays.List(wr.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	out := make([]dtos.ReplayAttempt, 0, len(attempts))
+	for _, a := range attempts {
+		out = append(out, dtos.NewReplayAttemptDTO(a))
+	}
+	utils.RenderJSON(w, http.StatusOK, out)
+}
+
+// GetReplayApi gets a single replay attempt
+// @Summary     Get replay attempt
+// @Description Gets one replay attempt of a request with the target's response
+// @Tags        Replays
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       requestID   path  string  true  "Request ID"
+// @Param       attemptID   path  string  true  "Replay attempt ID"
+// @Success     200  {object}  dtos.ReplayAttempt
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID}/replays/{attemptID} [get]
+func (h *WebhookRequestApiHandler) GetReplayApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	attempt, err := h.Replays.Get(chi.URLParam(r, "attemptID"))
+	if err == nil && attempt.RequestID != wr.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "replay attempt not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusOK, dtos.NewReplayAttemptDTO(*attempt))
+}
+
+// DeleteRequestApi deletes a single request
+// @Summary     Delete webhook request
+// @Description Deletes a single request received by a webhook
+// @Tags        Requests
+// @Security    ApiKeyAuth
+// @Param       id          path  string  true  "Webhook ID"
+// @Param       requestID   path  string  true  "Request ID"
+// @Success     204  {string}  string  "No Content"
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/{requestID} [delete]
+func (h *WebhookRequestApiHandler) DeleteRequestApi(w http.ResponseWriter, r *http.Request) {
+	wr, ok := h.ownedRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Requests.Delete(wr.ID); err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteRequestsApi deletes every request of a webhook
+// @Summary     Delete all webhook requests
+// @Description Deletes every request received by a webhook
+// @Tags        Requests
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     204  {string}  string  "No Content"
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests [delete]
+func (h *WebhookRequestApiHandler) DeleteRequestsApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Requests.DeleteAll(webhook.ID); err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExportRequestsApi downloads the requests of a webhook
+// @Summary     Export webhook requests
+// @Description Streams the requests received by a webhook, oldest first, as HAR 1.2, newline-delimited JSON, CSV, a Postman v2.1 collection or an Insomnia v4 export. Collections keep the target in a baseUrl variable that defaults to the webhook URL
+// @Tags        Requests
+// @Produce     json,application/x-ndjson,text/csv
+// @Security    ApiKeyAuth
+// @Param       id      path   string    true   "Webhook ID"
+// @Param       format  query  string    false  "Export format (default har)"  Enums(har, jsonl, csv, postman, insomnia)
+// @Param       since   query  string    false  "Only requests received at or after this RFC 3339 time"
+// @Param       until   query  string    false  "Only requests received before this RFC 3339 time"
+// @Param       method  query  []string  false  "Only requests with these methods"  collectionFormat(multi)
+// @Param       source  query  string    false  "Only live, imported or sent requests"  Enums(live, import, sent)
+// @Param       id      query  []string  false  "Only these requests"  collectionFormat(multi)
+// @Param       name    query  string    false  "Collection name (default the webhook title)"
+// @Success     200  {file}    file
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/export [get]
+func (h *WebhookRequestApiHandler) ExportRequestsApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	exportRequests(w, r, h.Logger, h.Requests, webhook)
+}
+
+// ImportRequestsApi stores the requests of a HAR or JSONL file
+// @Summary     Import webhook requests
+// @Description Stores every request of a HAR 1.2 or JSONL file (such as an export) under a webhook, marked with source "import". The file is imported completely or not at all. Requests keep their original timestamps and follow the webhook's retention policy
+// @Tags        Requests
+// @Accept      json,application/x-ndjson
+// @Produce     json
+// @Security    ApiKeyAuth
+// @Param       id      path   string  true   "Webhook ID"
+// @Param       format  query  string  false  "File format, detected when omitted"  Enums(har, jsonl)
+// @Param       file    body   string  true   "HAR or JSONL document"
+// @Success     201  {object}  dtos.ImportResult
+// @Failure     400  {object}  dtos.Problem
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Failure     413  {object}  dtos.Problem
+// @Failure     422  {object}  dtos.Problem
+// @Failure     507  {object}  dtos.Problem
+// @Router      /webhooks/{id}/requests/import [post]
+func (h *WebhookRequestApiHandler) ImportRequestsApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	n, err := importRequests(w, h.Logger, body, r.URL.Query().Get("format"), h.Requests, h.Retention, webhook)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return
+	}
+	utils.RenderJSON(w, http.StatusCreated, dtos.ImportResult{Imported: n})
+}
+
+// StreamRequestsApi streams new requests as server-sent events
+// @Summary     Stream webhook requests
+// @Description Streams each new request received by a webhook as a server-sent event whose data is a WebhookRequest
+// @Tags        Requests
+// @Produce     text/event-stream
+// @Security    ApiKeyAuth
+// @Param       id  path  string  true  "Webhook ID"
+// @Success     200  {object}  dtos.WebhookRequest
+// @Failure     401  {object}  dtos.Problem
+// @Failure     404  {object}  dtos.Problem
+// @Router      /webhooks/{id}/stream [get]
+func (h *WebhookRequestApiHandler) StreamRequestsApi(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderError(w, r, h.Logger, errors.New("streaming unsupported"))
+		return
+	}
+
+	events := subscribe(webhook.ID)
+	defer unsubscribe(webhook.ID, events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// comments keep idle connections open through proxies
+	ping := time.NewTicker(15 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case msg := <-events:
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// ownedWebhook loads the {id} webhook for the API user, writing a 404 when it
+// doesn't exist or belongs to someone else.
+func (h *WebhookRequestApiHandler) ownedWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	user := middlewares.GetAPIAuthenticatedUser(r)
+	webhook, err := h.Webhooks.GetUserWebhook(chi.URLParam(r, "id"), user.ID)
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, false
+	}
+	return webhook, true
+}
+
+// ownedRequest loads the {requestID} request, which must belong to the {id} webhook.
+func (h *WebhookRequestApiHandler) ownedRequest(w http.ResponseWriter, r *http.Request) (*models.WebhookRequest, bool) {
+	webhook, ok := h.ownedWebhook(w, r)
+	if !ok {
+		return nil, false
+	}
+	wr, err := h.Requests.Get(chi.URLParam(r, "requestID"))
+	if err == nil && wr.WebhookID != webhook.ID {
+		// don't reveal requests of other webhooks
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "request not found")
+	}
+	if err != nil {
+		renderError(w, r, h.Logger, err)
+		return nil, false
+	}
+	return wr, true
+}
+
+// queryInt reads a positive integer query parameter, falling back to def.
+func queryInt(r *http.Request, key string, def int) int {
+	n, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil || n < 1 {
+		return def
+	}
+	return n
+}
@@ -8,11 +8,12 @@ import (
 	"log"
 	"net/http"
 	"net/url"
-	"os"
-	"strings"
 	"time"
+	"webhook-tester/internal/compare"
+	"webhook-tester/internal/dtos"
 	"webhook-tester/internal/metrics"
 	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
 	"webhook-tester/internal/service"
 	"webhook-tester/internal/utils"
 
@@ -21,6 +22,14 @@ import (
 
 type WebhookRequestHandler struct {
 	reqService     *service.WebhookRequestService
+	retentionSvc   *service.RetentionService
+	replaySvc      *service.ReplayService
+	replayJobSvc   *service.ReplayJobService
+	comparisonSvc  *service.ComparisonService
+	senderSvc      *service.SenderService
+	deliverySvc    *service.DeliveryService
+	loadSvc        *service.LoadService
+	callbackSvc    *service.CallbackService
 	authSvc        *service.AuthService
 	metrics        *metrics.Recorder
 	logger         *log.Logger
@@ -33,10 +42,18 @@ func NewWebhookRequestHandler(
 	reqSvc *service.WebhookRequestService,
 	authSvc *service.AuthService,
 	webhookSvc *service.WebhookService,
+	retentionSvc *service.RetentionService,
+	replaySvc *service.ReplayService,
+	replayJobSvc *service.ReplayJobService,
+	comparisonSvc *service.ComparisonService,
+	senderSvc *service.SenderService,
+	deliverySvc *service.DeliveryService,
+	loadSvc *service.LoadService,
+	callbackSvc *service.CallbackService,
 	metricsRec *metrics.Recorder,
 	logger *log.Logger,
 ) *WebhookRequestHandler {
-	return &WebhookRequestHandler{reqService: reqSvc, webhookService: webhookSvc, metrics: metricsRec, logger: logger, authSvc: authSvc}
+	return &WebhookRequestHandler{reqService: reqSvc, webhookService: webhookSvc, retentionSvc: retentionSvc, replaySvc: replaySvc, replayJobSvc: replayJobSvc, comparisonSvc: comparisonSvc, senderSvc: senderSvc, deliverySvc: deliverySvc, loadSvc: loadSvc, callbackSvc: callbackSvc, metrics: metricsRec, logger: logger, authSvc: authSvc}
 }
 
 func (h *WebhookRequestHandler) GetRequest(w http.ResponseWriter, r *http.Request) {
@@ -47,62 +64,102 @@ func (h *WebhookRequestHandler) GetRequest(w http.ResponseWriter, r *http.Reques
 	// 2) Load the webhook and its requests via the service
 	wh, err := h.webhookService.GetWebhookWithRequests(address)
 	if err != nil {
-		h.logger.Printf("failed to load webhook %s: %v", address, err)
-		http.Error(w, "could not load webhook", http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
 		return
 	}
 
 	// 3) Load the individual request via the service
 	reqEvent, err := h.reqService.Get(reqID)
+	if err == nil && reqEvent.WebhookID != wh.ID {
+		err = problem.New(http.StatusNotFound, problem.CodeNotFound, "request not found")
+	}
 	if err != nil {
-		h.logger.Printf("request %s not found: %v", reqID, err)
-		http.NotFound(w, r)
+		renderError(w, r, h.logger, err)
 		return
 	}
 
-	// 4) Build the sidebar list: either the user’s own webhooks, or just the one
-	user, _ := h.authSvc.GetCurrentUser(r)
-	var list []models.Webhook
-	if user.ID != 0 {
-		if list, err = h.webhookService.ListWebhooks(user.ID); err != nil {
-			h.logger.Printf("failed to list webhooks for user %d: %v", user.ID, err)
-			http.Error(w, "could not load your webhooks", http.StatusInternalServerError)
-			return
-		}
-	} else {
-		list = []models.Webhook{*wh}
+	h.renderRequest(w, r, http.StatusOK, wh, reqEvent, newReplayForm(r, reqEvent, wh), compareForm{Resign: wh.SigningScheme != ""})
+}
+
+// renderRequest renders the request page with the given replay and
+// comparison forms.
+func (h *WebhookRequestHandler) renderRequest(w http.ResponseWriter, r *http.Request, status int, wh *models.Webhook, reqEvent *models.WebhookRequest, form replayForm, cmp compareForm) {
+	// 1) Build the sidebar list: either the user’s own webhooks, or just the one
+	user, list, err := h.sidebar(r, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	// 2) Snippets, aimed at the target the visitor typed in if it's valid
+	target := r.URL.Query().Get("target")
+	snippets, snippetErr := requestSnippets(r, url.Values{"target": {target}}, reqEvent)
+	if snippetErr != nil {
+		snippets, _ = requestSnippets(r, nil, reqEvent)
+	}
+
+	// 3) Earlier replays
+	replays, err := h.replaySvc.List(reqEvent.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+
+	// 4) Earlier comparisons
+	comparisons, err := h.comparisonSvc.List(reqEvent.ID)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
 	}
 
 	// 5) Render
 	data := struct {
-		ID        string
-		Year      int
-		User      models.User
-		Webhooks  []models.Webhook
-		Webhook   *models.Webhook
-		Request   *models.WebhookRequest
-		CSRFField template.HTML
+		ID            string
+		Year          int
+		User          models.User
+		Webhooks      []models.Webhook
+		Webhook       *models.Webhook
+		Request       *models.WebhookRequest
+		Snippets      []dtos.Snippet
+		Target        string
+		SnippetError  string
+		Replay        replayForm
+		Replays       []models.ReplayAttempt
+		Compare       compareForm
+		DefaultIgnore []string
+		Comparisons   []comparisonView
+		CSRFField     template.HTML
 	}{
-		ID:        reqID,
-		Year:      time.Now().Year(),
-		User:      *user,
-		Webhooks:  list,
-		Webhook:   wh,
-		Request:   reqEvent,
-		CSRFField: csrf.TemplateField(r),
+		ID:            reqEvent.ID,
+		Year:          time.Now().Year(),
+		User:          *user,
+		Webhooks:      list,
+		Webhook:       wh,
+		Request:       reqEvent,
+		Snippets:      snippets,
+		Target:        target,
+		Replay:        form,
+		Replays:       replays,
+		Compare:       cmp,
+		DefaultIgnore: compare.DefaultIgnore,
+		Comparisons:   newComparisonViews(comparisons),
+		CSRFField:     csrf.TemplateField(r),
+	}
+	if snippetErr != nil {
+		data.SnippetError = problem.From(snippetErr).Detail
 	}
 
+	w.WriteHeader(status)
 	utils.RenderHtml(w, r, "request", data)
 }
 
 func (h *WebhookRequestHandler) DeleteRequest(w http.ResponseWriter, r *http.Request) {
-	requestId := chi.URLParam(r, "id")
-
-	err := h.reqService.Delete(requestId)
-
+	reqEvent, _, err := h.accessibleRequest(r)
+	if err == nil {
+		err = h.reqService.Delete(reqEvent.ID)
+	}
 	if err != nil {
-		h.logger.Printf("failed to delete webhook %s: %v", requestId, err)
-		http.Error(w, "could not delete webhook", http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
 		return
 	}
 
@@ -115,57 +172,134 @@ func (h *WebhookRequestHandler) DeleteRequest(w http.ResponseWriter, r *http.Req
 	http.Redirect(w, r, referer, http.StatusFound)
 }
 
-// ReplayRequest re‐sends a stored webhook request via your services.
-func (h *WebhookRequestHandler) ReplayRequest(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
+// PinRequest pins or unpins a stored request so retention never removes it.
+func (h *WebhookRequestHandler) PinRequest(w http.ResponseWriter, r *http.Request) {
+	pinned := r.FormValue("pinned") == "true"
 
-	reqEvent, err := h.reqService.Get(id)
+	reqEvent, _, err := h.accessibleRequest(r)
+	if err == nil {
+		err = h.reqService.SetPinned(reqEvent.ID, pinned)
+	}
 	if err != nil {
-		h.logger.Printf("replay: request %s not found: %v", id, err)
-		http.Error(w, "request not found", http.StatusNotFound)
+		renderError(w, r, h.logger, err)
 		return
 	}
 
-	domain := os.Getenv("DOMAIN")
-	target, err := url.JoinPath(domain, "webhooks", reqEvent.WebhookID)
+	referer := r.Referer()
+	if referer == "" {
+		referer = "/" // fallback
+	}
+
+	http.Redirect(w, r, referer, http.StatusFound)
+}
+
+// sidebar returns the visitor, who must be allowed to see wh, and the webhooks
+// listed in the sidebar: their own, or just wh for guests.
+func (h *WebhookRequestHandler) sidebar(r *http.Request, wh *models.Webhook) (*models.User, []models.Webhook, error) {
+	user, err := h.authSvc.GetCurrentUser(r)
 	if err != nil {
-		h.logger.Printf("replay: invalid target URL: %v", err)
-		http.Error(w, "could not construct replay URL", http.StatusInternalServerError)
+		user = &models.User{} // guest
+	}
+	if err := h.webhookService.CheckAccess(wh, user.ID); err != nil {
+		return nil, nil, err
+	}
+	if user.ID == 0 {
+		return user, []models.Webhook{*wh}, nil
+	}
+	list, err := h.webhookService.ListWebhooks(user.ID)
+	return user, list, err
+}
+
+// ReplayRequest sends a stored request, with the edits of the replay form,
+// to the target of the form and records the response.
+func (h *WebhookRequestHandler) ReplayRequest(w http.ResponseWriter, r *http.Request) {
+	reqEvent, wh, err := h.accessibleRequest(r)
+	if err != nil {
+		renderError(w, r, h.logger, err)
 		return
 	}
 
-	parsed, _ := url.Parse(target)
-	q := parsed.Query()
-	for k, v := range reqEvent.Query {
-		if s, ok := v.(string); ok {
-			q.Set(k, s)
+	form, opts, err := parseReplayForm(r, reqEvent, wh)
+	if err == nil {
+		_, err = h.replaySvc.Replay(r.Context(), reqEvent, opts)
+	}
+	if err != nil {
+		p := problem.From(err)
+		if p.Status == http.StatusInternalServerError {
+			renderError(w, r, h.logger, err)
+			return
 		}
+		// show what's wrong next to the visitor's edits
+		form.Error, form.Errors = p.Detail, p.Errors
+		if len(p.Errors) > 0 {
+			form.Error = ""
+		}
+		h.renderRequest(w, r, p.Status, wh, reqEvent, form, compareForm{Resign: wh.SigningScheme != ""})
+		return
+	}
+
+	redirectURL := fmt.Sprintf("/requests/%s?address=%s#replays", reqEvent.ID, reqEvent.WebhookID)
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
+
+// accessibleRequest loads the {id} request and its webhook if the visitor may
+// manage the webhook.
+func (h *WebhookRequestHandler) accessibleRequest(r *http.Request) (*models.WebhookRequest, *models.Webhook, error) {
+	reqEvent, err := h.reqService.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, nil, err
 	}
-	parsed.RawQuery = q.Encode()
+	wh, err := h.webhookService.GetWebhook(reqEvent.WebhookID)
+	if err != nil {
+		return nil, nil, err
+	}
+	userID, _ := h.authSvc.Authorize(r)
+	return reqEvent, wh, h.webhookService.CheckAccess(wh, userID)
+}
 
-	bodyReader := strings.NewReader(reqEvent.Body)
-	outReq, err := http.NewRequest(reqEvent.Method, parsed.String(), bodyReader)
+// ExportRequests downloads the requests of the {id} webhook.
+func (h *WebhookRequestHandler) ExportRequests(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckAccess(wh, userID)
+	}
 	if err != nil {
-		h.logger.Printf("replay: error creating HTTP request: %v", err)
-		http.Error(w, "error creating request", http.StatusInternalServerError)
+		renderError(w, r, h.logger, err)
 		return
 	}
-	for k, v := range reqEvent.Headers {
-		if s, ok := v.(string); ok {
-			outReq.Header.Set(k, s)
-		}
+	exportRequests(w, r, h.logger, h.reqService, wh)
+}
+
+// ImportRequests stores the requests of an uploaded HAR or JSONL file under
+// the {id} webhook.
+func (h *WebhookRequestHandler) ImportRequests(w http.ResponseWriter, r *http.Request) {
+	wh, err := h.webhookService.GetWebhook(chi.URLParam(r, "id"))
+	if err == nil {
+		userID, _ := h.authSvc.Authorize(r)
+		err = h.webhookService.CheckAccess(wh, userID)
+	}
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(outReq)
+	file, header, err := r.FormFile("file")
 	if err != nil {
-		h.logger.Printf("replay: error sending request: %v", err)
-		http.Error(w, "error sending request", http.StatusBadGateway)
+		renderError(w, r, h.logger, problem.BadRequest("choose a HAR or JSONL file to import"))
+		return
+	}
+	defer file.Close()
+	if header.Size > maxImportSize {
+		renderError(w, r, h.logger, &http.MaxBytesError{Limit: maxImportSize})
 		return
 	}
-	defer resp.Body.Close()
 
-	// 6) Redirect back to the request details page
-	redirectURL := fmt.Sprintf("/requests/%s?address=%s", reqEvent.ID, reqEvent.WebhookID)
-	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+	n, err := importRequests(w, h.logger, file, "", h.reqService, h.retentionSvc, wh)
+	if err != nil {
+		renderError(w, r, h.logger, err)
+		return
+	}
+	h.logger.Printf("imported %d requests into %s", n, wh.ID)
+	http.Redirect(w, r, fmt.Sprintf("/?address=%s", wh.ID), http.StatusSeeOther)
 }
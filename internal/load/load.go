@@ -0,0 +1,162 @@
+// Package load sums up the results of firing many requests at a target:
+// status codes, errors, latency percentiles and throughput.
+//
+// Latencies are those of requests that got a response, whatever its status;
+// requests that got none, such as timeouts and refused connections, are
+// counted as errors and left out of the percentiles so they don't skew them.
+package load
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxErrorKinds is how many distinct error messages a Summary lists; any
+// others are counted under OtherErrors.
+const MaxErrorKinds = 10
+
+// OtherErrors is the message errors beyond the first MaxErrorKinds are
+// counted under.
+const OtherErrors = "other errors"
+
+// Result is the outcome of one request.
+type Result struct {
+	StatusCode int           // 0 when the request got no response
+	Error      string        // why the request got no response
+	Latency    time.Duration // from sending to the end of the response
+}
+
+// StatusCount is how many responses had a status code.
+type StatusCount struct {
+	Code  int `json:"code"`
+	Count int `json:"count"`
+}
+
+// ErrorCount is how many requests failed with an error message.
+type ErrorCount struct {
+	Error string `json:"error"`
+	Count int    `json:"count"`
+}
+
+// Summary describes the results recorded so far.
+type Summary struct {
+	Requests    int           // completed, with or without a response
+	Succeeded   int           // answered 2xx
+	Failed      int           // answered with another status
+	Errors      int           // got no response
+	StatusCodes []StatusCount // by code
+	ErrorCounts []ErrorCount  // most frequent first
+	Min         time.Duration
+	Mean        time.Duration
+	P50         time.Duration
+	P90         time.Duration
+	P95         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+	Elapsed     time.Duration
+	Throughput  float64 // completed requests per second of Elapsed
+}
+
+// Recorder collects results from concurrent requests.
+type Recorder struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	codes     map[int]int
+	errors    map[string]int
+	requests  int
+	succeeded int
+	failed    int
+	errored   int
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{codes: map[int]int{}, errors: map[string]int{}}
+}
+
+// Add records the outcome of one request.
+func (r *Recorder) Add(res Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests++
+	if res.StatusCode == 0 {
+		r.errored++
+		msg := res.Error
+		if _, ok := r.errors[msg]; !ok && len(r.errors) >= MaxErrorKinds {
+			msg = OtherErrors
+		}
+		r.errors[msg]++
+		return
+	}
+	r.latencies = append(r.latencies, res.Latency)
+	r.codes[res.StatusCode]++
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		r.succeeded++
+	} else {
+		r.failed++
+	}
+}
+
+// Summary sums up the results recorded so far, elapsed after the first
+// request was sent.
+func (r *Recorder) Summary(elapsed time.Duration) Summary {
+	r.mu.Lock()
+	latencies := append([]time.Duration(nil), r.latencies...)
+	s := Summary{
+		Requests:    r.requests,
+		Succeeded:   r.succeeded,
+		Failed:      r.failed,
+		Errors:      r.errored,
+		StatusCodes: make([]StatusCount, 0, len(r.codes)),
+		ErrorCounts: make([]ErrorCount, 0, len(r.errors)),
+		Elapsed:     elapsed,
+	}
+	for code, n := range r.codes {
+		s.StatusCodes = append(s.StatusCodes, StatusCount{Code: code, Count: n})
+	}
+	for msg, n := range r.errors {
+		s.ErrorCounts = append(s.ErrorCounts, ErrorCount{Error: msg, Count: n})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(s.StatusCodes, func(i, j int) bool { return s.StatusCodes[i].Code < s.StatusCodes[j].Code })
+	sort.Slice(s.ErrorCounts, func(i, j int) bool {
+		a, b := s.ErrorCounts[i], s.ErrorCounts[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Error < b.Error
+	})
+	if elapsed > 0 {
+		s.Throughput = float64(s.Requests) / elapsed.Seconds()
+	}
+	if len(latencies) == 0 {
+		return s
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+	}
+	s.Min, s.Max = latencies[0], latencies[len(latencies)-1]
+	s.Mean = sum / time.Duration(len(latencies))
+	s.P50 = Percentile(latencies, 50)
+	s.P90 = Percentile(latencies, 90)
+	s.P95 = Percentile(latencies, 95)
+	s.P99 = Percentile(latencies, 99)
+	return s
+}
+
+// Percentile returns the nearest-rank p-th percentile of sorted, which must
+// be in ascending order, or 0 when it is empty.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
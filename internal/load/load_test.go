@@ -0,0 +1,88 @@
+package load_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"webhook-tester/internal/load"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ms(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, ms(i))
+	}
+	assert.Equal(t, ms(50), load.Percentile(sorted, 50))
+	assert.Equal(t, ms(99), load.Percentile(sorted, 99))
+	assert.Equal(t, ms(100), load.Percentile(sorted, 100))
+	assert.Equal(t, ms(1), load.Percentile(sorted, 0))
+
+	assert.Equal(t, ms(7), load.Percentile([]time.Duration{ms(7)}, 99))
+	assert.Equal(t, ms(2), load.Percentile([]time.Duration{ms(1), ms(2), ms(3)}, 50))
+	assert.Zero(t, load.Percentile(nil, 50))
+}
+
+func TestSummary(t *testing.T) {
+	r := load.NewRecorder()
+	var wg sync.WaitGroup
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code := 200
+			switch {
+			case i == 9:
+				code = 500
+			case i == 10:
+				code = 429
+			}
+			r.Add(load.Result{StatusCode: code, Latency: ms(i * 10)})
+		}()
+	}
+	wg.Wait()
+	r.Add(load.Result{Error: "connection refused"})
+	r.Add(load.Result{Error: "no response within 1s", Latency: ms(1000)})
+	r.Add(load.Result{Error: "connection refused"})
+
+	s := r.Summary(2 * time.Second)
+	assert.Equal(t, 13, s.Requests)
+	assert.Equal(t, 8, s.Succeeded)
+	assert.Equal(t, 2, s.Failed)
+	assert.Equal(t, 3, s.Errors)
+	assert.Equal(t, []load.StatusCount{{Code: 200, Count: 8}, {Code: 429, Count: 1}, {Code: 500, Count: 1}}, s.StatusCodes)
+	assert.Equal(t, []load.ErrorCount{{Error: "connection refused", Count: 2}, {Error: "no response within 1s", Count: 1}}, s.ErrorCounts)
+	assert.Equal(t, ms(10), s.Min)
+	assert.Equal(t, ms(55), s.Mean)
+	assert.Equal(t, ms(50), s.P50)
+	assert.Equal(t, ms(90), s.P90)
+	assert.Equal(t, ms(100), s.P99)
+	assert.Equal(t, ms(100), s.Max, "requests without a response are left out of the latencies")
+	assert.InDelta(t, 6.5, s.Throughput, 0.001)
+}
+
+func TestSummaryEmpty(t *testing.T) {
+	s := load.NewRecorder().Summary(0)
+	assert.Zero(t, s.Requests)
+	assert.Zero(t, s.P99)
+	assert.Zero(t, s.Throughput)
+	assert.NotNil(t, s.StatusCodes)
+	assert.NotNil(t, s.ErrorCounts)
+}
+
+func TestErrorKinds(t *testing.T) {
+	r := load.NewRecorder()
+	for i := 0; i < load.MaxErrorKinds+5; i++ {
+		r.Add(load.Result{Error: fmt.Sprintf("error %02d", i)})
+	}
+	r.Add(load.Result{Error: "error 00"})
+
+	s := r.Summary(time.Second)
+	assert.Len(t, s.ErrorCounts, load.MaxErrorKinds+1)
+	assert.Equal(t, load.ErrorCount{Error: load.OtherErrors, Count: 5}, s.ErrorCounts[0])
+	assert.Equal(t, load.ErrorCount{Error: "error 00", Count: 2}, s.ErrorCounts[1])
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"webhook-tester/internal/models"
+	"webhook-tester/internal/problem"
 	"webhook-tester/internal/service"
 )
 
@@ -16,13 +17,13 @@ func RequireAPIKey(auth *service.AuthService) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			apiKey := r.Header.Get("X-API-Key")
 			if apiKey == "" {
-				http.Error(w, "API key missing", http.StatusUnauthorized)
+				problem.Write(w, r, problem.Unauthorized("API key missing"))
 				return
 			}
 
 			user, err := auth.ValidateAPIKey(apiKey)
 			if err != nil {
-				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				problem.Write(w, r, err)
 				return
 			}
 
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Callback states. A callback is scheduled until it's sent or cancelled.
+const (
+	CallbackScheduled = "scheduled"
+	CallbackSucceeded = "succeeded" // the target answered 2xx
+	CallbackFailed    = "failed"    // no 2xx, no URL or the server restarted
+	CallbackCancelled = "cancelled"
+)
+
+// CallbackAction makes a webhook call back some time after it receives a
+// request, like an asynchronous API reporting the outcome of a call. Headers
+// and Body are templates rendered with the received request, see package
+// callback.
+type CallbackAction struct {
+	ID        string            `gorm:"primaryKey" json:"id"`
+	WebhookID string            `json:"webhook_id"`
+	Name      string            `json:"name"`
+	Enabled   bool              `json:"enabled"`
+	DelayMs   int64             `json:"delay_ms"` // after the request is received
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`      // used when URLFrom is empty or not in the request
+	URLFrom   string            `json:"url_from"` // path of the URL in the received request, like body.callback_url
+	Headers   datatypes.JSONMap `json:"headers"`
+	Body      string            `json:"body"`
+	Sign      bool              `json:"sign"` // sign with the webhook's signing secret
+	TimeoutMs int64             `json:"timeout_ms"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Callback is one call made, or to be made, by a CallbackAction after a
+// request was received.
+type Callback struct {
+	ID         string     `gorm:"primaryKey" json:"id"`
+	WebhookID  string     `json:"webhook_id"`
+	ActionID   string     `json:"action_id"`
+	ActionName string     `json:"action_name"` // kept when the action is deleted
+	RequestID  string     `json:"request_id"`  // the received request
+	Status     string     `json:"status"`
+	DueAt      time.Time  `json:"due_at"`
+	URL        string     `json:"url"`        // as sent
+	AttemptID  string     `json:"attempt_id"` // the ReplayAttempt recording the exchange
+	StatusCode int        `json:"status_code"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error"`
+	CreatedAt  time.Time  `json:"created_at"`
+	SentAt     *time.Time `json:"sent_at"`
+}
+
+// Done reports whether the callback was sent or won't be.
+func (c *Callback) Done() bool {
+	return c.Status != CallbackScheduled
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+	"webhook-tester/internal/compare"
+
+	"gorm.io/datatypes"
+)
+
+// Comparison batch states.
+const (
+	ComparisonBatchRunning   = "running"
+	ComparisonBatchCompleted = "completed"
+	ComparisonBatchFailed    = "failed" // the batch itself broke, e.g. the server restarted
+)
+
+// Comparison is one stored request sent to a baseline and a candidate target,
+// with the differences between their responses.
+type Comparison struct {
+	ID                 string                                  `gorm:"primaryKey" json:"id"`
+	WebhookID          string                                  `json:"webhook_id"`
+	RequestID          string                                  `json:"request_id"`
+	BatchID            string                                  `json:"batch_id"` // empty unless part of a ComparisonBatch
+	BaselineURL        string                                  `json:"baseline_url"`
+	CandidateURL       string                                  `json:"candidate_url"`
+	Ignore             datatypes.JSONSlice[string]             `json:"ignore"`               // patterns left out of the diff
+	BaselineAttemptID  string                                  `json:"baseline_attempt_id"`  // the ReplayAttempt to the baseline
+	CandidateAttemptID string                                  `json:"candidate_attempt_id"` // the ReplayAttempt to the candidate
+	BaselineStatus     int                                     `json:"baseline_status"`
+	CandidateStatus    int                                     `json:"candidate_status"`
+	Equal              bool                                    `json:"equal"`
+	Differences        datatypes.JSONSlice[compare.Difference] `json:"differences"`
+	Error              string                                  `json:"error"` // why a side got no response; nothing was compared
+	CreatedAt          time.Time                               `json:"created_at"`
+}
+
+// ComparisonBatch compares a webhook's requests in the background.
+type ComparisonBatch struct {
+	ID           string                      `gorm:"primaryKey" json:"id"`
+	WebhookID    string                      `json:"webhook_id"`
+	BaselineURL  string                      `json:"baseline_url"`
+	CandidateURL string                      `json:"candidate_url"`
+	Ignore       datatypes.JSONSlice[string] `json:"ignore"`
+	Resign       bool                        `json:"resign"`
+	TimeoutMs    int64                       `json:"timeout_ms"` // per request
+	Concurrency  int                         `json:"concurrency"`
+	Status       string                      `json:"status"`
+	Total        int                         `json:"total"`
+	Equal        int                         `json:"equal"`
+	Different    int                         `json:"different"`
+	Failed       int                         `json:"failed"`  // a side got no response
+	Skipped      int                         `json:"skipped"` // the request was deleted before its turn
+	Error        string                      `json:"error"`
+	CreatedAt    time.Time                   `json:"created_at"`
+	FinishedAt   *time.Time                  `json:"finished_at"`
+}
+
+// Done reports whether the batch has ended.
+func (b *ComparisonBatch) Done() bool {
+	return b.Status != ComparisonBatchRunning
+}
+
+// Compared is how many of the batch's requests have been compared.
+func (b *ComparisonBatch) Compared() int {
+	return b.Equal + b.Different + b.Failed + b.Skipped
+}
+
+// Percent is the share of the batch's requests that have been compared.
+func (b *ComparisonBatch) Percent() int {
+	if b.Total == 0 {
+		return 100
+	}
+	return b.Compared() * 100 / b.Total
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Delivery retry policies.
+const (
+	RetryNone        = "none"        // one attempt, like GitHub
+	RetryExponential = "exponential" // doubling delays, like Stripe
+	RetryCustom      = "custom"      // delays chosen by the user
+)
+
+// Delivery states. A delivery is delivering until it succeeds, runs out of
+// attempts or time, or is cancelled.
+const (
+	DeliveryDelivering = "delivering"
+	DeliverySucceeded  = "succeeded" // an attempt got a 2xx
+	DeliveryFailed     = "failed"    // no attempt got a 2xx, or the server restarted
+	DeliveryCancelled  = "cancelled"
+)
+
+// Delivery sends one stored event to a target the way a provider would,
+// retrying on failure. Every attempt replays the same request, so IDs in the
+// event stay the same across attempts.
+type Delivery struct {
+	ID            string                     `gorm:"primaryKey" json:"id"`
+	WebhookID     string                     `json:"webhook_id"`
+	RequestID     string                     `json:"request_id"` // the event, a WebhookRequest with source "sent"
+	Target        string                     `json:"target"`
+	Policy        string                     `json:"policy"`
+	Schedule      datatypes.JSONSlice[int64] `json:"schedule_ms"` // wait before each retry
+	Sign          bool                       `json:"sign"`        // each attempt is signed afresh
+	TimeoutMs     int64                      `json:"timeout_ms"`  // per attempt
+	BudgetMs      int64                      `json:"budget_ms"`   // for all attempts and waits, 0 for no limit
+	Status        string                     `json:"status"`
+	Attempts      int                        `json:"attempts"`    // made so far
+	StatusCode    int                        `json:"status_code"` // of the latest attempt
+	NextAttemptAt *time.Time                 `json:"next_attempt_at"`
+	Error         string                     `json:"error"` // why the delivery gave up
+	CreatedAt     time.Time                  `json:"created_at"`
+	FinishedAt    *time.Time                 `json:"finished_at"`
+}
+
+// Done reports whether the delivery has ended.
+func (d *Delivery) Done() bool {
+	return d.Status != DeliveryDelivering
+}
+
+// MaxAttempts is how many attempts the delivery makes at most.
+func (d *Delivery) MaxAttempts() int {
+	return len(d.Schedule) + 1
+}
+
+// Wait is how long the delivery waits before attempt seq, counted from 1.
+func (d *Delivery) Wait(seq int) time.Duration {
+	if seq < 2 || seq > len(d.Schedule)+1 {
+		return 0
+	}
+	return time.Duration(d.Schedule[seq-2]) * time.Millisecond
+}
+
+// Offset is how long after the delivery started t is.
+func (d *Delivery) Offset(t time.Time) time.Duration {
+	return t.Sub(d.CreatedAt).Round(time.Millisecond)
+}
+
+// DeliveryAttempt is one attempt of a Delivery.
+type DeliveryAttempt struct {
+	DeliveryID string    `gorm:"primaryKey" json:"-"`
+	Seq        int       `gorm:"primaryKey" json:"seq"` // from 1
+	AttemptID  string    `json:"attempt_id"`            // the ReplayAttempt recording the exchange
+	Succeeded  bool      `json:"succeeded"`             // the target answered 2xx
+	StatusCode int       `json:"status_code"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error"`
+	SentAt     time.Time `json:"sent_at"`
+}
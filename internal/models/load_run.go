@@ -0,0 +1,93 @@
+package models
+
+import (
+	"math"
+	"time"
+	"webhook-tester/internal/load"
+
+	"gorm.io/datatypes"
+)
+
+// Load run states.
+const (
+	LoadRunning   = "running"
+	LoadCompleted = "completed" // the count was sent or the duration ran out
+	LoadCancelled = "cancelled"
+	LoadFailed    = "failed" // the server restarted
+)
+
+// LoadRun fires one request at a target again and again, with a fixed
+// concurrency and optionally a rate, and keeps the sums of the results
+// rather than every request. The request is a captured one or is rendered
+// from an event template for every send. Runs are removed with their
+// webhook but not with the captured request, so results outlive retention.
+type LoadRun struct {
+	ID          string  `gorm:"primaryKey" json:"id"`
+	WebhookID   string  `json:"webhook_id"`
+	RequestID   string  `json:"request_id"` // the captured request, or empty
+	Template    string  `json:"template"`   // the event template, or empty
+	Sign        bool    `json:"sign"`       // requests are signed, or re-signed, with the webhook's secret
+	Target      string  `json:"target"`
+	Concurrency int     `json:"concurrency"` // requests in flight at most
+	Rate        float64 `json:"rate"`        // requests started per second, 0 for no limit
+	DurationMs  int64   `json:"duration_ms"` // 0 for no limit
+	Count       int     `json:"count"`       // requests to send, 0 for no limit
+	TimeoutMs   int64   `json:"timeout_ms"`  // per request
+	Status      string  `json:"status"`
+
+	Requests    int                                   `json:"requests"`  // completed so far
+	Succeeded   int                                   `json:"succeeded"` // answered 2xx
+	Failed      int                                   `json:"failed"`    // answered with another status
+	Errors      int                                   `json:"errors"`    // got no response
+	StatusCodes datatypes.JSONSlice[load.StatusCount] `json:"status_codes"`
+	ErrorCounts datatypes.JSONSlice[load.ErrorCount]  `json:"error_counts"`
+	MinMs       float64                               `json:"min_ms"` // latencies of responses
+	MeanMs      float64                               `json:"mean_ms"`
+	P50Ms       float64                               `json:"p50_ms"`
+	P90Ms       float64                               `json:"p90_ms"`
+	P95Ms       float64                               `json:"p95_ms"`
+	P99Ms       float64                               `json:"p99_ms"`
+	MaxMs       float64                               `json:"max_ms"`
+	ElapsedMs   int64                                 `json:"elapsed_ms"`
+	Throughput  float64                               `json:"throughput"` // completed requests per second
+
+	Error      string     `json:"error"` // why the run failed
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// Done reports whether the run has ended.
+func (r *LoadRun) Done() bool {
+	return r.Status != LoadRunning
+}
+
+// Percent is how far along the run is, by count or duration, whichever is
+// further.
+func (r *LoadRun) Percent() int {
+	if r.Done() {
+		return 100
+	}
+	p := 0
+	if r.Count > 0 {
+		p = r.Requests * 100 / r.Count
+	}
+	if r.DurationMs > 0 && r.ElapsedMs > 0 {
+		p = max(p, int(r.ElapsedMs*100/r.DurationMs))
+	}
+	return min(p, 100)
+}
+
+// SetSummary stores the results s sums up.
+func (r *LoadRun) SetSummary(s load.Summary) {
+	r.Requests, r.Succeeded, r.Failed, r.Errors = s.Requests, s.Succeeded, s.Failed, s.Errors
+	r.StatusCodes, r.ErrorCounts = s.StatusCodes, s.ErrorCounts
+	r.MinMs, r.MeanMs, r.MaxMs = millis(s.Min), millis(s.Mean), millis(s.Max)
+	r.P50Ms, r.P90Ms, r.P95Ms, r.P99Ms = millis(s.P50), millis(s.P90), millis(s.P95), millis(s.P99)
+	r.ElapsedMs = s.Elapsed.Milliseconds()
+	r.Throughput = math.Round(s.Throughput*100) / 100
+}
+
+// millis is d in milliseconds, to the microsecond.
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ReplayAttempt is one replay of a stored request: what was sent and how the
+// target answered.
+type ReplayAttempt struct {
+	ID                string            `gorm:"primaryKey" json:"id"`
+	RequestID         string            `json:"request_id"`      // the replayed WebhookRequest
+	Method            string            `json:"method"`          // as sent, after edits
+	URL               string            `json:"url"`             // as sent, including the query
+	RequestHeaders    datatypes.JSONMap `json:"request_headers"` // as sent
+	RequestBody       string            `json:"request_body"`
+	StatusCode        int               `json:"status_code"` // 0 when no response arrived
+	ResponseHeaders   datatypes.JSONMap `json:"response_headers"`
+	ResponseBody      string            `json:"response_body"`
+	ResponseTruncated bool              `json:"response_truncated"` // only the start of the body was kept
+	DurationMs        int64             `json:"duration_ms"`
+	Error             string            `json:"error"` // why no response arrived
+	CreatedAt         time.Time         `json:"created_at"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+// Replay job modes.
+const (
+	ReplayModeTiming = "timing" // keep the gaps between the original requests
+	ReplayModeRate   = "rate"   // send at a fixed rate
+)
+
+// Replay job states. A job is running or paused until it is completed,
+// stopped, cancelled or failed.
+const (
+	ReplayJobRunning   = "running"
+	ReplayJobPaused    = "paused"
+	ReplayJobCompleted = "completed"
+	ReplayJobStopped   = "stopped" // a request failed and StopOnFailure was set
+	ReplayJobCancelled = "cancelled"
+	ReplayJobFailed    = "failed" // the job itself broke, e.g. the server restarted
+)
+
+// Replay job item states.
+const (
+	ReplayItemPending   = "pending"
+	ReplayItemSucceeded = "succeeded" // the target answered 2xx
+	ReplayItemFailed    = "failed"    // any other status, or no response
+	ReplayItemSkipped   = "skipped"   // the request was deleted before its turn
+)
+
+// ReplayJob replays a webhook's requests, in the order they arrived, to one
+// target in the background.
+type ReplayJob struct {
+	ID            string     `gorm:"primaryKey" json:"id"`
+	WebhookID     string     `json:"webhook_id"`
+	Target        string     `json:"target"`
+	Mode          string     `json:"mode"`
+	Speed         float64    `json:"speed"`       // timing mode: 2 halves the gaps
+	Rate          float64    `json:"rate"`        // rate mode: requests per second, 0 for no limit
+	Concurrency   int        `json:"concurrency"` // requests in flight at most
+	StopOnFailure bool       `json:"stop_on_failure"`
+	Resign        bool       `json:"resign"`     // with the webhook's signing secret
+	TimeoutMs     int64      `json:"timeout_ms"` // per request
+	Status        string     `json:"status"`
+	Total         int        `json:"total"`
+	Succeeded     int        `json:"succeeded"`
+	Failed        int        `json:"failed"`
+	Skipped       int        `json:"skipped"`
+	Error         string     `json:"error"` // why the job ended early
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+}
+
+// Done reports whether the job has ended.
+func (j *ReplayJob) Done() bool {
+	return j.Status != ReplayJobRunning && j.Status != ReplayJobPaused
+}
+
+// Sent is how many of the job's requests have been dealt with.
+func (j *ReplayJob) Sent() int {
+	return j.Succeeded + j.Failed + j.Skipped
+}
+
+// Percent is the share of the job's requests that have been dealt with.
+func (j *ReplayJob) Percent() int {
+	if j.Total == 0 {
+		return 100
+	}
+	return j.Sent() * 100 / j.Total
+}
+
+// ReplayJobItem is one request of a ReplayJob and, once sent, its result.
+type ReplayJobItem struct {
+	JobID      string     `gorm:"primaryKey" json:"-"`
+	Seq        int        `gorm:"primaryKey" json:"seq"` // position in the job, from 0
+	RequestID  string     `json:"request_id"`
+	OffsetMs   int64      `json:"offset_ms"` // when the request arrived, after the job's first
+	Status     string     `json:"status"`
+	AttemptID  string     `json:"attempt_id"` // the ReplayAttempt recording the exchange
+	StatusCode int        `json:"status_code"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error"`
+	SentAt     *time.Time `json:"sent_at"`
+}
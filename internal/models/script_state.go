@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ScriptState is the key/value state a webhook's script keeps between
+// requests, see package script.
+type ScriptState struct {
+	WebhookID string            `gorm:"primaryKey" json:"webhook_id"`
+	Data      datatypes.JSONMap `json:"data"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
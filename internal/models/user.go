@@ -13,4 +13,5 @@ type User struct {
 	APIKey           string    `json:"-"`
 	ResetToken       string    `json:"-"`
 	ResetTokenExpiry time.Time `json:"-"`
+	StorageQuota     int64     `json:"storage_quota"` // bytes, 0 falls back to the instance default
 }
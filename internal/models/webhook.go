@@ -2,23 +2,35 @@ package models
 
 import (
 	"time"
+	"webhook-tester/internal/chaos"
 
 	"gorm.io/datatypes"
 )
 
 // swagger:model [Webhook]
 type Webhook struct {
-	ID              string            `gorm:"primaryKey" json:"id"`
-	Title           string            `json:"title"`
-	ResponseCode    int               `json:"response_code"`
-	ResponseDelay   uint              `json:"response_delay"` // milliseconds
-	ContentType     *string           `json:"content_type"`
-	Payload         *string           `json:"payload"`
-	ResponseHeaders datatypes.JSONMap `json:"response_headers"`
-	NotifyOnEvent   bool              `json:"notify_on_event"`
-	UserID          int               `json:"user_id"`
-	CreatedAt       time.Time         `json:"created_at"`
-	UpdatedAt       time.Time         `json:"updated_at,omitempty"`
+	ID              string                           `gorm:"primaryKey" json:"id"`
+	Title           string                           `json:"title"`
+	ResponseCode    int                              `json:"response_code"`
+	ResponseDelay   uint                             `json:"response_delay"` // milliseconds
+	ContentType     *string                          `json:"content_type"`
+	Payload         *string                          `json:"payload"`
+	ResponseHeaders datatypes.JSONMap                `json:"response_headers"`
+	NotifyOnEvent   bool                             `json:"notify_on_event"`
+	RetentionCount  uint                             `json:"retention_count"` // keep the last N requests, 0 keeps all
+	RetentionDays   uint                             `json:"retention_days"`  // keep requests for D days, 0 keeps all
+	SigningScheme   string                           `json:"signing_scheme"`  // provider scheme replays are re-signed with, see package signing
+	SigningSecret   string                           `json:"-"`
+	Faults          datatypes.JSONType[chaos.Config] `json:"faults"` // injected into responses, see package chaos
+	Script          string                           `json:"script"` // Starlark script answering requests, see package script
+	UserID          int                              `json:"user_id"`
+	CreatedAt       time.Time                        `json:"created_at"`
+	UpdatedAt       time.Time                        `json:"updated_at,omitempty"`
 
 	Requests []WebhookRequest `gorm:"foreignKey:WebhookID" json:"requests,omitempty"`
 }
+
+// HasRetention reports whether the webhook declares its own retention policy.
+func (w *Webhook) HasRetention() bool {
+	return w.RetentionCount > 0 || w.RetentionDays > 0
+}
@@ -6,6 +6,13 @@ import (
 	"gorm.io/datatypes"
 )
 
+// Request sources.
+const (
+	SourceLive   = "live"   // received by the webhook
+	SourceImport = "import" // imported from a HAR or JSONL file
+	SourceSent   = "sent"   // sent from the tester by the event sender
+)
+
 // swagger:model WebhookRequest
 type WebhookRequest struct {
 	ID         string            `gorm:"primaryKey" json:"id"`
@@ -14,5 +21,29 @@ type WebhookRequest struct {
 	Headers    datatypes.JSONMap `json:"headers"`
 	Query      datatypes.JSONMap `json:"query"`
 	Body       string            `json:"body"`
+	Pinned     bool              `json:"pinned"` // pinned requests are never removed by retention
+	Size       int64             `json:"size"`   // bytes counted against the owner's storage quota
+	Source     string            `gorm:"default:live" json:"source"`
+	Fault      string            `json:"fault,omitempty"`          // the fault injected into the response, see package chaos
+	FaultDelay int64             `json:"fault_delay_ms,omitempty"` // milliseconds of injected delay
+	ScriptLog  string            `json:"script_log,omitempty"`     // what the webhook's script printed, and its error
 	ReceivedAt time.Time         `json:"received_at"`
 } // @name WebhookRequest
+
+// ComputeSize returns the number of bytes the request occupies for quota purposes.
+func (wr *WebhookRequest) ComputeSize() int64 {
+	size := int64(len(wr.Method) + len(wr.Body))
+	for k, v := range wr.Headers {
+		size += int64(len(k))
+		if s, ok := v.(string); ok {
+			size += int64(len(s))
+		}
+	}
+	for k, v := range wr.Query {
+		size += int64(len(k))
+		if s, ok := v.(string); ok {
+			size += int64(len(s))
+		}
+	}
+	return size
+}
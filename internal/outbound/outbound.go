@@ -0,0 +1,175 @@
+// Package outbound is the HTTP client for requests the server makes on a
+// user's behalf, such as replays. It connects only to addresses its Policy
+// allows, checking every address a host name resolves to, and every
+// redirect, just before connecting, so DNS tricks can't reach internal
+// networks. Responses are read up to a size cap and requests time out.
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds a request whose context has no deadline.
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxResponseSize is how much of a response body is read.
+	DefaultMaxResponseSize = 1 << 20
+)
+
+// BlockedError is returned when the policy doesn't allow the address a
+// request would connect to.
+type BlockedError struct {
+	Host string
+	Addr netip.Addr
+}
+
+func (e *BlockedError) Error() string {
+	if e.Host == e.Addr.String() {
+		return fmt.Sprintf("%s is not an address outbound requests may reach", e.Addr)
+	}
+	return fmt.Sprintf("%s resolves to %s, which outbound requests may not reach", e.Host, e.Addr)
+}
+
+// Response is a response whose body has been read, up to the client's cap.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Truncated  bool // Body holds only the first MaxResponseSize bytes
+}
+
+// Client sends requests under a Policy. It is safe for concurrent use.
+type Client struct {
+	policy          Policy
+	http            *http.Client
+	resolver        *net.Resolver
+	dialer          *net.Dialer
+	timeout         time.Duration
+	maxResponseSize int64
+	maxRedirects    int
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithTimeout sets the timeout of requests whose context has no deadline.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) { c.timeout = d }
+}
+
+// WithMaxResponseSize sets how many bytes of a response body are read.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *Client) { c.maxResponseSize = n }
+}
+
+// WithRedirects makes the client follow up to n redirects. By default it
+// returns redirect responses as they are.
+func WithRedirects(n int) Option {
+	return func(c *Client) { c.maxRedirects = n }
+}
+
+// New creates a client that enforces p.
+func New(p Policy, opts ...Option) *Client {
+	c := &Client{
+		policy:          p,
+		resolver:        net.DefaultResolver,
+		dialer:          &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second},
+		timeout:         DefaultTimeout,
+		maxResponseSize: DefaultMaxResponseSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would make the connection, hiding the real destination from the policy
+	transport.Proxy = nil
+	transport.DialContext = c.dial
+	// many requests to one target at once, as in load runs, reuse their connections
+	transport.MaxIdleConnsPerHost = 100
+	c.http = &http.Client{Transport: transport, CheckRedirect: c.checkRedirect}
+	return c
+}
+
+// Do sends req and reads the response. Requests to anything but http and
+// https URLs, or to addresses the policy blocks, fail without being sent.
+func (c *Client) Do(req *http.Request) (*Response, error) {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", req.URL.Scheme)
+	}
+	if _, ok := req.Context().Deadline(); !ok {
+		ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.maxResponseSize+1))
+	out := &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+	if int64(len(body)) > c.maxResponseSize {
+		out.Body, out.Truncated = body[:c.maxResponseSize], true
+	}
+	return out, err
+}
+
+// dial resolves addr itself and connects only to allowed addresses, so the
+// address that is checked is the one that is used.
+func (c *Client) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	portNum, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", port)
+	}
+	ips, err := c.resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		if !c.policy.Allows(host, port, ip) {
+			if lastErr == nil {
+				lastErr = &BlockedError{Host: host, Addr: ip.Unmap()}
+			}
+			continue
+		}
+		conn, err := c.dialer.DialContext(ctx, network, netip.AddrPortFrom(ip, uint16(portNum)).String())
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil, lastErr
+}
+
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.maxRedirects == 0 {
+		return http.ErrUseLastResponse
+	}
+	if len(via) > c.maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", c.maxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return errors.New("redirected to an unsupported URL scheme")
+	}
+	return nil
+}
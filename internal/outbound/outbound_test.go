@@ -0,0 +1,114 @@
+package outbound_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"testing"
+	"webhook-tester/internal/outbound"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyDefaults(t *testing.T) {
+	var p outbound.Policy
+	for _, ip := range []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.64.0.1", "0.0.0.0", "::1", "::", "fe80::1", "fd00::1", "::ffff:127.0.0.1",
+		"::ffff:169.254.169.254", "64:ff9b::a9fe:a9fe", "224.0.0.1", "255.255.255.255",
+		"2002:7f00:1::1", "2002:a9fe:a9fe::", "2001:0:4136:e378:8000:63bf:80ff:fffe",
+	} {
+		assert.False(t, p.Allows(ip, "80", netip.MustParseAddr(ip)), ip)
+	}
+	for _, ip := range []string{"93.184.216.34", "1.1.1.1", "2606:4700:4700::1111", "2001:4860:4860::8888", "172.32.0.1"} {
+		assert.True(t, p.Allows(ip, "80", netip.MustParseAddr(ip)), ip)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := outbound.ParsePolicy("10.0.0.0/8, 192.168.1.5, internal.example.com:8080, Other.example", "1.1.1.1, 10.9.0.0/16")
+	require.NoError(t, err)
+
+	assert.True(t, p.Allows("10.1.2.3", "80", netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, p.Allows("192.168.1.5", "80", netip.MustParseAddr("192.168.1.5")))
+	assert.False(t, p.Allows("192.168.1.6", "80", netip.MustParseAddr("192.168.1.6")))
+	assert.True(t, p.Allows("internal.example.com", "8080", netip.MustParseAddr("127.0.0.1")))
+	assert.False(t, p.Allows("internal.example.com", "80", netip.MustParseAddr("127.0.0.1")))
+	assert.True(t, p.Allows("other.example", "443", netip.MustParseAddr("127.0.0.1")))
+	// deny wins over allow and over the public internet
+	assert.False(t, p.Allows("1.1.1.1", "443", netip.MustParseAddr("1.1.1.1")))
+	assert.False(t, p.Allows("10.9.1.1", "80", netip.MustParseAddr("10.9.1.1")))
+	assert.False(t, p.Allows("other.example", "443", netip.MustParseAddr("10.9.1.1")))
+
+	_, err = outbound.ParsePolicy("not a host", "")
+	assert.ErrorContains(t, err, "OUTBOUND_ALLOW")
+	_, err = outbound.ParsePolicy("", "example.com")
+	assert.ErrorContains(t, err, "OUTBOUND_DENY")
+}
+
+func serve(t *testing.T, h http.HandlerFunc) (*httptest.Server, string) {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return srv, u.Port()
+}
+
+func TestClientBlocksLoopback(t *testing.T) {
+	srv, port := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("blocked request reached the server")
+	})
+
+	for _, target := range []string{srv.URL, "http://localhost:" + port} {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		_, err := outbound.New(outbound.Policy{}).Do(req)
+		var blocked *outbound.BlockedError
+		require.ErrorAs(t, err, &blocked, target)
+		assert.True(t, blocked.Addr.IsLoopback())
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "file:///etc/passwd", nil)
+	_, err := outbound.New(outbound.Policy{}).Do(req)
+	assert.ErrorContains(t, err, "unsupported URL scheme")
+}
+
+func TestClientAllowedHost(t *testing.T) {
+	srv, port := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	})
+	c := outbound.New(outbound.Policy{AllowHosts: []outbound.HostPort{{Host: "127.0.0.1", Port: port}}}, outbound.WithMaxResponseSize(10))
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "xxxxxxxxxx", string(resp.Body))
+	assert.True(t, resp.Truncated)
+}
+
+func TestClientChecksRedirects(t *testing.T) {
+	internal, _ := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect to a blocked address was followed")
+	})
+	public, port := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	})
+	policy := outbound.Policy{AllowHosts: []outbound.HostPort{{Host: "127.0.0.1", Port: port}}}
+
+	// redirects are returned as they are by default
+	req, _ := http.NewRequest(http.MethodGet, public.URL, nil)
+	resp, err := outbound.New(policy).Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodGet, public.URL, nil)
+	_, err = outbound.New(policy, outbound.WithRedirects(3)).Do(req)
+	var blocked *outbound.BlockedError
+	require.True(t, errors.As(err, &blocked), "got %v", err)
+	host, _, _ := net.SplitHostPort(strings.TrimPrefix(internal.URL, "http://"))
+	assert.Equal(t, host, blocked.Addr.String())
+}
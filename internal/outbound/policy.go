@@ -0,0 +1,149 @@
+package outbound
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// blocked are the ranges a Policy refuses unless they are allowed: loopback,
+// private, link-local and other addresses that don't lead to the public
+// internet. IPv4-mapped IPv6 addresses are checked as IPv4.
+var blocked = mustPrefixes(
+	"0.0.0.0/8",      // "this" network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local, including cloud metadata endpoints
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved and broadcast
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"64:ff9b::/96",   // NAT64, which can reach any of the IPv4 ranges
+	"64:ff9b:1::/48", // local-use NAT64
+	"2001::/32",      // Teredo, which embeds an IPv4 address
+	"2002::/16",      // 6to4, which embeds an IPv4 address
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+// Policy decides which addresses outbound requests may reach. Deny wins over
+// everything; otherwise an address is reachable when its host is in
+// AllowHosts, it is in Allow, or it isn't in one of the blocked ranges.
+type Policy struct {
+	Allow      []netip.Prefix // exceptions to the blocked ranges
+	AllowHosts []HostPort     // names whose addresses are all reachable
+	Deny       []netip.Prefix // never reachable, even if allowed
+}
+
+// HostPort names a host as it appears in a URL. An empty Port matches any port.
+type HostPort struct {
+	Host string
+	Port string
+}
+
+// PolicyFromEnv reads exceptions from OUTBOUND_ALLOW (IPs, CIDRs and
+// host[:port] names) and extra blocks from OUTBOUND_DENY (IPs and CIDRs),
+// both comma-separated.
+func PolicyFromEnv() (Policy, error) {
+	return ParsePolicy(os.Getenv("OUTBOUND_ALLOW"), os.Getenv("OUTBOUND_DENY"))
+}
+
+// ParsePolicy builds a Policy from comma-separated allow and deny lists.
+func ParsePolicy(allow, deny string) (Policy, error) {
+	var p Policy
+	for _, entry := range splitList(allow) {
+		if prefix, err := parsePrefix(entry); err == nil {
+			p.Allow = append(p.Allow, prefix)
+			continue
+		}
+		hp, err := ParseHostPort(entry)
+		if err != nil {
+			return Policy{}, fmt.Errorf("OUTBOUND_ALLOW: %w", err)
+		}
+		p.AllowHosts = append(p.AllowHosts, hp)
+	}
+	for _, entry := range splitList(deny) {
+		prefix, err := parsePrefix(entry)
+		if err != nil {
+			return Policy{}, fmt.Errorf("OUTBOUND_DENY: %q is not an IP address or CIDR range", entry)
+		}
+		p.Deny = append(p.Deny, prefix)
+	}
+	return p, nil
+}
+
+// ParseHostPort parses "host" or "host:port", with IPv6 hosts in brackets.
+func ParseHostPort(s string) (HostPort, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		host, port = strings.Trim(s, "[]"), ""
+	}
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		return HostPort{}, fmt.Errorf("%q is not an IP address, CIDR range or host name", s)
+	}
+	return HostPort{Host: strings.ToLower(host), Port: port}, nil
+}
+
+// Allows reports whether a connection to ip, resolved from host, on port may be made.
+func (p Policy) Allows(host, port string, ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if contains(p.Deny, ip) {
+		return false
+	}
+	host = strings.ToLower(host)
+	for _, hp := range p.AllowHosts {
+		if hp.Host == host && (hp.Port == "" || hp.Port == port) {
+			return true
+		}
+	}
+	return contains(p.Allow, ip) || !contains(blocked, ip)
+}
+
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefix parses a CIDR range or a single address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	ip = ip.Unmap()
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+func mustPrefixes(ss ...string) []netip.Prefix {
+	out := make([]netip.Prefix, len(ss))
+	for i, s := range ss {
+		out[i] = netip.MustParsePrefix(s)
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+// Package problem renders errors as RFC 7807 problem documents.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"webhook-tester/internal/dtos"
+	"webhook-tester/internal/service"
+)
+
+// ContentType is the media type of problem documents.
+const ContentType = "application/problem+json"
+
+// TypePrefix prefixes Code to form a problem's type URI.
+const TypePrefix = "urn:webhook-tester:problem:"
+
+// Stable error codes. Clients may switch on them; never change their meaning.
+const (
+	CodeBadRequest    = "bad_request"
+	CodeUnauthorized  = "unauthorized"
+	CodeForbidden     = "forbidden"
+	CodeNotFound      = "not_found"
+	CodeConflict      = "conflict"
+	CodeValidation    = "validation_failed"
+	CodeQuotaExceeded = "quota_exceeded"
+	CodeTooLarge      = "too_large"
+	CodeBadGateway    = "bad_gateway"
+	CodeInternal      = "internal_error"
+)
+
+// Error is a problem raised by a handler itself, such as a malformed body.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+}
+
+func (e *Error) Error() string { return e.Detail }
+
+// New returns an Error with the given status, code and detail.
+func New(status int, code, detail string) error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// BadRequest reports input that could not be parsed.
+func BadRequest(detail string) error {
+	return New(http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+// Unauthorized reports missing or invalid credentials.
+func Unauthorized(detail string) error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+// From maps err to a problem document. Errors that are not domain errors
+// become a 500 whose detail does not leak internals.
+func From(err error) dtos.Problem {
+	p := dtos.Problem{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "internal server error"}
+
+	var pe *Error
+	var ve *service.ValidationError
+	var me *http.MaxBytesError
+	switch {
+	case errors.As(err, &pe):
+		p.Status, p.Code, p.Detail = pe.Status, pe.Code, pe.Detail
+	case errors.As(err, &me):
+		p.Status, p.Code = http.StatusRequestEntityTooLarge, CodeTooLarge
+		p.Detail = fmt.Sprintf("request body is larger than %d bytes", me.Limit)
+	case errors.As(err, &ve):
+		p.Status, p.Code, p.Detail = http.StatusUnprocessableEntity, CodeValidation, "validation failed"
+		p.Errors = ve.Fields
+	case errors.Is(err, service.ErrValidation):
+		p.Status, p.Code, p.Detail = http.StatusUnprocessableEntity, CodeValidation, err.Error()
+	case errors.Is(err, service.ErrNotFound):
+		p.Status, p.Code, p.Detail = http.StatusNotFound, CodeNotFound, err.Error()
+	case errors.Is(err, service.ErrForbidden):
+		p.Status, p.Code, p.Detail = http.StatusForbidden, CodeForbidden, err.Error()
+	case errors.Is(err, service.ErrUnauthorized):
+		p.Status, p.Code, p.Detail = http.StatusUnauthorized, CodeUnauthorized, err.Error()
+	case errors.Is(err, service.ErrConflict):
+		p.Status, p.Code, p.Detail = http.StatusConflict, CodeConflict, err.Error()
+	case errors.Is(err, service.ErrQuotaExceeded):
+		p.Status, p.Code, p.Detail = http.StatusInsufficientStorage, CodeQuotaExceeded, err.Error()
+	}
+
+	p.Type = TypePrefix + p.Code
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// Write responds with the problem for err. Browsers navigating the web UI
+// get the detail as plain text; everyone else gets application/problem+json.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := From(err)
+	p.Instance = r.URL.Path
+
+	if wantsHTML(r) {
+		http.Error(w, p.Detail, p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Print("error rendering problem: ", err)
+	}
+}
+
+func wantsHTML(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "text/html") && !strings.Contains(accept, "json")
+}
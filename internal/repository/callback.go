@@ -0,0 +1,28 @@
+package repository
+
+import "webhook-tester/internal/models"
+
+type CallbackRepository interface {
+	// CreateAction inserts a new callback action
+	CreateAction(a *models.CallbackAction) error
+	// GetAction retrieves one callback action by its ID
+	GetAction(id string) (*models.CallbackAction, error)
+	// ListActions returns the callback actions of a webhook, oldest first
+	ListActions(webhookID string) ([]models.CallbackAction, error)
+	// UpdateAction saves a callback action
+	UpdateAction(a *models.CallbackAction) error
+	// DeleteAction removes a callback action; its callbacks are kept
+	DeleteAction(id string) error
+	// Create inserts a new callback
+	Create(c *models.Callback) error
+	// GetByID retrieves one callback by its ID
+	GetByID(id string) (*models.Callback, error)
+	// ListByWebhook returns the latest limit callbacks of a webhook, newest first
+	ListByWebhook(webhookID string, limit int) ([]models.Callback, error)
+	// ListByRequest returns the callbacks of a received request, newest first
+	ListByRequest(requestID string) ([]models.Callback, error)
+	// Update saves the state of a callback
+	Update(c *models.Callback) error
+	// FailUnfinished marks scheduled callbacks as failed with reason
+	FailUnfinished(reason string) (int64, error)
+}
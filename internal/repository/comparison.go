@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"time"
+	"webhook-tester/internal/models"
+)
+
+type ComparisonRepository interface {
+	// Insert a new comparison
+	Insert(c *models.Comparison) error
+	// GetByID retrieves one comparison by its ID
+	GetByID(id string) (*models.Comparison, error)
+	// ListByRequest returns the comparisons of a request, newest first
+	ListByRequest(requestID string) ([]models.Comparison, error)
+	// ListByBatch returns the comparisons of a batch, oldest first
+	ListByBatch(batchID string) ([]models.Comparison, error)
+	// CreateBatch inserts a new batch
+	CreateBatch(b *models.ComparisonBatch) error
+	// GetBatch retrieves one batch by its ID
+	GetBatch(id string) (*models.ComparisonBatch, error)
+	// ListBatches returns the batches of a webhook, newest first
+	ListBatches(webhookID string) ([]models.ComparisonBatch, error)
+	// UpdateBatch saves the state and counters of a batch
+	UpdateBatch(b *models.ComparisonBatch) error
+	// FailUnfinishedBatches marks running batches as failed with reason
+	FailUnfinishedBatches(reason string, at time.Time) (int64, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"time"
+	"webhook-tester/internal/models"
+)
+
+type DeliveryRepository interface {
+	// Create inserts a new delivery
+	Create(d *models.Delivery) error
+	// GetByID retrieves one delivery by its ID
+	GetByID(id string) (*models.Delivery, error)
+	// ListByWebhook returns the deliveries of a webhook, newest first
+	ListByWebhook(webhookID string) ([]models.Delivery, error)
+	// Update saves the state of a delivery
+	Update(d *models.Delivery) error
+	// AddAttempt records one attempt of a delivery
+	AddAttempt(a *models.DeliveryAttempt) error
+	// ListAttempts returns the attempts of a delivery in order
+	ListAttempts(deliveryID string) ([]models.DeliveryAttempt, error)
+	// FailUnfinished marks delivering deliveries as failed with reason
+	FailUnfinished(reason string, at time.Time) (int64, error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"time"
+	"webhook-tester/internal/models"
+)
+
+type LoadRunRepository interface {
+	// Create inserts a new run
+	Create(run *models.LoadRun) error
+	// GetByID retrieves one run by its ID
+	GetByID(id string) (*models.LoadRun, error)
+	// ListByWebhook returns the runs of a webhook, newest first
+	ListByWebhook(webhookID string) ([]models.LoadRun, error)
+	// Update saves the state and results of a run
+	Update(run *models.LoadRun) error
+	// FailUnfinished marks running runs as failed with reason
+	FailUnfinished(reason string, at time.Time) (int64, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "webhook-tester/internal/models"
+
+type ReplayAttemptRepository interface {
+	// Insert a new replay attempt
+	Insert(a *models.ReplayAttempt) error
+	// GetByID retrieves one attempt by its ID
+	GetByID(id string) (*models.ReplayAttempt, error)
+	// ListByRequest returns the attempts of a request, newest first
+	ListByRequest(requestID string) ([]models.ReplayAttempt, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"time"
+	"webhook-tester/internal/models"
+)
+
+type ReplayJobRepository interface {
+	// Create inserts a job with its items, setting their JobID, or nothing on error
+	Create(job *models.ReplayJob, items []models.ReplayJobItem) error
+	// GetByID retrieves one job by its ID
+	GetByID(id string) (*models.ReplayJob, error)
+	// ListByWebhook returns the jobs of a webhook, newest first
+	ListByWebhook(webhookID string) ([]models.ReplayJob, error)
+	// Update saves the state and counters of a job
+	Update(job *models.ReplayJob) error
+	// UpdateItem saves the result of one item
+	UpdateItem(item *models.ReplayJobItem) error
+	// ListItems returns the items of a job in order
+	ListItems(jobID string) ([]models.ReplayJobItem, error)
+	// FailUnfinished marks running and paused jobs as failed with reason
+	FailUnfinished(reason string, at time.Time) (int64, error)
+}
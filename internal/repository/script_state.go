@@ -0,0 +1,12 @@
+package repository
+
+import "webhook-tester/internal/models"
+
+type ScriptStateRepository interface {
+	// Get retrieves the script state of a webhook
+	Get(webhookID string) (*models.ScriptState, error)
+	// Save inserts or replaces the script state of a webhook
+	Save(s *models.ScriptState) error
+	// Delete removes the script state of a webhook
+	Delete(webhookID string) error
+}
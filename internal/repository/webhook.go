@@ -27,4 +27,6 @@ type WebhookRepository interface {
 	GetWithRequests(id string) (*models.Webhook, error)
 	// CleanPublic Clean up public webhooks older than duration d
 	CleanPublic(d time.Duration) error
+	// GetAllWithRetention Retrieves webhooks that declare their own retention policy
+	GetAllWithRetention() ([]models.Webhook, error)
 }
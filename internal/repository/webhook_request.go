@@ -1,10 +1,24 @@
 package repository
 
-import "webhook-tester/internal/models"
+import (
+	"time"
+	"webhook-tester/internal/models"
+)
+
+// RequestFilter narrows down the requests of a webhook. Zero fields match everything.
+type RequestFilter struct {
+	Since   time.Time // received at or after
+	Until   time.Time // received before
+	Methods []string  // upper-case HTTP methods
+	Source  string    // models.SourceLive, models.SourceImport or models.SourceSent
+	IDs     []string  // only these requests
+}
 
 type WebhookRequestRepository interface {
 	// Insert a new request record
 	Insert(req *models.WebhookRequest) error
+	// InsertMany inserts all requests or, on error, none of them
+	InsertMany(reqs []models.WebhookRequest) error
 	// GetByID retrieves one request by its ID
 	GetByID(id string) (*models.WebhookRequest, error)
 	// ListByWebhook returns all requests for a given webhook
@@ -13,4 +27,17 @@ type WebhookRequestRepository interface {
 	DeleteByID(id string) error
 	// DeleteByWebhook removes all requests for a webhook
 	DeleteByWebhook(webhookID string) error
+	// SetPinned pins or unpins one request
+	SetPinned(id string, pinned bool) error
+	// PruneKeepLast removes unpinned requests beyond the newest n for a webhook
+	PruneKeepLast(webhookID string, n uint) (int64, error)
+	// PruneBefore removes unpinned requests for a webhook received before t
+	PruneBefore(webhookID string, t time.Time) (int64, error)
+	// SizeByUser returns the total size of requests stored under a user's webhooks
+	SizeByUser(userID uint) (int64, error)
+	// ListPageByWebhook returns one page of requests, newest first, and the total count
+	ListPageByWebhook(webhookID string, offset, limit int) ([]models.WebhookRequest, int64, error)
+	// EachByWebhook calls fn with batches of at most batchSize matching requests,
+	// oldest first, without loading them all at once. It stops at the first error fn returns
+	EachByWebhook(webhookID string, f RequestFilter, batchSize int, fn func([]models.WebhookRequest) error) error
 }
@@ -11,10 +11,26 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-func NewApiRouter(webhookSvc *service.WebhookService, authSvc *service.AuthService, l *log.Logger, metricsRec metrics.Recorder) http.Handler {
+func NewApiRouter(
+	webhookSvc *service.WebhookService,
+	webhookReqSvc *service.WebhookRequestService,
+	authSvc *service.AuthService,
+	retentionSvc *service.RetentionService,
+	replaySvc *service.ReplayService,
+	replayJobSvc *service.ReplayJobService,
+	comparisonSvc *service.ComparisonService,
+	senderSvc *service.SenderService,
+	deliverySvc *service.DeliveryService,
+	loadSvc *service.LoadService,
+	callbackSvc *service.CallbackService,
+	scriptSvc *service.ScriptService,
+	l *log.Logger,
+	metricsRec metrics.Recorder,
+) http.Handler {
 	r := chi.NewRouter()
 
-	h := handlers.NewWebhookApiHandler(webhookSvc, metricsRec, l)
+	h := handlers.NewWebhookApiHandler(webhookSvc, service.NewSpecService(webhookSvc, callbackSvc), metricsRec, l)
+	rh := handlers.NewWebhookRequestApiHandler(webhookSvc, webhookReqSvc, retentionSvc, replaySvc, replayJobSvc, comparisonSvc, senderSvc, deliverySvc, loadSvc, callbackSvc, scriptSvc, l)
 
 	r.Route("/webhooks", func(r chi.Router) {
 		r.Use(middlewares.RequireAPIKey(authSvc))
@@ -24,9 +40,67 @@ func NewApiRouter(webhookSvc *service.WebhookService, authSvc *service.AuthServi
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", h.GetWebhookApi)
 			r.Put("/", h.UpdateWebhookApi)
+			r.Patch("/", h.PatchWebhookApi)
 			r.Delete("/", h.DeleteWebhookApi)
+
+			r.Get("/stream", rh.StreamRequestsApi)
+			r.Get("/requests", rh.ListRequestsApi)
+			r.Delete("/requests", rh.DeleteRequestsApi)
+			r.Get("/requests/export", rh.ExportRequestsApi)
+			r.Post("/requests/import", rh.ImportRequestsApi)
+			r.Get("/requests/{requestID}", rh.GetRequestApi)
+			r.Get("/requests/{requestID}/snippets", rh.GetRequestSnippetsApi)
+			r.Post("/requests/{requestID}/replays", rh.ReplayRequestApi)
+			r.Get("/requests/{requestID}/replays", rh.ListReplaysApi)
+			r.Get("/requests/{requestID}/replays/{attemptID}", rh.GetReplayApi)
+			r.Post("/requests/{requestID}/comparisons", rh.CompareRequestApi)
+			r.Get("/requests/{requestID}/comparisons", rh.ListComparisonsApi)
+			r.Get("/requests/{requestID}/comparisons/{comparisonID}", rh.GetComparisonApi)
+			r.Delete("/requests/{requestID}", rh.DeleteRequestApi)
+			r.Post("/replay-jobs", rh.StartReplayJobApi)
+			r.Get("/replay-jobs", rh.ListReplayJobsApi)
+			r.Get("/replay-jobs/{jobID}", rh.GetReplayJobApi)
+			r.Get("/replay-jobs/{jobID}/items", rh.ListReplayJobItemsApi)
+			r.Post("/replay-jobs/{jobID}/pause", rh.PauseReplayJobApi)
+			r.Post("/replay-jobs/{jobID}/resume", rh.ResumeReplayJobApi)
+			r.Post("/replay-jobs/{jobID}/cancel", rh.CancelReplayJobApi)
+			r.Post("/comparison-batches", rh.StartComparisonBatchApi)
+			r.Get("/comparison-batches", rh.ListComparisonBatchesApi)
+			r.Get("/comparison-batches/{batchID}", rh.GetComparisonBatchApi)
+			r.Get("/comparison-batches/{batchID}/results", rh.GetComparisonBatchResultsApi)
+			r.Post("/send", rh.SendEventApi)
+			r.Post("/deliveries", rh.StartDeliveryApi)
+			r.Get("/deliveries", rh.ListDeliveriesApi)
+			r.Get("/deliveries/{deliveryID}", rh.GetDeliveryApi)
+			r.Get("/deliveries/{deliveryID}/attempts", rh.ListDeliveryAttemptsApi)
+			r.Post("/deliveries/{deliveryID}/cancel", rh.CancelDeliveryApi)
+			r.Post("/load-runs", rh.StartLoadRunApi)
+			r.Get("/load-runs", rh.ListLoadRunsApi)
+			r.Get("/load-runs/{runID}", rh.GetLoadRunApi)
+			r.Post("/load-runs/{runID}/cancel", rh.CancelLoadRunApi)
+			r.Post("/callback-actions", rh.CreateCallbackActionApi)
+			r.Get("/callback-actions", rh.ListCallbackActionsApi)
+			r.Get("/callback-actions/{actionID}", rh.GetCallbackActionApi)
+			r.Put("/callback-actions/{actionID}", rh.UpdateCallbackActionApi)
+			r.Delete("/callback-actions/{actionID}", rh.DeleteCallbackActionApi)
+			r.Get("/callbacks", rh.ListCallbacksApi)
+			r.Get("/callbacks/{callbackID}", rh.GetCallbackApi)
+			r.Post("/callbacks/{callbackID}/cancel", rh.CancelCallbackApi)
+			r.Get("/script-state", rh.GetScriptStateApi)
+			r.Delete("/script-state", rh.ResetScriptStateApi)
 		})
 	})
 
+	r.Route("/event-templates", func(r chi.Router) {
+		r.Use(middlewares.RequireAPIKey(authSvc))
+		r.Get("/", rh.ListEventTemplatesApi)
+	})
+
+	r.Route("/spec", func(r chi.Router) {
+		r.Use(middlewares.RequireAPIKey(authSvc))
+		r.Get("/", h.ExportSpecApi)
+		r.Post("/apply", h.ApplySpecApi)
+	})
+
 	return r
 }
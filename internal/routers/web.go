@@ -17,6 +17,15 @@ func NewWebRouter(
 	wrs *service.WebhookRequestService,
 	ws *service.WebhookService,
 	authSvc *service.AuthService,
+	retentionSvc *service.RetentionService,
+	replaySvc *service.ReplayService,
+	replayJobSvc *service.ReplayJobService,
+	comparisonSvc *service.ComparisonService,
+	senderSvc *service.SenderService,
+	deliverySvc *service.DeliveryService,
+	loadSvc *service.LoadService,
+	callbackSvc *service.CallbackService,
+	scriptSvc *service.ScriptService,
 	metricsRec metrics.Recorder,
 	logger *log.Logger,
 ) http.Handler {
@@ -39,17 +48,48 @@ func NewWebRouter(
 
 	r.Use(csrfMiddleware)
 
-	webhookReqHandler := handlers.NewWebhookRequestHandler(wrs, authSvc, ws, &metricsRec, logger)
+	webhookReqHandler := handlers.NewWebhookRequestHandler(wrs, authSvc, ws, retentionSvc, replaySvc, replayJobSvc, comparisonSvc, senderSvc, deliverySvc, loadSvc, callbackSvc, &metricsRec, logger)
 	r.Route("/requests", func(r chi.Router) {
 		r.Get("/{id}", webhookReqHandler.GetRequest)
 		r.Post("/{id}/delete", webhookReqHandler.DeleteRequest)
+		r.Post("/{id}/pin", webhookReqHandler.PinRequest)
 		r.Post("/{id}/replay", webhookReqHandler.ReplayRequest)
+		r.Post("/{id}/compare", webhookReqHandler.CompareRequest)
+	})
+	r.Get("/export-requests/{id}", webhookReqHandler.ExportRequests)
+	r.Post("/import-requests/{id}", webhookReqHandler.ImportRequests)
+	r.Route("/replay-jobs/{id}", func(r chi.Router) {
+		r.Get("/", webhookReqHandler.ReplayJobs)
+		r.Post("/", webhookReqHandler.StartReplayJob)
+		r.Get("/{jobID}", webhookReqHandler.ReplayJob)
+		r.Post("/{jobID}/{action}", webhookReqHandler.ControlReplayJob)
+	})
+	r.Route("/comparisons/{id}", func(r chi.Router) {
+		r.Get("/", webhookReqHandler.Comparisons)
+		r.Post("/", webhookReqHandler.StartComparisonBatch)
+		r.Get("/{batchID}", webhookReqHandler.ComparisonBatch)
+	})
+	r.Get("/send/{id}", webhookReqHandler.Sender)
+	r.Post("/send/{id}", webhookReqHandler.SendEvent)
+	r.Get("/deliveries/{id}/{deliveryID}", webhookReqHandler.Delivery)
+	r.Post("/deliveries/{id}/{deliveryID}/cancel", webhookReqHandler.CancelDelivery)
+	r.Route("/load/{id}", func(r chi.Router) {
+		r.Get("/", webhookReqHandler.LoadRuns)
+		r.Post("/", webhookReqHandler.StartLoadRun)
+		r.Get("/{runID}", webhookReqHandler.LoadRun)
+		r.Post("/{runID}/cancel", webhookReqHandler.CancelLoadRun)
+	})
+	r.Route("/callbacks/{id}", func(r chi.Router) {
+		r.Get("/", webhookReqHandler.Callbacks)
+		r.Post("/", webhookReqHandler.SaveCallbackAction)
+		r.Post("/{actionID}/{action}", webhookReqHandler.ControlCallbackAction)
+		r.Post("/sent/{callbackID}/cancel", webhookReqHandler.CancelCallback)
 	})
 
-	hh := handlers.NewHomeHandler(ws, authSvc, logger, metricsRec)
+	hh := handlers.NewHomeHandler(ws, authSvc, retentionSvc, logger, metricsRec)
 	r.Get("/", hh.Home)
 
-	webhookHandler := handlers.NewWebhookHandler(ws, authSvc, logger, metricsRec)
+	webhookHandler := handlers.NewWebhookHandler(ws, authSvc, retentionSvc, callbackSvc, scriptSvc, logger, metricsRec)
 	r.Post("/create-webhook", webhookHandler.Create)
 	r.Post("/delete-requests/{id}", webhookHandler.DeleteRequests)
 	r.Post("/delete-webhook/{id}", webhookHandler.DeleteWebhook)
@@ -12,11 +12,14 @@ import (
 func NewWebhookRouter(
 	webhookSvc *service.WebhookService,
 	authSvc *service.AuthService,
+	retentionSvc *service.RetentionService,
+	callbackSvc *service.CallbackService,
+	scriptSvc *service.ScriptService,
 	logger *log.Logger,
 	metrics metrics.Recorder,
 ) http.Handler {
 	r := chi.NewRouter()
-	wh := handlers.NewWebhookHandler(webhookSvc, authSvc, logger, metrics)
+	wh := handlers.NewWebhookHandler(webhookSvc, authSvc, retentionSvc, callbackSvc, scriptSvc, logger, metrics)
 
 	// Match all HTTP methods at /{webhookID}
 	r.HandleFunc("/*", wh.HandleWebhookRequest)
@@ -0,0 +1,623 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// Steps don't bound memory: one step like "x" * (1 << 29) can build a
+// value of hundreds of MB. So the operators and built-ins that can build a
+// value much larger than their operands check its size first, and charge
+// what they built to the run: a run is stopped once they built MaxMemory
+// bytes in all. The count is kept on the thread, so runs in parallel don't
+// count against each other.
+//
+// The operators are guarded by rewriting the script before it is compiled:
+// x + y becomes +(x, y), a call to a predeclared built-in that checks the
+// sizes and then does the addition, and so on for *, %, their augmented
+// assignments, and the join, replace, format and extend methods. The names
+// of these built-ins aren't identifiers, so scripts can't shadow them.
+
+// elemSize is what a list or tuple element is reckoned to take.
+const elemSize = 16
+
+var errTooLarge = fmt.Errorf("result would be larger than %d bytes", MaxValue)
+
+var errTooMuchMemory = errors.New("used too much memory")
+
+// builtKey is the thread-local count of bytes the guarded operations built.
+const builtKey = "script.built"
+
+// charge counts n bytes built by the run on thread.
+func charge(thread *starlark.Thread, n int) error {
+	built, _ := thread.Local(builtKey).(int)
+	built += n
+	thread.SetLocal(builtKey, built)
+	if built > MaxMemory {
+		return errTooMuchMemory
+	}
+	return nil
+}
+
+// charged charges the size of v, the result of a guarded operation.
+func charged(thread *starlark.Thread, v starlark.Value, err error) (starlark.Value, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := charge(thread, size(v)); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// operators maps the guarded operators to the built-ins doing them.
+var operators = map[syntax.Token]string{
+	syntax.PLUS:       "+",
+	syntax.STAR:       "*",
+	syntax.PERCENT:    "%",
+	syntax.PLUS_EQ:    "+=",
+	syntax.STAR_EQ:    "*",
+	syntax.PERCENT_EQ: "%",
+}
+
+// methods are the guarded methods, called through the "." built-in.
+var methods = map[string]bool{"join": true, "replace": true, "format": true, "extend": true}
+
+var guards = starlark.StringDict{
+	"+":  starlark.NewBuiltin("+", add),
+	"+=": starlark.NewBuiltin("+=", addInPlace),
+	"*":  starlark.NewBuiltin("*", mul),
+	"%":  starlark.NewBuiltin("%", mod),
+	".":  starlark.NewBuiltin("getattr", method),
+}
+
+// guardFile rewrites the guarded operators and methods of f into calls.
+func guardFile(f *syntax.File) {
+	guardStmts(f.Stmts)
+}
+
+func guardStmts(stmts []syntax.Stmt) {
+	for _, s := range stmts {
+		guardStmt(s)
+	}
+}
+
+func guardStmt(s syntax.Stmt) {
+	switch s := s.(type) {
+	case *syntax.AssignStmt:
+		guardTarget(s.LHS)
+		s.RHS = guardExpr(s.RHS)
+		if name, ok := operators[s.Op]; ok {
+			// x op= y becomes x = op(x, y); the operands of an index or
+			// dot target are evaluated twice
+			s.RHS = call(name, s.OpPos, s.LHS, s.RHS)
+			s.Op = syntax.EQ
+		}
+	case *syntax.DefStmt:
+		guardExprs(s.Params)
+		guardStmts(s.Body)
+	case *syntax.ExprStmt:
+		s.X = guardExpr(s.X)
+	case *syntax.IfStmt:
+		s.Cond = guardExpr(s.Cond)
+		guardStmts(s.True)
+		guardStmts(s.False)
+	case *syntax.WhileStmt:
+		s.Cond = guardExpr(s.Cond)
+		guardStmts(s.Body)
+	case *syntax.ForStmt:
+		guardTarget(s.Vars)
+		s.X = guardExpr(s.X)
+		guardStmts(s.Body)
+	case *syntax.ReturnStmt:
+		if s.Result != nil {
+			s.Result = guardExpr(s.Result)
+		}
+	}
+}
+
+// guardTarget guards the operands of an assignment target, leaving the
+// target itself assignable.
+func guardTarget(e syntax.Expr) {
+	switch e := e.(type) {
+	case *syntax.IndexExpr:
+		e.X = guardExpr(e.X)
+		e.Y = guardExpr(e.Y)
+	case *syntax.DotExpr:
+		e.X = guardExpr(e.X)
+	case *syntax.ParenExpr:
+		guardTarget(e.X)
+	case *syntax.ListExpr:
+		for _, x := range e.List {
+			guardTarget(x)
+		}
+	case *syntax.TupleExpr:
+		for _, x := range e.List {
+			guardTarget(x)
+		}
+	}
+}
+
+func guardExprs(list []syntax.Expr) {
+	for i, e := range list {
+		list[i] = guardExpr(e)
+	}
+}
+
+func guardExpr(e syntax.Expr) syntax.Expr {
+	switch e := e.(type) {
+	case *syntax.BinaryExpr:
+		e.X = guardExpr(e.X)
+		e.Y = guardExpr(e.Y)
+		if name, ok := operators[e.Op]; ok {
+			return call(name, e.OpPos, e.X, e.Y)
+		}
+	case *syntax.UnaryExpr:
+		if e.X != nil {
+			e.X = guardExpr(e.X)
+		}
+	case *syntax.ParenExpr:
+		e.X = guardExpr(e.X)
+	case *syntax.CallExpr:
+		e.Fn = guardExpr(e.Fn)
+		guardExprs(e.Args)
+	case *syntax.DotExpr:
+		e.X = guardExpr(e.X)
+		if methods[e.Name.Name] {
+			name := &syntax.Literal{Token: syntax.STRING, TokenPos: e.NamePos, Raw: strconv.Quote(e.Name.Name), Value: e.Name.Name}
+			return call(".", e.Dot, e.X, name)
+		}
+	case *syntax.IndexExpr:
+		e.X = guardExpr(e.X)
+		e.Y = guardExpr(e.Y)
+	case *syntax.SliceExpr:
+		e.X = guardExpr(e.X)
+		for _, p := range []*syntax.Expr{&e.Lo, &e.Hi, &e.Step} {
+			if *p != nil {
+				*p = guardExpr(*p)
+			}
+		}
+	case *syntax.ListExpr:
+		guardExprs(e.List)
+	case *syntax.TupleExpr:
+		guardExprs(e.List)
+	case *syntax.DictExpr:
+		guardExprs(e.List)
+	case *syntax.DictEntry:
+		e.Key = guardExpr(e.Key)
+		e.Value = guardExpr(e.Value)
+	case *syntax.CondExpr:
+		e.Cond = guardExpr(e.Cond)
+		e.True = guardExpr(e.True)
+		e.False = guardExpr(e.False)
+	case *syntax.LambdaExpr:
+		guardExprs(e.Params)
+		e.Body = guardExpr(e.Body)
+	case *syntax.Comprehension:
+		e.Body = guardExpr(e.Body)
+		for _, c := range e.Clauses {
+			switch c := c.(type) {
+			case *syntax.ForClause:
+				guardTarget(c.Vars)
+				c.X = guardExpr(c.X)
+			case *syntax.IfClause:
+				c.Cond = guardExpr(c.Cond)
+			}
+		}
+	}
+	return e
+}
+
+// call is a call of the guard built-in name, positioned at pos so that
+// errors point at the operator.
+func call(name string, pos syntax.Position, args ...syntax.Expr) *syntax.CallExpr {
+	return &syntax.CallExpr{Fn: &syntax.Ident{NamePos: pos, Name: name}, Lparen: pos, Args: args, Rparen: pos}
+}
+
+func add(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	x, y := args[0], args[1]
+	if size(x)+size(y) > MaxValue {
+		return nil, errTooLarge
+	}
+	v, err := starlark.Binary(syntax.PLUS, x, y)
+	return charged(thread, v, err)
+}
+
+// addInPlace extends a list in place, as += does, and adds anything else.
+func addInPlace(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	list, ok := args[0].(*starlark.List)
+	if !ok {
+		return add(thread, b, args, kwargs)
+	}
+	iter, ok := args[1].(starlark.Iterable)
+	if !ok {
+		return add(thread, b, args, kwargs)
+	}
+	elems, err := collect(iter)
+	if err != nil {
+		return nil, err
+	}
+	if (list.Len()+len(elems))*elemSize > MaxValue {
+		return nil, errTooLarge
+	}
+	if err := charge(thread, len(elems)*elemSize); err != nil {
+		return nil, err
+	}
+	for _, v := range elems {
+		if err := list.Append(v); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func mul(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	x, y := args[0], args[1]
+	xi, xInt := x.(starlark.Int)
+	yi, yInt := y.(starlark.Int)
+	switch {
+	case xInt && yInt:
+		if bits(xi)+bits(yi) > MaxValue*8 {
+			return nil, errTooLarge
+		}
+	case xInt:
+		if repeatTooLarge(y, xi) {
+			return nil, errTooLarge
+		}
+	case yInt:
+		if repeatTooLarge(x, yi) {
+			return nil, errTooLarge
+		}
+	}
+	v, err := starlark.Binary(syntax.STAR, x, y)
+	return charged(thread, v, err)
+}
+
+func repeatTooLarge(seq starlark.Value, n starlark.Int) bool {
+	sz := size(seq)
+	if sz == 0 {
+		return false
+	}
+	times, ok := n.Int64()
+	return !ok || times > int64(MaxValue/sz)
+}
+
+func mod(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	x, y := args[0], args[1]
+	if format, ok := x.(starlark.String); ok {
+		// a conversion inserts at most the largest operand; %(key)s may
+		// insert the same one many times
+		var largest int
+		switch y := y.(type) {
+		case starlark.Tuple:
+			for _, v := range y {
+				largest = max(largest, textSize(v))
+			}
+		case *starlark.Dict:
+			for _, item := range y.Items() {
+				largest = max(largest, textSize(item[1]))
+			}
+		default:
+			largest = textSize(y)
+		}
+		if tooLarge(len(format), strings.Count(string(format), "%"), largest) {
+			return nil, errTooLarge
+		}
+	}
+	v, err := starlark.Binary(syntax.PERCENT, x, y)
+	return charged(thread, v, err)
+}
+
+// method gets a guarded method, checked before it is called.
+func method(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, _ []starlark.Tuple) (starlark.Value, error) {
+	recv, name := args[0], string(args[1].(starlark.String))
+	attr, err := starlark.Universe["getattr"].(*starlark.Builtin).CallInternal(thread, args, nil)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := attr.(*starlark.Builtin)
+	if !ok {
+		return attr, nil
+	}
+	switch recv := recv.(type) {
+	case starlark.String:
+		switch name {
+		case "join":
+			return checked(fn, func(args starlark.Tuple, _ []starlark.Tuple) error { return checkJoin(recv, args) }), nil
+		case "replace":
+			return checked(fn, func(args starlark.Tuple, _ []starlark.Tuple) error { return checkReplace(recv, args) }), nil
+		case "format":
+			return checked(fn, func(args starlark.Tuple, kwargs []starlark.Tuple) error { return checkFormat(recv, args, kwargs) }), nil
+		}
+	case *starlark.List:
+		if name == "extend" {
+			return starlark.NewBuiltin(fn.Name(), func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+				if err := checkExtend(recv, args); err != nil {
+					return nil, err
+				}
+				n := recv.Len()
+				v, err := fn.CallInternal(thread, args, kwargs)
+				if err != nil {
+					return nil, err
+				}
+				return v, charge(thread, (recv.Len()-n)*elemSize)
+			}), nil
+		}
+	}
+	return fn, nil
+}
+
+func checkJoin(sep starlark.String, args starlark.Tuple) error {
+	if len(args) != 1 {
+		return nil
+	}
+	iter, ok := args[0].(starlark.Iterable)
+	if !ok {
+		return nil
+	}
+	n := 0
+	it := iter.Iterate()
+	defer it.Done()
+	var v starlark.Value
+	for it.Next(&v) {
+		if n += len(sep) + size(v); n > MaxValue {
+			return errTooLarge
+		}
+	}
+	return nil
+}
+
+func checkReplace(s starlark.String, args starlark.Tuple) error {
+	if len(args) < 2 {
+		return nil
+	}
+	old, ok1 := args[0].(starlark.String)
+	repl, ok2 := args[1].(starlark.String)
+	if !ok1 || !ok2 || len(repl) <= len(old) {
+		return nil
+	}
+	if tooLarge(len(s), strings.Count(string(s), string(old)), len(repl)-len(old)) {
+		return errTooLarge
+	}
+	return nil
+}
+
+func checkFormat(format starlark.String, args starlark.Tuple, kwargs []starlark.Tuple) error {
+	// each field inserts at most the largest argument, and {0} may insert
+	// the same one many times
+	var largest int
+	for _, v := range args {
+		largest = max(largest, textSize(v))
+	}
+	for _, kv := range kwargs {
+		largest = max(largest, textSize(kv[1]))
+	}
+	if tooLarge(len(format), strings.Count(string(format), "{"), largest) {
+		return errTooLarge
+	}
+	return nil
+}
+
+func checkExtend(list *starlark.List, args starlark.Tuple) error {
+	if len(args) != 1 {
+		return nil
+	}
+	n := starlark.Len(args[0])
+	if n < 0 {
+		iter, ok := args[0].(starlark.Iterable)
+		if !ok {
+			return nil
+		}
+		elems, err := collect(iter)
+		if err != nil {
+			return err
+		}
+		n = len(elems)
+	}
+	if (list.Len()+n)*elemSize > MaxValue {
+		return errTooLarge
+	}
+	return nil
+}
+
+// tooLarge reports whether base plus count insertions of each bytes is
+// over MaxValue, without overflowing.
+func tooLarge(base, count, each int) bool {
+	return each > 0 && count > (MaxValue-base)/each || base > MaxValue
+}
+
+// checked wraps the built-in fn so that check runs before it, and its result
+// is charged to the run.
+func checked(fn *starlark.Builtin, check func(args starlark.Tuple, kwargs []starlark.Tuple) error) *starlark.Builtin {
+	return starlark.NewBuiltin(fn.Name(), func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if err := check(args, kwargs); err != nil {
+			return nil, err
+		}
+		v, err := fn.CallInternal(thread, args, kwargs)
+		return charged(thread, v, err)
+	})
+}
+
+// guardedUniverse overrides the built-ins that turn values into text, and
+// range, whose lists would otherwise be as long as asked.
+func guardedUniverse() starlark.StringDict {
+	textOf := func(args starlark.Tuple, kwargs []starlark.Tuple) error {
+		n := 0
+		for _, v := range args {
+			if n += textSize(v) + 1; n > MaxValue {
+				return errTooLarge
+			}
+		}
+		return nil
+	}
+	d := starlark.StringDict{}
+	for _, name := range []string{"str", "repr", "print"} {
+		d[name] = checked(starlark.Universe[name].(*starlark.Builtin), textOf)
+	}
+	rng := starlark.Universe["range"].(*starlark.Builtin)
+	d["range"] = starlark.NewBuiltin("range", func(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		r, err := rng.CallInternal(thread, args, kwargs)
+		if err != nil {
+			return nil, err
+		}
+		if n := starlark.Len(r); n > MaxRange {
+			return nil, fmt.Errorf("range of %d elements is longer than %d", n, MaxRange)
+		}
+		return r, nil
+	})
+	return d
+}
+
+// jsonModule is the json module with encode and indent checked, and decode
+// limited to MaxValue bytes of input.
+func jsonModule() *starlarkstruct.Module {
+	m := &starlarkstruct.Module{Name: "json", Members: starlark.StringDict{}}
+	for name, v := range starlarkjson.Module.Members {
+		m.Members[name] = v
+	}
+	member := func(name string) *starlark.Builtin { return starlarkjson.Module.Members[name].(*starlark.Builtin) }
+	m.Members["encode"] = checked(member("encode"), func(args starlark.Tuple, _ []starlark.Tuple) error {
+		if len(args) == 1 && textSize(args[0]) > MaxValue {
+			return errTooLarge
+		}
+		return nil
+	})
+	m.Members["decode"] = checked(member("decode"), func(args starlark.Tuple, _ []starlark.Tuple) error {
+		if len(args) > 0 && size(args[0]) > MaxValue {
+			return fmt.Errorf("input must be at most %d bytes", MaxValue)
+		}
+		return nil
+	})
+	m.Members["indent"] = checked(member("indent"), func(args starlark.Tuple, kwargs []starlark.Tuple) error {
+		var s, prefix, indent string
+		if err := starlark.UnpackArgs("indent", args, kwargs, "str", &s, "prefix?", &prefix, "indent?", &indent); err != nil {
+			return nil // indent reports it
+		}
+		if indent == "" {
+			indent = "\t"
+		}
+		// every value and closing bracket starts a line, indented as deep as
+		// the nesting
+		depth, deepest := 0, 0
+		for _, c := range s {
+			switch c {
+			case '[', '{':
+				depth++
+				deepest = max(deepest, depth)
+			case ']', '}':
+				depth--
+			}
+		}
+		lines := strings.Count(s, ",") + 2*strings.Count(s, "[") + 2*strings.Count(s, "{")
+		if tooLarge(len(s), lines, 1+len(prefix)+len(indent)*deepest) {
+			return errTooLarge
+		}
+		return nil
+	})
+	return m
+}
+
+// size is roughly what v's own memory takes, not counting what it refers to.
+func size(v starlark.Value) int {
+	switch v := v.(type) {
+	case starlark.String:
+		return len(v)
+	case starlark.Bytes:
+		return len(v)
+	case *starlark.List:
+		return v.Len() * elemSize
+	case starlark.Tuple:
+		return len(v) * elemSize
+	case starlark.Int:
+		return bits(v) / 8
+	}
+	return 0
+}
+
+func bits(i starlark.Int) int {
+	if _, ok := i.Int64(); ok {
+		return 64
+	}
+	return i.BigInt().BitLen()
+}
+
+// textSize estimates the length of v written out, as str, print or
+// json.encode do, counting shared and nested values each time they appear.
+// It stops counting past MaxValue.
+func textSize(v starlark.Value) int {
+	n := 0
+	var walk func(v starlark.Value, depth int)
+	walk = func(v starlark.Value, depth int) {
+		if n > MaxValue {
+			return
+		}
+		if depth > 64 {
+			n += elemSize
+			return
+		}
+		switch v := v.(type) {
+		case starlark.String:
+			n += len(v) + 2
+		case starlark.Bytes:
+			n += len(v) + 3
+		case starlark.Int:
+			n += bits(v)/3 + 1
+		case *starlark.List:
+			for i := 0; i < v.Len(); i++ {
+				n += 2
+				walk(v.Index(i), depth+1)
+			}
+		case starlark.Tuple:
+			for _, e := range v {
+				n += 2
+				walk(e, depth+1)
+			}
+		case *starlark.Dict:
+			for _, item := range v.Items() {
+				n += 4
+				walk(item[0], depth+1)
+				walk(item[1], depth+1)
+			}
+		case *starlark.Set:
+			it := v.Iterate()
+			defer it.Done()
+			var e starlark.Value
+			for it.Next(&e) {
+				n += 2
+				walk(e, depth+1)
+			}
+		case starlark.HasAttrs:
+			for _, name := range v.AttrNames() {
+				n += len(name) + 3
+				if attr, err := v.Attr(name); err == nil && attr != nil {
+					walk(attr, depth+1)
+				}
+			}
+		default:
+			n += 32
+		}
+	}
+	walk(v, 0)
+	return n
+}
+
+func collect(iter starlark.Iterable) ([]starlark.Value, error) {
+	var elems []starlark.Value
+	it := iter.Iterate()
+	defer it.Done()
+	var v starlark.Value
+	for it.Next(&v) {
+		if len(elems)*elemSize > MaxValue {
+			return nil, errTooLarge
+		}
+		elems = append(elems, v)
+	}
+	return elems, nil
+}
@@ -0,0 +1,414 @@
+// Package script answers webhook requests with Starlark scripts, for mocks
+// that static and templated payloads can't express.
+//
+// A script defines handle(request), which returns the response:
+//
+//	def handle(request):
+//	    n = state.get("count", 0) + 1
+//	    state["count"] = n
+//	    print("call", n)
+//	    if n % 3 == 0:
+//	        return {"status": 503, "headers": {"Retry-After": "1"}}
+//	    return {"body": {"ok": True, "n": n}, "delay_ms": 50}
+//
+// request has method, headers, query, body and json, the body decoded
+// when it is JSON. handle may return a dict with status, headers, body and
+// delay_ms, all optional, a string body, or None to keep the webhook's own
+// response. Bodies that aren't strings are sent as JSON. state is a dict kept
+// between requests of the webhook; it must hold JSON values. print writes to
+// the log recorded on the request.
+//
+// Scripts run sandboxed: they have only the json and math modules and time
+// without time zones, no load, filesystem or network, and are stopped after
+// MaxSteps steps or Timeout, whichever comes first. No operation may build
+// a value larger than MaxValue, and a run is stopped once those that can
+// build large values built MaxMemory bytes in all, see guard.go.
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	starlarkjson "go.starlark.net/lib/json"
+	"go.starlark.net/lib/math"
+	starlarktime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+const (
+	// MaxSource is the size of the largest script.
+	MaxSource = 64 << 10
+	// MaxSteps caps the computation steps of one run.
+	MaxSteps = 5_000_000
+	// Timeout caps the time of one run.
+	Timeout = time.Second
+	// MaxDelay caps the delay a script asks for.
+	MaxDelay = 30 * time.Second
+	// MaxBody is the size of the largest body a script returns.
+	MaxBody = 1 << 20
+	// MaxState is the size of the largest state, encoded as JSON.
+	MaxState = 64 << 10
+	// MaxLog is how much of the printed log is kept.
+	MaxLog = 16 << 10
+	// MaxValue is roughly the size of the largest string, list or integer
+	// one operation may build.
+	MaxValue = 1 << 20
+	// MaxRange is the length of the longest range.
+	MaxRange = 1 << 20
+	// MaxMemory caps how many bytes the guarded operations of one run may
+	// build, garbage included.
+	MaxMemory = 128 << 20
+)
+
+// options allow while loops and sets; MaxSteps bounds loops anyway.
+var options = &syntax.FileOptions{While: true, Set: true}
+
+// Request is the request a script answers.
+type Request struct {
+	Method  string
+	Headers map[string]string
+	Query   map[string]string
+	Body    string
+}
+
+// Response is what a script answered. Set is false when it returned None,
+// leaving the webhook's own response.
+type Response struct {
+	Set     bool
+	Status  int // 0 keeps the webhook's status
+	Headers map[string]string
+	Body    *string // nil keeps the webhook's payload
+	Delay   time.Duration
+}
+
+// Result is the outcome of a run. Log holds what the script printed, even
+// when it failed. State is the state after the run; Changed reports whether
+// it differs from the state the run started with.
+type Result struct {
+	Response Response
+	Log      string
+	State    map[string]any
+	Changed  bool
+}
+
+// Check reports whether src compiles and defines handle, without running it.
+func Check(src string) error {
+	f, _, err := compile(src)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range f.Stmts {
+		if def, ok := stmt.(*syntax.DefStmt); ok && def.Name.Name == "handle" {
+			if len(def.Params) != 1 {
+				return errors.New("handle must take one parameter, the request")
+			}
+			return nil
+		}
+	}
+	return errors.New("must define handle(request)")
+}
+
+// compile parses src, guards it and compiles it.
+func compile(src string) (*syntax.File, *starlark.Program, error) {
+	if len(src) > MaxSource {
+		return nil, nil, fmt.Errorf("must be at most %d bytes", MaxSource)
+	}
+	f, err := options.Parse("script.star", src, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	guardFile(f)
+	prog, err := starlark.FileProgram(f, isPredeclared)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, prog, nil
+}
+
+// predeclared are the names scripts see besides the built-ins, with the
+// guards and guarded built-ins of guard.go. state is added per run.
+var predeclared = func() starlark.StringDict {
+	d := starlark.StringDict{
+		"json":  jsonModule(),
+		"math":  math.Module,
+		"time":  timeModule(),
+		"state": starlark.None,
+	}
+	for _, extra := range []starlark.StringDict{guards, guardedUniverse()} {
+		for k, v := range extra {
+			d[k] = v
+		}
+	}
+	return d
+}()
+
+// timeModule is the time module without the functions that take a time
+// zone name, which read the zone database from the filesystem.
+func timeModule() *starlarkstruct.Module {
+	m := &starlarkstruct.Module{Name: "time", Members: starlark.StringDict{}}
+	for name, v := range starlarktime.Module.Members {
+		switch name {
+		case "is_valid_timezone", "parse_time", "time":
+		default:
+			m.Members[name] = v
+		}
+	}
+	return m
+}
+
+func isPredeclared(name string) bool {
+	_, ok := predeclared[name]
+	return ok
+}
+
+// Run runs src's handle with req and state, a JSON object. The returned
+// Result is never nil: on error it still holds the log.
+func Run(ctx context.Context, src string, req Request, state map[string]any) (*Result, error) {
+	res := &Result{State: state}
+	log := &limitedLog{}
+	defer func() { res.Log = log.String() }()
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	thread := &starlark.Thread{
+		Name:  "script",
+		Print: func(_ *starlark.Thread, msg string) { log.println(msg) },
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, errors.New("load is not allowed")
+		},
+	}
+	thread.SetMaxExecutionSteps(MaxSteps)
+	stop := context.AfterFunc(ctx, func() { thread.Cancel("ran out of time") })
+	defer stop()
+
+	stateIn, err := json.Marshal(nonNil(state))
+	if err != nil {
+		return res, fmt.Errorf("state: %w", err)
+	}
+	stateDict, err := decodeJSON(thread, string(stateIn))
+	if err != nil {
+		return res, fmt.Errorf("state: %w", err)
+	}
+	request, err := requestValue(thread, req)
+	if err != nil {
+		return res, err
+	}
+
+	env := starlark.StringDict{}
+	for k, v := range predeclared {
+		env[k] = v
+	}
+	env["state"] = stateDict
+	_, prog, err := compile(src)
+	if err != nil {
+		return res, err
+	}
+	globals, err := prog.Init(thread, env)
+	if err != nil {
+		return res, describe(err)
+	}
+	handle, ok := globals["handle"].(starlark.Callable)
+	if !ok {
+		return res, errors.New("script must define handle(request)")
+	}
+	out, err := starlark.Call(thread, handle, starlark.Tuple{request}, nil)
+	if err != nil {
+		return res, describe(err)
+	}
+	if res.Response, err = response(thread, out); err != nil {
+		return res, err
+	}
+
+	stateOut, err := encodeJSON(thread, stateDict)
+	if err != nil {
+		return res, fmt.Errorf("state: %w", err)
+	}
+	if len(stateOut) > MaxState {
+		return res, fmt.Errorf("state: must be at most %d bytes as JSON, is %d", MaxState, len(stateOut))
+	}
+	if stateOut != string(stateIn) {
+		var next map[string]any
+		if err := json.Unmarshal([]byte(stateOut), &next); err != nil {
+			return res, fmt.Errorf("state: %w", err)
+		}
+		res.State, res.Changed = next, true
+	}
+	return res, nil
+}
+
+// requestValue builds the request struct a script's handle receives.
+func requestValue(thread *starlark.Thread, req Request) (starlark.Value, error) {
+	// bodies too large to handle are only passed as text
+	var body starlark.Value = starlark.None
+	if len(req.Body) <= MaxValue && json.Valid([]byte(req.Body)) {
+		v, err := decodeJSON(thread, req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = v
+	}
+	r := starlarkstruct.FromStringDict(starlark.String("request"), starlark.StringDict{
+		"method":  starlark.String(req.Method),
+		"headers": stringDict(req.Headers),
+		"query":   stringDict(req.Query),
+		"body":    starlark.String(req.Body),
+		"json":    body,
+	})
+	r.Freeze()
+	return r, nil
+}
+
+func stringDict(m map[string]string) *starlark.Dict {
+	d := starlark.NewDict(len(m))
+	for k, v := range m {
+		_ = d.SetKey(starlark.String(k), starlark.String(v))
+	}
+	return d
+}
+
+// response reads what handle returned.
+func response(thread *starlark.Thread, v starlark.Value) (Response, error) {
+	var r Response
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return r, nil
+	case starlark.String:
+		r.Set = true
+		err := r.setBody(thread, v)
+		return r, err
+	case *starlark.Dict:
+		r.Set = true
+		for _, item := range v.Items() {
+			key, ok := starlark.AsString(item[0])
+			if !ok {
+				return r, fmt.Errorf("handle returned a dict with the key %s; want status, headers, body or delay_ms", item[0])
+			}
+			var err error
+			switch key {
+			case "status":
+				r.Status, err = starlark.AsInt32(item[1])
+				if err == nil && (r.Status < 100 || r.Status > 599) {
+					err = fmt.Errorf("%d is not a status code", r.Status)
+				}
+			case "headers":
+				r.Headers, err = headers(item[1])
+			case "body":
+				err = r.setBody(thread, item[1])
+			case "delay_ms":
+				var ms int
+				ms, err = starlark.AsInt32(item[1])
+				if err == nil && (ms < 0 || time.Duration(ms)*time.Millisecond > MaxDelay) {
+					err = fmt.Errorf("must be between 0 and %d", MaxDelay.Milliseconds())
+				}
+				r.Delay = time.Duration(ms) * time.Millisecond
+			default:
+				err = errors.New("unknown key; want status, headers, body or delay_ms")
+			}
+			if err != nil {
+				return r, fmt.Errorf("handle returned a bad %s: %w", key, err)
+			}
+		}
+		return r, nil
+	default:
+		return r, fmt.Errorf("handle returned a %s; want a dict, a string or None", v.Type())
+	}
+}
+
+// setBody sets the body to v, encoded as JSON unless it is a string.
+func (r *Response) setBody(thread *starlark.Thread, v starlark.Value) error {
+	s, ok := starlark.AsString(v)
+	if !ok {
+		var err error
+		if s, err = encodeJSON(thread, v); err != nil {
+			return err
+		}
+		if r.Headers == nil {
+			r.Headers = map[string]string{}
+		}
+		if _, set := r.Headers["Content-Type"]; !set {
+			r.Headers["Content-Type"] = "application/json"
+		}
+	}
+	if len(s) > MaxBody {
+		return fmt.Errorf("must be at most %d bytes, is %d", MaxBody, len(s))
+	}
+	r.Body = &s
+	return nil
+}
+
+func headers(v starlark.Value) (map[string]string, error) {
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("want a dict, got a %s", v.Type())
+	}
+	h := map[string]string{}
+	for _, item := range d.Items() {
+		name, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("header name %s is not a string", item[0])
+		}
+		value, ok := starlark.AsString(item[1])
+		if !ok {
+			value = item[1].String()
+		}
+		h[name] = value
+	}
+	return h, nil
+}
+
+// decodeJSON and encodeJSON convert through the json module, so that
+// scripts see the same values json.decode gives them.
+func decodeJSON(thread *starlark.Thread, s string) (starlark.Value, error) {
+	return starlark.Call(thread, starlarkjson.Module.Members["decode"], starlark.Tuple{starlark.String(s)}, nil)
+}
+
+func encodeJSON(thread *starlark.Thread, v starlark.Value) (string, error) {
+	out, err := starlark.Call(thread, starlarkjson.Module.Members["encode"], starlark.Tuple{v}, nil)
+	if err != nil {
+		return "", err
+	}
+	s, _ := starlark.AsString(out)
+	return s, nil
+}
+
+// describe turns a Starlark error into one with its backtrace.
+func describe(err error) error {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return errors.New(strings.TrimSpace(evalErr.Backtrace()))
+	}
+	return err
+}
+
+func nonNil(m map[string]any) map[string]any {
+	if m == nil {
+		return map[string]any{}
+	}
+	return m
+}
+
+// limitedLog keeps the first MaxLog bytes printed.
+type limitedLog struct {
+	b         strings.Builder
+	truncated bool
+}
+
+func (l *limitedLog) println(msg string) {
+	if l.b.Len()+len(msg)+1 > MaxLog {
+		if !l.truncated {
+			l.b.WriteString("... log truncated\n")
+			l.truncated = true
+		}
+		return
+	}
+	l.b.WriteString(msg)
+	l.b.WriteByte('\n')
+}
+
+func (l *limitedLog) String() string { return l.b.String() }
@@ -0,0 +1,353 @@
+package script_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"webhook-tester/internal/script"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var order = script.Request{
+	Method:  "POST",
+	Headers: map[string]string{"Content-Type": "application/json"},
+	Query:   map[string]string{"mode": "test"},
+	Body:    `{"order": {"id": 42, "total": 9.5}}`,
+}
+
+func run(t *testing.T, src string, state map[string]any) (*script.Result, error) {
+	t.Helper()
+	return script.Run(context.Background(), src, order, state)
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, script.Check("def handle(request):\n    return None\n"))
+	assert.ErrorContains(t, script.Check("x = 1\n"), "must define handle(request)")
+	assert.ErrorContains(t, script.Check("def handle():\n    return None\n"), "one parameter")
+	assert.Error(t, script.Check("def handle(request)\n"), "syntax error")
+	assert.ErrorContains(t, script.Check("def handle(request):\n    return open('x')\n"), "undefined: open")
+	assert.ErrorContains(t, script.Check(strings.Repeat("#", script.MaxSource+1)), "at most")
+}
+
+func TestResponse(t *testing.T) {
+	res, err := run(t, `
+def handle(request):
+    print("order", request.json["order"]["id"], request.query["mode"])
+    return {
+        "status": 201,
+        "headers": {"X-Order": str(request.json["order"]["id"])},
+        "body": {"id": request.json["order"]["id"], "method": request.method},
+        "delay_ms": 25,
+    }
+`, nil)
+	require.NoError(t, err)
+	assert.True(t, res.Response.Set)
+	assert.Equal(t, 201, res.Response.Status)
+	assert.Equal(t, map[string]string{"X-Order": "42", "Content-Type": "application/json"}, res.Response.Headers)
+	require.NotNil(t, res.Response.Body)
+	assert.JSONEq(t, `{"id": 42, "method": "POST"}`, *res.Response.Body)
+	assert.Equal(t, 25*time.Millisecond, res.Response.Delay)
+	assert.Equal(t, "order 42 test\n", res.Log)
+	assert.False(t, res.Changed)
+
+	res, err = run(t, "def handle(request):\n    return 'plain ' + request.body[:9]\n", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `plain {"order":`, *res.Response.Body)
+	assert.Empty(t, res.Response.Headers)
+
+	res, err = run(t, "def handle(request):\n    return None\n", nil)
+	require.NoError(t, err)
+	assert.False(t, res.Response.Set)
+}
+
+func TestState(t *testing.T) {
+	src := `
+def handle(request):
+    state["count"] = state.get("count", 0) + 1
+    return {"body": {"count": state["count"]}}
+`
+	var state map[string]any
+	for i := 1; i <= 3; i++ {
+		res, err := run(t, src, state)
+		require.NoError(t, err)
+		assert.True(t, res.Changed)
+		assert.JSONEq(t, `{"count": `+string(rune('0'+i))+`}`, *res.Response.Body)
+		state = res.State
+	}
+	assert.Equal(t, map[string]any{"count": float64(3)}, state)
+
+	_, err := run(t, "def handle(request):\n    state['f'] = handle\n", nil)
+	assert.ErrorContains(t, err, "state")
+	_, err = run(t, "def handle(request):\n    state['big'] = 'x' * 70000\n", nil)
+	assert.ErrorContains(t, err, "at most")
+}
+
+func TestErrors(t *testing.T) {
+	for name, tc := range map[string]struct{ src, err string }{
+		"runtime":  {"def handle(request):\n    print('before')\n    return 1 // 0\n", "division by zero"},
+		"return":   {"def handle(request):\n    return 42\n", "want a dict, a string or None"},
+		"status":   {"def handle(request):\n    return {'status': 42}\n", "not a status code"},
+		"delay":    {"def handle(request):\n    return {'delay_ms': 60000}\n", "delay_ms"},
+		"key":      {"def handle(request):\n    return {'code': 200}\n", "unknown key"},
+		"load":     {"load('x.star', 'y')\ndef handle(request):\n    return None\n", "load is not allowed"},
+		"frozen":   {"def handle(request):\n    request.headers['X'] = '1'\n", "frozen"},
+		"no steps": {"def handle(request):\n    while True:\n        pass\n", "too many steps"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			res, err := run(t, tc.src, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+			assert.NotNil(t, res)
+		})
+	}
+
+	res, _ := run(t, "def handle(request):\n    print('before')\n    fail('boom')\n", nil)
+	assert.Equal(t, "before\n", res.Log, "the log survives errors")
+}
+
+func TestTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := script.Run(ctx, `
+def handle(request):
+    s = "x" * 100000
+    for i in range(1000000):
+        s.replace("x", "y")
+`, order, nil)
+	assert.ErrorContains(t, err, "ran out of time")
+	assert.Less(t, time.Since(start), script.Timeout)
+}
+
+func TestLogLimit(t *testing.T) {
+	res, err := run(t, "def handle(request):\n    for i in range(10000):\n        print('line', i)\n", nil)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(res.Log), script.MaxLog+64)
+	assert.True(t, strings.HasSuffix(res.Log, "log truncated\n"))
+}
+
+func TestGuardedOperators(t *testing.T) {
+	res, err := run(t, `
+def handle(request):
+    items = [1]
+    alias = items
+    items += [2]
+    items.extend([3])
+    state["n"] = 1
+    state["n"] += 2
+    n = 2
+    n *= 3
+    s = "ab" * 2 + "-%s-%d" % ("x", 7)
+    return {"body": {
+        "items": alias, "n": state["n"], "m": n, "s": s,
+        "joined": ",".join(["a", "b"]), "replaced": "aaa".replace("a", "bb"),
+        "formatted": "{}={}".format("k", 1), "big": 2 * 3 * (1 << 100) % 7,
+    }}
+`, nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items": [1, 2, 3], "n": 3, "m": 6, "s": "abab-x-7",
+		"joined": "a,b", "replaced": "bbbbbb", "formatted": "k=1", "big": 5}`, *res.Response.Body)
+
+	_, err = run(t, "def handle(request):\n    return 'a'.nope()\n", nil)
+	assert.ErrorContains(t, err, "string has no .nope field or method")
+	_, err = run(t, "def handle(request):\n    request.headers.update({})\n    x = request.headers\n    x['a'] += 'b'\n", nil)
+	assert.Error(t, err)
+}
+
+func TestMemoryGuard(t *testing.T) {
+	for name, src := range map[string]string{
+		"string repeat": `"x" * (1 << 29)`,
+		"list repeat":   `[0] * (1 << 28)`,
+		"repeat left":   `(1 << 28) * [0]`,
+		"comprehension": `[s * 1000 for i in range(1000)]`,
+		"doubling":      `[s + s + s + s for i in range(3)] and double(s)`,
+		"list doubling": `grow()`,
+		"extend":        `extend()`,
+		"integers":      `square()`,
+		"join":          `"".join([s] * 1000)`,
+		"replace":       `s.replace("x", s)`,
+		"format":        `("{0}" * 1000).format(s)`,
+		"percent":       `("%s" * 1000) % tuple([s] * 1000)`,
+		"str":           `str([s] * 1000)`,
+		"print":         `print(*([s] * 1000))`,
+		"json":          `json.encode([s] * 1000)`,
+		"indent":        `json.indent("[" + "[" * 500 + "]" * 500 + "]", indent=" " * 4000)`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			src := `
+s = "x" * 10000
+
+def double(s):
+    for i in range(20):
+        s += s
+
+def grow():
+    l = [0]
+    for i in range(30):
+        l += l
+
+def extend():
+    l = [0]
+    for i in range(30):
+        l.extend(l)
+
+def square():
+    n = 3
+    for i in range(30):
+        n = n * n
+
+def handle(request):
+    return ` + src + `
+`
+			_, err := run(t, src, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "larger than")
+		})
+	}
+
+	_, err := run(t, "def handle(request):\n    return list(range(1 << 30))\n", nil)
+	assert.ErrorContains(t, err, "longer than")
+}
+
+// TestGuards runs each guarded operation once within the limits and once
+// beyond them.
+func TestGuards(t *testing.T) {
+	cases := []struct {
+		name     string
+		ok, want string // an expression and its value as JSON
+		tooLarge string
+		err      string
+	}{
+		{"+ strings", `"ab" + "c"`, `"abc"`, `s + s`, "larger than"},
+		{"+ lists", `[1] + [2]`, `[1, 2]`, `l + l`, "larger than"},
+		{"+ tuples", `(1,) + (2,)`, `[1, 2]`, `tuple(l) + tuple(l)`, "larger than"},
+		{"+= list", `inplace([1], [2])`, `[1, 2]`, `inplace(list(l), l)`, "larger than"},
+		{"+= string", `inplace("a", "b")`, `"ab"`, `inplace(s, s)`, "larger than"},
+		{"* string", `"ab" * 2`, `"abab"`, `s * 2`, "larger than"},
+		{"* repeat left", `2 * [1]`, `[1, 1]`, `2 * l`, "larger than"},
+		{"* integers", `square(3, 2)`, `81`, `square(3, 30)`, "larger than"},
+		{"*=", `times("ab", 2)`, `"abab"`, `times(s, 2)`, "larger than"},
+		{"% tuple", `"%s-%s" % ("a", 1)`, `"a-1"`, `"%s%s" % (s, s)`, "larger than"},
+		{"% dict", `"%(a)s" % {"a": 1}`, `"1"`, `"%(a)s%(a)s" % {"a": s}`, "larger than"},
+		{"% value", `"<%s>" % "a"`, `"<a>"`, `("%s" + "x" * 500000) % s`, "larger than"},
+		{"%=", `remainder("%s!", "a")`, `"a!"`, `remainder("%s%s", (s, s))`, "larger than"},
+		{"join", `",".join(["a", "b"])`, `"a,b"`, `"".join([s, s])`, "larger than"},
+		{"replace", `"aaa".replace("a", "bb")`, `"bbbbbb"`, `s.replace("x", "yy")`, "larger than"},
+		{"format", `"{}={}".format("k", 1)`, `"k=1"`, `"{}{}".format(s, s)`, "larger than"},
+		{"format keywords", `"{a}".format(a=1)`, `"1"`, `"{a}{a}".format(a=s)`, "larger than"},
+		{"extend", `extend([1], [2])`, `[1, 2]`, `extend(list(l), l)`, "larger than"},
+		{"extend iterable", `extend([1], range(2))`, `[1, 0, 1]`, `extend(list(l), range(40000))`, "larger than"},
+		{"str", `str([1])`, `"[1]"`, `str([s, s])`, "larger than"},
+		{"repr", `repr("a")`, `"\"a\""`, `repr([s, s])`, "larger than"},
+		{"print", `print("a")`, `null`, `print(s, s)`, "larger than"},
+		{"json.encode", `json.encode([1])`, `"[1]"`, `json.encode([s, s])`, "larger than"},
+		{"json.indent", `json.indent("[1]", indent="")`, `"[\n1\n]"`, `json.indent("[" + "[" * 500 + "]" * 500 + "]", indent=" " * 4000)`, "larger than"},
+		{"range", `list(range(3))`, `[0, 1, 2]`, `range(1 << 21)`, "longer than"},
+	}
+	const prelude = `
+s = "x" * 600000
+l = [0] * 40000
+
+def inplace(x, y):
+    x += y
+    return x
+
+def times(x, n):
+    x *= n
+    return x
+
+def remainder(x, y):
+    x %= y
+    return x
+
+def extend(x, y):
+    x.extend(y)
+    return x
+
+def square(n, times):
+    for i in range(times):
+        n = n * n
+    return n
+`
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := run(t, prelude+"def handle(request):\n    return {\"body\": {\"v\": "+tc.ok+"}}\n", nil)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"v": `+tc.want+`}`, *res.Response.Body)
+
+			_, err = run(t, prelude+"def handle(request):\n    return "+tc.tooLarge+"\n", nil)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	t.Run("json.decode", func(t *testing.T) {
+		src := "def handle(request):\n    return {\"body\": {\"v\": json.decode(request.body)}}\n"
+		res, err := script.Run(context.Background(), src, script.Request{Body: "[1]"}, nil)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"v": [1]}`, *res.Response.Body)
+
+		_, err = script.Run(context.Background(), src, script.Request{Body: "[" + strings.Repeat(" ", script.MaxValue) + "]"}, nil)
+		assert.ErrorContains(t, err, "input must be at most")
+	})
+}
+
+func TestMemoryLimit(t *testing.T) {
+	// each sum is a new string just under MaxValue; keeping them all
+	// would take a GB
+	_, err := run(t, `
+def handle(request):
+    s = "x" * 1000000
+    kept = [s + str(i) for i in range(1000)]
+`, nil)
+	assert.ErrorContains(t, err, "too much memory")
+
+	// what an operation builds counts even once it is garbage
+	for name, op := range map[string]string{
+		"+":      `s + "y"`,
+		"*":      `s * 1`,
+		"%":      `"%s" % s`,
+		"join":   `"".join([s])`,
+		"format": `"{}".format(s)`,
+		"extend": `[].extend(l)`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := run(t, `
+s = "x" * 1000000
+l = [0] * 60000
+
+def handle(request):
+    for i in range(200):
+        x = `+op+`
+`, nil)
+			assert.ErrorContains(t, err, "too much memory")
+		})
+	}
+}
+
+// TestMemoryLimitPerRun checks that runs in parallel don't count against
+// each other: together they build more than MaxMemory, each of them less.
+func TestMemoryLimitPerRun(t *testing.T) {
+	src := `
+def handle(request):
+    s = "x" * 1000000
+    for i in range(100):
+        x = s + str(i)
+`
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = script.Run(context.Background(), src, order, nil)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
@@ -1,10 +1,8 @@
 package service
 
 import (
-	"errors"
 	"fmt"
-	"github.com/wader/gormstore/v2"
-	"gorm.io/gorm"
+	"github.com/gorilla/sessions"
 	"net/http"
 	"os"
 	"time"
@@ -16,16 +14,11 @@ import (
 // AuthService holds user business logic
 type AuthService struct {
 	repo         repository.UserRepository
-	sessionStore *gormstore.Store
+	sessionStore sessions.Store
 }
 
-// NewAuthService creates an AuthService
-func NewAuthService(userRepo repository.UserRepository, db *gorm.DB, authSecret string) *AuthService {
-	// build the GORM‐backed session store
-	store := gormstore.New(db, []byte(authSecret))
-	quit := make(chan struct{})
-	go store.PeriodicCleanup(48*time.Hour, quit)
-
+// NewAuthService creates an AuthService that keeps sessions in store.
+func NewAuthService(userRepo repository.UserRepository, store sessions.Store) *AuthService {
 	return &AuthService{
 		repo:         userRepo,
 		sessionStore: store,
@@ -35,7 +28,7 @@ func NewAuthService(userRepo repository.UserRepository, db *gorm.DB, authSecret
 // Register creates a new user with hashed password
 func (s *AuthService) Register(email, plainPassword, fullName string) (*models.User, error) {
 	if _, err := s.repo.GetByEmail(email); err == nil {
-		return nil, fmt.Errorf("email already taken")
+		return nil, newError(ErrConflict, "email already taken", nil)
 	}
 	hash, err := utils.HashPassword(plainPassword)
 	if err != nil {
@@ -56,10 +49,10 @@ func (s *AuthService) Register(email, plainPassword, fullName string) (*models.U
 func (s *AuthService) Authenticate(email, plainPassword string) (*models.User, error) {
 	user, err := s.repo.GetByEmail(email)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, newError(ErrUnauthorized, "invalid credentials", nil)
 	}
 	if !utils.CheckPasswordHash(plainPassword, user.Password) {
-		return nil, errors.New("invalid credentials")
+		return nil, newError(ErrUnauthorized, "invalid credentials", nil)
 	}
 	return user, nil
 }
@@ -67,7 +60,7 @@ func (s *AuthService) Authenticate(email, plainPassword string) (*models.User, e
 // Authorize extracts and validates the user_id from the session cookie.
 func (s *AuthService) Authorize(r *http.Request) (uint, error) {
 	const Name = "_webhook_tester_session_id"
-	authErr := errors.New("unauthorized")
+	authErr := newError(ErrUnauthorized, "unauthorized", nil)
 	sess, err := s.sessionStore.Get(r, Name)
 	if err != nil {
 		return 0, authErr
@@ -117,7 +110,7 @@ func (s *AuthService) ClearSession(w http.ResponseWriter, r *http.Request) {
 func (s *AuthService) ForgotPassword(email, domain string) (string, error) {
 	user, err := s.repo.GetByEmail(email)
 	if err != nil {
-		return "", fmt.Errorf("user not found")
+		return "", newError(ErrNotFound, "user not found", err)
 	}
 	// Generate secure token
 	token, err := utils.GenerateSecureToken(32)
@@ -136,10 +129,10 @@ func (s *AuthService) ForgotPassword(email, domain string) (string, error) {
 func (s *AuthService) ValidateResetToken(token string) (*models.User, error) {
 	user, err := s.repo.GetByResetToken(token)
 	if err != nil {
-		return nil, fmt.Errorf("invalid or expired token")
+		return nil, newError(ErrNotFound, "invalid or expired token", nil)
 	}
 	if time.Now().After(user.ResetTokenExpiry) {
-		return nil, fmt.Errorf("invalid or expired token")
+		return nil, newError(ErrNotFound, "invalid or expired token", nil)
 	}
 	return user, nil
 }
@@ -149,10 +142,10 @@ func (s *AuthService) ValidateResetToken(token string) (*models.User, error) {
 func (s *AuthService) ResetPassword(token, newPassword string) error {
 	user, err := s.repo.GetByResetToken(token)
 	if err != nil {
-		return fmt.Errorf("invalid or expired reset link")
+		return newError(ErrNotFound, "invalid or expired reset link", nil)
 	}
 	if time.Now().After(user.ResetTokenExpiry) {
-		return fmt.Errorf("invalid or expired reset link")
+		return newError(ErrNotFound, "invalid or expired reset link", nil)
 	}
 
 	rules := utils.PasswordRules{
@@ -179,7 +172,7 @@ func (s *AuthService) ResetPassword(token, newPassword string) error {
 func (s *AuthService) ValidateAPIKey(key string) (*models.User, error) {
 	user, err := s.repo.GetByAPIKey(key)
 	if err != nil {
-		return nil, fmt.Errorf("invalid API key")
+		return nil, newError(ErrUnauthorized, "invalid API key", err)
 	}
 	return user, nil
 }
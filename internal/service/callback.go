@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"webhook-tester/internal/callback"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/utils"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxCallbackActions is how many callback actions one webhook may have.
+	MaxCallbackActions = 10
+	// MaxCallbackDelay caps how long after a request its callback is sent.
+	MaxCallbackDelay = time.Hour
+	// MaxScheduledCallbacks is how many callbacks of one webhook may wait at
+	// once. Requests received beyond it get failed callbacks.
+	MaxScheduledCallbacks = 1000
+	// CallbackListLimit is how many callbacks of a webhook List returns.
+	CallbackListLimit = 100
+	// CallbackHeader carries the callback ID on every callback. Requests
+	// with it don't trigger callbacks, so an action calling back its own
+	// webhook can't loop.
+	CallbackHeader = "X-Webhook-Tester-Callback"
+)
+
+// CallbackService makes webhooks call back after they receive a request, the
+// way asynchronous APIs acknowledge a call and report the outcome later.
+// Each callback is sent through replays, so the exchange is recorded as a
+// replay of the received request. Callbacks wait in this process: after a
+// restart, callbacks that were scheduled are marked failed.
+type CallbackService struct {
+	callbacks repository.CallbackRepository
+	replays   *ReplayService
+	logger    *log.Logger
+
+	mu        sync.Mutex
+	scheduled map[string]*scheduledCallback // by callback ID
+}
+
+// scheduledCallback is a callback waiting for its time.
+type scheduledCallback struct {
+	webhookID string
+	actionID  string
+	timer     *time.Timer
+}
+
+// NewCallbackService constructs a CallbackService that sends through replays.
+func NewCallbackService(callbacks repository.CallbackRepository, replays *ReplayService, logger *log.Logger) *CallbackService {
+	return &CallbackService{callbacks: callbacks, replays: replays, logger: logger, scheduled: map[string]*scheduledCallback{}}
+}
+
+// FailInterrupted marks callbacks left scheduled by an earlier process as failed.
+func (s *CallbackService) FailInterrupted() (int64, error) {
+	return s.callbacks.FailUnfinished("the server restarted before the callback was due")
+}
+
+// ListActions returns the callback actions of a webhook, oldest first.
+func (s *CallbackService) ListActions(webhookID string) ([]models.CallbackAction, error) {
+	return s.callbacks.ListActions(webhookID)
+}
+
+// GetAction retrieves one callback action by ID.
+func (s *CallbackService) GetAction(id string) (*models.CallbackAction, error) {
+	a, err := s.callbacks.GetAction(id)
+	return a, storeError("callback action", err)
+}
+
+// CreateAction validates a and adds it to wh.
+func (s *CallbackService) CreateAction(wh *models.Webhook, a *models.CallbackAction) error {
+	if err := validateCallbackAction(wh, a); err != nil {
+		return err
+	}
+	actions, err := s.callbacks.ListActions(wh.ID)
+	if err != nil {
+		return err
+	}
+	if len(actions) >= MaxCallbackActions {
+		return newError(ErrConflict, fmt.Sprintf("a webhook can have at most %d callback actions", MaxCallbackActions), nil)
+	}
+	now := time.Now().UTC()
+	a.ID, a.WebhookID, a.CreatedAt, a.UpdatedAt = utils.GenerateID(), wh.ID, now, now
+	return storeError("callback action", s.callbacks.CreateAction(a))
+}
+
+// UpdateAction validates a and saves it over the action of wh with its ID.
+// Callbacks already scheduled keep the action as it was.
+func (s *CallbackService) UpdateAction(wh *models.Webhook, a *models.CallbackAction) error {
+	existing, err := s.GetAction(a.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateCallbackAction(wh, a); err != nil {
+		return err
+	}
+	a.WebhookID, a.CreatedAt, a.UpdatedAt = existing.WebhookID, existing.CreatedAt, time.Now().UTC()
+	return storeError("callback action", s.callbacks.UpdateAction(a))
+}
+
+// DeleteAction removes a callback action and cancels its scheduled
+// callbacks. Callbacks already made are kept.
+func (s *CallbackService) DeleteAction(id string) error {
+	if err := s.callbacks.DeleteAction(id); err != nil {
+		return storeError("callback action", err)
+	}
+	s.mu.Lock()
+	var cancelled []string
+	for cID, sc := range s.scheduled {
+		if sc.actionID == id {
+			sc.timer.Stop()
+			delete(s.scheduled, cID)
+			cancelled = append(cancelled, cID)
+		}
+	}
+	s.mu.Unlock()
+	for _, cID := range cancelled {
+		if c, err := s.callbacks.GetByID(cID); err == nil {
+			c.Status, c.Error = models.CallbackCancelled, "the action was deleted"
+			s.save(c)
+		}
+	}
+	return nil
+}
+
+// Schedule schedules a callback from each enabled action of wh for wr, a
+// request wh just received. Failing to schedule is logged, not returned: the
+// request itself was received.
+func (s *CallbackService) Schedule(wh *models.Webhook, wr *models.WebhookRequest) {
+	for name := range wr.Headers {
+		if strings.EqualFold(name, CallbackHeader) {
+			return
+		}
+	}
+	actions, err := s.callbacks.ListActions(wh.ID)
+	if err != nil {
+		s.logger.Printf("listing callback actions of %s failed: %v", wh.ID, err)
+		return
+	}
+	for _, a := range actions {
+		if a.Enabled {
+			s.schedule(a, *wr)
+		}
+	}
+}
+
+// schedule stores a callback of a for wr and sends it when it's due.
+func (s *CallbackService) schedule(a models.CallbackAction, wr models.WebhookRequest) {
+	now := time.Now().UTC()
+	delay := time.Duration(a.DelayMs) * time.Millisecond
+	c := &models.Callback{
+		ID:         utils.GenerateID(),
+		WebhookID:  a.WebhookID,
+		ActionID:   a.ID,
+		ActionName: a.Name,
+		RequestID:  wr.ID,
+		Status:     models.CallbackScheduled,
+		DueAt:      now.Add(delay),
+		CreatedAt:  now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, sc := range s.scheduled {
+		if sc.webhookID == a.WebhookID {
+			n++
+		}
+	}
+	if n >= MaxScheduledCallbacks {
+		c.Status, c.Error = models.CallbackFailed, fmt.Sprintf("the webhook already has %d callbacks scheduled", MaxScheduledCallbacks)
+	}
+	if err := s.callbacks.Create(c); err != nil {
+		s.logger.Printf("scheduling callback %s of request %s failed: %v", a.Name, wr.ID, err)
+		return
+	}
+	if c.Done() {
+		return
+	}
+	sc := &scheduledCallback{webhookID: a.WebhookID, actionID: a.ID}
+	sc.timer = time.AfterFunc(delay, func() { s.fire(*c, a, wr) })
+	s.scheduled[c.ID] = sc
+}
+
+// fire sends a callback that is due, unless it was cancelled.
+func (s *CallbackService) fire(c models.Callback, a models.CallbackAction, wr models.WebhookRequest) {
+	s.mu.Lock()
+	_, ok := s.scheduled[c.ID]
+	delete(s.scheduled, c.ID)
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	s.send(&c, a, &wr)
+	s.save(&c)
+}
+
+// send makes the callback and records its outcome in c.
+func (s *CallbackService) send(c *models.Callback, a models.CallbackAction, wr *models.WebhookRequest) {
+	now := time.Now().UTC()
+	c.SentAt, c.Status = &now, models.CallbackFailed
+	out, target, err := callbackRequest(c, a, wr, now)
+	if err != nil {
+		c.Error = err.Error()
+		return
+	}
+	c.URL = target
+	attempt, err := s.replays.Replay(context.Background(), out, ReplayOptions{
+		Target:  target,
+		Resign:  a.Sign,
+		Timeout: time.Duration(a.TimeoutMs) * time.Millisecond,
+	})
+	if err != nil {
+		c.Error = err.Error()
+		return
+	}
+	c.URL, c.AttemptID, c.StatusCode, c.DurationMs, c.Error = attempt.URL, attempt.ID, attempt.StatusCode, attempt.DurationMs, attempt.Error
+	if attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+		c.Status = models.CallbackSucceeded
+	}
+}
+
+// callbackRequest renders what a sends back for wr at now, as a request to
+// replay to the returned target. It has wr's ID, so the exchange is recorded
+// as a replay of wr.
+func callbackRequest(c *models.Callback, a models.CallbackAction, wr *models.WebhookRequest, now time.Time) (*models.WebhookRequest, string, error) {
+	in := callback.Request{
+		ID:         wr.ID,
+		Method:     wr.Method,
+		Headers:    stringMap(wr.Headers),
+		Query:      stringMap(wr.Query),
+		Body:       wr.Body,
+		ReceivedAt: wr.ReceivedAt,
+	}
+
+	target := a.URL
+	if a.URLFrom != "" {
+		if v, ok := callback.Lookup(in, a.URLFrom); ok && callback.Text(v) != "" {
+			target = callback.Text(v)
+		} else if target == "" {
+			return nil, "", fmt.Errorf("the request has no URL at %s", a.URLFrom)
+		}
+	}
+	if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, "", fmt.Errorf("%q is not an http or https URL", target)
+	}
+
+	headers := map[string]string{}
+	for name, v := range a.Headers {
+		value, err := callback.Render(fmt.Sprint(v), in, now)
+		if err != nil {
+			return nil, "", fmt.Errorf("header %s: %w", name, err)
+		}
+		setHeader(headers, name, value)
+	}
+	body, err := callback.Render(a.Body, in, now)
+	if err != nil {
+		return nil, "", fmt.Errorf("body: %w", err)
+	}
+	if body != "" && lookupHeader(headers, "Content-Type") == "" {
+		headers["Content-Type"] = "application/json"
+	}
+	setHeader(headers, CallbackHeader, c.ID)
+
+	out := &models.WebhookRequest{
+		ID:         wr.ID,
+		WebhookID:  wr.WebhookID,
+		Method:     a.Method,
+		Headers:    datatypes.JSONMap{},
+		Body:       body,
+		ReceivedAt: now,
+	}
+	for k, v := range headers {
+		out.Headers[k] = v
+	}
+	return out, target, nil
+}
+
+// stringMap returns the values of m as text.
+func stringMap(m datatypes.JSONMap) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+// List returns the latest callbacks of a webhook, newest first.
+func (s *CallbackService) List(webhookID string) ([]models.Callback, error) {
+	return s.callbacks.ListByWebhook(webhookID, CallbackListLimit)
+}
+
+// ListByRequest returns the callbacks of a received request, newest first.
+func (s *CallbackService) ListByRequest(requestID string) ([]models.Callback, error) {
+	return s.callbacks.ListByRequest(requestID)
+}
+
+// Get retrieves one callback by ID.
+func (s *CallbackService) Get(id string) (*models.Callback, error) {
+	c, err := s.callbacks.GetByID(id)
+	return c, storeError("callback", err)
+}
+
+// Cancel stops a scheduled callback from being sent.
+func (s *CallbackService) Cancel(id string) (*models.Callback, error) {
+	s.mu.Lock()
+	sc, ok := s.scheduled[id]
+	if ok {
+		sc.timer.Stop()
+		delete(s.scheduled, id)
+	}
+	s.mu.Unlock()
+
+	c, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !ok && !c.Done():
+		return nil, newError(ErrConflict, "the callback is being sent", nil)
+	case !ok:
+		return nil, newError(ErrConflict, "the callback has already "+c.Status, nil)
+	}
+	c.Status = models.CallbackCancelled
+	if err := s.callbacks.Update(c); err != nil {
+		return nil, storeError("callback", err)
+	}
+	return c, nil
+}
+
+// save stores the state of a callback, which may have been deleted with its
+// request in the meantime.
+func (s *CallbackService) save(c *models.Callback) {
+	if err := s.callbacks.Update(c); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("saving callback %s failed: %v", c.ID, err)
+	}
+}
+
+// validateCallbackAction checks a callback action of wh and fills in its
+// defaults.
+func validateCallbackAction(wh *models.Webhook, a *models.CallbackAction) error {
+	verr := &ValidationError{}
+	a.Name = strings.TrimSpace(a.Name)
+	switch {
+	case a.Name == "":
+		verr.add("name", "is required")
+	case utf8.RuneCountInString(a.Name) > maxTitleLength:
+		verr.add("name", "must be at most %d characters", maxTitleLength)
+	}
+	if a.DelayMs < 0 || a.DelayMs > MaxCallbackDelay.Milliseconds() {
+		verr.add("delay_ms", "must be between 0 and %d", MaxCallbackDelay.Milliseconds())
+	}
+	a.Method = strings.ToUpper(a.Method)
+	if a.Method == "" {
+		a.Method = http.MethodPost
+	}
+	if !validHeaderName(a.Method) {
+		verr.add("method", "is not a valid HTTP method")
+	}
+	if a.URL == "" && a.URLFrom == "" {
+		verr.add("url", "or url_from must be set")
+	}
+	if u, err := url.Parse(a.URL); a.URL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		verr.add("url", "must be an http or https URL")
+	}
+	if a.URLFrom != "" {
+		if err := callback.CheckPath(a.URLFrom); err != nil {
+			verr.add("url_from", "%s", err.Error())
+		}
+	}
+	for name, v := range a.Headers {
+		field := "headers." + name
+		value, ok := v.(string)
+		switch {
+		case !validHeaderName(name):
+			verr.add(field, "is not a valid header name")
+		case !ok:
+			verr.add(field, "value must be a string")
+		case strings.ContainsAny(value, "\r\n\x00"):
+			verr.add(field, "value must not contain line breaks")
+		default:
+			if err := callback.Parse(value); err != nil {
+				verr.add(field, "%s", strings.TrimPrefix(err.Error(), "callback: "))
+			}
+		}
+	}
+	if err := callback.Parse(a.Body); err != nil {
+		verr.add("body", "%s", strings.TrimPrefix(err.Error(), "callback: "))
+	}
+	if a.Sign && wh.SigningScheme == "" {
+		verr.add("sign", "the webhook has no signing scheme and secret")
+	}
+	if a.TimeoutMs < 0 || a.TimeoutMs > MaxReplayTimeout.Milliseconds() {
+		verr.add("timeout_ms", "must be between 0 and %d", MaxReplayTimeout.Milliseconds())
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
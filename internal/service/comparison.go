@@ -0,0 +1,355 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+	"webhook-tester/internal/compare"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/utils"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxComparisonBatchRequests caps how many requests one batch compares.
+	MaxComparisonBatchRequests = 1000
+	// MaxComparisonConcurrency caps ComparisonBatchOptions.Concurrency.
+	MaxComparisonConcurrency = 20
+	// MaxIgnorePatterns caps CompareOptions.Ignore.
+	MaxIgnorePatterns = 100
+	// MaxActiveComparisonBatches is how many batches of one webhook may run at once.
+	MaxActiveComparisonBatches = 1
+)
+
+// CompareOptions says which two targets a request is sent to and what to
+// leave out of the diff.
+type CompareOptions struct {
+	Baseline  string        // absolute http(s) URL, e.g. the current consumer
+	Candidate string        // absolute http(s) URL, e.g. its replacement
+	Ignore    []string      // compare patterns, on top of compare.DefaultIgnore
+	Resign    bool          // re-sign both requests with the webhook's signing secret
+	Timeout   time.Duration // per side, DefaultReplayTimeout by default
+}
+
+// ComparisonBatchOptions says which requests a batch compares and how.
+type ComparisonBatchOptions struct {
+	CompareOptions
+	Filter      repository.RequestFilter // all of the webhook's requests when empty
+	Concurrency int                      // requests compared at once, 4 by default
+}
+
+// ComparisonService sends stored requests to a baseline and a candidate
+// target and stores how their responses differ. Both sides go through
+// ReplayService, so each comparison also records two replay attempts.
+type ComparisonService struct {
+	repo     repository.ComparisonRepository
+	requests repository.WebhookRequestRepository
+	replays  *ReplayService
+	logger   *log.Logger
+
+	mu     sync.Mutex
+	active map[string]int // running batches by webhook ID
+}
+
+// NewComparisonService constructs a ComparisonService that sends through replays.
+func NewComparisonService(repo repository.ComparisonRepository, requests repository.WebhookRequestRepository, replays *ReplayService, logger *log.Logger) *ComparisonService {
+	return &ComparisonService{repo: repo, requests: requests, replays: replays, logger: logger, active: map[string]int{}}
+}
+
+// FailInterrupted marks batches left running by an earlier process as failed.
+func (s *ComparisonService) FailInterrupted() (int64, error) {
+	return s.repo.FailUnfinishedBatches("the server restarted before the batch finished", time.Now().UTC())
+}
+
+// Compare sends wr to both targets at once and stores the comparison of
+// their responses. Failing to reach a target isn't an error: the comparison
+// is stored with its Error set.
+func (s *ComparisonService) Compare(ctx context.Context, wr *models.WebhookRequest, opts CompareOptions) (*models.Comparison, error) {
+	opts, err := s.validate(wr.WebhookID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.compare(ctx, wr, opts, "")
+}
+
+// List returns the comparisons of a request, newest first.
+func (s *ComparisonService) List(requestID string) ([]models.Comparison, error) {
+	return s.repo.ListByRequest(requestID)
+}
+
+// Get retrieves one comparison by ID.
+func (s *ComparisonService) Get(id string) (*models.Comparison, error) {
+	c, err := s.repo.GetByID(id)
+	return c, storeError("comparison", err)
+}
+
+// StartBatch selects the webhook's requests matching opts.Filter, oldest
+// first, and compares them in the background. The returned batch is running.
+func (s *ComparisonService) StartBatch(webhookID string, opts ComparisonBatchOptions) (*models.ComparisonBatch, error) {
+	verr := &ValidationError{}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = 4
+	}
+	if opts.Concurrency < 0 || opts.Concurrency > MaxComparisonConcurrency {
+		verr.add("concurrency", "must be between 1 and %d", MaxComparisonConcurrency)
+	}
+	if !opts.Filter.Since.IsZero() && !opts.Filter.Until.IsZero() && !opts.Filter.Since.Before(opts.Filter.Until) {
+		verr.add("until", "must be after since")
+	}
+	compareOpts, err := s.validate(webhookID, opts.CompareOptions)
+	var cerr *ValidationError
+	switch {
+	case errors.As(err, &cerr):
+		for field, msg := range cerr.Fields {
+			verr.add(field, "%s", msg)
+		}
+	case err != nil:
+		return nil, err
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+
+	var ids []string
+	errTooMany := errors.New("too many requests")
+	err = s.requests.EachByWebhook(webhookID, opts.Filter, 500, func(batch []models.WebhookRequest) error {
+		for _, wr := range batch {
+			if len(ids) == MaxComparisonBatchRequests {
+				return errTooMany
+			}
+			ids = append(ids, wr.ID)
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errTooMany):
+		return nil, newError(ErrValidation, fmt.Sprintf("a batch can compare at most %d requests; narrow the selection", MaxComparisonBatchRequests), nil)
+	case err != nil:
+		return nil, err
+	case len(ids) == 0:
+		return nil, newError(ErrValidation, "no requests match the selection", nil)
+	}
+
+	batch := &models.ComparisonBatch{
+		ID:           utils.GenerateID(),
+		WebhookID:    webhookID,
+		BaselineURL:  compareOpts.Baseline,
+		CandidateURL: compareOpts.Candidate,
+		Ignore:       compareOpts.Ignore,
+		Resign:       compareOpts.Resign,
+		TimeoutMs:    compareOpts.Timeout.Milliseconds(),
+		Concurrency:  opts.Concurrency,
+		Status:       models.ComparisonBatchRunning,
+		Total:        len(ids),
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[webhookID] >= MaxActiveComparisonBatches {
+		return nil, newError(ErrConflict, fmt.Sprintf("a webhook can run at most %d comparison batch at once", MaxActiveComparisonBatches), nil)
+	}
+	if err := s.repo.CreateBatch(batch); err != nil {
+		return nil, storeError("comparison batch", err)
+	}
+	s.active[webhookID]++
+	go s.run(*batch, compareOpts, ids)
+	return batch, nil
+}
+
+// Batches returns the batches of a webhook, newest first.
+func (s *ComparisonService) Batches(webhookID string) ([]models.ComparisonBatch, error) {
+	return s.repo.ListBatches(webhookID)
+}
+
+// Batch retrieves one batch by ID.
+func (s *ComparisonService) Batch(id string) (*models.ComparisonBatch, error) {
+	b, err := s.repo.GetBatch(id)
+	return b, storeError("comparison batch", err)
+}
+
+// BatchComparisons returns the comparisons of a batch, oldest first, and the
+// paths where they differ, most frequent first.
+func (s *ComparisonService) BatchComparisons(batchID string) ([]models.Comparison, []compare.PathCount, error) {
+	list, err := s.repo.ListByBatch(batchID)
+	if err != nil {
+		return nil, nil, err
+	}
+	diffs := make([][]compare.Difference, len(list))
+	for i, c := range list {
+		diffs[i] = c.Differences
+	}
+	return list, compare.Summarize(diffs), nil
+}
+
+// run compares the requests of a batch and records its outcome.
+func (s *ComparisonService) run(batch models.ComparisonBatch, opts CompareOptions, ids []string) {
+	defer func() {
+		s.mu.Lock()
+		s.active[batch.WebhookID]--
+		if s.active[batch.WebhookID] == 0 {
+			delete(s.active, batch.WebhookID)
+		}
+		s.mu.Unlock()
+	}()
+
+	var mu sync.Mutex // guards batch
+	next := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < batch.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range next {
+				c, err := s.compareByID(id, opts, batch.ID)
+				mu.Lock()
+				switch {
+				case errors.Is(err, gorm.ErrRecordNotFound):
+					batch.Skipped++
+				case err != nil:
+					s.logger.Printf("comparing request %s in batch %s failed: %v", id, batch.ID, err)
+					batch.Failed++
+				case c.Error != "":
+					batch.Failed++
+				case c.Equal:
+					batch.Equal++
+				default:
+					batch.Different++
+				}
+				if err := s.repo.UpdateBatch(&batch); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					s.logger.Printf("saving comparison batch %s failed: %v", batch.ID, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		next <- id
+	}
+	close(next)
+	wg.Wait()
+
+	now := time.Now().UTC()
+	batch.Status, batch.FinishedAt = models.ComparisonBatchCompleted, &now
+	if err := s.repo.UpdateBatch(&batch); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("saving comparison batch %s failed: %v", batch.ID, err)
+	}
+}
+
+// compareByID loads a request and compares it as part of a batch.
+func (s *ComparisonService) compareByID(id string, opts CompareOptions, batchID string) (*models.Comparison, error) {
+	wr, err := s.requests.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.compare(context.Background(), wr, opts, batchID)
+}
+
+// compare sends wr to both targets as validated opts say and stores the result.
+func (s *ComparisonService) compare(ctx context.Context, wr *models.WebhookRequest, opts CompareOptions, batchID string) (*models.Comparison, error) {
+	var baseline, candidate *models.ReplayAttempt
+	var errBaseline, errCandidate error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		baseline, errBaseline = s.replays.Replay(ctx, wr, ReplayOptions{Target: opts.Baseline, Resign: opts.Resign, Timeout: opts.Timeout})
+	}()
+	go func() {
+		defer wg.Done()
+		candidate, errCandidate = s.replays.Replay(ctx, wr, ReplayOptions{Target: opts.Candidate, Resign: opts.Resign, Timeout: opts.Timeout})
+	}()
+	wg.Wait()
+	if err := errors.Join(errBaseline, errCandidate); err != nil {
+		return nil, err
+	}
+
+	c := &models.Comparison{
+		ID:                 utils.GenerateID(),
+		WebhookID:          wr.WebhookID,
+		RequestID:          wr.ID,
+		BatchID:            batchID,
+		BaselineURL:        opts.Baseline,
+		CandidateURL:       opts.Candidate,
+		Ignore:             opts.Ignore,
+		BaselineAttemptID:  baseline.ID,
+		CandidateAttemptID: candidate.ID,
+		BaselineStatus:     baseline.StatusCode,
+		CandidateStatus:    candidate.StatusCode,
+		Differences:        datatypes.JSONSlice[compare.Difference]{},
+		CreatedAt:          time.Now().UTC(),
+	}
+	switch {
+	case baseline.Error != "":
+		c.Error = "baseline: " + baseline.Error
+	case candidate.Error != "":
+		c.Error = "candidate: " + candidate.Error
+	default:
+		ignore := append(append([]string{}, compare.DefaultIgnore...), opts.Ignore...)
+		if diffs := compare.Diff(attemptResponse(baseline), attemptResponse(candidate), ignore); diffs != nil {
+			c.Differences = diffs
+		}
+		c.Equal = len(c.Differences) == 0
+	}
+	if err := s.repo.Insert(c); err != nil {
+		return nil, storeError("comparison", err)
+	}
+	return c, nil
+}
+
+// validate checks opts for a request of webhookID and fills in defaults.
+func (s *ComparisonService) validate(webhookID string, opts CompareOptions) (CompareOptions, error) {
+	verr := &ValidationError{}
+	for field, target := range map[string]string{"baseline": opts.Baseline, "candidate": opts.Candidate} {
+		if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			verr.add(field, "must be an http or https URL")
+		}
+	}
+	if len(opts.Ignore) > MaxIgnorePatterns {
+		verr.add("ignore", "must have at most %d patterns", MaxIgnorePatterns)
+	}
+	for _, p := range opts.Ignore {
+		if err := compare.ValidPattern(p); err != nil {
+			verr.add("ignore", "%s", err.Error())
+			break
+		}
+	}
+	if opts.Timeout < 0 || opts.Timeout > MaxReplayTimeout {
+		verr.add("timeout_ms", "must be between 0 and %d", MaxReplayTimeout.Milliseconds())
+	}
+	if opts.Resign {
+		key, err := s.replays.signingKey(webhookID)
+		if err != nil {
+			return opts, err
+		}
+		if key == nil {
+			verr.add("resign", "the webhook has no signing scheme and secret")
+		}
+	}
+	if len(verr.Fields) > 0 {
+		return opts, verr
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultReplayTimeout
+	}
+	if opts.Ignore == nil {
+		opts.Ignore = []string{}
+	}
+	return opts, nil
+}
+
+// attemptResponse is the response an attempt recorded.
+func attemptResponse(a *models.ReplayAttempt) compare.Response {
+	h := make(map[string]string, len(a.ResponseHeaders))
+	for k, v := range a.ResponseHeaders {
+		h[k] = fmt.Sprint(v)
+	}
+	return compare.Response{StatusCode: a.StatusCode, Header: h, Body: a.ResponseBody}
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/utils"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxDeliveryAttempts caps how many attempts one delivery makes.
+	MaxDeliveryAttempts = 20
+	// MaxRetryDelay caps the wait before one retry.
+	MaxRetryDelay = time.Hour
+	// MaxDeliveryBudget caps DeliveryOptions.Budget.
+	MaxDeliveryBudget = 24 * time.Hour
+	// MaxActiveDeliveries is how many deliveries of one webhook may run at once.
+	MaxActiveDeliveries = 10
+	// DefaultRetryAttempts is how many attempts the exponential policy makes
+	// when DeliveryOptions.MaxAttempts is zero.
+	DefaultRetryAttempts = 5
+	// DefaultRetryDelay is the exponential policy's first wait when
+	// DeliveryOptions.InitialDelay is zero.
+	DefaultRetryDelay = time.Second
+)
+
+// DeliveryOptions describes an event to deliver and how to retry it.
+type DeliveryOptions struct {
+	SendOptions
+	Policy         string          // models.RetryNone by default
+	MaxAttempts    int             // exponential: attempts in all, DefaultRetryAttempts by default
+	InitialDelay   time.Duration   // exponential: the first wait, doubled for each retry
+	Schedule       []time.Duration // custom: the wait before each retry
+	Budget         time.Duration   // for all attempts and waits, 0 for no limit
+	DeliveryHeader string          // header carrying the delivery ID, none by default
+}
+
+// DeliveryService sends events the way providers deliver webhooks: the event
+// is stored once and sent again, on a retry schedule, until the target
+// answers 2xx. Deliveries run in this process: after a restart, deliveries
+// that were running are marked failed.
+type DeliveryService struct {
+	deliveries repository.DeliveryRepository
+	sender     *SenderService
+	replays    *ReplayService
+	logger     *log.Logger
+
+	mu     sync.Mutex
+	active map[string]*deliveryRun // by delivery ID
+}
+
+// NewDeliveryService constructs a DeliveryService that stores events through
+// sender and sends them through replays.
+func NewDeliveryService(deliveries repository.DeliveryRepository, sender *SenderService, replays *ReplayService, logger *log.Logger) *DeliveryService {
+	return &DeliveryService{deliveries: deliveries, sender: sender, replays: replays, logger: logger, active: map[string]*deliveryRun{}}
+}
+
+// FailInterrupted marks deliveries left running by an earlier process as failed.
+func (s *DeliveryService) FailInterrupted() (int64, error) {
+	return s.deliveries.FailUnfinished("the server restarted before the delivery finished", time.Now().UTC())
+}
+
+// Start stores the event described by opts under wh and delivers it in the
+// background. The returned delivery is delivering.
+func (s *DeliveryService) Start(wh *models.Webhook, opts DeliveryOptions) (*models.Delivery, error) {
+	schedule, err := retrySchedule(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &models.Delivery{
+		ID:        utils.GenerateID(),
+		WebhookID: wh.ID,
+		Target:    opts.Target,
+		Policy:    opts.Policy,
+		Schedule:  datatypes.JSONSlice[int64]{},
+		Sign:      opts.Sign,
+		TimeoutMs: opts.Timeout.Milliseconds(),
+		BudgetMs:  opts.Budget.Milliseconds(),
+		Status:    models.DeliveryDelivering,
+	}
+	if d.Policy == "" {
+		d.Policy = models.RetryNone
+	}
+	for _, delay := range schedule {
+		d.Schedule = append(d.Schedule, delay.Milliseconds())
+	}
+	if opts.DeliveryHeader != "" {
+		headers := map[string]string{}
+		for k, v := range opts.Headers {
+			headers[k] = v
+		}
+		setHeader(headers, opts.DeliveryHeader, d.ID)
+		opts.Headers = headers
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, run := range s.active {
+		if run.webhookID == wh.ID {
+			n++
+		}
+	}
+	if n >= MaxActiveDeliveries {
+		return nil, newError(ErrConflict, fmt.Sprintf("a webhook can run at most %d deliveries at once", MaxActiveDeliveries), nil)
+	}
+	wr, err := s.sender.record(wh, opts.SendOptions)
+	if err != nil {
+		return nil, err
+	}
+	d.RequestID, d.CreatedAt = wr.ID, time.Now().UTC()
+	if err := s.deliveries.Create(d); err != nil {
+		return nil, storeError("delivery", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &deliveryRun{webhookID: wh.ID, delivery: *d, ctx: ctx, cancel: cancel}
+	s.active[d.ID] = run
+	go s.run(run, wr)
+	return d, nil
+}
+
+// List returns the deliveries of a webhook, newest first.
+func (s *DeliveryService) List(webhookID string) ([]models.Delivery, error) {
+	return s.deliveries.ListByWebhook(webhookID)
+}
+
+// Get retrieves one delivery by ID.
+func (s *DeliveryService) Get(id string) (*models.Delivery, error) {
+	d, err := s.deliveries.GetByID(id)
+	return d, storeError("delivery", err)
+}
+
+// Attempts returns the attempts of a delivery, in order.
+func (s *DeliveryService) Attempts(deliveryID string) ([]models.DeliveryAttempt, error) {
+	return s.deliveries.ListAttempts(deliveryID)
+}
+
+// Cancel stops a delivery from making more attempts. An attempt in flight
+// completes.
+func (s *DeliveryService) Cancel(id string) (*models.Delivery, error) {
+	s.mu.Lock()
+	run, ok := s.active[id]
+	s.mu.Unlock()
+	if ok {
+		run.mu.Lock()
+		defer run.mu.Unlock()
+	}
+	if !ok || run.done {
+		d, err := s.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newError(ErrConflict, "the delivery has already "+d.Status, nil)
+	}
+
+	run.end(models.DeliveryCancelled, "")
+	d := run.delivery
+	if err := s.deliveries.Update(&d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// deliveryRun is the live state of an active delivery.
+type deliveryRun struct {
+	webhookID string
+	ctx       context.Context // done when the delivery ends early
+	cancel    context.CancelFunc
+
+	mu       sync.Mutex
+	delivery models.Delivery // latest state, saved after every change
+	ended    bool            // the final status is set
+	done     bool            // the delivery has finished and can't be changed
+}
+
+// end stops the delivery with the given final status. Callers must hold r.mu.
+func (r *deliveryRun) end(status, reason string) {
+	if r.ended {
+		return
+	}
+	r.ended = true
+	r.delivery.Status, r.delivery.Error, r.delivery.NextAttemptAt = status, reason, nil
+	r.cancel()
+}
+
+// run makes the delivery's attempts on schedule and records its outcome.
+func (s *DeliveryService) run(r *deliveryRun, wr *models.WebhookRequest) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.active, r.delivery.ID)
+		s.mu.Unlock()
+	}()
+
+	r.mu.Lock()
+	d := r.delivery
+	r.mu.Unlock()
+	start := time.Now()
+	budget := time.Duration(d.BudgetMs) * time.Millisecond
+
+	for seq := 1; r.ctx.Err() == nil; seq++ {
+		timeout := time.Duration(d.TimeoutMs) * time.Millisecond
+		if timeout == 0 {
+			timeout = DefaultReplayTimeout
+		}
+		if budget > 0 {
+			left := budget - time.Since(start)
+			if left < time.Millisecond {
+				r.mu.Lock()
+				r.end(models.DeliveryFailed, "the time budget ran out")
+				r.mu.Unlock()
+				break
+			}
+			timeout = min(timeout, left)
+		}
+
+		a := s.attempt(&d, wr, seq, timeout)
+
+		r.mu.Lock()
+		r.delivery.Attempts, r.delivery.StatusCode = seq, a.StatusCode
+		switch {
+		case r.ended:
+		case a.Succeeded:
+			r.end(models.DeliverySucceeded, "")
+		case a.AttemptID == "":
+			// the event couldn't be sent at all, e.g. it was deleted
+			r.end(models.DeliveryFailed, a.Error)
+		case seq > len(d.Schedule):
+			reason := "the only attempt failed"
+			if seq > 1 {
+				reason = fmt.Sprintf("all %d attempts failed", seq)
+			}
+			r.end(models.DeliveryFailed, reason)
+		default:
+			delay := time.Duration(d.Schedule[seq-1]) * time.Millisecond
+			if budget > 0 && time.Since(start)+delay >= budget {
+				r.end(models.DeliveryFailed, fmt.Sprintf("the time budget ran out before attempt %d", seq+1))
+				break
+			}
+			next := time.Now().Add(delay).UTC()
+			r.delivery.NextAttemptAt = &next
+		}
+		s.save(&r.delivery)
+		next := r.delivery.NextAttemptAt
+		r.mu.Unlock()
+		if next == nil {
+			break
+		}
+
+		timer := time.NewTimer(time.Until(*next))
+		select {
+		case <-timer.C:
+		case <-r.ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.done = true
+	r.cancel()
+	now := time.Now().UTC()
+	r.delivery.NextAttemptAt, r.delivery.FinishedAt = nil, &now
+	s.save(&r.delivery)
+}
+
+// attempt sends the event once and records the attempt. Retries of signed
+// deliveries are signed afresh, as providers do.
+func (s *DeliveryService) attempt(d *models.Delivery, wr *models.WebhookRequest, seq int, timeout time.Duration) models.DeliveryAttempt {
+	a := models.DeliveryAttempt{DeliveryID: d.ID, Seq: seq, SentAt: time.Now().UTC()}
+	attempt, err := s.replays.Replay(context.Background(), wr, ReplayOptions{Target: d.Target, Resign: d.Sign && seq > 1, Timeout: timeout})
+	if err != nil {
+		a.Error = err.Error()
+	} else {
+		a.AttemptID, a.StatusCode, a.DurationMs, a.Error = attempt.ID, attempt.StatusCode, attempt.DurationMs, attempt.Error
+		a.Succeeded = attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300
+	}
+	if err := s.deliveries.AddAttempt(&a); err != nil && !errors.Is(err, gorm.ErrForeignKeyViolated) {
+		s.logger.Printf("saving attempt %d of delivery %s failed: %v", seq, d.ID, err)
+	}
+	return a
+}
+
+// save stores the state of a delivery, which may have been deleted with its
+// event in the meantime.
+func (s *DeliveryService) save(d *models.Delivery) {
+	if err := s.deliveries.Update(d); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("saving delivery %s failed: %v", d.ID, err)
+	}
+}
+
+// retrySchedule checks the retry options and returns the wait before each
+// retry under opts.Policy.
+func retrySchedule(opts DeliveryOptions) ([]time.Duration, error) {
+	verr := &ValidationError{}
+	var schedule []time.Duration
+	if opts.Policy != models.RetryExponential {
+		if opts.MaxAttempts != 0 {
+			verr.add("max_attempts", "only applies to the exponential policy")
+		}
+		if opts.InitialDelay != 0 {
+			verr.add("initial_delay_ms", "only applies to the exponential policy")
+		}
+	}
+	if opts.Policy != models.RetryCustom && len(opts.Schedule) > 0 {
+		verr.add("schedule_ms", "only applies to the custom policy")
+	}
+	switch opts.Policy {
+	case "", models.RetryNone:
+	case models.RetryExponential:
+		attempts, delay := opts.MaxAttempts, opts.InitialDelay
+		if attempts == 0 {
+			attempts = DefaultRetryAttempts
+		}
+		if delay == 0 {
+			delay = DefaultRetryDelay
+		}
+		if attempts < 1 || attempts > MaxDeliveryAttempts {
+			verr.add("max_attempts", "must be between 1 and %d", MaxDeliveryAttempts)
+		}
+		if delay < 0 || delay > MaxRetryDelay {
+			verr.add("initial_delay_ms", "must be between 0 and %d", MaxRetryDelay.Milliseconds())
+		}
+		for i := 1; i < attempts && i < MaxDeliveryAttempts; i++ {
+			schedule = append(schedule, delay)
+			delay = min(2*delay, MaxRetryDelay)
+		}
+	case models.RetryCustom:
+		if len(opts.Schedule) == 0 || len(opts.Schedule) >= MaxDeliveryAttempts {
+			verr.add("schedule_ms", "must list between 1 and %d retries", MaxDeliveryAttempts-1)
+		}
+		for i, delay := range opts.Schedule {
+			if delay < 0 || delay > MaxRetryDelay {
+				verr.add(fmt.Sprintf("schedule_ms.%d", i), "must be between 0 and %d", MaxRetryDelay.Milliseconds())
+			}
+		}
+		schedule = opts.Schedule
+	default:
+		verr.add("policy", `must be "none", "exponential" or "custom"`)
+	}
+	if opts.Budget < 0 || opts.Budget > MaxDeliveryBudget {
+		verr.add("budget_ms", "must be between 0 and %d", MaxDeliveryBudget.Milliseconds())
+	}
+	if opts.DeliveryHeader != "" && !validHeaderName(opts.DeliveryHeader) {
+		verr.add("delivery_header", "is not a valid header name")
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+	return schedule, nil
+}
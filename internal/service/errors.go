@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Domain errors returned by the services. Handlers map them to HTTP
+// responses with errors.Is, so the storage backend never leaks into status codes.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	// ErrQuotaExceeded is returned when storing a request would exceed the owner's storage quota.
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// Error is a domain error with a message that is safe to show to users. It
+// matches its Kind with errors.Is and keeps the underlying cause in the chain.
+type Error struct {
+	Kind error
+	Msg  string
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Msg }
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+func newError(kind error, msg string, cause error) error {
+	return &Error{Kind: kind, Msg: msg, Err: cause}
+}
+
+// storeError translates repository errors about what ("webhook", "request")
+// into domain errors. Other errors are returned unchanged.
+func storeError(what string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return newError(ErrNotFound, what+" not found", err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return newError(ErrConflict, what+" already exists", err)
+	}
+	return err
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"webhook-tester/internal/load"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/signing"
+	"webhook-tester/internal/utils"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxLoadConcurrency caps LoadOptions.Concurrency.
+	MaxLoadConcurrency = 100
+	// MaxLoadRate caps LoadOptions.Rate.
+	MaxLoadRate = 1000
+	// MaxLoadDuration caps LoadOptions.Duration.
+	MaxLoadDuration = 10 * time.Minute
+	// MaxLoadRequests caps how many requests one run sends, whatever its duration.
+	MaxLoadRequests = 100_000
+	// MaxActiveLoadRuns is how many load runs of one webhook may run at once.
+	MaxActiveLoadRuns = 1
+	// DefaultLoadConcurrency is used when LoadOptions.Concurrency is zero.
+	DefaultLoadConcurrency = 10
+	// LoadProgressInterval is how often a running run saves its results.
+	LoadProgressInterval = time.Second
+)
+
+// LoadOptions describes a load run: what to send, where, and how hard.
+type LoadOptions struct {
+	Target      string        // absolute http(s) URL
+	RequestID   string        // a captured request of the webhook, sent as captured
+	Template    string        // or an events.Template, rendered afresh for every request
+	Sign        bool          // sign every request with the webhook's signing secret
+	Concurrency int           // requests in flight at most, DefaultLoadConcurrency by default
+	Rate        float64       // requests started per second, 0 for as fast as responses allow
+	Duration    time.Duration // stop starting requests after this long, 0 for no limit
+	Count       int           // stop after this many requests, 0 for no limit
+	Timeout     time.Duration // per request, DefaultReplayTimeout by default
+}
+
+// LoadService fires a captured or templated request at a target many times,
+// like a provider's burst of webhooks, and keeps the sums of the results:
+// status codes, errors, latency percentiles and throughput. Requests aren't
+// stored one by one. Runs happen in this process: after a restart, runs
+// that were running are marked failed.
+type LoadService struct {
+	runs     repository.LoadRunRepository
+	requests repository.WebhookRequestRepository
+	replays  *ReplayService
+	logger   *log.Logger
+
+	mu     sync.Mutex
+	active map[string]*loadRun // by run ID
+}
+
+// NewLoadService constructs a LoadService that sends through the client of replays.
+func NewLoadService(runs repository.LoadRunRepository, requests repository.WebhookRequestRepository, replays *ReplayService, logger *log.Logger) *LoadService {
+	return &LoadService{runs: runs, requests: requests, replays: replays, logger: logger, active: map[string]*loadRun{}}
+}
+
+// FailInterrupted marks runs left running by an earlier process as failed.
+func (s *LoadService) FailInterrupted() (int64, error) {
+	return s.runs.FailUnfinished("the server restarted before the run finished", time.Now().UTC())
+}
+
+// Start checks opts and starts a load run from wh in the background. The
+// returned run is running.
+func (s *LoadService) Start(wh *models.Webhook, opts LoadOptions) (*models.LoadRun, error) {
+	verr := &ValidationError{}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = DefaultLoadConcurrency
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultReplayTimeout
+	}
+	switch {
+	case opts.RequestID == "" && opts.Template == "":
+		verr.add("request_id", "or template must be set")
+	case opts.RequestID != "" && opts.Template != "":
+		verr.add("template", "can't be set with request_id")
+	}
+	if opts.Concurrency < 0 || opts.Concurrency > MaxLoadConcurrency {
+		verr.add("concurrency", "must be between 1 and %d", MaxLoadConcurrency)
+	}
+	if opts.Rate < 0 || opts.Rate > MaxLoadRate {
+		verr.add("rate", "must be between 0 and %d", MaxLoadRate)
+	}
+	if opts.Duration < 0 || opts.Duration > MaxLoadDuration {
+		verr.add("duration_ms", "must be between 0 and %d", MaxLoadDuration.Milliseconds())
+	}
+	if opts.Count < 0 || opts.Count > MaxLoadRequests {
+		verr.add("count", "must be between 0 and %d", MaxLoadRequests)
+	}
+	if opts.Duration == 0 && opts.Count == 0 {
+		verr.add("count", "or duration_ms must be set")
+	}
+	if opts.Timeout < 0 || opts.Timeout > MaxReplayTimeout {
+		verr.add("timeout_ms", "must be between 0 and %d", MaxReplayTimeout.Milliseconds())
+	}
+	if opts.Sign && wh.SigningScheme == "" {
+		verr.add("sign", "the webhook has no signing scheme and secret")
+	}
+	var build requestBuilder
+	if verr.Fields["request_id"] == "" && verr.Fields["template"] == "" && verr.Fields["sign"] == "" {
+		var err error
+		build, err = s.builder(wh, opts)
+		var berr *ValidationError
+		switch {
+		case errors.As(err, &berr):
+			for field, msg := range berr.Fields {
+				verr.add(field, "%s", msg)
+			}
+		case err != nil:
+			return nil, err
+		}
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+
+	run := &models.LoadRun{
+		ID:          utils.GenerateID(),
+		WebhookID:   wh.ID,
+		RequestID:   opts.RequestID,
+		Template:    opts.Template,
+		Sign:        opts.Sign,
+		Target:      opts.Target,
+		Concurrency: opts.Concurrency,
+		Rate:        opts.Rate,
+		DurationMs:  opts.Duration.Milliseconds(),
+		Count:       opts.Count,
+		TimeoutMs:   opts.Timeout.Milliseconds(),
+		Status:      models.LoadRunning,
+		StatusCodes: datatypes.JSONSlice[load.StatusCount]{},
+		ErrorCounts: datatypes.JSONSlice[load.ErrorCount]{},
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.active {
+		if r.webhookID == wh.ID {
+			n++
+		}
+	}
+	if n >= MaxActiveLoadRuns {
+		return nil, newError(ErrConflict, fmt.Sprintf("a webhook can run at most %d load run at once", MaxActiveLoadRuns), nil)
+	}
+	if err := s.runs.Create(run); err != nil {
+		return nil, storeError("load run", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &loadRun{webhookID: wh.ID, run: *run, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	s.active[run.ID] = r
+	go s.run(r, build)
+	return run, nil
+}
+
+// List returns the runs of a webhook, newest first.
+func (s *LoadService) List(webhookID string) ([]models.LoadRun, error) {
+	return s.runs.ListByWebhook(webhookID)
+}
+
+// Get retrieves one run by ID. The results of a running run are at most
+// LoadProgressInterval old.
+func (s *LoadService) Get(id string) (*models.LoadRun, error) {
+	run, err := s.runs.GetByID(id)
+	return run, storeError("load run", err)
+}
+
+// Cancel stops a run. Requests in flight are abandoned and left out of the
+// results, which are returned.
+func (s *LoadService) Cancel(id string) (*models.LoadRun, error) {
+	s.mu.Lock()
+	r, ok := s.active[id]
+	s.mu.Unlock()
+	if !ok {
+		run, err := s.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newError(ErrConflict, "the load run has already "+run.Status, nil)
+	}
+
+	r.mu.Lock()
+	r.cancelled = true
+	r.mu.Unlock()
+	r.cancel()
+	<-r.done
+	return s.Get(id)
+}
+
+// loadRun is the live state of a running run.
+type loadRun struct {
+	webhookID string
+	ctx       context.Context // done when the run is cancelled
+	cancel    context.CancelFunc
+	done      chan struct{} // closed when the run has saved its outcome
+
+	mu        sync.Mutex
+	run       models.LoadRun // latest state
+	cancelled bool
+}
+
+// requestBuilder builds the next request of a run.
+type requestBuilder func(ctx context.Context) (*http.Request, error)
+
+// builder checks what opts sends and returns how to build each request.
+func (s *LoadService) builder(wh *models.Webhook, opts LoadOptions) (requestBuilder, error) {
+	var build requestBuilder
+	if opts.Template != "" {
+		send := SendOptions{Target: opts.Target, Template: opts.Template, Sign: opts.Sign}
+		build = func(ctx context.Context) (*http.Request, error) {
+			now := time.Now().UTC()
+			ev, err := buildEvent(wh, send, now)
+			if err != nil {
+				return nil, err
+			}
+			return buildReplay(ctx, eventRequest(wh, ev, now), ReplayOptions{Target: opts.Target}, nil)
+		}
+	} else {
+		wr, err := s.requests.GetByID(opts.RequestID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && wr.WebhookID != wh.ID) {
+			return nil, &ValidationError{Fields: map[string]string{"request_id": "is not a request of this webhook"}}
+		}
+		if err != nil {
+			return nil, storeError("request", err)
+		}
+		var key *signing.Key
+		if opts.Sign {
+			key = &signing.Key{Scheme: wh.SigningScheme, Secret: wh.SigningSecret}
+		}
+		replay := ReplayOptions{Target: opts.Target, Resign: opts.Sign}
+		build = func(ctx context.Context) (*http.Request, error) {
+			return buildReplay(ctx, wr, replay, key)
+		}
+	}
+	// building the first request reports bad options before the run starts
+	if _, err := build(context.Background()); err != nil {
+		return nil, err
+	}
+	return build, nil
+}
+
+// run sends the requests of a run and records its results.
+func (s *LoadService) run(r *loadRun, build requestBuilder) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.active, r.run.ID)
+		s.mu.Unlock()
+		close(r.done)
+	}()
+
+	r.mu.Lock()
+	run := r.run
+	r.mu.Unlock()
+	timeout := time.Duration(run.TimeoutMs) * time.Millisecond
+	limit := run.Count
+	if limit == 0 {
+		limit = MaxLoadRequests
+	}
+	start := time.Now()
+	dispatch := r.ctx
+	if run.DurationMs > 0 {
+		var cancel context.CancelFunc
+		dispatch, cancel = context.WithDeadline(r.ctx, start.Add(time.Duration(run.DurationMs)*time.Millisecond))
+		defer cancel()
+	}
+
+	rec := load.NewRecorder()
+	next := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < run.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range next {
+				// requests in flight when the run is cancelled are abandoned, not counted
+				if res := s.fire(r.ctx, build, timeout); r.ctx.Err() == nil {
+					rec.Add(res)
+				}
+			}
+		}()
+	}
+
+	stopProgress := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		ticker := time.NewTicker(LoadProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.mu.Lock()
+				r.run.SetSummary(rec.Summary(time.Since(start)))
+				s.save(&r.run)
+				r.mu.Unlock()
+			case <-stopProgress:
+				return
+			}
+		}
+	}()
+
+send:
+	for i := 0; i < limit; i++ {
+		if run.Rate > 0 {
+			due := time.Duration(float64(i) / run.Rate * float64(time.Second))
+			timer := time.NewTimer(time.Until(start.Add(due)))
+			select {
+			case <-timer.C:
+			case <-dispatch.Done():
+				timer.Stop()
+				break send
+			}
+		}
+		select {
+		case next <- struct{}{}:
+		case <-dispatch.Done():
+			break send
+		}
+	}
+	close(next)
+	wg.Wait()
+	close(stopProgress)
+	<-progressDone
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.run.SetSummary(rec.Summary(time.Since(start)))
+	r.run.Status = models.LoadCompleted
+	if r.cancelled {
+		r.run.Status = models.LoadCancelled
+	}
+	now := time.Now().UTC()
+	r.run.FinishedAt = &now
+	s.save(&r.run)
+	r.cancel()
+}
+
+// fire sends one request and times it.
+func (s *LoadService) fire(ctx context.Context, build requestBuilder, timeout time.Duration) load.Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := build(ctx)
+	if err != nil {
+		return load.Result{Error: err.Error()}
+	}
+	start := time.Now()
+	resp, err := s.replays.client.Do(req)
+	res := load.Result{Latency: time.Since(start)}
+	switch {
+	case resp != nil:
+		res.StatusCode = resp.StatusCode
+	case err != nil:
+		res.Error = replayError(err, timeout)
+	}
+	return res
+}
+
+// save stores the state of a run, whose webhook may have been deleted in
+// the meantime.
+func (s *LoadService) save(run *models.LoadRun) {
+	if err := s.runs.Update(run); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("saving load run %s failed: %v", run.ID, err)
+	}
+}
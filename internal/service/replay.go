@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/outbound"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/signing"
+	"webhook-tester/internal/snippet"
+	"webhook-tester/internal/utils"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	// DefaultReplayTimeout is how long a replay waits for the target when
+	// ReplayOptions.Timeout is zero.
+	DefaultReplayTimeout = 30 * time.Second
+	// MaxReplayTimeout caps ReplayOptions.Timeout.
+	MaxReplayTimeout = 2 * time.Minute
+)
+
+// ReplayOptions says where to send a stored request and how to change it on
+// the way. Zero fields keep what was captured.
+type ReplayOptions struct {
+	Target        string            // absolute http(s) URL; the captured query is merged into its query
+	Method        string            // replaces the captured method
+	Headers       map[string]string // set, replacing captured headers of the same name
+	RemoveHeaders []string          // captured headers not to send
+	Query         map[string]string // set, replacing captured parameters of the same name
+	RemoveQuery   []string          // captured parameters not to send
+	Body          *string           // replaces the captured body
+	Resign        bool              // recompute signature headers with the webhook's signing secret
+	Timeout       time.Duration     // defaults to DefaultReplayTimeout
+}
+
+// ReplayService sends stored requests to arbitrary URLs and records each
+// attempt with the target's response.
+type ReplayService struct {
+	repo     repository.ReplayAttemptRepository
+	webhooks repository.WebhookRepository
+	client   *outbound.Client
+}
+
+// NewReplayService constructs a ReplayService that sends through client,
+// which decides what targets are reachable. The client should not follow
+// redirects, so an attempt records the target's own response. Webhooks
+// provide the signing secrets of re-signed replays.
+func NewReplayService(repo repository.ReplayAttemptRepository, webhooks repository.WebhookRepository, client *outbound.Client) *ReplayService {
+	return &ReplayService{repo: repo, webhooks: webhooks, client: client}
+}
+
+// Replay sends wr, changed by opts, and stores the attempt. Failing to reach
+// the target isn't an error: the attempt is stored with its Error set.
+func (s *ReplayService) Replay(ctx context.Context, wr *models.WebhookRequest, opts ReplayOptions) (*models.ReplayAttempt, error) {
+	var key *signing.Key
+	if opts.Resign {
+		var err error
+		if key, err = s.signingKey(wr.WebhookID); err != nil {
+			return nil, err
+		}
+	}
+	req, err := buildReplay(ctx, wr, opts, key)
+	if err != nil {
+		return nil, err
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultReplayTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	attempt := &models.ReplayAttempt{
+		ID:             utils.GenerateID(),
+		RequestID:      wr.ID,
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: headerMap(req.Header),
+		CreatedAt:      time.Now().UTC(),
+	}
+	if req.Host != "" {
+		attempt.RequestHeaders["Host"] = req.Host
+	}
+	if req.GetBody != nil {
+		body, _ := req.GetBody()
+		b, _ := io.ReadAll(body)
+		attempt.RequestBody = string(b)
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if resp != nil {
+		attempt.StatusCode = resp.StatusCode
+		attempt.ResponseHeaders = headerMap(resp.Header)
+		attempt.ResponseBody = string(resp.Body)
+		attempt.ResponseTruncated = resp.Truncated
+	}
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = replayError(err, timeout)
+	}
+
+	if err := s.repo.Insert(attempt); err != nil {
+		return nil, storeError("replay attempt", err)
+	}
+	return attempt, nil
+}
+
+// signingKey returns the signing scheme and secret of a webhook, or nil when
+// it has none.
+func (s *ReplayService) signingKey(webhookID string) (*signing.Key, error) {
+	w, err := s.webhooks.Get(webhookID)
+	if err != nil {
+		return nil, storeError("webhook", err)
+	}
+	if w.SigningScheme == "" {
+		return nil, nil
+	}
+	return &signing.Key{Scheme: w.SigningScheme, Secret: w.SigningSecret}, nil
+}
+
+// List returns the attempts of a request, newest first.
+func (s *ReplayService) List(requestID string) ([]models.ReplayAttempt, error) {
+	return s.repo.ListByRequest(requestID)
+}
+
+// Get retrieves one attempt by ID.
+func (s *ReplayService) Get(id string) (*models.ReplayAttempt, error) {
+	a, err := s.repo.GetByID(id)
+	return a, storeError("replay attempt", err)
+}
+
+// buildReplay validates opts and builds the request that replays wr. With
+// opts.Resign, key signs the request as it will be sent.
+func buildReplay(ctx context.Context, wr *models.WebhookRequest, opts ReplayOptions, key *signing.Key) (*http.Request, error) {
+	verr := &ValidationError{}
+	if opts.Method != "" && !validHeaderName(opts.Method) {
+		verr.add("method", "is not a valid HTTP method")
+	}
+	for name, value := range opts.Headers {
+		field := "headers." + name
+		switch {
+		case !validHeaderName(name):
+			verr.add(field, "is not a valid header name")
+		case strings.ContainsAny(value, "\r\n\x00"):
+			verr.add(field, "value must not contain line breaks")
+		}
+	}
+	if opts.Timeout < 0 || opts.Timeout > MaxReplayTimeout {
+		verr.add("timeout_ms", "must be between 0 and %d", MaxReplayTimeout.Milliseconds())
+	}
+	if opts.Resign && key == nil {
+		verr.add("resign", "the webhook has no signing scheme and secret")
+	}
+	sr, err := snippet.New(wr, opts.Target)
+	if err == nil && !strings.HasPrefix(sr.URL, "http://") && !strings.HasPrefix(sr.URL, "https://") {
+		err = errors.New("must be an http or https URL")
+	}
+	if err != nil {
+		verr.add("target", "%s", err.Error())
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+
+	u, _ := url.Parse(sr.URL)
+	q := u.Query()
+	for _, k := range opts.RemoveQuery {
+		q.Del(k)
+	}
+	for k, v := range opts.Query {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+
+	method := strings.ToUpper(wr.Method)
+	if opts.Method != "" {
+		method = strings.ToUpper(opts.Method)
+	}
+	body := sr.Body
+	if opts.Body != nil {
+		body = *opts.Body
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, newError(ErrValidation, "can't build the request: "+err.Error(), err)
+	}
+	if body == "" {
+		req.Body, req.GetBody, req.ContentLength = http.NoBody, nil, 0
+	}
+
+	removed := map[string]bool{}
+	for _, name := range opts.RemoveHeaders {
+		removed[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, h := range sr.Headers {
+		if !removed[http.CanonicalHeaderKey(h.Name)] {
+			req.Header.Set(h.Name, h.Value)
+		}
+	}
+	for name, value := range opts.Headers {
+		if http.CanonicalHeaderKey(name) == "Host" {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	if opts.Resign {
+		if err := key.Sign(req.Header, []byte(body), time.Now()); err != nil {
+			return nil, newError(ErrValidation, err.Error(), err)
+		}
+	}
+	return req, nil
+}
+
+// headerMap flattens h into one comma-separated value per name.
+func headerMap(h http.Header) datatypes.JSONMap {
+	m := make(datatypes.JSONMap, len(h))
+	for k, v := range h {
+		m[k] = strings.Join(v, ", ")
+	}
+	return m
+}
+
+// replayError describes why a replay got no response.
+func replayError(err error, timeout time.Duration) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("no response within %s", timeout)
+	}
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		err = ue.Err
+	}
+	return err.Error()
+}
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxReplayJobRequests caps how many requests one job replays.
+	MaxReplayJobRequests = 10000
+	// MaxReplayJobConcurrency caps ReplayJobOptions.Concurrency.
+	MaxReplayJobConcurrency = 50
+	// MaxReplayJobRate caps ReplayJobOptions.Rate, in requests per second.
+	MaxReplayJobRate = 1000
+	// MaxReplayJobSpeed caps ReplayJobOptions.Speed.
+	MaxReplayJobSpeed = 100
+	// MaxActiveReplayJobs is how many jobs of one webhook may run at once.
+	MaxActiveReplayJobs = 3
+)
+
+// ReplayJobOptions says which requests a job replays, where, and how fast.
+type ReplayJobOptions struct {
+	Target        string                   // absolute http(s) URL
+	Filter        repository.RequestFilter // all of the webhook's requests when empty
+	Mode          string                   // models.ReplayModeTiming by default
+	Speed         float64                  // timing mode: 2 halves the gaps; 1 by default
+	Rate          float64                  // rate mode: requests per second, 0 for no limit
+	Concurrency   int                      // 10 in timing mode and 1 in rate mode by default
+	StopOnFailure bool                     // stop at the first failed request
+	Resign        bool                     // re-sign each request with the webhook's signing secret
+	Timeout       time.Duration            // per request, DefaultReplayTimeout by default
+}
+
+// ReplayJobService replays batches of requests in the background. Jobs run in
+// this process: after a restart, jobs that were running are marked failed.
+type ReplayJobService struct {
+	jobs     repository.ReplayJobRepository
+	requests repository.WebhookRequestRepository
+	replays  *ReplayService
+	logger   *log.Logger
+
+	mu     sync.Mutex
+	active map[string]*jobRun // by job ID
+}
+
+// NewReplayJobService constructs a ReplayJobService that sends through replays.
+func NewReplayJobService(jobs repository.ReplayJobRepository, requests repository.WebhookRequestRepository, replays *ReplayService, logger *log.Logger) *ReplayJobService {
+	return &ReplayJobService{jobs: jobs, requests: requests, replays: replays, logger: logger, active: map[string]*jobRun{}}
+}
+
+// FailInterrupted marks jobs left running by an earlier process as failed.
+func (s *ReplayJobService) FailInterrupted() (int64, error) {
+	return s.jobs.FailUnfinished("the server restarted before the job finished", time.Now().UTC())
+}
+
+// Start selects the webhook's requests matching opts.Filter, oldest first,
+// and replays them in the background. The returned job is running.
+func (s *ReplayJobService) Start(webhookID string, opts ReplayJobOptions) (*models.ReplayJob, error) {
+	opts, err := validateJob(opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Resign {
+		key, err := s.replays.signingKey(webhookID)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			verr := &ValidationError{}
+			verr.add("resign", "the webhook has no signing scheme and secret")
+			return nil, verr
+		}
+	}
+
+	var items []models.ReplayJobItem
+	var first time.Time
+	errTooMany := errors.New("too many requests")
+	err = s.requests.EachByWebhook(webhookID, opts.Filter, 500, func(batch []models.WebhookRequest) error {
+		for _, wr := range batch {
+			if len(items) == MaxReplayJobRequests {
+				return errTooMany
+			}
+			if len(items) == 0 {
+				first = wr.ReceivedAt
+			}
+			items = append(items, models.ReplayJobItem{
+				Seq:       len(items),
+				RequestID: wr.ID,
+				OffsetMs:  wr.ReceivedAt.Sub(first).Milliseconds(),
+				Status:    models.ReplayItemPending,
+			})
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errTooMany):
+		return nil, newError(ErrValidation, fmt.Sprintf("a job can replay at most %d requests; narrow the selection", MaxReplayJobRequests), nil)
+	case err != nil:
+		return nil, err
+	case len(items) == 0:
+		return nil, newError(ErrValidation, "no requests match the selection", nil)
+	}
+
+	job := &models.ReplayJob{
+		ID:            utils.GenerateID(),
+		WebhookID:     webhookID,
+		Target:        opts.Target,
+		Mode:          opts.Mode,
+		Speed:         opts.Speed,
+		Rate:          opts.Rate,
+		Concurrency:   opts.Concurrency,
+		StopOnFailure: opts.StopOnFailure,
+		Resign:        opts.Resign,
+		TimeoutMs:     opts.Timeout.Milliseconds(),
+		Status:        models.ReplayJobRunning,
+		Total:         len(items),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, run := range s.active {
+		if run.webhookID == job.WebhookID {
+			n++
+		}
+	}
+	if n >= MaxActiveReplayJobs {
+		return nil, newError(ErrConflict, fmt.Sprintf("a webhook can run at most %d replay jobs at once", MaxActiveReplayJobs), nil)
+	}
+	if err := s.jobs.Create(job, items); err != nil {
+		return nil, storeError("replay job", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &jobRun{webhookID: webhookID, job: *job, ctx: ctx, cancel: cancel}
+	s.active[job.ID] = run
+	go s.run(run, items)
+	return job, nil
+}
+
+// List returns the jobs of a webhook, newest first.
+func (s *ReplayJobService) List(webhookID string) ([]models.ReplayJob, error) {
+	return s.jobs.ListByWebhook(webhookID)
+}
+
+// Get retrieves one job by ID.
+func (s *ReplayJobService) Get(id string) (*models.ReplayJob, error) {
+	job, err := s.jobs.GetByID(id)
+	return job, storeError("replay job", err)
+}
+
+// Items returns the requests of a job, in order, with their results.
+func (s *ReplayJobService) Items(jobID string) ([]models.ReplayJobItem, error) {
+	return s.jobs.ListItems(jobID)
+}
+
+// Pause stops a running job from sending more requests until it is resumed.
+// Requests already in flight complete.
+func (s *ReplayJobService) Pause(id string) (*models.ReplayJob, error) {
+	return s.control(id, func(r *jobRun) {
+		if r.resume == nil {
+			r.resume = make(chan struct{})
+			r.pausedAt = time.Now()
+			r.job.Status = models.ReplayJobPaused
+		}
+	})
+}
+
+// Resume lets a paused job continue. In timing mode the remaining requests
+// keep their gaps, shifted by the time spent paused.
+func (s *ReplayJobService) Resume(id string) (*models.ReplayJob, error) {
+	return s.control(id, func(r *jobRun) {
+		if r.resume != nil {
+			close(r.resume)
+			r.resume = nil
+			r.paused += time.Since(r.pausedAt)
+			r.job.Status = models.ReplayJobRunning
+		}
+	})
+}
+
+// Cancel ends a running or paused job. Requests already in flight complete.
+func (s *ReplayJobService) Cancel(id string) (*models.ReplayJob, error) {
+	return s.control(id, func(r *jobRun) {
+		r.end(models.ReplayJobCancelled, "")
+	})
+}
+
+// control applies fn to the active job id and saves its new state.
+func (s *ReplayJobService) control(id string, fn func(r *jobRun)) (*models.ReplayJob, error) {
+	s.mu.Lock()
+	run, ok := s.active[id]
+	s.mu.Unlock()
+	if ok {
+		run.mu.Lock()
+		defer run.mu.Unlock()
+	}
+	if !ok || run.done {
+		job, err := s.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newError(ErrConflict, "the replay job has already "+job.Status, nil)
+	}
+
+	fn(run)
+	job := run.job
+	if err := s.jobs.Update(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// jobRun is the live state of an active job.
+type jobRun struct {
+	webhookID string
+	ctx       context.Context // done when the job ends early
+	cancel    context.CancelFunc
+
+	mu       sync.Mutex
+	job      models.ReplayJob // latest state, saved after every change
+	resume   chan struct{}    // closed on resume; nil unless paused
+	pausedAt time.Time
+	paused   time.Duration // time spent paused before the current pause
+	ended    bool          // cancelled or stopped; the final status is set
+	done     bool          // the job has finished and can't be changed
+}
+
+// end stops the job from sending more requests with the given final status.
+// Callers must hold r.mu.
+func (r *jobRun) end(status, reason string) {
+	if r.ended {
+		return
+	}
+	r.ended = true
+	r.job.Status, r.job.Error = status, reason
+	r.cancel()
+}
+
+// wait blocks while the job is paused and returns how long it has been
+// paused in all, which shifts the schedule.
+func (r *jobRun) wait() (time.Duration, error) {
+	r.mu.Lock()
+	resume := r.resume
+	r.mu.Unlock()
+	if resume != nil {
+		select {
+		case <-resume:
+		case <-r.ctx.Done():
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused, r.ctx.Err()
+}
+
+// run sends the items on schedule and records the outcome of the job.
+func (s *ReplayJobService) run(r *jobRun, items []models.ReplayJobItem) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.active, r.job.ID)
+		s.mu.Unlock()
+	}()
+
+	r.mu.Lock()
+	job := r.job
+	r.mu.Unlock()
+	slots := make(chan struct{}, job.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+
+dispatch:
+	for i := range items {
+		due := start.Add(jobDelay(&job, &items[i]))
+		// sleep until due, moving due back by any pause in the meantime
+		for {
+			paused, err := r.wait()
+			if err != nil {
+				break dispatch
+			}
+			delay := time.Until(due.Add(paused))
+			if delay <= 0 {
+				break
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-r.ctx.Done():
+				timer.Stop()
+				break dispatch
+			}
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-r.ctx.Done():
+			break dispatch
+		}
+		if r.ctx.Err() != nil {
+			<-slots
+			break
+		}
+		wg.Add(1)
+		go func(item models.ReplayJobItem) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			s.send(r, &job, item)
+		}(items[i])
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.ended {
+		r.job.Status = models.ReplayJobCompleted
+	}
+	r.done = true
+	r.cancel()
+	now := time.Now().UTC()
+	r.job.FinishedAt = &now
+	if err := s.jobs.Update(&r.job); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("saving replay job %s failed: %v", r.job.ID, err)
+	}
+}
+
+// send replays one item as job says and records its result.
+func (s *ReplayJobService) send(r *jobRun, job *models.ReplayJob, item models.ReplayJobItem) {
+	now := time.Now().UTC()
+	item.SentAt = &now
+
+	wr, err := s.requests.GetByID(item.RequestID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		item.Status, item.Error = models.ReplayItemSkipped, "the request was deleted"
+	case err != nil:
+		item.Status, item.Error = models.ReplayItemFailed, err.Error()
+	default:
+		timeout := time.Duration(job.TimeoutMs) * time.Millisecond
+		attempt, err := s.replays.Replay(context.Background(), wr, ReplayOptions{Target: job.Target, Resign: job.Resign, Timeout: timeout})
+		if err != nil {
+			item.Status, item.Error = models.ReplayItemFailed, err.Error()
+			break
+		}
+		item.AttemptID, item.StatusCode, item.DurationMs, item.Error = attempt.ID, attempt.StatusCode, attempt.DurationMs, attempt.Error
+		item.Status = models.ReplayItemFailed
+		if attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+			item.Status = models.ReplayItemSucceeded
+		}
+	}
+	if err := s.jobs.UpdateItem(&item); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("saving item %d of replay job %s failed: %v", item.Seq, item.JobID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch item.Status {
+	case models.ReplayItemSucceeded:
+		r.job.Succeeded++
+	case models.ReplayItemFailed:
+		r.job.Failed++
+		if r.job.StopOnFailure {
+			reason := fmt.Sprintf("request %s got %d", item.RequestID, item.StatusCode)
+			if item.Error != "" {
+				reason = fmt.Sprintf("request %s failed: %s", item.RequestID, item.Error)
+			}
+			r.end(models.ReplayJobStopped, reason)
+		}
+	default:
+		r.job.Skipped++
+	}
+	if err := s.jobs.Update(&r.job); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Printf("saving replay job %s failed: %v", r.job.ID, err)
+	}
+}
+
+// jobDelay is when an item is due, counted from the start of the job.
+func jobDelay(job *models.ReplayJob, item *models.ReplayJobItem) time.Duration {
+	if job.Mode == models.ReplayModeTiming {
+		return time.Duration(float64(item.OffsetMs) / job.Speed * float64(time.Millisecond))
+	}
+	if job.Rate == 0 {
+		return 0
+	}
+	return time.Duration(float64(item.Seq) / job.Rate * float64(time.Second))
+}
+
+// validateJob checks opts and fills in defaults.
+func validateJob(opts ReplayJobOptions) (ReplayJobOptions, error) {
+	verr := &ValidationError{}
+	if u, err := url.Parse(opts.Target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.add("target", "must be an http or https URL")
+	}
+	switch opts.Mode {
+	case "", models.ReplayModeTiming:
+		opts.Mode = models.ReplayModeTiming
+		if opts.Speed == 0 {
+			opts.Speed = 1
+		}
+		if opts.Concurrency == 0 {
+			opts.Concurrency = 10
+		}
+	case models.ReplayModeRate:
+		if opts.Concurrency == 0 {
+			opts.Concurrency = 1
+		}
+	default:
+		verr.add("mode", `must be "timing" or "rate"`)
+	}
+	if opts.Speed < 0 || opts.Speed > MaxReplayJobSpeed {
+		verr.add("speed", "must be between 0 and %d", MaxReplayJobSpeed)
+	}
+	if opts.Rate < 0 || opts.Rate > MaxReplayJobRate {
+		verr.add("rate", "must be between 0 and %d", MaxReplayJobRate)
+	}
+	if opts.Concurrency < 0 || opts.Concurrency > MaxReplayJobConcurrency {
+		verr.add("concurrency", "must be between 1 and %d", MaxReplayJobConcurrency)
+	}
+	if opts.Timeout < 0 || opts.Timeout > MaxReplayTimeout {
+		verr.add("timeout_ms", "must be between 0 and %d", MaxReplayTimeout.Milliseconds())
+	}
+	if !opts.Filter.Since.IsZero() && !opts.Filter.Until.IsZero() && !opts.Filter.Since.Before(opts.Filter.Until) {
+		verr.add("until", "must be after since")
+	}
+	if len(verr.Fields) > 0 {
+		return opts, verr
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultReplayTimeout
+	}
+	return opts, nil
+}
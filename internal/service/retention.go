@@ -0,0 +1,131 @@
+package service
+
+import (
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/utils"
+)
+
+// Usage describes how much storage a user consumes against their quota.
+type Usage struct {
+	Used  int64
+	Limit int64 // 0 means unlimited
+}
+
+// Percent returns the used share of the quota, capped at 100.
+func (u Usage) Percent() int {
+	if u.Limit <= 0 {
+		return 0
+	}
+	p := int(u.Used * 100 / u.Limit)
+	if p > 100 {
+		p = 100
+	}
+	return p
+}
+
+// HumanUsed returns the used storage in human-readable form.
+func (u Usage) HumanUsed() string {
+	return utils.FormatBytes(u.Used)
+}
+
+// HumanLimit returns the quota in human-readable form.
+func (u Usage) HumanLimit() string {
+	if u.Limit <= 0 {
+		return "unlimited"
+	}
+	return utils.FormatBytes(u.Limit)
+}
+
+// RetentionService enforces per-webhook retention and per-user storage quotas.
+type RetentionService struct {
+	webhooks     repository.WebhookRepository
+	requests     repository.WebhookRequestRepository
+	users        repository.UserRepository
+	defaultQuota int64
+}
+
+// NewRetentionService constructs a RetentionService. defaultQuota is in bytes
+// and applies to users without their own quota; 0 disables quotas.
+func NewRetentionService(
+	webhooks repository.WebhookRepository,
+	requests repository.WebhookRequestRepository,
+	users repository.UserRepository,
+	defaultQuota int64,
+) *RetentionService {
+	return &RetentionService{
+		webhooks:     webhooks,
+		requests:     requests,
+		users:        users,
+		defaultQuota: defaultQuota,
+	}
+}
+
+// Enforce removes unpinned requests that fall outside the webhook's retention policy.
+func (s *RetentionService) Enforce(w *models.Webhook) (int64, error) {
+	var removed int64
+	if w.RetentionCount > 0 {
+		n, err := s.requests.PruneKeepLast(w.ID, w.RetentionCount)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	if w.RetentionDays > 0 {
+		cutoff := time.Now().UTC().AddDate(0, 0, -int(w.RetentionDays))
+		n, err := s.requests.PruneBefore(w.ID, cutoff)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	return removed, nil
+}
+
+// EnforceAll applies retention to every webhook that declares a policy.
+func (s *RetentionService) EnforceAll() (int64, error) {
+	webhooks, err := s.webhooks.GetAllWithRetention()
+	if err != nil {
+		return 0, err
+	}
+	var removed int64
+	for i := range webhooks {
+		n, err := s.Enforce(&webhooks[i])
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	return removed, nil
+}
+
+// Usage returns the storage consumed by a user and the quota that applies to them.
+func (s *RetentionService) Usage(userID uint) (Usage, error) {
+	used, err := s.requests.SizeByUser(userID)
+	if err != nil {
+		return Usage{}, err
+	}
+	limit := s.defaultQuota
+	if user, err := s.users.GetByID(userID); err == nil && user.StorageQuota > 0 {
+		limit = user.StorageQuota
+	}
+	return Usage{Used: used, Limit: limit}, nil
+}
+
+// CheckQuota returns ErrQuotaExceeded if storing size more bytes under the
+// webhook would exceed its owner's quota. Guest webhooks are not subject to
+// quotas; they are removed by the public cleanup instead.
+func (s *RetentionService) CheckQuota(w *models.Webhook, size int64) (Usage, error) {
+	if w.UserID == 0 {
+		return Usage{}, nil
+	}
+	usage, err := s.Usage(uint(w.UserID))
+	if err != nil {
+		return usage, err
+	}
+	if usage.Limit > 0 && usage.Used+size > usage.Limit {
+		return usage, ErrQuotaExceeded
+	}
+	return usage, nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/script"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ScriptService answers requests with the Starlark scripts of webhooks and
+// keeps each webhook's script state. Runs of one webhook's script take turns,
+// so that each sees the state the previous one left.
+type ScriptService struct {
+	states repository.ScriptStateRepository
+	locks  [64]sync.Mutex // by hash of the webhook ID
+}
+
+// NewScriptService constructs a ScriptService keeping state in states.
+func NewScriptService(states repository.ScriptStateRepository) *ScriptService {
+	return &ScriptService{states: states}
+}
+
+// Run runs the script of wh for wr and records what it printed, and its
+// error, in wr.ScriptLog. The state is saved only when the script succeeds.
+func (s *ScriptService) Run(ctx context.Context, wh *models.Webhook, wr *models.WebhookRequest) (*script.Response, error) {
+	mu := s.lock(wh.ID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	state, err := s.State(wh.ID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := script.Run(ctx, wh.Script, scriptRequest(wr), state)
+	wr.ScriptLog = res.Log
+	if err != nil {
+		wr.ScriptLog += "error: " + err.Error() + "\n"
+		return nil, err
+	}
+	for name, value := range res.Response.Headers {
+		// the same headers a webhook can't be configured with
+		if !validHeaderName(name) || reservedResponseHeaders[http.CanonicalHeaderKey(name)] || strings.ContainsAny(value, "\r\n\x00") {
+			delete(res.Response.Headers, name)
+			wr.ScriptLog += fmt.Sprintf("dropped the header %q: it can't be set by a script\n", name)
+		}
+	}
+	if res.Changed {
+		if err := s.states.Save(&models.ScriptState{WebhookID: wh.ID, Data: res.State, UpdatedAt: time.Now().UTC()}); err != nil {
+			return nil, storeError("script state", err)
+		}
+	}
+	return &res.Response, nil
+}
+
+// State returns the script state of a webhook, empty when it has none.
+func (s *ScriptService) State(webhookID string) (datatypes.JSONMap, error) {
+	st, err := s.states.Get(webhookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return datatypes.JSONMap{}, nil
+	}
+	if err != nil {
+		return nil, storeError("script state", err)
+	}
+	if st.Data == nil {
+		return datatypes.JSONMap{}, nil
+	}
+	return st.Data, nil
+}
+
+// ResetState forgets the script state of a webhook.
+func (s *ScriptService) ResetState(webhookID string) error {
+	mu := s.lock(webhookID)
+	mu.Lock()
+	defer mu.Unlock()
+	return storeError("script state", s.states.Delete(webhookID))
+}
+
+func (s *ScriptService) lock(webhookID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(webhookID))
+	return &s.locks[h.Sum32()%uint32(len(s.locks))]
+}
+
+// scriptRequest is the request a script sees: headers and query values as
+// they were received, joined with commas.
+func scriptRequest(wr *models.WebhookRequest) script.Request {
+	req := script.Request{
+		Method:  wr.Method,
+		Headers: map[string]string{},
+		Query:   map[string]string{},
+		Body:    wr.Body,
+	}
+	for k, v := range wr.Headers {
+		if s, ok := v.(string); ok {
+			req.Headers[k] = s
+		}
+	}
+	for k, v := range wr.Query {
+		if s, ok := v.(string); ok {
+			req.Query[k] = s
+		}
+	}
+	return req
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"webhook-tester/internal/events"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/signing"
+	"webhook-tester/internal/utils"
+
+	"gorm.io/datatypes"
+)
+
+// SendOptions describes an event to send from a webhook. Fields left empty
+// come from the template, if any.
+type SendOptions struct {
+	Target   string            // absolute http(s) URL
+	Template string            // name of an events.Template
+	Method   string            // POST without a template
+	Headers  map[string]string // set over the template's headers
+	Body     *string           // replaces the template's body
+	Sign     bool              // add signature headers with the webhook's signing secret
+	Timeout  time.Duration     // defaults to DefaultReplayTimeout
+}
+
+// SenderService sends events to the user's own endpoints. Each event is
+// stored as a request of the webhook it's sent from, with Source
+// models.SourceSent, and the delivery as a replay attempt of that request.
+type SenderService struct {
+	requests  repository.WebhookRequestRepository
+	replays   *ReplayService
+	retention *RetentionService
+	logger    *log.Logger
+}
+
+// NewSenderService constructs a SenderService that delivers through replays.
+func NewSenderService(requests repository.WebhookRequestRepository, replays *ReplayService, retention *RetentionService, logger *log.Logger) *SenderService {
+	return &SenderService{requests: requests, replays: replays, retention: retention, logger: logger}
+}
+
+// Send builds the event described by opts, stores it under wh and delivers
+// it. Failing to reach the target isn't an error: the attempt is stored
+// with its Error set.
+func (s *SenderService) Send(ctx context.Context, wh *models.Webhook, opts SendOptions) (*models.WebhookRequest, *models.ReplayAttempt, error) {
+	wr, err := s.record(wh, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	attempt, err := s.replays.Replay(ctx, wr, ReplayOptions{Target: opts.Target, Timeout: opts.Timeout})
+	if err != nil {
+		return nil, nil, err
+	}
+	return wr, attempt, nil
+}
+
+// record builds the event described by opts and stores it under wh.
+func (s *SenderService) record(wh *models.Webhook, opts SendOptions) (*models.WebhookRequest, error) {
+	now := time.Now().UTC()
+	ev, err := buildEvent(wh, opts, now)
+	if err != nil {
+		return nil, err
+	}
+
+	wr := eventRequest(wh, ev, now)
+	if _, err := s.retention.CheckQuota(wh, wr.Size); err != nil {
+		return nil, err
+	}
+	if err := s.requests.Insert(wr); err != nil {
+		return nil, storeError("request", err)
+	}
+	if wh.RetentionCount > 0 {
+		if _, err := s.retention.Enforce(wh); err != nil {
+			s.logger.Printf("error applying retention: %s", err)
+		}
+	}
+	return wr, nil
+}
+
+// eventRequest is ev as a new request of wh, sent at now.
+func eventRequest(wh *models.Webhook, ev events.Event, now time.Time) *models.WebhookRequest {
+	headers := datatypes.JSONMap{}
+	for k, v := range ev.Headers {
+		headers[k] = v
+	}
+	wr := &models.WebhookRequest{
+		ID:         utils.GenerateID(),
+		WebhookID:  wh.ID,
+		Method:     ev.Method,
+		Headers:    headers,
+		Query:      datatypes.JSONMap{},
+		Body:       ev.Body,
+		Source:     models.SourceSent,
+		ReceivedAt: now,
+	}
+	wr.Size = wr.ComputeSize()
+	return wr
+}
+
+// buildEvent validates opts and returns the event to send, signed when asked.
+func buildEvent(wh *models.Webhook, opts SendOptions, now time.Time) (events.Event, error) {
+	verr := &ValidationError{}
+	if u, err := url.Parse(opts.Target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.add("target", "must be an http or https URL")
+	}
+	ev := events.Event{Method: http.MethodPost, Headers: map[string]string{}}
+	if opts.Template != "" {
+		t, ok := events.Lookup(opts.Template)
+		if !ok {
+			verr.add("template", "is not a known event template")
+		} else {
+			var err error
+			if ev, err = t.Render(now); err != nil {
+				return ev, err
+			}
+		}
+	}
+	if opts.Method != "" {
+		if !validHeaderName(opts.Method) {
+			verr.add("method", "is not a valid HTTP method")
+		}
+		ev.Method = strings.ToUpper(opts.Method)
+	}
+	for name, value := range opts.Headers {
+		field := "headers." + name
+		switch {
+		case !validHeaderName(name):
+			verr.add(field, "is not a valid header name")
+		case strings.ContainsAny(value, "\r\n\x00"):
+			verr.add(field, "value must not contain line breaks")
+		}
+		setHeader(ev.Headers, name, value)
+	}
+	if opts.Body != nil {
+		ev.Body = *opts.Body
+	}
+	if opts.Timeout < 0 || opts.Timeout > MaxReplayTimeout {
+		verr.add("timeout_ms", "must be between 0 and %d", MaxReplayTimeout.Milliseconds())
+	}
+	if opts.Sign && wh.SigningScheme == "" {
+		verr.add("sign", "the webhook has no signing scheme and secret")
+	}
+	if len(verr.Fields) > 0 {
+		return ev, verr
+	}
+
+	if opts.Sign {
+		h := http.Header{}
+		for k, v := range ev.Headers {
+			h.Set(k, v)
+		}
+		key := signing.Key{Scheme: wh.SigningScheme, Secret: wh.SigningSecret}
+		if err := key.Sign(h, []byte(ev.Body), now); err != nil {
+			return ev, err
+		}
+		// add the signature headers, keeping the template's spelling of the others
+		for k := range h {
+			if v := h.Get(k); lookupHeader(ev.Headers, k) != v {
+				setHeader(ev.Headers, k, v)
+			}
+		}
+	}
+	return ev, nil
+}
+
+func lookupHeader(h map[string]string, name string) string {
+	for k, v := range h {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// setHeader sets name in h, replacing it in any case.
+func setHeader(h map[string]string, name, value string) {
+	for k := range h {
+		if strings.EqualFold(k, name) {
+			delete(h, k)
+		}
+	}
+	h[name] = value
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"webhook-tester/internal/chaos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/script"
+	"webhook-tester/internal/signing"
+)
+
+const (
+	// MaxResponseDelay caps how long a webhook may hold its response.
+	MaxResponseDelay = 30 * time.Second
+	maxTitleLength   = 255
+	maxSecretLength  = 1024
+)
+
+// reservedResponseHeaders are managed by the server and can't be configured.
+var reservedResponseHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Trailer":           true,
+}
+
+// ValidationError reports invalid input, with one message per field. Field
+// names are the JSON names used by the API and the web forms.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	msgs := make([]string, len(keys))
+	for i, k := range keys {
+		msgs[i] = k + ": " + e.Fields[k]
+	}
+	return "invalid input: " + strings.Join(msgs, "; ")
+}
+
+// Is makes a ValidationError match ErrValidation.
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	if e.Fields == nil {
+		e.Fields = map[string]string{}
+	}
+	e.Fields[field] = fmt.Sprintf(format, args...)
+}
+
+// merge adds the fields of err, if it is a ValidationError, under prefix.
+func (e *ValidationError) merge(prefix string, err error) {
+	var fe *ValidationError
+	if errors.As(err, &fe) {
+		for field, msg := range fe.Fields {
+			e.add(prefix+field, "%s", msg)
+		}
+	}
+}
+
+// ValidateWebhook checks a webhook's response configuration. It returns a
+// *ValidationError, or nil when the webhook is valid.
+func ValidateWebhook(w *models.Webhook) error {
+	verr := &ValidationError{}
+
+	if utf8.RuneCountInString(w.Title) > maxTitleLength {
+		verr.add("title", "must be at most %d characters", maxTitleLength)
+	}
+	if w.ResponseCode < 100 || w.ResponseCode > 599 {
+		verr.add("response_code", "must be a status code between 100 and 599")
+	}
+	if time.Duration(w.ResponseDelay)*time.Millisecond > MaxResponseDelay {
+		verr.add("response_delay", "must be at most %d milliseconds", MaxResponseDelay.Milliseconds())
+	}
+	if w.ContentType != nil && *w.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(*w.ContentType); err != nil {
+			verr.add("content_type", "is not a valid media type")
+		}
+	}
+	for name, value := range w.ResponseHeaders {
+		field := "response_headers." + name
+		s, ok := value.(string)
+		switch {
+		case !validHeaderName(name):
+			verr.add(field, "is not a valid header name")
+		case reservedResponseHeaders[http.CanonicalHeaderKey(name)]:
+			verr.add(field, "is set by the server and can't be configured")
+		case !ok:
+			verr.add(field, "value must be a string")
+		case strings.ContainsAny(s, "\r\n\x00"):
+			verr.add(field, "value must not contain line breaks")
+		}
+	}
+	switch {
+	case w.SigningScheme != "" && !signing.Valid(w.SigningScheme):
+		verr.add("signing_scheme", "must be one of %s", strings.Join(signing.Schemes, ", "))
+	case w.SigningScheme != "" && w.SigningSecret == "":
+		verr.add("signing_secret", "is required with a signing scheme")
+	case w.SigningScheme == "" && w.SigningSecret != "":
+		verr.add("signing_scheme", "is required with a signing secret")
+	case len(w.SigningSecret) > maxSecretLength:
+		verr.add("signing_secret", "must be at most %d bytes", maxSecretLength)
+	}
+	validateFaults(verr, w.Faults.Data())
+	if w.Script != "" {
+		if err := script.Check(w.Script); err != nil {
+			verr.add("script", "%s", err)
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// validateFaults checks the fault injection of a webhook. Field names are
+// prefixed with "faults.".
+func validateFaults(verr *ValidationError, c chaos.Config) {
+	percents := map[string]float64{
+		"error_percent":      c.ErrorPercent,
+		"truncate_percent":   c.TruncatePercent,
+		"drip_percent":       c.DripPercent,
+		"disconnect_percent": c.DisconnectPercent,
+	}
+	for field, p := range percents {
+		if p < 0 || p > 100 {
+			verr.add("faults."+field, "must be between 0 and 100")
+		}
+	}
+	if c.ErrorStatus != 0 && (c.ErrorStatus < 100 || c.ErrorStatus > 599) {
+		verr.add("faults.error_status", "must be a status code between 100 and 599")
+	}
+	if c.FailEvery < 0 || c.FailEvery == 1 {
+		verr.add("faults.fail_every", "must be at least 2, or 0 for none")
+	}
+	maxDelay := int(chaos.MaxDelay.Milliseconds())
+	switch {
+	case c.DelayMinMs < 0 || c.DelayMinMs > maxDelay:
+		verr.add("faults.delay_min_ms", "must be between 0 and %d", maxDelay)
+	case c.DelayMaxMs < 0 || c.DelayMaxMs > maxDelay:
+		verr.add("faults.delay_max_ms", "must be between 0 and %d", maxDelay)
+	case c.DelayMaxMs < c.DelayMinMs:
+		verr.add("faults.delay_max_ms", "must be at least delay_min_ms")
+	}
+	switch c.DelayDistribution {
+	case "", chaos.DelayUniform, chaos.DelayNormal, chaos.DelayExponential:
+	default:
+		verr.add("faults.delay_distribution", "must be one of %s, %s, %s", chaos.DelayUniform, chaos.DelayNormal, chaos.DelayExponential)
+	}
+	if c.RateLimit < 0 {
+		verr.add("faults.rate_limit", "must not be negative")
+	}
+	if c.RateLimitWindowMs < 0 || c.RateLimitWindowMs > int(time.Hour.Milliseconds()) {
+		verr.add("faults.rate_limit_window_ms", "must be between 0 and %d", time.Hour.Milliseconds())
+	}
+	if c.RetryAfter < 0 || c.RetryAfter > int(time.Hour.Seconds()) {
+		verr.add("faults.retry_after", "must be between 0 and %d seconds", int(time.Hour.Seconds()))
+	}
+	if c.DripIntervalMs < 0 || c.DripIntervalMs > 10_000 {
+		verr.add("faults.drip_interval_ms", "must be between 0 and 10000")
+	}
+}
+
+// validHeaderName reports whether name is an RFC 9110 token.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range []byte(name) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
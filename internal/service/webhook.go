@@ -16,20 +16,43 @@ func NewWebhookService(repo repository.WebhookRepository) *WebhookService {
 	return &WebhookService{repo: repo}
 }
 
-// CreateWebhook creates a new webhook record.
+// CreateWebhook validates and creates a new webhook record.
 func (s *WebhookService) CreateWebhook(w *models.Webhook) error {
-	// e.g., generate ID, validate
-	return s.repo.Insert(w)
+	if err := ValidateWebhook(w); err != nil {
+		return err
+	}
+	return storeError("webhook", s.repo.Insert(w))
 }
 
 // GetWebhook retrieves a public webhook by ID.
 func (s *WebhookService) GetWebhook(id string) (*models.Webhook, error) {
-	return s.repo.Get(id)
+	w, err := s.repo.Get(id)
+	return w, storeError("webhook", err)
 }
 
 // GetUserWebhook retrieves a webhook by ID for a specific user.
 func (s *WebhookService) GetUserWebhook(id string, userID uint) (*models.Webhook, error) {
-	return s.repo.GetByUser(id, userID)
+	w, err := s.repo.GetByUser(id, userID)
+	return w, storeError("webhook", err)
+}
+
+// CheckAccess returns ErrForbidden unless userID may manage w. Guest
+// webhooks belong to nobody and are open to everyone who knows their ID.
+func (s *WebhookService) CheckAccess(w *models.Webhook, userID uint) error {
+	if w.UserID != 0 && w.UserID != int(userID) {
+		return newError(ErrForbidden, "webhook belongs to another user", nil)
+	}
+	return nil
+}
+
+// CheckSendAccess is CheckAccess for features that make the server send
+// traffic: load runs, replay jobs, sent events and deliveries. Guest webhooks
+// are open to anyone who knows their address, so these need a signed-in user.
+func (s *WebhookService) CheckSendAccess(w *models.Webhook, userID uint) error {
+	if userID == 0 {
+		return newError(ErrUnauthorized, "sign in to send requests from the server", nil)
+	}
+	return s.CheckAccess(w, userID)
 }
 
 // ListWebhooks lists public or user-specific webhooks.
@@ -40,23 +63,27 @@ func (s *WebhookService) ListWebhooks(userID uint) ([]models.Webhook, error) {
 	return s.repo.GetAllByUser(userID)
 }
 
-// UpdateWebhook updates an existing webhook.
+// UpdateWebhook validates and updates an existing webhook.
 func (s *WebhookService) UpdateWebhook(w *models.Webhook) error {
-	return s.repo.Update(w)
+	if err := ValidateWebhook(w); err != nil {
+		return err
+	}
+	return storeError("webhook", s.repo.Update(w))
 }
 
 func (s *WebhookService) CreateRequest(wr *models.WebhookRequest) error {
-	return s.repo.InsertRequest(wr)
+	return storeError("request", s.repo.InsertRequest(wr))
 }
 
 // DeleteWebhook deletes a webhook and its requests.
 func (s *WebhookService) DeleteWebhook(id string, userID uint) error {
-	return s.repo.Delete(id, userID)
+	return storeError("webhook", s.repo.Delete(id, userID))
 }
 
 // GetWebhookWithRequests fetches a webhook along with its requests.
 func (s *WebhookService) GetWebhookWithRequests(id string) (*models.Webhook, error) {
-	return s.repo.GetWithRequests(id)
+	w, err := s.repo.GetWithRequests(id)
+	return w, storeError("webhook", err)
 }
 
 // CleanPublicWebhooks cleans up old public webhooks.
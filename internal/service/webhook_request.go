@@ -1,8 +1,14 @@
 package service
 
 import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+	"webhook-tester/internal/archive"
 	"webhook-tester/internal/models"
 	"webhook-tester/internal/repository"
+	"webhook-tester/internal/utils"
 )
 
 // WebhookRequestService encapsulates business logic for webhook events.
@@ -22,7 +28,8 @@ func (s *WebhookRequestService) Record(rq *models.WebhookRequest) error {
 
 // Get retrieves a single request by ID.
 func (s *WebhookRequestService) Get(id string) (*models.WebhookRequest, error) {
-	return s.repo.GetByID(id)
+	wr, err := s.repo.GetByID(id)
+	return wr, storeError("request", err)
 }
 
 // List returns recent requests for a webhook.
@@ -30,6 +37,15 @@ func (s *WebhookRequestService) List(webhookID string) ([]models.WebhookRequest,
 	return s.repo.ListByWebhook(webhookID)
 }
 
+// ListPage returns the given 1-based page of requests for a webhook, newest
+// first, along with the total number of requests.
+func (s *WebhookRequestService) ListPage(webhookID string, page, perPage int) ([]models.WebhookRequest, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	return s.repo.ListPageByWebhook(webhookID, (page-1)*perPage, perPage)
+}
+
 // Delete removes a single request.
 func (s *WebhookRequestService) Delete(id string) error {
 	return s.repo.DeleteByID(id)
@@ -39,3 +55,71 @@ func (s *WebhookRequestService) Delete(id string) error {
 func (s *WebhookRequestService) DeleteAll(webhookID string) error {
 	return s.repo.DeleteByWebhook(webhookID)
 }
+
+// SetPinned pins or unpins a request so retention never removes it.
+func (s *WebhookRequestService) SetPinned(id string, pinned bool) error {
+	return storeError("request", s.repo.SetPinned(id, pinned))
+}
+
+// exportBatchSize is how many requests Export loads at a time.
+const exportBatchSize = 500
+
+// Export writes the webhook's requests that match f to aw, oldest first, and
+// returns how many it wrote. It doesn't close aw.
+func (s *WebhookRequestService) Export(aw archive.Writer, webhookID string, f repository.RequestFilter) (int, error) {
+	n := 0
+	err := s.repo.EachByWebhook(webhookID, f, exportBatchSize, func(batch []models.WebhookRequest) error {
+		for i := range batch {
+			if err := aw.Write(&batch[i]); err != nil {
+				return err
+			}
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Import reads every request from ar and stores them under the webhook as
+// imported requests, all or none, returning how many it stored. Requests
+// without a timestamp are dated now. checkQuota is given their total size
+// before anything is stored and can refuse the import.
+func (s *WebhookRequestService) Import(ar archive.Reader, webhookID string, checkQuota func(size int64) error) (int, error) {
+	var (
+		reqs  []models.WebhookRequest
+		total int64
+		now   = time.Now().UTC()
+	)
+	for {
+		wr, err := ar.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return 0, err
+		}
+		if err != nil {
+			return 0, newError(ErrValidation, err.Error(), err)
+		}
+		wr.ID = utils.GenerateID()
+		wr.WebhookID = webhookID
+		wr.Source = models.SourceImport
+		if wr.ReceivedAt.IsZero() {
+			wr.ReceivedAt = now
+		}
+		wr.Size = wr.ComputeSize()
+		total += wr.Size
+		reqs = append(reqs, *wr)
+	}
+	if len(reqs) == 0 {
+		return 0, newError(ErrValidation, "the file contains no requests", nil)
+	}
+	if err := checkQuota(total); err != nil {
+		return 0, err
+	}
+	if err := s.repo.InsertMany(reqs); err != nil {
+		return 0, err
+	}
+	return len(reqs), nil
+}
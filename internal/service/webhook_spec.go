@@ -0,0 +1,324 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"time"
+	"webhook-tester/internal/chaos"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/utils"
+	"webhook-tester/spec"
+
+	"gorm.io/datatypes"
+)
+
+// ApplyOptions control ApplySpec.
+type ApplyOptions struct {
+	// Prune deletes the user's webhooks that the file doesn't mention.
+	Prune bool
+	// DryRun returns the plan without changing anything.
+	DryRun bool
+}
+
+// SpecService exports and applies spec files: a user's webhooks together
+// with their callback actions.
+type SpecService struct {
+	webhooks  *WebhookService
+	callbacks *CallbackService
+}
+
+// NewSpecService constructs a SpecService.
+func NewSpecService(webhooks *WebhookService, callbacks *CallbackService) *SpecService {
+	return &SpecService{webhooks: webhooks, callbacks: callbacks}
+}
+
+// ExportSpec describes the user's webhooks as a spec file. Signing secrets
+// are left out; applying the file keeps them.
+func (s *SpecService) ExportSpec(userID uint) (*spec.File, error) {
+	current, _, err := s.current(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range current {
+		current[i].SigningSecret = ""
+	}
+	return &spec.File{Version: spec.Version, Webhooks: current}, nil
+}
+
+// current returns the user's webhooks both as spec entries, secrets
+// included, and as models.
+func (s *SpecService) current(userID uint) ([]spec.Webhook, []models.Webhook, error) {
+	webhooks, err := s.webhooks.repo.GetAllByUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := make([]spec.Webhook, 0, len(webhooks))
+	for _, w := range webhooks {
+		actions, err := s.callbacks.ListActions(w.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		current = append(current, specWebhook(w, actions))
+	}
+	return current, webhooks, nil
+}
+
+// ApplySpec syncs the user's webhooks to f. The whole file is validated before
+// anything changes, so invalid input leaves the server untouched.
+func (s *SpecService) ApplySpec(userID uint, f *spec.File, opts ApplyOptions) (spec.Plan, error) {
+	if err := f.Validate(); err != nil {
+		return spec.Plan{}, newError(ErrValidation, err.Error(), err)
+	}
+
+	current, existing, err := s.current(userID)
+	if err != nil {
+		return spec.Plan{}, err
+	}
+	plan, err := spec.Diff(current, f.Webhooks, opts.Prune)
+	if err != nil {
+		return spec.Plan{}, newError(ErrValidation, err.Error(), err)
+	}
+
+	// Changes come in file order, deletes last. Updates are validated on top
+	// of the webhook they change, which keeps the secrets the file leaves out.
+	verr := &ValidationError{}
+	for i, c := range plan.Changes {
+		var w models.Webhook
+		switch c.Action {
+		case spec.Create:
+		case spec.Update, spec.Unchanged:
+			for _, e := range existing {
+				if e.ID == c.ID {
+					w = e
+				}
+			}
+		default:
+			continue
+		}
+		applySpecWebhook(c.Webhook, &w)
+		prefix := fmt.Sprintf("webhooks[%d].", i)
+		verr.merge(prefix, ValidateWebhook(&w))
+		if name, ok := spec.SecretRef(c.Webhook.SigningSecret); ok {
+			verr.add(prefix+"signing_secret", "references %s, which the client must resolve", name)
+		}
+		if len(c.Webhook.Callbacks) > MaxCallbackActions {
+			verr.add(prefix+"callbacks", "a webhook can have at most %d callback actions", MaxCallbackActions)
+		}
+		for j, sc := range c.Webhook.Callbacks {
+			a := callbackAction(sc)
+			verr.merge(fmt.Sprintf("%scallbacks[%d].", prefix, j), validateCallbackAction(&w, &a))
+		}
+	}
+	if len(verr.Fields) > 0 {
+		return spec.Plan{}, verr
+	}
+
+	// IDs are global, so an explicit one may belong to another user
+	for _, c := range plan.Changes {
+		if c.Action != spec.Create || c.ID == "" {
+			continue
+		}
+		if _, err := s.webhooks.repo.Get(c.ID); err == nil {
+			return spec.Plan{}, newError(ErrConflict, fmt.Sprintf("webhook id %q is already taken", c.ID), nil)
+		}
+	}
+
+	if opts.DryRun {
+		return plan, nil
+	}
+	for _, c := range plan.Changes {
+		if err := s.applyChange(userID, c); err != nil {
+			return plan, fmt.Errorf("%s %s: %w", c.Action, c.Title, err)
+		}
+	}
+	plan.Applied = true
+	return plan, nil
+}
+
+func (s *SpecService) applyChange(userID uint, c spec.Change) error {
+	now := time.Now().UTC()
+	switch c.Action {
+	case spec.Create:
+		w := models.Webhook{ID: c.ID, UserID: int(userID), CreatedAt: now, UpdatedAt: now}
+		if w.ID == "" {
+			w.ID = utils.GenerateID()
+		}
+		applySpecWebhook(c.Webhook, &w)
+		if err := s.webhooks.CreateWebhook(&w); err != nil {
+			return err
+		}
+		return s.syncCallbacks(&w, c.Webhook.Callbacks)
+	case spec.Update:
+		w, err := s.webhooks.GetUserWebhook(c.ID, userID)
+		if err != nil {
+			return err
+		}
+		applySpecWebhook(c.Webhook, w)
+		w.UpdatedAt = now
+		if err := s.webhooks.UpdateWebhook(w); err != nil {
+			return err
+		}
+		if !slices.Contains(c.Fields, "callbacks") {
+			return nil
+		}
+		return s.syncCallbacks(w, c.Webhook.Callbacks)
+	case spec.Delete:
+		return s.webhooks.DeleteWebhook(c.ID, userID)
+	}
+	return nil
+}
+
+// syncCallbacks makes the callback actions of w match desired, by name.
+// Actions the file doesn't list are deleted first, so the others fit the limit.
+func (s *SpecService) syncCallbacks(w *models.Webhook, desired []spec.Callback) error {
+	actions, err := s.callbacks.ListActions(w.ID)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]models.CallbackAction, len(actions))
+	for _, a := range actions {
+		if _, dup := byName[a.Name]; dup || !containsCallback(desired, a.Name) {
+			if err := s.callbacks.DeleteAction(a.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		byName[a.Name] = a
+	}
+	for _, sc := range desired {
+		a := callbackAction(sc)
+		if existing, ok := byName[a.Name]; ok {
+			a.ID = existing.ID
+			err = s.callbacks.UpdateAction(w, &a)
+		} else {
+			err = s.callbacks.CreateAction(w, &a)
+		}
+		if err != nil {
+			return fmt.Errorf("callback %s: %w", a.Name, err)
+		}
+	}
+	return nil
+}
+
+func containsCallback(callbacks []spec.Callback, name string) bool {
+	for _, c := range callbacks {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func specWebhook(w models.Webhook, actions []models.CallbackAction) spec.Webhook {
+	sw := spec.Webhook{
+		ID:             w.ID,
+		Title:          w.Title,
+		ResponseCode:   w.ResponseCode,
+		ResponseDelay:  w.ResponseDelay,
+		NotifyOnEvent:  w.NotifyOnEvent,
+		RetentionCount: w.RetentionCount,
+		RetentionDays:  w.RetentionDays,
+		SigningScheme:  w.SigningScheme,
+		SigningSecret:  w.SigningSecret,
+		Faults:         spec.Faults(w.Faults.Data()),
+		Script:         w.Script,
+	}
+	if w.ContentType != nil {
+		sw.ContentType = *w.ContentType
+	}
+	if w.Payload != nil {
+		sw.Payload = *w.Payload
+	}
+	if len(w.ResponseHeaders) > 0 {
+		sw.ResponseHeaders = make(map[string]string, len(w.ResponseHeaders))
+		for k, v := range w.ResponseHeaders {
+			sw.ResponseHeaders[k] = fmt.Sprint(v)
+		}
+	}
+	for _, a := range actions {
+		sw.Callbacks = append(sw.Callbacks, specCallback(a))
+	}
+	return sw
+}
+
+// applySpecWebhook writes every configurable field of sw onto w. An empty
+// signing secret keeps the one w has.
+func applySpecWebhook(sw spec.Webhook, w *models.Webhook) {
+	w.Title = sw.Title
+	w.ResponseCode = sw.ResponseCode
+	if w.ResponseCode == 0 {
+		w.ResponseCode = spec.DefaultResponseCode
+	}
+	w.ResponseDelay = sw.ResponseDelay
+	w.ContentType = nil
+	if sw.ContentType != "" {
+		w.ContentType = &sw.ContentType
+	}
+	w.Payload = nil
+	if sw.Payload != "" {
+		w.Payload = &sw.Payload
+	}
+	w.ResponseHeaders = nil
+	if len(sw.ResponseHeaders) > 0 {
+		w.ResponseHeaders = make(datatypes.JSONMap, len(sw.ResponseHeaders))
+		for k, v := range sw.ResponseHeaders {
+			w.ResponseHeaders[k] = v
+		}
+	}
+	w.NotifyOnEvent = sw.NotifyOnEvent
+	w.RetentionCount = sw.RetentionCount
+	w.RetentionDays = sw.RetentionDays
+	w.SigningScheme = sw.SigningScheme
+	switch {
+	case sw.SigningScheme == "":
+		w.SigningSecret = ""
+	case sw.SigningSecret != "":
+		w.SigningSecret = sw.SigningSecret
+	}
+	w.Faults = datatypes.NewJSONType(chaos.Config(sw.Faults))
+	w.Script = sw.Script
+}
+
+func specCallback(a models.CallbackAction) spec.Callback {
+	sc := spec.Callback{
+		Name:      a.Name,
+		DelayMs:   a.DelayMs,
+		Method:    a.Method,
+		URL:       a.URL,
+		URLFrom:   a.URLFrom,
+		Body:      a.Body,
+		Sign:      a.Sign,
+		TimeoutMs: a.TimeoutMs,
+	}
+	if !a.Enabled {
+		sc.Enabled = &a.Enabled
+	}
+	if len(a.Headers) > 0 {
+		sc.Headers = make(map[string]string, len(a.Headers))
+		for k, v := range a.Headers {
+			sc.Headers[k] = fmt.Sprint(v)
+		}
+	}
+	return sc
+}
+
+func callbackAction(sc spec.Callback) models.CallbackAction {
+	a := models.CallbackAction{
+		Name:      sc.Name,
+		Enabled:   sc.Enabled == nil || *sc.Enabled,
+		DelayMs:   sc.DelayMs,
+		Method:    sc.Method,
+		URL:       sc.URL,
+		URLFrom:   sc.URLFrom,
+		Body:      sc.Body,
+		Sign:      sc.Sign,
+		TimeoutMs: sc.TimeoutMs,
+	}
+	if len(sc.Headers) > 0 {
+		a.Headers = make(datatypes.JSONMap, len(sc.Headers))
+		for k, v := range sc.Headers {
+			a.Headers[k] = v
+		}
+	}
+	return a
+}
@@ -0,0 +1,83 @@
+// Package signing computes the signature headers webhook providers send, so
+// that a replayed request is accepted by a consumer that verifies them.
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"time"
+)
+
+// Supported schemes.
+const (
+	// GitHub signs the body with HMAC-SHA256 in X-Hub-Signature-256, and with
+	// HMAC-SHA1 in the legacy X-Hub-Signature.
+	GitHub = "github"
+	// Stripe signs "timestamp.body" with HMAC-SHA256 in Stripe-Signature.
+	Stripe = "stripe"
+	// Shopify signs the body with HMAC-SHA256, base64-encoded in
+	// X-Shopify-Hmac-Sha256.
+	Shopify = "shopify"
+	// Slack signs "v0:timestamp:body" with HMAC-SHA256 in X-Slack-Signature,
+	// sending the timestamp in X-Slack-Request-Timestamp.
+	Slack = "slack"
+)
+
+// Schemes lists the supported schemes.
+var Schemes = []string{GitHub, Stripe, Shopify, Slack}
+
+// Valid reports whether scheme is supported.
+func Valid(scheme string) bool {
+	for _, s := range Schemes {
+		if s == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// Key is a provider scheme and the secret it signs with.
+type Key struct {
+	Scheme string
+	Secret string
+}
+
+// Sign replaces the signature headers in h with ones computed for body at
+// now. Legacy headers are only set when h already has them.
+func (k Key) Sign(h http.Header, body []byte, now time.Time) error {
+	switch k.Scheme {
+	case GitHub:
+		h.Set("X-Hub-Signature-256", "sha256="+k.mac(sha256.New, body))
+		if h.Get("X-Hub-Signature") != "" {
+			h.Set("X-Hub-Signature", "sha1="+k.mac(sha1.New, body))
+		}
+	case Stripe:
+		ts := fmt.Sprint(now.Unix())
+		signed := append([]byte(ts+"."), body...)
+		h.Set("Stripe-Signature", fmt.Sprintf("t=%s,v1=%s", ts, k.mac(sha256.New, signed)))
+	case Shopify:
+		m := hmac.New(sha256.New, []byte(k.Secret))
+		m.Write(body)
+		h.Set("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(m.Sum(nil)))
+	case Slack:
+		ts := fmt.Sprint(now.Unix())
+		signed := append([]byte("v0:"+ts+":"), body...)
+		h.Set("X-Slack-Request-Timestamp", ts)
+		h.Set("X-Slack-Signature", "v0="+k.mac(sha256.New, signed))
+	default:
+		return fmt.Errorf("signing: unknown scheme %q", k.Scheme)
+	}
+	return nil
+}
+
+func (k Key) mac(fn func() hash.Hash, data []byte) string {
+	m := hmac.New(fn, []byte(k.Secret))
+	m.Write(data)
+	return hex.EncodeToString(m.Sum(nil))
+}
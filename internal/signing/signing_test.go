@@ -0,0 +1,57 @@
+package signing_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"webhook-tester/internal/signing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHub(t *testing.T) {
+	// the example from GitHub's "Validating webhook deliveries" guide
+	key := signing.Key{Scheme: signing.GitHub, Secret: "It's a Secret to Everybody"}
+	h := http.Header{"X-Hub-Signature-256": {"sha256=stale"}}
+	require.NoError(t, key.Sign(h, []byte("Hello, World!"), time.Now()))
+	assert.Equal(t, "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17", h.Get("X-Hub-Signature-256"))
+	assert.Empty(t, h.Get("X-Hub-Signature"), "the legacy header is only refreshed when present")
+
+	h.Set("X-Hub-Signature", "sha1=stale")
+	require.NoError(t, key.Sign(h, []byte("Hello, World!"), time.Now()))
+	assert.Equal(t, "sha1=01dc10d0c83e72ed246219cdd91669667fe2ca59", h.Get("X-Hub-Signature"))
+}
+
+func TestStripe(t *testing.T) {
+	key := signing.Key{Scheme: signing.Stripe, Secret: "whsec_test"}
+	h := http.Header{"Stripe-Signature": {"t=1,v1=stale,v0=old"}}
+	require.NoError(t, key.Sign(h, []byte(`{"id":"evt_1"}`), time.Unix(1700000000, 0)))
+	assert.Equal(t, "t=1700000000,v1=c89214b5b5da833daed6f0b8c5bb6bd58cea9022bd80ccc78230f3942d632925", h.Get("Stripe-Signature"))
+}
+
+func TestShopify(t *testing.T) {
+	key := signing.Key{Scheme: signing.Shopify, Secret: "shpss_test"}
+	h := http.Header{}
+	require.NoError(t, key.Sign(h, []byte(`{"id":820982911946154508}`), time.Now()))
+	assert.Equal(t, "3JxXO8Lul52iD5+qb1FHtoXK8vDwPbXpi8eB+yETk5I=", h.Get("X-Shopify-Hmac-Sha256"))
+}
+
+func TestSlack(t *testing.T) {
+	// the example from Slack's "Verifying requests from Slack" guide
+	key := signing.Key{Scheme: signing.Slack, Secret: "8f742231b10e8888abcd99yyyzzz85a5"}
+	body := "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar" +
+		"&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=" +
+		"&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN" +
+		"&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	h := http.Header{}
+	require.NoError(t, key.Sign(h, []byte(body), time.Unix(1531420618, 0)))
+	assert.Equal(t, "1531420618", h.Get("X-Slack-Request-Timestamp"))
+	assert.Equal(t, "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503", h.Get("X-Slack-Signature"))
+}
+
+func TestUnknownScheme(t *testing.T) {
+	assert.Error(t, signing.Key{Scheme: "svix", Secret: "x"}.Sign(http.Header{}, nil, time.Now()))
+	assert.True(t, signing.Valid(signing.Stripe))
+	assert.False(t, signing.Valid(""))
+}
@@ -0,0 +1,35 @@
+package snippet
+
+import (
+	"strconv"
+	"strings"
+)
+
+func golang(r *Request) string {
+	var b strings.Builder
+	b.WriteString("package main\n\nimport (\n\t\"fmt\"\n\t\"io\"\n\t\"net/http\"\n")
+	if r.Body != "" {
+		b.WriteString("\t\"strings\"\n")
+	}
+	b.WriteString(")\n\nfunc main() {\n")
+	body := "http.NoBody"
+	if r.Body != "" {
+		// strconv.Quote escapes invalid UTF-8 byte by byte, so binary bodies survive
+		b.WriteString("\tbody := strings.NewReader(" + strconv.Quote(r.Body) + ")\n")
+		body = "body"
+	}
+	b.WriteString("\treq, err := http.NewRequest(" + strconv.Quote(r.Method) + ", " + strconv.Quote(r.URL) + ", " + body + ")\n")
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	for _, h := range r.Headers {
+		// assign directly so the captured spelling of the name is kept
+		b.WriteString("\treq.Header[" + strconv.Quote(h.Name) + "] = []string{" + strconv.Quote(h.Value) + "}\n")
+	}
+	b.WriteString("\n\tresp, err := http.DefaultClient.Do(req)\n")
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	b.WriteString("\tdefer resp.Body.Close()\n")
+	b.WriteString("\tout, _ := io.ReadAll(resp.Body)\n")
+	b.WriteString("\tfmt.Println(resp.Status)\n")
+	b.WriteString("\tfmt.Println(string(out))\n")
+	b.WriteString("}\n")
+	return b.String()
+}
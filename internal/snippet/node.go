@@ -0,0 +1,43 @@
+package snippet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+func node(r *Request) string {
+	var b strings.Builder
+	b.WriteString("// Node 18 or later, as an ES module (request.mjs)\n")
+	b.WriteString("const response = await fetch(" + jsString(r.URL) + ", {\n")
+	b.WriteString("  method: " + jsString(r.Method) + ",\n")
+	if len(r.Headers) > 0 {
+		b.WriteString("  headers: {\n")
+		for _, h := range r.Headers {
+			b.WriteString("    " + jsString(h.Name) + ": " + jsString(h.Value) + ",\n")
+		}
+		b.WriteString("  },\n")
+	}
+	switch {
+	case r.Body == "":
+	case Binary(r.Body):
+		b.WriteString("  body: Buffer.from(" + jsString(base64.StdEncoding.EncodeToString([]byte(r.Body))) + ", \"base64\"),\n")
+	default:
+		b.WriteString("  body: " + jsString(r.Body) + ",\n")
+	}
+	b.WriteString("});\n")
+	b.WriteString("console.log(response.status);\n")
+	b.WriteString("console.log(await response.text());\n")
+	return b.String()
+}
+
+// jsString quotes s as a JavaScript string literal; JSON strings are valid
+// JavaScript since ES2019.
+func jsString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,61 @@
+package snippet
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+func powershell(r *Request) string {
+	var b strings.Builder
+	contentType := r.header("Content-Type")
+	headers := 0
+	for _, h := range r.Headers {
+		if !strings.EqualFold(h.Name, "Content-Type") {
+			if headers == 0 {
+				b.WriteString("$headers = @{\n")
+			}
+			b.WriteString("    " + psQuote(h.Name) + " = " + psQuote(h.Value) + "\n")
+			headers++
+		}
+	}
+	if headers > 0 {
+		b.WriteString("}\n")
+	}
+	switch {
+	case r.Body == "":
+	case Binary(r.Body) || !isASCII(r.Body):
+		// a string body would be re-encoded, so send the exact bytes
+		b.WriteString("$body = [Convert]::FromBase64String(" + psQuote(base64.StdEncoding.EncodeToString([]byte(r.Body))) + ")\n")
+	default:
+		b.WriteString("$body = " + psQuote(r.Body) + "\n")
+	}
+
+	b.WriteString("\nInvoke-WebRequest -Method " + r.Method + " -Uri " + psQuote(r.URL))
+	if headers > 0 {
+		b.WriteString(" `\n  -Headers $headers")
+	}
+	if contentType != "" {
+		b.WriteString(" `\n  -ContentType " + psQuote(contentType))
+	}
+	if r.Body != "" {
+		b.WriteString(" `\n  -Body $body")
+	}
+	b.WriteString(" `\n  -SkipHeaderValidation\n")
+	return b.String()
+}
+
+// psQuote quotes s as a PowerShell verbatim string, where only single quotes
+// (including the typographic ones PowerShell also accepts) need doubling.
+func psQuote(s string) string {
+	r := strings.NewReplacer("'", "''", "‘", "‘‘", "’", "’’", "‚", "‚‚", "‛", "‛‛")
+	return "'" + r.Replace(s) + "'"
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
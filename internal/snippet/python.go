@@ -0,0 +1,83 @@
+package snippet
+
+import (
+	"fmt"
+	"strings"
+)
+
+func python(r *Request) string {
+	var b strings.Builder
+	b.WriteString("import requests\n\n")
+	b.WriteString("url = " + pyString(r.URL) + "\n")
+	if len(r.Headers) > 0 {
+		b.WriteString("headers = {\n")
+		for _, h := range r.Headers {
+			b.WriteString("    " + pyString(h.Name) + ": " + pyString(h.Value) + ",\n")
+		}
+		b.WriteString("}\n")
+	} else {
+		b.WriteString("headers = {}\n")
+	}
+	data := "None"
+	switch {
+	case r.Body == "":
+	case Binary(r.Body):
+		b.WriteString("data = " + pyBytes(r.Body) + "\n")
+		data = "data"
+	default:
+		b.WriteString("data = " + pyString(r.Body) + "\n")
+		data = `data.encode("utf-8")`
+	}
+	b.WriteString("\nresponse = requests.request(" + pyString(r.Method) + ", url, headers=headers, data=" + data + ")\n")
+	b.WriteString("print(response.status_code)\n")
+	b.WriteString("print(response.text)\n")
+	return b.String()
+}
+
+// pyString quotes s as a Python str literal.
+func pyString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\x%02x`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// pyBytes quotes s as a Python bytes literal.
+func pyBytes(s string) string {
+	var b strings.Builder
+	b.WriteString(`b"`)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' || c == '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= 0x20 && c < 0x7f:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package snippet
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+func curl(r *Request) string {
+	var b strings.Builder
+	if r.Body != "" && Binary(r.Body) {
+		b.WriteString("echo " + shQuote(base64.StdEncoding.EncodeToString([]byte(r.Body))) + " | base64 -d | ")
+	}
+	b.WriteString("curl")
+	if r.Method != "GET" || r.Body != "" {
+		b.WriteString(" -X " + r.Method)
+	}
+	b.WriteString(" " + shQuote(r.URL))
+	for _, h := range r.Headers {
+		if h.Value == "" {
+			// curl drops "Name:" but sends "Name;" as an empty header
+			b.WriteString(" \\\n  -H " + shQuote(h.Name+";"))
+		} else {
+			b.WriteString(" \\\n  -H " + shQuote(h.Name+": "+h.Value))
+		}
+	}
+	switch {
+	case r.Body == "":
+	case Binary(r.Body):
+		b.WriteString(" \\\n  --data-binary @-")
+	default:
+		b.WriteString(" \\\n  --data-raw " + shQuote(r.Body))
+	}
+	return b.String()
+}
+
+func httpie(r *Request) string {
+	var b strings.Builder
+	switch {
+	case r.Body == "":
+		b.WriteString("http --ignore-stdin")
+	case Binary(r.Body):
+		b.WriteString("echo " + shQuote(base64.StdEncoding.EncodeToString([]byte(r.Body))) + " | base64 -d | http")
+	default:
+		b.WriteString("printf '%s' " + shQuote(r.Body) + " | http")
+	}
+	b.WriteString(" " + r.Method + " " + shQuote(r.URL))
+	for _, h := range r.Headers {
+		if h.Value == "" {
+			b.WriteString(" \\\n  " + shQuote(h.Name+";"))
+		} else {
+			b.WriteString(" \\\n  " + shQuote(h.Name+":"+h.Value))
+		}
+	}
+	return b.String()
+}
+
+// shQuote quotes s for POSIX shells. Nothing is special inside single
+// quotes, so only single quotes themselves need escaping.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
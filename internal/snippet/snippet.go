@@ -0,0 +1,142 @@
+// Package snippet renders a captured webhook request as code that sends the
+// same request again: a curl or HTTPie command, or a Go, Python, Node or
+// PowerShell program. Bodies that aren't printable UTF-8 text are embedded
+// as base64 or byte escapes, so every snippet reproduces the exact bytes.
+package snippet
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+	"webhook-tester/internal/models"
+)
+
+// Language is a snippet language.
+type Language string
+
+const (
+	Curl       Language = "curl"
+	HTTPie     Language = "httpie"
+	Go         Language = "go"
+	Python     Language = "python"
+	Node       Language = "node"
+	PowerShell Language = "powershell"
+)
+
+// Languages lists every supported language in display order.
+var Languages = []Language{Curl, HTTPie, Go, Python, Node, PowerShell}
+
+var labels = map[Language]string{
+	Curl:       "curl",
+	HTTPie:     "HTTPie",
+	Go:         "Go net/http",
+	Python:     "Python requests",
+	Node:       "Node fetch",
+	PowerShell: "PowerShell",
+}
+
+// Label is the human-readable name of l.
+func (l Language) Label() string { return labels[l] }
+
+// ParseLanguage returns the Language named s.
+func ParseLanguage(s string) (Language, error) {
+	for _, l := range Languages {
+		if strings.EqualFold(s, string(l)) {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unknown language %q, want one of curl, httpie, go, python, node or powershell", s)
+}
+
+// Header is one request header.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Request is what a snippet sends.
+type Request struct {
+	Method  string
+	URL     string
+	Headers []Header // sorted by name
+	Body    string
+}
+
+// skipHeaders are set by the HTTP client for the new connection, and some
+// clients refuse to send them.
+var skipHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+}
+
+// New builds the Request that reproduces wr against target, whose query is
+// merged with the captured one.
+func New(wr *models.WebhookRequest, target string) (*Request, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("must be an absolute URL such as https://example.com/hooks")
+	}
+	q := u.Query()
+	for k, v := range wr.Query {
+		q.Set(k, fmt.Sprint(v))
+	}
+	u.RawQuery = q.Encode()
+
+	req := &Request{Method: wr.Method, URL: u.String(), Body: wr.Body}
+	for k, v := range wr.Headers {
+		if !skipHeaders[http.CanonicalHeaderKey(k)] {
+			req.Headers = append(req.Headers, Header{Name: k, Value: fmt.Sprint(v)})
+		}
+	}
+	sort.Slice(req.Headers, func(i, j int) bool { return req.Headers[i].Name < req.Headers[j].Name })
+	return req, nil
+}
+
+// Generate renders req in language l.
+func Generate(l Language, req *Request) (string, error) {
+	switch l {
+	case Curl:
+		return curl(req), nil
+	case HTTPie:
+		return httpie(req), nil
+	case Go:
+		return golang(req), nil
+	case Python:
+		return python(req), nil
+	case Node:
+		return node(req), nil
+	case PowerShell:
+		return powershell(req), nil
+	}
+	return "", fmt.Errorf("unknown language %q", l)
+}
+
+// Binary reports whether body can't be embedded as text: it isn't valid
+// UTF-8 or holds control characters other than tab and line breaks.
+func Binary(body string) bool {
+	if !utf8.ValidString(body) {
+		return true
+	}
+	for _, r := range body {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// header returns the value of the named header, ignoring case.
+func (r *Request) header(name string) string {
+	for _, h := range r.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package snippet_test
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/snippet"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func request(t *testing.T, body string) *snippet.Request {
+	wr := &models.WebhookRequest{
+		Method: "POST",
+		Headers: datatypes.JSONMap{
+			"Content-Type":   "application/json",
+			"X-Sig":          "it's",
+			"Host":           "hooks.example.com",
+			"Content-Length": "12",
+		},
+		Query: datatypes.JSONMap{"q": "a b"},
+		Body:  body,
+	}
+	req, err := snippet.New(wr, "http://localhost:8080/hook?x=1")
+	require.NoError(t, err)
+	return req
+}
+
+func TestNew(t *testing.T) {
+	req := request(t, "")
+	assert.Equal(t, "http://localhost:8080/hook?q=a+b&x=1", req.URL)
+	assert.Equal(t, []snippet.Header{{Name: "Content-Type", Value: "application/json"}, {Name: "X-Sig", Value: "it's"}}, req.Headers,
+		"connection headers are dropped")
+
+	_, err := snippet.New(&models.WebhookRequest{Method: "GET"}, "/relative")
+	assert.Error(t, err)
+}
+
+func TestCurl(t *testing.T) {
+	code, err := snippet.Generate(snippet.Curl, request(t, `{"a":"it's"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `curl -X POST 'http://localhost:8080/hook?q=a+b&x=1' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Sig: it'\''s' \
+  --data-raw '{"a":"it'\''s"}'`, code)
+
+	code, err = snippet.Generate(snippet.Curl, request(t, "\x00\xff"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(code, "echo 'AP8=' | base64 -d | curl"), code)
+	assert.True(t, strings.HasSuffix(code, "--data-binary @-"), code)
+}
+
+func TestGoSnippetParses(t *testing.T) {
+	for _, body := range []string{"", `{"ok":true}`, "\x00\xff`"} {
+		code, err := snippet.Generate(snippet.Go, request(t, body))
+		require.NoError(t, err)
+		_, err = parser.ParseFile(token.NewFileSet(), "main.go", code, 0)
+		assert.NoError(t, err, code)
+	}
+}
+
+func TestBinaryBodies(t *testing.T) {
+	assert.False(t, snippet.Binary("héllo\r\n\tworld"))
+	assert.True(t, snippet.Binary("\x00"))
+	assert.True(t, snippet.Binary("\xff"))
+
+	req := request(t, "\x00\x01\xff\"")
+	for l, want := range map[snippet.Language]string{
+		snippet.HTTPie:     "echo 'AAH/Ig==' | base64 -d | http POST",
+		snippet.Python:     `data = b"\x00\x01\xff\""`,
+		snippet.Node:       `body: Buffer.from("AAH/Ig==", "base64")`,
+		snippet.PowerShell: `$body = [Convert]::FromBase64String('AAH/Ig==')`,
+	} {
+		code, err := snippet.Generate(l, req)
+		require.NoError(t, err)
+		assert.Contains(t, code, want, l)
+	}
+}
+
+func TestTextEscaping(t *testing.T) {
+	req := request(t, "line 1\nit's \"quoted\" \\ é")
+	for l, want := range map[snippet.Language]string{
+		snippet.HTTPie:     `printf '%s' 'line 1` + "\n" + `it'\''s "quoted" \ é' | http POST`,
+		snippet.Python:     `data = "line 1\nit's \"quoted\" \\ é"`,
+		snippet.Node:       `body: "line 1\nit's \"quoted\" \\ é"`,
+		snippet.PowerShell: `'X-Sig' = 'it''s'`,
+	} {
+		code, err := snippet.Generate(l, req)
+		require.NoError(t, err)
+		assert.Contains(t, code, want, l)
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	l, err := snippet.ParseLanguage("HTTPie")
+	require.NoError(t, err)
+	assert.Equal(t, snippet.HTTPie, l)
+	assert.Equal(t, "HTTPie", l.Label())
+	_, err = snippet.ParseLanguage("ruby")
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"log"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure GormCallbackRepo implements repository.CallbackRepository
+var _ repository.CallbackRepository = &GormCallbackRepo{}
+
+// GormCallbackRepo is a GORM implementation of CallbackRepository. Actions
+// and callbacks are removed with their webhook, and callbacks with their
+// request, by ON DELETE CASCADE.
+type GormCallbackRepo struct {
+	DB     *gorm.DB
+	logger *log.Logger
+}
+
+// NewGormCallbackRepo constructs a new repository with a logger.
+func NewGormCallbackRepo(db *gorm.DB, logger *log.Logger) *GormCallbackRepo {
+	return &GormCallbackRepo{DB: db, logger: logger}
+}
+
+func (r *GormCallbackRepo) CreateAction(a *models.CallbackAction) error {
+	if err := r.DB.Create(a).Error; err != nil {
+		r.logger.Printf("create callback action failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormCallbackRepo) GetAction(id string) (*models.CallbackAction, error) {
+	var a models.CallbackAction
+	if err := r.DB.First(&a, "id = ?", id).Error; err != nil {
+		r.logger.Printf("get callback action %s failed: %v", id, err)
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *GormCallbackRepo) ListActions(webhookID string) ([]models.CallbackAction, error) {
+	list := []models.CallbackAction{}
+	if err := r.DB.
+		Where("webhook_id = ?", webhookID).
+		Order("created_at, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list callback actions for %s failed: %v", webhookID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormCallbackRepo) UpdateAction(a *models.CallbackAction) error {
+	res := r.DB.Model(&models.CallbackAction{}).Where("id = ?", a.ID).Select("*").Updates(a)
+	if res.Error != nil {
+		r.logger.Printf("update callback action %s failed: %v", a.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormCallbackRepo) DeleteAction(id string) error {
+	res := r.DB.Delete(&models.CallbackAction{}, "id = ?", id)
+	if res.Error != nil {
+		r.logger.Printf("delete callback action %s failed: %v", id, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormCallbackRepo) Create(c *models.Callback) error {
+	if err := r.DB.Create(c).Error; err != nil {
+		r.logger.Printf("create callback failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormCallbackRepo) GetByID(id string) (*models.Callback, error) {
+	var c models.Callback
+	if err := r.DB.First(&c, "id = ?", id).Error; err != nil {
+		r.logger.Printf("get callback %s failed: %v", id, err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *GormCallbackRepo) ListByWebhook(webhookID string, limit int) ([]models.Callback, error) {
+	list := []models.Callback{}
+	if err := r.DB.
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id").
+		Limit(limit).
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list callbacks for %s failed: %v", webhookID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormCallbackRepo) ListByRequest(requestID string) ([]models.Callback, error) {
+	list := []models.Callback{}
+	if err := r.DB.
+		Where("request_id = ?", requestID).
+		Order("created_at DESC, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list callbacks of request %s failed: %v", requestID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormCallbackRepo) Update(c *models.Callback) error {
+	res := r.DB.Model(&models.Callback{}).Where("id = ?", c.ID).Select("*").Updates(c)
+	if res.Error != nil {
+		r.logger.Printf("update callback %s failed: %v", c.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormCallbackRepo) FailUnfinished(reason string) (int64, error) {
+	res := r.DB.Model(&models.Callback{}).
+		Where("status = ?", models.CallbackScheduled).
+		Updates(map[string]any{"status": models.CallbackFailed, "error": reason})
+	if res.Error != nil {
+		r.logger.Printf("fail unfinished callbacks failed: %v", res.Error)
+	}
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"log"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure GormComparisonRepo implements repository.ComparisonRepository
+var _ repository.ComparisonRepository = &GormComparisonRepo{}
+
+// GormComparisonRepo is a GORM implementation of ComparisonRepository.
+// Comparisons are removed with their request, and batches with their webhook,
+// by ON DELETE CASCADE.
+type GormComparisonRepo struct {
+	DB     *gorm.DB
+	logger *log.Logger
+}
+
+// NewGormComparisonRepo constructs a new repository with a logger.
+func NewGormComparisonRepo(db *gorm.DB, logger *log.Logger) *GormComparisonRepo {
+	return &GormComparisonRepo{DB: db, logger: logger}
+}
+
+func (r *GormComparisonRepo) Insert(c *models.Comparison) error {
+	if err := r.DB.Create(c).Error; err != nil {
+		r.logger.Printf("insert comparison failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormComparisonRepo) GetByID(id string) (*models.Comparison, error) {
+	var c models.Comparison
+	if err := r.DB.First(&c, "id = ?", id).Error; err != nil {
+		r.logger.Printf("get comparison %s failed: %v", id, err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *GormComparisonRepo) ListByRequest(requestID string) ([]models.Comparison, error) {
+	list := []models.Comparison{}
+	if err := r.DB.
+		Where("request_id = ?", requestID).
+		Order("created_at DESC, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list comparisons for %s failed: %v", requestID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormComparisonRepo) ListByBatch(batchID string) ([]models.Comparison, error) {
+	list := []models.Comparison{}
+	if err := r.DB.
+		Where("batch_id = ?", batchID).
+		Order("created_at, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list comparisons of batch %s failed: %v", batchID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormComparisonRepo) CreateBatch(b *models.ComparisonBatch) error {
+	if err := r.DB.Create(b).Error; err != nil {
+		r.logger.Printf("create comparison batch failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormComparisonRepo) GetBatch(id string) (*models.ComparisonBatch, error) {
+	var b models.ComparisonBatch
+	if err := r.DB.First(&b, "id = ?", id).Error; err != nil {
+		r.logger.Printf("get comparison batch %s failed: %v", id, err)
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *GormComparisonRepo) ListBatches(webhookID string) ([]models.ComparisonBatch, error) {
+	list := []models.ComparisonBatch{}
+	if err := r.DB.
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list comparison batches for %s failed: %v", webhookID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormComparisonRepo) UpdateBatch(b *models.ComparisonBatch) error {
+	res := r.DB.Model(&models.ComparisonBatch{}).Where("id = ?", b.ID).Select("*").Updates(b)
+	if res.Error != nil {
+		r.logger.Printf("update comparison batch %s failed: %v", b.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormComparisonRepo) FailUnfinishedBatches(reason string, at time.Time) (int64, error) {
+	res := r.DB.Model(&models.ComparisonBatch{}).
+		Where("status = ?", models.ComparisonBatchRunning).
+		Updates(map[string]any{"status": models.ComparisonBatchFailed, "error": reason, "finished_at": at})
+	if res.Error != nil {
+		r.logger.Printf("fail unfinished comparison batches failed: %v", res.Error)
+	}
+	return res.RowsAffected, res.Error
+}
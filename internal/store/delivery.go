@@ -0,0 +1,93 @@
+package store
+
+import (
+	"log"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure GormDeliveryRepo implements repository.DeliveryRepository
+var _ repository.DeliveryRepository = &GormDeliveryRepo{}
+
+// GormDeliveryRepo is a GORM implementation of DeliveryRepository. Deliveries
+// and their attempts are removed with their webhook or event by ON DELETE CASCADE.
+type GormDeliveryRepo struct {
+	DB     *gorm.DB
+	logger *log.Logger
+}
+
+// NewGormDeliveryRepo constructs a new repository with a logger.
+func NewGormDeliveryRepo(db *gorm.DB, logger *log.Logger) *GormDeliveryRepo {
+	return &GormDeliveryRepo{DB: db, logger: logger}
+}
+
+func (r *GormDeliveryRepo) Create(d *models.Delivery) error {
+	if err := r.DB.Create(d).Error; err != nil {
+		r.logger.Printf("create delivery failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormDeliveryRepo) GetByID(id string) (*models.Delivery, error) {
+	var d models.Delivery
+	if err := r.DB.First(&d, "id = ?", id).Error; err != nil {
+		r.logger.Printf("get delivery %s failed: %v", id, err)
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *GormDeliveryRepo) ListByWebhook(webhookID string) ([]models.Delivery, error) {
+	list := []models.Delivery{}
+	if err := r.DB.
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list deliveries for %s failed: %v", webhookID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormDeliveryRepo) Update(d *models.Delivery) error {
+	res := r.DB.Model(&models.Delivery{}).Where("id = ?", d.ID).Select("*").Updates(d)
+	if res.Error != nil {
+		r.logger.Printf("update delivery %s failed: %v", d.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormDeliveryRepo) AddAttempt(a *models.DeliveryAttempt) error {
+	if err := r.DB.Create(a).Error; err != nil {
+		r.logger.Printf("add attempt %d of delivery %s failed: %v", a.Seq, a.DeliveryID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormDeliveryRepo) ListAttempts(deliveryID string) ([]models.DeliveryAttempt, error) {
+	list := []models.DeliveryAttempt{}
+	if err := r.DB.Where("delivery_id = ?", deliveryID).Order("seq").Find(&list).Error; err != nil {
+		r.logger.Printf("list attempts of delivery %s failed: %v", deliveryID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormDeliveryRepo) FailUnfinished(reason string, at time.Time) (int64, error) {
+	res := r.DB.Model(&models.Delivery{}).
+		Where("status = ?", models.DeliveryDelivering).
+		Updates(map[string]any{"status": models.DeliveryFailed, "error": reason, "next_attempt_at": nil, "finished_at": at})
+	if res.Error != nil {
+		r.logger.Printf("fail unfinished deliveries failed: %v", res.Error)
+	}
+	return res.RowsAffected, res.Error
+}
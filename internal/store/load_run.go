@@ -0,0 +1,76 @@
+package store
+
+import (
+	"log"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure GormLoadRunRepo implements repository.LoadRunRepository
+var _ repository.LoadRunRepository = &GormLoadRunRepo{}
+
+// GormLoadRunRepo is a GORM implementation of LoadRunRepository. Runs are
+// removed with their webhook by ON DELETE CASCADE.
+type GormLoadRunRepo struct {
+	DB     *gorm.DB
+	logger *log.Logger
+}
+
+// NewGormLoadRunRepo constructs a new repository with a logger.
+func NewGormLoadRunRepo(db *gorm.DB, logger *log.Logger) *GormLoadRunRepo {
+	return &GormLoadRunRepo{DB: db, logger: logger}
+}
+
+func (r *GormLoadRunRepo) Create(run *models.LoadRun) error {
+	if err := r.DB.Create(run).Error; err != nil {
+		r.logger.Printf("create load run failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormLoadRunRepo) GetByID(id string) (*models.LoadRun, error) {
+	var run models.LoadRun
+	if err := r.DB.First(&run, "id = ?", id).Error; err != nil {
+		r.logger.Printf("get load run %s failed: %v", id, err)
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *GormLoadRunRepo) ListByWebhook(webhookID string) ([]models.LoadRun, error) {
+	list := []models.LoadRun{}
+	if err := r.DB.
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list load runs for %s failed: %v", webhookID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormLoadRunRepo) Update(run *models.LoadRun) error {
+	res := r.DB.Model(&models.LoadRun{}).Where("id = ?", run.ID).Select("*").Updates(run)
+	if res.Error != nil {
+		r.logger.Printf("update load run %s failed: %v", run.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormLoadRunRepo) FailUnfinished(reason string, at time.Time) (int64, error) {
+	res := r.DB.Model(&models.LoadRun{}).
+		Where("status = ?", models.LoadRunning).
+		Updates(map[string]any{"status": models.LoadFailed, "error": reason, "finished_at": at})
+	if res.Error != nil {
+		r.logger.Printf("fail unfinished load runs failed: %v", res.Error)
+	}
+	return res.RowsAffected, res.Error
+}
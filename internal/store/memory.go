@@ -0,0 +1,238 @@
+package store
+
+import (
+	"sync"
+	"webhook-tester/internal/compare"
+	"webhook-tester/internal/load"
+	"webhook-tester/internal/models"
+
+	"gorm.io/datatypes"
+)
+
+// MemoryDB is the shared, thread-safe backing store for the in-memory
+// repositories. Repositories built on the same MemoryDB see each other's
+// data, the same way GORM repositories share one *gorm.DB.
+type MemoryDB struct {
+	mu                sync.RWMutex
+	webhooks          map[string]models.Webhook
+	requests          map[string]models.WebhookRequest
+	replays           map[string][]models.ReplayAttempt // by request ID
+	jobs              map[string]models.ReplayJob
+	jobItems          map[string][]models.ReplayJobItem // by job ID, in order
+	comparisons       map[string]models.Comparison
+	comparisonBatches map[string]models.ComparisonBatch
+	deliveries        map[string]models.Delivery
+	deliveryAttempts  map[string][]models.DeliveryAttempt // by delivery ID, in order
+	loadRuns          map[string]models.LoadRun
+	callbackActions   map[string]models.CallbackAction
+	callbacks         map[string]models.Callback
+	scriptStates      map[string]models.ScriptState // by webhook ID
+	users             map[uint]models.User
+	nextUserID        uint
+}
+
+// NewMemoryDB creates an empty in-memory store.
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		webhooks:          map[string]models.Webhook{},
+		requests:          map[string]models.WebhookRequest{},
+		replays:           map[string][]models.ReplayAttempt{},
+		jobs:              map[string]models.ReplayJob{},
+		jobItems:          map[string][]models.ReplayJobItem{},
+		comparisons:       map[string]models.Comparison{},
+		comparisonBatches: map[string]models.ComparisonBatch{},
+		deliveries:        map[string]models.Delivery{},
+		deliveryAttempts:  map[string][]models.DeliveryAttempt{},
+		loadRuns:          map[string]models.LoadRun{},
+		callbackActions:   map[string]models.CallbackAction{},
+		callbacks:         map[string]models.Callback{},
+		scriptStates:      map[string]models.ScriptState{},
+		users:             map[uint]models.User{},
+		nextUserID:        1,
+	}
+}
+
+// requestsFor returns copies of the requests that belong to a webhook.
+// Callers must hold at least a read lock.
+func (db *MemoryDB) requestsFor(webhookID string) []models.WebhookRequest {
+	var list []models.WebhookRequest
+	for _, wr := range db.requests {
+		if wr.WebhookID == webhookID {
+			list = append(list, copyRequest(wr))
+		}
+	}
+	return list
+}
+
+// deleteRequest removes a request and, like the SQL foreign keys, its replay
+// attempts, comparisons, deliveries and callbacks. Callers must hold the
+// write lock.
+func (db *MemoryDB) deleteRequest(id string) {
+	delete(db.requests, id)
+	delete(db.replays, id)
+	for cID, c := range db.comparisons {
+		if c.RequestID == id {
+			delete(db.comparisons, cID)
+		}
+	}
+	for dID, d := range db.deliveries {
+		if d.RequestID == id {
+			delete(db.deliveries, dID)
+			delete(db.deliveryAttempts, dID)
+		}
+	}
+	for cID, c := range db.callbacks {
+		if c.RequestID == id {
+			delete(db.callbacks, cID)
+		}
+	}
+}
+
+// deleteWebhook removes a webhook with its requests and, like the SQL foreign
+// keys, its replay jobs, comparison batches, load runs, callback actions and
+// script state. Callers must hold the write lock.
+func (db *MemoryDB) deleteWebhook(id string) {
+	for reqID, wr := range db.requests {
+		if wr.WebhookID == id {
+			db.deleteRequest(reqID)
+		}
+	}
+	for jobID, job := range db.jobs {
+		if job.WebhookID == id {
+			delete(db.jobs, jobID)
+			delete(db.jobItems, jobID)
+		}
+	}
+	for batchID, b := range db.comparisonBatches {
+		if b.WebhookID == id {
+			delete(db.comparisonBatches, batchID)
+		}
+	}
+	for runID, run := range db.loadRuns {
+		if run.WebhookID == id {
+			delete(db.loadRuns, runID)
+		}
+	}
+	for actionID, a := range db.callbackActions {
+		if a.WebhookID == id {
+			delete(db.callbackActions, actionID)
+		}
+	}
+	delete(db.scriptStates, id)
+	delete(db.webhooks, id)
+}
+
+// copyWebhook returns a copy that shares no mutable state with the store.
+func copyWebhook(w models.Webhook) models.Webhook {
+	if w.ContentType != nil {
+		ct := *w.ContentType
+		w.ContentType = &ct
+	}
+	if w.Payload != nil {
+		p := *w.Payload
+		w.Payload = &p
+	}
+	w.ResponseHeaders = copyMap(w.ResponseHeaders)
+	w.Requests = nil
+	return w
+}
+
+// copyRequest returns a copy that shares no mutable state with the store.
+func copyRequest(wr models.WebhookRequest) models.WebhookRequest {
+	wr.Headers = copyMap(wr.Headers)
+	wr.Query = copyMap(wr.Query)
+	return wr
+}
+
+// copyReplay returns a copy that shares no mutable state with the store.
+func copyReplay(a models.ReplayAttempt) models.ReplayAttempt {
+	a.RequestHeaders = copyMap(a.RequestHeaders)
+	a.ResponseHeaders = copyMap(a.ResponseHeaders)
+	return a
+}
+
+func copyMap(m datatypes.JSONMap) datatypes.JSONMap {
+	if m == nil {
+		return nil
+	}
+	c := make(datatypes.JSONMap, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// copyJob returns a copy that shares no mutable state with the store.
+func copyJob(j models.ReplayJob) models.ReplayJob {
+	if j.FinishedAt != nil {
+		t := *j.FinishedAt
+		j.FinishedAt = &t
+	}
+	return j
+}
+
+// copyJobItem returns a copy that shares no mutable state with the store.
+func copyJobItem(item models.ReplayJobItem) models.ReplayJobItem {
+	if item.SentAt != nil {
+		t := *item.SentAt
+		item.SentAt = &t
+	}
+	return item
+}
+
+// copyDelivery returns a copy that shares no mutable state with the store.
+func copyDelivery(d models.Delivery) models.Delivery {
+	d.Schedule = append(datatypes.JSONSlice[int64](nil), d.Schedule...)
+	if d.NextAttemptAt != nil {
+		t := *d.NextAttemptAt
+		d.NextAttemptAt = &t
+	}
+	if d.FinishedAt != nil {
+		t := *d.FinishedAt
+		d.FinishedAt = &t
+	}
+	return d
+}
+
+// copyLoadRun returns a copy that shares no mutable state with the store.
+func copyLoadRun(run models.LoadRun) models.LoadRun {
+	run.StatusCodes = append(datatypes.JSONSlice[load.StatusCount](nil), run.StatusCodes...)
+	run.ErrorCounts = append(datatypes.JSONSlice[load.ErrorCount](nil), run.ErrorCounts...)
+	if run.FinishedAt != nil {
+		t := *run.FinishedAt
+		run.FinishedAt = &t
+	}
+	return run
+}
+
+// copyCallbackAction returns a copy that shares no mutable state with the store.
+func copyCallbackAction(a models.CallbackAction) models.CallbackAction {
+	a.Headers = copyMap(a.Headers)
+	return a
+}
+
+// copyCallback returns a copy that shares no mutable state with the store.
+func copyCallback(c models.Callback) models.Callback {
+	if c.SentAt != nil {
+		t := *c.SentAt
+		c.SentAt = &t
+	}
+	return c
+}
+
+// copyComparison returns a copy that shares no mutable state with the store.
+func copyComparison(c models.Comparison) models.Comparison {
+	c.Ignore = append(datatypes.JSONSlice[string](nil), c.Ignore...)
+	c.Differences = append(datatypes.JSONSlice[compare.Difference](nil), c.Differences...)
+	return c
+}
+
+// copyComparisonBatch returns a copy that shares no mutable state with the store.
+func copyComparisonBatch(b models.ComparisonBatch) models.ComparisonBatch {
+	b.Ignore = append(datatypes.JSONSlice[string](nil), b.Ignore...)
+	if b.FinishedAt != nil {
+		t := *b.FinishedAt
+		b.FinishedAt = &t
+	}
+	return b
+}
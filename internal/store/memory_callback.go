@@ -0,0 +1,177 @@
+package store
+
+import (
+	"sort"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryCallbackRepo implements repository.CallbackRepository
+var _ repository.CallbackRepository = &MemoryCallbackRepo{}
+
+// MemoryCallbackRepo is an in-memory implementation of CallbackRepository.
+type MemoryCallbackRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryCallbackRepo constructs a repository backed by db.
+func NewMemoryCallbackRepo(db *MemoryDB) *MemoryCallbackRepo {
+	return &MemoryCallbackRepo{db: db}
+}
+
+func (r *MemoryCallbackRepo) CreateAction(a *models.CallbackAction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[a.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.callbackActions[a.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.db.callbackActions[a.ID] = copyCallbackAction(*a)
+	return nil
+}
+
+func (r *MemoryCallbackRepo) GetAction(id string) (*models.CallbackAction, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	a, ok := r.db.callbackActions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	a = copyCallbackAction(a)
+	return &a, nil
+}
+
+func (r *MemoryCallbackRepo) ListActions(webhookID string) ([]models.CallbackAction, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := []models.CallbackAction{}
+	for _, a := range r.db.callbackActions {
+		if a.WebhookID == webhookID {
+			list = append(list, copyCallbackAction(a))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *MemoryCallbackRepo) UpdateAction(a *models.CallbackAction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.callbackActions[a.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.db.callbackActions[a.ID] = copyCallbackAction(*a)
+	return nil
+}
+
+func (r *MemoryCallbackRepo) DeleteAction(id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.callbackActions[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.db.callbackActions, id)
+	return nil
+}
+
+func (r *MemoryCallbackRepo) Create(c *models.Callback) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[c.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.requests[c.RequestID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.callbacks[c.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.db.callbacks[c.ID] = copyCallback(*c)
+	return nil
+}
+
+func (r *MemoryCallbackRepo) GetByID(id string) (*models.Callback, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	c, ok := r.db.callbacks[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c = copyCallback(c)
+	return &c, nil
+}
+
+func (r *MemoryCallbackRepo) ListByWebhook(webhookID string, limit int) ([]models.Callback, error) {
+	list := r.list(func(c models.Callback) bool { return c.WebhookID == webhookID })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (r *MemoryCallbackRepo) ListByRequest(requestID string) ([]models.Callback, error) {
+	return r.list(func(c models.Callback) bool { return c.RequestID == requestID }), nil
+}
+
+// list returns the callbacks matching keep, newest first.
+func (r *MemoryCallbackRepo) list(keep func(models.Callback) bool) []models.Callback {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := []models.Callback{}
+	for _, c := range r.db.callbacks {
+		if keep(c) {
+			list = append(list, copyCallback(c))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list
+}
+
+func (r *MemoryCallbackRepo) Update(c *models.Callback) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.callbacks[c.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.db.callbacks[c.ID] = copyCallback(*c)
+	return nil
+}
+
+func (r *MemoryCallbackRepo) FailUnfinished(reason string) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var n int64
+	for id, c := range r.db.callbacks {
+		if c.Done() {
+			continue
+		}
+		c.Status, c.Error = models.CallbackFailed, reason
+		r.db.callbacks[id] = copyCallback(c)
+		n++
+	}
+	return n, nil
+}
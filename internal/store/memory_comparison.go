@@ -0,0 +1,157 @@
+package store
+
+import (
+	"sort"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryComparisonRepo implements repository.ComparisonRepository
+var _ repository.ComparisonRepository = &MemoryComparisonRepo{}
+
+// MemoryComparisonRepo is an in-memory implementation of ComparisonRepository.
+type MemoryComparisonRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryComparisonRepo constructs a repository backed by db.
+func NewMemoryComparisonRepo(db *MemoryDB) *MemoryComparisonRepo {
+	return &MemoryComparisonRepo{db: db}
+}
+
+func (r *MemoryComparisonRepo) Insert(c *models.Comparison) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.requests[c.RequestID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.comparisons[c.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.db.comparisons[c.ID] = copyComparison(*c)
+	return nil
+}
+
+func (r *MemoryComparisonRepo) GetByID(id string) (*models.Comparison, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	c, ok := r.db.comparisons[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	c = copyComparison(c)
+	return &c, nil
+}
+
+func (r *MemoryComparisonRepo) ListByRequest(requestID string) ([]models.Comparison, error) {
+	list := r.list(func(c *models.Comparison) bool { return c.RequestID == requestID })
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *MemoryComparisonRepo) ListByBatch(batchID string) ([]models.Comparison, error) {
+	list := r.list(func(c *models.Comparison) bool { return c.BatchID == batchID })
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+// list returns copies of the comparisons matching keep, in no order.
+func (r *MemoryComparisonRepo) list(keep func(c *models.Comparison) bool) []models.Comparison {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := []models.Comparison{}
+	for _, c := range r.db.comparisons {
+		if keep(&c) {
+			list = append(list, copyComparison(c))
+		}
+	}
+	return list
+}
+
+func (r *MemoryComparisonRepo) CreateBatch(b *models.ComparisonBatch) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[b.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.comparisonBatches[b.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.db.comparisonBatches[b.ID] = copyComparisonBatch(*b)
+	return nil
+}
+
+func (r *MemoryComparisonRepo) GetBatch(id string) (*models.ComparisonBatch, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	b, ok := r.db.comparisonBatches[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	b = copyComparisonBatch(b)
+	return &b, nil
+}
+
+func (r *MemoryComparisonRepo) ListBatches(webhookID string) ([]models.ComparisonBatch, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := []models.ComparisonBatch{}
+	for _, b := range r.db.comparisonBatches {
+		if b.WebhookID == webhookID {
+			list = append(list, copyComparisonBatch(b))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *MemoryComparisonRepo) UpdateBatch(b *models.ComparisonBatch) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.comparisonBatches[b.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.db.comparisonBatches[b.ID] = copyComparisonBatch(*b)
+	return nil
+}
+
+func (r *MemoryComparisonRepo) FailUnfinishedBatches(reason string, at time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var n int64
+	for id, b := range r.db.comparisonBatches {
+		if b.Done() {
+			continue
+		}
+		b.Status, b.Error, b.FinishedAt = models.ComparisonBatchFailed, reason, &at
+		r.db.comparisonBatches[id] = copyComparisonBatch(b)
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"sort"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryDeliveryRepo implements repository.DeliveryRepository
+var _ repository.DeliveryRepository = &MemoryDeliveryRepo{}
+
+// MemoryDeliveryRepo is an in-memory implementation of DeliveryRepository.
+type MemoryDeliveryRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryDeliveryRepo constructs a repository backed by db.
+func NewMemoryDeliveryRepo(db *MemoryDB) *MemoryDeliveryRepo {
+	return &MemoryDeliveryRepo{db: db}
+}
+
+func (r *MemoryDeliveryRepo) Create(d *models.Delivery) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[d.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.requests[d.RequestID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.deliveries[d.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.db.deliveries[d.ID] = copyDelivery(*d)
+	return nil
+}
+
+func (r *MemoryDeliveryRepo) GetByID(id string) (*models.Delivery, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	d, ok := r.db.deliveries[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	d = copyDelivery(d)
+	return &d, nil
+}
+
+func (r *MemoryDeliveryRepo) ListByWebhook(webhookID string) ([]models.Delivery, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := []models.Delivery{}
+	for _, d := range r.db.deliveries {
+		if d.WebhookID == webhookID {
+			list = append(list, copyDelivery(d))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *MemoryDeliveryRepo) Update(d *models.Delivery) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.deliveries[d.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.db.deliveries[d.ID] = copyDelivery(*d)
+	return nil
+}
+
+func (r *MemoryDeliveryRepo) AddAttempt(a *models.DeliveryAttempt) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.deliveries[a.DeliveryID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	for _, existing := range r.db.deliveryAttempts[a.DeliveryID] {
+		if existing.Seq == a.Seq {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	list := append(r.db.deliveryAttempts[a.DeliveryID], *a)
+	sort.Slice(list, func(i, j int) bool { return list[i].Seq < list[j].Seq })
+	r.db.deliveryAttempts[a.DeliveryID] = list
+	return nil
+}
+
+func (r *MemoryDeliveryRepo) ListAttempts(deliveryID string) ([]models.DeliveryAttempt, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return append([]models.DeliveryAttempt{}, r.db.deliveryAttempts[deliveryID]...), nil
+}
+
+func (r *MemoryDeliveryRepo) FailUnfinished(reason string, at time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var n int64
+	for id, d := range r.db.deliveries {
+		if d.Done() {
+			continue
+		}
+		d.Status, d.Error, d.NextAttemptAt, d.FinishedAt = models.DeliveryFailed, reason, nil, &at
+		r.db.deliveries[id] = copyDelivery(d)
+		n++
+	}
+	return n, nil
+}
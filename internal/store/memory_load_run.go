@@ -0,0 +1,95 @@
+package store
+
+import (
+	"sort"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryLoadRunRepo implements repository.LoadRunRepository
+var _ repository.LoadRunRepository = &MemoryLoadRunRepo{}
+
+// MemoryLoadRunRepo is an in-memory implementation of LoadRunRepository.
+type MemoryLoadRunRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryLoadRunRepo constructs a repository backed by db.
+func NewMemoryLoadRunRepo(db *MemoryDB) *MemoryLoadRunRepo {
+	return &MemoryLoadRunRepo{db: db}
+}
+
+func (r *MemoryLoadRunRepo) Create(run *models.LoadRun) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[run.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.loadRuns[run.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.db.loadRuns[run.ID] = copyLoadRun(*run)
+	return nil
+}
+
+func (r *MemoryLoadRunRepo) GetByID(id string) (*models.LoadRun, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	run, ok := r.db.loadRuns[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	run = copyLoadRun(run)
+	return &run, nil
+}
+
+func (r *MemoryLoadRunRepo) ListByWebhook(webhookID string) ([]models.LoadRun, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := []models.LoadRun{}
+	for _, run := range r.db.loadRuns {
+		if run.WebhookID == webhookID {
+			list = append(list, copyLoadRun(run))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *MemoryLoadRunRepo) Update(run *models.LoadRun) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.loadRuns[run.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.db.loadRuns[run.ID] = copyLoadRun(*run)
+	return nil
+}
+
+func (r *MemoryLoadRunRepo) FailUnfinished(reason string, at time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var n int64
+	for id, run := range r.db.loadRuns {
+		if run.Done() {
+			continue
+		}
+		run.Status, run.Error, run.FinishedAt = models.LoadFailed, reason, &at
+		r.db.loadRuns[id] = copyLoadRun(run)
+		n++
+	}
+	return n, nil
+}
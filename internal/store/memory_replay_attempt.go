@@ -0,0 +1,72 @@
+package store
+
+import (
+	"sort"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryReplayAttemptRepo implements repository.ReplayAttemptRepository
+var _ repository.ReplayAttemptRepository = &MemoryReplayAttemptRepo{}
+
+// MemoryReplayAttemptRepo is an in-memory implementation of ReplayAttemptRepository.
+type MemoryReplayAttemptRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryReplayAttemptRepo constructs a repository backed by db.
+func NewMemoryReplayAttemptRepo(db *MemoryDB) *MemoryReplayAttemptRepo {
+	return &MemoryReplayAttemptRepo{db: db}
+}
+
+func (r *MemoryReplayAttemptRepo) Insert(a *models.ReplayAttempt) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.requests[a.RequestID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	for _, list := range r.db.replays {
+		for _, existing := range list {
+			if existing.ID == a.ID {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+	}
+	r.db.replays[a.RequestID] = append(r.db.replays[a.RequestID], copyReplay(*a))
+	return nil
+}
+
+func (r *MemoryReplayAttemptRepo) GetByID(id string) (*models.ReplayAttempt, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	for _, list := range r.db.replays {
+		for _, a := range list {
+			if a.ID == id {
+				a = copyReplay(a)
+				return &a, nil
+			}
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *MemoryReplayAttemptRepo) ListByRequest(requestID string) ([]models.ReplayAttempt, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := make([]models.ReplayAttempt, 0, len(r.db.replays[requestID]))
+	for _, a := range r.db.replays[requestID] {
+		list = append(list, copyReplay(a))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"sort"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryReplayJobRepo implements repository.ReplayJobRepository
+var _ repository.ReplayJobRepository = &MemoryReplayJobRepo{}
+
+// MemoryReplayJobRepo is an in-memory implementation of ReplayJobRepository.
+type MemoryReplayJobRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryReplayJobRepo constructs a repository backed by db.
+func NewMemoryReplayJobRepo(db *MemoryDB) *MemoryReplayJobRepo {
+	return &MemoryReplayJobRepo{db: db}
+}
+
+func (r *MemoryReplayJobRepo) Create(job *models.ReplayJob, items []models.ReplayJobItem) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[job.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.jobs[job.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.db.jobs[job.ID] = copyJob(*job)
+	list := make([]models.ReplayJobItem, len(items))
+	for i := range items {
+		items[i].JobID = job.ID
+		list[i] = copyJobItem(items[i])
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Seq < list[j].Seq })
+	r.db.jobItems[job.ID] = list
+	return nil
+}
+
+func (r *MemoryReplayJobRepo) GetByID(id string) (*models.ReplayJob, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	job, ok := r.db.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	job = copyJob(job)
+	return &job, nil
+}
+
+func (r *MemoryReplayJobRepo) ListByWebhook(webhookID string) ([]models.ReplayJob, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := []models.ReplayJob{}
+	for _, job := range r.db.jobs {
+		if job.WebhookID == webhookID {
+			list = append(list, copyJob(job))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	return list, nil
+}
+
+func (r *MemoryReplayJobRepo) Update(job *models.ReplayJob) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.jobs[job.ID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	r.db.jobs[job.ID] = copyJob(*job)
+	return nil
+}
+
+func (r *MemoryReplayJobRepo) UpdateItem(item *models.ReplayJobItem) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for i, existing := range r.db.jobItems[item.JobID] {
+		if existing.Seq == item.Seq {
+			r.db.jobItems[item.JobID][i] = copyJobItem(*item)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *MemoryReplayJobRepo) ListItems(jobID string) ([]models.ReplayJobItem, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	list := make([]models.ReplayJobItem, 0, len(r.db.jobItems[jobID]))
+	for _, item := range r.db.jobItems[jobID] {
+		list = append(list, copyJobItem(item))
+	}
+	return list, nil
+}
+
+func (r *MemoryReplayJobRepo) FailUnfinished(reason string, at time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var n int64
+	for id, job := range r.db.jobs {
+		if job.Done() {
+			continue
+		}
+		job.Status, job.Error, job.FinishedAt = models.ReplayJobFailed, reason, &at
+		r.db.jobs[id] = copyJob(job)
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryScriptStateRepo implements repository.ScriptStateRepository
+var _ repository.ScriptStateRepository = &MemoryScriptStateRepo{}
+
+// MemoryScriptStateRepo is an in-memory implementation of ScriptStateRepository.
+type MemoryScriptStateRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryScriptStateRepo constructs a repository backed by db.
+func NewMemoryScriptStateRepo(db *MemoryDB) *MemoryScriptStateRepo {
+	return &MemoryScriptStateRepo{db: db}
+}
+
+func (r *MemoryScriptStateRepo) Get(webhookID string) (*models.ScriptState, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	s, ok := r.db.scriptStates[webhookID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	s.Data = copyMap(s.Data)
+	return &s, nil
+}
+
+func (r *MemoryScriptStateRepo) Save(s *models.ScriptState) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[s.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	c := *s
+	c.Data = copyMap(s.Data)
+	r.db.scriptStates[s.WebhookID] = c
+	return nil
+}
+
+func (r *MemoryScriptStateRepo) Delete(webhookID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	delete(r.db.scriptStates, webhookID)
+	return nil
+}
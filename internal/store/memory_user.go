@@ -0,0 +1,100 @@
+package store
+
+import (
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var _ repository.UserRepository = &MemoryUserRepo{}
+
+// MemoryUserRepo is an in-memory implementation of UserRepository.
+type MemoryUserRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryUserRepo constructs a repository backed by db.
+func NewMemoryUserRepo(db *MemoryDB) *MemoryUserRepo {
+	return &MemoryUserRepo{db: db}
+}
+
+func (r *MemoryUserRepo) Create(user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, u := range r.db.users {
+		if u.Email == user.Email {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	if user.ID == 0 {
+		user.ID = r.db.nextUserID
+	} else if _, ok := r.db.users[user.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	if user.ID >= r.db.nextUserID {
+		r.db.nextUserID = user.ID + 1
+	}
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.db.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryUserRepo) GetByEmail(email string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Email == email })
+}
+
+func (r *MemoryUserRepo) GetByID(id uint) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id })
+}
+
+// GetByResetToken looks up a user whose ResetToken matches the given string.
+func (r *MemoryUserRepo) GetByResetToken(token string) (*models.User, error) {
+	u, err := r.find(func(u models.User) bool { return u.ResetToken == token })
+	if err != nil {
+		return &models.User{}, err
+	}
+	return u, nil
+}
+
+func (r *MemoryUserRepo) Update(user *models.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user.UpdatedAt = time.Now()
+	r.db.users[user.ID] = *user
+	if user.ID >= r.db.nextUserID {
+		r.db.nextUserID = user.ID + 1
+	}
+	return nil
+}
+
+func (r *MemoryUserRepo) GetByAPIKey(key string) (*models.User, error) {
+	u, err := r.find(func(u models.User) bool { return u.APIKey == key })
+	if err != nil {
+		return &models.User{}, err
+	}
+	return u, nil
+}
+
+// find returns the user with the lowest ID that matches, like SQL First.
+func (r *MemoryUserRepo) find(match func(models.User) bool) (*models.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var found *models.User
+	for _, u := range r.db.users {
+		if match(u) && (found == nil || u.ID < found.ID) {
+			u := u
+			found = &u
+		}
+	}
+	if found == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return found, nil
+}
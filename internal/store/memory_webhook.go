@@ -0,0 +1,204 @@
+package store
+
+import (
+	"sort"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryWebhookRepo implements repository.WebhookRepository
+var _ repository.WebhookRepository = &MemoryWebhookRepo{}
+
+// MemoryWebhookRepo is an in-memory implementation of WebhookRepository.
+type MemoryWebhookRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryWebhookRepo constructs a repository backed by db.
+func NewMemoryWebhookRepo(db *MemoryDB) *MemoryWebhookRepo {
+	return &MemoryWebhookRepo{db: db}
+}
+
+func (r *MemoryWebhookRepo) Insert(webhook *models.Webhook) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[webhook.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	now := time.Now()
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = now
+	}
+	if webhook.UpdatedAt.IsZero() {
+		webhook.UpdatedAt = now
+	}
+	r.db.webhooks[webhook.ID] = copyWebhook(*webhook)
+	for _, wr := range webhook.Requests {
+		r.db.requests[wr.ID] = copyRequest(wr)
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRepo) Get(id string) (*models.Webhook, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	w, ok := r.db.webhooks[id]
+	if !ok {
+		return &models.Webhook{}, gorm.ErrRecordNotFound
+	}
+	w = copyWebhook(w)
+	return &w, nil
+}
+
+func (r *MemoryWebhookRepo) InsertRequest(wr *models.WebhookRequest) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[wr.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.requests[wr.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.db.requests[wr.ID] = copyRequest(*wr)
+	return nil
+}
+
+func (r *MemoryWebhookRepo) GetByUser(id string, userID uint) (*models.Webhook, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	w, ok := r.db.webhooks[id]
+	if !ok || w.UserID != int(userID) {
+		return &models.Webhook{}, gorm.ErrRecordNotFound
+	}
+	w = copyWebhook(w)
+	return &w, nil
+}
+
+func (r *MemoryWebhookRepo) GetAll() ([]models.Webhook, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var webhooks []models.Webhook
+	for _, w := range r.db.webhooks {
+		w = copyWebhook(w)
+		w.Requests = r.db.requestsFor(w.ID)
+		webhooks = append(webhooks, w)
+	}
+	sortWebhooks(webhooks)
+	return webhooks, nil
+}
+
+func (r *MemoryWebhookRepo) GetAllByUser(userID uint) ([]models.Webhook, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var webhooks []models.Webhook
+	for _, w := range r.db.webhooks {
+		if w.UserID != int(userID) {
+			continue
+		}
+		w = copyWebhook(w)
+		w.Requests = newestFirst(r.db.requestsFor(w.ID))
+		if len(w.Requests) > 1000 {
+			w.Requests = w.Requests[:1000]
+		}
+		webhooks = append(webhooks, w)
+	}
+	sortWebhooks(webhooks)
+	return webhooks, nil
+}
+
+func (r *MemoryWebhookRepo) Update(webhook *models.Webhook) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	webhook.UpdatedAt = time.Now()
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = webhook.UpdatedAt
+	}
+	r.db.webhooks[webhook.ID] = copyWebhook(*webhook)
+	return nil
+}
+
+func (r *MemoryWebhookRepo) Delete(id string, userID uint) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	w, ok := r.db.webhooks[id]
+	if !ok || w.UserID != int(userID) {
+		return gorm.ErrRecordNotFound
+	}
+	r.db.deleteWebhook(id)
+	return nil
+}
+
+func (r *MemoryWebhookRepo) GetWithRequests(id string) (*models.Webhook, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	w, ok := r.db.webhooks[id]
+	if !ok {
+		return &models.Webhook{}, gorm.ErrRecordNotFound
+	}
+	w = copyWebhook(w)
+	w.Requests = newestFirst(r.db.requestsFor(id))
+	return &w, nil
+}
+
+// CleanPublic deletes public webhooks created before now minus d, along with
+// their requests. See GormWebhookRepo.CleanPublic.
+func (r *MemoryWebhookRepo) CleanPublic(d time.Duration) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	beforeDate := time.Now().Add(-d)
+	for id, w := range r.db.webhooks {
+		if w.UserID != 0 || !w.CreatedAt.Before(beforeDate) {
+			continue
+		}
+		r.db.deleteWebhook(id)
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRepo) GetAllWithRetention() ([]models.Webhook, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var webhooks []models.Webhook
+	for _, w := range r.db.webhooks {
+		if w.HasRetention() {
+			webhooks = append(webhooks, copyWebhook(w))
+		}
+	}
+	sortWebhooks(webhooks)
+	return webhooks, nil
+}
+
+// sortWebhooks orders webhooks oldest first, matching insertion order in SQL.
+func sortWebhooks(list []models.Webhook) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+}
+
+// newestFirst orders requests by received_at descending, then by ID.
+func newestFirst(list []models.WebhookRequest) []models.WebhookRequest {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].ReceivedAt.Equal(list[j].ReceivedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].ReceivedAt.After(list[j].ReceivedAt)
+	})
+	return list
+}
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"slices"
+	"sort"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure MemoryWebhookRequestRepo implements repository.WebhookRequestRepository
+var _ repository.WebhookRequestRepository = &MemoryWebhookRequestRepo{}
+
+// MemoryWebhookRequestRepo is an in-memory implementation of WebhookRequestRepository.
+type MemoryWebhookRequestRepo struct {
+	db *MemoryDB
+}
+
+// NewMemoryWebhookRequestRepo constructs a repository backed by db.
+func NewMemoryWebhookRequestRepo(db *MemoryDB) *MemoryWebhookRequestRepo {
+	return &MemoryWebhookRequestRepo{db: db}
+}
+
+func (r *MemoryWebhookRequestRepo) Insert(req *models.WebhookRequest) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[req.WebhookID]; !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	if _, ok := r.db.requests[req.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	if req.Source == "" {
+		req.Source = models.SourceLive // the column default
+	}
+	r.db.requests[req.ID] = copyRequest(*req)
+	return nil
+}
+
+func (r *MemoryWebhookRequestRepo) InsertMany(reqs []models.WebhookRequest) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	seen := make(map[string]bool, len(reqs))
+	for _, req := range reqs {
+		if _, ok := r.db.webhooks[req.WebhookID]; !ok {
+			return gorm.ErrForeignKeyViolated
+		}
+		if _, ok := r.db.requests[req.ID]; ok || seen[req.ID] {
+			return gorm.ErrDuplicatedKey
+		}
+		seen[req.ID] = true
+	}
+	for i := range reqs {
+		if reqs[i].Source == "" {
+			reqs[i].Source = models.SourceLive
+		}
+		r.db.requests[reqs[i].ID] = copyRequest(reqs[i])
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRequestRepo) GetByID(id string) (*models.WebhookRequest, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	wr, ok := r.db.requests[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	wr = copyRequest(wr)
+	return &wr, nil
+}
+
+func (r *MemoryWebhookRequestRepo) ListByWebhook(webhookID string) ([]models.WebhookRequest, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	return newestFirst(r.db.requestsFor(webhookID)), nil
+}
+
+func (r *MemoryWebhookRequestRepo) ListPageByWebhook(webhookID string, offset, limit int) ([]models.WebhookRequest, int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	all := newestFirst(r.db.requestsFor(webhookID))
+	total := int64(len(all))
+	if offset >= len(all) {
+		return []models.WebhookRequest{}, total, nil
+	}
+	all = all[offset:]
+	if limit < len(all) {
+		all = all[:limit]
+	}
+	return all, total, nil
+}
+
+func (r *MemoryWebhookRequestRepo) DeleteByID(id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	r.db.deleteRequest(id)
+	return nil
+}
+
+func (r *MemoryWebhookRequestRepo) DeleteByWebhook(webhookID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for id, wr := range r.db.requests {
+		if wr.WebhookID == webhookID {
+			r.db.deleteRequest(id)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryWebhookRequestRepo) SetPinned(id string, pinned bool) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	wr, ok := r.db.requests[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	wr.Pinned = pinned
+	r.db.requests[id] = wr
+	return nil
+}
+
+func (r *MemoryWebhookRequestRepo) PruneKeepLast(webhookID string, n uint) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var unpinned []models.WebhookRequest
+	for _, wr := range r.db.requests {
+		if wr.WebhookID == webhookID && !wr.Pinned {
+			unpinned = append(unpinned, wr)
+		}
+	}
+	newestFirst(unpinned)
+
+	var removed int64
+	for i := int(n); i < len(unpinned); i++ {
+		r.db.deleteRequest(unpinned[i].ID)
+		removed++
+	}
+	return removed, nil
+}
+
+func (r *MemoryWebhookRequestRepo) PruneBefore(webhookID string, t time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var removed int64
+	for id, wr := range r.db.requests {
+		if wr.WebhookID == webhookID && !wr.Pinned && wr.ReceivedAt.Before(t) {
+			r.db.deleteRequest(id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (r *MemoryWebhookRequestRepo) SizeByUser(userID uint) (int64, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+
+	var total int64
+	for _, wr := range r.db.requests {
+		if w, ok := r.db.webhooks[wr.WebhookID]; ok && w.UserID == int(userID) {
+			total += wr.Size
+		}
+	}
+	return total, nil
+}
+
+// EachByWebhook filters a snapshot of the webhook's requests and hands it to
+// fn in batches, without holding the lock while fn runs.
+func (r *MemoryWebhookRequestRepo) EachByWebhook(webhookID string, f repository.RequestFilter, batchSize int, fn func([]models.WebhookRequest) error) error {
+	r.db.mu.RLock()
+	var list []models.WebhookRequest
+	for _, wr := range r.db.requestsFor(webhookID) {
+		switch {
+		case !f.Since.IsZero() && wr.ReceivedAt.Before(f.Since):
+		case !f.Until.IsZero() && !wr.ReceivedAt.Before(f.Until):
+		case len(f.Methods) > 0 && !slices.Contains(f.Methods, wr.Method):
+		case f.Source != "" && wr.Source != f.Source:
+		case len(f.IDs) > 0 && !slices.Contains(f.IDs, wr.ID):
+		default:
+			list = append(list, wr)
+		}
+	}
+	r.db.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].ReceivedAt.Equal(list[j].ReceivedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].ReceivedAt.Before(list[j].ReceivedAt)
+	})
+	for batch := range slices.Chunk(list, batchSize) {
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"log"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure GormReplayAttemptRepo implements repository.ReplayAttemptRepository
+var _ repository.ReplayAttemptRepository = &GormReplayAttemptRepo{}
+
+// GormReplayAttemptRepo is a GORM implementation of ReplayAttemptRepository.
+// Attempts are removed with their request by the foreign key's ON DELETE CASCADE.
+type GormReplayAttemptRepo struct {
+	DB     *gorm.DB
+	logger *log.Logger
+}
+
+// NewGormReplayAttemptRepo constructs a new repository with a logger.
+func NewGormReplayAttemptRepo(db *gorm.DB, logger *log.Logger) *GormReplayAttemptRepo {
+	return &GormReplayAttemptRepo{DB: db, logger: logger}
+}
+
+func (r *GormReplayAttemptRepo) Insert(a *models.ReplayAttempt) error {
+	if err := r.DB.Create(a).Error; err != nil {
+		r.logger.Printf("insert replay attempt failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormReplayAttemptRepo) GetByID(id string) (*models.ReplayAttempt, error) {
+	var a models.ReplayAttempt
+	if err := r.DB.First(&a, "id = ?", id).Error; err != nil {
+		r.logger.Printf("get replay attempt %s failed: %v", id, err)
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *GormReplayAttemptRepo) ListByRequest(requestID string) ([]models.ReplayAttempt, error) {
+	list := []models.ReplayAttempt{}
+	if err := r.DB.
+		Where("request_id = ?", requestID).
+		Order("created_at DESC, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list replay attempts for %s failed: %v", requestID, err)
+		return nil, err
+	}
+	return list, nil
+}
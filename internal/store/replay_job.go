@@ -0,0 +1,110 @@
+package store
+
+import (
+	"log"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// Ensure GormReplayJobRepo implements repository.ReplayJobRepository
+var _ repository.ReplayJobRepository = &GormReplayJobRepo{}
+
+// GormReplayJobRepo is a GORM implementation of ReplayJobRepository. Jobs and
+// their items are removed with their webhook by ON DELETE CASCADE.
+type GormReplayJobRepo struct {
+	DB     *gorm.DB
+	logger *log.Logger
+}
+
+// NewGormReplayJobRepo constructs a new repository with a logger.
+func NewGormReplayJobRepo(db *gorm.DB, logger *log.Logger) *GormReplayJobRepo {
+	return &GormReplayJobRepo{DB: db, logger: logger}
+}
+
+func (r *GormReplayJobRepo) Create(job *models.ReplayJob, items []models.ReplayJobItem) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		for i := range items {
+			items[i].JobID = job.ID
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+	if err != nil {
+		r.logger.Printf("create replay job failed: %v", err)
+	}
+	return err
+}
+
+func (r *GormReplayJobRepo) GetByID(id string) (*models.ReplayJob, error) {
+	var job models.ReplayJob
+	if err := r.DB.First(&job, "id = ?", id).Error; err != nil {
+		r.logger.Printf("get replay job %s failed: %v", id, err)
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *GormReplayJobRepo) ListByWebhook(webhookID string) ([]models.ReplayJob, error) {
+	list := []models.ReplayJob{}
+	if err := r.DB.
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id").
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list replay jobs for %s failed: %v", webhookID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormReplayJobRepo) Update(job *models.ReplayJob) error {
+	res := r.DB.Model(&models.ReplayJob{}).Where("id = ?", job.ID).Select("*").Updates(job)
+	if res.Error != nil {
+		r.logger.Printf("update replay job %s failed: %v", job.ID, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormReplayJobRepo) UpdateItem(item *models.ReplayJobItem) error {
+	res := r.DB.Model(&models.ReplayJobItem{}).
+		Where("job_id = ? AND seq = ?", item.JobID, item.Seq).
+		Select("*").Updates(item)
+	if res.Error != nil {
+		r.logger.Printf("update replay job item %s/%d failed: %v", item.JobID, item.Seq, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormReplayJobRepo) ListItems(jobID string) ([]models.ReplayJobItem, error) {
+	list := []models.ReplayJobItem{}
+	if err := r.DB.Where("job_id = ?", jobID).Order("seq").Find(&list).Error; err != nil {
+		r.logger.Printf("list items of replay job %s failed: %v", jobID, err)
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *GormReplayJobRepo) FailUnfinished(reason string, at time.Time) (int64, error) {
+	res := r.DB.Model(&models.ReplayJob{}).
+		Where("status IN ?", []string{models.ReplayJobRunning, models.ReplayJobPaused}).
+		Updates(map[string]any{"status": models.ReplayJobFailed, "error": reason, "finished_at": at})
+	if res.Error != nil {
+		r.logger.Printf("fail unfinished replay jobs failed: %v", res.Error)
+	}
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"log"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ensure GormScriptStateRepo implements repository.ScriptStateRepository
+var _ repository.ScriptStateRepository = &GormScriptStateRepo{}
+
+// GormScriptStateRepo is a GORM implementation of ScriptStateRepository.
+// States are removed with their webhook by ON DELETE CASCADE.
+type GormScriptStateRepo struct {
+	DB     *gorm.DB
+	logger *log.Logger
+}
+
+// NewGormScriptStateRepo constructs a new repository with a logger.
+func NewGormScriptStateRepo(db *gorm.DB, logger *log.Logger) *GormScriptStateRepo {
+	return &GormScriptStateRepo{DB: db, logger: logger}
+}
+
+func (r *GormScriptStateRepo) Get(webhookID string) (*models.ScriptState, error) {
+	var s models.ScriptState
+	if err := r.DB.First(&s, "webhook_id = ?", webhookID).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *GormScriptStateRepo) Save(s *models.ScriptState) error {
+	if err := r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(s).Error; err != nil {
+		r.logger.Printf("save script state of %s failed: %v", s.WebhookID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *GormScriptStateRepo) Delete(webhookID string) error {
+	if err := r.DB.Delete(&models.ScriptState{}, "webhook_id = ?", webhookID).Error; err != nil {
+		r.logger.Printf("delete script state of %s failed: %v", webhookID, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,1311 @@
+// Package storetest is a conformance suite for repository implementations.
+// Every backend in internal/store runs the same tests so that they keep
+// identical semantics.
+package storetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"webhook-tester/internal/chaos"
+	"webhook-tester/internal/compare"
+	"webhook-tester/internal/load"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Repos is one backend under test. All repositories must share storage.
+type Repos struct {
+	Webhooks   repository.WebhookRepository
+	Requests   repository.WebhookRequestRepository
+	Users      repository.UserRepository
+	Replays    repository.ReplayAttemptRepository
+	Jobs       repository.ReplayJobRepository
+	Compares   repository.ComparisonRepository
+	Deliveries repository.DeliveryRepository
+	LoadRuns   repository.LoadRunRepository
+	Callbacks  repository.CallbackRepository
+	Scripts    repository.ScriptStateRepository
+}
+
+// Factory returns empty repositories for a single test.
+type Factory func(t *testing.T) Repos
+
+// Run executes the conformance suite against the backend built by newRepos.
+func Run(t *testing.T, newRepos Factory) {
+	tests := map[string]func(*testing.T, Repos){
+		"WebhookInsertAndGet":      testWebhookInsertAndGet,
+		"WebhookOwnership":         testWebhookOwnership,
+		"WebhookUpdate":            testWebhookUpdate,
+		"WebhookDelete":            testWebhookDelete,
+		"WebhookListByUser":        testWebhookListByUser,
+		"WebhookWithRequests":      testWebhookWithRequests,
+		"WebhookCleanPublic":       testWebhookCleanPublic,
+		"WebhookRetentionList":     testWebhookRetentionList,
+		"RequestInsertAndGet":      testRequestInsertAndGet,
+		"RequestDelete":            testRequestDelete,
+		"RequestPinning":           testRequestPinning,
+		"RequestPruneKeepLast":     testRequestPruneKeepLast,
+		"RequestPruneBefore":       testRequestPruneBefore,
+		"RequestSizeByUser":        testRequestSizeByUser,
+		"RequestListPage":          testRequestListPage,
+		"RequestEach":              testRequestEach,
+		"RequestInsertMany":        testRequestInsertMany,
+		"ReplayInsertAndList":      testReplayInsertAndList,
+		"ReplayCascade":            testReplayCascade,
+		"ReplayJobLifecycle":       testReplayJobLifecycle,
+		"ReplayJobFailUnfinished":  testReplayJobFailUnfinished,
+		"ReplayJobCascade":         testReplayJobCascade,
+		"ComparisonInsertAndList":  testComparisonInsertAndList,
+		"ComparisonBatch":          testComparisonBatch,
+		"ComparisonCascade":        testComparisonCascade,
+		"DeliveryLifecycle":        testDeliveryLifecycle,
+		"DeliveryFailUnfinished":   testDeliveryFailUnfinished,
+		"DeliveryCascade":          testDeliveryCascade,
+		"LoadRunLifecycle":         testLoadRunLifecycle,
+		"LoadRunFailUnfinished":    testLoadRunFailUnfinished,
+		"LoadRunCascade":           testLoadRunCascade,
+		"CallbackActions":          testCallbackActions,
+		"CallbackLifecycle":        testCallbackLifecycle,
+		"CallbackFailUnfinished":   testCallbackFailUnfinished,
+		"CallbackCascade":          testCallbackCascade,
+		"ScriptState":              testScriptState,
+		"UserCreateAndLookup":      testUserCreateAndLookup,
+		"UserUpdate":               testUserUpdate,
+		"ConcurrentRequestInserts": testConcurrentRequestInserts,
+	}
+	for name, fn := range tests {
+		t.Run(name, func(t *testing.T) {
+			fn(t, newRepos(t))
+		})
+	}
+}
+
+var base = time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+
+func newWebhook(id string, userID int) *models.Webhook {
+	ct := "application/json"
+	payload := `{"ok":true}`
+	return &models.Webhook{
+		ID:              id,
+		Title:           "hook " + id,
+		ResponseCode:    201,
+		ResponseDelay:   5,
+		ContentType:     &ct,
+		Payload:         &payload,
+		ResponseHeaders: datatypes.JSONMap{"X-Test": "1"},
+		UserID:          userID,
+		CreatedAt:       base,
+		UpdatedAt:       base,
+	}
+}
+
+func newRequest(id, webhookID string, receivedAt time.Time) *models.WebhookRequest {
+	wr := &models.WebhookRequest{
+		ID:         id,
+		WebhookID:  webhookID,
+		Method:     "POST",
+		Headers:    datatypes.JSONMap{"Content-Type": "application/json"},
+		Query:      datatypes.JSONMap{"a": "1"},
+		Body:       `{"event":"test"}`,
+		ReceivedAt: receivedAt,
+	}
+	wr.Size = wr.ComputeSize()
+	return wr
+}
+
+func requestIDs(list []models.WebhookRequest) []string {
+	ids := make([]string, 0, len(list))
+	for _, wr := range list {
+		ids = append(ids, wr.ID)
+	}
+	return ids
+}
+
+func testWebhookInsertAndGet(t *testing.T, r Repos) {
+	wh := newWebhook("w1", 7)
+	require.NoError(t, r.Webhooks.Insert(wh))
+
+	got, err := r.Webhooks.Get("w1")
+	require.NoError(t, err)
+	assert.Equal(t, "hook w1", got.Title)
+	assert.Equal(t, 201, got.ResponseCode)
+	assert.Equal(t, uint(5), got.ResponseDelay)
+	assert.Equal(t, "application/json", *got.ContentType)
+	assert.Equal(t, `{"ok":true}`, *got.Payload)
+	assert.Equal(t, "1", got.ResponseHeaders["X-Test"])
+	assert.Equal(t, 7, got.UserID)
+	assert.True(t, base.Equal(got.CreatedAt))
+
+	_, err = r.Webhooks.Get("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func testWebhookOwnership(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+
+	got, err := r.Webhooks.GetByUser("w1", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "w1", got.ID)
+
+	_, err = r.Webhooks.GetByUser("w1", 8)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func testWebhookUpdate(t *testing.T, r Repos) {
+	wh := newWebhook("w1", 7)
+	require.NoError(t, r.Webhooks.Insert(wh))
+
+	wh.Title = "renamed"
+	wh.ResponseCode = 418
+	wh.RetentionCount = 3
+	wh.SigningScheme, wh.SigningSecret = "github", "s3cret"
+	wh.Faults = datatypes.NewJSONType(chaos.Config{ErrorPercent: 12.5, ErrorStatus: 503, FailEvery: 4})
+	wh.Script = "def handle(request):\n    return None\n"
+	require.NoError(t, r.Webhooks.Update(wh))
+
+	got, err := r.Webhooks.Get("w1")
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", got.Title)
+	assert.Equal(t, 418, got.ResponseCode)
+	assert.Equal(t, uint(3), got.RetentionCount)
+	assert.Equal(t, "github", got.SigningScheme)
+	assert.Equal(t, "s3cret", got.SigningSecret)
+	assert.Equal(t, chaos.Config{ErrorPercent: 12.5, ErrorStatus: 503, FailEvery: 4}, got.Faults.Data())
+	assert.Equal(t, wh.Script, got.Script)
+}
+
+func testWebhookDelete(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+
+	err := r.Webhooks.Delete("w1", 8)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting another user's webhook must fail")
+	_, err = r.Webhooks.Get("w1")
+	require.NoError(t, err)
+
+	require.NoError(t, r.Webhooks.Delete("w1", 7))
+	_, err = r.Webhooks.Get("w1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	_, err = r.Requests.GetByID("r1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "requests are deleted with their webhook")
+}
+
+func testWebhookListByUser(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w3", 8)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base.Add(time.Minute))))
+
+	list, err := r.Webhooks.GetAllByUser(7)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, wh := range list {
+		assert.Equal(t, 7, wh.UserID)
+		if wh.ID == "w1" {
+			assert.Equal(t, []string{"r2", "r1"}, requestIDs(wh.Requests))
+		}
+	}
+
+	all, err := r.Webhooks.GetAll()
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func testWebhookWithRequests(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 0)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Webhooks.InsertRequest(newRequest("r2", "w1", base.Add(2*time.Minute))))
+	require.NoError(t, r.Requests.Insert(newRequest("r3", "w1", base.Add(time.Minute))))
+
+	got, err := r.Webhooks.GetWithRequests("w1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r2", "r3", "r1"}, requestIDs(got.Requests))
+
+	_, err = r.Webhooks.GetWithRequests("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func testWebhookCleanPublic(t *testing.T, r Repos) {
+	old := newWebhook("old", 0)
+	old.CreatedAt = time.Now().Add(-72 * time.Hour)
+	fresh := newWebhook("fresh", 0)
+	fresh.CreatedAt = time.Now()
+	owned := newWebhook("owned", 7)
+	owned.CreatedAt = time.Now().Add(-72 * time.Hour)
+	for _, wh := range []*models.Webhook{old, fresh, owned} {
+		require.NoError(t, r.Webhooks.Insert(wh))
+	}
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "old", base)))
+
+	require.NoError(t, r.Webhooks.CleanPublic(48*time.Hour))
+
+	_, err := r.Webhooks.Get("old")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	_, err = r.Requests.GetByID("r1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	_, err = r.Webhooks.Get("fresh")
+	assert.NoError(t, err)
+	_, err = r.Webhooks.Get("owned")
+	assert.NoError(t, err)
+}
+
+func testWebhookRetentionList(t *testing.T, r Repos) {
+	keep := newWebhook("keep", 7)
+	keep.RetentionDays = 2
+	require.NoError(t, r.Webhooks.Insert(keep))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("plain", 7)))
+
+	list, err := r.Webhooks.GetAllWithRetention()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "keep", list[0].ID)
+}
+
+func testRequestInsertAndGet(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	wr := newRequest("r1", "w1", base)
+	wr.Fault, wr.FaultDelay = chaos.FaultTruncate, 250
+	wr.ScriptLog = "seen 1\n"
+	require.NoError(t, r.Requests.Insert(wr))
+	require.Error(t, r.Requests.Insert(newRequest("r1", "w1", base)), "duplicate IDs are rejected")
+	require.Error(t, r.Requests.Insert(newRequest("r9", "missing", base)), "requests need an existing webhook")
+	require.Error(t, r.Webhooks.InsertRequest(newRequest("r9", "missing", base)), "requests need an existing webhook")
+
+	got, err := r.Requests.GetByID("r1")
+	require.NoError(t, err)
+	assert.Equal(t, "POST", got.Method)
+	assert.Equal(t, "application/json", got.Headers["Content-Type"])
+	assert.Equal(t, "1", got.Query["a"])
+	assert.Equal(t, `{"event":"test"}`, got.Body)
+	assert.Equal(t, wr.Size, got.Size)
+	assert.True(t, base.Equal(got.ReceivedAt))
+	assert.Equal(t, models.SourceLive, got.Source, "source defaults to live")
+	assert.Equal(t, chaos.FaultTruncate, got.Fault)
+	assert.Equal(t, int64(250), got.FaultDelay)
+	assert.Equal(t, "seen 1\n", got.ScriptLog)
+
+	_, err = r.Requests.GetByID("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base.Add(time.Second))))
+	list, err := r.Requests.ListByWebhook("w1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r2", "r1"}, requestIDs(list))
+}
+
+func testRequestDelete(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r3", "w2", base)))
+
+	require.NoError(t, r.Requests.DeleteByID("r1"))
+	require.NoError(t, r.Requests.DeleteByID("missing"), "deleting a missing request is not an error")
+	_, err := r.Requests.GetByID("r1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	require.NoError(t, r.Requests.DeleteByWebhook("w1"))
+	list, err := r.Requests.ListByWebhook("w1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	_, err = r.Requests.GetByID("r3")
+	assert.NoError(t, err)
+}
+
+func testRequestPinning(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+
+	require.NoError(t, r.Requests.SetPinned("r1", true))
+	got, err := r.Requests.GetByID("r1")
+	require.NoError(t, err)
+	assert.True(t, got.Pinned)
+
+	require.NoError(t, r.Requests.SetPinned("r1", false))
+	got, err = r.Requests.GetByID("r1")
+	require.NoError(t, err)
+	assert.False(t, got.Pinned)
+
+	assert.True(t, errors.Is(r.Requests.SetPinned("missing", true), gorm.ErrRecordNotFound))
+}
+
+func testRequestPruneKeepLast(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, r.Requests.Insert(newRequest(fmt.Sprintf("r%d", i), "w1", base.Add(time.Duration(i)*time.Minute))))
+	}
+	require.NoError(t, r.Requests.Insert(newRequest("other", "w2", base)))
+	require.NoError(t, r.Requests.SetPinned("r0", true))
+
+	removed, err := r.Requests.PruneKeepLast("w1", 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	list, err := r.Requests.ListByWebhook("w1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r4", "r3", "r0"}, requestIDs(list), "the newest two and pinned requests survive")
+	_, err = r.Requests.GetByID("other")
+	assert.NoError(t, err)
+}
+
+func testRequestPruneBefore(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("old", "w1", base.Add(-48*time.Hour))))
+	require.NoError(t, r.Requests.Insert(newRequest("pinned", "w1", base.Add(-48*time.Hour))))
+	require.NoError(t, r.Requests.Insert(newRequest("new", "w1", base)))
+	require.NoError(t, r.Requests.SetPinned("pinned", true))
+
+	removed, err := r.Requests.PruneBefore("w1", base.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	list, err := r.Requests.ListByWebhook("w1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"pinned", "new"}, requestIDs(list))
+}
+
+func testRequestSizeByUser(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 8)))
+	a := newRequest("r1", "w1", base)
+	b := newRequest("r2", "w1", base)
+	require.NoError(t, r.Requests.Insert(a))
+	require.NoError(t, r.Requests.Insert(b))
+	require.NoError(t, r.Requests.Insert(newRequest("r3", "w2", base)))
+
+	size, err := r.Requests.SizeByUser(7)
+	require.NoError(t, err)
+	assert.Equal(t, a.Size+b.Size, size)
+
+	size, err = r.Requests.SizeByUser(99)
+	require.NoError(t, err)
+	assert.Zero(t, size)
+}
+
+func testRequestListPage(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, r.Requests.Insert(newRequest(fmt.Sprintf("r%d", i), "w1", base.Add(time.Duration(i)*time.Minute))))
+	}
+
+	page, total, err := r.Requests.ListPageByWebhook("w1", 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, []string{"r4", "r3"}, requestIDs(page))
+
+	page, _, err = r.Requests.ListPageByWebhook("w1", 4, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r0"}, requestIDs(page))
+
+	page, total, err = r.Requests.ListPageByWebhook("w1", 10, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.NotNil(t, page)
+	assert.Empty(t, page)
+}
+
+func testRequestEach(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	// r3 and r4 share a timestamp, so paging must break ties by ID
+	times := []time.Duration{4, 0, 2, 3, 3, 1}
+	for i, d := range times {
+		wr := newRequest(fmt.Sprintf("r%d", i), "w1", base.Add(d*time.Minute))
+		if i%2 == 1 {
+			wr.Method = "GET"
+		}
+		require.NoError(t, r.Requests.Insert(wr))
+	}
+	require.NoError(t, r.Requests.Insert(newRequest("other", "w2", base)))
+
+	collect := func(f repository.RequestFilter, size int) ([]string, int) {
+		var ids []string
+		batches := 0
+		err := r.Requests.EachByWebhook("w1", f, size, func(batch []models.WebhookRequest) error {
+			assert.LessOrEqual(t, len(batch), size)
+			ids = append(ids, requestIDs(batch)...)
+			batches++
+			return nil
+		})
+		require.NoError(t, err)
+		return ids, batches
+	}
+
+	ids, batches := collect(repository.RequestFilter{}, 2)
+	assert.Equal(t, []string{"r1", "r5", "r2", "r3", "r4", "r0"}, ids, "oldest first")
+	assert.Equal(t, 3, batches)
+
+	ids, _ = collect(repository.RequestFilter{Since: base.Add(2 * time.Minute), Until: base.Add(4 * time.Minute)}, 10)
+	assert.Equal(t, []string{"r2", "r3", "r4"}, ids)
+
+	ids, _ = collect(repository.RequestFilter{Methods: []string{"GET"}}, 1)
+	assert.Equal(t, []string{"r1", "r5", "r3"}, ids)
+
+	ids, _ = collect(repository.RequestFilter{IDs: []string{"r4", "r0", "other"}}, 10)
+	assert.Equal(t, []string{"r4", "r0"}, ids, "IDs of other webhooks don't match")
+
+	stop := errors.New("stop")
+	calls := 0
+	err := r.Requests.EachByWebhook("w1", repository.RequestFilter{}, 2, func([]models.WebhookRequest) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func testRequestInsertMany(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("live", "w1", base)))
+	require.NoError(t, r.Requests.InsertMany(nil))
+
+	var batch []models.WebhookRequest
+	for i := range 3 {
+		wr := newRequest(fmt.Sprintf("i%d", i), "w1", base.Add(time.Duration(i+1)*time.Minute))
+		wr.Source = models.SourceImport
+		batch = append(batch, *wr)
+	}
+	require.NoError(t, r.Requests.InsertMany(batch))
+
+	got, err := r.Requests.GetByID("i1")
+	require.NoError(t, err)
+	assert.Equal(t, models.SourceImport, got.Source)
+	assert.Equal(t, `{"event":"test"}`, got.Body)
+
+	var ids []string
+	err = r.Requests.EachByWebhook("w1", repository.RequestFilter{Source: models.SourceImport}, 10, func(b []models.WebhookRequest) error {
+		ids = append(ids, requestIDs(b)...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i0", "i1", "i2"}, ids)
+
+	require.Error(t, r.Requests.InsertMany([]models.WebhookRequest{*newRequest("orphan", "missing", base)}), "requests need an existing webhook")
+
+	// one clash rejects the whole batch
+	clash := []models.WebhookRequest{*newRequest("new", "w1", base), *newRequest("i0", "w1", base)}
+	require.Error(t, r.Requests.InsertMany(clash))
+	_, err = r.Requests.GetByID("new")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "nothing from a failed batch is stored")
+}
+
+func newReplay(id, requestID string, createdAt time.Time) *models.ReplayAttempt {
+	return &models.ReplayAttempt{
+		ID:              id,
+		RequestID:       requestID,
+		Method:          "POST",
+		URL:             "http://localhost:8080/hooks?a=1",
+		RequestHeaders:  datatypes.JSONMap{"Content-Type": "application/json"},
+		RequestBody:     `{"event":"test"}`,
+		StatusCode:      202,
+		ResponseHeaders: datatypes.JSONMap{"X-Handled": "yes"},
+		ResponseBody:    "ok",
+		DurationMs:      12,
+		CreatedAt:       createdAt,
+	}
+}
+
+func replayIDs(list []models.ReplayAttempt) []string {
+	ids := make([]string, len(list))
+	for i, a := range list {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func testReplayInsertAndList(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base)))
+
+	require.NoError(t, r.Replays.Insert(newReplay("a1", "r1", base)))
+	require.NoError(t, r.Replays.Insert(newReplay("a2", "r1", base.Add(time.Second))))
+	require.NoError(t, r.Replays.Insert(newReplay("a3", "r2", base)))
+	require.Error(t, r.Replays.Insert(newReplay("a1", "r1", base)), "duplicate IDs are rejected")
+	require.Error(t, r.Replays.Insert(newReplay("a4", "missing", base)), "attempts need an existing request")
+
+	got, err := r.Replays.GetByID("a1")
+	require.NoError(t, err)
+	assert.Equal(t, "r1", got.RequestID)
+	assert.Equal(t, "http://localhost:8080/hooks?a=1", got.URL)
+	assert.Equal(t, "application/json", got.RequestHeaders["Content-Type"])
+	assert.Equal(t, 202, got.StatusCode)
+	assert.Equal(t, "yes", got.ResponseHeaders["X-Handled"])
+	assert.Equal(t, "ok", got.ResponseBody)
+	assert.Equal(t, int64(12), got.DurationMs)
+	assert.True(t, base.Equal(got.CreatedAt))
+
+	_, err = r.Replays.GetByID("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	list, err := r.Replays.ListByRequest("r1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a2", "a1"}, replayIDs(list))
+	list, err = r.Replays.ListByRequest("missing")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func testReplayCascade(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	for i, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, r.Requests.Insert(newRequest(id, "w1", base.Add(time.Duration(i)*time.Hour))))
+		require.NoError(t, r.Replays.Insert(newReplay("a"+id, id, base)))
+	}
+	require.NoError(t, r.Requests.Insert(newRequest("r4", "w2", base)))
+	require.NoError(t, r.Replays.Insert(newReplay("ar4", "r4", base)))
+
+	count := func(requestID string) int {
+		list, err := r.Replays.ListByRequest(requestID)
+		require.NoError(t, err)
+		return len(list)
+	}
+
+	require.NoError(t, r.Requests.DeleteByID("r1"))
+	assert.Zero(t, count("r1"), "deleting a request deletes its attempts")
+	_, err := r.Requests.PruneKeepLast("w1", 1)
+	require.NoError(t, err)
+	assert.Zero(t, count("r2"), "pruning deletes attempts")
+	assert.Equal(t, 1, count("r3"))
+	require.NoError(t, r.Webhooks.Delete("w1", 7))
+	assert.Zero(t, count("r3"), "deleting a webhook deletes its attempts")
+	assert.Equal(t, 1, count("r4"))
+}
+
+func newJob(id, webhookID string, createdAt time.Time) *models.ReplayJob {
+	return &models.ReplayJob{
+		ID:          id,
+		WebhookID:   webhookID,
+		Target:      "http://localhost:8080/hooks",
+		Mode:        models.ReplayModeRate,
+		Rate:        2.5,
+		Concurrency: 4,
+		Resign:      true,
+		Status:      models.ReplayJobRunning,
+		Total:       2,
+		CreatedAt:   createdAt,
+	}
+}
+
+func jobItems(requestIDs ...string) []models.ReplayJobItem {
+	items := make([]models.ReplayJobItem, len(requestIDs))
+	for i, id := range requestIDs {
+		items[i] = models.ReplayJobItem{Seq: i, RequestID: id, OffsetMs: int64(i) * 1000, Status: models.ReplayItemPending}
+	}
+	return items
+}
+
+func testReplayJobLifecycle(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	created := jobItems("r1", "r2")
+	require.NoError(t, r.Jobs.Create(newJob("j1", "w1", base), created))
+	assert.Equal(t, "j1", created[1].JobID, "items are tied to the job")
+	require.NoError(t, r.Jobs.Create(newJob("j2", "w1", base.Add(time.Second)), nil))
+	require.Error(t, r.Jobs.Create(newJob("j1", "w1", base), nil), "duplicate IDs are rejected")
+	require.Error(t, r.Jobs.Create(newJob("j3", "missing", base), nil), "jobs need an existing webhook")
+
+	got, err := r.Jobs.GetByID("j1")
+	require.NoError(t, err)
+	assert.Equal(t, "w1", got.WebhookID)
+	assert.Equal(t, 2.5, got.Rate)
+	assert.Equal(t, 4, got.Concurrency)
+	assert.True(t, got.Resign)
+	assert.Equal(t, models.ReplayJobRunning, got.Status)
+	assert.Nil(t, got.FinishedAt)
+	_, err = r.Jobs.GetByID("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	list, err := r.Jobs.ListByWebhook("w1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "j2", list[0].ID, "newest first")
+
+	sent := base.Add(time.Minute)
+	item := models.ReplayJobItem{JobID: "j1", Seq: 1, RequestID: "r2", OffsetMs: 1000, Status: models.ReplayItemFailed,
+		AttemptID: "a1", StatusCode: 500, DurationMs: 7, SentAt: &sent}
+	require.NoError(t, r.Jobs.UpdateItem(&item))
+	require.Error(t, r.Jobs.UpdateItem(&models.ReplayJobItem{JobID: "j1", Seq: 5}))
+	items, err := r.Jobs.ListItems("j1")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "r1", items[0].RequestID)
+	assert.Equal(t, models.ReplayItemPending, items[0].Status)
+	assert.Equal(t, models.ReplayItemFailed, items[1].Status)
+	assert.Equal(t, 500, items[1].StatusCode)
+	assert.True(t, sent.Equal(*items[1].SentAt))
+
+	got.Status, got.Failed, got.Succeeded, got.FinishedAt = models.ReplayJobCompleted, 1, 1, &sent
+	require.NoError(t, r.Jobs.Update(got))
+	got, err = r.Jobs.GetByID("j1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ReplayJobCompleted, got.Status)
+	assert.Equal(t, 1, got.Failed)
+	assert.True(t, sent.Equal(*got.FinishedAt))
+	assert.True(t, errors.Is(r.Jobs.Update(newJob("missing", "w1", base)), gorm.ErrRecordNotFound))
+}
+
+func testReplayJobFailUnfinished(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	for id, status := range map[string]string{"j1": models.ReplayJobRunning, "j2": models.ReplayJobPaused, "j3": models.ReplayJobCompleted} {
+		job := newJob(id, "w1", base)
+		job.Status = status
+		require.NoError(t, r.Jobs.Create(job, nil))
+	}
+
+	n, err := r.Jobs.FailUnfinished("server restarted", base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	for id, status := range map[string]string{"j1": models.ReplayJobFailed, "j2": models.ReplayJobFailed, "j3": models.ReplayJobCompleted} {
+		job, err := r.Jobs.GetByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, status, job.Status, id)
+	}
+	job, _ := r.Jobs.GetByID("j1")
+	assert.Equal(t, "server restarted", job.Error)
+	require.NotNil(t, job.FinishedAt)
+}
+
+func testReplayJobCascade(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	require.NoError(t, r.Jobs.Create(newJob("j1", "w1", base), jobItems("r1")))
+	require.NoError(t, r.Jobs.Create(newJob("j2", "w2", base), jobItems("r2")))
+
+	require.NoError(t, r.Webhooks.Delete("w1", 7))
+	_, err := r.Jobs.GetByID("j1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a webhook deletes its jobs")
+	items, err := r.Jobs.ListItems("j1")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	_, err = r.Jobs.GetByID("j2")
+	assert.NoError(t, err)
+}
+
+func newComparison(id, requestID, batchID string, createdAt time.Time) *models.Comparison {
+	return &models.Comparison{
+		ID:              id,
+		WebhookID:       "w1",
+		RequestID:       requestID,
+		BatchID:         batchID,
+		BaselineURL:     "http://old.internal/hooks",
+		CandidateURL:    "http://new.internal/hooks",
+		Ignore:          datatypes.JSONSlice[string]{"body.id"},
+		BaselineStatus:  200,
+		CandidateStatus: 201,
+		Differences: datatypes.JSONSlice[compare.Difference]{
+			{Path: "status", Change: compare.Changed, Baseline: 200, Candidate: 201},
+			{Path: "body.total", Change: compare.Removed, Baseline: "10"},
+		},
+		CreatedAt: createdAt,
+	}
+}
+
+func comparisonIDs(list []models.Comparison) []string {
+	ids := make([]string, len(list))
+	for i, c := range list {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func testComparisonInsertAndList(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base)))
+
+	require.NoError(t, r.Compares.Insert(newComparison("c1", "r1", "", base)))
+	require.NoError(t, r.Compares.Insert(newComparison("c2", "r1", "b1", base.Add(time.Second))))
+	require.NoError(t, r.Compares.Insert(newComparison("c3", "r2", "b1", base)))
+	require.Error(t, r.Compares.Insert(newComparison("c1", "r1", "", base)), "duplicate IDs are rejected")
+	require.Error(t, r.Compares.Insert(newComparison("c4", "missing", "", base)), "comparisons need an existing request")
+
+	got, err := r.Compares.GetByID("c1")
+	require.NoError(t, err)
+	assert.Equal(t, "r1", got.RequestID)
+	assert.Equal(t, "http://new.internal/hooks", got.CandidateURL)
+	assert.Equal(t, []string{"body.id"}, []string(got.Ignore))
+	assert.Equal(t, 201, got.CandidateStatus)
+	require.Len(t, got.Differences, 2)
+	assert.Equal(t, "body.total", got.Differences[1].Path)
+	assert.Equal(t, compare.Removed, got.Differences[1].Change)
+	assert.Equal(t, "10", got.Differences[1].Baseline)
+	assert.Nil(t, got.Differences[1].Candidate)
+	assert.True(t, base.Equal(got.CreatedAt))
+	_, err = r.Compares.GetByID("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	list, err := r.Compares.ListByRequest("r1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c2", "c1"}, comparisonIDs(list), "newest first")
+	list, err = r.Compares.ListByBatch("b1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c3", "c2"}, comparisonIDs(list), "oldest first")
+	list, err = r.Compares.ListByRequest("missing")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func newComparisonBatch(id, webhookID string, createdAt time.Time) *models.ComparisonBatch {
+	return &models.ComparisonBatch{
+		ID:           id,
+		WebhookID:    webhookID,
+		BaselineURL:  "http://old.internal/hooks",
+		CandidateURL: "http://new.internal/hooks",
+		Ignore:       datatypes.JSONSlice[string]{"headers.X-Request-Id"},
+		Resign:       true,
+		Concurrency:  4,
+		Status:       models.ComparisonBatchRunning,
+		Total:        3,
+		CreatedAt:    createdAt,
+	}
+}
+
+func testComparisonBatch(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Compares.CreateBatch(newComparisonBatch("b1", "w1", base)))
+	require.NoError(t, r.Compares.CreateBatch(newComparisonBatch("b2", "w1", base.Add(time.Second))))
+	require.Error(t, r.Compares.CreateBatch(newComparisonBatch("b1", "w1", base)), "duplicate IDs are rejected")
+	require.Error(t, r.Compares.CreateBatch(newComparisonBatch("b3", "missing", base)), "batches need an existing webhook")
+
+	got, err := r.Compares.GetBatch("b1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"headers.X-Request-Id"}, []string(got.Ignore))
+	assert.True(t, got.Resign)
+	assert.Equal(t, models.ComparisonBatchRunning, got.Status)
+	assert.Nil(t, got.FinishedAt)
+	_, err = r.Compares.GetBatch("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	list, err := r.Compares.ListBatches("w1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "b2", list[0].ID, "newest first")
+
+	done := base.Add(time.Minute)
+	got.Status, got.Equal, got.Different, got.Failed, got.FinishedAt = models.ComparisonBatchCompleted, 1, 1, 1, &done
+	require.NoError(t, r.Compares.UpdateBatch(got))
+	got, err = r.Compares.GetBatch("b1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ComparisonBatchCompleted, got.Status)
+	assert.Equal(t, 3, got.Compared())
+	assert.True(t, done.Equal(*got.FinishedAt))
+	assert.True(t, errors.Is(r.Compares.UpdateBatch(newComparisonBatch("missing", "w1", base)), gorm.ErrRecordNotFound))
+
+	n, err := r.Compares.FailUnfinishedBatches("server restarted", base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	got, err = r.Compares.GetBatch("b2")
+	require.NoError(t, err)
+	assert.Equal(t, models.ComparisonBatchFailed, got.Status)
+	assert.Equal(t, "server restarted", got.Error)
+	require.NotNil(t, got.FinishedAt)
+	got, _ = r.Compares.GetBatch("b1")
+	assert.Equal(t, models.ComparisonBatchCompleted, got.Status)
+}
+
+func testComparisonCascade(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r3", "w2", base)))
+	require.NoError(t, r.Compares.CreateBatch(newComparisonBatch("b1", "w1", base)))
+	require.NoError(t, r.Compares.CreateBatch(newComparisonBatch("b2", "w2", base)))
+	for _, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, r.Compares.Insert(newComparison("c"+id, id, "", base)))
+	}
+
+	require.NoError(t, r.Requests.DeleteByID("r1"))
+	_, err := r.Compares.GetByID("cr1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a request deletes its comparisons")
+	require.NoError(t, r.Webhooks.Delete("w1", 7))
+	_, err = r.Compares.GetByID("cr2")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a webhook deletes its comparisons")
+	_, err = r.Compares.GetBatch("b1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a webhook deletes its batches")
+	_, err = r.Compares.GetByID("cr3")
+	assert.NoError(t, err)
+	_, err = r.Compares.GetBatch("b2")
+	assert.NoError(t, err)
+}
+
+func newDelivery(id, webhookID, requestID string, createdAt time.Time) *models.Delivery {
+	return &models.Delivery{
+		ID:        id,
+		WebhookID: webhookID,
+		RequestID: requestID,
+		Target:    "http://localhost:8080/hooks",
+		Policy:    models.RetryCustom,
+		Schedule:  datatypes.JSONSlice[int64]{100, 2000},
+		Sign:      true,
+		TimeoutMs: 5000,
+		BudgetMs:  60000,
+		Status:    models.DeliveryDelivering,
+		CreatedAt: createdAt,
+	}
+}
+
+func testDeliveryLifecycle(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Deliveries.Create(newDelivery("d1", "w1", "r1", base)))
+	require.NoError(t, r.Deliveries.Create(newDelivery("d2", "w1", "r1", base.Add(time.Second))))
+	require.Error(t, r.Deliveries.Create(newDelivery("d1", "w1", "r1", base)), "duplicate IDs are rejected")
+	require.Error(t, r.Deliveries.Create(newDelivery("d3", "w1", "missing", base)), "deliveries need an existing request")
+
+	got, err := r.Deliveries.GetByID("d1")
+	require.NoError(t, err)
+	assert.Equal(t, "r1", got.RequestID)
+	assert.Equal(t, []int64{100, 2000}, []int64(got.Schedule))
+	assert.Equal(t, 3, got.MaxAttempts())
+	assert.True(t, got.Sign)
+	assert.Equal(t, int64(60000), got.BudgetMs)
+	assert.Nil(t, got.NextAttemptAt)
+	_, err = r.Deliveries.GetByID("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	list, err := r.Deliveries.ListByWebhook("w1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "d2", list[0].ID, "newest first")
+
+	require.NoError(t, r.Deliveries.AddAttempt(&models.DeliveryAttempt{DeliveryID: "d1", Seq: 2, AttemptID: "a2", Succeeded: true, StatusCode: 200, SentAt: base.Add(time.Minute)}))
+	require.NoError(t, r.Deliveries.AddAttempt(&models.DeliveryAttempt{DeliveryID: "d1", Seq: 1, AttemptID: "a1", StatusCode: 503, DurationMs: 7, SentAt: base}))
+	require.Error(t, r.Deliveries.AddAttempt(&models.DeliveryAttempt{DeliveryID: "d1", Seq: 1}), "attempts are numbered once")
+	attempts, err := r.Deliveries.ListAttempts("d1")
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	assert.Equal(t, "a1", attempts[0].AttemptID, "in order")
+	assert.Equal(t, 503, attempts[0].StatusCode)
+	assert.False(t, attempts[0].Succeeded)
+	assert.True(t, attempts[1].Succeeded)
+	assert.True(t, base.Add(time.Minute).Equal(attempts[1].SentAt))
+
+	next := base.Add(time.Hour)
+	got.Attempts, got.StatusCode, got.NextAttemptAt = 1, 503, &next
+	require.NoError(t, r.Deliveries.Update(got))
+	got, err = r.Deliveries.GetByID("d1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Attempts)
+	require.NotNil(t, got.NextAttemptAt)
+	assert.True(t, next.Equal(*got.NextAttemptAt))
+	got.Status, got.NextAttemptAt, got.FinishedAt = models.DeliverySucceeded, nil, &next
+	require.NoError(t, r.Deliveries.Update(got))
+	got, err = r.Deliveries.GetByID("d1")
+	require.NoError(t, err)
+	assert.True(t, got.Done())
+	assert.Nil(t, got.NextAttemptAt)
+	assert.True(t, errors.Is(r.Deliveries.Update(newDelivery("missing", "w1", "r1", base)), gorm.ErrRecordNotFound))
+}
+
+func testDeliveryFailUnfinished(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	next := base.Add(time.Minute)
+	for id, status := range map[string]string{"d1": models.DeliveryDelivering, "d2": models.DeliverySucceeded} {
+		d := newDelivery(id, "w1", "r1", base)
+		d.Status, d.NextAttemptAt = status, &next
+		require.NoError(t, r.Deliveries.Create(d))
+	}
+
+	n, err := r.Deliveries.FailUnfinished("server restarted", base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	d, err := r.Deliveries.GetByID("d1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryFailed, d.Status)
+	assert.Equal(t, "server restarted", d.Error)
+	assert.Nil(t, d.NextAttemptAt)
+	require.NotNil(t, d.FinishedAt)
+	d, err = r.Deliveries.GetByID("d2")
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliverySucceeded, d.Status)
+}
+
+func testDeliveryCascade(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r3", "w2", base)))
+	for _, id := range []string{"r1", "r2", "r3"} {
+		wr, err := r.Requests.GetByID(id)
+		require.NoError(t, err)
+		require.NoError(t, r.Deliveries.Create(newDelivery("d"+id, wr.WebhookID, id, base)))
+		require.NoError(t, r.Deliveries.AddAttempt(&models.DeliveryAttempt{DeliveryID: "d" + id, Seq: 1, SentAt: base}))
+	}
+
+	require.NoError(t, r.Requests.DeleteByID("r1"))
+	_, err := r.Deliveries.GetByID("dr1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting an event deletes its deliveries")
+	attempts, err := r.Deliveries.ListAttempts("dr1")
+	require.NoError(t, err)
+	assert.Empty(t, attempts)
+	require.NoError(t, r.Webhooks.Delete("w1", 7))
+	_, err = r.Deliveries.GetByID("dr2")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a webhook deletes its deliveries")
+	_, err = r.Deliveries.GetByID("dr3")
+	assert.NoError(t, err)
+}
+
+func newLoadRun(id, webhookID string, createdAt time.Time) *models.LoadRun {
+	return &models.LoadRun{
+		ID:          id,
+		WebhookID:   webhookID,
+		Template:    "github.push",
+		Sign:        true,
+		Target:      "http://localhost:8080/hooks",
+		Concurrency: 8,
+		Rate:        12.5,
+		DurationMs:  30000,
+		TimeoutMs:   5000,
+		Status:      models.LoadRunning,
+		CreatedAt:   createdAt,
+	}
+}
+
+func testLoadRunLifecycle(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.LoadRuns.Create(newLoadRun("l1", "w1", base)))
+	require.NoError(t, r.LoadRuns.Create(newLoadRun("l2", "w1", base.Add(time.Second))))
+	require.Error(t, r.LoadRuns.Create(newLoadRun("l1", "w1", base)), "duplicate IDs are rejected")
+	require.Error(t, r.LoadRuns.Create(newLoadRun("l3", "missing", base)), "runs need an existing webhook")
+	run := newLoadRun("l4", "w1", base)
+	run.Template, run.RequestID = "", "gone"
+	require.NoError(t, r.LoadRuns.Create(run), "the captured request may be gone")
+
+	got, err := r.LoadRuns.GetByID("l1")
+	require.NoError(t, err)
+	assert.Equal(t, "github.push", got.Template)
+	assert.Equal(t, 12.5, got.Rate)
+	assert.Equal(t, int64(30000), got.DurationMs)
+	assert.False(t, got.Done())
+	_, err = r.LoadRuns.GetByID("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	list, err := r.LoadRuns.ListByWebhook("w1")
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, "l2", list[0].ID, "newest first")
+
+	finished := base.Add(time.Minute)
+	got.SetSummary(load.Summary{
+		Requests:    120,
+		Succeeded:   100,
+		Failed:      15,
+		Errors:      5,
+		StatusCodes: []load.StatusCount{{Code: 200, Count: 100}, {Code: 503, Count: 15}},
+		ErrorCounts: []load.ErrorCount{{Error: "connection refused", Count: 5}},
+		P50:         1500 * time.Microsecond,
+		P99:         40 * time.Millisecond,
+		Elapsed:     30 * time.Second,
+		Throughput:  4,
+	})
+	got.Status, got.FinishedAt = models.LoadCompleted, &finished
+	require.NoError(t, r.LoadRuns.Update(got))
+	got, err = r.LoadRuns.GetByID("l1")
+	require.NoError(t, err)
+	assert.True(t, got.Done())
+	assert.Equal(t, 120, got.Requests)
+	assert.Equal(t, 5, got.Errors)
+	assert.Equal(t, []load.StatusCount{{Code: 200, Count: 100}, {Code: 503, Count: 15}}, []load.StatusCount(got.StatusCodes))
+	assert.Equal(t, []load.ErrorCount{{Error: "connection refused", Count: 5}}, []load.ErrorCount(got.ErrorCounts))
+	assert.Equal(t, 1.5, got.P50Ms)
+	assert.Equal(t, 40.0, got.P99Ms)
+	assert.Equal(t, int64(30000), got.ElapsedMs)
+	assert.Equal(t, 4.0, got.Throughput)
+	require.NotNil(t, got.FinishedAt)
+	assert.True(t, finished.Equal(*got.FinishedAt))
+	assert.True(t, errors.Is(r.LoadRuns.Update(newLoadRun("missing", "w1", base)), gorm.ErrRecordNotFound))
+}
+
+func testLoadRunFailUnfinished(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	for id, status := range map[string]string{"l1": models.LoadRunning, "l2": models.LoadCancelled} {
+		run := newLoadRun(id, "w1", base)
+		run.Status = status
+		require.NoError(t, r.LoadRuns.Create(run))
+	}
+
+	n, err := r.LoadRuns.FailUnfinished("server restarted", base.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	run, err := r.LoadRuns.GetByID("l1")
+	require.NoError(t, err)
+	assert.Equal(t, models.LoadFailed, run.Status)
+	assert.Equal(t, "server restarted", run.Error)
+	require.NotNil(t, run.FinishedAt)
+	run, err = r.LoadRuns.GetByID("l2")
+	require.NoError(t, err)
+	assert.Equal(t, models.LoadCancelled, run.Status)
+}
+
+func testLoadRunCascade(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w2", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	run := newLoadRun("l1", "w1", base)
+	run.Template, run.RequestID = "", "r1"
+	require.NoError(t, r.LoadRuns.Create(run))
+	require.NoError(t, r.LoadRuns.Create(newLoadRun("l2", "w2", base)))
+
+	require.NoError(t, r.Requests.DeleteByID("r1"))
+	_, err := r.LoadRuns.GetByID("l1")
+	assert.NoError(t, err, "runs outlive their captured request")
+	require.NoError(t, r.Webhooks.Delete("w1", 7))
+	_, err = r.LoadRuns.GetByID("l1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a webhook deletes its runs")
+	_, err = r.LoadRuns.GetByID("l2")
+	assert.NoError(t, err)
+}
+
+func newCallbackAction(id, webhookID string, createdAt time.Time) *models.CallbackAction {
+	return &models.CallbackAction{
+		ID:        id,
+		WebhookID: webhookID,
+		Name:      "payment status",
+		Enabled:   true,
+		DelayMs:   2000,
+		Method:    "POST",
+		URLFrom:   "body.callback_url",
+		Headers:   datatypes.JSONMap{"Content-Type": "application/json"},
+		Body:      `{"id":{{json "body.id"}},"status":"paid"}`,
+		TimeoutMs: 5000,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+}
+
+func newCallback(id, actionID, requestID, webhookID string, createdAt time.Time) *models.Callback {
+	return &models.Callback{
+		ID:         id,
+		WebhookID:  webhookID,
+		ActionID:   actionID,
+		ActionName: "payment status",
+		RequestID:  requestID,
+		Status:     models.CallbackScheduled,
+		DueAt:      createdAt.Add(2 * time.Second),
+		CreatedAt:  createdAt,
+	}
+}
+
+func testCallbackActions(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Callbacks.CreateAction(newCallbackAction("a2", "w1", base.Add(time.Second))))
+	require.NoError(t, r.Callbacks.CreateAction(newCallbackAction("a1", "w1", base)))
+	require.Error(t, r.Callbacks.CreateAction(newCallbackAction("a1", "w1", base)), "duplicate IDs are rejected")
+	require.Error(t, r.Callbacks.CreateAction(newCallbackAction("a3", "missing", base)), "actions need an existing webhook")
+
+	list, err := r.Callbacks.ListActions("w1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a1", list[0].ID, "oldest first")
+	assert.Equal(t, "body.callback_url", list[0].URLFrom)
+	assert.Equal(t, "application/json", list[0].Headers["Content-Type"])
+
+	a, err := r.Callbacks.GetAction("a1")
+	require.NoError(t, err)
+	a.Enabled, a.URL, a.URLFrom, a.Headers = false, "http://localhost:8080/status", "", nil
+	require.NoError(t, r.Callbacks.UpdateAction(a))
+	a, err = r.Callbacks.GetAction("a1")
+	require.NoError(t, err)
+	assert.False(t, a.Enabled)
+	assert.Equal(t, "http://localhost:8080/status", a.URL)
+	assert.Empty(t, a.URLFrom)
+	assert.Empty(t, a.Headers)
+	assert.True(t, errors.Is(r.Callbacks.UpdateAction(newCallbackAction("missing", "w1", base)), gorm.ErrRecordNotFound))
+
+	require.NoError(t, r.Callbacks.DeleteAction("a1"))
+	_, err = r.Callbacks.GetAction("a1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	assert.True(t, errors.Is(r.Callbacks.DeleteAction("a1"), gorm.ErrRecordNotFound))
+}
+
+func testCallbackLifecycle(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base)))
+	require.NoError(t, r.Callbacks.Create(newCallback("c1", "a1", "r1", "w1", base)))
+	require.NoError(t, r.Callbacks.Create(newCallback("c2", "a1", "r2", "w1", base.Add(time.Second))))
+	require.NoError(t, r.Callbacks.Create(newCallback("c3", "a2", "r1", "w1", base.Add(2*time.Second))))
+	require.Error(t, r.Callbacks.Create(newCallback("c1", "a1", "r1", "w1", base)), "duplicate IDs are rejected")
+	require.Error(t, r.Callbacks.Create(newCallback("c4", "a1", "missing", "w1", base)), "callbacks need an existing request")
+
+	list, err := r.Callbacks.ListByWebhook("w1", 2)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "c3", list[0].ID, "newest first")
+	list, err = r.Callbacks.ListByRequest("r1")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "c3", list[0].ID)
+	assert.Equal(t, "c1", list[1].ID)
+
+	c, err := r.Callbacks.GetByID("c1")
+	require.NoError(t, err)
+	assert.False(t, c.Done())
+	assert.True(t, base.Add(2*time.Second).Equal(c.DueAt))
+	sent := base.Add(2 * time.Second)
+	c.Status, c.URL, c.AttemptID, c.StatusCode, c.DurationMs, c.SentAt = models.CallbackSucceeded, "http://localhost:8080/status", "x1", 200, 12, &sent
+	require.NoError(t, r.Callbacks.Update(c))
+	c, err = r.Callbacks.GetByID("c1")
+	require.NoError(t, err)
+	assert.True(t, c.Done())
+	assert.Equal(t, "x1", c.AttemptID)
+	assert.Equal(t, 200, c.StatusCode)
+	require.NotNil(t, c.SentAt)
+	assert.True(t, sent.Equal(*c.SentAt))
+	_, err = r.Callbacks.GetByID("missing")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	assert.True(t, errors.Is(r.Callbacks.Update(newCallback("missing", "a1", "r1", "w1", base)), gorm.ErrRecordNotFound))
+}
+
+func testCallbackFailUnfinished(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	for id, status := range map[string]string{"c1": models.CallbackScheduled, "c2": models.CallbackCancelled} {
+		c := newCallback(id, "a1", "r1", "w1", base)
+		c.Status = status
+		require.NoError(t, r.Callbacks.Create(c))
+	}
+
+	n, err := r.Callbacks.FailUnfinished("server restarted")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	c, err := r.Callbacks.GetByID("c1")
+	require.NoError(t, err)
+	assert.Equal(t, models.CallbackFailed, c.Status)
+	assert.Equal(t, "server restarted", c.Error)
+	c, err = r.Callbacks.GetByID("c2")
+	require.NoError(t, err)
+	assert.Equal(t, models.CallbackCancelled, c.Status)
+}
+
+func testCallbackCascade(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	require.NoError(t, r.Requests.Insert(newRequest("r1", "w1", base)))
+	require.NoError(t, r.Requests.Insert(newRequest("r2", "w1", base)))
+	require.NoError(t, r.Callbacks.CreateAction(newCallbackAction("a1", "w1", base)))
+	require.NoError(t, r.Callbacks.Create(newCallback("c1", "a1", "r1", "w1", base)))
+	require.NoError(t, r.Callbacks.Create(newCallback("c2", "a1", "r2", "w1", base)))
+
+	require.NoError(t, r.Callbacks.DeleteAction("a1"))
+	_, err := r.Callbacks.GetByID("c1")
+	assert.NoError(t, err, "callbacks outlive their action")
+	require.NoError(t, r.Requests.DeleteByID("r1"))
+	_, err = r.Callbacks.GetByID("c1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a request deletes its callbacks")
+
+	require.NoError(t, r.Callbacks.CreateAction(newCallbackAction("a2", "w1", base)))
+	require.NoError(t, r.Webhooks.Delete("w1", 7))
+	_, err = r.Callbacks.GetByID("c2")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a webhook deletes its callbacks")
+	_, err = r.Callbacks.GetAction("a2")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a webhook deletes its actions")
+}
+
+func testScriptState(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+	_, err := r.Scripts.Get("w1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+
+	require.NoError(t, r.Scripts.Save(&models.ScriptState{WebhookID: "w1", Data: datatypes.JSONMap{"count": float64(1)}, UpdatedAt: base}))
+	require.NoError(t, r.Scripts.Save(&models.ScriptState{WebhookID: "w1", Data: datatypes.JSONMap{"count": float64(2), "last": "r2"}, UpdatedAt: base.Add(time.Second)}))
+	got, err := r.Scripts.Get("w1")
+	require.NoError(t, err)
+	data, err := json.Marshal(got.Data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count": 2, "last": "r2"}`, string(data), "saving replaces the state")
+	assert.False(t, got.UpdatedAt.IsZero())
+
+	require.NoError(t, r.Scripts.Delete("w1"))
+	_, err = r.Scripts.Get("w1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	assert.NoError(t, r.Scripts.Delete("w1"), "deleting no state is fine")
+
+	require.NoError(t, r.Scripts.Save(&models.ScriptState{WebhookID: "w1", Data: datatypes.JSONMap{}, UpdatedAt: base}))
+	require.NoError(t, r.Webhooks.Delete("w1", 7))
+	_, err = r.Scripts.Get("w1")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "deleting a webhook deletes its state")
+}
+
+func testUserCreateAndLookup(t *testing.T, r Repos) {
+	u := &models.User{FullName: "Ada", Email: "ada@example.com", APIKey: "user_key", ResetToken: "tok"}
+	require.NoError(t, r.Users.Create(u))
+	require.NotZero(t, u.ID)
+	require.Error(t, r.Users.Create(&models.User{Email: "ada@example.com"}), "emails are unique")
+
+	byID, err := r.Users.GetByID(u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada", byID.FullName)
+
+	byEmail, err := r.Users.GetByEmail("ada@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, byEmail.ID)
+
+	byKey, err := r.Users.GetByAPIKey("user_key")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, byKey.ID)
+
+	byToken, err := r.Users.GetByResetToken("tok")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, byToken.ID)
+
+	_, err = r.Users.GetByID(u.ID + 100)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	_, err = r.Users.GetByEmail("nobody@example.com")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	_, err = r.Users.GetByAPIKey("nope")
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func testUserUpdate(t *testing.T, r Repos) {
+	u := &models.User{FullName: "Ada", Email: "ada@example.com"}
+	require.NoError(t, r.Users.Create(u))
+
+	u.FullName = "Ada Lovelace"
+	u.StorageQuota = 1 << 20
+	require.NoError(t, r.Users.Update(u))
+
+	got, err := r.Users.GetByID(u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", got.FullName)
+	assert.Equal(t, int64(1<<20), got.StorageQuota)
+}
+
+func testConcurrentRequestInserts(t *testing.T, r Repos) {
+	require.NoError(t, r.Webhooks.Insert(newWebhook("w1", 7)))
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- r.Requests.Insert(newRequest(fmt.Sprintf("r%02d", i), "w1", base.Add(time.Duration(i)*time.Second)))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	list, err := r.Requests.ListByWebhook("w1")
+	require.NoError(t, err)
+	assert.Len(t, list, n)
+}
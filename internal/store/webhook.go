@@ -142,15 +142,27 @@ func (r GormWebhookRepo) CleanPublic(d time.Duration) error {
 
 	err := r.DB.Transaction(func(tx *gorm.DB) error {
 		var webhooks []models.Webhook
-		tx.Where("created_at > ? AND user_id = 0", beforeDate).Find(&webhooks)
+		if err := tx.Where("created_at < ? AND user_id = 0", beforeDate).Find(&webhooks).Error; err != nil {
+			return err
+		}
 
 		var webhookIDs []string
 		for _, webhook := range webhooks {
 			webhookIDs = append(webhookIDs, webhook.ID)
 		}
+		if len(webhookIDs) == 0 {
+			return nil
+		}
 
 		// delete requests
 		err := tx.Where("webhook_id IN (?)", webhookIDs).Delete(&models.WebhookRequest{}).Error
+		if err != nil {
+			r.logger.Printf("Error deleting webhook requests: %v", err)
+			return err
+		}
+
+		// delete webhooks
+		err = tx.Where("id IN (?)", webhookIDs).Delete(&models.Webhook{}).Error
 		if err != nil {
 			r.logger.Printf("Error deleting webhooks: %v", err)
 			return err
@@ -165,3 +177,12 @@ func (r GormWebhookRepo) CleanPublic(d time.Duration) error {
 
 	return err
 }
+
+func (r GormWebhookRepo) GetAllWithRetention() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.DB.Where("retention_count > 0 OR retention_days > 0").Find(&webhooks).Error
+	if err != nil {
+		r.logger.Printf("failed to get webhooks with retention: %v", err)
+	}
+	return webhooks, err
+}
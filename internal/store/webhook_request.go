@@ -3,6 +3,7 @@ package store
 import (
 	"gorm.io/gorm"
 	"log"
+	"time"
 	"webhook-tester/internal/models"
 	"webhook-tester/internal/repository"
 )
@@ -16,6 +17,10 @@ type GormWebhookRequestRepo struct {
 	logger *log.Logger
 }
 
+// insertBatchSize keeps each INSERT of InsertMany under the bind parameter
+// limits of SQLite and Postgres.
+const insertBatchSize = 500
+
 // NewGormWebhookRequestRepo constructs a new repository with a logger.
 func NewGormWebhookRequestRepo(db *gorm.DB, logger *log.Logger) *GormWebhookRequestRepo {
 	return &GormWebhookRequestRepo{DB: db, logger: logger}
@@ -29,6 +34,19 @@ func (r *GormWebhookRequestRepo) Insert(req *models.WebhookRequest) error {
 	return nil
 }
 
+func (r *GormWebhookRequestRepo) InsertMany(reqs []models.WebhookRequest) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(reqs, insertBatchSize).Error
+	})
+	if err != nil {
+		r.logger.Printf("insert %d requests failed: %v", len(reqs), err)
+	}
+	return err
+}
+
 func (r *GormWebhookRequestRepo) GetByID(id string) (*models.WebhookRequest, error) {
 	var wr models.WebhookRequest
 	if err := r.DB.First(&wr, "id = ?", id).Error; err != nil {
@@ -50,6 +68,28 @@ func (r *GormWebhookRequestRepo) ListByWebhook(webhookID string) ([]models.Webho
 	return list, nil
 }
 
+func (r *GormWebhookRequestRepo) ListPageByWebhook(webhookID string, offset, limit int) ([]models.WebhookRequest, int64, error) {
+	var total int64
+	if err := r.DB.Model(&models.WebhookRequest{}).
+		Where("webhook_id = ?", webhookID).
+		Count(&total).Error; err != nil {
+		r.logger.Printf("count requests for %s failed: %v", webhookID, err)
+		return nil, 0, err
+	}
+
+	list := []models.WebhookRequest{}
+	if err := r.DB.
+		Where("webhook_id = ?", webhookID).
+		Order("received_at DESC, id").
+		Offset(offset).
+		Limit(limit).
+		Find(&list).Error; err != nil {
+		r.logger.Printf("list requests for %s failed: %v", webhookID, err)
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
 func (r *GormWebhookRequestRepo) DeleteByID(id string) error {
 	if err := r.DB.Delete(&models.WebhookRequest{}, "id = ?", id).Error; err != nil {
 		r.logger.Printf("delete request %s failed: %v", id, err)
@@ -67,3 +107,100 @@ func (r *GormWebhookRequestRepo) DeleteByWebhook(webhookID string) error {
 	}
 	return nil
 }
+
+func (r *GormWebhookRequestRepo) SetPinned(id string, pinned bool) error {
+	res := r.DB.Model(&models.WebhookRequest{}).Where("id = ?", id).Update("pinned", pinned)
+	if res.Error != nil {
+		r.logger.Printf("pin request %s failed: %v", id, res.Error)
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *GormWebhookRequestRepo) PruneKeepLast(webhookID string, n uint) (int64, error) {
+	keep := r.DB.Model(&models.WebhookRequest{}).
+		Select("id").
+		Where("webhook_id = ? AND pinned = ?", webhookID, false).
+		Order("received_at DESC").
+		Limit(int(n))
+
+	res := r.DB.
+		Where("webhook_id = ? AND pinned = ?", webhookID, false).
+		Where("id NOT IN (?)", keep).
+		Delete(&models.WebhookRequest{})
+	if res.Error != nil {
+		r.logger.Printf("prune requests for %s failed: %v", webhookID, res.Error)
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+func (r *GormWebhookRequestRepo) PruneBefore(webhookID string, t time.Time) (int64, error) {
+	res := r.DB.
+		Where("webhook_id = ? AND pinned = ? AND received_at < ?", webhookID, false, t).
+		Delete(&models.WebhookRequest{})
+	if res.Error != nil {
+		r.logger.Printf("prune requests for %s failed: %v", webhookID, res.Error)
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+func (r *GormWebhookRequestRepo) SizeByUser(userID uint) (int64, error) {
+	var total int64
+	err := r.DB.Model(&models.WebhookRequest{}).
+		Select("COALESCE(SUM(webhook_requests.size), 0)").
+		Joins("JOIN webhooks ON webhooks.id = webhook_requests.webhook_id").
+		Where("webhooks.user_id = ?", userID).
+		Scan(&total).Error
+	if err != nil {
+		r.logger.Printf("size of requests for user %d failed: %v", userID, err)
+	}
+	return total, err
+}
+
+// EachByWebhook pages through requests with a keyset on (received_at, id), so
+// each batch is an indexed range scan however deep into the table it is.
+func (r *GormWebhookRequestRepo) EachByWebhook(webhookID string, f repository.RequestFilter, batchSize int, fn func([]models.WebhookRequest) error) error {
+	var last *models.WebhookRequest
+	for {
+		q := r.DB.Where("webhook_id = ?", webhookID)
+		if !f.Since.IsZero() {
+			q = q.Where("received_at >= ?", f.Since)
+		}
+		if !f.Until.IsZero() {
+			q = q.Where("received_at < ?", f.Until)
+		}
+		if len(f.Methods) > 0 {
+			q = q.Where("method IN ?", f.Methods)
+		}
+		if f.Source != "" {
+			q = q.Where("source = ?", f.Source)
+		}
+		if len(f.IDs) > 0 {
+			q = q.Where("id IN ?", f.IDs)
+		}
+		if last != nil {
+			q = q.Where("received_at > ? OR (received_at = ? AND id > ?)", last.ReceivedAt, last.ReceivedAt, last.ID)
+		}
+
+		var batch []models.WebhookRequest
+		if err := q.Order("received_at, id").Limit(batchSize).Find(&batch).Error; err != nil {
+			r.logger.Printf("stream requests for %s failed: %v", webhookID, err)
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		last = &batch[len(batch)-1]
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 )
 
 // GenerateSecureToken returns a secure random token of n bytes, hex-encoded.
@@ -14,3 +15,17 @@ func GenerateSecureToken(n int) (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+// FormatBytes renders a byte count using binary units, e.g. "1.5 MB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
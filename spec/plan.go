@@ -0,0 +1,180 @@
+package spec
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Action is what syncing does to one webhook.
+type Action string
+
+const (
+	Create    Action = "create"
+	Update    Action = "update"
+	Delete    Action = "delete"
+	Unchanged Action = "unchanged"
+)
+
+// Change is one step of a Plan.
+type Change struct {
+	Action Action `json:"action" example:"update"`
+	ID     string `json:"id,omitempty"`
+	Title  string `json:"title"`
+	// Fields lists the JSON names of the fields an update changes.
+	Fields []string `json:"fields,omitempty"`
+	// Webhook is the desired state for creates and updates.
+	Webhook Webhook `json:"-"`
+} // @name SpecChange
+
+// Plan is the outcome of comparing a File with a server.
+type Plan struct {
+	Changes []Change `json:"changes"`
+	// Applied is false for dry runs.
+	Applied bool `json:"applied"`
+} // @name SpecPlan
+
+// Count returns how many changes carry out action a.
+func (p Plan) Count(a Action) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+// HasChanges reports whether syncing would modify anything.
+func (p Plan) HasChanges() bool {
+	return p.Count(Create)+p.Count(Update)+p.Count(Delete) > 0
+}
+
+// Diff plans the changes that turn current into desired, in file order. With
+// prune, current webhooks that no entry matches are deleted; otherwise they
+// are left alone.
+func Diff(current, desired []Webhook, prune bool) (Plan, error) {
+	byID := make(map[string]int, len(current))
+	byTitle := make(map[string][]int)
+	for i, w := range current {
+		byID[w.ID] = i
+		byTitle[w.Title] = append(byTitle[w.Title], i)
+	}
+
+	matched := make([]bool, len(current))
+	plan := Plan{Changes: []Change{}}
+	for _, d := range desired {
+		d = d.normalized()
+		i, found := byID[d.ID]
+		if d.ID == "" {
+			var candidates []int
+			for _, j := range byTitle[d.Title] {
+				if !matched[j] {
+					candidates = append(candidates, j)
+				}
+			}
+			if len(candidates) > 1 {
+				return Plan{}, fmt.Errorf("spec: title %q matches %d webhooks; give the entry an id", d.Title, len(candidates))
+			}
+			if len(candidates) == 1 {
+				i, found = candidates[0], true
+			}
+		}
+
+		if !found {
+			plan.Changes = append(plan.Changes, Change{Action: Create, ID: d.ID, Title: d.Title, Webhook: d})
+			continue
+		}
+		matched[i] = true
+		cur := current[i].normalized()
+		d.ID = cur.ID
+		change := Change{Action: Unchanged, ID: cur.ID, Title: d.Title, Webhook: d}
+		if fields := changedFields(cur, d); len(fields) > 0 {
+			change.Action, change.Fields = Update, fields
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	if prune {
+		for i, w := range current {
+			if !matched[i] {
+				plan.Changes = append(plan.Changes, Change{Action: Delete, ID: w.ID, Title: w.Title})
+			}
+		}
+	}
+	return plan, nil
+}
+
+// normalized fills in server defaults so that equal configurations compare equal.
+func (w Webhook) normalized() Webhook {
+	if w.ResponseCode == 0 {
+		w.ResponseCode = DefaultResponseCode
+	}
+	if len(w.ResponseHeaders) == 0 {
+		w.ResponseHeaders = nil
+	}
+	if len(w.Callbacks) == 0 {
+		w.Callbacks = nil
+	} else {
+		callbacks := make([]Callback, len(w.Callbacks))
+		for i, c := range w.Callbacks {
+			callbacks[i] = c.normalized()
+		}
+		w.Callbacks = callbacks
+	}
+	return w
+}
+
+// DefaultCallbackMethod is the method a callback sends when the file omits it.
+const DefaultCallbackMethod = http.MethodPost
+
+func (c Callback) normalized() Callback {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Method = strings.ToUpper(c.Method)
+	if c.Method == "" {
+		c.Method = DefaultCallbackMethod
+	}
+	if c.Enabled == nil {
+		enabled := true
+		c.Enabled = &enabled
+	}
+	if len(c.Headers) == 0 {
+		c.Headers = nil
+	}
+	return c
+}
+
+// sameCallbacks compares callbacks by name, ignoring their order.
+func sameCallbacks(a, b []Callback) bool {
+	byName := func(x, y Callback) int { return strings.Compare(x.Name, y.Name) }
+	a, b = slices.SortedFunc(slices.Values(a), byName), slices.SortedFunc(slices.Values(b), byName)
+	return reflect.DeepEqual(a, b)
+}
+
+func changedFields(a, b Webhook) []string {
+	var fields []string
+	diff := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	diff("title", a.Title != b.Title)
+	diff("response_code", a.ResponseCode != b.ResponseCode)
+	diff("response_delay", a.ResponseDelay != b.ResponseDelay)
+	diff("content_type", a.ContentType != b.ContentType)
+	diff("payload", a.Payload != b.Payload)
+	diff("response_headers", !maps.Equal(a.ResponseHeaders, b.ResponseHeaders))
+	diff("notify_on_event", a.NotifyOnEvent != b.NotifyOnEvent)
+	diff("retention_count", a.RetentionCount != b.RetentionCount)
+	diff("retention_days", a.RetentionDays != b.RetentionDays)
+	diff("signing_scheme", a.SigningScheme != b.SigningScheme)
+	// An empty secret keeps the current one.
+	diff("signing_secret", b.SigningSecret != "" && a.SigningSecret != b.SigningSecret)
+	diff("faults", a.Faults != b.Faults)
+	diff("script", a.Script != b.Script)
+	diff("callbacks", !sameCallbacks(a.Callbacks, b.Callbacks))
+	return fields
+}
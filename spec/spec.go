@@ -0,0 +1,218 @@
+// Package spec is the configuration-as-code format for webhook-tester: a
+// versioned YAML or JSON file describing a user's webhooks.
+//
+//	version: 1
+//	webhooks:
+//	  - id: orders
+//	    title: Order events
+//	    response_code: 202
+//	    retention_count: 500
+//	    signing_scheme: github
+//	    signing_secret: ${ORDERS_SECRET}
+//	    callbacks:
+//	      - name: payment status
+//	        url: http://localhost:8080/status
+//
+// Diff compares a file with the webhooks on a server and returns the Plan that
+// syncing them would carry out. Webhooks are matched by id, or by title when a
+// file entry has no id.
+//
+// Signing secrets are never exported. A file gives one as a ${NAME} reference
+// that ResolveSecrets reads from the environment of whoever applies it; an
+// entry without a secret keeps the webhook's current one.
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the only file version this package understands.
+const Version = 1
+
+// DefaultResponseCode is the status a webhook answers with when the file omits it.
+const DefaultResponseCode = 200
+
+// Formats accepted by Encode.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// File is a spec document.
+type File struct {
+	Version  int       `json:"version" yaml:"version"`
+	Webhooks []Webhook `json:"webhooks" yaml:"webhooks"`
+} // @name SpecFile
+
+// Webhook describes one webhook. Zero values mean the server default.
+type Webhook struct {
+	ID              string            `json:"id,omitempty" yaml:"id,omitempty"`
+	Title           string            `json:"title,omitempty" yaml:"title,omitempty"`
+	ResponseCode    int               `json:"response_code,omitempty" yaml:"response_code,omitempty"`
+	ResponseDelay   uint              `json:"response_delay,omitempty" yaml:"response_delay,omitempty"` // milliseconds
+	ContentType     string            `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+	Payload         string            `json:"payload,omitempty" yaml:"payload,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty" yaml:"response_headers,omitempty"`
+	NotifyOnEvent   bool              `json:"notify_on_event,omitempty" yaml:"notify_on_event,omitempty"`
+	RetentionCount  uint              `json:"retention_count,omitempty" yaml:"retention_count,omitempty"`
+	RetentionDays   uint              `json:"retention_days,omitempty" yaml:"retention_days,omitempty"`
+	SigningScheme   string            `json:"signing_scheme,omitempty" yaml:"signing_scheme,omitempty"`
+	SigningSecret   string            `json:"signing_secret,omitempty" yaml:"signing_secret,omitempty"` // a ${NAME} reference; empty keeps the current secret
+	Faults          Faults            `json:"faults,omitzero" yaml:"faults,omitempty"`
+	Script          string            `json:"script,omitempty" yaml:"script,omitempty"`
+	Callbacks       []Callback        `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
+} // @name SpecWebhook
+
+// Faults are the faults a webhook injects into its responses, see the Fault
+// injection section of the README.
+type Faults struct {
+	ErrorPercent      float64 `json:"error_percent,omitempty" yaml:"error_percent,omitempty"`
+	ErrorStatus       int     `json:"error_status,omitempty" yaml:"error_status,omitempty"`
+	FailEvery         int     `json:"fail_every,omitempty" yaml:"fail_every,omitempty"`
+	DelayMinMs        int     `json:"delay_min_ms,omitempty" yaml:"delay_min_ms,omitempty"`
+	DelayMaxMs        int     `json:"delay_max_ms,omitempty" yaml:"delay_max_ms,omitempty"`
+	DelayDistribution string  `json:"delay_distribution,omitempty" yaml:"delay_distribution,omitempty"`
+	RateLimit         int     `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	RateLimitWindowMs int     `json:"rate_limit_window_ms,omitempty" yaml:"rate_limit_window_ms,omitempty"`
+	RetryAfter        int     `json:"retry_after,omitempty" yaml:"retry_after,omitempty"`
+	TruncatePercent   float64 `json:"truncate_percent,omitempty" yaml:"truncate_percent,omitempty"`
+	DripPercent       float64 `json:"drip_percent,omitempty" yaml:"drip_percent,omitempty"`
+	DripIntervalMs    int     `json:"drip_interval_ms,omitempty" yaml:"drip_interval_ms,omitempty"`
+	DisconnectPercent float64 `json:"disconnect_percent,omitempty" yaml:"disconnect_percent,omitempty"`
+} // @name SpecFaults
+
+// Callback is a callback action of a webhook. Callbacks are matched by name,
+// and those a webhook has but its entry doesn't list are deleted.
+type Callback struct {
+	Name      string            `json:"name" yaml:"name"`
+	Enabled   *bool             `json:"enabled,omitempty" yaml:"enabled,omitempty"` // default true
+	DelayMs   int64             `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty"`
+	Method    string            `json:"method,omitempty" yaml:"method,omitempty"` // default POST
+	URL       string            `json:"url,omitempty" yaml:"url,omitempty"`
+	URLFrom   string            `json:"url_from,omitempty" yaml:"url_from,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body      string            `json:"body,omitempty" yaml:"body,omitempty"`
+	Sign      bool              `json:"sign,omitempty" yaml:"sign,omitempty"`
+	TimeoutMs int64             `json:"timeout_ms,omitempty" yaml:"timeout_ms,omitempty"`
+} // @name SpecCallback
+
+var (
+	idPattern        = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+	secretRefPattern = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+)
+
+// Parse decodes a YAML or JSON spec and validates it. Unknown fields are
+// rejected so that typos don't silently fall back to defaults.
+func Parse(data []byte) (*File, error) {
+	var f File
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			return nil, fmt.Errorf("spec: %w", err)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("spec: %w", err)
+		}
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Validate checks the version and that every entry can be matched unambiguously.
+func (f *File) Validate() error {
+	if f.Version != Version {
+		return fmt.Errorf("spec: version must be %d", Version)
+	}
+	ids := map[string]bool{}
+	titles := map[string]bool{}
+	for i, w := range f.Webhooks {
+		switch {
+		case w.ID != "" && !idPattern.MatchString(w.ID):
+			return fmt.Errorf("spec: webhooks[%d].id: must be 1-64 letters, digits, '-' or '_'", i)
+		case w.ID != "" && ids[w.ID]:
+			return fmt.Errorf("spec: webhooks[%d].id: %q is used twice", i, w.ID)
+		case w.ID == "" && w.Title == "":
+			return fmt.Errorf("spec: webhooks[%d]: needs an id or a title", i)
+		case w.ID == "" && titles[w.Title]:
+			return fmt.Errorf("spec: webhooks[%d].title: %q is used twice; give the webhooks ids", i, w.Title)
+		}
+		if w.ID != "" {
+			ids[w.ID] = true
+		} else {
+			titles[w.Title] = true
+		}
+		names := map[string]bool{}
+		for j, c := range w.Callbacks {
+			name := strings.TrimSpace(c.Name)
+			switch {
+			case name == "":
+				return fmt.Errorf("spec: webhooks[%d].callbacks[%d]: needs a name", i, j)
+			case names[name]:
+				return fmt.Errorf("spec: webhooks[%d].callbacks[%d].name: %q is used twice", i, j, name)
+			}
+			names[name] = true
+		}
+	}
+	return nil
+}
+
+// SecretRef returns the NAME of a ${NAME} secret reference.
+func SecretRef(secret string) (name string, ok bool) {
+	m := secretRefPattern.FindStringSubmatch(secret)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// ResolveSecrets replaces every ${NAME} signing secret reference with the
+// value lookup returns for NAME, such as os.LookupEnv. It is up to whoever
+// applies a file to resolve it: the server refuses unresolved references
+// rather than reading its own environment.
+func (f *File) ResolveSecrets(lookup func(string) (string, bool)) error {
+	for i := range f.Webhooks {
+		w := &f.Webhooks[i]
+		name, ok := SecretRef(w.SigningSecret)
+		if !ok {
+			continue
+		}
+		value, ok := lookup(name)
+		if !ok || value == "" {
+			return fmt.Errorf("spec: webhooks[%d].signing_secret: %s is not set", i, name)
+		}
+		w.SigningSecret = value
+	}
+	return nil
+}
+
+// Encode writes f to w as YAML or JSON.
+func Encode(w io.Writer, f *File, format string) error {
+	switch strings.ToLower(format) {
+	case FormatYAML, "yml", "":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(f)
+	}
+	return fmt.Errorf("spec: unknown format %q", format)
+}
@@ -0,0 +1,191 @@
+package spec_test
+
+import (
+	"bytes"
+	"testing"
+	"webhook-tester/spec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orders = `
+version: 1
+webhooks:
+  - id: orders
+    title: Order events
+    response_code: 202
+    response_headers:
+      X-Env: test
+    signing_scheme: github
+    faults:
+      error_percent: 12.5
+      fail_every: 4
+    script: |
+      def handle(req):
+          return {"status": 201}
+    callbacks:
+      - name: status
+        enabled: false
+        url: http://localhost:8080/status
+        headers:
+          X-Order: '{{field "body.id"}}'
+  - title: Payments
+    payload: |
+      {"ok": true}
+`
+
+func TestParse(t *testing.T) {
+	f, err := spec.Parse([]byte(orders))
+	require.NoError(t, err)
+	require.Len(t, f.Webhooks, 2)
+	assert.Equal(t, "orders", f.Webhooks[0].ID)
+	assert.Equal(t, 202, f.Webhooks[0].ResponseCode)
+	assert.Equal(t, map[string]string{"X-Env": "test"}, f.Webhooks[0].ResponseHeaders)
+	assert.Equal(t, "{\"ok\": true}\n", f.Webhooks[1].Payload)
+	assert.Equal(t, spec.Faults{ErrorPercent: 12.5, FailEvery: 4}, f.Webhooks[0].Faults)
+	assert.Contains(t, f.Webhooks[0].Script, "def handle(req)")
+	require.Len(t, f.Webhooks[0].Callbacks, 1)
+	assert.Equal(t, `{{field "body.id"}}`, f.Webhooks[0].Callbacks[0].Headers["X-Order"])
+	assert.False(t, *f.Webhooks[0].Callbacks[0].Enabled)
+
+	f, err = spec.Parse([]byte(`{"version": 1, "webhooks": [{"title": "json"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "json", f.Webhooks[0].Title)
+}
+
+func TestParseRejectsInvalidFiles(t *testing.T) {
+	cases := map[string]string{
+		"unknown field":      "version: 1\nwebhooks:\n  - title: a\n    status: 200\n",
+		"unknown json":       `{"version": 1, "webhooks": [{"title": "a", "status": 200}]}`,
+		"missing version":    "webhooks:\n  - title: a\n",
+		"duplicate id":       "version: 1\nwebhooks:\n  - id: a\n  - id: a\n",
+		"duplicate title":    "version: 1\nwebhooks:\n  - title: a\n  - title: a\n",
+		"no identity":        "version: 1\nwebhooks:\n  - response_code: 201\n",
+		"bad id":             "version: 1\nwebhooks:\n  - id: a/b\n",
+		"unknown fault":      "version: 1\nwebhooks:\n  - title: a\n    faults:\n      oops: 1\n",
+		"unnamed callback":   "version: 1\nwebhooks:\n  - title: a\n    callbacks:\n      - url: http://x\n",
+		"duplicate callback": "version: 1\nwebhooks:\n  - title: a\n    callbacks:\n      - name: x\n      - name: x\n",
+	}
+	for name, in := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := spec.Parse([]byte(in))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	f, err := spec.Parse([]byte(orders))
+	require.NoError(t, err)
+	for _, format := range []string{spec.FormatYAML, spec.FormatJSON} {
+		var buf bytes.Buffer
+		require.NoError(t, spec.Encode(&buf, f, format))
+		got, err := spec.Parse(buf.Bytes())
+		require.NoError(t, err, buf.String())
+		assert.Equal(t, f, got, format)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	current := []spec.Webhook{
+		{ID: "a1", Title: "orders", ResponseCode: 200},
+		{ID: "b2", Title: "payments", ResponseCode: 201},
+		{ID: "c3", Title: "stale", ResponseCode: 200},
+	}
+	desired := []spec.Webhook{
+		{Title: "orders"},
+		{ID: "b2", Title: "payments v2", ResponseCode: 201, RetentionCount: 10},
+		{Title: "new"},
+	}
+
+	plan, err := spec.Diff(current, desired, false)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 3)
+	assert.Equal(t, spec.Change{Action: spec.Unchanged, ID: "a1", Title: "orders", Webhook: spec.Webhook{ID: "a1", Title: "orders", ResponseCode: 200}}, plan.Changes[0])
+	assert.Equal(t, spec.Update, plan.Changes[1].Action)
+	assert.Equal(t, []string{"title", "retention_count"}, plan.Changes[1].Fields)
+	assert.Equal(t, spec.Create, plan.Changes[2].Action)
+	assert.True(t, plan.HasChanges())
+
+	plan, err = spec.Diff(current, desired, true)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 4)
+	assert.Equal(t, spec.Change{Action: spec.Delete, ID: "c3", Title: "stale"}, plan.Changes[3])
+	assert.Equal(t, 1, plan.Count(spec.Delete))
+}
+
+func TestDiffConfiguration(t *testing.T) {
+	enabled := true
+	current := []spec.Webhook{{
+		ID:            "a",
+		Title:         "orders",
+		SigningScheme: "github",
+		SigningSecret: "s3cret",
+		Faults:        spec.Faults{ErrorPercent: 10},
+		Callbacks: []spec.Callback{
+			{Name: "one", Enabled: &enabled, Method: "POST", URL: "http://x/1"},
+			{Name: "two", Enabled: &enabled, Method: "PUT", URL: "http://x/2"},
+		},
+	}}
+	same := current[0]
+	same.SigningSecret = "" // keeps the current secret
+	same.Callbacks = []spec.Callback{{Name: "two", Method: "put", URL: "http://x/2"}, {Name: "one", URL: "http://x/1"}}
+
+	cases := map[string]struct {
+		change func(w *spec.Webhook)
+		fields []string
+	}{
+		"defaults and order": {func(w *spec.Webhook) {}, nil},
+		"same secret":        {func(w *spec.Webhook) { w.SigningSecret = "s3cret" }, nil},
+		"new secret":         {func(w *spec.Webhook) { w.SigningSecret = "other" }, []string{"signing_secret"}},
+		"no signing":         {func(w *spec.Webhook) { w.SigningScheme = "" }, []string{"signing_scheme"}},
+		"faults":             {func(w *spec.Webhook) { w.Faults = spec.Faults{} }, []string{"faults"}},
+		"script":             {func(w *spec.Webhook) { w.Script = "def handle(req): pass" }, []string{"script"}},
+		"callback removed":   {func(w *spec.Webhook) { w.Callbacks = w.Callbacks[:1] }, []string{"callbacks"}},
+		"callback changed": {func(w *spec.Webhook) {
+			w.Callbacks = []spec.Callback{w.Callbacks[0], {Name: "one", URL: "http://x/one"}}
+		}, []string{"callbacks"}},
+		"callback disabled": {func(w *spec.Webhook) {
+			w.Callbacks = []spec.Callback{w.Callbacks[0], {Name: "one", Enabled: new(bool), URL: "http://x/1"}}
+		}, []string{"callbacks"}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := same
+			desired.Callbacks = append([]spec.Callback(nil), same.Callbacks...)
+			tc.change(&desired)
+			plan, err := spec.Diff(current, []spec.Webhook{desired}, false)
+			require.NoError(t, err)
+			assert.Equal(t, tc.fields, plan.Changes[0].Fields)
+		})
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	env := map[string]string{"ORDERS_SECRET": "s3cret"}
+	lookup := func(name string) (string, bool) { v, ok := env[name]; return v, ok }
+
+	f := &spec.File{Version: spec.Version, Webhooks: []spec.Webhook{
+		{Title: "a", SigningSecret: "${ORDERS_SECRET}"},
+		{Title: "b", SigningSecret: "literal"},
+		{Title: "c"},
+	}}
+	require.NoError(t, f.ResolveSecrets(lookup))
+	assert.Equal(t, "s3cret", f.Webhooks[0].SigningSecret)
+	assert.Equal(t, "literal", f.Webhooks[1].SigningSecret)
+	assert.Empty(t, f.Webhooks[2].SigningSecret)
+
+	f.Webhooks[2].SigningSecret = "${MISSING}"
+	assert.ErrorContains(t, f.ResolveSecrets(lookup), "webhooks[2].signing_secret: MISSING is not set")
+}
+
+func TestDiffAmbiguousTitle(t *testing.T) {
+	current := []spec.Webhook{{ID: "a", Title: "dup"}, {ID: "b", Title: "dup"}}
+	_, err := spec.Diff(current, []spec.Webhook{{Title: "dup"}}, false)
+	assert.ErrorContains(t, err, "matches 2 webhooks")
+
+	plan, err := spec.Diff(current, []spec.Webhook{{ID: "a", Title: "dup"}, {Title: "dup"}}, false)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+}
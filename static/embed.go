@@ -0,0 +1,8 @@
+package static
+
+import "embed"
+
+// FS holds the JS and icon assets served under /static/.
+//
+//go:embed icons js
+var FS embed.FS
@@ -0,0 +1,234 @@
+package webhooktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Expectation describes requests a Hook should receive. Build it with the
+// With* methods and finish with Times, Once, AtLeast or Never.
+type Expectation struct {
+	hook     *Hook
+	timeout  time.Duration
+	settle   time.Duration
+	matchers []matcher
+}
+
+type matcher struct {
+	desc  string
+	match func(Request) bool
+}
+
+// Expect starts an expectation for requests with the given method.
+func (h *Hook) Expect(method string) *Expectation {
+	e := h.ExpectAny()
+	method = strings.ToUpper(method)
+	return e.Matching(method, func(r Request) bool { return r.Method == method })
+}
+
+// ExpectAny starts an expectation that matches requests of any method.
+func (h *Hook) ExpectAny() *Expectation {
+	return &Expectation{hook: h, timeout: h.timeout, settle: h.settle}
+}
+
+// ExpectGET is Expect(http.MethodGet).
+func (h *Hook) ExpectGET() *Expectation { return h.Expect(http.MethodGet) }
+
+// ExpectPOST is Expect(http.MethodPost).
+func (h *Hook) ExpectPOST() *Expectation { return h.Expect(http.MethodPost) }
+
+// ExpectPUT is Expect(http.MethodPut).
+func (h *Hook) ExpectPUT() *Expectation { return h.Expect(http.MethodPut) }
+
+// ExpectPATCH is Expect(http.MethodPatch).
+func (h *Hook) ExpectPATCH() *Expectation { return h.Expect(http.MethodPatch) }
+
+// ExpectDELETE is Expect(http.MethodDelete).
+func (h *Hook) ExpectDELETE() *Expectation { return h.Expect(http.MethodDelete) }
+
+// WithHeader matches requests whose header key equals value.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	return e.Matching(fmt.Sprintf("header %s=%q", key, value), func(r Request) bool {
+		return r.Header.Get(key) == value
+	})
+}
+
+// WithQuery matches requests whose query parameter key equals value.
+func (e *Expectation) WithQuery(key, value string) *Expectation {
+	return e.Matching(fmt.Sprintf("query %s=%q", key, value), func(r Request) bool {
+		return r.Query.Get(key) == value
+	})
+}
+
+// WithBody matches requests whose body equals body exactly.
+func (e *Expectation) WithBody(body string) *Expectation {
+	return e.Matching(fmt.Sprintf("body %q", body), func(r Request) bool {
+		return string(r.Body) == body
+	})
+}
+
+// WithBodyContains matches requests whose body contains s.
+func (e *Expectation) WithBodyContains(s string) *Expectation {
+	return e.Matching(fmt.Sprintf("body containing %q", s), func(r Request) bool {
+		return bytes.Contains(r.Body, []byte(s))
+	})
+}
+
+// WithJSON matches requests whose body is JSON equal to v, ignoring formatting
+// and key order.
+func (e *Expectation) WithJSON(v any) *Expectation {
+	want, err := normalizeJSON(v)
+	if err != nil {
+		e.hook.t.Fatalf("webhooktest: WithJSON: %v", err)
+	}
+	return e.Matching("JSON body "+string(mustMarshal(want)), func(r Request) bool {
+		var got any
+		if err := json.Unmarshal(r.Body, &got); err != nil {
+			return false
+		}
+		return reflect.DeepEqual(got, want)
+	})
+}
+
+// Matching adds a custom predicate, described by desc in failure messages.
+func (e *Expectation) Matching(desc string, match func(Request) bool) *Expectation {
+	e.matchers = append(e.matchers, matcher{desc: desc, match: match})
+	return e
+}
+
+// Within overrides how long this expectation waits for matching requests.
+func (e *Expectation) Within(d time.Duration) *Expectation {
+	e.timeout = d
+	return e
+}
+
+// Settle overrides how long Times watches for extra requests once enough
+// have arrived. Zero makes it return at once.
+func (e *Expectation) Settle(d time.Duration) *Expectation {
+	e.settle = d
+	return e
+}
+
+// Times asserts that exactly n matching requests arrive and returns them.
+// Once n have arrived it keeps watching for the settle time (DefaultSettle
+// unless set with WithSettle or Settle), so that a late extra request fails
+// the expectation rather than slipping past it.
+func (e *Expectation) Times(n int) []Request {
+	e.hook.t.Helper()
+	got := e.wait(e.timeout, func(c int) bool { return c >= n })
+	if len(got) == n && e.settle > 0 {
+		got = e.wait(e.settle, func(c int) bool { return c > n })
+	}
+	if len(got) != n {
+		e.fail(fmt.Sprintf("exactly %d", n), got)
+	}
+	return got
+}
+
+// Once is Times(1).
+func (e *Expectation) Once() Request {
+	e.hook.t.Helper()
+	got := e.Times(1)
+	if len(got) == 0 {
+		return Request{}
+	}
+	return got[0]
+}
+
+// AtLeast asserts that n or more matching requests arrive and returns them.
+func (e *Expectation) AtLeast(n int) []Request {
+	e.hook.t.Helper()
+	got := e.wait(e.timeout, func(c int) bool { return c >= n })
+	if len(got) < n {
+		e.fail(fmt.Sprintf("at least %d", n), got)
+	}
+	return got
+}
+
+// Never asserts that no matching request has been received. It does not wait.
+func (e *Expectation) Never() {
+	e.hook.t.Helper()
+	if got := e.matches(); len(got) > 0 {
+		e.fail("no", got)
+	}
+}
+
+// wait polls until done reports true for the number of matches or d
+// passes, and returns the matches seen last.
+func (e *Expectation) wait(d time.Duration, done func(int) bool) []Request {
+	deadline := time.Now().Add(d)
+	for {
+		got := e.matches()
+		if done(len(got)) || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (e *Expectation) matches() []Request {
+	var got []Request
+	for _, r := range e.hook.Requests() {
+		if e.matchAll(r) {
+			got = append(got, r)
+		}
+	}
+	return got
+}
+
+func (e *Expectation) matchAll(r Request) bool {
+	for _, m := range e.matchers {
+		if !m.match(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) fail(want string, got []Request) {
+	e.hook.t.Helper()
+	e.hook.t.Errorf("webhooktest: expected %s %s, got %d (%d received in total)",
+		want, e.describe(), len(got), len(e.hook.Requests()))
+}
+
+func (e *Expectation) describe() string {
+	if len(e.matchers) == 0 {
+		return "requests"
+	}
+	descs := make([]string, len(e.matchers))
+	for i, m := range e.matchers {
+		descs[i] = m.desc
+	}
+	return "requests matching " + strings.Join(descs, ", ")
+}
+
+// normalizeJSON round-trips v so it compares equal to a decoded request body.
+func normalizeJSON(v any) (any, error) {
+	var raw []byte
+	switch b := v.(type) {
+	case string:
+		raw = []byte(b)
+	case []byte:
+		raw = b
+	default:
+		var err error
+		if raw, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func mustMarshal(v any) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
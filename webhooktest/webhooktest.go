@@ -0,0 +1,231 @@
+// Package webhooktest runs a webhook-tester endpoint inside Go tests.
+//
+//	hook := webhooktest.New(t, webhooktest.WithStatus(http.StatusAccepted))
+//	notifier.Send(hook.URL(), event)
+//	hook.ExpectPOST().WithHeader("Content-Type", "application/json").Times(1)
+//
+// Each Hook serves the real webhook handler from an httptest.Server backed by
+// an in-memory store, and is shut down automatically through t.Cleanup. It
+// records every request as it arrives, with all values of repeated headers
+// and query parameters.
+package webhooktest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+	"webhook-tester/internal/models"
+	"webhook-tester/internal/repository"
+	"webhook-tester/internal/routers"
+	"webhook-tester/internal/service"
+	"webhook-tester/internal/store"
+	"webhook-tester/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/sessions"
+	"gorm.io/datatypes"
+)
+
+// DefaultTimeout is how long expectations wait for matching requests.
+const DefaultTimeout = 2 * time.Second
+
+// DefaultSettle is how long Times keeps watching for extra requests once the
+// expected number has arrived.
+const DefaultSettle = 50 * time.Millisecond
+
+// Hook is a single webhook endpoint served for the duration of a test.
+type Hook struct {
+	t        testing.TB
+	srv      *httptest.Server
+	webhooks *service.WebhookService
+	requests repository.WebhookRequestRepository
+	webhook  *models.Webhook
+	timeout  time.Duration
+	settle   time.Duration
+
+	// The handler stores each header and query parameter as one
+	// comma-joined string, so requests are recorded as they arrive instead.
+	mu       sync.Mutex
+	received []Request
+}
+
+// Request is a request received by a Hook.
+type Request struct {
+	Method     string
+	Header     http.Header
+	Query      url.Values
+	Body       []byte
+	ReceivedAt time.Time
+}
+
+// Option configures how a Hook responds.
+type Option func(h *Hook)
+
+// WithStatus sets the response status code. The default is 200.
+func WithStatus(code int) Option {
+	return func(h *Hook) { h.webhook.ResponseCode = code }
+}
+
+// WithBody sets the response body.
+func WithBody(body string) Option {
+	return func(h *Hook) { h.webhook.Payload = &body }
+}
+
+// WithContentType sets the response Content-Type. The default is application/json.
+func WithContentType(ct string) Option {
+	return func(h *Hook) { h.webhook.ContentType = &ct }
+}
+
+// WithResponseHeader adds a header to every response.
+func WithResponseHeader(key, value string) Option {
+	return func(h *Hook) {
+		if h.webhook.ResponseHeaders == nil {
+			h.webhook.ResponseHeaders = datatypes.JSONMap{}
+		}
+		h.webhook.ResponseHeaders[key] = value
+	}
+}
+
+// WithDelay delays every response by d.
+func WithDelay(d time.Duration) Option {
+	return func(h *Hook) { h.webhook.ResponseDelay = uint(d.Milliseconds()) }
+}
+
+// WithScript answers requests with a Starlark script defining
+// handle(request), see the Scripts section of the README.
+func WithScript(src string) Option {
+	return func(h *Hook) { h.webhook.Script = src }
+}
+
+// WithTimeout sets how long expectations wait. The default is DefaultTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(h *Hook) { h.timeout = d }
+}
+
+// WithSettle sets how long Times watches for extra requests. The default is
+// DefaultSettle; zero makes Times return as soon as enough have arrived.
+func WithSettle(d time.Duration) Option {
+	return func(h *Hook) { h.settle = d }
+}
+
+// New starts a Hook and registers its shutdown with t.Cleanup.
+func New(t testing.TB, opts ...Option) *Hook {
+	t.Helper()
+
+	mem := store.NewMemoryDB()
+	webhookRepo := store.NewMemoryWebhookRepo(mem)
+	requestRepo := store.NewMemoryWebhookRequestRepo(mem)
+	userRepo := store.NewMemoryUserRepo(mem)
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatalf("webhooktest: generating session key: %v", err)
+	}
+	logger := log.New(io.Discard, "", 0)
+	webhookSvc := service.NewWebhookService(webhookRepo)
+	authSvc := service.NewAuthService(userRepo, sessions.NewCookieStore(secret))
+	retentionSvc := service.NewRetentionService(webhookRepo, requestRepo, userRepo, 0)
+	scriptSvc := service.NewScriptService(store.NewMemoryScriptStateRepo(mem))
+
+	h := &Hook{
+		t:        t,
+		webhooks: webhookSvc,
+		requests: requestRepo,
+		webhook: &models.Webhook{
+			ID:           utils.GenerateID(),
+			Title:        t.Name(),
+			ResponseCode: http.StatusOK,
+		},
+		timeout: DefaultTimeout,
+		settle:  DefaultSettle,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if err := webhookSvc.CreateWebhook(h.webhook); err != nil {
+		t.Fatalf("webhooktest: creating webhook: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/webhooks", routers.NewWebhookRouter(webhookSvc, authSvc, retentionSvc, nil, scriptSvc, logger, noopRecorder{}))
+	h.srv = httptest.NewServer(h.record(r))
+	t.Cleanup(h.srv.Close)
+	return h
+}
+
+// URL returns the address to send webhooks to.
+func (h *Hook) URL() string {
+	return h.srv.URL + "/webhooks/" + h.webhook.ID
+}
+
+// Configure changes how the Hook responds to subsequent requests.
+func (h *Hook) Configure(opts ...Option) {
+	h.t.Helper()
+	for _, opt := range opts {
+		opt(h)
+	}
+	if err := h.webhooks.UpdateWebhook(h.webhook); err != nil {
+		h.t.Fatalf("webhooktest: updating webhook: %v", err)
+	}
+}
+
+// Requests returns every request received so far, oldest first.
+func (h *Hook) Requests() []Request {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	list := make([]Request, len(h.received))
+	for i, r := range h.received {
+		r.Header, r.Query, r.Body = r.Header.Clone(), maps.Clone(r.Query), bytes.Clone(r.Body)
+		list[i] = r
+	}
+	return list
+}
+
+// Reset forgets every request received so far.
+func (h *Hook) Reset() {
+	h.t.Helper()
+	if err := h.requests.DeleteByWebhook(h.webhook.ID); err != nil {
+		h.t.Fatalf("webhooktest: deleting requests: %v", err)
+	}
+	h.mu.Lock()
+	h.received = nil
+	h.mu.Unlock()
+}
+
+// record keeps every request to the webhook before next handles it.
+func (h *Hook) record(next http.Handler) http.Handler {
+	path := "/webhooks/" + h.webhook.ID
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == path {
+			// a body that fails to read reaches the handler just as short
+			body, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			h.mu.Lock()
+			h.received = append(h.received, Request{
+				Method:     r.Method,
+				Header:     r.Header.Clone(),
+				Query:      r.URL.Query(),
+				Body:       body,
+				ReceivedAt: time.Now().UTC(),
+			})
+			h.mu.Unlock()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// noopRecorder discards metrics; tests have no Prometheus registry.
+type noopRecorder struct{}
+
+func (noopRecorder) IncWebhooksCreated()        {}
+func (noopRecorder) IncWebhookRequest(_ string) {}
+func (noopRecorder) IncSignUp()                 {}
+func (noopRecorder) IncLogin()                  {}
@@ -0,0 +1,182 @@
+package webhooktest_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"webhook-tester/webhooktest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func post(t *testing.T, url, body string, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestHookRespondsAsConfigured(t *testing.T) {
+	hook := webhooktest.New(t,
+		webhooktest.WithStatus(http.StatusAccepted),
+		webhooktest.WithBody(`{"ok":true}`),
+		webhooktest.WithResponseHeader("X-Hook", "1"),
+	)
+
+	resp := post(t, hook.URL(), "{}", nil)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, `{"ok":true}`, string(body))
+	assert.Equal(t, "1", resp.Header.Get("X-Hook"))
+
+	hook.Configure(webhooktest.WithStatus(http.StatusTeapot))
+	resp = post(t, hook.URL(), "{}", nil)
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+}
+
+func TestHookRunsScript(t *testing.T) {
+	hook := webhooktest.New(t, webhooktest.WithScript(`
+def handle(request):
+    state["n"] = state.get("n", 0) + 1
+    if state["n"] == 1:
+        return {"status": 503, "headers": {"Retry-After": "1"}}
+    return {"body": {"attempt": state["n"], "id": request.json["id"]}}
+`))
+
+	resp := post(t, hook.URL(), `{"id": 7}`, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	resp = post(t, hook.URL(), `{"id": 7}`, nil)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"attempt": 2, "id": 7}`, string(body))
+}
+
+func TestExpectations(t *testing.T) {
+	hook := webhooktest.New(t)
+
+	// deliver asynchronously, like a real notifier would
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		for i, body := range []string{`{"event": "paid", "id": 7}`, `{"id":7,"event":"paid"}`} {
+			req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("%s?attempt=%d", hook.URL(), i+1), strings.NewReader(body))
+			req.Header.Set("X-Signature", "abc")
+			if resp, err := http.DefaultClient.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	got := hook.ExpectPOST().WithHeader("X-Signature", "abc").Times(2)
+	require.Len(t, got, 2)
+	assert.Equal(t, "1", got[0].Query.Get("attempt"), "requests are returned oldest first")
+
+	hook.ExpectPOST().WithJSON(map[string]any{"event": "paid", "id": 7}).Times(2)
+	hook.ExpectAny().WithQuery("attempt", "2").WithBodyContains(`"event"`).Once()
+	hook.ExpectGET().Never()
+	hook.ExpectAny().AtLeast(1)
+
+	hook.Reset()
+	assert.Empty(t, hook.Requests())
+}
+
+func TestFailedExpectationReportsError(t *testing.T) {
+	rec := &recordingT{TB: t}
+	hook := webhooktest.New(rec)
+	post(t, hook.URL(), "{}", nil)
+
+	hook.ExpectPOST().Within(50 * time.Millisecond).Times(2)
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "expected exactly 2 requests matching POST, got 1")
+}
+
+func TestMultiValueHeaders(t *testing.T) {
+	hook := webhooktest.New(t)
+	req, err := http.NewRequest(http.MethodPost, hook.URL()+"?tag=a&tag=b", strings.NewReader("{}"))
+	require.NoError(t, err)
+	req.Header.Add("X-Trace", "one")
+	req.Header.Add("X-Trace", "two")
+	req.Header.Set("X-Agent", "app (linux, amd64)")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	got := hook.ExpectPOST().WithHeader("X-Trace", "one").Once()
+	assert.Equal(t, []string{"one", "two"}, got.Header.Values("X-Trace"))
+	assert.Equal(t, "app (linux, amd64)", got.Header.Get("X-Agent"), "commas inside a value are kept")
+	assert.Equal(t, []string{"a", "b"}, got.Query["tag"])
+	assert.Equal(t, got.Header, hook.Requests()[0].Header)
+}
+
+func TestRequestsKeepTheirOwnValues(t *testing.T) {
+	hook := webhooktest.New(t)
+	send := func(query string, values ...string) {
+		req, err := http.NewRequest(http.MethodPost, hook.URL()+query, strings.NewReader("{}"))
+		require.NoError(t, err)
+		for _, v := range values {
+			req.Header.Add("X-Trace", v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	// the same values, joined the way the handler stores them
+	send("?tag=a,b", "one,two")
+	send("?tag=a&tag=b", "one", "two")
+
+	got := hook.Requests()
+	require.Len(t, got, 2)
+	assert.Equal(t, []string{"one,two"}, got[0].Header.Values("X-Trace"))
+	assert.Equal(t, []string{"a,b"}, got[0].Query["tag"])
+	assert.Equal(t, []string{"one", "two"}, got[1].Header.Values("X-Trace"))
+	assert.Equal(t, []string{"a", "b"}, got[1].Query["tag"])
+
+	got[0].Header.Set("X-Trace", "changed")
+	assert.Equal(t, "one,two", hook.Requests()[0].Header.Get("X-Trace"), "callers get copies")
+}
+
+func TestTimesFailsOnLateExtraRequest(t *testing.T) {
+	rec := &recordingT{TB: t}
+	hook := webhooktest.New(rec)
+	post(t, hook.URL(), "{}", nil)
+	go func() {
+		if resp, err := http.Post(hook.URL(), "application/json", strings.NewReader("{}")); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	// the extra request fails the expectation as soon as it arrives
+	hook.ExpectPOST().Settle(webhooktest.DefaultTimeout).Times(1)
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "expected exactly 1 requests matching POST, got 2")
+}
+
+func TestTimesWithoutSettle(t *testing.T) {
+	hook := webhooktest.New(t, webhooktest.WithSettle(time.Hour))
+	post(t, hook.URL(), "{}", nil)
+
+	start := time.Now()
+	hook.ExpectPOST().Settle(0).Once()
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// recordingT captures Errorf calls so failures can be asserted on.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}